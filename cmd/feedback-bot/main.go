@@ -1,19 +1,72 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embedded tz database for per-user timezones (Windows hosts lack one)
 
+	"feedback_bot/internal/ai"
 	"feedback_bot/internal/config"
-	"feedback_bot/internal/telegram"
+	"feedback_bot/internal/scheduler"
+	"feedback_bot/internal/service"
 	"feedback_bot/internal/storage"
+	"feedback_bot/internal/telegram"
+	"feedback_bot/internal/wbapi"
 	"feedback_bot/pkg/logger"
 	"feedback_bot/pkg/metrics"
+
+	"go.uber.org/zap"
 )
 
+// botLimits collects the bot's limits from the configuration.
+func botLimits(cfg config.Config) telegram.Limits {
+	return telegram.Limits{
+		RequestsPerMinute:    cfg.TGRateLimit,
+		Burst:                cfg.TGRateBurst,
+		MaxConcurrentUpdates: cfg.MaxConcurrentUpdates,
+		WBRPS:                cfg.WBRPS,
+		WBBurst:              cfg.WBBurst,
+		WBGlobalRPS:          cfg.WBGlobalRPS,
+		WBGlobalBurst:        cfg.WBGlobalBurst,
+		WBMaxConns:           cfg.WBMaxConns,
+		MaxConcurrentCycles:  cfg.MaxConcurrentCycles,
+		CycleInterval:        cfg.PollInterval,
+	}
+}
+
+// botSubscription converts the channel subscription settings for the bot.
+func botSubscription(cfg config.Config) telegram.Subscription {
+	channels := make([]telegram.Channel, len(cfg.RequiredChannels))
+	for i, ch := range cfg.RequiredChannels {
+		channels[i] = telegram.Channel{ID: ch.ID, Username: ch.Username, Link: ch.Link}
+	}
+	return telegram.Subscription{Channels: channels, Exempt: cfg.SubscriptionExempt}
+}
+
+// reloadOnSignal reloads the configuration each time hup fires until ctx is
+// done. An invalid configuration is logged and the running settings stay.
+func reloadOnSignal(ctx context.Context, hup <-chan os.Signal, bot *telegram.Bot, log *zap.SugaredLogger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+		log.Info("SIGHUP received, reloading configuration")
+		cfg, err := config.Load()
+		if err != nil {
+			log.Errorw("configuration reload failed, keeping current settings", "err", err)
+			continue
+		}
+		bot.Reload(botLimits(cfg), botSubscription(cfg))
+	}
+}
+
 // maskDSN masks sensitive information in PostgreSQL DSN for logging
 func maskDSN(dsn string) string {
 	if strings.Contains(dsn, "password=") {
@@ -29,6 +82,41 @@ func maskDSN(dsn string) string {
 	return dsn
 }
 
+// exclusiveHold is how long a replica keeps the lock of a daily job after
+// running it, so that a replica whose timer fires a little later skips the
+// job instead of repeating it. Hourly jobs keep it for hourlyHold, well
+// before their next run.
+const (
+	exclusiveHold = time.Hour
+	hourlyHold    = 30 * time.Minute
+)
+
+// exclusive makes the daily job fn run on one replica only when locker is
+// set (CYCLE_LOCKS=true); without it fn is returned as is.
+func exclusive(locker storage.Locker, key int64, fn func(ctx context.Context), log *zap.SugaredLogger) func(ctx context.Context) {
+	return exclusiveFor(locker, key, exclusiveHold, fn, log)
+}
+
+// exclusiveFor is exclusive with the lock kept for hold after the run.
+func exclusiveFor(locker storage.Locker, key int64, hold time.Duration, fn func(ctx context.Context), log *zap.SugaredLogger) func(ctx context.Context) {
+	if locker == nil {
+		return fn
+	}
+	return func(ctx context.Context) {
+		release, ok, err := locker.TryLock(ctx, key)
+		if err != nil {
+			log.Warnw("daily job skipped: failed to take lock", "key", key, "err", err)
+			return
+		}
+		if !ok {
+			log.Infow("daily job skipped: run by another instance", "key", key)
+			return
+		}
+		fn(ctx)
+		time.AfterFunc(hold, release)
+	}
+}
+
 func main() {
 	// 1. Load configuration
 	cfg := config.MustLoad()
@@ -39,73 +127,191 @@ func main() {
 
 	log.Infow("starting feedback-bot", "version", cfg.Version)
 	
-	// Log admin user ID if configured
-	if cfg.AdminUserID != 0 {
-		log.Infow("admin user configured", "admin_user_id", cfg.AdminUserID)
+	// Log admin user IDs if configured
+	if len(cfg.AdminUserIDs) > 0 {
+		log.Infow("admin users configured", "admin_user_ids", cfg.AdminUserIDs)
 	} else {
-		log.Warnw("admin user not configured", "tip", "Set ADMIN_USER_ID environment variable to enable /admin command")
+		log.Warnw("admin user not configured", "tip", "Set ADMIN_USER_IDS environment variable to enable /admin command")
 	}
 	
 	// Log channel subscription check configuration
-	if cfg.RequiredChannelID != 0 || cfg.RequiredChannel != "" {
-		if cfg.RequiredChannelID != 0 {
-			log.Infow("channel subscription check enabled", "channel_id", cfg.RequiredChannelID, "channel", cfg.RequiredChannel)
-		} else {
-			log.Infow("channel subscription check enabled", "channel", cfg.RequiredChannel)
-		}
+	if len(cfg.RequiredChannels) > 0 {
+		log.Infow("channel subscription check enabled", "channels", cfg.RequiredChannels)
 	} else {
-		log.Warnw("channel subscription check disabled", "tip", "Set REQUIRED_CHANNEL_ID or REQUIRED_CHANNEL environment variable to enable subscription check")
+		log.Warnw("channel subscription check disabled", "tip", "Set REQUIRED_CHANNELS, REQUIRED_CHANNEL_ID or REQUIRED_CHANNEL environment variable to enable subscription check")
 	}
 
 	// 3. Root context with graceful shutdown on SIGINT/SIGTERM
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// 4. Expose Prometheus metrics endpoint (optional)
-	metricsSrv := metrics.MustServe(cfg.MetricsAddr, log)
-
-	// 5. Storage for processed feedback IDs and user configurations
+	// 4. Storage for processed feedback IDs and user configurations
 	// Supports both SQLite (default) and PostgreSQL
 	var store storage.Store
 	var configStore storage.ConfigStore
 	
 	var err error
+	var tokens *storage.TokenCipher
+	if cfg.EncryptionKey != "" {
+		if tokens, err = storage.NewTokenCipher(cfg.EncryptionKey); err != nil {
+			log.Fatalw("invalid ENCRYPTION_KEY", "err", err)
+		}
+	} else {
+		log.Warnw("WB tokens are stored unencrypted", "tip", "Set ENCRYPTION_KEY (openssl rand -base64 32) to encrypt them at rest")
+	}
 	if cfg.DBType == "postgres" {
 		log.Infow("initializing PostgreSQL storage", "dsn", maskDSN(cfg.DBPath))
-		store, configStore, err = storage.NewPostgreSQL(cfg.DBPath)
+		store, configStore, err = storage.NewPostgreSQL(cfg.DBPath, tokens)
 		if err != nil {
 			log.Fatalw("init PostgreSQL storage failed", "err", err)
 		}
 	} else {
 		log.Infow("initializing SQLite storage", "path", cfg.DBPath)
-		store, configStore, err = storage.NewSQLite(cfg.DBPath)
+		store, configStore, err = storage.NewSQLite(cfg.DBPath, tokens)
 		if err != nil {
 			log.Fatalw("init SQLite storage failed", "err", err)
 		}
 	}
 	defer store.Close()
 
+	// 5. Expose Prometheus metrics and the /healthz probe, which pings the database
+	metricsSrv := metrics.MustServe(cfg.MetricsAddr, log, store.Ping)
+
 	// 6. Initialize and start Telegram bot (required)
 	// Bot will handle service initialization after user provides configuration
-	tgBot, err := telegram.New(cfg.TelegramToken, configStore, store, log, ctx, cfg.RequiredChannel, cfg.RequiredChannelID, cfg.AdminUserID)
+	tgBot, err := telegram.New(cfg.TelegramToken, configStore, store, log, ctx, botSubscription(cfg), cfg.AdminUserIDs, cfg.StartupStagger, cfg.BlockSharedTokens, botLimits(cfg))
 	if err != nil {
 		log.Fatalw("failed to initialize telegram bot", "err", err)
 	}
 
+	if cfg.Billing {
+		tgBot.EnableBilling(service.Plan{
+			TrialDays:    cfg.TrialDays,
+			TrialAnswers: cfg.TrialAnswers,
+			PeriodDays:   cfg.SubscriptionDays,
+			Price:        int64(cfg.SubscriptionPrice) * 100,
+			ReferralDays: cfg.ReferralBonusDays,
+		}, cfg.PaymentProviderToken)
+		if cfg.PaymentProviderToken == "" {
+			log.Warnw("billing enabled without a payment provider", "tip", "Set PAYMENT_PROVIDER_TOKEN or grant access with /admin grant")
+		}
+	}
+
+	if cfg.AIAPIKey != "" {
+		tgBot.EnableDrafts(ai.New(cfg.AIAPIKey, ai.WithBaseURL(cfg.AIAPIURL), ai.WithModel(cfg.AIModel)))
+		log.Infow("AI reply drafts available", "model", cmp.Or(cfg.AIModel, ai.DefaultModel))
+	}
+
+	if cfg.WBProxy != "" {
+		proxyURL, err := wbapi.ParseProxy(cfg.WBProxy)
+		if err != nil {
+			log.Fatalw("invalid WB_PROXY", "err", err)
+		}
+		tgBot.EnableWBProxy(proxyURL)
+		log.Infow("WB requests go through a proxy", "proxy", proxyURL.Redacted())
+	}
+
+	// Replicas sharing the PostgreSQL database lock users' cycles and
+	// daily jobs so that nothing is processed twice
+	var locker storage.Locker
+	if cfg.CycleLocks {
+		l, ok := store.(storage.Locker)
+		if !ok {
+			log.Fatalw("CYCLE_LOCKS is not supported by the storage", "db_type", cfg.DBType)
+		}
+		locker = l
+		tgBot.EnableCycleLocks(locker)
+		log.Info("cycle locks enabled")
+	}
+
 	// 7. Start Telegram bot (main interface)
 	go tgBot.Run(ctx)
 	log.Info("telegram bot started - waiting for user configuration")
 
+	// Resume answering for users configured before the restart
+	go tgBot.RestoreServices(ctx)
+
+	// Periodic jobs below are skipped while the bot is in maintenance mode
+	// ("/admin maintenance on"), like users' cycles
+
+	// 7a. Nightly category benchmarks (03:00 Moscow time)
+	benchLoc, err := time.LoadLocation(service.DefaultTimezone)
+	if err != nil {
+		benchLoc = time.UTC
+	}
+	benchmarks := scheduler.NewDaily(3*time.Hour, benchLoc, exclusive(locker, storage.LockKeyBenchmarks, tgBot.MaintenanceGuard("benchmarks", func(ctx context.Context) {
+		service.RefreshBenchmarks(ctx, configStore, log)
+	}), log), log)
+	go benchmarks.Run(ctx)
+
+	// 7b. Monthly satisfaction poll, checked daily at 12:00 Moscow time
+	polls := scheduler.NewDaily(12*time.Hour, benchLoc, exclusive(locker, storage.LockKeyPolls, tgBot.MaintenanceGuard("polls", tgBot.SendSatisfactionPolls), log), log)
+	go polls.Run(ctx)
+
+	// 7c. Nightly archival of old answer history (04:00 Moscow time)
+	if cfg.ArchiveAfterMonths > 0 {
+		archive := scheduler.NewDaily(4*time.Hour, benchLoc, exclusive(locker, storage.LockKeyArchive, tgBot.MaintenanceGuard("archive", func(ctx context.Context) {
+			service.ArchiveHistory(ctx, store, cfg.ArchiveAfterMonths, log)
+		}), log), log)
+		go archive.Run(ctx)
+	}
+
+	// 7d. Nightly deletion of answer history past retention (04:30 Moscow time)
+	if cfg.ProcessedRetentionDays > 0 {
+		retention := time.Duration(cfg.ProcessedRetentionDays) * 24 * time.Hour
+		prune := scheduler.NewDaily(4*time.Hour+30*time.Minute, benchLoc, exclusive(locker, storage.LockKeyPrune, tgBot.MaintenanceGuard("prune", func(ctx context.Context) {
+			service.PruneProcessed(ctx, store, configStore, retention, log)
+		}), log), log)
+		go prune.Run(ctx)
+	}
+
+	// 7e. Weekly digest for users, checked hourly and sent on Mondays at
+	// 10:00 in each user's timezone
+	if cfg.WeeklyDigest {
+		digest := scheduler.NewHourly(0, exclusiveFor(locker, storage.LockKeyDigest, hourlyHold, tgBot.MaintenanceGuard("digest", tgBot.SendWeeklyDigests), log), log)
+		go digest.Run(ctx)
+	}
+	// Monthly report with complaint outcomes, sent on the 1st at the same hour
+	if cfg.MonthlyReport {
+		monthly := scheduler.NewHourly(0, exclusiveFor(locker, storage.LockKeyMonthlyReport, hourlyHold, tgBot.MaintenanceGuard("monthly_report", tgBot.SendMonthlyReports), log), log)
+		go monthly.Run(ctx)
+	}
+
+	// 7f. Reminders about expiring WB tokens, checked hourly; expired tokens
+	// stop the user's service
+	tokenExpiry := scheduler.NewHourly(15*time.Minute, exclusiveFor(locker, storage.LockKeyTokenExpiry, hourlyHold, tgBot.MaintenanceGuard("token_expiry", tgBot.CheckTokenExpiry), log), log)
+	go tokenExpiry.Run(ctx)
+
+	// 7g. Daily reports of negative reviews for users who turned them on,
+	// checked hourly and sent at 09:00 in each user's timezone
+	negativeReports := scheduler.NewHourly(30*time.Minute, exclusiveFor(locker, storage.LockKeyNegativeReport, hourlyHold, tgBot.MaintenanceGuard("negative_report", tgBot.SendNegativeReports), log), log)
+	go negativeReports.Run(ctx)
+
+	// 7h. Database watchdog: alerts the admins when pings keep failing
+	dbWatch := service.NewDBWatchdog(store.Ping, 0, tgBot.DatabaseChanged, log)
+	go dbWatch.Run(ctx, service.DefaultDBCheckInterval)
+
+	// 7i. Re-read the configuration on SIGHUP and apply the settings that
+	// can change at runtime
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go reloadOnSignal(ctx, hup, tgBot, log)
+
 	// 8. Wait for termination signal
 	<-ctx.Done()
 	log.Info("shutdown signal received, shutting down ...")
 
-	// 9. Graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// 9. Graceful shutdown: the grace period for running cycles plus time
+	// for the rest
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace+5*time.Second)
 	defer cancel()
 
-	// Shutdown bot (stops all schedulers)
-	tgBot.Shutdown()
+	// Shutdown bot (stops all schedulers, lets running cycles finish within
+	// the grace period) and report what was interrupted
+	report := tgBot.Shutdown(shutdownCtx, cfg.ShutdownGrace)
+	if cfg.ShutdownReport {
+		tgBot.SendShutdownReport(report)
+	}
 	
 	if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
 		log.Warnw("metrics server shutdown error", "err", err)
@@ -8,6 +8,7 @@ require (
 	github.com/prometheus/client_golang v1.23.0
 	go.uber.org/zap v1.27.0
 	golang.org/x/time v0.12.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.38.2
 )
 
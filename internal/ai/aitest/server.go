@@ -0,0 +1,100 @@
+// Package aitest provides a fake chat completions API for end-to-end checks
+// of AI reply drafts.
+package aitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"feedback_bot/internal/ai"
+)
+
+// Server is an in-memory fake of an OpenAI-compatible chat completions
+// endpoint served over httptest. It answers every request with the reply
+// set by SetReply, or fails while SetFailing is on, and records the
+// requests.
+//
+//	srv := aitest.NewServer("key")
+//	defer srv.Close()
+//	cli := ai.New("key", ai.WithBaseURL(srv.URL))
+type Server struct {
+	URL string
+
+	srv *httptest.Server
+	key string
+
+	mu       sync.Mutex
+	reply    string
+	failing  bool
+	requests []ai.ChatRequest
+}
+
+// NewServer starts a fake API that accepts only the given key. The caller
+// must Close it.
+func NewServer(key string) *Server {
+	s := &Server{key: key, reply: "Здравствуйте! Спасибо за отзыв, нам очень жаль."}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /chat/completions", s.complete)
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// SetReply sets the text of the following replies.
+func (s *Server) SetReply(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = text
+}
+
+// SetFailing makes the following requests fail with 503 until turned off.
+func (s *Server) SetFailing(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = on
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []ai.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ai.ChatRequest(nil), s.requests...)
+}
+
+func (s *Server) complete(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.key {
+		writeError(w, http.StatusUnauthorized, "invalid api key")
+		return
+	}
+	var req ai.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	reply, failing := s.reply, s.failing
+	s.mu.Unlock()
+	if failing {
+		writeError(w, http.StatusServiceUnavailable, "overloaded")
+		return
+	}
+
+	resp := ai.ChatResponse{Choices: []ai.Choice{{Message: ai.Message{Role: "assistant", Content: reply}}}}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"message": msg}})
+}
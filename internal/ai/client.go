@@ -0,0 +1,197 @@
+// Package ai drafts replies to reviews with a chat model behind an
+// OpenAI-compatible chat completions API (OpenAI, YandexGPT and GigaChat
+// gateways, a local vLLM or Ollama). Only the standard library is used.
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/wbapi"
+)
+
+// Defaults used when no option overrides them.
+const (
+	DefaultBaseURL     = "https://api.openai.com/v1"
+	DefaultModel       = "gpt-4o-mini"
+	DefaultHTTPTimeout = 30 * time.Second
+)
+
+// systemPrompt tells the model how a seller answers a negative review.
+const systemPrompt = `Ты помогаешь продавцу на Wildberries отвечать на отзывы покупателей.
+Напиши вежливый ответ на русском языке на отзыв с низкой оценкой: поблагодари за отзыв, признай конкретную проблему покупателя своими словами, извинись и коротко скажи, что продавец сделает.
+Не обещай возврат денег, компенсацию или замену, не упоминай другие магазины и площадки, не давай ссылок и контактов.
+Ответ — от 2 до 5 предложений, без обращения по имени, без Markdown. Сохрани тон и подпись примера ответа продавца.
+Выведи только текст ответа.`
+
+// Client drafts replies through the chat completions endpoint. It is safe
+// for concurrent use.
+//
+//	cli := ai.New(apiKey, ai.WithModel("gpt-4o-mini"))
+//	text, err := cli.Draft(ctx, fb, templateReply)
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+}
+
+// Option mutates the client during construction.
+type Option func(*Client)
+
+// WithBaseURL overrides DefaultBaseURL, e.g. with a gateway to another
+// provider; the URL ends before /chat/completions.
+func WithBaseURL(raw string) Option {
+	return func(c *Client) {
+		if raw != "" {
+			c.baseURL = strings.TrimSuffix(raw, "/")
+		}
+	}
+}
+
+// WithModel overrides DefaultModel.
+func WithModel(model string) Option {
+	return func(c *Client) {
+		if model != "" {
+			c.model = model
+		}
+	}
+}
+
+// WithHTTPClient makes the client send requests through hc, which should
+// have a timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// New constructs a Client authenticating with apiKey.
+func New(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: &http.Client{Timeout: DefaultHTTPTimeout},
+		baseURL:    DefaultBaseURL,
+		apiKey:     apiKey,
+		model:      DefaultModel,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Message is one message of a chat completions request.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the body of a chat completions request; only the fields
+// the client sends are mapped.
+type ChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+}
+
+// ChatResponse is the part of a chat completions response the client reads.
+type ChatResponse struct {
+	Choices []Choice `json:"choices"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Choice is one reply of the model.
+type Choice struct {
+	Message Message `json:"message"`
+}
+
+// ErrEmptyDraft is returned when the model answers with no text.
+var ErrEmptyDraft = errors.New("model returned an empty reply")
+
+// Draft asks the model for a reply to fb. example is the reply the seller's
+// templates would post; the draft follows its tone and signature.
+func (c *Client) Draft(ctx context.Context, fb wbapi.Feedback, example string) (string, error) {
+	req := ChatRequest{
+		Model: c.model,
+		Messages: []Message{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: reviewPrompt(fb, example)},
+		},
+		Temperature: 0.4,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var out ChatResponse
+	if err := json.Unmarshal(data, &out); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil && out.Error.Message != "" {
+			return "", fmt.Errorf("ai api: %s: %s", resp.Status, out.Error.Message)
+		}
+		return "", fmt.Errorf("ai api: %s", resp.Status)
+	}
+	if len(out.Choices) == 0 {
+		return "", ErrEmptyDraft
+	}
+	text := strings.TrimSpace(out.Choices[0].Message.Content)
+	if text == "" {
+		return "", ErrEmptyDraft
+	}
+	return text, nil
+}
+
+// reviewPrompt describes the review and the seller's usual reply.
+func reviewPrompt(fb wbapi.Feedback, example string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Оценка: %d из 5\n", fb.ProductValuation)
+	if name := fb.ProductDetails.ProductName; name != "" {
+		fmt.Fprintf(&sb, "Товар: %s\n", name)
+	} else if fb.SubjectName != "" {
+		fmt.Fprintf(&sb, "Категория: %s\n", fb.SubjectName)
+	}
+	for _, part := range []struct{ label, text string }{
+		{"Отзыв", fb.Text}, {"Достоинства", fb.Pros}, {"Недостатки", fb.Cons},
+	} {
+		if t := strings.TrimSpace(part.text); t != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", part.label, t)
+		}
+	}
+	if fb.Text == "" && fb.Pros == "" && fb.Cons == "" {
+		sb.WriteString("Отзыв без текста\n")
+	}
+	if example != "" {
+		fmt.Fprintf(&sb, "\nПример ответа продавца:\n%s\n", example)
+	}
+	return sb.String()
+}
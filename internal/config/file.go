@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileVars holds the variables read from CONFIG_FILE, by their environment
+// names. Variables set in the environment take precedence over it.
+type fileVars map[string]string
+
+// readConfigFile reads path as YAML if it ends in .yaml or .yml and as an
+// env file otherwise. An empty path returns an empty set. Keys are the
+// environment variable names in any case; a key Load does not know is an
+// error so that a typo does not silently fall back to the default.
+func readConfigFile(path string) (fileVars, error) {
+	if path == "" {
+		return fileVars{}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", envConfigFile, err)
+	}
+	defer f.Close()
+
+	var vars fileVars
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		vars, err = parseYAML(f)
+	default:
+		vars, err = parseEnvFile(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// parseEnvFile parses KEY=VALUE lines. Blank lines and lines starting with
+// "#" are skipped, an "export " prefix is allowed and values may be wrapped
+// in single or double quotes.
+func parseEnvFile(r io.Reader) (fileVars, error) {
+	vars := fileVars{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		if err := vars.set(key, unquote(strings.TrimSpace(value))); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return vars, sc.Err()
+}
+
+// parseYAML parses a YAML mapping of settings. Scalars are used as
+// written, sequences are joined with commas (for ADMIN_USER_IDS) and nested
+// mappings are an error.
+func parseYAML(r io.Reader) (fileVars, error) {
+	var doc map[string]any
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	vars := fileVars{}
+	for key, value := range doc {
+		s, err := yamlValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if err := vars.set(key, s); err != nil {
+			return nil, err
+		}
+	}
+	return vars, nil
+}
+
+// yamlValue returns the setting value of a decoded YAML value.
+func yamlValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case map[string]any:
+		return "", errors.New("nested settings are not supported")
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return "", errors.New("list items must be plain values")
+			}
+			if s, _ := yamlValue(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ","), nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// set stores value under the environment name of key.
+func (v fileVars) set(key, value string) error {
+	name := strings.ToUpper(strings.TrimSpace(key))
+	if !slices.Contains(knownVars, name) {
+		return fmt.Errorf("unknown setting %q", strings.TrimSpace(key))
+	}
+	v[name] = value
+	return nil
+}
+
+// unquote removes matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// get returns the environment variable key, or its value in the file when
+// it is unset.
+func (v fileVars) get(key string) string {
+	if s := os.Getenv(key); s != "" {
+		return s
+	}
+	return v[key]
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    fileVars
+		wantErr string
+	}{
+		{
+			name: "scalars and comments",
+			in:   "# settings\nlog_level: debug # inline\nWB_RPS: 3\nweekly_digest: true\n",
+			want: fileVars{"LOG_LEVEL": "debug", "WB_RPS": "3", "WEEKLY_DIGEST": "true"},
+		},
+		{
+			name: "hash inside values",
+			in:   "tpl_good: \"Спасибо! #1 в категории\"\ntpl_bad: Жаль#нет\n",
+			want: fileVars{"TPL_GOOD": "Спасибо! #1 в категории", "TPL_BAD": "Жаль#нет"},
+		},
+		{
+			name: "escapes and block scalar",
+			in:   "tpl_good: \"Спасибо!\\nЖдём снова\"\ntpl_bad: |\n  Жаль.\n  Напишите нам.\n",
+			want: fileVars{"TPL_GOOD": "Спасибо!\nЖдём снова", "TPL_BAD": "Жаль.\nНапишите нам.\n"},
+		},
+		{
+			name: "flow list",
+			in:   "admin_user_ids: [111, 222]\n",
+			want: fileVars{"ADMIN_USER_IDS": "111,222"},
+		},
+		{
+			name: "flow list with commas in quotes",
+			in:   "required_channels: [\"@a\", \"b,c\"]\n",
+			want: fileVars{"REQUIRED_CHANNELS": "@a,b,c"},
+		},
+		{
+			name: "block list",
+			in:   "admin_user_ids:\n  - 111\n  - 222\n",
+			want: fileVars{"ADMIN_USER_IDS": "111,222"},
+		},
+		{
+			name: "empty value",
+			in:   "wb_proxy:\n",
+			want: fileVars{"WB_PROXY": ""},
+		},
+		{
+			name: "empty file",
+			in:   "",
+			want: fileVars{},
+		},
+		{
+			name:    "nested mapping",
+			in:      "db_type:\n  name: postgres\n",
+			wantErr: "nested settings",
+		},
+		{
+			name:    "mapping in a list",
+			in:      "admin_user_ids:\n  - id: 1\n",
+			wantErr: "plain values",
+		},
+		{
+			name:    "unknown key",
+			in:      "log_levl: debug\n",
+			wantErr: "unknown setting",
+		},
+		{
+			name:    "tab indentation",
+			in:      "log_level: debug\n\tdb_type: sqlite\n",
+			wantErr: "yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(strings.NewReader(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseYAML = %v, %v; want an error with %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("parseYAML = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	in := "# comment\nexport LOG_LEVEL=debug\nTPL_GOOD=\"Спасибо!\"\n\nwb_rps='3'\n"
+	got, err := parseEnvFile(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("parseEnvFile: %v", err)
+	}
+	want := fileVars{"LOG_LEVEL": "debug", "TPL_GOOD": "Спасибо!", "WB_RPS": "3"}
+	if !maps.Equal(got, want) {
+		t.Errorf("parseEnvFile = %v, want %v", got, want)
+	}
+	if _, err := parseEnvFile(strings.NewReader("LOG_LEVEL\n")); err == nil {
+		t.Error("parseEnvFile accepted a line without =")
+	}
+}
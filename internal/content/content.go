@@ -0,0 +1,159 @@
+// Package content checks answer texts against the Wildberries rules for
+// seller answers. WB rejects answers outside MinLength..MaxLength characters
+// and rejects or hides answers with links, contact details and mentions of
+// messengers, social networks or other marketplaces, so the bot checks
+// templates when they are saved and every answer before posting.
+//
+//	if err := content.Validate(text); err != nil {
+//	    var cerr *content.Error
+//	    errors.As(err, &cerr) // cerr.Violations lists the offending fragments
+//	}
+package content
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lengths of an answer WB accepts, in characters.
+const (
+	MinLength = 2
+	MaxLength = 5000
+)
+
+// LengthError is returned by Validate for a text WB refuses for its length.
+type LengthError struct {
+	Length int // characters, after trimming spaces
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("answer is %d characters long, WB accepts %d to %d", e.Length, MinLength, MaxLength)
+}
+
+// Rules a fragment of an answer can break.
+const (
+	RuleLink    = "link"    // URL or bare domain, including t.me
+	RuleEmail   = "email"   // e-mail address
+	RulePhone   = "phone"   // Russian phone number
+	RuleHandle  = "handle"  // @username in a messenger or social network
+	RuleMention = "mention" // messenger, social network or other marketplace
+)
+
+// Violation is a fragment of an answer that breaks a rule.
+type Violation struct {
+	Rule     string
+	Fragment string // as written in the text
+}
+
+// Error is returned by Validate for a text that breaks the rules.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = fmt.Sprintf("%s %q", v.Rule, v.Fragment)
+	}
+	return "answer breaks WB content rules: " + strings.Join(parts, ", ")
+}
+
+// before and after bound words: regexp's \b only knows ASCII letters.
+const (
+	before = `(?:^|[^\p{L}\p{N}_])`
+	after  = `(?:[^\p{L}\p{N}_]|$)`
+)
+
+// Patterns in the order they are tried; a fragment matched by an earlier
+// one (an e-mail) is not reported again by a later one (its domain).
+var rules = []struct {
+	rule string
+	re   *regexp.Regexp
+	sub  int // submatch holding the fragment; 0 is the whole match
+}{
+	{RuleEmail, regexp.MustCompile(`[\p{L}\p{N}._%+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)+`), 0},
+	{RuleLink, regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s]+`), 0},
+	// The label before the zone needs a letter: "1234.ru" or "46.ru" ending
+	// a number is not taken for a site.
+	{RuleLink, regexp.MustCompile(`(?i)` + before + `((?:(?:[a-z0-9-]+\.)*[a-z0-9-]*[a-z][a-z0-9-]*\.(?:ru|su|com|net|org|info|biz|pro|shop|store|online|site|me|io|by|kz)|(?:[а-яё0-9-]+\.)*[а-яё0-9-]*[а-яё][а-яё0-9-]*\.рф)(?:/[^\s]*)?)` + after), 1},
+	// Mobile and city codes start with 3, 4, 8 or 9, so other 11-digit
+	// runs (batch and order numbers) are not phones.
+	{RulePhone, regexp.MustCompile(`(?:^|[^\d+])((?:\+7|8)[\s(-]*[3489]\d{2}[\s)-]*\d{3}[\s-]*\d{2}[\s-]*\d{2})(?:\D|$)`), 1},
+	{RuleHandle, regexp.MustCompile(before + `(@[A-Za-z][A-Za-z0-9_]{3,31})` + after), 1},
+	{RuleMention, regexp.MustCompile(`(?i)` + before + `(` + strings.Join([]string{
+		`whats\s?app`, `в[оа]т?сап\p{L}*`, `telegram`, `viber`, `вайбер\p{L}*`,
+		`instagram`, `инстаграм\p{L}*`, `вконтакт\p{L}*`, `ozon`,
+		`яндекс[\s.]?маркет\p{L}*`, `avito`, `авито`,
+		// the messenger, not "телеграмма", a telegram
+		`телегр[аa](?:м(?:а|у|ом|е)?|мм(?:ом|е)?)`,
+	}, "|") + `)` + after), 1},
+	// "vk" followed by a hyphen is a model code, e.g. "VK-100"
+	{RuleMention, regexp.MustCompile(`(?i)` + before + `(vk)(?:[^\p{L}\p{N}_-]|$)`), 1},
+	// The marketplace is written with a capital, unlike the gas: "запах озона"
+	{RuleMention, regexp.MustCompile(before + `((?:Озон|ОЗОН)(?:е|а|у|ом|Е|А|У|ОМ)?)` + after), 1},
+}
+
+// Check returns the fragments of text that break the rules, in the order
+// they appear. Each fragment is reported once.
+func Check(text string) []Violation {
+	type found struct {
+		Violation
+		start, end int
+	}
+	var all []found
+	overlaps := func(start, end int) bool {
+		for _, f := range all {
+			if start < f.end && f.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range rules {
+		// The next search starts right after the fragment rather than after
+		// the whole match, so that the separator the match consumed after one
+		// fragment can also precede the next one.
+		for pos := 0; pos < len(text); {
+			m := r.re.FindStringSubmatchIndex(text[pos:])
+			if m == nil || m[2*r.sub] < 0 {
+				break
+			}
+			start, end := pos+m[2*r.sub], pos+m[2*r.sub+1]
+			pos = end
+			if r.rule == RuleLink {
+				// Punctuation ending the sentence is not part of the link
+				end = start + len(strings.TrimRight(text[start:end], ".,;:!?)»\"'"))
+			}
+			if overlaps(start, end) {
+				continue
+			}
+			all = append(all, found{Violation{Rule: r.rule, Fragment: text[start:end]}, start, end})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].start < all[j].start })
+
+	var out []Violation
+	seen := make(map[Violation]bool)
+	for _, f := range all {
+		if !seen[f.Violation] {
+			seen[f.Violation] = true
+			out = append(out, f.Violation)
+		}
+	}
+	return out
+}
+
+// Validate returns a *LengthError if WB refuses text for its length, an
+// *Error listing the fragments of text that break the rules, or nil.
+func Validate(text string) error {
+	if n := utf8.RuneCountInString(strings.TrimSpace(text)); n < MinLength || n > MaxLength {
+		return &LengthError{Length: n}
+	}
+	if v := Check(text); len(v) > 0 {
+		return &Error{Violations: v}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package content
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Violation // nil: the text is fine
+	}{
+		// Ordinary answers
+		{"thanks", "Спасибо за отзыв! Ждём вас снова.", nil},
+		{"wildberries", "Спасибо за покупку на Wildberries", nil},
+		{"marketplace word", "Лучший маркетплейс для покупок", nil},
+
+		// Marketplace and messenger names inside ordinary words
+		{"ozone generator", "Озонатор работает отлично", nil},
+		{"ozone gas", "Появился запах озона, это нормально", nil},
+		{"ozone layer", "озоновый слой", nil},
+		{"avitaminosis", "Авитаминоз не наш случай", nil},
+		{"a telegram", "Телеграмма пришла вовремя", nil},
+		{"telegrams", "Отправили две телеграммы", nil},
+		{"vk in a word", "Это не vkусно", nil},
+		{"vk model code", "Модель VK-100 в наличии", nil},
+		{"ozerki", "Забирайте в ТЦ Озерки", nil},
+
+		// Tokens that look like zones but are not sites
+		{"bare zone", "info com pro ru", nil},
+		{"number with a zone", "Заказ 1234.ru отправлен", nil},
+		{"sentence without a space", "Отличный товар.Ру", nil},
+		{"decimal", "Кофе на 1.5 литра, версия 2.0", nil},
+		{"abbreviations", "т.е. размер S.M, и т.д.", nil},
+		{"date", "Доставка 10.05.2024", nil},
+
+		// Digit runs that are not phones
+		{"hours", "Работаем с 8 до 20, 7 дней в неделю", nil},
+		{"sizes", "Размеры 8-10 лет, рост 110-116 см", nil},
+		{"price", "Цена 8 990 ₽", nil},
+		{"batch number", "Партия 8 123 456 78 90 пришла", nil},
+		{"long number", "Номер 812345678901", nil},
+		{"barcode", "Штрихкод 4607123456789", nil},
+		{"taxpayer number", "ИНН 7712345678", nil},
+		{"time range", "Время 8:00-12:30", nil},
+
+		// Violations
+		{"link", "Подробнее на https://example.com/a.", []Violation{{RuleLink, "https://example.com/a"}}},
+		{"www", "Заходите на www.shop.ru", []Violation{{RuleLink, "www.shop.ru"}}},
+		{"bare domain", "Наш сайт shop.ru, заходите", []Violation{{RuleLink, "shop.ru"}}},
+		{"cyrillic domain", "Пишите на почта.рф", []Violation{{RuleLink, "почта.рф"}}},
+		{"email", "Почта shop@mail.ru", []Violation{{RuleEmail, "shop@mail.ru"}}},
+		{"mobile", "Звоните +7 (999) 123-45-67", []Violation{{RulePhone, "+7 (999) 123-45-67"}}},
+		{"mobile digits only", "Звоните 89991234567", []Violation{{RulePhone, "89991234567"}}},
+		{"toll free", "Горячая линия 8-800-555-35-35", []Violation{{RulePhone, "8-800-555-35-35"}}},
+		{"handle", "Пишите в директ @shop_help", []Violation{{RuleHandle, "@shop_help"}}},
+		{"messenger", "Напишите в WhatsApp или в вотсап", []Violation{{RuleMention, "WhatsApp"}, {RuleMention, "вотсап"}}},
+		{"telegram messenger", "Мы есть в телеграме", []Violation{{RuleMention, "телеграме"}}},
+		{"vk", "Звоните, в VK не пишите", []Violation{{RuleMention, "VK"}}},
+		{"ozon", "На Озоне дешевле, чем на OZON", []Violation{{RuleMention, "Озоне"}, {RuleMention, "OZON"}}},
+		{"marketplace", "Есть на Яндекс Маркете и Авито", []Violation{{RuleMention, "Яндекс Маркете"}, {RuleMention, "Авито"}}},
+		{"reported once", "vk, vk и ещё раз vk", []Violation{{RuleMention, "vk"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Check(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		length int  // length reported by a *LengthError; -1 for none
+		rules  bool // an *Error is expected
+	}{
+		{"fine", "Спасибо за отзыв!", -1, false},
+		{"too short", "  ы ", 1, false},
+		{"empty", "", 0, false},
+		{"longest", strings.Repeat("я", MaxLength), -1, false},
+		{"too long", strings.Repeat("я", MaxLength+1), MaxLength + 1, false},
+		{"link", "Заходите на shop.ru", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.text)
+			var lerr *LengthError
+			var cerr *Error
+			switch {
+			case tt.length >= 0:
+				if !errors.As(err, &lerr) || lerr.Length != tt.length {
+					t.Errorf("Validate = %v, want a *LengthError for %d characters", err, tt.length)
+				}
+			case tt.rules:
+				if !errors.As(err, &cerr) || len(cerr.Violations) == 0 {
+					t.Errorf("Validate = %v, want an *Error with the violations", err)
+				}
+			case err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Package export renders stored data into files users can download.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"feedback_bot/internal/storage"
+)
+
+// utf8BOM makes Excel detect the encoding of the Cyrillic text.
+const utf8BOM = "\uFEFF"
+
+// answerHeader names the columns written by AnswersCSV.
+var answerHeader = []string{
+	"ID", "Тип", "Дата ответа", "Оценка", "Категория", "Артикул WB", "Артикул продавца", "Товар", "Шаблон", "Версия шаблона", "Время до ответа, мин", "Текст ответа",
+}
+
+// sourceNames are plain-text labels for storage sources.
+var sourceNames = map[string]string{
+	"good":      "положительный",
+	"bad":       "отрицательный",
+	"off_hours": "вне рабочих часов",
+	"sentiment": "жалоба в тексте",
+	"media":     "благодарность за фото",
+	"question":  "вопрос",
+}
+
+// AnswersCSV writes answers as CSV with ";" as the separator, which is what
+// Excel expects in the Russian locale. Dates are rendered in loc.
+func AnswersCSV(w io.Writer, answers []storage.AnswerRecord, loc *time.Location) error {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	cw.UseCRLF = true
+	if err := cw.Write(answerHeader); err != nil {
+		return err
+	}
+
+	for _, a := range answers {
+		kind := "отзыв"
+		if a.Kind == storage.KindQuestion {
+			kind = "вопрос"
+		}
+		rating := ""
+		if a.Rating > 0 {
+			rating = strconv.Itoa(a.Rating)
+		}
+		responseMinutes := ""
+		if a.ResponseTime > 0 {
+			responseMinutes = strconv.FormatInt(int64(a.ResponseTime/time.Minute), 10)
+		}
+		nmID := ""
+		if a.NmID != 0 {
+			nmID = strconv.FormatInt(a.NmID, 10)
+		}
+		source := sourceNames[a.Source]
+		if source == "" {
+			source = a.Source
+		}
+		record := []string{
+			a.FeedbackID,
+			kind,
+			a.AnsweredAt.In(loc).Format("2006-01-02 15:04:05"),
+			rating,
+			a.SubjectName,
+			nmID,
+			a.SupplierArticle,
+			a.ProductName,
+			source,
+			a.TemplateVersion,
+			responseMinutes,
+			a.ReplyText,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
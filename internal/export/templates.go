@@ -0,0 +1,88 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"feedback_bot/internal/storage"
+)
+
+// templatesVersion is written to template files so that a future layout can
+// still read old files.
+const templatesVersion = 1
+
+// templatesFile is the JSON layout of a template file. The field names are
+// part of the format: users edit these files by hand.
+type templatesFile struct {
+	Version      int      `json:"version"`
+	Good         string   `json:"good"`
+	GoodVariants []string `json:"good_variants,omitempty"`
+	Bad          string   `json:"bad"`
+	BadVariants  []string `json:"bad_variants,omitempty"`
+	Question     string   `json:"question,omitempty"`
+	Media        string   `json:"media,omitempty"`
+	OffHours     string   `json:"off_hours,omitempty"`
+	Signatures   []string `json:"signatures,omitempty"`
+}
+
+// TemplatesJSON writes set as an indented JSON template file.
+func TemplatesJSON(w io.Writer, set storage.TemplateSet) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(templatesFile{
+		Version:      templatesVersion,
+		Good:         set.Good,
+		GoodVariants: set.GoodVariants,
+		Bad:          set.Bad,
+		BadVariants:  set.BadVariants,
+		Question:     set.Question,
+		Media:        set.Media,
+		OffHours:     set.OffHours,
+		Signatures:   set.Signatures,
+	})
+}
+
+// ParseTemplatesJSON reads a template file written by TemplatesJSON,
+// possibly edited by hand. Unknown fields are rejected so that typos in
+// field names do not silently drop a template. Texts are trimmed and the
+// main good and bad templates are required; lengths are left to the caller.
+func ParseTemplatesJSON(r io.Reader) (storage.TemplateSet, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var f templatesFile
+	if err := dec.Decode(&f); err != nil {
+		return storage.TemplateSet{}, fmt.Errorf("invalid template file: %w", err)
+	}
+	if f.Version != templatesVersion {
+		return storage.TemplateSet{}, fmt.Errorf("unsupported template file version %d", f.Version)
+	}
+	set := storage.TemplateSet{
+		Good:         strings.TrimSpace(f.Good),
+		Bad:          strings.TrimSpace(f.Bad),
+		GoodVariants: trimTexts(f.GoodVariants),
+		BadVariants:  trimTexts(f.BadVariants),
+		Question:     strings.TrimSpace(f.Question),
+		Media:        strings.TrimSpace(f.Media),
+		OffHours:     strings.TrimSpace(f.OffHours),
+		Signatures:   trimTexts(f.Signatures),
+	}
+	if set.Good == "" || set.Bad == "" {
+		return storage.TemplateSet{}, errors.New(`template file must contain "good" and "bad"`)
+	}
+	return set, nil
+}
+
+// trimTexts trims texts and drops the empty ones.
+func trimTexts(texts []string) []string {
+	var out []string
+	for _, t := range texts {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
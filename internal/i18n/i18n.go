@@ -0,0 +1,83 @@
+// Package i18n holds the message catalogs for user-facing bot texts.
+//
+// Every message has a Key and a Russian text; other languages may omit a
+// key, in which case the Russian text is used. Texts are Markdown (v1)
+// format strings: arguments are applied with fmt.Sprintf when given.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"feedback_bot/internal/locale"
+)
+
+// Key identifies a message in the catalogs.
+type Key string
+
+// Language describes a supported language for the language picker.
+type Language struct {
+	Code string // locale.LangRU, locale.LangEN
+	Name string // name in the language itself, with a flag
+}
+
+// Languages lists the supported languages in picker order.
+var Languages = []Language{
+	{Code: locale.LangRU, Name: "🇷🇺 Русский"},
+	{Code: locale.LangEN, Name: "🇬🇧 English"},
+}
+
+var catalogs = map[string]map[Key]string{
+	locale.LangRU: ru,
+	locale.LangEN: en,
+}
+
+// Supported reports whether lang has a catalog.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Normalize maps lang to a supported language code, falling back to
+// Russian. Telegram language codes such as "en-US" are accepted.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		lang = base
+	}
+	if Supported(lang) {
+		return lang
+	}
+	return locale.LangRU
+}
+
+// T returns the text for key in lang, formatted with args if any.
+func T(lang string, key Key, args ...any) string {
+	text, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		if text, ok = ru[key]; !ok {
+			return string(key)
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Plural returns the form of the noun under key that goes with n. The text
+// lists the forms separated by "|": one, few and many in Russian (see
+// locale.Plural), one and other in English.
+func Plural(lang string, key Key, n int64) string {
+	forms := strings.Split(T(lang, key), "|")
+	switch len(forms) {
+	case 3:
+		return locale.Plural(n, forms[0], forms[1], forms[2])
+	case 2:
+		if n == 1 || n == -1 {
+			return forms[0]
+		}
+		return forms[1]
+	}
+	return forms[0]
+}
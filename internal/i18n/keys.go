@@ -0,0 +1,677 @@
+package i18n
+
+// Main menu and common keyboard buttons.
+const (
+	BtnInfo          Key = "btn.info"
+	BtnWhatsNew      Key = "btn.whats_new" // %d unread entries
+	BtnLanguage      Key = "btn.language"
+	BtnTimezone      Key = "btn.timezone"
+	BtnAddToken      Key = "btn.add_token"
+	BtnAddGood       Key = "btn.add_good"
+	BtnAddBad        Key = "btn.add_bad"
+	BtnEditGood      Key = "btn.edit_good"
+	BtnEditBad       Key = "btn.edit_bad"
+	BtnBusinessHours Key = "btn.business_hours"
+	BtnOffHours      Key = "btn.off_hours"
+	BtnQuestions     Key = "btn.questions"
+	BtnBenchmarks    Key = "btn.benchmarks"
+	BtnSimulate      Key = "btn.simulate"
+	BtnPreview       Key = "btn.preview"
+	BtnHistory       Key = "btn.history"
+	BtnUnread        Key = "btn.unread"
+	BtnSubscription  Key = "btn.subscription"
+	BtnInvite        Key = "btn.invite"
+	BtnDuplicates    Key = "btn.duplicates"
+	BtnExclusions    Key = "btn.exclusions"
+	BtnDailyLimit    Key = "btn.daily_limit"
+	BtnAnswerDelay   Key = "btn.answer_delay"
+	BtnAdvanced      Key = "btn.advanced"
+	BtnHumanize      Key = "btn.humanize"
+	BtnSentiment     Key = "btn.sentiment"
+	BtnVariants      Key = "btn.variants"
+	BtnMedia         Key = "btn.media"
+	BtnTemplateFile  Key = "btn.template_file"
+	BtnTracking      Key = "btn.tracking"
+	BtnDrafts        Key = "btn.drafts"
+	BtnDailyReport   Key = "btn.daily_report"
+	BtnEscalation    Key = "btn.escalation"
+	BtnFailures      Key = "btn.failures"
+	BtnProblems      Key = "btn.problems" // %d stuck answers
+	BtnRestart       Key = "btn.restart"
+	BtnResume        Key = "btn.resume"
+	BtnRun           Key = "btn.run"
+	BtnPause         Key = "btn.pause"
+	BtnDeleteAll     Key = "btn.delete_all"
+	BtnCancel        Key = "btn.cancel"
+	BtnConfirmDelete Key = "btn.confirm_delete"
+)
+
+// Welcome screen and setup status.
+const (
+	MsgWelcome      Key = "msg.welcome"
+	MsgStatusHeader Key = "msg.status_header"
+	MsgStepsNoToken Key = "msg.steps_no_token"
+	MsgStepsNoTpl   Key = "msg.steps_no_templates"
+	MsgStepsDone    Key = "msg.steps_done"
+)
+
+// Common replies.
+const (
+	MsgRateLimited Key = "msg.rate_limited"
+	MsgBanned      Key = "msg.banned"
+	MsgMaintenance Key = "msg.maintenance"
+	MsgCancelled   Key = "msg.cancelled"
+	MsgSaveFailed  Key = "msg.save_failed"
+	MsgTokenFirst  Key = "msg.token_first"
+)
+
+// Token and template setup.
+const (
+	MsgTokenAlreadySet  Key = "msg.token_already_set" // %s masked token
+	MsgTokenPrompt      Key = "msg.token_prompt"
+	MsgTokenEmpty       Key = "msg.token_empty"
+	MsgTokenTooShort    Key = "msg.token_too_short" // %d minimum length
+	MsgTokenTooLong     Key = "msg.token_too_long"  // %d maximum length
+	MsgTokenBadChars    Key = "msg.token_bad_chars"
+	MsgTokenShared      Key = "msg.token_shared"
+	MsgTokenSavedReady  Key = "msg.token_saved_ready"
+	MsgTokenSavedNext   Key = "msg.token_saved_next"
+	MsgGoodPrompt       Key = "msg.good_prompt"
+	MsgBadPrompt        Key = "msg.bad_prompt"
+	MsgTemplateEmpty    Key = "msg.template_empty"
+	MsgTemplateTooShort Key = "msg.template_too_short"
+	MsgTemplateTooLong  Key = "msg.template_too_long" // %d maximum length
+	MsgTemplateBadChars Key = "msg.template_bad_chars"
+	MsgGoodSavedReady   Key = "msg.good_saved_ready"
+	MsgGoodSavedNext    Key = "msg.good_saved_next"
+	MsgBadSavedReady    Key = "msg.bad_saved_ready"
+	MsgBadSavedNext     Key = "msg.bad_saved_next"
+)
+
+// Onboarding wizard.
+const (
+	MsgWizardIntro       Key = "msg.wizard_intro"
+	MsgWizardHeader      Key = "msg.wizard_header" // %d step, %d step count
+	MsgWizardToken       Key = "msg.wizard_token"
+	MsgWizardGood        Key = "msg.wizard_good"
+	MsgWizardBad         Key = "msg.wizard_bad"
+	MsgWizardTest        Key = "msg.wizard_test"
+	MsgWizardTokenSet    Key = "msg.wizard_token_set"    // %s masked token
+	MsgWizardTemplateSet Key = "msg.wizard_template_set" // %d length in characters
+	MsgWizardNoReviews   Key = "msg.wizard_no_reviews"
+	MsgWizardTestFailed  Key = "msg.wizard_test_failed" // %s reason
+	MsgWizardTestDone    Key = "msg.wizard_test_done"
+	BtnWizardBack        Key = "btn.wizard_back"
+	BtnWizardKeep        Key = "btn.wizard_keep"
+	BtnWizardRetest      Key = "btn.wizard_retest"
+)
+
+// Deleting all data.
+const (
+	MsgDeleteConfirm Key = "msg.delete_confirm"
+	MsgDeleteFailed  Key = "msg.delete_failed"
+	MsgDeleted       Key = "msg.deleted"
+	MsgDeletedShort  Key = "msg.deleted_short"
+)
+
+// Language selection.
+const (
+	MsgLanguagePrompt Key = "msg.language_prompt"
+	MsgLanguageSet    Key = "msg.language_set"
+)
+
+// Shared buttons and replies of the settings screens.
+const (
+	BtnMainMenu          Key = "btn.main_menu"
+	BtnTurnOn            Key = "btn.turn_on"
+	BtnTurnOff           Key = "btn.turn_off"
+	MsgTokenFirstSetting Key = "msg.token_first_setting"
+	MsgOn                Key = "msg.on"
+	MsgOff               Key = "msg.off"
+	MsgMoreItems         Key = "msg.more_items" // %d items left out
+)
+
+// Notifications about reviews and their summaries.
+const (
+	MsgReviewArticle           Key = "msg.review_article"   // %d WB article
+	MsgEscalationTitle         Key = "msg.escalation_title" // %d reviews
+	MsgEscalationReview        Key = "msg.escalation_review"
+	MsgEscalationSellerArticle Key = "msg.escalation_seller_article"
+	MsgEscalationReply         Key = "msg.escalation_reply"
+	BtnOpenProduct             Key = "btn.open_product"
+	MsgReviewChangeTitle       Key = "msg.review_change_title" // %d reviews
+	MsgReviewWorse             Key = "msg.review_worse"
+	MsgReviewBetter            Key = "msg.review_better"
+	MsgReviewRatingChange      Key = "msg.review_rating_change" // old and new rating
+	MsgReviewTextChanged       Key = "msg.review_text_changed"
+	MsgReviewAnswerOutdated    Key = "msg.review_answer_outdated"
+	BtnEditAnswer              Key = "btn.edit_answer"
+)
+
+// Forwarding negative reviews.
+const (
+	MsgEscalationOff         Key = "msg.escalation_off"
+	MsgEscalationChat        Key = "msg.escalation_chat"   // %d chat ID
+	MsgEscalationPrompt      Key = "msg.escalation_prompt" // status and the highest forwarded rating
+	MsgEscalationBadID       Key = "msg.escalation_bad_id"
+	MsgEscalationHello       Key = "msg.escalation_hello"
+	MsgEscalationUnreachable Key = "msg.escalation_unreachable"
+	MsgEscalationDisabled    Key = "msg.escalation_disabled"
+	MsgEscalationEnabled     Key = "msg.escalation_enabled" // rating and chat ID
+)
+
+// Tracking review changes.
+const (
+	MsgTrackingPrompt   Key = "msg.tracking_prompt" // status and check interval
+	MsgTrackingDisabled Key = "msg.tracking_disabled"
+	MsgTrackingEnabled  Key = "msg.tracking_enabled"
+)
+
+// Weekly digest and monthly report.
+const (
+	MsgDigestTitle       Key = "msg.digest_title"  // first and last day
+	MsgMonthlyTitle      Key = "msg.monthly_title" // first and last day
+	MsgSummaryReviews    Key = "msg.summary_reviews"
+	MsgSummaryAvgRating  Key = "msg.summary_avg_rating"
+	MsgSummaryNegative   Key = "msg.summary_negative"
+	MsgSummaryQuestions  Key = "msg.summary_questions"
+	MsgDigestStuck       Key = "msg.digest_stuck"
+	MsgMonthlyComplaints Key = "msg.monthly_complaints"
+	MsgMonthlyAccepted   Key = "msg.monthly_accepted"
+	MsgMonthlyRejected   Key = "msg.monthly_rejected"
+	MsgMonthlyPending    Key = "msg.monthly_pending"
+)
+
+// Wildberries errors and content rules.
+const (
+	MsgWBUnauthorized  Key = "msg.wb_unauthorized"
+	MsgWBForbidden     Key = "msg.wb_forbidden"
+	MsgWBRateLimited   Key = "msg.wb_rate_limited"
+	MsgWBServer        Key = "msg.wb_server"
+	MsgWBBadRequest    Key = "msg.wb_bad_request"
+	MsgWBUnreachable   Key = "msg.wb_unreachable"
+	MsgContentRejected Key = "msg.content_rejected" // list of fragments
+	MsgRuleLink        Key = "msg.rule_link"
+	MsgRuleEmail       Key = "msg.rule_email"
+	MsgRulePhone       Key = "msg.rule_phone"
+	MsgRuleHandle      Key = "msg.rule_handle"
+	MsgRuleMention     Key = "msg.rule_mention"
+)
+
+// Browsing unanswered reviews.
+const (
+	MsgBrowseSetupFirst      Key = "msg.browse_setup_first"
+	MsgBrowseLoadFailed      Key = "msg.browse_load_failed"
+	MsgBrowseEmpty           Key = "msg.browse_empty"
+	MsgDailyLimitReached     Key = "msg.daily_limit_reached"
+	MsgBrowseAlreadyAnswered Key = "msg.browse_already_answered"
+	MsgAnswerNotSent         Key = "msg.answer_not_sent"
+	MsgBrowseNoMore          Key = "msg.browse_no_more"
+	MsgAnswerSent            Key = "msg.answer_sent"
+	MsgBrowseAnswer          Key = "msg.browse_answer"
+	BtnNextReview            Key = "btn.next_review"
+	BtnReload                Key = "btn.reload"
+	MsgBrowseExpired         Key = "msg.browse_expired"
+	MsgBrowsePage            Key = "msg.browse_page" // position and list length
+	MsgBrowseTotal           Key = "msg.browse_total"
+	BtnPrev                  Key = "btn.prev"
+	BtnNext                  Key = "btn.next"
+	BtnAnswerNow             Key = "btn.answer_now"
+	BtnOwnAnswer             Key = "btn.own_answer"
+	BtnComplain              Key = "btn.complain"
+	MsgReviewPhotos          Key = "msg.review_photos"
+	MsgReviewVideo           Key = "msg.review_video"
+	MsgReviewComplained      Key = "msg.review_complained"
+	MsgReviewNoText          Key = "msg.review_no_text"
+)
+
+// Answer preview.
+const (
+	MsgPreviewSetupFirst   Key = "msg.preview_setup_first"
+	MsgPreviewNoReviews    Key = "msg.preview_no_reviews"
+	MsgPreviewLoadFailed   Key = "msg.preview_load_failed"
+	MsgPreviewTitle        Key = "msg.preview_title"
+	MsgPreviewAnsweredOnly Key = "msg.preview_answered_only"
+	MsgPreviewLatest       Key = "msg.preview_latest"
+	MsgPreviewReply        Key = "msg.preview_reply"
+	MsgPreviewWhy          Key = "msg.preview_why"
+	MsgPreviewRejected     Key = "msg.preview_rejected"
+	MsgPreviewTooLong      Key = "msg.preview_too_long"
+	MsgPreviewOnWB         Key = "msg.preview_on_wb"
+	MsgPreviewNothingSent  Key = "msg.preview_nothing_sent"
+	BtnAgain               Key = "btn.again"
+)
+
+// Custom answers and complaints.
+const (
+	MsgOwnAnswerPrompt          Key = "msg.own_answer_prompt" // the review
+	MsgOwnAnswerAlreadyAnswered Key = "msg.own_answer_already_answered"
+	MsgOwnAnswerPosted          Key = "msg.own_answer_posted"
+	MsgComplaintFiled           Key = "msg.complaint_filed"
+	MsgComplaintAccepted        Key = "msg.complaint_accepted"
+	MsgComplaintRejected        Key = "msg.complaint_rejected"
+	MsgComplaintFailed          Key = "msg.complaint_failed"
+	MsgComplaintReasonsFailed   Key = "msg.complaint_reasons_failed"
+	BtnBackToReview             Key = "btn.back_to_review"
+	MsgComplaintPrompt          Key = "msg.complaint_prompt" // the review
+	MsgComplaintNotFiled        Key = "msg.complaint_not_filed"
+	MsgComplaintSent            Key = "msg.complaint_sent"
+)
+
+// Editing a posted answer.
+const (
+	MsgEditAnswerPrompt     Key = "msg.edit_answer_prompt"
+	MsgEditAnswerBadID      Key = "msg.edit_answer_bad_id"
+	MsgEditAnswerTextPrompt Key = "msg.edit_answer_text_prompt" // review ID
+	MsgEditAnswerNoCurrent  Key = "msg.edit_answer_no_current"
+	MsgEditAnswerRefused    Key = "msg.edit_answer_refused"
+	MsgEditAnswerFailed     Key = "msg.edit_answer_failed"
+	MsgEditAnswerDone       Key = "msg.edit_answer_done" // review ID
+)
+
+// Template files.
+const (
+	MsgTemplateFilePrompt         Key = "msg.template_file_prompt"
+	BtnExportTemplates            Key = "btn.export_templates"
+	MsgTemplateExportFailed       Key = "msg.template_export_failed"
+	MsgTemplateExportCaption      Key = "msg.template_export_caption"
+	MsgTemplateSendFailed         Key = "msg.template_send_failed"
+	MsgTemplateFileUnexpected     Key = "msg.template_file_unexpected"
+	MsgTemplateFileTooBig         Key = "msg.template_file_too_big"
+	MsgTemplateFileDownloadFailed Key = "msg.template_file_download_failed"
+	MsgTemplateFileInvalid        Key = "msg.template_file_invalid" // the reason
+	MsgTemplateFileLoaded         Key = "msg.template_file_loaded"
+	MsgTemplateTooManyVariants    Key = "msg.template_too_many_variants"
+	MsgTemplateTooManySignatures  Key = "msg.template_too_many_signatures"
+	MsgTemplateBadEncoding        Key = "msg.template_bad_encoding"
+	MsgTemplateSignatureTooLong   Key = "msg.template_signature_too_long"
+	MsgTemplateSignatureRejected  Key = "msg.template_signature_rejected"
+	MsgTemplateTextTooLong        Key = "msg.template_text_too_long"
+	MsgTemplateTextRejected       Key = "msg.template_text_rejected"
+	MsgYes                        Key = "msg.yes"
+	MsgNo                         Key = "msg.no"
+)
+
+// Unknown callbacks and commands.
+const (
+	MsgUnknownCommand Key = "msg.unknown_command"
+)
+
+// Replies by product category.
+const (
+	MsgCategoryFallback          Key = "msg.category_fallback" // %d WB subject ID
+	MsgCategoriesLoadFailed      Key = "msg.categories_load_failed"
+	MsgCategoriesIntro           Key = "msg.categories_intro"
+	MsgCategoriesOwn             Key = "msg.categories_own"
+	MsgCategoriesDiscoveryFailed Key = "msg.categories_discovery_failed" // the reason
+	MsgCategoriesNone            Key = "msg.categories_none"
+	MsgCategoriesChoose          Key = "msg.categories_choose"
+	BtnBackToVariants            Key = "btn.back_to_variants"
+	MsgCategoryBoth              Key = "msg.category_both"
+	MsgCategoryMainTemplate      Key = "msg.category_main_template" // the template
+	MsgCategoryScreen            Key = "msg.category_screen"        // category and its two answers
+	BtnCategoryGood              Key = "btn.category_good"
+	BtnCategoryBad               Key = "btn.category_bad"
+	BtnCategoryReset             Key = "btn.category_reset"
+	BtnCategories                Key = "btn.categories"
+	MsgCategoryGoodTitle         Key = "msg.category_good_title"
+	MsgCategoryBadTitle          Key = "msg.category_bad_title"
+	MsgCategoryPrompt            Key = "msg.category_prompt" // category and kind of reviews
+	MsgCategoryCleared           Key = "msg.category_cleared"
+	MsgCategorySaved             Key = "msg.category_saved"
+	MsgCategoryDeleteFailed      Key = "msg.category_delete_failed"
+	MsgCategoryRemoved           Key = "msg.category_removed"
+)
+
+// Failures and problem reviews.
+const (
+	MsgFailuresLoadFailed  Key = "msg.failures_load_failed"
+	MsgFailuresPaused      Key = "msg.failures_paused"
+	MsgFailuresNotRunning  Key = "msg.failures_not_running"
+	MsgFailuresTitle       Key = "msg.failures_title"
+	MsgFailuresNone        Key = "msg.failures_none" // period
+	MsgFailureCause        Key = "msg.failure_cause"
+	MsgFailureFix          Key = "msg.failure_fix"
+	MsgFailuresShown       Key = "msg.failures_shown"  // count, noun and period
+	MsgFailureEntries      Key = "msg.failure_entries" // plural forms
+	MsgStageFetchQuestions Key = "msg.stage_fetch_questions"
+	MsgStageFetchReviews   Key = "msg.stage_fetch_reviews"
+	MsgStageCheck          Key = "msg.stage_check"
+	MsgStageAnswerQuestion Key = "msg.stage_answer_question"
+	MsgStageAnswerReview   Key = "msg.stage_answer_review"
+	MsgStageSave           Key = "msg.stage_save"
+	MsgCauseUnauthorized   Key = "msg.cause_unauthorized"
+	MsgFixUnauthorized     Key = "msg.fix_unauthorized"
+	MsgCauseForbidden      Key = "msg.cause_forbidden"
+	MsgFixForbidden        Key = "msg.fix_forbidden"
+	MsgCauseRateLimited    Key = "msg.cause_rate_limited"
+	MsgFixRateLimited      Key = "msg.fix_rate_limited"
+	MsgCauseServer         Key = "msg.cause_server"
+	MsgFixNextCycle        Key = "msg.fix_next_cycle"
+	MsgCauseBadRequest     Key = "msg.cause_bad_request"
+	MsgFixBadRequest       Key = "msg.fix_bad_request"
+	MsgCauseNotFound       Key = "msg.cause_not_found"
+	MsgFixNothing          Key = "msg.fix_nothing"
+	MsgCauseStorage        Key = "msg.cause_storage"
+	MsgFixStorage          Key = "msg.fix_storage"
+	MsgCauseContent        Key = "msg.cause_content"
+	MsgFixContent          Key = "msg.fix_content"
+	MsgCauseLength         Key = "msg.cause_length"
+	MsgFixLength           Key = "msg.fix_length"
+	MsgCauseNetwork        Key = "msg.cause_network"
+	MsgCauseUnknown        Key = "msg.cause_unknown"
+	MsgFixUnknown          Key = "msg.fix_unknown"
+	MsgProblemsLoadFailed  Key = "msg.problems_load_failed"
+	BtnRetryNow            Key = "btn.retry_now"
+	MsgProblemsTitle       Key = "msg.problems_title"
+	MsgProblemsNone        Key = "msg.problems_none"
+	MsgProblemsIntro       Key = "msg.problems_intro" // first retry delay
+	MsgProblemsMore        Key = "msg.problems_more"  // count and noun
+	MsgReviewNoun          Key = "msg.review_noun"    // plural forms
+	MsgProblemQuestion     Key = "msg.problem_question"
+	MsgProblemReview       Key = "msg.problem_review"
+	MsgProblemAttempts     Key = "msg.problem_attempts"
+	MsgProblemNextRetry    Key = "msg.problem_next_retry"
+	MsgProblemsStuck       Key = "msg.problems_stuck"
+	MsgProblemsRetryLater  Key = "msg.problems_retry_later"
+	MsgProblemsRetrySoon   Key = "msg.problems_retry_soon"
+)
+
+// AI drafts of answers to negative reviews.
+const (
+	MsgDraftTitle           Key = "msg.draft_title"
+	BtnDraftSend            Key = "btn.draft_send"
+	BtnDraftEdit            Key = "btn.draft_edit"
+	BtnDraftSkip            Key = "btn.draft_skip"
+	MsgDraftNoButtons       Key = "msg.draft_no_buttons"
+	MsgDraftsUnavailable    Key = "msg.drafts_unavailable"
+	MsgDraftsOn             Key = "msg.drafts_on"
+	MsgDraftsOff            Key = "msg.drafts_off"
+	MsgDraftsPrompt         Key = "msg.drafts_prompt" // status
+	MsgDraftsDisabled       Key = "msg.drafts_disabled"
+	MsgDraftsEnabled        Key = "msg.drafts_enabled"
+	MsgDraftResolved        Key = "msg.draft_resolved"
+	MsgDraftSkipped         Key = "msg.draft_skipped"
+	MsgDraftEditPrompt      Key = "msg.draft_edit_prompt" // the draft
+	MsgDraftAlreadyAnswered Key = "msg.draft_already_answered"
+	MsgDraftDailyLimit      Key = "msg.draft_daily_limit"
+	MsgDraftNotSent         Key = "msg.draft_not_sent" // the reason
+	MsgDraftPosted          Key = "msg.draft_posted"
+)
+
+// Daily report of negative reviews.
+const (
+	MsgNegReportEmpty      Key = "msg.neg_report_empty"
+	MsgNegReportHeader     Key = "msg.neg_report_header"     // count
+	MsgNegReportUnanswered Key = "msg.neg_report_unanswered" // count
+	MsgNegReportNoReply    Key = "msg.neg_report_no_reply"
+	MsgNegReportOff        Key = "msg.neg_report_off"
+	MsgNegReportOn         Key = "msg.neg_report_on"
+	MsgNegReportPrompt     Key = "msg.neg_report_prompt" // status, hour and time zone
+	MsgNegReportDisabled   Key = "msg.neg_report_disabled"
+	MsgNegReportEnabled    Key = "msg.neg_report_enabled" // hour
+)
+
+// Answering the review archive.
+const (
+	MsgArchiveNotRunning       Key = "msg.archive_not_running"
+	MsgArchiveStopping         Key = "msg.archive_stopping"
+	MsgArchiveBusy             Key = "msg.archive_busy"
+	MsgArchiveNoService        Key = "msg.archive_no_service"
+	MsgArchiveNoServiceConfirm Key = "msg.archive_no_service_confirm"
+	MsgArchiveCounting         Key = "msg.archive_counting"
+	MsgArchiveFetchFailed      Key = "msg.archive_fetch_failed"
+	MsgArchiveUnavailable      Key = "msg.archive_unavailable"
+	MsgArchiveNothing          Key = "msg.archive_nothing" // count
+	BtnArchiveAnswer           Key = "btn.archive_answer"
+	MsgArchivePrompt           Key = "msg.archive_prompt" // unanswered reviews and archive size
+	MsgArchiveStarted          Key = "msg.archive_started"
+	MsgArchiveProgress         Key = "msg.archive_progress" // checked and answered
+	MsgArchiveLimitReached     Key = "msg.archive_limit_reached"
+	MsgArchiveDone             Key = "msg.archive_done"
+	MsgArchiveStopped          Key = "msg.archive_stopped"
+	MsgArchiveUnreachable      Key = "msg.archive_unreachable"
+	MsgArchiveAborted          Key = "msg.archive_aborted" // the reason
+	MsgArchiveTotals           Key = "msg.archive_totals"
+	MsgArchiveRetryLater       Key = "msg.archive_retry_later"
+)
+
+// Subscription and payments.
+const (
+	MsgAccessEnded         Key = "msg.access_ended"
+	MsgTrialEnded          Key = "msg.trial_ended"
+	MsgSubscriptionFailed  Key = "msg.subscription_failed"
+	MsgSubscriptionPaid    Key = "msg.subscription_paid"  // date
+	MsgSubscriptionTrial   Key = "msg.subscription_trial" // date and answers left
+	MsgSubscriptionExpired Key = "msg.subscription_expired"
+	MsgSubscriptionTitle   Key = "msg.subscription_title"
+	MsgPrice               Key = "msg.price" // price and days
+	MsgPriceAskAdmin       Key = "msg.price_ask_admin"
+	BtnPay                 Key = "btn.pay" // price
+	MsgPaymentUnavailable  Key = "msg.payment_unavailable"
+	MsgInvoiceTitle        Key = "msg.invoice_title"
+	MsgInvoiceDescription  Key = "msg.invoice_description" // days
+	MsgInvoiceLabel        Key = "msg.invoice_label"       // days
+	MsgInvoiceFailed       Key = "msg.invoice_failed"
+	MsgInvoiceOutdated     Key = "msg.invoice_outdated"
+	MsgPaymentNotRecorded  Key = "msg.payment_not_recorded"
+	MsgPaymentReceived     Key = "msg.payment_received" // date
+	MsgAccessGranted       Key = "msg.access_granted"   // date
+)
+
+// Timezone.
+const (
+	MsgCityKaliningrad   Key = "msg.city_kaliningrad"
+	MsgCityMoscow        Key = "msg.city_moscow"
+	MsgCitySamara        Key = "msg.city_samara"
+	MsgCityYekaterinburg Key = "msg.city_yekaterinburg"
+	MsgCityOmsk          Key = "msg.city_omsk"
+	MsgCityNovosibirsk   Key = "msg.city_novosibirsk"
+	MsgCityIrkutsk       Key = "msg.city_irkutsk"
+	MsgCityYakutsk       Key = "msg.city_yakutsk"
+	MsgCityVladivostok   Key = "msg.city_vladivostok"
+	MsgCityMagadan       Key = "msg.city_magadan"
+	MsgCityKamchatka     Key = "msg.city_kamchatka"
+	MsgTimezoneDefault   Key = "msg.timezone_default" // zone name
+	MsgTimezonePrompt    Key = "msg.timezone_prompt"  // zone and local time
+	MsgTimezoneUnknown   Key = "msg.timezone_unknown"
+	MsgTimezoneSaved     Key = "msg.timezone_saved" // zone, offset and local time
+)
+
+// Referrals.
+const (
+	MsgReferralJoined    Key = "msg.referral_joined"
+	MsgReferralCredited  Key = "msg.referral_credited"
+	MsgReferralExtended  Key = "msg.referral_extended"   // days
+	MsgReferralBonusDays Key = "msg.referral_bonus_days" // days
+	MsgReferralBonusNone Key = "msg.referral_bonus_none"
+	MsgInviteTitle       Key = "msg.invite_title"      // referral link
+	MsgInviteBonus       Key = "msg.invite_bonus"      // days
+	MsgInviteStats       Key = "msg.invite_stats"      // invited and credited
+	MsgInviteBonusDays   Key = "msg.invite_bonus_days" // days
+)
+
+// Proxy for WB requests.
+const (
+	MsgProxyNone        Key = "msg.proxy_none"
+	MsgProxyShared      Key = "msg.proxy_shared"
+	MsgProxyDown        Key = "msg.proxy_down"   // proxy and time
+	MsgProxyPrompt      Key = "msg.proxy_prompt" // proxy status
+	MsgProxyAsk         Key = "msg.proxy_ask"
+	MsgProxyOff         Key = "msg.proxy_off"
+	MsgProxyBadScheme   Key = "msg.proxy_bad_scheme"
+	MsgProxyCheckFailed Key = "msg.proxy_check_failed"
+	MsgProxySet         Key = "msg.proxy_set" // proxy
+	MsgSaveFailedInline Key = "msg.save_failed_inline"
+)
+
+// Signatures.
+const (
+	MsgSignaturesLoadFailed Key = "msg.signatures_load_failed"
+	MsgSignaturesTitle      Key = "msg.signatures_title"
+	MsgSignaturesEmpty      Key = "msg.signatures_empty"
+	BtnSignatureAdd         Key = "btn.signature_add"
+	MsgSignaturePrompt      Key = "msg.signature_prompt" // max length
+	MsgSignatureEmpty       Key = "msg.signature_empty"
+	MsgSignatureTooLong     Key = "msg.signature_too_long"  // max length
+	MsgSignaturesTooMany    Key = "msg.signatures_too_many" // max count
+	MsgSignatureAdded       Key = "msg.signature_added"
+)
+
+// Duplicate reviews.
+const (
+	MsgDuplicatesAll     Key = "msg.duplicates_all"
+	MsgDuplicatesShare   Key = "msg.duplicates_share"   // percent
+	MsgDuplicatesSkipped Key = "msg.duplicates_skipped" // count
+	MsgDuplicatesPrompt  Key = "msg.duplicates_prompt"  // current setting and burst size
+	MsgDuplicatesInvalid Key = "msg.duplicates_invalid"
+	MsgDuplicatesOff     Key = "msg.duplicates_off"
+	MsgDuplicatesOn      Key = "msg.duplicates_on" // percent
+)
+
+// Answer delay.
+const (
+	MsgAnswerDelayNone    Key = "msg.answer_delay_none"
+	MsgAnswerDelayPrompt  Key = "msg.answer_delay_prompt"  // current delay and max hours
+	MsgAnswerDelayInvalid Key = "msg.answer_delay_invalid" // max hours
+	MsgAnswerDelayOff     Key = "msg.answer_delay_off"
+	MsgAnswerDelaySet     Key = "msg.answer_delay_set" // duration
+)
+
+// Required channel subscription.
+const (
+	MsgChannelFallbackName Key = "msg.channel_fallback_name" // channel ID
+	BtnJoinChannel         Key = "btn.join_channel"
+	BtnCheckSubscription   Key = "btn.check_subscription"
+	MsgChannelGateOne      Key = "msg.channel_gate_one"  // channel list
+	MsgChannelGateMany     Key = "msg.channel_gate_many" // channel list
+)
+
+// Token expiry.
+const (
+	MsgTokenExpiring Key = "msg.token_expiring" // expiry and time left
+	MsgTokenExpired  Key = "msg.token_expired"  // expiry
+)
+
+// Editing a template.
+const (
+	MsgEditTemplateGoodTitle Key = "msg.edit_template_good_title"
+	MsgEditTemplateBadTitle  Key = "msg.edit_template_bad_title"
+	MsgEditTemplatePrompt    Key = "msg.edit_template_prompt" // title and length
+	MsgEditTemplateGoodSaved Key = "msg.edit_template_good_saved"
+	MsgEditTemplateBadSaved  Key = "msg.edit_template_bad_saved"
+)
+
+// Wildberries outage.
+const (
+	MsgWBDown Key = "msg.wb_down" // time
+)
+
+// Pausing answers.
+const (
+	MsgPaused         Key = "msg.paused"
+	MsgResumedPending Key = "msg.resumed_pending"
+	MsgResumed        Key = "msg.resumed" // cycle interval
+)
+
+// Reply variants.
+const (
+	MsgVariantsLoadFailed  Key = "msg.variants_load_failed"
+	MsgVariantsTitle       Key = "msg.variants_title"
+	MsgVariantsMain        Key = "msg.variants_main" // section title and main template
+	MsgVariantsGood        Key = "msg.variants_good"
+	MsgVariantsBad         Key = "msg.variants_bad"
+	BtnVariantAddGood      Key = "btn.variant_add_good"
+	BtnVariantAddBad       Key = "btn.variant_add_bad"
+	BtnSignatures          Key = "btn.signatures"
+	BtnByCategory          Key = "btn.by_category"
+	MsgVariantPromptGood   Key = "msg.variant_prompt_good"
+	MsgVariantPromptBad    Key = "msg.variant_prompt_bad"
+	MsgVariantsTooMany     Key = "msg.variants_too_many" // max count
+	MsgVariantAdded        Key = "msg.variant_added"
+	MsgVariantDeleteFailed Key = "msg.variant_delete_failed"
+)
+
+// Running a cycle.
+const (
+	MsgTokenAlreadyExpired Key = "msg.token_already_expired" // expiry
+	MsgOutsideHours        Key = "msg.outside_hours"         // opening time
+	MsgCycleBusy           Key = "msg.cycle_busy"
+	MsgTokenRevoked        Key = "msg.token_revoked" // WB error
+	BtnReplaceToken        Key = "btn.replace_token"
+)
+
+// Working hours.
+const (
+	MsgHoursUnset         Key = "msg.hours_unset"
+	MsgHoursInvalid       Key = "msg.hours_invalid"
+	MsgHoursHold          Key = "msg.hours_hold"            // hours
+	MsgHoursNoOffTemplate Key = "msg.hours_no_off_template" // hours
+	MsgHoursPrompt        Key = "msg.hours_prompt"          // current hours and time zone
+	BtnHoursOnlyOn        Key = "btn.hours_only_on"
+	BtnHoursOnlyOff       Key = "btn.hours_only_off"
+	MsgHoursOnlyOff       Key = "msg.hours_only_off"
+	MsgHoursOnlyOn        Key = "msg.hours_only_on"
+	MsgHoursBadFormat     Key = "msg.hours_bad_format"
+	MsgHoursParseFailed   Key = "msg.hours_parse_failed"
+	MsgHoursDisabled      Key = "msg.hours_disabled"
+	MsgHoursSaved         Key = "msg.hours_saved" // start, end and zone
+	MsgOffHoursPrompt     Key = "msg.off_hours_prompt"
+	MsgOffHoursSaved      Key = "msg.off_hours_saved"
+	MsgOffHoursDisabled   Key = "msg.off_hours_disabled"
+)
+
+// Answer history.
+const (
+	MsgHistoryLoadFailed       Key = "msg.history_load_failed"
+	BtnExportHistory           Key = "btn.export_history"
+	MsgHistoryTitle            Key = "msg.history_title"
+	MsgHistoryEmpty            Key = "msg.history_empty"
+	MsgHistoryQuestion         Key = "msg.history_question"
+	MsgHistoryBySource         Key = "msg.history_by_source"  // period
+	MsgHistoryAvgRating        Key = "msg.history_avg_rating" // rating
+	MsgHistoryVersionNote      Key = "msg.history_version_note"
+	MsgHistoryByProduct        Key = "msg.history_by_product" // period
+	MsgHistoryComplaints       Key = "msg.history_complaints"
+	MsgHistoryComplaintsPeriod Key = "msg.history_complaints_period" // period and counts
+	MsgHistoryComplaintsNote   Key = "msg.history_complaints_note"
+	MsgComplaintStats          Key = "msg.complaint_stats" // filed, accepted, rejected, pending
+	MsgProductArticle          Key = "msg.product_article" // article
+	MsgSourceGood              Key = "msg.source_good"
+	MsgSourceBad               Key = "msg.source_bad"
+	MsgSourceOffHours          Key = "msg.source_off_hours"
+	MsgSourceSentiment         Key = "msg.source_sentiment"
+	MsgSourceMedia             Key = "msg.source_media"
+	MsgSourceCategory          Key = "msg.source_category"
+	MsgSourceQuestion          Key = "msg.source_question"
+	MsgSourceEdited            Key = "msg.source_edited"
+	MsgSourceManual            Key = "msg.source_manual"
+	MsgSourceDraft             Key = "msg.source_draft"
+	MsgSourceUnknown           Key = "msg.source_unknown"
+)
+
+// WB API address and advanced settings.
+const (
+	MsgBaseURLUnparsable Key = "msg.base_url_unparsable"
+	MsgBaseURLNotHTTPS   Key = "msg.base_url_not_https"
+	MsgBaseURLExtras     Key = "msg.base_url_extras"
+	MsgBaseURLPrompt     Key = "msg.base_url_prompt" // current address
+	MsgBaseURLSaved      Key = "msg.base_url_saved"  // address
+	MsgBaseURLStandard   Key = "msg.base_url_standard"
+	MsgBaseURLSandbox    Key = "msg.base_url_sandbox"
+	MsgBaseURLCustom     Key = "msg.base_url_custom"
+	MsgAdvancedSettings  Key = "msg.advanced_settings" // address kind, address and proxy status
+	BtnSandboxOn         Key = "btn.sandbox_on"
+	BtnSandboxOff        Key = "btn.sandbox_off"
+	BtnCustomBaseURL     Key = "btn.custom_base_url"
+	BtnCustomProxy       Key = "btn.custom_proxy"
+	BtnProxyOff          Key = "btn.proxy_off"
+	MsgSandboxOff        Key = "msg.sandbox_off"
+	MsgSandboxOn         Key = "msg.sandbox_on"
+	MsgBaseURLAsk        Key = "msg.base_url_ask"
+)
+
+// Wildberries maintenance windows.
+const (
+	MsgBlackoutDeferred Key = "msg.blackout_deferred" // end time
+)
@@ -0,0 +1,170 @@
+// Package locale formats dates, durations and counts for user-facing
+// messages according to the user's language and timezone.
+package locale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported languages. Anything else falls back to LangRU.
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// Formatter renders values for one user. The zero value formats in Russian
+// using the server's local timezone.
+type Formatter struct {
+	Lang string
+	Loc  *time.Location
+}
+
+// New returns a Formatter for lang and loc; a nil loc means time.Local.
+func New(lang string, loc *time.Location) Formatter {
+	if lang != LangEN {
+		lang = LangRU
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return Formatter{Lang: lang, Loc: loc}
+}
+
+func (f Formatter) in(t time.Time) time.Time {
+	if f.Loc == nil {
+		return t.Local()
+	}
+	return t.In(f.Loc)
+}
+
+// DateTime renders t as "02.01.2006 15:04" (ru) or "Jan 2, 2006 15:04" (en).
+func (f Formatter) DateTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	if f.Lang == LangEN {
+		return f.in(t).Format("Jan 2, 2006 15:04")
+	}
+	return f.in(t).Format("02.01.2006 15:04")
+}
+
+// ShortDateTime renders t without the year, for compact lists.
+func (f Formatter) ShortDateTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	if f.Lang == LangEN {
+		return f.in(t).Format("Jan 2 15:04")
+	}
+	return f.in(t).Format("02.01 15:04")
+}
+
+// Date renders the calendar date of t.
+func (f Formatter) Date(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	if f.Lang == LangEN {
+		return f.in(t).Format("Jan 2, 2006")
+	}
+	return f.in(t).Format("02.01.2006")
+}
+
+// Duration renders d as "3 ч 20 мин" / "45 мин" / "2 дн 4 ч" (or the English
+// equivalent). Non-positive durations render as "—".
+func (f Formatter) Duration(d time.Duration) string {
+	if d <= 0 {
+		return "—"
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	mins := int(d.Minutes()) % 60
+	if f.Lang == LangEN {
+		switch {
+		case days > 0:
+			return fmt.Sprintf("%dd %dh", days, hours)
+		case hours > 0:
+			return fmt.Sprintf("%dh %dm", hours, mins)
+		default:
+			return fmt.Sprintf("%dm", mins)
+		}
+	}
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d дн %d ч", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%d ч %d мин", hours, mins)
+	default:
+		return fmt.Sprintf("%d мин", mins)
+	}
+}
+
+// Count renders n with digit grouping: "12 345" (ru) or "12,345" (en).
+func (f Formatter) Count(n int64) string {
+	sep := " " // non-breaking space keeps the number on one line
+	if f.Lang == LangEN {
+		sep = ","
+	}
+	s := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + sep + s[i:]
+	}
+	return sign + s
+}
+
+// Bytes renders a size in binary units with one decimal: "512 Б",
+// "1,4 МБ" (ru) or "1.4 MB" (en).
+func (f Formatter) Bytes(n int64) string {
+	units := []string{"Б", "КБ", "МБ", "ГБ", "ТБ"}
+	if f.Lang == LangEN {
+		units = []string{"B", "KB", "MB", "GB", "TB"}
+	}
+	if n < 1024 {
+		return f.Count(n) + " " + units[0]
+	}
+	v, i := float64(n), 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	s := strconv.FormatFloat(v, 'f', 1, 64)
+	if f.Lang != LangEN {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s + " " + units[i]
+}
+
+// Days renders a whole number of days in window, e.g. "30 дней" / "30 days".
+func (f Formatter) Days(window time.Duration) string {
+	n := int64(window.Hours() / 24)
+	if f.Lang == LangEN {
+		if n == 1 {
+			return "1 day"
+		}
+		return f.Count(n) + " days"
+	}
+	return f.Count(n) + " " + Plural(n, "день", "дня", "дней")
+}
+
+// Plural picks the Russian plural form for n: one (1, 21), few (2–4, 22–24)
+// or many (0, 5–20, 25…).
+func Plural(n int64, one, few, many string) string {
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return one
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return few
+	default:
+		return many
+	}
+}
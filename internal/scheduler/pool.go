@@ -0,0 +1,407 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// DefaultPoolWorkers is the number of jobs a Pool runs at once when NewPool
+// is given zero.
+const DefaultPoolWorkers = 20
+
+// Pool runs the periodic jobs of many users on a fixed number of workers.
+// Due jobs wait in a single FIFO
+// queue, ordered by how long they have been due, so a burst of due users is
+// worked off fairly instead of all running at once. A job never overlaps
+// itself: a run that is due while the previous one is still going starts
+// after it.
+//
+// The queue length and the number of busy workers are exported as metrics.
+type Pool struct {
+	workers int
+	log     *zap.SugaredLogger
+	work    chan *Job
+	wake    chan struct{}
+
+	mu      sync.Mutex
+	jobs    map[int64]*Job
+	ready   []*Job // due jobs waiting for a worker, oldest first
+	handing *Job   // taken off ready and offered to the workers
+	busy    int
+}
+
+// Job is a periodic job of one user registered in a Pool.
+type Job struct {
+	pool     *Pool
+	key      int64
+	interval time.Duration
+	fn       func(ctx context.Context)
+
+	// guarded by pool.mu
+	next    time.Time // next regular run
+	soon    time.Time // extra run requested with RunSoon; zero if none
+	queued  bool
+	running bool
+	removed bool
+	abort   bool               // cancel the run once it starts or right away
+	cancel  context.CancelFunc // cancels the running run
+	lastRun time.Time
+	done    chan struct{} // closed once removed and not running
+}
+
+// NewPool constructs a Pool with the given number of workers; workers <= 0
+// means DefaultPoolWorkers.
+func NewPool(workers int, logger *zap.SugaredLogger) *Pool {
+	if workers <= 0 {
+		workers = DefaultPoolWorkers
+	}
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return &Pool{
+		workers: workers,
+		log:     logger,
+		work:    make(chan *Job),
+		wake:    make(chan struct{}, 1),
+		jobs:    make(map[int64]*Job),
+	}
+}
+
+// Add registers fn to run every interval for key, first after delay (0 runs
+// it as soon as a worker is free). A job already registered for key is
+// stopped and replaced. Intervals below 1s are clamped to 1s to avoid busy
+// loops. The job
+// runs once Run has been started.
+func (p *Pool) Add(key int64, interval, delay time.Duration, fn func(ctx context.Context)) *Job {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	j := &Job{
+		pool:     p,
+		key:      key,
+		interval: interval,
+		fn:       fn,
+		next:     time.Now().Add(max(delay, 0)),
+		done:     make(chan struct{}),
+	}
+	p.mu.Lock()
+	if old := p.jobs[key]; old != nil {
+		p.removeLocked(old)
+	}
+	p.jobs[key] = j
+	p.mu.Unlock()
+	p.poke()
+	return j
+}
+
+// Run starts the workers and dispatches due jobs until ctx is done. Runs get
+// a context derived from ctx. Call it once, in its own goroutine.
+func (p *Pool) Run(ctx context.Context) {
+	p.log.Infow("cycle pool started", "workers", p.workers)
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.worker(ctx)
+		}()
+	}
+	defer wg.Wait()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		p.mu.Lock()
+		wait := p.promoteLocked(time.Now())
+		var work chan *Job
+		var head *Job
+		if len(p.ready) > 0 {
+			// head stays queued until the worker marks it running, so it
+			// cannot be queued again in between. Once it is off ready, a
+			// Stop leaves closing done to whoever ends up with it.
+			work, head = p.work, p.ready[0]
+			p.ready = p.ready[1:]
+			p.handing = head
+		}
+		p.mu.Unlock()
+
+		timer.Reset(wait)
+		sent := false
+		select {
+		case <-ctx.Done():
+			p.log.Info("cycle pool: context cancelled")
+			return
+		case work <- head:
+			sent = true
+		case <-p.wake:
+		case <-timer.C:
+		}
+		if head != nil {
+			p.mu.Lock()
+			p.handing = nil
+			if !sent {
+				p.takeBackLocked(head)
+			}
+			p.updateMetricsLocked()
+			p.mu.Unlock()
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// takeBackLocked returns a job no worker took to the front of the queue,
+// or finishes it if it was removed meanwhile.
+func (p *Pool) takeBackLocked(j *Job) {
+	if j.removed {
+		j.queued = false
+		close(j.done)
+		return
+	}
+	p.ready = slices.Insert(p.ready, 0, j)
+}
+
+// promoteLocked queues jobs that are due at now, the longest overdue first,
+// and returns how long to sleep until the next one is due.
+func (p *Pool) promoteLocked(now time.Time) time.Duration {
+	wait := time.Hour
+	var due []*Job
+	for _, j := range p.jobs {
+		if j.queued || j.running {
+			continue
+		}
+		at := j.dueAt()
+		if at.After(now) {
+			wait = min(wait, at.Sub(now))
+			continue
+		}
+		due = append(due, j)
+	}
+	if len(due) > 0 {
+		slices.SortFunc(due, func(a, b *Job) int { return a.dueAt().Compare(b.dueAt()) })
+		for _, j := range due {
+			j.queued = true
+			j.soon = time.Time{}
+		}
+		p.ready = append(p.ready, due...)
+		p.updateMetricsLocked()
+	}
+	return wait
+}
+
+func (p *Pool) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-p.work:
+			p.runJob(ctx, j)
+		}
+	}
+}
+
+func (p *Pool) runJob(ctx context.Context, j *Job) {
+	p.mu.Lock()
+	j.queued = false
+	if j.removed {
+		close(j.done)
+		p.mu.Unlock()
+		return
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if j.abort {
+		cancel()
+	}
+	start := time.Now()
+	j.running, j.cancel = true, cancel
+	p.busy++
+	p.updateMetricsLocked()
+	p.mu.Unlock()
+
+	j.fn(runCtx)
+
+	p.mu.Lock()
+	j.running, j.cancel = false, nil
+	j.lastRun = start
+	j.next = start.Add(j.interval)
+	p.busy--
+	p.updateMetricsLocked()
+	if j.removed {
+		close(j.done)
+	}
+	p.mu.Unlock()
+	p.poke()
+}
+
+// removeLocked unregisters j. Its done channel is closed now if it is idle,
+// otherwise by runJob.
+func (p *Pool) removeLocked(j *Job) {
+	if j.removed {
+		return
+	}
+	j.removed = true
+	if p.jobs[j.key] == j {
+		delete(p.jobs, j.key)
+	}
+	if i := slices.Index(p.ready, j); i >= 0 {
+		p.ready = slices.Delete(p.ready, i, i+1)
+		j.queued = false
+		p.updateMetricsLocked()
+	}
+	// A job being handed to a worker is closed by the dispatcher or runJob
+	// instead.
+	if !j.running && !j.queued {
+		close(j.done)
+	}
+}
+
+// QueueDepth returns the number of due jobs waiting for a free worker.
+func (p *Pool) QueueDepth() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queueLocked()
+}
+
+// queueLocked counts the due jobs waiting, the one offered to the workers
+// included.
+func (p *Pool) queueLocked() int {
+	if p.handing != nil {
+		return len(p.ready) + 1
+	}
+	return len(p.ready)
+}
+
+func (p *Pool) updateMetricsLocked() {
+	metrics.SetCyclePool(p.queueLocked(), p.busy)
+}
+
+// poke wakes the dispatcher to re-examine the jobs.
+func (p *Pool) poke() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dueAt returns when the job should run next, counting a RunSoon request.
+// Must be called with pool.mu held.
+func (j *Job) dueAt() time.Time {
+	if !j.soon.IsZero() && j.soon.Before(j.next) {
+		return j.soon
+	}
+	return j.next
+}
+
+// RunSoon requests one extra run after d, in addition to the regular runs.
+// Used by jobs that were cut short (e.g. rate limited) to retry earlier than
+// the next regular run. Requests not shorter than the interval are ignored,
+// as is a request made while another one is pending. Safe to call from the
+// job itself.
+func (j *Job) RunSoon(d time.Duration) {
+	p := j.pool
+	p.mu.Lock()
+	if d >= j.interval || j.removed || !j.soon.IsZero() {
+		p.mu.Unlock()
+		return
+	}
+	j.soon = time.Now().Add(d)
+	p.mu.Unlock()
+	p.poke()
+}
+
+// SetInterval changes the time between runs, clamped as in Add. An idle job
+// that has run is rescheduled to interval after its latest run; a job that
+// has not run yet keeps its first run, and a queued or running one picks the
+// new interval up once the run ends.
+func (j *Job) SetInterval(interval time.Duration) {
+	if interval < time.Second {
+		interval = time.Second
+	}
+	p := j.pool
+	p.mu.Lock()
+	if j.removed || j.interval == interval {
+		p.mu.Unlock()
+		return
+	}
+	j.interval = interval
+	if !j.queued && !j.running && !j.lastRun.IsZero() {
+		j.next = j.lastRun.Add(interval)
+	}
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Busy reports whether the job is executing right now.
+func (j *Job) Busy() bool {
+	j.pool.mu.Lock()
+	defer j.pool.mu.Unlock()
+	return j.running
+}
+
+// LastRun returns when the latest run started; zero before the first one.
+func (j *Job) LastRun() time.Time {
+	j.pool.mu.Lock()
+	defer j.pool.mu.Unlock()
+	return j.lastRun
+}
+
+// NextRun returns when the job is due to run next; zero once it is removed.
+func (j *Job) NextRun() time.Time {
+	j.pool.mu.Lock()
+	defer j.pool.mu.Unlock()
+	if j.removed {
+		return time.Time{}
+	}
+	return j.dueAt()
+}
+
+// Interval returns the time between runs.
+func (j *Job) Interval() time.Duration {
+	j.pool.mu.Lock()
+	defer j.pool.mu.Unlock()
+	return j.interval
+}
+
+// Stop removes the job from the pool without starting new runs, letting the
+// running one, if any, finish. Follow with Wait to drain it. Idempotent.
+func (j *Job) Stop() {
+	j.pool.mu.Lock()
+	j.pool.removeLocked(j)
+	j.pool.mu.Unlock()
+	j.pool.poke()
+}
+
+// Shutdown removes the job and cancels its running run. Idempotent.
+func (j *Job) Shutdown() {
+	p := j.pool
+	p.mu.Lock()
+	p.removeLocked(j)
+	j.abort = true
+	if j.cancel != nil {
+		j.cancel()
+	}
+	p.mu.Unlock()
+	p.poke()
+}
+
+// Wait blocks until the job has been stopped and its last run has returned,
+// or ctx is done, and reports whether the job finished.
+func (j *Job) Wait(ctx context.Context) bool {
+	select {
+	case <-j.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
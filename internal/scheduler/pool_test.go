@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startPool runs a pool with the given number of workers until the test ends.
+func startPool(t *testing.T, workers int) *Pool {
+	t.Helper()
+	p := NewPool(workers, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return p
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func waitJob(t *testing.T, j *Job) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if !j.Wait(ctx) {
+		t.Fatal("job did not finish")
+	}
+}
+
+// blocker occupies a worker until release is called.
+func blocker(t *testing.T, p *Pool, key int64) (j *Job, release func()) {
+	t.Helper()
+	started, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	j = p.Add(key, time.Hour, 0, func(context.Context) {
+		once.Do(func() { close(started) })
+		<-unblock
+	})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("blocking job did not start")
+	}
+	var released sync.Once
+	release = func() { released.Do(func() { close(unblock) }) }
+	t.Cleanup(release)
+	return j, release
+}
+
+func TestPoolRunsDueJobsInOrder(t *testing.T) {
+	p := startPool(t, 1)
+	_, release := blocker(t, p, 0)
+
+	var mu sync.Mutex
+	var order []int64
+	record := func(key int64) func(context.Context) {
+		return func(context.Context) {
+			mu.Lock()
+			order = append(order, key)
+			mu.Unlock()
+		}
+	}
+	// Due while the only worker is busy, in the order 2, 3, 1
+	p.Add(1, time.Hour, 30*time.Millisecond, record(1))
+	p.Add(2, time.Hour, 10*time.Millisecond, record(2))
+	p.Add(3, time.Hour, 20*time.Millisecond, record(3))
+	waitFor(t, "all jobs queued", func() bool { return p.QueueDepth() == 3 })
+
+	release()
+	waitFor(t, "all jobs run", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 3
+	})
+	if want := []int64{2, 3, 1}; !slices.Equal(order, want) {
+		t.Errorf("run order = %v, want %v", order, want)
+	}
+}
+
+func TestPoolJobDoesNotOverlapItself(t *testing.T) {
+	p := startPool(t, 4)
+	var running, maxRunning, runs atomic.Int32
+	added := make(chan *Job, 1)
+	added <- p.Add(1, time.Second, 0, func(context.Context) {
+		j := <-added
+		defer func() { added <- j }()
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// Due again while still running
+		j.RunSoon(0)
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		runs.Add(1)
+	})
+	waitFor(t, "three runs", func() bool { return runs.Load() >= 3 })
+	if m := maxRunning.Load(); m != 1 {
+		t.Errorf("job ran %d times at once, want 1", m)
+	}
+}
+
+func TestPoolRunSoon(t *testing.T) {
+	p := startPool(t, 1)
+	var runs atomic.Int32
+	j := p.Add(1, time.Hour, 0, func(context.Context) { runs.Add(1) })
+	waitFor(t, "the first run", func() bool { return runs.Load() == 1 && !j.Busy() })
+
+	j.RunSoon(time.Hour) // not shorter than the interval: ignored
+	if next := j.NextRun(); time.Until(next) < 59*time.Minute {
+		t.Errorf("NextRun after RunSoon(interval) = %v from now, want the regular run", time.Until(next))
+	}
+	j.RunSoon(5 * time.Millisecond)
+	waitFor(t, "the extra run", func() bool { return runs.Load() == 2 })
+	if next := j.NextRun(); time.Until(next) < 59*time.Minute {
+		t.Errorf("NextRun after the extra run = %v from now, want the regular run", time.Until(next))
+	}
+}
+
+func TestPoolSetInterval(t *testing.T) {
+	p := startPool(t, 1)
+	var runs atomic.Int32
+	j := p.Add(1, time.Hour, 0, func(context.Context) { runs.Add(1) })
+	waitFor(t, "the first run", func() bool { return runs.Load() == 1 && !j.Busy() })
+
+	j.SetInterval(2 * time.Second)
+	if got, want := j.NextRun(), j.LastRun().Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("NextRun = %v, want %v: the latest run plus the new interval", got, want)
+	}
+	if got := j.Interval(); got != 2*time.Second {
+		t.Errorf("Interval = %v, want 2s", got)
+	}
+	j.SetInterval(time.Millisecond)
+	if got := j.Interval(); got != time.Second {
+		t.Errorf("Interval = %v, want it clamped to 1s", got)
+	}
+
+	// A job that has not run yet keeps its first run
+	later := p.Add(2, time.Hour, time.Hour, func(context.Context) {})
+	first := later.NextRun()
+	later.SetInterval(time.Second)
+	if got := later.NextRun(); !got.Equal(first) {
+		t.Errorf("NextRun of a job not run yet = %v, want %v", got, first)
+	}
+}
+
+func TestPoolStopLetsTheRunFinish(t *testing.T) {
+	p := startPool(t, 1)
+	j, release := blocker(t, p, 1)
+
+	j.Stop()
+	if !j.NextRun().IsZero() {
+		t.Error("NextRun of a stopped job is set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if j.Wait(ctx) {
+		t.Fatal("Wait returned while the run was going on")
+	}
+	release()
+	waitJob(t, j)
+	j.Stop() // idempotent
+}
+
+func TestPoolShutdownCancelsTheRun(t *testing.T) {
+	p := startPool(t, 1)
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	j := p.Add(1, time.Hour, 0, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+	})
+	<-started
+	j.Shutdown()
+	waitJob(t, j)
+	if !cancelled.Load() {
+		t.Error("run was not cancelled")
+	}
+	j.Shutdown() // idempotent
+}
+
+func TestPoolStopIdleJob(t *testing.T) {
+	p := startPool(t, 1)
+	var runs atomic.Int32
+	j := p.Add(1, time.Hour, time.Hour, func(context.Context) { runs.Add(1) })
+	j.Stop()
+	waitJob(t, j)
+
+	// Replacing a job stops the old one
+	old := p.Add(2, time.Hour, time.Hour, func(context.Context) { runs.Add(1) })
+	p.Add(2, time.Hour, time.Hour, func(context.Context) {})
+	waitJob(t, old)
+	if n := runs.Load(); n != 0 {
+		t.Errorf("stopped jobs ran %d times", n)
+	}
+}
+
+// TestPoolStopWhileHandedOver stops a job the dispatcher has taken off the
+// queue and is offering to the busy workers. Its done channel must be
+// closed once, whoever ends up with the job.
+func TestPoolStopWhileHandedOver(t *testing.T) {
+	for _, stop := range []struct {
+		name string
+		fn   func(j *Job)
+	}{
+		{"stop", (*Job).Stop},
+		{"shutdown", (*Job).Shutdown},
+	} {
+		t.Run(stop.name, func(t *testing.T) {
+			p := startPool(t, 1)
+			_, release := blocker(t, p, 0)
+
+			var ran atomic.Bool
+			j := p.Add(1, time.Hour, 0, func(context.Context) { ran.Store(true) })
+			waitFor(t, "the job offered to the workers", func() bool {
+				p.mu.Lock()
+				defer p.mu.Unlock()
+				return p.handing == j
+			})
+			stop.fn(j)
+			release()
+			waitJob(t, j)
+
+			// The pool keeps working
+			var next atomic.Bool
+			p.Add(2, time.Hour, 0, func(context.Context) { next.Store(true) })
+			waitFor(t, "a later job", next.Load)
+			if ran.Load() {
+				t.Error("stopped job ran")
+			}
+		})
+	}
+}
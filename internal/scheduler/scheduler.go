@@ -7,71 +7,88 @@ import (
 	"go.uber.org/zap"
 )
 
-// Scheduler wraps a time.Ticker to execute a job at a fixed interval.
-// It supports graceful shutdown via outer context cancellation or explicit
-// Shutdown() call. Each job run inherits the parent context with the same
-// deadline/cancellation.
-//
-// The job function should be idempotent and handle its own internal timeouts;
-// Scheduler does NOT create a per-run timeout to keep flexibility.
-// You may wrap fn in a context.WithTimeout in main if desired.
-
-type Scheduler struct {
-	interval time.Duration
-	fn       func(ctx context.Context)
-	log      *zap.SugaredLogger
-	stopCh   chan struct{}
+// Daily runs a job once a day at a fixed local time. It does not run on
+// start; intended for low-frequency maintenance jobs (nightly aggregates,
+// digests). The periodic jobs of users run in a Pool.
+type Daily struct {
+	at     time.Duration // offset from local midnight, or from the hour for NewHourly
+	hourly bool
+	loc    *time.Location
+	fn     func(ctx context.Context)
+	log    *zap.SugaredLogger
+	stopCh chan struct{}
 }
 
-// New constructs a Scheduler. If interval <1s, it is clamped to 1s to avoid
-// busy-loops.
-func New(interval time.Duration, fn func(ctx context.Context), logger *zap.SugaredLogger) *Scheduler {
-	if interval < time.Second {
-		interval = time.Second
+// NewDaily constructs a Daily scheduler firing at the given offset from
+// midnight in loc (nil loc means time.Local).
+func NewDaily(at time.Duration, loc *time.Location, fn func(ctx context.Context), logger *zap.SugaredLogger) *Daily {
+	if loc == nil {
+		loc = time.Local
 	}
 	if logger == nil {
 		logger = zap.NewNop().Sugar()
 	}
-	return &Scheduler{
-		interval: interval,
-		fn:       fn,
-		log:      logger,
-		stopCh:   make(chan struct{}),
+	return &Daily{
+		at:     at % (24 * time.Hour),
+		loc:    loc,
+		fn:     fn,
+		log:    logger,
+		stopCh: make(chan struct{}),
 	}
 }
 
-// Run starts the ticker loop. It blocks until the parent context is done or
-// Shutdown() is called. Safe to call in its own goroutine.
-func (s *Scheduler) Run(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
-
-	s.log.Info("scheduler started", "interval", s.interval.String())
-
-	// Immediate execution at start (optional; comment if not needed)
-	s.fn(ctx)
+// NewHourly constructs a Daily that fires every hour at the given offset
+// from the start of the hour, e.g. for jobs that pick the users whose local
+// time has come.
+func NewHourly(at time.Duration, fn func(ctx context.Context), logger *zap.SugaredLogger) *Daily {
+	d := NewDaily(0, time.UTC, fn, logger)
+	d.at = at % time.Hour
+	d.hourly = true
+	return d
+}
 
+// Run blocks until the parent context is done or Shutdown() is called.
+func (d *Daily) Run(ctx context.Context) {
 	for {
+		next := d.next(time.Now())
+		d.log.Infow("daily scheduler: next run", "at", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
 		select {
 		case <-ctx.Done():
-			s.log.Info("scheduler: parent context cancelled")
+			timer.Stop()
 			return
-		case <-s.stopCh:
-			s.log.Info("scheduler: shutdown signal received")
+		case <-d.stopCh:
+			timer.Stop()
 			return
-		case <-ticker.C:
-			s.fn(ctx)
+		case <-timer.C:
+			d.fn(ctx)
 		}
 	}
 }
 
-// Shutdown signals the Run loop to exit as soon as possible.
-// It is idempotent.
-func (s *Scheduler) Shutdown() {
+// Shutdown signals the Run loop to exit. It is idempotent.
+func (d *Daily) Shutdown() {
 	select {
-	case <-s.stopCh:
-		// already closed
+	case <-d.stopCh:
 	default:
-		close(s.stopCh)
+		close(d.stopCh)
+	}
+}
+
+// next returns the first firing time strictly after now.
+func (d *Daily) next(now time.Time) time.Time {
+	local := now.In(d.loc)
+	if d.hourly {
+		t := local.Truncate(time.Hour).Add(d.at)
+		if !t.After(local) {
+			t = t.Add(time.Hour)
+		}
+		return t
+	}
+	y, m, day := local.Date()
+	t := time.Date(y, m, day, 0, 0, 0, 0, d.loc).Add(d.at)
+	if !t.After(local) {
+		t = time.Date(y, m, day+1, 0, 0, 0, 0, d.loc).Add(d.at)
 	}
+	return t
 }
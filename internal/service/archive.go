@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// ArchiveHistory moves answers older than months into the archive table so
+// that the hot processed table stays small. Intended to be run nightly by
+// scheduler.Daily.
+func ArchiveHistory(ctx context.Context, store storage.Store, months int, log *zap.SugaredLogger) {
+	start := time.Now()
+	cutoff := start.UTC().AddDate(0, -months, 0)
+	moved, err := store.ArchiveAnswers(ctx, cutoff)
+	if err != nil {
+		log.Errorw("archive: failed", "cutoff", cutoff.Format(time.DateOnly), "err", err)
+		return
+	}
+	log.Infow("archive: done", "moved", moved, "cutoff", cutoff.Format(time.DateOnly), "duration", time.Since(start).String())
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// archivePageSize is how many archived feedbacks are fetched per request.
+// Smaller than the cycle's take so that progress is reported often.
+const archivePageSize = 500
+
+// ArchiveResult summarizes a pass over the WB feedback archive.
+type ArchiveResult struct {
+	Scanned      int  // archived feedbacks looked at
+	Unanswered   int  // of them without an answer on WB
+	Answered     int  // answers posted and stored
+	Skipped      int  // unanswered but excluded, answered before or waiting for a retry
+	Failed       int  // WB rejected the answer
+	LimitReached bool // stopped by the daily answer limit
+}
+
+// CountArchived pages through the archive and counts feedbacks that have no
+// answer, without posting anything. Used to warn the user before
+// AnswerArchived, since the archive can hold thousands of reviews.
+func (s *Service) CountArchived(ctx context.Context) (ArchiveResult, error) {
+	var res ArchiveResult
+	err := s.eachArchivedPage(ctx, func(page []wbapi.Feedback) bool {
+		res.Scanned += len(page)
+		for _, fb := range page {
+			if fb.Answer == nil {
+				res.Unanswered++
+			}
+		}
+		return true
+	})
+	return res, err
+}
+
+// AnswerArchived answers archived feedbacks that have no answer yet, the
+// same way HandleCycle answers new ones: exclusions, answered IDs, retry
+// backoff and the daily limit apply. progress, if not nil, is called after
+// every page with the totals so far. A fetch error or a WB rate limit stops
+// the run; the feedbacks answered by then stay answered, so it can simply
+// be started again.
+func (s *Service) AnswerArchived(ctx context.Context, progress func(ArchiveResult)) (ArchiveResult, error) {
+	if left := s.CooldownLeft(); left > 0 {
+		return ArchiveResult{}, fmt.Errorf("%w: cooldown %s", wbapi.ErrRateLimited, left.Round(time.Second))
+	}
+
+	var res ArchiveResult
+	var runErr error
+	retries := s.failedAnswers(ctx)
+	defer s.reportStuck(retries)
+	left := s.answersLeft(ctx)
+	batch := s.newAnswerBatch(storage.KindFeedback)
+	calls := 0
+
+	err := s.eachArchivedPage(ctx, func(page []wbapi.Feedback) bool {
+		res.Scanned += len(page)
+		pending := make([]wbapi.Feedback, 0, len(page))
+		ids := make([]string, 0, len(page))
+		for _, fb := range page {
+			if fb.Answer != nil {
+				continue
+			}
+			res.Unanswered++
+			if s.excluded(fb) {
+				res.Skipped++
+				continue
+			}
+			pending = append(pending, fb)
+			ids = append(ids, fb.ID)
+		}
+		done := s.answeredIDs(ctx, storage.KindFeedback, ids)
+		if done == nil {
+			runErr = fmt.Errorf("archive: answered lookup failed")
+			return false
+		}
+
+		for _, fb := range pending {
+			if done[fb.ID] || !retryDue(retries, fb.ID, time.Now()) {
+				res.Skipped++
+				continue
+			}
+			if left <= 0 {
+				res.LimitReached = true
+				return false
+			}
+			if calls > 0 && !s.humanPause(ctx) {
+				return false
+			}
+			calls++
+
+			decision := s.templates.Decide(fb, time.Now())
+			if err := s.answerFeedback(ctx, fb.ID, decision.Text); err != nil {
+				if contentRefused(err) {
+					s.refuseAnswer(ctx, storage.KindFeedback, fb.ID, err)
+					metrics.IncrementProcessedFeedback(s.userID, "failed")
+					res.Failed++
+					continue
+				}
+				s.recordFailure(ctx, storage.KindFeedback, StageAnswer, fb.ID, FailureCause(err), err)
+				if s.rateLimited(err) {
+					runErr = err
+					return false
+				}
+				s.log.Warnw("archive: answer failed", "user_id", s.userID, "id", fb.ID, "err", err)
+				s.retryLater(ctx, retries, storage.KindFeedback, fb.ID, err)
+				metrics.IncrementAPIError("wb", "answer")
+				metrics.IncrementProcessedFeedback(s.userID, "failed")
+				res.Failed++
+				continue
+			}
+			s.answerPosted(ctx, retries, fb.ID)
+
+			left--
+			res.Answered++
+			s.countAnswer(ctx)
+			batch.add(ctx, feedbackRecord(fb, decision))
+		}
+		batch.flush(ctx)
+		if progress != nil {
+			progress(res)
+		}
+		return true
+	})
+	// Answers posted before an early stop are still recorded.
+	batch.flush(ctx)
+	for i := 0; i < res.Answered; i++ {
+		metrics.IncrementProcessedFeedback(s.userID, "answered")
+	}
+	if err == nil {
+		err = runErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if res.LimitReached {
+		s.limitReached()
+	}
+	if res.Answered > 0 || res.Failed > 0 {
+		s.audit(ctx, s.userID, storage.AuditCycleRun, fmt.Sprintf("archive answered=%d skipped=%d failed=%d", res.Answered, res.Skipped, res.Failed))
+	}
+	s.log.Infow("archive run complete", "user_id", s.userID,
+		"scanned", res.Scanned, "unanswered", res.Unanswered, "answered", res.Answered,
+		"skipped", res.Skipped, "failed", res.Failed, "limit_reached", res.LimitReached, "err", err)
+	return res, err
+}
+
+// eachArchivedPage calls fn with consecutive archive pages until the archive
+// ends or fn returns false. Answering a feedback keeps it in the archive,
+// so skip-based pagination stays stable during AnswerArchived.
+func (s *Service) eachArchivedPage(ctx context.Context, fn func([]wbapi.Feedback) bool) error {
+	for skip := 0; ; skip += archivePageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := s.client.FetchArchived(ctx, archivePageSize, skip)
+		if err != nil {
+			s.recordFailure(ctx, storage.KindFeedback, StageFetch, "", FailureCause(err), err)
+			if !s.rateLimited(err) {
+				s.log.Warnw("archive: fetch failed", "user_id", s.userID, "skip", skip, "err", err)
+				metrics.IncrementAPIError("wb", "fetch_archive")
+			}
+			return err
+		}
+		if len(page) == 0 || !fn(page) || len(page) < archivePageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// RecordAudit appends ev to the audit trail. A storage error is only logged:
+// auditing must never fail the action it describes. The event is written
+// even if ctx is already cancelled, like the final answer batch.
+func RecordAudit(ctx context.Context, store storage.Store, ev storage.AuditEvent, log *zap.SugaredLogger) {
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+	defer cancel()
+	if err := store.RecordAudit(dbCtx, ev); err != nil {
+		log.Warnw("audit: record failed", "user_id", ev.UserID, "action", ev.Action, "err", err)
+		metrics.IncrementDatabaseError("record_audit")
+	}
+}
+
+// audit records an event of the service's user; actor 0 means the bot.
+func (s *Service) audit(ctx context.Context, actor int64, action, details string) {
+	RecordAudit(ctx, s.store, storage.AuditEvent{UserID: s.userID, Actor: actor, Action: action, Details: details}, s.log)
+}
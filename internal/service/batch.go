@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// saveBatchSize is how many posted answers are buffered before they are
+// written with one SaveBatch call. Flushing during the cycle bounds what a
+// crash can lose: WB no longer lists answered reviews, so the loss is only
+// the local record, not a duplicate reply.
+const saveBatchSize = 50
+
+// flushTimeout bounds the final flush, which runs even when the cycle
+// context is already cancelled so that posted answers are still recorded.
+const flushTimeout = 5 * time.Second
+
+// answeredIDs looks up which of ids were answered before. On error it
+// records a check failure and returns nil.
+func (s *Service) answeredIDs(ctx context.Context, kind string, ids []string) map[string]bool {
+	if len(ids) == 0 {
+		return map[string]bool{}
+	}
+	found, err := s.store.ExistsBatch(ctx, s.userID, ids)
+	if err != nil {
+		s.log.Warnw("cycle: storage exists batch err", "user_id", s.userID, "kind", kind, "ids", len(ids), "err", err)
+		s.recordFailure(ctx, kind, StageCheck, "", CauseStorage, err)
+		metrics.IncrementDatabaseError("exists")
+		return nil
+	}
+	return found
+}
+
+// answerBatch buffers records of posted answers and stores them with
+// SaveBatch. Callers count an answer as soon as WB accepts it: a record that
+// fails to save is a lost local entry, not an unanswered review.
+type answerBatch struct {
+	s     *Service
+	kind  string
+	recs  []storage.AnswerRecord
+	saved int // records stored so far
+}
+
+func (s *Service) newAnswerBatch(kind string) *answerBatch {
+	return &answerBatch{s: s, kind: kind, recs: make([]storage.AnswerRecord, 0, saveBatchSize)}
+}
+
+// feedbackRecord describes the answer d posted to fb for storage.
+func feedbackRecord(fb wbapi.Feedback, d Decision) storage.AnswerRecord {
+	rec := storage.AnswerRecord{
+		FeedbackID:      fb.ID,
+		Rating:          fb.ProductValuation,
+		SubjectName:     fb.SubjectName,
+		NmID:            fb.ProductDetails.NmID,
+		ProductName:     fb.ProductDetails.ProductName,
+		SupplierArticle: fb.ProductDetails.SupplierArticle,
+		Source:          d.Source,
+		TemplateVersion: d.Version,
+		ReplyText:       d.Text,
+	}
+	if !fb.CreatedDate.IsZero() {
+		rec.ResponseTime = time.Since(fb.CreatedDate)
+	}
+	return rec
+}
+
+// add queues rec, flushing the buffer once it is full.
+func (b *answerBatch) add(ctx context.Context, rec storage.AnswerRecord) {
+	b.recs = append(b.recs, rec)
+	if len(b.recs) >= saveBatchSize {
+		b.flush(ctx)
+	}
+}
+
+// flush stores the buffered records. A failed batch is recorded as a save
+// failure for each of its items.
+func (b *answerBatch) flush(ctx context.Context) {
+	if len(b.recs) == 0 {
+		return
+	}
+	recs := b.recs
+	b.recs = b.recs[:0]
+
+	dbCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+	defer cancel()
+	if err := b.s.store.SaveBatch(dbCtx, b.s.userID, recs); err != nil {
+		b.s.log.Warnw("cycle: save batch failed", "user_id", b.s.userID, "kind", b.kind, "count", len(recs), "err", err)
+		for _, rec := range recs {
+			b.s.recordFailure(dbCtx, b.kind, StageSave, rec.FeedbackID, CauseStorage, err)
+		}
+		metrics.IncrementDatabaseError("save")
+		return
+	}
+	b.saved += len(recs)
+}
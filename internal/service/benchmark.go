@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// BenchmarkWindow is the period category averages are computed over;
+	// the rating trend compares it with the preceding window of equal length.
+	BenchmarkWindow = 30 * 24 * time.Hour
+	// BenchmarkMinUsers hides categories with fewer sellers so that
+	// published averages cannot be traced back to a single user.
+	BenchmarkMinUsers = 3
+)
+
+// RefreshBenchmarks recomputes anonymized per-category averages across all
+// opted-in users. Intended to be run nightly by scheduler.Daily.
+func RefreshBenchmarks(ctx context.Context, store storage.ConfigStore, log *zap.SugaredLogger) {
+	start := time.Now()
+	if err := store.RefreshCategoryBenchmarks(ctx, BenchmarkWindow, BenchmarkMinUsers); err != nil {
+		log.Errorw("benchmarks: refresh failed", "err", err)
+		return
+	}
+	log.Infow("benchmarks: refreshed", "duration", time.Since(start).String())
+}
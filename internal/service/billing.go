@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// ErrNoAccess is returned by AnswerNow and AnswerCustom when the trial is
+// over and access has not been paid for.
+var ErrNoAccess = errors.New("trial ended and access is not paid")
+
+// Plan describes paid access: a free trial that ends after TrialDays days or
+// TrialAnswers answers, whichever comes first, then paid periods.
+type Plan struct {
+	TrialDays    int
+	TrialAnswers int
+	PeriodDays   int   // access bought by one payment
+	Price        int64 // of one period, in kopecks
+	ReferralDays int   // paid access credited for each referred seller
+}
+
+// Access is a user's billing standing at a moment.
+type Access struct {
+	Active      bool      // answers may be posted
+	Paid        bool      // within a paid period
+	Until       time.Time // end of the paid period, or of the trial
+	AnswersLeft int       // trial answers left; math.MaxInt when paid
+}
+
+// Access evaluates sub at now.
+func (p Plan) Access(sub storage.Subscription, now time.Time) Access {
+	if now.Before(sub.PaidUntil) {
+		return Access{Active: true, Paid: true, Until: sub.PaidUntil, AnswersLeft: math.MaxInt}
+	}
+	a := Access{
+		Until:       sub.TrialStartedAt.AddDate(0, 0, p.TrialDays),
+		AnswersLeft: max(p.TrialAnswers-sub.TrialAnswers, 0),
+	}
+	a.Active = now.Before(a.Until) && a.AnswersLeft > 0
+	if !a.Active {
+		a.AnswersLeft = 0
+	}
+	return a
+}
+
+// billing gates answering on the user's subscription, which lives in
+// storage so that payments take effect without restarting the service.
+type billing struct {
+	plan   Plan
+	notify func(a Access) // called once each time access runs out; optional
+
+	trial atomic.Bool // last check found the user on the trial
+	bound atomic.Bool // the trial, not the daily limit, capped answersLeft
+
+	mu       sync.Mutex
+	last     Access // result of the latest check
+	notified bool
+}
+
+// WithBilling stops answering once the user's trial is over until access is
+// paid for. Answers posted during the trial are counted against
+// plan.TrialAnswers. notify is called once each time access runs out.
+func WithBilling(plan Plan, notify func(a Access)) Option {
+	return func(s *Service) {
+		s.billing = &billing{plan: plan, notify: notify}
+	}
+}
+
+// access returns the user's current access. Without billing, or if the
+// subscription cannot be read, answering is not restricted.
+func (s *Service) access(ctx context.Context) Access {
+	unlimited := Access{Active: true, AnswersLeft: math.MaxInt}
+	if s.billing == nil {
+		return unlimited
+	}
+	sub, err := s.store.GetSubscription(ctx, s.userID)
+	if err != nil {
+		s.log.Warnw("billing: failed to read subscription", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("get_subscription")
+		return unlimited
+	}
+	a := s.billing.plan.Access(sub, time.Now())
+	s.billing.trial.Store(!a.Paid)
+	s.billing.mu.Lock()
+	s.billing.last = a
+	if a.Active {
+		s.billing.notified = false
+	}
+	s.billing.mu.Unlock()
+	return a
+}
+
+// accessAllowed reports whether the user may be answered now and notifies
+// the user once when access has run out.
+func (s *Service) accessAllowed(ctx context.Context) bool {
+	a := s.access(ctx)
+	if !a.Active {
+		s.accessEnded(a)
+	}
+	return a.Active
+}
+
+// accessEnded logs the end of access and notifies the user once.
+func (s *Service) accessEnded(a Access) {
+	s.billing.mu.Lock()
+	a.Active, a.AnswersLeft = false, 0 // the trial may have run out after the check
+	first := !s.billing.notified
+	s.billing.notified = true
+	s.billing.mu.Unlock()
+
+	if !first {
+		return
+	}
+	s.log.Infow("billing: access ended", "user_id", s.userID, "until", a.Until.Format(time.RFC3339))
+	if s.billing.notify != nil {
+		s.billing.notify(a)
+	}
+}
+
+// countTrialAnswer counts a posted answer against the trial.
+func (s *Service) countTrialAnswer(ctx context.Context) {
+	if s.billing == nil || !s.billing.trial.Load() {
+		return
+	}
+	if err := s.store.AddTrialAnswers(ctx, s.userID, 1); err != nil {
+		s.log.Warnw("billing: failed to count trial answer", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("trial_answers")
+	}
+}
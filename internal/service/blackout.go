@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Blackout is a period during which no cycles run, e.g. scheduled WB
+// maintenance. It is either a daily window (Daily != nil) or a one-off
+// interval [From, To).
+type Blackout struct {
+	Daily    *BusinessHours
+	From, To time.Time
+}
+
+// ParseBlackout parses an admin-supplied window in loc:
+//
+//	"02:00-04:00"                        every day
+//	"2025-10-20 01:00 2025-10-20 05:00"  once (a " - " between dates is allowed)
+func ParseBlackout(spec string, loc *time.Location) (*Blackout, error) {
+	fields := strings.Fields(strings.ReplaceAll(spec, " - ", " "))
+	switch len(fields) {
+	case 1, 2:
+		var start, end string
+		if len(fields) == 2 {
+			start, end = fields[0], fields[1]
+		} else {
+			var ok bool
+			if start, end, ok = strings.Cut(fields[0], "-"); !ok {
+				return nil, fmt.Errorf("expected HH:MM-HH:MM")
+			}
+		}
+		s, err := parseClock(start)
+		if err != nil {
+			return nil, err
+		}
+		e, err := parseClock(end)
+		if err != nil {
+			return nil, err
+		}
+		if s == e {
+			return nil, fmt.Errorf("window start and end must differ")
+		}
+		return &Blackout{Daily: &BusinessHours{Loc: loc, Start: s, End: e}}, nil
+	case 4:
+		from, err := time.ParseInLocation("2006-01-02 15:04", fields[0]+" "+fields[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start, expected YYYY-MM-DD HH:MM")
+		}
+		to, err := time.ParseInLocation("2006-01-02 15:04", fields[2]+" "+fields[3], loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end, expected YYYY-MM-DD HH:MM")
+		}
+		if !to.After(from) {
+			return nil, fmt.Errorf("window must end after it starts")
+		}
+		return &Blackout{From: from, To: to}, nil
+	}
+	return nil, fmt.Errorf("expected HH:MM-HH:MM or YYYY-MM-DD HH:MM YYYY-MM-DD HH:MM")
+}
+
+// Active reports whether now falls within the window and, if so, when the
+// window ends.
+func (b *Blackout) Active(now time.Time) (bool, time.Time) {
+	if b.Daily == nil {
+		if !now.Before(b.From) && now.Before(b.To) {
+			return true, b.To
+		}
+		return false, time.Time{}
+	}
+	if !b.Daily.Contains(now) {
+		return false, time.Time{}
+	}
+	local := now.In(b.Daily.Loc)
+	y, m, d := local.Date()
+	end := time.Date(y, m, d, 0, 0, 0, 0, b.Daily.Loc).Add(b.Daily.End)
+	if !end.After(local) {
+		end = time.Date(y, m, d+1, 0, 0, 0, 0, b.Daily.Loc).Add(b.Daily.End)
+	}
+	return true, end
+}
+
+// Expired reports whether a one-off window is over. Daily windows never expire.
+func (b *Blackout) Expired(now time.Time) bool {
+	return b.Daily == nil && !now.Before(b.To)
+}
+
+// String renders the window for the admin.
+func (b *Blackout) String() string {
+	if b.Daily != nil {
+		return "ежедневно " + b.Daily.String()
+	}
+	return fmt.Sprintf("%s – %s (%s)", b.From.Format("02.01.2006 15:04"), b.To.Format("02.01.2006 15:04"), b.From.Location())
+}
+
+// BlackoutSet is the current list of windows shared by all users' cycles.
+// The zero value is an empty set ready for use.
+type BlackoutSet struct {
+	mu      sync.RWMutex
+	windows []*Blackout
+}
+
+// Set replaces the windows.
+func (s *BlackoutSet) Set(windows []*Blackout) {
+	s.mu.Lock()
+	s.windows = windows
+	s.mu.Unlock()
+}
+
+// Active reports whether now falls within any window and when the latest
+// of the active windows ends.
+func (s *BlackoutSet) Active(now time.Time) (bool, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var active bool
+	var until time.Time
+	for _, w := range s.windows {
+		if ok, end := w.Active(now); ok {
+			active = true
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+	return active, until
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"feedback_bot/internal/wbapi"
+)
+
+// DefaultAuthFailures is how many consecutive 401/403 responses trip the
+// breaker of WithAuthBreaker when no threshold is given.
+const DefaultAuthFailures = 3
+
+// authBreaker stops a service from using a token WB keeps rejecting.
+type authBreaker struct {
+	threshold int32
+	trip      func(err error) // optional
+	failures  atomic.Int32
+	open      atomic.Bool
+}
+
+// WithAuthBreaker stops using a token that WB rejects: after threshold
+// consecutive 401 or 403 responses to fetching or answering reviews, cycles
+// are skipped and trip is called once with the last error. A threshold <= 0
+// selects DefaultAuthFailures. A new service is needed to use the token again.
+func WithAuthBreaker(threshold int, trip func(err error)) Option {
+	if threshold <= 0 {
+		threshold = DefaultAuthFailures
+	}
+	return func(s *Service) {
+		s.breaker = &authBreaker{threshold: int32(threshold), trip: trip}
+	}
+}
+
+// TokenRejected reports whether the auth breaker has tripped.
+func (s *Service) TokenRejected() bool {
+	return s.breaker != nil && s.breaker.open.Load()
+}
+
+// authResult feeds the breaker with the result of a WB call and reports
+// whether the token must not be used any more. Errors other than 401 and
+// 403 say nothing about the token and leave the count as is.
+func (s *Service) authResult(err error) bool {
+	b := s.breaker
+	if b == nil {
+		return false
+	}
+	switch {
+	case err == nil:
+		b.failures.Store(0)
+	case errors.Is(err, wbapi.ErrUnauthorized), errors.Is(err, wbapi.ErrForbidden):
+		if b.failures.Add(1) >= b.threshold && b.open.CompareAndSwap(false, true) {
+			s.log.Warnw("token rejected by WB, stopping", "user_id", s.userID, "failures", b.failures.Load(), "err", err)
+			if b.trip != nil {
+				b.trip(err)
+			}
+		}
+	}
+	return b.open.Load()
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// Errors returned by AnswerNow before anything is sent to WB; see also
+// ErrNoAccess.
+var (
+	ErrAlreadyAnswered = errors.New("feedback already answered")
+	ErrDailyLimit      = errors.New("daily answer limit reached")
+)
+
+// Unanswered returns one page of unanswered feedbacks, newest first, for
+// browsing them in Telegram.
+func (s *Service) Unanswered(ctx context.Context, take, skip int) (wbapi.FeedbackPage, error) {
+	if left := s.CooldownLeft(); left > 0 {
+		return wbapi.FeedbackPage{}, fmt.Errorf("%w: cooldown %s", wbapi.ErrRateLimited, left.Round(time.Second))
+	}
+	page, err := s.client.FetchUnansweredPage(ctx, take, skip)
+	if err != nil && !s.rateLimited(err) {
+		s.log.Warnw("browse: fetch failed", "user_id", s.userID, "err", err)
+		metrics.IncrementAPIError("wb", "fetch")
+	}
+	return page, err
+}
+
+// ErrNoReviews is returned by Sample when the seller has no reviews yet.
+var ErrNoReviews = errors.New("no reviews")
+
+// Sample returns a real review to preview replies on: the newest unanswered
+// one or, if all are answered, the newest answered one, reported by
+// answered. Nothing is posted.
+func (s *Service) Sample(ctx context.Context) (fb wbapi.Feedback, answered bool, err error) {
+	if left := s.CooldownLeft(); left > 0 {
+		return fb, false, fmt.Errorf("%w: cooldown %s", wbapi.ErrRateLimited, left.Round(time.Second))
+	}
+	fbs, err := s.client.FetchUnanswered(ctx, 1, 0)
+	if err == nil && len(fbs) == 0 {
+		answered = true
+		fbs, err = s.client.FetchAnswered(ctx, 1, 0)
+	}
+	if err != nil {
+		if !s.rateLimited(err) {
+			s.log.Warnw("preview: fetch failed", "user_id", s.userID, "err", err)
+			metrics.IncrementAPIError("wb", "fetch")
+		}
+		return fb, false, err
+	}
+	if len(fbs) == 0 {
+		return fb, false, ErrNoReviews
+	}
+	return fbs[0], answered, nil
+}
+
+// AnswerNow answers fb immediately on the user's request, choosing the reply
+// the way HandleCycle would. Exclusions and retry backoff are ignored since
+// the user picked the review explicitly; answers already given by the bot
+// and the daily limit are respected.
+func (s *Service) AnswerNow(ctx context.Context, fb wbapi.Feedback) (Decision, error) {
+	return s.answerOnRequest(ctx, fb, s.templates.Decide(fb, time.Now()))
+}
+
+// AnswerCustom posts text written by the user as the answer to fb and
+// records it as SourceManual. The same checks as in AnswerNow apply.
+func (s *Service) AnswerCustom(ctx context.Context, fb wbapi.Feedback, text string) error {
+	_, err := s.answerOnRequest(ctx, fb, Decision{Text: text, Source: SourceManual})
+	return err
+}
+
+// answerOnRequest posts decision as the answer to fb and records it.
+func (s *Service) answerOnRequest(ctx context.Context, fb wbapi.Feedback, decision Decision) (Decision, error) {
+	if left := s.CooldownLeft(); left > 0 {
+		return Decision{}, fmt.Errorf("%w: cooldown %s", wbapi.ErrRateLimited, left.Round(time.Second))
+	}
+	done := s.answeredIDs(ctx, storage.KindFeedback, []string{fb.ID})
+	if done == nil {
+		return Decision{}, fmt.Errorf("answered lookup failed")
+	}
+	if done[fb.ID] {
+		return Decision{}, ErrAlreadyAnswered
+	}
+	if !s.access(ctx).Active {
+		return Decision{}, ErrNoAccess
+	}
+	if s.answersLeft(ctx) <= 0 {
+		s.limitReached()
+		return Decision{}, ErrDailyLimit
+	}
+
+	if err := s.answerFeedback(ctx, fb.ID, decision.Text); err != nil {
+		s.recordFailure(ctx, storage.KindFeedback, StageAnswer, fb.ID, FailureCause(err), err)
+		if !s.rateLimited(err) {
+			s.log.Warnw("browse: answer failed", "user_id", s.userID, "id", fb.ID, "err", err)
+			metrics.IncrementAPIError("wb", "answer")
+		}
+		return Decision{}, err
+	}
+	retries := s.failedAnswers(ctx)
+	s.answerPosted(ctx, retries, fb.ID)
+	s.reportStuck(retries)
+	s.countAnswer(ctx)
+
+	batch := s.newAnswerBatch(storage.KindFeedback)
+	batch.add(ctx, feedbackRecord(fb, decision))
+	batch.flush(ctx)
+	metrics.IncrementProcessedFeedback(s.userID, "answered")
+	s.audit(ctx, s.userID, storage.AuditAnswerPosted, fmt.Sprintf("id=%s source=%s", fb.ID, decision.Source))
+	s.log.Infow("answered on request", "user_id", s.userID, "id", fb.ID, "source", decision.Source)
+	return decision, nil
+}
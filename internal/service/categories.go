@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// categoryScanSize is how many of the newest unanswered and of the newest
+// answered reviews Categories looks through.
+const categoryScanSize = 1000
+
+// Category is a WB product category (subjectId) found among the seller's
+// reviews.
+type Category struct {
+	SubjectID int64
+	Name      string
+	Reviews   int // among the reviews looked through
+}
+
+// Categories returns the product categories of the seller's newest reviews,
+// the most reviewed first, to choose which get their own replies. Nothing
+// is posted.
+func (s *Service) Categories(ctx context.Context) ([]Category, error) {
+	if left := s.CooldownLeft(); left > 0 {
+		return nil, fmt.Errorf("%w: cooldown %s", wbapi.ErrRateLimited, left.Round(time.Second))
+	}
+	fbs, err := s.client.FetchUnanswered(ctx, categoryScanSize, 0)
+	if err == nil {
+		var answered []wbapi.Feedback
+		answered, err = s.client.FetchAnswered(ctx, categoryScanSize, 0)
+		fbs = append(fbs, answered...)
+	}
+	if err != nil {
+		if !s.rateLimited(err) {
+			s.log.Warnw("categories: fetch failed", "user_id", s.userID, "err", err)
+			metrics.IncrementAPIError("wb", "fetch")
+		}
+		return nil, err
+	}
+
+	byID := make(map[int64]*Category)
+	var out []*Category
+	for _, fb := range fbs {
+		if fb.SubjectID == 0 {
+			continue
+		}
+		c := byID[fb.SubjectID]
+		if c == nil {
+			c = &Category{SubjectID: fb.SubjectID}
+			byID[fb.SubjectID] = c
+			out = append(out, c)
+		}
+		if c.Name == "" {
+			c.Name = fb.SubjectName
+		}
+		c.Reviews++
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Reviews > out[j].Reviews })
+	categories := make([]Category, len(out))
+	for i, c := range out {
+		categories[i] = *c
+	}
+	return categories, nil
+}
+
+// WithCategoryTemplates replaces the good and bad templates for the reviews
+// of the given product categories.
+func WithCategoryTemplates(list []storage.CategoryTemplate) Option {
+	return func(s *Service) {
+		s.templates.SetCategoryTemplates(list)
+	}
+}
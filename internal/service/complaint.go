@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// ComplaintModeration is how long WB moderation takes at most: a review
+// still published this long after the complaint was filed counts as a
+// rejected complaint.
+const ComplaintModeration = 7 * 24 * time.Hour
+
+const (
+	// complaintCheckInterval is how often a filed complaint is checked.
+	complaintCheckInterval = 6 * time.Hour
+	// complaintCheckBatch bounds the complaints checked per cycle, one WB
+	// request each.
+	complaintCheckBatch = 5
+)
+
+// complaintChecks tells when filed complaints are checked and given up on.
+type complaintChecks struct {
+	every      time.Duration
+	moderation time.Duration
+}
+
+// WithComplaintChecks changes how often filed complaints are checked for
+// their outcome (every) and after how long one still under moderation counts
+// as rejected (moderation). Zero keeps complaintCheckInterval and
+// ComplaintModeration.
+func WithComplaintChecks(every, moderation time.Duration) Option {
+	return func(s *Service) {
+		if every > 0 {
+			s.complaints.every = every
+		}
+		if moderation > 0 {
+			s.complaints.moderation = moderation
+		}
+	}
+}
+
+// Complain files a complaint about fb with reason on WB and records it for
+// the history, as filed or, if WB refused it, as failed with the error,
+// which is also returned. A storage error is only logged: the complaint is
+// already on WB at that point.
+func Complain(ctx context.Context, client *wbapi.Client, store storage.Store, userID int64, fb wbapi.Feedback, reason wbapi.ComplaintReason, log *zap.SugaredLogger) error {
+	c := storage.Complaint{
+		FeedbackID: fb.ID,
+		ReasonID:   reason.ID,
+		Reason:     reason.Text,
+		Rating:     fb.ProductValuation,
+		NmID:       fb.ProductDetails.NmID,
+		Status:     storage.ComplaintFiled,
+	}
+	err := client.ComplainFeedback(ctx, fb.ID, reason.ID)
+	if err != nil {
+		log.Warnw("complaint: wb err", "user_id", userID, "id", fb.ID, "reason", reason.ID, "err", err)
+		metrics.IncrementAPIError("wb", "complaint")
+		c.Status, c.Error = storage.ComplaintFailed, err.Error()
+	} else {
+		RecordAudit(ctx, store, storage.AuditEvent{UserID: userID, Actor: userID, Action: storage.AuditComplaintFiled,
+			Details: fmt.Sprintf("id=%s reason=%d", fb.ID, reason.ID)}, log)
+		log.Infow("complaint filed", "user_id", userID, "id", fb.ID, "reason", reason.ID)
+	}
+	if serr := store.SaveComplaint(ctx, userID, c); serr != nil {
+		log.Warnw("complaint: storage err", "user_id", userID, "id", fb.ID, "err", serr)
+		metrics.IncrementDatabaseError("save_complaint")
+	}
+	return err
+}
+
+// checkComplaints infers the outcome of filed complaints, since WB does not
+// report it: a review WB no longer returns was removed by moderation, and
+// the complaint was accepted; a review still published ComplaintModeration
+// after the complaint means it was rejected. A review the buyer deleted
+// themselves counts as accepted too. Complaints not decided yet are marked
+// checked and come up again after complaintCheckInterval.
+func (s *Service) checkComplaints(ctx context.Context) {
+	if ctx.Err() != nil || s.CooldownLeft() > 0 || !s.client.Supports(wbapi.EndpointFeedback) {
+		return
+	}
+	now := time.Now()
+	pending, err := s.store.PendingComplaints(ctx, s.userID, now.Add(-s.complaints.every), complaintCheckBatch)
+	if err != nil {
+		s.log.Warnw("complaints: failed to load pending", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("pending_complaints")
+		return
+	}
+	for _, c := range pending {
+		fb, err := s.client.FetchFeedback(ctx, c.FeedbackID)
+		if err != nil {
+			if s.rateLimited(err) {
+				return
+			}
+			s.log.Warnw("complaints: fetch failed", "user_id", s.userID, "id", c.FeedbackID, "err", err)
+			metrics.IncrementAPIError("wb", "fetch_feedback")
+			return
+		}
+		status := storage.ComplaintFiled
+		switch {
+		case fb == nil:
+			status = storage.ComplaintAccepted
+		case now.Sub(c.CreatedAt) >= s.complaints.moderation:
+			status = storage.ComplaintRejected
+		}
+		if status != storage.ComplaintFiled {
+			s.log.Infow("complaints: moderated", "user_id", s.userID, "id", c.FeedbackID, "status", status)
+		}
+		if err := s.store.SetComplaintStatus(ctx, s.userID, c.FeedbackID, status); err != nil {
+			s.log.Warnw("complaints: failed to save status", "user_id", s.userID, "id", c.FeedbackID, "err", err)
+			metrics.IncrementDatabaseError("set_complaint_status")
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"feedback_bot/internal/content"
+)
+
+// contentRefused reports whether err is the content check refusing a text.
+// The same text fails the same way on every attempt, so such answers are
+// not retried: the review waits for the user to change the template.
+func contentRefused(err error) bool {
+	var cerr *content.Error
+	var lerr *content.LengthError
+	return errors.As(err, &cerr) || errors.As(err, &lerr)
+}
+
+// refusals remembers the reviews whose answer the content check refused, so
+// that each is reported once rather than on every cycle. The set lives as
+// long as the service, which is rebuilt when the user changes a template.
+type refusals struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+// first records id and reports whether it was not refused before.
+func (r *refusals) first(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ids[id] {
+		return false
+	}
+	if r.ids == nil {
+		r.ids = make(map[string]bool)
+	}
+	r.ids[id] = true
+	return true
+}
+
+// refuseAnswer handles an answer to id the content check refused: the first
+// time it records the failure for the user's error screen and reports true,
+// later it only reports false. No retry is scheduled.
+func (s *Service) refuseAnswer(ctx context.Context, kind, id string, err error) bool {
+	if !s.refused.first(kind + ":" + id) {
+		return false
+	}
+	s.recordFailure(ctx, kind, StageAnswer, id, FailureCause(err), err)
+	s.noteError(err)
+	s.log.Warnw("cycle: answer refused by the content check", "user_id", s.userID, "kind", kind, "id", id, "err", err)
+	return true
+}
+
+// answerFeedback posts text as the answer to feedback id. A text breaking
+// the WB content rules (length, links, contacts, other platforms) is not
+// sent, since WB would reject it; see contentRefused.
+func (s *Service) answerFeedback(ctx context.Context, id, text string) error {
+	if err := content.Validate(text); err != nil {
+		return err
+	}
+	return s.client.AnswerFeedback(ctx, id, text)
+}
+
+// answerQuestion is answerFeedback for a product question.
+func (s *Service) answerQuestion(ctx context.Context, id, text string) error {
+	if err := content.Validate(text); err != nil {
+		return err
+	}
+	return s.client.AnswerQuestion(ctx, id, text)
+}
@@ -2,6 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"feedback_bot/internal/storage"
@@ -16,55 +21,386 @@ import (
 // except for IO operations delegated to thread‑safe dependencies.
 
 type Service struct {
-	userID    int64 // user ID for multi-user support
-	client    *wbapi.Client
-	store     storage.Store
-	templates *TemplateEngine
-	log       *zap.SugaredLogger
-	take      int // maximum items per fetch (<=5000 for WB)
+	userID      int64 // user ID for multi-user support
+	client      *wbapi.Client
+	store       storage.Store
+	templates   *TemplateEngine
+	question    string                    // reply for product questions; empty disables them
+	excludedNm  map[int64]struct{}        // articles that must not be answered
+	excludedIDs map[string]struct{}       // individual reviews that must not be answered
+	backlog     atomic.Int64              // reviews left unanswered by the latest cycle
+	reschedule  func(after time.Duration) // asks the scheduler for an earlier run; optional
+	log         *zap.SugaredLogger
+	take        int            // maximum items per fetch (<=5000 for WB)
+	limit       *dailyLimit    // nil means unlimited
+	billing     *billing       // nil means answering is free
+	outage      *OutageTracker // shared by all users; nil disables outage detection
+	breaker     *authBreaker   // nil keeps using the token whatever WB answers
+	humanize    bool           // random pause between answers
+	window      *BusinessHours // answers are posted only within it; nil means any time
+	minAge      time.Duration  // reviews younger than this wait for a later cycle
+	tracker     *reviewTracker // nil leaves answered reviews alone
+
+	escalate       func(fb wbapi.Feedback, reply string) // gets answered 1–2★ reviews; optional
+	duplicateShare int                                   // percent of each burst of identical reviews answered; 0 answers all
+	drafter        Drafter                               // nil answers negative reviews without approval
+	deliverDraft   func(fb wbapi.Feedback, draft storage.Draft)
+	logNegative    bool // record negative reviews for the daily report
+	refused        refusals
+	complaints     complaintChecks
+
+	cooldownMu    sync.Mutex
+	cooldownUntil time.Time // WB rate limit: no requests until then
+	lastErr       error     // first WB error of the latest cycle; guarded by cooldownMu
+}
+
+// Option mutates the service during construction, mirroring wbapi options.
+type Option func(*Service)
+
+// WithBusinessHours makes the template engine use offHoursTpl for answers
+// generated outside hours. No-op if hours is nil or the text is empty.
+func WithBusinessHours(hours *BusinessHours, offHoursTpl string) Option {
+	return func(s *Service) {
+		s.templates.SetBusinessHours(hours, offHoursTpl)
+	}
+}
+
+// WithAnswerWindow holds reviews and questions outside hours: cycles are
+// skipped and the backlog is answered once the window opens. It takes
+// precedence over WithBusinessHours, whose reply would never be used.
+func WithAnswerWindow(hours *BusinessHours) Option {
+	return func(s *Service) {
+		s.window = hours
+	}
+}
+
+// WindowClosed reports whether now is outside the answer window and, if so,
+// when it opens next.
+func (s *Service) WindowClosed(now time.Time) (opens time.Time, closed bool) {
+	if s.window == nil || s.window.Contains(now) {
+		return time.Time{}, false
+	}
+	return s.window.NextStart(now), true
+}
+
+// WithTemplateVariants adds reply texts rotated at random with the bad and
+// good templates.
+func WithTemplateVariants(bad, good []string) Option {
+	return func(s *Service) {
+		s.templates.SetVariants(bad, good)
+	}
+}
+
+// WithSignatures appends one of texts, picked at random, to every reply.
+func WithSignatures(texts []string) Option {
+	return func(s *Service) {
+		s.templates.SetSignatures(texts)
+	}
+}
+
+// WithSentiment routes positive reviews with complaint text to the bad
+// template using the given analyzer (KeywordAnalyzer if nil).
+func WithSentiment(a SentimentAnalyzer) Option {
+	if a == nil {
+		a = KeywordAnalyzer{}
+	}
+	return func(s *Service) {
+		s.templates.SetSentiment(a)
+	}
+}
+
+// WithMediaTemplate thanks buyers who attach photos or video to a positive
+// review with the given text instead of the good template.
+func WithMediaTemplate(text string) Option {
+	return func(s *Service) {
+		s.templates.SetMediaTemplate(text)
+	}
+}
+
+// WithQuestionTemplate enables answering product questions with the given text.
+func WithQuestionTemplate(text string) Option {
+	return func(s *Service) {
+		s.question = strings.TrimSpace(text)
+	}
+}
+
+// Bounds for the pause after WB answers 429.
+const (
+	defaultRateLimitCooldown = time.Minute
+	maxRateLimitCooldown     = 10 * time.Minute
+)
+
+// WithReschedule registers fn to be called with the cooldown when a cycle is
+// cut short by WB rate limiting, so the caller can run the next cycle sooner.
+func WithReschedule(fn func(after time.Duration)) Option {
+	return func(s *Service) {
+		s.reschedule = fn
+	}
+}
+
+// rateLimited starts a cooldown if err is a WB 429 and reports whether it was.
+func (s *Service) rateLimited(err error) bool {
+	if !errors.Is(err, wbapi.ErrRateLimited) {
+		return false
+	}
+	d := wbapi.RetryAfter(err)
+	if d <= 0 {
+		d = defaultRateLimitCooldown
+	}
+	if d > maxRateLimitCooldown {
+		d = maxRateLimitCooldown
+	}
+	s.noteError(err)
+	s.cooldownMu.Lock()
+	s.cooldownUntil = time.Now().Add(d)
+	s.cooldownMu.Unlock()
+
+	s.log.Warnw("cycle: rate limited by WB, backing off", "user_id", s.userID, "cooldown", d.String())
+	metrics.IncrementAPIError("wb", "rate_limited")
+	if s.reschedule != nil {
+		s.reschedule(d)
+	}
+	return true
+}
+
+// CooldownLeft returns the remaining WB rate-limit cooldown, 0 if none.
+func (s *Service) CooldownLeft() time.Duration {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	if d := time.Until(s.cooldownUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// noteError remembers err as the cause of a failed cycle unless an earlier
+// error was already recorded in this cycle.
+func (s *Service) noteError(err error) {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	if s.lastErr == nil {
+		s.lastErr = err
+	}
+}
+
+// LastError returns the first WB API error of the most recent cycle, or nil
+// if it ran cleanly. Test it with errors.Is against the wbapi sentinels
+// (wbapi.ErrUnauthorized, wbapi.ErrRateLimited, ...).
+func (s *Service) LastError() error {
+	s.cooldownMu.Lock()
+	defer s.cooldownMu.Unlock()
+	return s.lastErr
+}
+
+// Backlog returns how many fetched reviews the latest cycle left unanswered
+// (daily limit, WB errors, shutdown). Excluded and already answered reviews
+// are not counted. A cycle that fetches no reviews leaves the value as is.
+func (s *Service) Backlog() int64 {
+	return s.backlog.Load()
+}
+
+// SetRateLimit changes the WB request rate of the service's client; see
+// wbapi.WithRateLimit. A running cycle picks it up with its next request.
+func (s *Service) SetRateLimit(rps, burst int) {
+	s.client.SetRateLimit(rps, burst)
+}
+
+// WithExclusions skips reviews for the given articles (nmId) and review IDs.
+// Skipped reviews are not stored, so removing an exclusion lets the next
+// cycle answer them.
+func WithExclusions(nmIDs []int64, feedbackIDs []string) Option {
+	return func(s *Service) {
+		s.excludedNm = make(map[int64]struct{}, len(nmIDs))
+		for _, id := range nmIDs {
+			s.excludedNm[id] = struct{}{}
+		}
+		s.excludedIDs = make(map[string]struct{}, len(feedbackIDs))
+		for _, id := range feedbackIDs {
+			s.excludedIDs[id] = struct{}{}
+		}
+	}
+}
+
+// excluded reports whether the user asked not to answer fb.
+func (s *Service) excluded(fb wbapi.Feedback) bool {
+	if _, ok := s.excludedIDs[fb.ID]; ok {
+		return true
+	}
+	if fb.ProductDetails.NmID == 0 {
+		return false
+	}
+	_, ok := s.excludedNm[fb.ProductDetails.NmID]
+	return ok
 }
 
 // New constructs a Service instance. `take` defines the slice size for the
 // API call; set to 5000 for maximal coverage (WB limit).
-func New(userID int64, client *wbapi.Client, store storage.Store, badTpl, goodTpl string, logger *zap.SugaredLogger, take int) *Service {
+func New(userID int64, client *wbapi.Client, store storage.Store, badTpl, goodTpl string, logger *zap.SugaredLogger, take int, opts ...Option) *Service {
 	if take <= 0 || take > 5000 {
 		take = 5000
 	}
 	if logger == nil {
 		logger = zap.NewNop().Sugar()
 	}
-	return &Service{
-		userID:    userID,
-		client:    client,
-		store:     store,
-		templates: NewTemplateEngine(badTpl, goodTpl),
-		log:       logger,
-		take:      take,
+	s := &Service{
+		userID:     userID,
+		client:     client,
+		store:      store,
+		templates:  NewTemplateEngine(badTpl, goodTpl),
+		log:        logger,
+		take:       take,
+		complaints: complaintChecks{every: complaintCheckInterval, moderation: ComplaintModeration},
+	}
+	for _, o := range opts {
+		o(s)
 	}
+	return s
 }
 
 // HandleCycle performs a single polling cycle:
-//  1. Fetch unanswered reviews from Wildberries API.
-//  2. For each review not yet processed locally:
-//     – choose reply template based on rating
-//     – POST answer
+//  0. Skip the cycle outside the answer window, if one is set, while WB is
+//     down (see OutageTracker) and once WB has rejected the token (see
+//     WithAuthBreaker).
+//  1. Fetch unanswered reviews from Wildberries API and record the negative
+//     ones if asked to (see WithNegativeLog).
+//  2. For each review not yet processed locally whose retry backoff is over,
+//     that is older than the answer delay (see WithAnswerDelay), is not a
+//     duplicate left unanswered (see WithDuplicateShare) and does not wait
+//     for the user's approval (see WithDrafts):
+//     – choose reply template based on rating (and business hours)
+//     – POST answer; on failure schedule a retry (see RetryDelay)
 //     – persist ID to storage (idempotent)
+//  3. If a question template is configured, do the same for product questions.
 //
 // All errors are logged; the function never panics.
 func (s *Service) HandleCycle(ctx context.Context) {
 	start := time.Now()
+	if left := s.CooldownLeft(); left > 0 {
+		s.log.Infow("cycle: skipped, rate limit cooldown", "user_id", s.userID, "left", left.String())
+		return
+	}
+	if s.TokenRejected() {
+		s.log.Infow("cycle: skipped, token rejected by WB", "user_id", s.userID)
+		// Still counted as failing, so the user stays on the admin error list.
+		s.recordCycle(ctx, storage.CycleResult{Cause: CauseUnauthorized, Message: "cycle skipped: token rejected by WB"})
+		return
+	}
+	if opens, closed := s.WindowClosed(start); closed {
+		s.log.Infow("cycle: skipped, outside working hours", "user_id", s.userID, "opens", opens.Format(time.RFC3339))
+		return
+	}
+	if !s.accessAllowed(ctx) {
+		s.log.Infow("cycle: skipped, trial over and access not paid", "user_id", s.userID)
+		return
+	}
+	allowed, probe := s.outage.allow(start)
+	if !allowed {
+		s.log.Debugw("cycle: skipped, WB is down", "user_id", s.userID)
+		return
+	}
+	s.cooldownMu.Lock()
+	s.lastErr = nil
+	s.cooldownMu.Unlock()
 	s.log.Debug("cycle: fetching reviews")
 
-	feedbacks, err := s.client.FetchUnanswered(ctx, s.take, 0)
+	var answered, skipped, excluded, duplicates, drafts, deferred, young, failed, calls int
+	defer func() { metrics.RecordCycle(s.userID, answered) }()
+	var result storage.CycleResult // Cause and Message of the last error
+	defer func() {
+		result.Answered, result.Failed = answered, failed
+		s.recordCycle(ctx, result)
+	}()
+
+	if err := s.client.EnsureCapabilities(ctx); err != nil {
+		s.log.Warnw("cycle: capability detection failed, will retry", "user_id", s.userID, "err", err)
+	}
+
+	// Runs last, after the questions below.
+	retries := s.failedAnswers(ctx)
+	defer s.reportStuck(retries)
+
+	if s.question != "" && s.client.Supports(wbapi.EndpointQuestions) {
+		defer s.handleQuestions(ctx, retries)
+	}
+
+	if !s.client.Supports(wbapi.EndpointFeedbacks) {
+		s.log.Debugw("cycle: feedbacks not available to the token, skipped", "user_id", s.userID)
+		s.outage.skip(probe)
+		return
+	}
+	page, err := s.client.FetchUnansweredPage(ctx, s.take, 0)
+	s.outage.observe(err, probe)
+	s.authResult(err)
 	if err != nil {
+		s.recordFailure(ctx, storage.KindFeedback, StageFetch, "", FailureCause(err), err)
+		result.Cause, result.Message = FailureCause(err), err.Error()
+		if s.rateLimited(err) {
+			return
+		}
 		s.log.Errorw("cycle: fetch failed", "err", err)
+		s.noteError(err)
 		metrics.IncrementAPIError("wb", "fetch")
 		return
 	}
+	feedbacks := page.Feedbacks
+	metrics.SetFeedbacksPending(s.userID, page.CountUnanswered)
+	// Whichever way the cycle ends from here on.
+	defer func() { s.backlog.Store(int64(len(feedbacks) - answered - skipped)) }()
+	if s.logNegative {
+		s.logNegativeReviews(ctx, feedbacks)
+	}
+	// Run after the answers below are recorded, before the questions.
+	defer s.trackReviews(ctx)
+	defer s.checkComplaints(ctx)
 
-	var answered, skipped, failed int
-
+	pending := make([]wbapi.Feedback, 0, len(feedbacks))
+	ids := make([]string, 0, len(feedbacks))
 	for _, fb := range feedbacks {
+		if s.excluded(fb) {
+			s.log.Debugw("cycle: review excluded by user", "user_id", s.userID, "id", fb.ID, "nm_id", fb.ProductDetails.NmID)
+			skipped++
+			excluded++
+			continue
+		}
+		if s.tooYoung(fb, start) {
+			// Not skipped: the review stays in the backlog until it is old enough.
+			young++
+			continue
+		}
+		pending = append(pending, fb)
+		ids = append(ids, fb.ID)
+	}
+	done := s.answeredIDs(ctx, storage.KindFeedback, ids)
+	if done == nil {
+		// Without the lookup every review could be a duplicate; retry next cycle.
+		result.Cause, result.Message = CauseStorage, "answered reviews lookup failed"
+		return
+	}
+	if s.duplicateShare > 0 {
+		pending, duplicates = s.dropDuplicates(ctx, pending, done)
+		skipped += duplicates
+	}
+	if s.drafter != nil {
+		pending, drafts = s.holdForDrafts(ctx, pending, done)
+		skipped += drafts
+	}
+
+	left := s.answersLeft(ctx)
+	batch := s.newAnswerBatch(storage.KindFeedback)
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		// Answers posted before an early return are still recorded and
+		// counted.
+		batch.flush(ctx)
+		for i := 0; i < answered; i++ {
+			metrics.IncrementProcessedFeedback(s.userID, "answered")
+		}
+	}()
+
+	for _, fb := range pending {
 		select {
 		case <-ctx.Done():
 			s.log.Infow("cycle: context cancelled", "answered", answered, "skipped", skipped, "failed", failed)
@@ -72,35 +408,69 @@ func (s *Service) HandleCycle(ctx context.Context) {
 		default:
 		}
 
-		exists, err := s.store.Exists(ctx, s.userID, fb.ID)
-		if err != nil {
-			s.log.Warnw("cycle: storage exists err", "user_id", s.userID, "id", fb.ID, "err", err)
-			metrics.IncrementDatabaseError("exists")
+		if done[fb.ID] {
+			skipped++
 			continue
 		}
-		if exists {
-			skipped++
+
+		if !retryDue(retries, fb.ID, time.Now()) {
+			deferred++
 			continue
 		}
 
-		tpl := s.templates.Select(fb.ProductValuation)
-		if err := s.client.AnswerFeedback(ctx, fb.ID, tpl); err != nil {
+		if left <= 0 {
+			s.limitReached()
+			break
+		}
+
+		if calls > 0 && !s.humanPause(ctx) {
+			s.log.Infow("cycle: context cancelled", "answered", answered, "skipped", skipped, "failed", failed)
+			break
+		}
+		calls++
+
+		decision := s.templates.Decide(fb, time.Now())
+		if err := s.answerFeedback(ctx, fb.ID, decision.Text); err != nil {
+			if contentRefused(err) {
+				if s.refuseAnswer(ctx, storage.KindFeedback, fb.ID, err) {
+					result.Cause, result.Message = FailureCause(err), err.Error()
+					failed++
+				} else {
+					skipped++
+				}
+				continue
+			}
+			s.recordFailure(ctx, storage.KindFeedback, StageAnswer, fb.ID, FailureCause(err), err)
+			result.Cause, result.Message = FailureCause(err), err.Error()
+			if s.rateLimited(err) {
+				break
+			}
 			s.log.Warnw("cycle: answer failed", "user_id", s.userID, "id", fb.ID, "err", err)
+			s.noteError(err)
+			s.retryLater(ctx, retries, storage.KindFeedback, fb.ID, err)
 			metrics.IncrementAPIError("wb", "answer")
 			failed++
+			if s.authResult(err) {
+				break
+			}
 			continue
 		}
+		s.authResult(nil)
+		s.answerPosted(ctx, retries, fb.ID)
+		s.escalated(fb, decision.Text)
 
-		if err := s.store.Save(ctx, s.userID, fb.ID); err != nil {
-			s.log.Warnw("cycle: save failed", "user_id", s.userID, "id", fb.ID, "err", err)
-			metrics.IncrementDatabaseError("save")
-		} else {
-			answered++
-			metrics.IncrementProcessedFeedback(s.userID, "answered")
-		}
+		left--
+		answered++
+		s.countAnswer(ctx)
+		batch.add(ctx, feedbackRecord(fb, decision))
 	}
+	batch.flush(ctx)
+	completed = true
 
-	// Report skipped and failed
+	// Report answered, skipped and failed
+	for i := 0; i < answered; i++ {
+		metrics.IncrementProcessedFeedback(s.userID, "answered")
+	}
 	for i := 0; i < skipped; i++ {
 		metrics.IncrementProcessedFeedback(s.userID, "skipped")
 	}
@@ -108,11 +478,140 @@ func (s *Service) HandleCycle(ctx context.Context) {
 		metrics.IncrementProcessedFeedback(s.userID, "failed")
 	}
 
+	if answered > 0 || failed > 0 {
+		s.audit(ctx, 0, storage.AuditCycleRun, fmt.Sprintf("answered=%d skipped=%d failed=%d", answered, skipped, failed))
+	}
 	s.log.Infow("cycle complete",
 		"user_id", s.userID,
 		"duration", time.Since(start).String(),
 		"answered", answered,
+		"saved", batch.saved,
 		"skipped", skipped,
+		"excluded", excluded,
+		"duplicates", duplicates,
+		"drafts", drafts,
+		"deferred", deferred,
+		"young", young,
 		"failed", failed,
 		"total", len(feedbacks))
 }
+
+// handleQuestions answers unanswered product questions with the question
+// template. Mirrors the feedback loop in HandleCycle, sharing its retries.
+func (s *Service) handleQuestions(ctx context.Context, retries map[string]storage.FailedAnswer) {
+	if ctx.Err() != nil || s.CooldownLeft() > 0 {
+		return
+	}
+	start := time.Now()
+
+	questions, err := s.client.FetchUnansweredQuestions(ctx, s.take, 0)
+	if err != nil {
+		s.recordFailure(ctx, storage.KindQuestion, StageFetch, "", FailureCause(err), err)
+		if s.rateLimited(err) {
+			return
+		}
+		s.log.Errorw("cycle: fetch questions failed", "user_id", s.userID, "err", err)
+		s.noteError(err)
+		metrics.IncrementAPIError("wb", "fetch_questions")
+		return
+	}
+
+	ids := make([]string, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	done := s.answeredIDs(ctx, storage.KindQuestion, ids)
+	if done == nil {
+		return
+	}
+
+	var answered, skipped, deferred, failed, calls int
+	left := s.answersLeft(ctx)
+	batch := s.newAnswerBatch(storage.KindQuestion)
+	for _, q := range questions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if done[q.ID] {
+			skipped++
+			metrics.IncrementProcessedQuestion(s.userID, "skipped")
+			continue
+		}
+
+		if !retryDue(retries, q.ID, time.Now()) {
+			deferred++
+			continue
+		}
+
+		if left <= 0 {
+			s.limitReached()
+			break
+		}
+
+		if calls > 0 && !s.humanPause(ctx) {
+			break
+		}
+		calls++
+
+		text := s.templates.Sign(s.question)
+		if err := s.answerQuestion(ctx, q.ID, text); err != nil {
+			if contentRefused(err) {
+				if s.refuseAnswer(ctx, storage.KindQuestion, q.ID, err) {
+					metrics.IncrementProcessedQuestion(s.userID, "failed")
+					failed++
+				} else {
+					skipped++
+				}
+				continue
+			}
+			s.recordFailure(ctx, storage.KindQuestion, StageAnswer, q.ID, FailureCause(err), err)
+			if s.rateLimited(err) {
+				break
+			}
+			s.log.Warnw("cycle: answer question failed", "user_id", s.userID, "id", q.ID, "err", err)
+			s.noteError(err)
+			s.retryLater(ctx, retries, storage.KindQuestion, q.ID, err)
+			metrics.IncrementAPIError("wb", "answer_question")
+			metrics.IncrementProcessedQuestion(s.userID, "failed")
+			failed++
+			continue
+		}
+		s.answerPosted(ctx, retries, q.ID)
+
+		rec := storage.AnswerRecord{
+			FeedbackID:      q.ID,
+			Kind:            storage.KindQuestion,
+			Source:          SourceQuestion,
+			TemplateVersion: TemplateVersion(s.question),
+			ReplyText:       text,
+		}
+		left--
+		answered++
+		s.countAnswer(ctx)
+		if !q.CreatedDate.IsZero() {
+			rec.ResponseTime = time.Since(q.CreatedDate)
+		}
+		batch.add(ctx, rec)
+	}
+	batch.flush(ctx)
+	for i := 0; i < answered; i++ {
+		metrics.IncrementProcessedQuestion(s.userID, "answered")
+	}
+
+	s.log.Infow("questions cycle complete",
+		"user_id", s.userID,
+		"duration", time.Since(start).String(),
+		"answered", answered,
+		"saved", batch.saved,
+		"skipped", skipped,
+		"deferred", deferred,
+		"failed", failed,
+		"total", len(questions))
+}
+
+// Explain returns the decision the service would make for fb right now,
+// without calling WB or touching storage. Used by the bot's simulator.
+func (s *Service) Explain(fb wbapi.Feedback) Decision {
+	return s.templates.Decide(fb, time.Now())
+}
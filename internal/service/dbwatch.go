@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"feedback_bot/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Defaults for NewDBWatchdog and DBWatchdog.Run.
+const (
+	DefaultDBCheckInterval = 30 * time.Second
+	DefaultDBFailures      = 3 // consecutive failed pings before the database counts as down
+	dbPingTimeout          = 5 * time.Second
+)
+
+// DBWatchdog pings the database periodically. After threshold consecutive
+// failures the database is reported down; the first successful ping after
+// that reports it back. Single failures, e.g. a PostgreSQL restart, are
+// only logged.
+type DBWatchdog struct {
+	ping      func(ctx context.Context) error
+	threshold int
+	onChange  func(down bool, since time.Time, err error) // optional
+	log       *zap.SugaredLogger
+
+	mu       sync.Mutex
+	failures int       // consecutive failed pings
+	since    time.Time // first failed ping in a row; zero while the database answers
+	down     bool
+}
+
+// NewDBWatchdog returns a watchdog for ping, usually Store.Ping. threshold <=
+// 0 selects DefaultDBFailures. onChange is called when the database goes down,
+// with the first failure of the streak and the latest error, and when it
+// answers again, with the same start and a nil error.
+func NewDBWatchdog(ping func(ctx context.Context) error, threshold int, onChange func(down bool, since time.Time, err error), log *zap.SugaredLogger) *DBWatchdog {
+	if threshold <= 0 {
+		threshold = DefaultDBFailures
+	}
+	return &DBWatchdog{ping: ping, threshold: threshold, onChange: onChange, log: log}
+}
+
+// Run checks the database every interval (DefaultDBCheckInterval if <= 0)
+// until ctx is done, starting at once.
+func (w *DBWatchdog) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDBCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings the database once, updates the state and returns the ping error.
+func (w *DBWatchdog) Check(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	err := w.ping(pingCtx)
+	cancel()
+	if err != nil && ctx.Err() != nil {
+		// Shutting down, not a database problem
+		return err
+	}
+
+	w.mu.Lock()
+	var changed bool
+	since := w.since
+	if err == nil {
+		changed = w.down
+		w.failures, w.since, w.down = 0, time.Time{}, false
+	} else {
+		w.failures++
+		if w.failures == 1 {
+			w.since = time.Now()
+			since = w.since
+		}
+		changed = !w.down && w.failures >= w.threshold
+		w.down = w.down || changed
+	}
+	failures, down := w.failures, w.down
+	w.mu.Unlock()
+
+	metrics.SetDatabaseUp(!down)
+	if err != nil {
+		w.log.Warnw("database health check failed", "failures", failures, "err", err)
+	}
+	if !changed {
+		return err
+	}
+	if down {
+		w.log.Errorw("database is unreachable", "since", since.Format(time.RFC3339), "err", err)
+	} else {
+		w.log.Infow("database is reachable again", "downtime", time.Since(since).Round(time.Second).String())
+	}
+	if w.onChange != nil {
+		w.onChange(down, since, err)
+	}
+	return err
+}
+
+// Down reports whether the database is considered down and since when.
+func (w *DBWatchdog) Down() (bool, time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.down, w.since
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"time"
+
+	"feedback_bot/internal/wbapi"
+)
+
+// MaxAnswerDelay bounds WithAnswerDelay: reviews waiting longer than this
+// would look ignored to the customer.
+const MaxAnswerDelay = 72 * time.Hour
+
+// WithAnswerDelay makes HandleCycle leave reviews alone until they are at
+// least d old. Customers often edit a review shortly after posting it, and
+// an answer to the first version reads oddly next to the second. d <= 0
+// answers at once; larger values are capped at MaxAnswerDelay.
+func WithAnswerDelay(d time.Duration) Option {
+	return func(s *Service) {
+		s.minAge = min(max(d, 0), MaxAnswerDelay)
+	}
+}
+
+// tooYoung reports whether fb must wait for the answer delay at now.
+// Reviews without a creation date are never held back.
+func (s *Service) tooYoung(fb wbapi.Feedback, now time.Time) bool {
+	return s.minAge > 0 && !fb.CreatedDate.IsZero() && now.Sub(fb.CreatedDate) < s.minAge
+}
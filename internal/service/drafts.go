@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// DraftMaxRating is the highest rating of reviews drafted by WithDrafts;
+// it matches the range of the bad template.
+const DraftMaxRating = 3
+
+// draftsPerCycle limits the drafts generated in one cycle so that a large
+// backlog neither floods the chat nor stalls the cycle on the AI; the rest
+// are drafted by the following cycles.
+const draftsPerCycle = 10
+
+// Errors returned by SendDraft and SkipDraft.
+var (
+	ErrDraftNotFound = errors.New("draft not found")
+	ErrDraftResolved = errors.New("draft already sent or skipped")
+)
+
+// Drafter writes a reply to a review. example is the reply the templates
+// would post, for the tone and the signature; the result is shown to the
+// user before anything is posted.
+type Drafter interface {
+	Draft(ctx context.Context, fb wbapi.Feedback, example string) (string, error)
+}
+
+// WithDrafts stops answering 1–3★ reviews automatically: each gets a reply
+// drafted by d, stored with Store.SaveDraft and handed to deliver, and is
+// posted only by SendDraft. If d fails the template reply becomes the
+// draft. Drafted reviews are left alone by later cycles, sent or not.
+func WithDrafts(d Drafter, deliver func(fb wbapi.Feedback, draft storage.Draft)) Option {
+	return func(s *Service) {
+		s.drafter = d
+		s.deliverDraft = deliver
+	}
+}
+
+// draftable reports whether fb waits for the user's approval instead of
+// being answered by the cycle.
+func draftable(fb wbapi.Feedback) bool {
+	return fb.ProductValuation >= 1 && fb.ProductValuation <= DraftMaxRating
+}
+
+// holdForDrafts removes from pending the negative reviews, drafting replies
+// to those without a draft yet, and returns the reviews left to answer with
+// the number held. done lists reviews already answered. If the drafts
+// cannot be loaded, negative reviews are held without new drafts.
+func (s *Service) holdForDrafts(ctx context.Context, pending []wbapi.Feedback, done map[string]bool) ([]wbapi.Feedback, int) {
+	var ids []string
+	for _, fb := range pending {
+		if !done[fb.ID] && draftable(fb) {
+			ids = append(ids, fb.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return pending, 0
+	}
+	drafted, err := s.store.DraftedIDs(ctx, s.userID, ids)
+	if err != nil {
+		s.log.Warnw("cycle: failed to load drafts, holding negative reviews", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("drafted_ids")
+	}
+
+	kept := pending[:0:0]
+	created := 0
+	for _, fb := range pending {
+		if done[fb.ID] || !draftable(fb) {
+			kept = append(kept, fb)
+			continue
+		}
+		if err != nil || drafted[fb.ID] || created >= draftsPerCycle || ctx.Err() != nil {
+			continue
+		}
+		if s.draft(ctx, fb) {
+			created++
+		}
+	}
+	if created > 0 {
+		s.log.Infow("cycle: drafted replies for approval", "user_id", s.userID, "drafts", created)
+	}
+	return kept, len(pending) - len(kept)
+}
+
+// draft prepares, stores and delivers the draft for fb and reports whether
+// it was stored. A draft stored meanwhile by a concurrent cycle is kept and
+// not delivered again.
+func (s *Service) draft(ctx context.Context, fb wbapi.Feedback) bool {
+	example := s.templates.Decide(fb, time.Now()).Text
+	text, err := s.drafter.Draft(ctx, fb, example)
+	if err == nil {
+		err = content.Validate(text)
+	}
+	if err != nil {
+		s.log.Warnw("cycle: AI draft failed, using the template", "user_id", s.userID, "id", fb.ID, "err", err)
+		text = example
+	}
+
+	d := storage.Draft{
+		FeedbackID:      fb.ID,
+		Rating:          fb.ProductValuation,
+		SubjectName:     fb.SubjectName,
+		NmID:            fb.ProductDetails.NmID,
+		ProductName:     fb.ProductDetails.ProductName,
+		SupplierArticle: fb.ProductDetails.SupplierArticle,
+		ReviewedAt:      fb.CreatedDate,
+		Text:            text,
+		Status:          storage.DraftPending,
+	}
+	created, err := s.store.SaveDraft(ctx, s.userID, d)
+	if err != nil {
+		// Not delivered: the next cycle drafts the review again.
+		s.log.Warnw("cycle: failed to save draft", "user_id", s.userID, "id", fb.ID, "err", err)
+		metrics.IncrementDatabaseError("save_draft")
+		return false
+	}
+	if !created {
+		return false
+	}
+	if s.deliverDraft != nil {
+		s.deliverDraft(fb, d)
+	}
+	return true
+}
+
+// SendDraft posts the draft for the review with the given ID, or text in
+// its place if not empty, and records it as SourceDraft (SourceManual if
+// the text was changed). The same checks as in AnswerNow apply; if posting
+// fails the draft can be sent again.
+func (s *Service) SendDraft(ctx context.Context, feedbackID, text string) error {
+	d, err := s.store.GetDraft(ctx, s.userID, feedbackID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return ErrDraftNotFound
+	}
+	ok, err := s.store.ResolveDraft(ctx, s.userID, feedbackID, storage.DraftPending, storage.DraftSent)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDraftResolved
+	}
+
+	decision := Decision{Text: d.Text, Source: SourceDraft}
+	if text != "" && text != d.Text {
+		decision = Decision{Text: text, Source: SourceManual}
+	}
+	fb := wbapi.Feedback{
+		ID:               d.FeedbackID,
+		ProductValuation: d.Rating,
+		SubjectName:      d.SubjectName,
+		CreatedDate:      d.ReviewedAt,
+		ProductDetails: wbapi.ProductDetails{
+			NmID:            d.NmID,
+			ProductName:     d.ProductName,
+			SupplierArticle: d.SupplierArticle,
+		},
+	}
+
+	if _, err = s.answerOnRequest(ctx, fb, decision); err != nil && !errors.Is(err, ErrAlreadyAnswered) {
+		if _, rerr := s.store.ResolveDraft(ctx, s.userID, feedbackID, storage.DraftSent, storage.DraftPending); rerr != nil {
+			s.log.Warnw("drafts: failed to reopen draft", "user_id", s.userID, "id", feedbackID, "err", rerr)
+			metrics.IncrementDatabaseError("resolve_draft")
+		}
+	}
+	return err
+}
+
+// SkipDraft leaves the review with the given ID unanswered.
+func (s *Service) SkipDraft(ctx context.Context, feedbackID string) error {
+	ok, err := s.store.ResolveDraft(ctx, s.userID, feedbackID, storage.DraftPending, storage.DraftSkipped)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDraftResolved
+	}
+	s.log.Infow("drafts: review skipped", "user_id", s.userID, "id", feedbackID)
+	return nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// A burst is DuplicateMinBurst or more reviews with the same text among the
+// unanswered reviews of one cycle. Texts shorter than duplicateMinLetters
+// letters ("Отлично!", "Всё супер") are common in genuine reviews and never
+// form a burst; neither do reviews without text.
+const (
+	DuplicateMinBurst   = 3
+	duplicateMinLetters = 15
+)
+
+// WithDuplicateShare answers only percent (1–99) of each burst of identical
+// reviews in a cycle, rounded up so that at least one is answered. The rest
+// are recorded with Store.SkipDuplicates and left unanswered by later
+// cycles too. Other values answer every review.
+func WithDuplicateShare(percent int) Option {
+	return func(s *Service) {
+		if percent > 0 && percent < 100 {
+			s.duplicateShare = percent
+		}
+	}
+}
+
+// duplicateKey returns the text of fb reduced to lowercase words, so that
+// reviews differing only in case, punctuation or emoji match; "" if it is
+// too short to tell bot reviews apart from ordinary ones.
+func duplicateKey(fb wbapi.Feedback) string {
+	var sb strings.Builder
+	letters, space := 0, false
+	for _, r := range strings.ToLower(fb.Text + " " + fb.Pros + " " + fb.Cons) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			space = sb.Len() > 0
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+		sb.WriteRune(r)
+	}
+	if letters < duplicateMinLetters {
+		return ""
+	}
+	return sb.String()
+}
+
+// dropDuplicates removes from pending the reviews skipped as duplicates in
+// earlier cycles and all but the answered share of each new burst, and
+// returns the reviews left to answer with the number dropped. done lists
+// reviews already answered, which do not count towards a burst. If the
+// earlier skips cannot be loaded, whole bursts wait for the next cycle.
+func (s *Service) dropDuplicates(ctx context.Context, pending []wbapi.Feedback, done map[string]bool) ([]wbapi.Feedback, int) {
+	ids := make([]string, len(pending))
+	for i, fb := range pending {
+		ids[i] = fb.ID
+	}
+	skipped, err := s.store.SkippedDuplicates(ctx, s.userID, ids)
+	if err != nil {
+		s.log.Warnw("cycle: failed to load skipped duplicates, holding bursts", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("skipped_duplicates")
+	}
+
+	bursts := make(map[string][]string) // text -> IDs of its reviews
+	for _, fb := range pending {
+		if done[fb.ID] || skipped[fb.ID] {
+			continue
+		}
+		if key := duplicateKey(fb); key != "" {
+			bursts[key] = append(bursts[key], fb.ID)
+		}
+	}
+	drop := make(map[string]bool, len(skipped))
+	for id := range skipped {
+		drop[id] = true
+	}
+	var fresh []string
+	for text, burst := range bursts {
+		if len(burst) < DuplicateMinBurst {
+			continue
+		}
+		answer := (len(burst)*s.duplicateShare + 99) / 100
+		if err != nil {
+			answer = 0
+		}
+		if r := []rune(text); len(r) > 80 {
+			text = string(r[:80]) + "…"
+		}
+		s.log.Infow("cycle: identical reviews, answering a share",
+			"user_id", s.userID,
+			"reviews", len(burst),
+			"answered", answer,
+			"text", text,
+			"skipped_ids", burst[answer:])
+		for _, id := range burst[answer:] {
+			drop[id] = true
+		}
+		if err == nil {
+			fresh = append(fresh, burst[answer:]...)
+		}
+	}
+	if len(fresh) > 0 {
+		if err := s.store.SkipDuplicates(ctx, s.userID, fresh); err != nil {
+			// Skipped in this cycle anyway; the next one sees the burst again.
+			s.log.Warnw("cycle: failed to record skipped duplicates", "user_id", s.userID, "err", err)
+			metrics.IncrementDatabaseError("skip_duplicates")
+		}
+		for range fresh {
+			metrics.IncrementDuplicateSkip(s.userID)
+		}
+	}
+	if len(drop) == 0 {
+		return pending, 0
+	}
+
+	kept := pending[:0:0]
+	for _, fb := range pending {
+		if !drop[fb.ID] {
+			kept = append(kept, fb)
+		}
+	}
+	return kept, len(pending) - len(kept)
+}
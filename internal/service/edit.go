@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// EditAnswer replaces the posted answer to feedback id on WB and then the
+// stored copy, marking it as SourceEdited. Only the WB call can fail: the
+// answer is already changed for buyers at that point, so a storage error is
+// logged and the local history keeps the old text. A text breaking the WB
+// content rules is refused with a content.Validate error before WB is called.
+func EditAnswer(ctx context.Context, client *wbapi.Client, store storage.Store, userID int64, id, text string, log *zap.SugaredLogger) error {
+	if err := content.Validate(text); err != nil {
+		return err
+	}
+	if err := client.EditAnswer(ctx, id, text); err != nil {
+		log.Warnw("edit answer: wb err", "user_id", userID, "id", id, "err", err)
+		metrics.IncrementAPIError("wb", "edit_answer")
+		return err
+	}
+	RecordAudit(ctx, store, storage.AuditEvent{UserID: userID, Actor: userID, Action: storage.AuditAnswerEdited, Details: "id=" + id}, log)
+	found, err := store.UpdateAnswerText(ctx, userID, id, SourceEdited, text)
+	if err != nil {
+		log.Warnw("edit answer: storage err", "user_id", userID, "id", id, "err", err)
+		metrics.IncrementDatabaseError("edit_answer")
+		return nil
+	}
+	log.Infow("answer edited", "user_id", userID, "id", id, "stored", found)
+	return nil
+}
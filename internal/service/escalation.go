@@ -0,0 +1,25 @@
+package service
+
+import "feedback_bot/internal/wbapi"
+
+// EscalationMaxRating is the highest rating forwarded by WithEscalation.
+const EscalationMaxRating = 2
+
+// WithEscalation calls forward with every 1–2★ review after the bot has
+// answered it, together with the posted reply, so that people can follow up,
+// e.g. in the seller's support chat. Delivery is up to forward.
+func WithEscalation(forward func(fb wbapi.Feedback, reply string)) Option {
+	return func(s *Service) {
+		s.escalate = forward
+	}
+}
+
+// escalated reports a posted answer to the escalation hook if the review is
+// negative enough.
+func (s *Service) escalated(fb wbapi.Feedback, reply string) {
+	if s.escalate == nil || fb.ProductValuation < 1 || fb.ProductValuation > EscalationMaxRating {
+		return
+	}
+	s.log.Infow("cycle: escalating negative review", "user_id", s.userID, "id", fb.ID, "rating", fb.ProductValuation)
+	s.escalate(fb, reply)
+}
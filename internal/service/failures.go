@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// Stages of a cycle recorded in storage.Failure.Stage.
+const (
+	StageFetch  = "fetch"  // loading unanswered reviews or questions
+	StageCheck  = "check"  // looking up whether an item was already answered
+	StageAnswer = "answer" // posting the reply to WB
+	StageSave   = "save"   // recording a posted reply
+)
+
+// Causes recorded in storage.Failure.Cause.
+const (
+	CauseUnauthorized = "unauthorized"
+	CauseForbidden    = "forbidden"
+	CauseRateLimited  = "rate_limited"
+	CauseServer       = "server"
+	CauseBadRequest   = "bad_request"
+	CauseNotFound     = "not_found"
+	CauseStorage      = "storage"
+	CauseNetwork      = "network" // WB unreachable: timeouts, DNS, connection errors
+	CauseUnknown      = "unknown" // anything else, e.g. a response that cannot be decoded
+	CauseContent      = "content" // the text breaks the WB content rules, not sent
+	CauseLength       = "length"  // the text is longer than WB accepts, not sent
+)
+
+// FailureCause classifies a WB client error or a text refused by the content
+// check. Only transport errors count as CauseNetwork; errors it does not
+// recognize are CauseUnknown.
+func FailureCause(err error) string {
+	var cerr *content.Error
+	var lerr *content.LengthError
+	var nerr net.Error
+	var uerr *url.Error
+	switch {
+	case errors.As(err, &cerr):
+		return CauseContent
+	case errors.As(err, &lerr):
+		return CauseLength
+	case errors.Is(err, wbapi.ErrUnauthorized):
+		return CauseUnauthorized
+	case errors.Is(err, wbapi.ErrForbidden):
+		return CauseForbidden
+	case errors.Is(err, wbapi.ErrRateLimited):
+		return CauseRateLimited
+	case errors.Is(err, wbapi.ErrServer), errors.Is(err, wbapi.ErrMaintenance):
+		return CauseServer
+	case errors.Is(err, wbapi.ErrBadRequest):
+		return CauseBadRequest
+	case errors.Is(err, wbapi.ErrNotFound):
+		return CauseNotFound
+	case errors.As(err, &nerr), errors.As(err, &uerr):
+		return CauseNetwork
+	}
+	return CauseUnknown
+}
+
+// recordFailure persists a failed step for the user's error screen.
+// Failures caused by shutdown are not recorded.
+func (s *Service) recordFailure(ctx context.Context, kind, stage, id, cause string, err error) {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	f := storage.Failure{
+		FeedbackID: id,
+		Kind:       kind,
+		Stage:      stage,
+		Cause:      cause,
+		Message:    err.Error(),
+	}
+	if err := s.store.RecordFailure(ctx, s.userID, f); err != nil {
+		s.log.Warnw("cycle: record failure failed", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("record_failure")
+	}
+}
+
+// recordCycle stores the outcome of a cycle for the admin error overview.
+// Cycles interrupted by shutdown are not recorded.
+func (s *Service) recordCycle(ctx context.Context, r storage.CycleResult) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := s.store.RecordCycleResult(ctx, s.userID, r); err != nil {
+		s.log.Warnw("cycle: record result failed", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("record_cycle_result")
+	}
+}
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/wbapi"
+)
+
+func TestFailureCause(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name   string
+		err    error
+		cause  string
+		outage bool
+	}{
+		{"server error", fmt.Errorf("fetch: %w", wbapi.ErrServer), service.CauseServer, true},
+		{"maintenance page", wbapi.ErrMaintenance, service.CauseServer, true},
+		{"dial error", &url.Error{Op: "Get", URL: "https://feedbacks-api.wildberries.ru", Err: dial}, service.CauseNetwork, true},
+		{"dns error", &net.DNSError{Err: "no such host", Name: "feedbacks-api.wildberries.ru", IsNotFound: true}, service.CauseNetwork, true},
+		{"timeout", fmt.Errorf("fetch: %w", &url.Error{Op: "Get", URL: "https://x", Err: context.DeadlineExceeded}), service.CauseNetwork, true},
+		{"unauthorized", wbapi.ErrUnauthorized, service.CauseUnauthorized, false},
+		{"rate limited", wbapi.ErrRateLimited, service.CauseRateLimited, false},
+		{"bad json", fmt.Errorf("decode: %w", &json.SyntaxError{Offset: 1}), service.CauseUnknown, false},
+		{"anything else", errors.New("unexpected"), service.CauseUnknown, false},
+		{"cancelled", &url.Error{Op: "Get", URL: "https://x", Err: context.Canceled}, service.CauseNetwork, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.FailureCause(tt.err); got != tt.cause {
+				t.Errorf("FailureCause = %q, want %q", got, tt.cause)
+			}
+			if got := service.IsOutage(tt.err); got != tt.outage {
+				t.Errorf("IsOutage = %v, want %v", got, tt.outage)
+			}
+		})
+	}
+}
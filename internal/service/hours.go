@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimezone is used when the user has not chosen a timezone explicitly.
+// Most WB sellers work in Moscow time.
+const DefaultTimezone = "Europe/Moscow"
+
+// BusinessHours is a daily working window in a fixed location.
+// The window is [Start, End); if End < Start the window spans midnight
+// (e.g. 22:00–06:00).
+type BusinessHours struct {
+	Loc   *time.Location
+	Start time.Duration // offset from local midnight
+	End   time.Duration // offset from local midnight
+}
+
+// ParseBusinessHours builds BusinessHours from user settings.
+// start/end must be "HH:MM"; tz is an IANA name and defaults to DefaultTimezone.
+func ParseBusinessHours(tz, start, end string) (*BusinessHours, error) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		tz = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", tz, err)
+	}
+	s, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	if s == e {
+		return nil, fmt.Errorf("working hours start and end must differ")
+	}
+	return &BusinessHours{Loc: loc, Start: s, End: e}, nil
+}
+
+// Contains reports whether t falls within the working window.
+func (h *BusinessHours) Contains(t time.Time) bool {
+	local := t.In(h.Loc)
+	y, m, d := local.Date()
+	offset := local.Sub(time.Date(y, m, d, 0, 0, 0, 0, h.Loc))
+	if h.Start < h.End {
+		return offset >= h.Start && offset < h.End
+	}
+	return offset >= h.Start || offset < h.End
+}
+
+// NextStart returns the first moment at or after t when the window opens.
+func (h *BusinessHours) NextStart(t time.Time) time.Time {
+	local := t.In(h.Loc)
+	y, m, d := local.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, h.Loc).Add(h.Start)
+	if start.Before(local) {
+		start = time.Date(y, m, d+1, 0, 0, 0, 0, h.Loc).Add(h.Start)
+	}
+	return start
+}
+
+// Bounds returns start and end as "HH:MM" strings, suitable for persisting.
+func (h *BusinessHours) Bounds() (start, end string) {
+	return formatClock(h.Start), formatClock(h.End)
+}
+
+// String renders the window as "HH:MM–HH:MM (Zone)".
+func (h *BusinessHours) String() string {
+	return fmt.Sprintf("%s–%s (%s)", formatClock(h.Start), formatClock(h.End), h.Loc.String())
+}
+
+// parseClock parses "HH:MM" into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
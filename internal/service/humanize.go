@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Bounds of the pause between consecutive answers when humanizing.
+const (
+	humanDelayMin = 30 * time.Second
+	humanDelayMax = 5 * time.Minute
+)
+
+// WithHumanize inserts a random 30s–5m pause between consecutive answers
+// within a cycle so replies do not all appear in the same second.
+func WithHumanize() Option {
+	return func(s *Service) {
+		s.humanize = true
+	}
+}
+
+// humanPause waits a random delay before the next answer if humanizing is
+// enabled. It returns false if ctx was cancelled while waiting.
+func (s *Service) humanPause(ctx context.Context) bool {
+	if !s.humanize {
+		return ctx.Err() == nil
+	}
+	d := humanDelayMin + rand.N(humanDelayMax-humanDelayMin)
+	s.log.Debugw("cycle: humanize pause", "user_id", s.userID, "delay", d.String())
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
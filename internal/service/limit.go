@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// Daily answer limit bounds. The default protects sellers from flooding WB
+// with answers after a long pause or on their first run.
+const (
+	DefaultDailyLimit = 200
+	MaxDailyLimit     = 5000
+)
+
+// dailyLimit caps the number of answers per calendar day in loc. The
+// counter itself lives in storage so it survives restarts.
+type dailyLimit struct {
+	max    int
+	loc    *time.Location
+	notify func(limit int) // called once per day when the cap is hit; optional
+
+	mu          sync.Mutex
+	notifiedDay string
+}
+
+// WithDailyLimit stops answering once max answers (feedbacks and questions
+// together) were posted today in loc. notify is called the first time the
+// cap is hit each day. max <= 0 means DefaultDailyLimit; nil loc means UTC.
+func WithDailyLimit(max int, loc *time.Location, notify func(limit int)) Option {
+	if max <= 0 {
+		max = DefaultDailyLimit
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return func(s *Service) {
+		s.limit = &dailyLimit{max: max, loc: loc, notify: notify}
+	}
+}
+
+// today returns the counter key for the current day in the limit's timezone.
+func (l *dailyLimit) today() string {
+	return time.Now().In(l.loc).Format("2006-01-02")
+}
+
+// answersLeft returns how many answers may still be posted today, also
+// bounded by the trial when billing is on. Without a limit, or if the
+// counter cannot be read, answering is not restricted.
+func (s *Service) answersLeft(ctx context.Context) int {
+	left := s.dailyLeft(ctx)
+	if s.billing != nil {
+		trial := s.access(ctx).AnswersLeft
+		s.billing.bound.Store(trial < left)
+		left = min(left, trial)
+	}
+	return left
+}
+
+func (s *Service) dailyLeft(ctx context.Context) int {
+	if s.limit == nil {
+		return math.MaxInt
+	}
+	n, err := s.store.DailyCount(ctx, s.userID, s.limit.today())
+	if err != nil {
+		s.log.Warnw("cycle: failed to read daily counter", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("daily_count")
+		return math.MaxInt
+	}
+	if left := s.limit.max - n; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// countAnswer records a posted answer in today's counter and in the trial.
+func (s *Service) countAnswer(ctx context.Context) {
+	s.countTrialAnswer(ctx)
+	if s.limit == nil {
+		return
+	}
+	if _, err := s.store.IncrementDailyCount(ctx, s.userID, s.limit.today()); err != nil {
+		s.log.Warnw("cycle: failed to update daily counter", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("daily_count")
+	}
+}
+
+// limitReached logs the cap and notifies the user once per day. When it was
+// the trial that ran out, the user is told that instead.
+func (s *Service) limitReached() {
+	if s.billing != nil && s.billing.bound.Load() {
+		s.billing.mu.Lock()
+		last := s.billing.last
+		s.billing.mu.Unlock()
+		s.accessEnded(last)
+		return
+	}
+	if s.limit == nil {
+		return
+	}
+	day := s.limit.today()
+	s.limit.mu.Lock()
+	first := s.limit.notifiedDay != day
+	s.limit.notifiedDay = day
+	s.limit.mu.Unlock()
+
+	if !first {
+		return
+	}
+	s.log.Infow("cycle: daily answer limit reached", "user_id", s.userID, "limit", s.limit.max)
+	if s.limit.notify != nil {
+		s.limit.notify(s.limit.max)
+	}
+}
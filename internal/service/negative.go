@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// WithNegativeLog records the 1–3★ reviews fetched by each cycle with
+// Store.SaveNegativeReviews, whether they get answered or not, for the
+// daily report of negative reviews.
+func WithNegativeLog() Option {
+	return func(s *Service) {
+		s.logNegative = true
+	}
+}
+
+// logNegativeReviews records the negative reviews among feedbacks. A failure
+// is only logged: reviews still unanswered are recorded by the next cycle.
+func (s *Service) logNegativeReviews(ctx context.Context, feedbacks []wbapi.Feedback) {
+	var reviews []storage.NegativeReview
+	for _, fb := range feedbacks {
+		if fb.ProductValuation < 1 || fb.ProductValuation > 3 {
+			continue
+		}
+		reviews = append(reviews, storage.NegativeReview{
+			FeedbackID:      fb.ID,
+			Rating:          fb.ProductValuation,
+			SubjectName:     fb.SubjectName,
+			NmID:            fb.ProductDetails.NmID,
+			ProductName:     fb.ProductDetails.ProductName,
+			SupplierArticle: fb.ProductDetails.SupplierArticle,
+			Text:            fb.Text,
+			Pros:            fb.Pros,
+			Cons:            fb.Cons,
+			ReviewedAt:      fb.CreatedDate,
+		})
+	}
+	if len(reviews) == 0 {
+		return
+	}
+	if err := s.store.SaveNegativeReviews(ctx, s.userID, reviews); err != nil {
+		s.log.Warnw("cycle: failed to record negative reviews", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("save_negative_reviews")
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// Defaults for NewOutageTracker.
+const (
+	DefaultOutageThreshold  = 5                // consecutive failed fetches, across users
+	DefaultOutageProbeDelay = 30 * time.Second // first probe after WB goes down
+	maxOutageProbeDelay     = 10 * time.Minute
+)
+
+// OutageTracker detects WB-wide outages from the cycles of all users. After
+// threshold consecutive fetches fail with server errors, network errors or a
+// maintenance page, WB is considered degraded: cycles are skipped except for
+// a single probe, retried with a doubling delay, until one succeeds. This
+// keeps an outage from producing a failed request and an error log for
+// every user on every tick.
+type OutageTracker struct {
+	threshold  int
+	probeDelay time.Duration
+	onChange   func(degraded bool, since time.Time) // optional
+
+	mu        sync.Mutex
+	failures  int       // consecutive outage errors
+	since     time.Time // start of the outage; zero while WB is up
+	delay     time.Duration
+	nextProbe time.Time
+	probing   bool
+}
+
+// NewOutageTracker returns a tracker that declares an outage after threshold
+// consecutive failures and first probes WB probeDelay later; zero values
+// select the defaults. onChange is called when WB goes down and when it
+// recovers, with the outage start.
+func NewOutageTracker(threshold int, probeDelay time.Duration, onChange func(degraded bool, since time.Time)) *OutageTracker {
+	if threshold <= 0 {
+		threshold = DefaultOutageThreshold
+	}
+	if probeDelay <= 0 {
+		probeDelay = DefaultOutageProbeDelay
+	}
+	return &OutageTracker{threshold: threshold, probeDelay: probeDelay, onChange: onChange}
+}
+
+// WithOutageTracker shares o between users' services: cycles report fetch
+// results to it and are skipped while it considers WB down.
+func WithOutageTracker(o *OutageTracker) Option {
+	return func(s *Service) {
+		s.outage = o
+	}
+}
+
+// IsOutage reports whether err means WB itself is unavailable rather than
+// rejecting the user's request.
+func IsOutage(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch FailureCause(err) {
+	case CauseServer, CauseNetwork:
+		return true
+	}
+	return false
+}
+
+// Degraded reports whether WB is considered down and since when.
+func (o *OutageTracker) Degraded() (bool, time.Time) {
+	if o == nil {
+		return false, time.Time{}
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return !o.since.IsZero(), o.since
+}
+
+// allow reports whether a cycle may contact WB now. While WB is down only
+// one cycle at a time is let through, once the probe delay is over; it must
+// report its outcome with observe.
+func (o *OutageTracker) allow(now time.Time) (ok, probe bool) {
+	if o == nil {
+		return true, false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.since.IsZero() {
+		return true, false
+	}
+	if o.probing || now.Before(o.nextProbe) {
+		return false, false
+	}
+	o.probing = true
+	return true, true
+}
+
+// skip ends a cycle let through by allow that did not contact WB, leaving
+// the outage state as it is.
+func (o *OutageTracker) skip(probe bool) {
+	if o == nil || !probe {
+		return
+	}
+	o.mu.Lock()
+	o.probing = false
+	o.mu.Unlock()
+}
+
+// observe records the result of a fetch. Errors other than outages count as
+// success: WB answered, if only to refuse the request.
+func (o *OutageTracker) observe(err error, probe bool) {
+	if o == nil {
+		return
+	}
+	now := time.Now()
+	o.mu.Lock()
+	if probe {
+		o.probing = false
+	}
+	if errors.Is(err, context.Canceled) {
+		o.mu.Unlock()
+		return
+	}
+
+	var changed, degraded bool
+	since := o.since
+	switch {
+	case !IsOutage(err):
+		o.failures = 0
+		if !o.since.IsZero() {
+			o.since, o.delay = time.Time{}, 0
+			changed = true
+		}
+	case !o.since.IsZero():
+		if probe {
+			o.delay = min(2*o.delay, max(maxOutageProbeDelay, o.probeDelay))
+			o.nextProbe = now.Add(o.delay)
+		}
+	default:
+		o.failures++
+		if o.failures >= o.threshold {
+			o.since, o.delay = now, o.probeDelay
+			o.nextProbe = now.Add(o.delay)
+			since, changed, degraded = now, true, true
+		}
+	}
+	o.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	metrics.SetWBDegraded(degraded)
+	if o.onChange != nil {
+		o.onChange(degraded, since)
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// MinProcessedRetention is the shortest accepted retention for answer
+// history, so that a typo cannot wipe recent statistics and exports.
+const MinProcessedRetention = 30 * 24 * time.Hour
+
+// PruneProcessed permanently deletes answers older than retention, user by
+// user, from the processed and archive tables, and returns how many rows were
+// removed. A failure for one user is logged and does not stop the others.
+// Intended to be run nightly by scheduler.Daily and by the admin command.
+func PruneProcessed(ctx context.Context, store storage.Store, users storage.ConfigStore, retention time.Duration, log *zap.SugaredLogger) (int64, error) {
+	start := time.Now()
+	cutoff := start.UTC().Add(-retention)
+	ids, err := users.ListUserIDs(ctx)
+	if err != nil {
+		log.Errorw("retention: failed to list users", "err", err)
+		return 0, err
+	}
+
+	var total int64
+	var failed int
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		n, err := store.DeleteProcessedOlderThan(ctx, id, cutoff)
+		if err != nil {
+			log.Warnw("retention: failed to prune user", "user_id", id, "err", err)
+			failed++
+			continue
+		}
+		total += n
+	}
+	log.Infow("retention: done", "deleted", total, "users", len(ids), "failed", failed,
+		"cutoff", cutoff.Format(time.DateOnly), "duration", time.Since(start).String())
+	return total, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// Backoff for answers that could not be posted: the first retry waits
+// retryBaseDelay, each next one twice as long, up to retryMaxDelay.
+const (
+	retryBaseDelay = 10 * time.Minute
+	retryMaxDelay  = 24 * time.Hour
+)
+
+// StuckAttempts is the number of failed attempts after which an answer is
+// considered stuck: it is still retried, but counted in the stuck metric
+// and highlighted on the user's problem reviews screen.
+const StuckAttempts = 5
+
+// RetryDelay returns the pause before the next attempt after the given
+// number of failed ones.
+func RetryDelay(attempts int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempts && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, retryMaxDelay)
+}
+
+// failedAnswers loads the user's retry states keyed by ID. On error it
+// returns an empty map, so every review is tried as if it never failed.
+func (s *Service) failedAnswers(ctx context.Context) map[string]storage.FailedAnswer {
+	list, err := s.store.ListFailedAnswers(ctx, s.userID)
+	if err != nil {
+		s.log.Warnw("cycle: list failed answers err", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("list_failed_answers")
+	}
+	retries := make(map[string]storage.FailedAnswer, len(list))
+	for _, f := range list {
+		retries[f.FeedbackID] = f
+	}
+	return retries
+}
+
+// retryDue reports whether id may be answered now: it never failed or its
+// backoff is over.
+func retryDue(retries map[string]storage.FailedAnswer, id string, now time.Time) bool {
+	f, ok := retries[id]
+	return !ok || !now.Before(f.NextRetryAt)
+}
+
+// retryLater counts a failed answer to id and schedules the next attempt.
+// Errors that concern the whole account rather than the review (token,
+// access, rate limit), texts refused by the content check (see
+// contentRefused) and shutdown are not counted.
+func (s *Service) retryLater(ctx context.Context, retries map[string]storage.FailedAnswer, kind, id string, err error) {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || contentRefused(err) ||
+		errors.Is(err, wbapi.ErrUnauthorized) || errors.Is(err, wbapi.ErrForbidden) || errors.Is(err, wbapi.ErrRateLimited) {
+		return
+	}
+	f := retries[id]
+	f.FeedbackID = id
+	f.Kind = kind
+	f.Attempts++
+	f.Cause = FailureCause(err)
+	f.LastError = err.Error()
+	f.NextRetryAt = time.Now().Add(RetryDelay(f.Attempts))
+	retries[id] = f
+	if err := s.store.SaveFailedAnswer(ctx, s.userID, f); err != nil {
+		s.log.Warnw("cycle: save failed answer err", "user_id", s.userID, "id", id, "err", err)
+		metrics.IncrementDatabaseError("save_failed_answer")
+		return
+	}
+	if f.Attempts == StuckAttempts {
+		s.log.Warnw("cycle: answer stuck", "user_id", s.userID, "kind", kind, "id", id, "attempts", f.Attempts, "cause", f.Cause)
+	}
+}
+
+// answerPosted forgets the retry state of id after its answer went through.
+func (s *Service) answerPosted(ctx context.Context, retries map[string]storage.FailedAnswer, id string) {
+	if _, ok := retries[id]; !ok {
+		return
+	}
+	delete(retries, id)
+	if err := s.store.DeleteFailedAnswer(ctx, s.userID, id); err != nil {
+		s.log.Warnw("cycle: delete failed answer err", "user_id", s.userID, "id", id, "err", err)
+		metrics.IncrementDatabaseError("delete_failed_answer")
+	}
+}
+
+// reportStuck updates the stuck answers metric from the retry states.
+func (s *Service) reportStuck(retries map[string]storage.FailedAnswer) {
+	var n int
+	for _, f := range retries {
+		if f.Attempts >= StuckAttempts {
+			n++
+		}
+	}
+	metrics.SetStuckAnswers(s.userID, n)
+}
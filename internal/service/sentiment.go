@@ -0,0 +1,86 @@
+package service
+
+import (
+	"strings"
+
+	"feedback_bot/internal/wbapi"
+)
+
+// Sentiment is the result of analysing a review text.
+type Sentiment struct {
+	Score   int      // < 0 means the text contains complaints
+	Matches []string // phrases that contributed to the score, for the decision trace
+}
+
+// Negative reports whether the text reads as a complaint.
+func (s Sentiment) Negative() bool {
+	return s.Score < 0
+}
+
+// SentimentAnalyzer scores review text. KeywordAnalyzer is the built-in
+// implementation; an ML-backed one only has to satisfy this interface.
+type SentimentAnalyzer interface {
+	Analyze(text string) Sentiment
+}
+
+// complaintPhrases are lower-case stems typical for defect and delivery
+// complaints in Russian reviews.
+var complaintPhrases = []string{
+	"брак", "дефект", "сломан", "сломал", "не работает", "перестал работать",
+	"порван", "порвал", "дыр", "разбит", "треснул", "трещин", "царапин",
+	"пятно", "пятна", "грязн", "воняет", "неприятный запах",
+	"не соответствует", "не тот цвет", "не тот размер", "прислали не",
+	"не пришел", "не пришла", "не доложили", "некомплект",
+	"подделк", "обман", "разочаров", "ужасн", "отвратительн", "недовол",
+	"возврат",
+}
+
+// negations cancel a complaint phrase when they directly precede it,
+// e.g. "без брака", "нет дефектов".
+var negations = []string{"без ", "нет ", "не было ", "никакого ", "никаких "}
+
+// KeywordAnalyzer is a dictionary-based analyzer: each complaint phrase
+// found in the text lowers the score by one.
+type KeywordAnalyzer struct{}
+
+// Analyze implements SentimentAnalyzer.
+func (KeywordAnalyzer) Analyze(text string) Sentiment {
+	lower := strings.ToLower(strings.ReplaceAll(text, "ё", "е"))
+	var s Sentiment
+	for _, phrase := range complaintPhrases {
+		p := strings.ReplaceAll(phrase, "ё", "е")
+		if containsUnnegated(lower, p) {
+			s.Score--
+			s.Matches = append(s.Matches, phrase)
+		}
+	}
+	return s
+}
+
+// containsUnnegated reports whether phrase occurs in text at least once
+// without a negation right before it.
+func containsUnnegated(text, phrase string) bool {
+	for from := 0; ; {
+		i := strings.Index(text[from:], phrase)
+		if i < 0 {
+			return false
+		}
+		i += from
+		negated := false
+		for _, n := range negations {
+			if strings.HasSuffix(text[:i], n) {
+				negated = true
+				break
+			}
+		}
+		if !negated {
+			return true
+		}
+		from = i + len(phrase)
+	}
+}
+
+// reviewText joins the parts of a review where buyers describe problems.
+func reviewText(fb wbapi.Feedback) string {
+	return strings.Join([]string{fb.Text, fb.Cons}, "\n")
+}
@@ -1,8 +1,16 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"math/rand/v2"
 	"strings"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
 )
 
 // TemplateEngine stores pre‑defined reply texts and picks the right one
@@ -11,12 +19,47 @@ import (
 //   • rating 1–3 → Bad template
 //   • rating 4–5 → Good template
 //
-// You may later extend this to load multiple templates per category or use
-// text/template for interpolation, but for MVP plain strings are enough.
+// If business hours and an off-hours template are configured, answers
+// generated outside the working window use the off-hours text instead
+// (e.g. "ответим подробнее утром").
+//
+// Each category may have extra variants; one of the main text and its
+// variants is picked at random so buyers don't see identical replies.
+//
+// An optional media template thanks buyers who attached photos or video to
+// a 4–5 ★ review instead of the good template.
+//
+// Signatures, if set, are closing lines appended to every reply, one picked
+// at random each time, so that even a single template reads differently.
+//
+// A product category (WB subjectId) may have its own good and bad replies,
+// used for its reviews instead of the main templates and their variants,
+// e.g. a reply about sizing for every review of shoes.
 
 type TemplateEngine struct {
 	bad  string // reply for 1–3 ★
 	good string // reply for 4–5 ★
+
+	badVariants  []string // rotated with bad
+	goodVariants []string // rotated with good
+
+	media string // reply for 4–5 ★ reviews with photos or video, optional
+
+	offHours string         // reply outside business hours, optional
+	hours    *BusinessHours // nil → always "in hours"
+
+	sentiment SentimentAnalyzer // nil → rating alone decides
+
+	signatures []string // closing lines rotated under every reply, optional
+
+	categories map[int64]categoryReplies // by Feedback.SubjectID, optional
+}
+
+// categoryReplies are the replies for the reviews of one product category;
+// an empty one leaves the main template.
+type categoryReplies struct {
+	name      string
+	good, bad string
 }
 
 // NewTemplateEngine trims input texts and validates they are non‑empty.
@@ -35,12 +78,228 @@ func NewTemplateEngine(bad, good string) *TemplateEngine {
 	}
 }
 
+// SetBusinessHours enables the off-hours variant. Passing nil hours or an
+// empty text disables it.
+func (t *TemplateEngine) SetBusinessHours(hours *BusinessHours, offHours string) {
+	t.hours = hours
+	t.offHours = strings.TrimSpace(offHours)
+}
+
+// SetVariants adds extra texts rotated with the bad and good templates.
+// Blank texts are dropped.
+func (t *TemplateEngine) SetVariants(bad, good []string) {
+	t.badVariants = nonEmpty(bad)
+	t.goodVariants = nonEmpty(good)
+}
+
+// SetSignatures sets the closing lines appended to replies, one at random.
+// Blank texts are dropped; none disables signatures.
+func (t *TemplateEngine) SetSignatures(texts []string) {
+	t.signatures = nonEmpty(texts)
+}
+
+// Sign appends a random signature to text. Text is returned unchanged
+// without signatures.
+func (t *TemplateEngine) Sign(text string) string {
+	signed, _, _ := t.sign(text)
+	return signed
+}
+
+// sign is Sign that also returns the 1-based number of the signature used
+// and how many there are; zero when none is set.
+func (t *TemplateEngine) sign(text string) (string, int, int) {
+	if len(t.signatures) == 0 {
+		return text, 0, 0
+	}
+	i := rand.IntN(len(t.signatures))
+	return text + "\n\n" + t.signatures[i], i + 1, len(t.signatures)
+}
+
+func nonEmpty(texts []string) []string {
+	var out []string
+	for _, s := range texts {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// pick returns main or one of its variants at random, with the 1-based
+// number of the chosen text and the total count.
+func pick(main string, variants []string) (string, int, int) {
+	n := len(variants) + 1
+	i := rand.IntN(n)
+	if i == 0 {
+		return main, 1, n
+	}
+	return variants[i-1], i + 1, n
+}
+
+// SetCategoryTemplates sets the replies for the reviews of product
+// categories, replacing earlier ones. Categories without texts are dropped.
+func (t *TemplateEngine) SetCategoryTemplates(list []storage.CategoryTemplate) {
+	t.categories = make(map[int64]categoryReplies, len(list))
+	for _, c := range list {
+		r := categoryReplies{name: c.SubjectName, good: strings.TrimSpace(c.Good), bad: strings.TrimSpace(c.Bad)}
+		if r.good != "" || r.bad != "" {
+			t.categories[c.SubjectID] = r
+		}
+	}
+}
+
+// SetMediaTemplate sets the reply for positive reviews with photos or
+// video. An empty text disables it.
+func (t *TemplateEngine) SetMediaTemplate(text string) {
+	t.media = strings.TrimSpace(text)
+}
+
+// hasMedia reports whether the buyer attached photos or video.
+func hasMedia(fb wbapi.Feedback) bool {
+	return len(fb.PhotoLinks) > 0 || (fb.Video != nil && fb.Video.Link != "")
+}
+
+// SetSentiment makes positive ratings whose text reads as a complaint get
+// the bad (apology) template. nil disables text analysis.
+func (t *TemplateEngine) SetSentiment(a SentimentAnalyzer) {
+	t.sentiment = a
+}
+
 // Select returns the template suitable for the given rating.
-// For any rating <4 returns bad; rating >=4 returns good.
+// For any rating <4 returns bad; rating >=4 returns good, choosing randomly
+// among the category's variants when there are any.
 // Out‑of‑range ratings (<1 or >5) are clamped to nearest bucket.
 func (t *TemplateEngine) Select(rating int) string {
 	if rating >= 4 {
-		return t.good
+		text, _, _ := pick(t.good, t.goodVariants)
+		return text
+	}
+	text, _, _ := pick(t.bad, t.badVariants)
+	return text
+}
+
+// SelectAt is like Select but honours business hours: outside the working
+// window the off-hours template is returned when configured.
+func (t *TemplateEngine) SelectAt(rating int, now time.Time) string {
+	return t.Decide(wbapi.Feedback{ProductValuation: rating}, now).Text
+}
+
+// Template sources recorded in Decision.Source.
+const (
+	SourceGood      = "good"
+	SourceBad       = "bad"
+	SourceOffHours  = "off_hours"
+	SourceSentiment = "sentiment"
+	SourceMedia     = "media"
+	SourceCategory  = "category" // reply of the review's product category
+	SourceQuestion  = "question"
+	SourceEdited    = "edited" // answer text replaced by the user after posting
+	SourceManual    = "manual" // one-off reply typed by the user for a single review
+	SourceDraft     = "draft"  // AI draft posted as is on the user's approval
+)
+
+// Decision is the outcome of template selection for a single feedback.
+// Trace lists the steps that led to it in user-facing (Russian) wording so
+// the bot can show "why" without re-implementing the selection rules.
+type Decision struct {
+	Text    string
+	Source  string
+	Version string // short hash of Text; changes whenever the template is edited
+	Trace   []string
+}
+
+// TemplateVersion returns a short stable fingerprint of a template text so
+// answers can be attributed to a specific revision of a template.
+func TemplateVersion(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Decide picks the reply for fb and records why. It is the single place
+// where selection rules live; SelectAt is a thin wrapper. A signature is
+// appended after the version is taken, so rotating signatures do not split
+// a template's statistics into revisions.
+func (t *TemplateEngine) Decide(fb wbapi.Feedback, now time.Time) Decision {
+	d := t.decide(fb, now)
+	var i, n int
+	d.Text, i, n = t.sign(d.Text)
+	if n > 0 {
+		d.Trace = append(d.Trace, fmt.Sprintf("Добавлена подпись %d из %d", i, n))
+	}
+	return d
+}
+
+func (t *TemplateEngine) decide(fb wbapi.Feedback, now time.Time) Decision {
+	var d Decision
+	if t.hours != nil && t.offHours != "" {
+		if !t.hours.Contains(now) {
+			d.Trace = append(d.Trace, fmt.Sprintf("Сейчас вне рабочих часов %s", t.hours))
+			d.Text, d.Source = t.offHours, SourceOffHours
+			d.Trace = append(d.Trace, "Выбран шаблон для нерабочего времени")
+			d.Version = TemplateVersion(d.Text)
+			return d
+		}
+		d.Trace = append(d.Trace, fmt.Sprintf("Сейчас рабочее время %s", t.hours))
+	}
+
+	if fb.ProductValuation >= 4 && t.sentiment != nil {
+		if s := t.sentiment.Analyze(reviewText(fb)); s.Negative() {
+			d.Source = SourceSentiment
+			d.Trace = append(d.Trace, fmt.Sprintf("Оценка %d★, но в тексте жалоба («%s») → шаблон для отрицательных отзывов",
+				fb.ProductValuation, strings.Join(s.Matches, "», «")))
+			t.reply(&d, fb, false)
+			return d
+		}
+	}
+
+	if fb.ProductValuation >= 4 && t.media != "" && hasMedia(fb) {
+		d.Text, d.Source = t.media, SourceMedia
+		d.Trace = append(d.Trace, fmt.Sprintf("Оценка %d★ и к отзыву приложены фото или видео → шаблон благодарности за фото", fb.ProductValuation))
+		d.Version = TemplateVersion(d.Text)
+		return d
+	}
+
+	if fb.ProductValuation >= 4 {
+		d.Source = SourceGood
+		d.Trace = append(d.Trace, fmt.Sprintf("Оценка %d★ (4–5★) → шаблон для положительных отзывов", fb.ProductValuation))
+		t.reply(&d, fb, true)
+	} else {
+		d.Source = SourceBad
+		d.Trace = append(d.Trace, fmt.Sprintf("Оценка %d★ (1–3★) → шаблон для отрицательных отзывов", fb.ProductValuation))
+		t.reply(&d, fb, false)
+	}
+	return d
+}
+
+// reply sets the decision to the good or bad reply for fb: its product
+// category's own if set, otherwise the main template or one of its variants.
+func (t *TemplateEngine) reply(d *Decision, fb wbapi.Feedback, good bool) {
+	main, variants := t.bad, t.badVariants
+	if good {
+		main, variants = t.good, t.goodVariants
+	}
+	if c, ok := t.categories[fb.SubjectID]; ok {
+		text := c.bad
+		if good {
+			text = c.good
+		}
+		if text != "" {
+			d.Text, d.Source = text, SourceCategory
+			d.Trace = append(d.Trace, fmt.Sprintf("Для категории «%s» задан свой шаблон", c.name))
+			d.Version = TemplateVersion(d.Text)
+			return
+		}
+	}
+	d.pick(main, variants)
+}
+
+// pick sets the decision text to main or one of its variants and records
+// which one was chosen.
+func (d *Decision) pick(main string, variants []string) {
+	text, i, n := pick(main, variants)
+	d.Text = text
+	if n > 1 {
+		d.Trace = append(d.Trace, fmt.Sprintf("Случайно выбран вариант %d из %d", i, n))
 	}
-	return t.bad
+	d.Version = TemplateVersion(d.Text)
 }
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// DefaultTrackInterval is how often answered reviews are re-checked for
+// buyer edits when WithReviewTracking is given no interval.
+const DefaultTrackInterval = 3 * time.Hour
+
+// trackTake is how many of the latest answered reviews are re-checked.
+const trackTake = 500
+
+// ReviewChange is an answered review the buyer edited since it was last seen.
+type ReviewChange struct {
+	Feedback    wbapi.Feedback // the review as it is now
+	OldRating   int
+	TextChanged bool // text, pros or cons differ; unknown before the first check
+}
+
+// Flipped reports whether the review moved between negative (1–3★) and
+// positive (4–5★), the split used to choose the reply template.
+func (c ReviewChange) Flipped() bool {
+	return (c.OldRating >= 4) != (c.Feedback.ProductValuation >= 4)
+}
+
+// reviewTracker re-checks answered reviews at most once per interval.
+type reviewTracker struct {
+	every  time.Duration
+	notify func(ReviewChange) // called for flipped reviews
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// WithReviewTracking re-fetches the latest answered reviews after a cycle,
+// at most once per every (DefaultTrackInterval if every <= 0), and compares
+// them with what was seen before: the review itself on earlier checks or the
+// rating stored with the bot's answer. notify is called for reviews that
+// moved between negative and positive; other edits are only logged.
+func WithReviewTracking(every time.Duration, notify func(ReviewChange)) Option {
+	if every <= 0 {
+		every = DefaultTrackInterval
+	}
+	return func(s *Service) {
+		s.tracker = &reviewTracker{every: every, notify: notify}
+	}
+}
+
+// due reports whether a check is due at now and, if so, counts it as done.
+func (t *reviewTracker) due(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.last.IsZero() && now.Sub(t.last) < t.every {
+		return false
+	}
+	t.last = now
+	return true
+}
+
+// trackReviews compares the latest answered reviews with their snapshots,
+// stores the new state and reports flipped reviews. Snapshots are saved
+// before notifying, so that a storage error cannot repeat a notification.
+func (s *Service) trackReviews(ctx context.Context) {
+	if s.tracker == nil || ctx.Err() != nil || s.CooldownLeft() > 0 || !s.tracker.due(time.Now()) {
+		return
+	}
+	feedbacks, err := s.client.FetchAnswered(ctx, trackTake, 0)
+	if err != nil {
+		if s.rateLimited(err) {
+			return
+		}
+		s.log.Warnw("track reviews: fetch failed", "user_id", s.userID, "err", err)
+		metrics.IncrementAPIError("wb", "fetch_answered")
+		return
+	}
+	seen, err := s.store.ReviewSnapshots(ctx, s.userID)
+	if err != nil {
+		s.log.Warnw("track reviews: failed to load snapshots", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("review_snapshots")
+		return
+	}
+	answered := s.answeredRatings(ctx)
+
+	var changes []ReviewChange
+	snaps := make([]storage.ReviewSnapshot, 0, len(feedbacks))
+	for _, fb := range feedbacks {
+		if fb.ProductValuation == 0 {
+			continue
+		}
+		hash := reviewHash(fb)
+		snaps = append(snaps, storage.ReviewSnapshot{FeedbackID: fb.ID, Rating: fb.ProductValuation, TextHash: hash})
+
+		prev, ok := seen[fb.ID]
+		if !ok {
+			// First check of a review the bot answered: its text is unknown
+			prev.Rating, ok = answered[fb.ID]
+		}
+		if !ok {
+			continue
+		}
+		change := ReviewChange{Feedback: fb, OldRating: prev.Rating, TextChanged: prev.TextHash != "" && prev.TextHash != hash}
+		if change.OldRating == fb.ProductValuation && !change.TextChanged {
+			continue
+		}
+		s.log.Infow("track reviews: review edited", "user_id", s.userID, "id", fb.ID,
+			"old_rating", change.OldRating, "rating", fb.ProductValuation, "text_changed", change.TextChanged)
+		changes = append(changes, change)
+	}
+	if err := s.store.SaveReviewSnapshots(ctx, s.userID, snaps); err != nil {
+		s.log.Warnw("track reviews: failed to save snapshots", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("save_review_snapshots")
+		return
+	}
+	for _, c := range changes {
+		if c.Flipped() && s.tracker.notify != nil {
+			s.tracker.notify(c)
+		}
+	}
+}
+
+// answeredRatings returns the ratings stored with the bot's latest answers to
+// reviews. Without them, reviews are only compared from their second check.
+func (s *Service) answeredRatings(ctx context.Context) map[string]int {
+	recs, err := s.store.RecentAnswers(ctx, s.userID, trackTake)
+	if err != nil {
+		s.log.Warnw("track reviews: failed to load answers", "user_id", s.userID, "err", err)
+		metrics.IncrementDatabaseError("recent_answers")
+		return nil
+	}
+	ratings := make(map[string]int, len(recs))
+	for _, rec := range recs {
+		if rec.Kind != storage.KindQuestion && rec.Rating > 0 {
+			ratings[rec.FeedbackID] = rec.Rating
+		}
+	}
+	return ratings
+}
+
+// reviewHash fingerprints what the buyer wrote in a review.
+func reviewHash(fb wbapi.Feedback) string {
+	sum := sha256.Sum256([]byte(fb.Text + "\x00" + fb.Pros + "\x00" + fb.Cons))
+	return hex.EncodeToString(sum[:16])
+}
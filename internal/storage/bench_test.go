@@ -0,0 +1,322 @@
+package storage_test
+
+// Benchmarks of the storage operations on the hot path of a processing
+// cycle, so that storage changes (batch APIs, caching) can be compared
+// before and after. Each operation has a budget; a benchmark slower than it
+// fails, so the run can gate CI:
+//
+//	go test ./internal/storage -run '^$' -bench .                      # SQLite in a temp dir
+//	STORAGE_BENCH_DSN="host=... dbname=bench_scratch" go test ./internal/storage -run '^$' -bench .
+//	go test ./internal/storage -run '^$' -bench . -budget-scale 3       # slower CI runners
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"feedback_bot/internal/storage"
+)
+
+var budgetScale = flag.Float64("budget-scale", 1, "multiply every storage benchmark budget by this factor; 0 disables the check")
+
+// benchDSNEnv names a scratch PostgreSQL database to benchmark and test
+// locks against as well as SQLite. It gets rows of benchUser only, which
+// are removed afterwards.
+const benchDSNEnv = "STORAGE_BENCH_DSN"
+
+// benchUser owns every row the benchmarks write. It is far outside the
+// range of Telegram user IDs so a shared database is not disturbed.
+const benchUser int64 = -42_000_000
+
+// seedAnswers is the number of answers stored before the benchmarks run,
+// about a year of history for an active seller.
+const seedAnswers = 10_000
+
+// pageSize matches the number of reviews requested from WB per cycle.
+const pageSize = 100
+
+// benchStore is a seeded backend shared by all benchmarks of the run.
+type benchStore struct {
+	st    storage.Store
+	cs    storage.ConfigStore
+	close func()
+}
+
+var benchStores = struct {
+	sync.Mutex
+	m map[string]*benchStore
+}{m: make(map[string]*benchStore)}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	benchStores.Lock()
+	for _, s := range benchStores.m {
+		if err := s.cs.DeleteUserConfig(context.Background(), benchUser); err != nil {
+			fmt.Fprintln(os.Stderr, "storage benchmarks: cleanup:", err)
+		}
+		s.close()
+	}
+	benchStores.Unlock()
+	os.Exit(code)
+}
+
+// backends returns the names of the backends to benchmark.
+func backends() []string {
+	if os.Getenv(benchDSNEnv) != "" {
+		return []string{"sqlite", "postgres"}
+	}
+	return []string{"sqlite"}
+}
+
+// openBenchStore opens and seeds backend on first use.
+func openBenchStore(b *testing.B, backend string) *benchStore {
+	b.Helper()
+	benchStores.Lock()
+	defer benchStores.Unlock()
+	if s, ok := benchStores.m[backend]; ok {
+		return s
+	}
+
+	var s benchStore
+	switch backend {
+	case "sqlite":
+		dir, err := os.MkdirTemp("", "storage-bench-")
+		if err != nil {
+			b.Fatal(err)
+		}
+		st, cs, err := storage.NewSQLite(filepath.Join(dir, "bench.db"), nil)
+		if err != nil {
+			os.RemoveAll(dir)
+			b.Fatal(err)
+		}
+		s = benchStore{st: st, cs: cs, close: func() { st.Close(); os.RemoveAll(dir) }}
+	case "postgres":
+		st, cs, err := storage.NewPostgreSQL(os.Getenv(benchDSNEnv), nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		s = benchStore{st: st, cs: cs, close: func() { st.Close() }}
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	if err := seed(ctx, s.st, s.cs); err != nil {
+		s.close()
+		b.Fatal(err)
+	}
+	b.Logf("%s: seeded %d answers in %s", backend, seedAnswers, time.Since(start).Round(time.Millisecond))
+	benchStores.m[backend] = &s
+	return &s
+}
+
+// seed stores seedAnswers answers and a config for benchUser.
+func seed(ctx context.Context, st storage.Store, cs storage.ConfigStore) error {
+	if err := cs.SaveUserConfig(ctx, benchUser, "bench-token", "good", "bad"); err != nil {
+		return fmt.Errorf("seed config: %w", err)
+	}
+	for i := range seedAnswers {
+		rec := storage.AnswerRecord{
+			FeedbackID:  seededID(i),
+			Rating:      1 + i%5,
+			SubjectName: "Бенчмарк",
+			Source:      "good",
+		}
+		if err := st.SaveAnswer(ctx, benchUser, rec); err != nil {
+			return fmt.Errorf("seed answers: %w", err)
+		}
+	}
+	return nil
+}
+
+func seededID(i int) string {
+	return fmt.Sprintf("bench-%06d", i)
+}
+
+// benchBackends runs fn on every backend and fails a backend slower than
+// budget per operation. Budgets are generous for a developer machine with
+// an SSD; CI scales them with -budget-scale.
+func benchBackends(b *testing.B, budget time.Duration, fn func(b *testing.B, st storage.Store, cs storage.ConfigStore)) {
+	for _, backend := range backends() {
+		b.Run(backend, func(b *testing.B) {
+			s := openBenchStore(b, backend)
+			b.ReportAllocs()
+			fn(b, s.st, s.cs)
+			if *budgetScale <= 0 || b.N == 0 {
+				return
+			}
+			limit := time.Duration(float64(budget) * *budgetScale)
+			if perOp := b.Elapsed() / time.Duration(b.N); perOp > limit {
+				b.Errorf("%s per operation, over the budget of %s", perOp, limit)
+			}
+		})
+	}
+}
+
+func BenchmarkExistsHit(b *testing.B) {
+	benchBackends(b, 200*time.Microsecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		for i := 0; b.Loop(); i++ {
+			if _, err := st.Exists(ctx, benchUser, seededID(i%seedAnswers)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkExistsMiss(b *testing.B) {
+	benchBackends(b, 200*time.Microsecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		for i := 0; b.Loop(); i++ {
+			if _, err := st.Exists(ctx, benchUser, fmt.Sprintf("missing-%d", i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// pageIDs fills ids with page n of a WB list, half of it already answered.
+func pageIDs(ids []string, n int) {
+	for i := range ids {
+		ids[i] = seededID((n*len(ids) + i) % seedAnswers)
+		if i%2 == 1 {
+			ids[i] = fmt.Sprintf("new-%d-%d", n, i)
+		}
+	}
+}
+
+// BenchmarkExistsPage checks a WB page ID by ID: the baseline a batch lookup
+// has to beat.
+func BenchmarkExistsPage(b *testing.B) {
+	benchBackends(b, 20*time.Millisecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		ids := make([]string, pageSize)
+		for n := 0; b.Loop(); n++ {
+			pageIDs(ids, n)
+			for _, id := range ids {
+				if _, err := st.Exists(ctx, benchUser, id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkExistsBatch checks the same page with one call.
+func BenchmarkExistsBatch(b *testing.B) {
+	benchBackends(b, 2*time.Millisecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		ids := make([]string, pageSize)
+		for n := 0; b.Loop(); n++ {
+			pageIDs(ids, n)
+			if _, err := st.ExistsBatch(ctx, benchUser, ids); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkSaveAnswer(b *testing.B) {
+	benchBackends(b, 5*time.Millisecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		start := time.Now().UnixNano()
+		for i := 0; b.Loop(); i++ {
+			rec := storage.AnswerRecord{
+				FeedbackID: fmt.Sprintf("saved-%d-%d", start, i),
+				Rating:     5,
+				Source:     "good",
+			}
+			if err := st.SaveAnswer(ctx, benchUser, rec); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkSaveBatch is one flush of the cycle's answer buffer
+// (service.saveBatchSize).
+func BenchmarkSaveBatch(b *testing.B) {
+	benchBackends(b, 10*time.Millisecond, func(b *testing.B, st storage.Store, _ storage.ConfigStore) {
+		ctx := context.Background()
+		start := time.Now().UnixNano()
+		recs := make([]storage.AnswerRecord, 50)
+		for n := 0; b.Loop(); n++ {
+			for i := range recs {
+				recs[i] = storage.AnswerRecord{
+					FeedbackID: fmt.Sprintf("batch-%d-%d-%d", start, n, i),
+					Rating:     5,
+					Source:     "good",
+				}
+			}
+			if err := st.SaveBatch(ctx, benchUser, recs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetUserConfig(b *testing.B) {
+	benchBackends(b, 300*time.Microsecond, func(b *testing.B, _ storage.Store, cs storage.ConfigStore) {
+		ctx := context.Background()
+		for b.Loop() {
+			if _, err := cs.GetUserConfig(ctx, benchUser); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// TestAdvisoryLocksExclude opens two stores on the STORAGE_BENCH_DSN
+// database, as two replicas would, and checks that their locks exclude each
+// other: while one holds a key the other cannot take it, and can once it is
+// released. A failed attempt on another key leaves the held one in place.
+func TestAdvisoryLocksExclude(t *testing.T) {
+	dsn := os.Getenv(benchDSNEnv)
+	if dsn == "" {
+		t.Skip(benchDSNEnv + " is not set")
+	}
+	ctx := context.Background()
+	open := func() storage.Locker {
+		st, _, err := storage.NewPostgreSQL(dsn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { st.Close() })
+		l, ok := st.(storage.Locker)
+		if !ok {
+			t.Fatal("store does not implement storage.Locker")
+		}
+		return l
+	}
+	a, b := open(), open()
+
+	release, ok, err := a.TryLock(ctx, benchUser)
+	if err != nil || !ok {
+		t.Fatalf("first lock = %v, %v; want it taken (is another run holding it?)", ok, err)
+	}
+	if _, ok, err := a.TryLock(ctx, benchUser); err != nil || ok {
+		t.Fatalf("same instance again = %v, %v; want refused", ok, err)
+	}
+	if _, ok, err := b.TryLock(ctx, benchUser); err != nil || ok {
+		t.Fatalf("second instance = %v, %v; want refused while held", ok, err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := a.TryLock(cancelled, benchUser-1); err == nil {
+		t.Fatal("lock with a cancelled context succeeded")
+	}
+	if _, ok, err := b.TryLock(ctx, benchUser); err != nil || ok {
+		t.Fatalf("second instance after a failed lock of another key = %v, %v; want still refused", ok, err)
+	}
+	release()
+	releaseB, ok, err := b.TryLock(ctx, benchUser)
+	if err != nil || !ok {
+		t.Fatalf("second instance after release = %v, %v; want it taken", ok, err)
+	}
+	releaseB()
+}
@@ -0,0 +1,951 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Helpers shared by the SQLite and PostgreSQL backends. Only dialect-neutral
+// logic lives here; SQL text stays in the backend files.
+
+// templateColumn maps a template category (VariantGood, VariantBad) to its
+// user_configs column. Column names cannot be query parameters, so only these
+// constants may reach the SQL text.
+func templateColumn(category string) (string, error) {
+	switch category {
+	case VariantGood:
+		return "template_good", nil
+	case VariantBad:
+		return "template_bad", nil
+	}
+	return "", fmt.Errorf("unknown template category %q", category)
+}
+
+// recordKind defaults an empty AnswerRecord.Kind to KindFeedback.
+func recordKind(rec AnswerRecord) string {
+	if rec.Kind == "" {
+		return KindFeedback
+	}
+	return rec.Kind
+}
+
+// windowedCategoryStats runs query for the window ending now and for the
+// preceding window of the same length, filling PrevAvgRating from the latter.
+// query must select (subject_name, users, answers, avg_response_seconds,
+// avg_rating) and take args followed by the [since, until) bounds.
+func windowedCategoryStats(ctx context.Context, db *sql.DB, query string, window time.Duration, args ...any) ([]CategoryStats, error) {
+	now := utcNow()
+	cur, err := queryCategoryStats(ctx, db, query, append(args, now.Add(-window), now)...)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := queryCategoryStats(ctx, db, query, append(args, now.Add(-2*window), now.Add(-window))...)
+	if err != nil {
+		return nil, err
+	}
+	prevRating := make(map[string]float64, len(prev))
+	for _, p := range prev {
+		prevRating[p.SubjectName] = p.AvgRating
+	}
+	for i := range cur {
+		cur[i].PrevAvgRating = prevRating[cur[i].SubjectName]
+		cur[i].ComputedAt = now
+	}
+	return cur, nil
+}
+
+func queryCategoryStats(ctx context.Context, db *sql.DB, query string, args ...any) ([]CategoryStats, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CategoryStats
+	for rows.Next() {
+		var st CategoryStats
+		var avgResponse sql.NullFloat64
+		if err := rows.Scan(&st.SubjectName, &st.Users, &st.Answers, &avgResponse, &st.AvgRating); err != nil {
+			return nil, err
+		}
+		st.AvgResponse = time.Duration(avgResponse.Float64 * float64(time.Second))
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// archiveColumns lists the processed columns copied to processed_archive.
+const archiveColumns = `user_id, id, created_at, rating, subject_name, response_seconds, kind, source, template_version, reply_text,
+	nm_id, product_name, supplier_article`
+
+// answerColumns lists processed columns in the order expected by queryAnswers.
+const answerColumns = `id, kind, rating, subject_name, response_seconds, source, template_version, reply_text, created_at,
+	nm_id, product_name, supplier_article`
+
+func queryAnswers(ctx context.Context, db *sql.DB, query string, args ...any) ([]AnswerRecord, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AnswerRecord
+	for rows.Next() {
+		var rec AnswerRecord
+		var responseSeconds int64
+		if err := rows.Scan(&rec.FeedbackID, &rec.Kind, &rec.Rating, &rec.SubjectName, &responseSeconds,
+			&rec.Source, &rec.TemplateVersion, &rec.ReplyText, &rec.AnsweredAt,
+			&rec.NmID, &rec.ProductName, &rec.SupplierArticle); err != nil {
+			return nil, err
+		}
+		rec.ResponseTime = time.Duration(responseSeconds) * time.Second
+		rec.AnsweredAt = fromDB(rec.AnsweredAt)
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func queryProductStats(ctx context.Context, db *sql.DB, query string, args ...any) ([]ProductStats, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ProductStats
+	for rows.Next() {
+		var st ProductStats
+		if err := rows.Scan(&st.NmID, &st.ProductName, &st.SupplierArticle, &st.Answers, &st.AvgRating); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+func querySourceStats(ctx context.Context, db *sql.DB, query string, args ...any) ([]SourceStats, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SourceStats
+	for rows.Next() {
+		var st SourceStats
+		if err := rows.Scan(&st.Source, &st.TemplateVersion, &st.Answers, &st.AvgRating); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// queryPeriodSummary runs a query selecting kind, rating and a count grouped
+// by both, and sums the rows into a PeriodSummary.
+func queryPeriodSummary(ctx context.Context, db *sql.DB, query string, args ...any) (PeriodSummary, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return PeriodSummary{}, err
+	}
+	defer rows.Close()
+
+	var sum PeriodSummary
+	var stars int64
+	for rows.Next() {
+		var kind string
+		var rating int
+		var n int64
+		if err := rows.Scan(&kind, &rating, &n); err != nil {
+			return PeriodSummary{}, err
+		}
+		if kind == KindQuestion {
+			sum.Questions += n
+			continue
+		}
+		sum.Reviews += n
+		if rating >= 1 && rating <= 5 {
+			sum.ByRating[rating-1] += n
+			stars += int64(rating) * n
+		}
+	}
+	if rated := sum.ByRating[0] + sum.ByRating[1] + sum.ByRating[2] + sum.ByRating[3] + sum.ByRating[4]; rated > 0 {
+		sum.AvgRating = float64(stars) / float64(rated)
+	}
+	return sum, rows.Err()
+}
+
+// queryUserConfigs runs a query selecting userConfigColumns and scans all rows.
+func queryUserConfigs(ctx context.Context, db *sql.DB, tokens *TokenCipher, query string, args ...any) ([]*UserConfig, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*UserConfig
+	for rows.Next() {
+		cfg, err := scanUserConfig(rows, tokens)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cfg)
+	}
+	return out, rows.Err()
+}
+
+func querySatisfaction(ctx context.Context, db *sql.DB, query string, args ...any) ([]SatisfactionResponse, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SatisfactionResponse
+	for rows.Next() {
+		var r SatisfactionResponse
+		if err := rows.Scan(&r.UserID, &r.Score, &r.Comment, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.CreatedAt = fromDB(r.CreatedAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func queryChangelog(ctx context.Context, db *sql.DB, query string, args ...any) ([]ChangelogEntry, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChangelogEntry
+	for rows.Next() {
+		var e ChangelogEntry
+		if err := rows.Scan(&e.ID, &e.Version, &e.Text, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = fromDB(e.CreatedAt)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func queryTemplateVariants(ctx context.Context, db *sql.DB, query string, args ...any) ([]TemplateVariant, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TemplateVariant
+	for rows.Next() {
+		var v TemplateVariant
+		if err := rows.Scan(&v.Category, &v.Idx, &v.Text); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+func queryCategoryTemplates(ctx context.Context, db *sql.DB, query string, args ...any) ([]CategoryTemplate, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CategoryTemplate
+	for rows.Next() {
+		var t CategoryTemplate
+		if err := rows.Scan(&t.SubjectID, &t.SubjectName, &t.Good, &t.Bad); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func queryBlackouts(ctx context.Context, db *sql.DB, query string, args ...any) ([]Blackout, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Blackout
+	for rows.Next() {
+		var b Blackout
+		if err := rows.Scan(&b.ID, &b.Spec, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		b.CreatedAt = fromDB(b.CreatedAt)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func queryBannedUsers(ctx context.Context, db *sql.DB, query string, args ...any) ([]BannedUser, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BannedUser
+	for rows.Next() {
+		var u BannedUser
+		if err := rows.Scan(&u.UserID, &u.Reason, &u.BannedAt); err != nil {
+			return nil, err
+		}
+		u.BannedAt = fromDB(u.BannedAt)
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// queryConversation returns the single conversation row of query, or nil if
+// there is none.
+func queryConversation(ctx context.Context, db *sql.DB, query string, args ...any) (*Conversation, error) {
+	var c Conversation
+	err := db.QueryRowContext(ctx, query, args...).Scan(&c.UserID, &c.State, &c.Subject, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.UpdatedAt = fromDB(c.UpdatedAt)
+	return &c, nil
+}
+
+func queryAdmins(ctx context.Context, db *sql.DB, query string, args ...any) ([]Admin, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Admin
+	for rows.Next() {
+		var a Admin
+		if err := rows.Scan(&a.UserID, &a.AddedBy, &a.AddedAt); err != nil {
+			return nil, err
+		}
+		a.AddedAt = fromDB(a.AddedAt)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func queryExemptions(ctx context.Context, db *sql.DB, query string, args ...any) ([]Exemption, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Exemption
+	for rows.Next() {
+		var e Exemption
+		if err := rows.Scan(&e.UserID, &e.AddedBy, &e.AddedAt); err != nil {
+			return nil, err
+		}
+		e.AddedAt = fromDB(e.AddedAt)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// negativeReviewColumns are read by queryNegativeReviews from
+// negative_reviews n joined with processed p.
+const negativeReviewColumns = `n.feedback_id, n.rating, n.subject_name, n.nm_id, n.product_name, n.supplier_article,
+	n.text, n.pros, n.cons, n.reviewed_at, n.seen_at, COALESCE(p.reply_text, '')`
+
+func queryNegativeReviews(ctx context.Context, db *sql.DB, query string, args ...any) ([]NegativeReview, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []NegativeReview
+	for rows.Next() {
+		var r NegativeReview
+		var reviewed sql.NullTime
+		if err := rows.Scan(&r.FeedbackID, &r.Rating, &r.SubjectName, &r.NmID, &r.ProductName, &r.SupplierArticle,
+			&r.Text, &r.Pros, &r.Cons, &reviewed, &r.SeenAt, &r.Reply); err != nil {
+			return nil, err
+		}
+		if reviewed.Valid {
+			r.ReviewedAt = fromDB(reviewed.Time)
+		}
+		r.SeenAt = fromDB(r.SeenAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func queryMaintenance(ctx context.Context, db *sql.DB, query string) (*Maintenance, error) {
+	var m Maintenance
+	err := db.QueryRowContext(ctx, query).Scan(&m.Reason, &m.StartedBy, &m.StartedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.StartedAt = fromDB(m.StartedAt)
+	return &m, nil
+}
+
+func queryFailures(ctx context.Context, db *sql.DB, query string, args ...any) ([]Failure, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Failure
+	for rows.Next() {
+		var f Failure
+		if err := rows.Scan(&f.FeedbackID, &f.Kind, &f.Stage, &f.Cause, &f.Message, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		f.CreatedAt = fromDB(f.CreatedAt)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func queryCycleResults(ctx context.Context, db *sql.DB, query string, args ...any) ([]CycleResult, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CycleResult
+	for rows.Next() {
+		var r CycleResult
+		if err := rows.Scan(&r.Answered, &r.Failed, &r.Cause, &r.Message, &r.FinishedAt); err != nil {
+			return nil, err
+		}
+		r.FinishedAt = fromDB(r.FinishedAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// queryCycleProblems reads cycle results ordered by user and newest first
+// and keeps the users whose latest cycle failed, with the length of their
+// failure streak. Longest streaks come first, at most limit users.
+func queryCycleProblems(ctx context.Context, db *sql.DB, query string, limit int, args ...any) ([]CycleProblem, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CycleProblem
+	var cur *CycleProblem
+	done := false // the current user's streak has ended
+	for rows.Next() {
+		var userID int64
+		var r CycleResult
+		if err := rows.Scan(&userID, &r.Answered, &r.Failed, &r.Cause, &r.Message, &r.FinishedAt); err != nil {
+			return nil, err
+		}
+		r.FinishedAt = fromDB(r.FinishedAt)
+		if cur == nil || cur.UserID != userID {
+			if cur != nil && cur.Streak > 0 {
+				out = append(out, *cur)
+			}
+			cur = &CycleProblem{UserID: userID, Last: r}
+			done = false
+		}
+		if done || !r.Failing() {
+			done = true
+			continue
+		}
+		cur.Streak++
+		cur.Since = r.FinishedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil && cur.Streak > 0 {
+		out = append(out, *cur)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Streak != out[j].Streak {
+			return out[i].Streak > out[j].Streak
+		}
+		return out[i].Last.FinishedAt.After(out[j].Last.FinishedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func queryReviewSnapshots(ctx context.Context, db *sql.DB, query string, args ...any) (map[string]ReviewSnapshot, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]ReviewSnapshot)
+	for rows.Next() {
+		var s ReviewSnapshot
+		if err := rows.Scan(&s.FeedbackID, &s.Rating, &s.TextHash, &s.CheckedAt); err != nil {
+			return nil, err
+		}
+		s.CheckedAt = fromDB(s.CheckedAt)
+		out[s.FeedbackID] = s
+	}
+	return out, rows.Err()
+}
+
+// complaintColumns lists complaints columns in the order expected by
+// queryComplaints.
+const complaintColumns = `feedback_id, reason_id, reason, rating, nm_id, status, error, created_at, updated_at`
+
+func queryComplaints(ctx context.Context, db *sql.DB, query string, args ...any) ([]Complaint, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Complaint
+	for rows.Next() {
+		var c Complaint
+		if err := rows.Scan(&c.FeedbackID, &c.ReasonID, &c.Reason, &c.Rating, &c.NmID, &c.Status, &c.Error, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt, c.UpdatedAt = fromDB(c.CreatedAt), fromDB(c.UpdatedAt)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// countComplaints sums the (status, count) rows of query into
+// ComplaintStats.
+func countComplaints(ctx context.Context, db *sql.DB, query string, args ...any) (ComplaintStats, error) {
+	var cs ComplaintStats
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return cs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int64
+		if err := rows.Scan(&status, &n); err != nil {
+			return cs, err
+		}
+		switch status {
+		case ComplaintFiled:
+			cs.Pending += n
+		case ComplaintAccepted:
+			cs.Accepted += n
+		case ComplaintRejected:
+			cs.Rejected += n
+		case ComplaintFailed:
+			cs.Failed += n
+		}
+	}
+	return cs, rows.Err()
+}
+
+// draftColumns lists reply_drafts columns in the order expected by scanDraft.
+const draftColumns = `feedback_id, rating, subject_name, nm_id, product_name, supplier_article, reviewed_at, text, status, created_at`
+
+// scanDraft reads a reply_drafts row selected with draftColumns; nil if
+// there is none.
+func scanDraft(row rowScanner) (*Draft, error) {
+	var d Draft
+	var reviewed sql.NullTime
+	err := row.Scan(&d.FeedbackID, &d.Rating, &d.SubjectName, &d.NmID, &d.ProductName, &d.SupplierArticle, &reviewed, &d.Text, &d.Status, &d.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reviewed.Valid {
+		d.ReviewedAt = fromDB(reviewed.Time)
+	}
+	d.CreatedAt = fromDB(d.CreatedAt)
+	return &d, nil
+}
+
+// failedAnswerColumns lists failed_answers columns in the order expected by
+// queryFailedAnswers.
+const failedAnswerColumns = `id, kind, attempts, cause, last_error, first_failed_at, next_retry_at, updated_at`
+
+func queryFailedAnswers(ctx context.Context, db *sql.DB, query string, args ...any) ([]FailedAnswer, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FailedAnswer
+	for rows.Next() {
+		var f FailedAnswer
+		if err := rows.Scan(&f.FeedbackID, &f.Kind, &f.Attempts, &f.Cause, &f.LastError, &f.FirstFailedAt, &f.NextRetryAt, &f.UpdatedAt); err != nil {
+			return nil, err
+		}
+		f.FirstFailedAt = fromDB(f.FirstFailedAt)
+		f.NextRetryAt = fromDB(f.NextRetryAt)
+		f.UpdatedAt = fromDB(f.UpdatedAt)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func queryAuditEvents(ctx context.Context, db *sql.DB, query string, args ...any) ([]AuditEvent, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AuditEvent
+	for rows.Next() {
+		var ev AuditEvent
+		if err := rows.Scan(&ev.UserID, &ev.Actor, &ev.Action, &ev.Details, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		ev.CreatedAt = fromDB(ev.CreatedAt)
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+// failureMessageLimit caps stored error texts; WB error bodies can be long.
+const failureMessageLimit = 500
+
+func truncateMessage(s string) string {
+	if r := []rune(s); len(r) > failureMessageLimit {
+		return string(r[:failureMessageLimit])
+	}
+	return s
+}
+
+// batchRows bounds the IDs per ExistsBatch query and the rows per SaveBatch
+// statement, keeping SQLite well under its bind-parameter limit (32766).
+const batchRows = 500
+
+// answerArgCount is the number of values answerArgs returns per row.
+const answerArgCount = 13
+
+// insertColumns lists the processed columns set by answerArgs, in order.
+const insertColumns = `user_id, id, created_at, rating, subject_name, response_seconds, kind,
+	source, template_version, reply_text, nm_id, product_name, supplier_article`
+
+// answerArgs returns the processed column values for rec in the order of
+// insertColumns.
+func answerArgs(userID int64, rec AnswerRecord, now time.Time) []any {
+	return []any{userID, rec.FeedbackID, now, rec.Rating, rec.SubjectName, int64(rec.ResponseTime.Seconds()),
+		recordKind(rec), rec.Source, rec.TemplateVersion, rec.ReplyText,
+		rec.NmID, rec.ProductName, rec.SupplierArticle}
+}
+
+// queryIDSet runs a query selecting a single text column and collects the
+// values into a set.
+func queryIDSet(ctx context.Context, db *sql.DB, into map[string]bool, query string, args ...any) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		into[id] = true
+	}
+	return rows.Err()
+}
+
+func queryUserIDs(ctx context.Context, db *sql.DB, query string, args ...any) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func queryExclusions(ctx context.Context, db *sql.DB, query string, args ...any) ([]Exclusion, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Exclusion
+	for rows.Next() {
+		var ex Exclusion
+		if err := rows.Scan(&ex.Kind, &ex.Value); err != nil {
+			return nil, err
+		}
+		out = append(out, ex)
+	}
+	return out, rows.Err()
+}
+
+// TokenHash returns a stable fingerprint of a WB token used to detect the
+// same cabinet registered by several users without comparing raw tokens.
+// Empty and placeholder tokens hash to "".
+func TokenHash(token string) string {
+	if token == "" || token == "not_set" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenExpiresAt returns when a WB token expires, read from the "exp" claim of
+// its JWT payload. The signature is not verified: WB checks the token, the
+// date is only used for reminders. The zero time means the token is not a
+// JWT or carries no expiry.
+func TokenExpiresAt(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0).UTC()
+}
+
+// backfillTokenExpiry fills token_expires_at for tokens saved before the
+// column existed. updateStmt must take (token_expires_at, user_id). Tokens
+// without an expiry keep NULL and are read again on the next start, which
+// is cheap.
+func backfillTokenExpiry(ctx context.Context, db *sql.DB, tokens *TokenCipher, updateStmt string) error {
+	rows, err := db.QueryContext(ctx, `SELECT user_id, wb_token FROM user_configs
+		WHERE token_expires_at IS NULL AND wb_token <> '' AND wb_token <> 'not_set'`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		userID  int64
+		expires time.Time
+	}
+	var todo []pending
+	for rows.Next() {
+		var id int64
+		var stored string
+		if err := rows.Scan(&id, &stored); err != nil {
+			rows.Close()
+			return err
+		}
+		token, err := tokens.open(stored)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("user %d: %w", id, err)
+		}
+		if exp := TokenExpiresAt(token); !exp.IsZero() {
+			todo = append(todo, pending{id, exp})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, p := range todo {
+		if _, err := db.ExecContext(ctx, updateStmt, dbTime(p.expires), p.userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryTokenExpiries(ctx context.Context, db *sql.DB, query string, args ...any) ([]TokenExpiry, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TokenExpiry
+	for rows.Next() {
+		var t TokenExpiry
+		if err := rows.Scan(&t.UserID, &t.ExpiresAt, &t.Notice); err != nil {
+			return nil, err
+		}
+		t.ExpiresAt = fromDB(t.ExpiresAt)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// backfillTokenHashes fills token_hash for rows saved before the column
+// existed. updateStmt must take (token_hash, user_id). Rows are read fully
+// before updating because SQLite runs with a single connection.
+func backfillTokenHashes(db *sql.DB, updateStmt string) error {
+	rows, err := db.Query(`SELECT user_id, wb_token FROM user_configs
+		WHERE token_hash = '' AND wb_token <> '' AND wb_token <> 'not_set'`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		userID int64
+		hash   string
+	}
+	var todo []pending
+	for rows.Next() {
+		var id int64
+		var token string
+		if err := rows.Scan(&id, &token); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, pending{id, TokenHash(token)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, p := range todo {
+		if _, err := db.Exec(updateStmt, p.hash, p.userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryReferralStats(ctx context.Context, db *sql.DB, query string, args ...any) ([]ReferralStats, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ReferralStats
+	for rows.Next() {
+		var rs ReferralStats
+		if err := rows.Scan(&rs.ReferrerID, &rs.Invited, &rs.Credited, &rs.BonusDays); err != nil {
+			return nil, err
+		}
+		out = append(out, rs)
+	}
+	return out, rows.Err()
+}
+
+// statsQueries are one backend's statements for getStats.
+type statsQueries struct {
+	engine     string
+	counts     string // (since) selects the Stats counters in field order
+	version    string // selects the server version
+	size       string // selects the database size in bytes
+	complaints string // selects (status, count) of all complaints
+}
+
+// getStats collects Stats with one backend's queries. A token counts as
+// stale when a fetch or answer failed with "unauthorized" or "forbidden"
+// (service.CauseUnauthorized, service.CauseForbidden) after the user's
+// config was last saved; failures are kept for FailureRetention.
+func getStats(ctx context.Context, db *sql.DB, q statsQueries) (*Stats, error) {
+	st := &Stats{Engine: q.engine}
+	since := utcNow().Add(-24 * time.Hour)
+	if err := db.QueryRowContext(ctx, q.counts, since).Scan(&st.TotalUsers, &st.ConfiguredUsers, &st.StaleTokens, &st.TotalAnswered, &st.Answered24h); err != nil {
+		return nil, fmt.Errorf("failed to count stats: %w", err)
+	}
+	complaints, err := countComplaints(ctx, db, q.complaints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count complaints: %w", err)
+	}
+	st.Complaints = complaints
+	if err := db.QueryRowContext(ctx, q.version).Scan(&st.EngineVersion); err != nil {
+		return nil, fmt.Errorf("failed to get database version: %w", err)
+	}
+	if err := db.QueryRowContext(ctx, q.size).Scan(&st.DBSize); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return st, nil
+}
+
+// subscriptionQueries are one backend's statements for the subscription
+// helpers below, each taking the user ID as its last argument.
+type subscriptionQueries struct {
+	ensure string // (trial_started_at, updated_at, user_id) inserts a trial row unless one exists
+	get    string // selects (trial_started_at, trial_answers, paid_until)
+	extend string // (paid_until, updated_at, user_id)
+}
+
+// execQuerier is implemented by both *sql.DB and *sql.Tx.
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func getSubscription(ctx context.Context, q execQuerier, sq subscriptionQueries, userID int64) (Subscription, error) {
+	now := utcNow()
+	if _, err := q.ExecContext(ctx, sq.ensure, now, now, userID); err != nil {
+		return Subscription{}, err
+	}
+	sub := Subscription{UserID: userID}
+	var paidUntil sql.NullTime
+	if err := q.QueryRowContext(ctx, sq.get, userID).Scan(&sub.TrialStartedAt, &sub.TrialAnswers, &paidUntil); err != nil {
+		return Subscription{}, err
+	}
+	sub.TrialStartedAt = fromDB(sub.TrialStartedAt)
+	if paidUntil.Valid {
+		sub.PaidUntil = fromDB(paidUntil.Time)
+	}
+	return sub, nil
+}
+
+// extendSubscription adds d to the paid period inside tx.
+func extendSubscription(ctx context.Context, tx *sql.Tx, sq subscriptionQueries, userID int64, d time.Duration) (time.Time, error) {
+	sub, err := getSubscription(ctx, tx, sq, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := utcNow()
+	until := sub.PaidUntil
+	if until.Before(now) {
+		until = now
+	}
+	until = until.Add(d)
+	if _, err := tx.ExecContext(ctx, sq.extend, until, now, userID); err != nil {
+		return time.Time{}, err
+	}
+	return until, nil
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WB tokens are encrypted at rest with AES-256-GCM when ENCRYPTION_KEY is
+// set. An encrypted value is stored as
+//
+//	enc:v1:<base64(nonce || ciphertext)>
+//
+// The placeholders "" and "not_set" stay as they are so the SQL filters on
+// wb_token keep working, and token_hash is always computed from the plain
+// token. Rows saved before encryption was enabled are plaintext; they are
+// still read as-is and encryptStoredTokens converts them at startup.
+const encryptedTokenPrefix = "enc:v1:"
+
+// ErrNoEncryptionKey is returned when the database holds encrypted tokens
+// but no TokenCipher was configured.
+var ErrNoEncryptionKey = errors.New("database contains encrypted WB tokens but ENCRYPTION_KEY is not set")
+
+// TokenCipher encrypts and decrypts WB tokens. A nil *TokenCipher stores
+// tokens in plaintext.
+type TokenCipher struct {
+	aead cipher.AEAD
+}
+
+// NewTokenCipher creates a cipher from a 32-byte key encoded in base64 or
+// hex, e.g. the output of "openssl rand -base64 32".
+func NewTokenCipher(key string) (*TokenCipher, error) {
+	raw, err := decodeKey(strings.TrimSpace(key))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenCipher{aead: aead}, nil
+}
+
+func decodeKey(key string) ([]byte, error) {
+	if raw, err := base64.StdEncoding.DecodeString(key); err == nil && len(raw) == 32 {
+		return raw, nil
+	}
+	if raw, err := hex.DecodeString(key); err == nil && len(raw) == 32 {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("encryption key must be 32 bytes encoded in base64 or hex")
+}
+
+// isPlaceholderToken reports whether token is one of the values stored
+// instead of a real token.
+func isPlaceholderToken(token string) bool {
+	return token == "" || token == "not_set"
+}
+
+// seal returns the value to store for token.
+func (c *TokenCipher) seal(token string) (string, error) {
+	if c == nil || isPlaceholderToken(token) || strings.HasPrefix(token, encryptedTokenPrefix) {
+		return token, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(token), nil)
+	return encryptedTokenPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open returns the plain token for a stored value. Plaintext values from
+// before encryption was enabled are returned unchanged.
+func (c *TokenCipher) open(stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedTokenPrefix) {
+		return stored, nil
+	}
+	if c == nil {
+		return "", ErrNoEncryptionKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedTokenPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted token")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt token (wrong ENCRYPTION_KEY?): %w", err)
+	}
+	return string(plain), nil
+}
+
+// encryptStoredTokens prepares user_configs for the configured cipher at
+// startup. With a cipher, it checks that an already encrypted token can be
+// decrypted (catching a wrong key before any cycle runs) and encrypts
+// plaintext tokens in place; updateStmt must take (wb_token, user_id).
+// Without one it fails if any token is already encrypted, rather than
+// handing ciphertext to WB later.
+func encryptStoredTokens(ctx context.Context, db *sql.DB, tokens *TokenCipher, updateStmt string) error {
+	if tokens == nil {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_configs WHERE wb_token LIKE 'enc:%'`).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrNoEncryptionKey
+		}
+		return nil
+	}
+
+	var sample string
+	err := db.QueryRowContext(ctx, `SELECT wb_token FROM user_configs WHERE wb_token LIKE 'enc:%' LIMIT 1`).Scan(&sample)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	default:
+		if _, err := tokens.open(sample); err != nil {
+			return err
+		}
+	}
+
+	// Read all rows first: SQLite runs with a single connection
+	rows, err := db.QueryContext(ctx, `SELECT user_id, wb_token FROM user_configs
+		WHERE wb_token <> '' AND wb_token <> 'not_set' AND wb_token NOT LIKE 'enc:%'`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		userID int64
+		token  string
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.userID, &p.token); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range todo {
+		sealed, err := tokens.seal(p.token)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, updateStmt, sealed, p.userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
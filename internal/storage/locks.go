@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Keys of the locks of periodic jobs that one instance runs for all users.
+// User locks are keyed by the user ID, which is positive for Telegram users,
+// so negative keys cannot collide with them.
+const (
+	LockKeyBenchmarks int64 = -(iota + 1)
+	LockKeyPolls
+	LockKeyArchive
+	LockKeyPrune
+	LockKeyDigest
+	LockKeyTokenExpiry
+	LockKeyNegativeReport
+	LockKeyMonthlyReport
+)
+
+// Locker takes locks shared by every bot instance using the same database,
+// so that replicas never process the same user at once. Only PostgreSQL
+// implements it; a SQLite file is not shared between hosts.
+type Locker interface {
+	// TryLock takes the lock key without waiting. ok is false when another
+	// instance holds it. After a successful call release must be called
+	// once the work is done.
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+}
+
+// pgLocks implements Locker with session-level advisory locks, each key on
+// a connection of its own: a failure on one key then closes only the session
+// holding it, never the locks of other keys. The connections come from a
+// pool apart from the store's, so locks held through long cycles cannot
+// starve the queries of those cycles.
+type pgLocks struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	held   map[int64]*sql.Conn // nil while the key is being taken
+	closed bool
+}
+
+func newPGLocks(dsn string) (*pgLocks, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock connections: %w", err)
+	}
+	db.SetMaxIdleConns(2)
+	return &pgLocks{db: db, held: make(map[int64]*sql.Conn)}, nil
+}
+
+func (l *pgLocks) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	l.mu.Lock()
+	if _, busy := l.held[key]; busy || l.closed {
+		l.mu.Unlock()
+		return nil, false, nil
+	}
+	l.held[key] = nil
+	l.mu.Unlock()
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		l.forget(key)
+		return nil, false, fmt.Errorf("failed to open lock connection: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil {
+		discard(conn)
+		l.forget(key)
+		return nil, false, fmt.Errorf("failed to take advisory lock %d: %w", key, err)
+	}
+	if !ok {
+		_ = conn.Close()
+		l.forget(key)
+		return nil, false, nil
+	}
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		discard(conn)
+		return nil, false, nil
+	}
+	l.held[key] = conn
+	l.mu.Unlock()
+	var once sync.Once
+	return func() { once.Do(func() { l.unlock(key, conn) }) }, true, nil
+}
+
+// forget drops the reservation of a key that was not taken.
+func (l *pgLocks) forget(key int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] == nil {
+		delete(l.held, key)
+	}
+}
+
+// unlock releases key and returns its connection to the lock pool. Nothing
+// is left to release when close has dropped the connection since.
+func (l *pgLocks) unlock(key int64, conn *sql.Conn) {
+	l.mu.Lock()
+	if l.held[key] != conn {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.held, key)
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var released bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_advisory_unlock($1)`, key).Scan(&released); err != nil || !released {
+		// Drop the session rather than risk returning it to the pool with
+		// the lock still held. It holds no other key.
+		discard(conn)
+		return
+	}
+	_ = conn.Close()
+}
+
+// discard closes conn without returning it to the pool, which releases the
+// lock of its session.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
+
+// close releases all locks of this instance.
+func (l *pgLocks) close() {
+	l.mu.Lock()
+	l.closed = true
+	for key, conn := range l.held {
+		if conn != nil {
+			discard(conn)
+		}
+		delete(l.held, key)
+	}
+	l.mu.Unlock()
+	_ = l.db.Close()
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema changes are versioned. Version 1 is the schema produced by the
+// baseline functions (baselineSQLite, baselinePostgres), which also upgrade
+// databases created before versioning existed; they are frozen. Every later
+// change is a file migrations/<dialect>/NNNN_name.sql, applied once, in
+// order, inside a transaction, and recorded in schema_version.
+//
+// To change the schema add a new file with the next number for BOTH
+// dialects and update the Go code that reads the affected tables.
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// baselineVersion is the version recorded after the baseline function ran.
+const baselineVersion = 1
+
+// dialect describes how migrations are applied to one database backend.
+type dialect struct {
+	name     string // directory under migrations/
+	baseline func(db *sql.DB) error
+	// record inserts (version, name, applied_at) into schema_version.
+	record string
+}
+
+var (
+	sqliteDialect = dialect{
+		name:     "sqlite",
+		baseline: baselineSQLite,
+		record:   `INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?);`,
+	}
+	postgresDialect = dialect{
+		name:     "postgres",
+		baseline: baselinePostgres,
+		record:   `INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, $3)`,
+	}
+)
+
+// migration is one embedded SQL file.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the dialect's migrations ordered by version.
+func loadMigrations(d dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.name)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var out []migration
+	seen := make(map[int]string)
+	for _, e := range entries {
+		file := e.Name()
+		if e.IsDir() || !strings.HasSuffix(file, ".sql") {
+			continue
+		}
+		num, name, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version <= baselineVersion {
+			return nil, fmt.Errorf("invalid migration file name %s: want NNNN_name.sql with NNNN > %d", file, baselineVersion)
+		}
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, prev, file)
+		}
+		seen[version] = file
+
+		body, err := migrationFiles.ReadFile(path.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		out = append(out, migration{version: version, name: name, sql: string(body)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].version < out[j].version })
+	return out, nil
+}
+
+// migrate brings db to the latest schema version.
+func migrate(db *sql.DB, d dialect) error {
+	migrations, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+
+	const versionTable = `CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`
+	if _, err := db.Exec(versionTable); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if current < baselineVersion {
+		if err := d.baseline(db); err != nil {
+			return fmt.Errorf("baseline schema: %w", err)
+		}
+		if _, err := db.Exec(d.record, baselineVersion, "baseline", utcNow()); err != nil {
+			return fmt.Errorf("failed to record baseline version: %w", err)
+		}
+		current = baselineVersion
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, d, m); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, d dialect, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(d.record, m.version, m.name, utcNow()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,14 @@
+-- Answers moved out of processed by the nightly archival job
+CREATE TABLE IF NOT EXISTS processed_archive (
+	user_id BIGINT NOT NULL,
+	id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	rating INTEGER NOT NULL DEFAULT 0,
+	subject_name TEXT NOT NULL DEFAULT '',
+	response_seconds BIGINT NOT NULL DEFAULT 0,
+	kind TEXT NOT NULL DEFAULT 'feedback',
+	source TEXT NOT NULL DEFAULT '',
+	template_version TEXT NOT NULL DEFAULT '',
+	reply_text TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (user_id, id)
+);
@@ -0,0 +1,7 @@
+-- Timestamps are written as UTC wall time; see timestamps.go. Values in
+-- TIMESTAMP columns carry no offset, so rows written earlier in server local
+-- time cannot be told apart and are kept as they are.
+
+-- Range scans by time: archival, history, statistics windows
+CREATE INDEX IF NOT EXISTS idx_processed_user_created_at ON processed(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_processed_archive_created_at ON processed_archive(created_at);
@@ -0,0 +1,6 @@
+-- Admins added at runtime with "/admin add"; those from ADMIN_USER_IDS are not stored
+CREATE TABLE IF NOT EXISTS admins (
+	user_id BIGINT PRIMARY KEY,
+	added_by BIGINT NOT NULL,
+	added_at TIMESTAMP NOT NULL
+);
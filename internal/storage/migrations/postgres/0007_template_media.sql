@@ -0,0 +1,2 @@
+-- Reply for positive reviews with photos or video; empty disables it
+ALTER TABLE user_configs ADD COLUMN template_media TEXT NOT NULL DEFAULT '';
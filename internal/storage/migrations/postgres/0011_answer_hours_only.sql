@@ -0,0 +1,2 @@
+-- Outside working hours reviews wait for the window instead of being answered
+ALTER TABLE user_configs ADD COLUMN answer_hours_only BOOLEAN NOT NULL DEFAULT FALSE;
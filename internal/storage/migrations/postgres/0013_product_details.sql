@@ -0,0 +1,8 @@
+-- Reviewed product, for grouping statistics and exports by product
+ALTER TABLE processed ADD COLUMN nm_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE processed ADD COLUMN product_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE processed ADD COLUMN supplier_article TEXT NOT NULL DEFAULT '';
+ALTER TABLE processed_archive ADD COLUMN nm_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE processed_archive ADD COLUMN product_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE processed_archive ADD COLUMN supplier_article TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_processed_user_nm ON processed(user_id, nm_id);
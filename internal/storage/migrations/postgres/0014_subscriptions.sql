@@ -0,0 +1,21 @@
+-- Billing: free trial and paid access. Rows outlive user data deletion so
+-- that deleting and re-adding a token does not restart the trial.
+CREATE TABLE IF NOT EXISTS subscriptions (
+	user_id BIGINT PRIMARY KEY,
+	trial_started_at TIMESTAMP NOT NULL,
+	trial_answers INTEGER NOT NULL DEFAULT 0,
+	paid_until TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL
+);
+
+-- Successful payments; the Telegram charge ID makes recording idempotent
+CREATE TABLE IF NOT EXISTS payments (
+	charge_id TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	provider_charge_id TEXT NOT NULL DEFAULT '',
+	amount BIGINT NOT NULL,
+	currency TEXT NOT NULL,
+	days INTEGER NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_payments_user ON payments(user_id, created_at);
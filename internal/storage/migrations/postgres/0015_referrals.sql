@@ -0,0 +1,10 @@
+-- Referrals from /start deep links. A user is referred at most once; the
+-- referrer is credited when the invited user saves a WB token.
+CREATE TABLE IF NOT EXISTS referrals (
+	user_id BIGINT PRIMARY KEY,
+	referrer_id BIGINT NOT NULL,
+	bonus_days INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL,
+	credited_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id);
@@ -0,0 +1,2 @@
+-- Reviews younger than the delay wait, since customers often edit them right after posting
+ALTER TABLE user_configs ADD COLUMN answer_delay_minutes INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- Complaints about unfair reviews filed through WB, shown in the answer history
+CREATE TABLE IF NOT EXISTS complaints (
+	user_id BIGINT NOT NULL,
+	feedback_id TEXT NOT NULL,
+	reason_id INTEGER NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	rating INTEGER NOT NULL DEFAULT 0,
+	nm_id BIGINT NOT NULL DEFAULT 0,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, feedback_id)
+);
+CREATE INDEX IF NOT EXISTS idx_complaints_user_updated_at ON complaints(user_id, updated_at);
@@ -0,0 +1,12 @@
+-- Outcome of each user's recent polling cycles, for the admin error overview
+CREATE TABLE IF NOT EXISTS cycle_results (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	answered INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	cause TEXT NOT NULL DEFAULT '',
+	message TEXT NOT NULL DEFAULT '',
+	finished_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cycle_results_user_finished_at ON cycle_results(user_id, finished_at);
+CREATE INDEX IF NOT EXISTS idx_cycle_results_finished_at ON cycle_results(finished_at);
@@ -0,0 +1,14 @@
+-- Buyers edit reviews after the answer; opted-in users are told when a
+-- review turns from negative to positive or back
+ALTER TABLE user_configs ADD COLUMN track_edits BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Answered reviews as last seen on WB, compared with later fetches
+CREATE TABLE IF NOT EXISTS review_snapshots (
+	user_id BIGINT NOT NULL,
+	feedback_id TEXT NOT NULL,
+	rating INTEGER NOT NULL DEFAULT 0,
+	text_hash TEXT NOT NULL DEFAULT '',
+	checked_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, feedback_id)
+);
+CREATE INDEX IF NOT EXISTS idx_review_snapshots_checked_at ON review_snapshots(checked_at);
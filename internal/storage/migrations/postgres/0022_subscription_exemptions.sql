@@ -0,0 +1,7 @@
+-- Users let through the channel subscription check with "/admin exempt add";
+-- those from SUBSCRIPTION_EXEMPT_IDS are not stored
+CREATE TABLE IF NOT EXISTS subscription_exemptions (
+	user_id BIGINT PRIMARY KEY,
+	added_by BIGINT NOT NULL,
+	added_at TIMESTAMP NOT NULL
+);
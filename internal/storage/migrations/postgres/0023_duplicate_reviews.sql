@@ -0,0 +1,11 @@
+-- Bursts of identical reviews: opted-in users answer only a share of them
+ALTER TABLE user_configs ADD COLUMN duplicate_share INTEGER NOT NULL DEFAULT 0;
+
+-- Reviews of such bursts left unanswered on purpose; later cycles skip them
+-- too, since they stay unanswered on WB
+CREATE TABLE IF NOT EXISTS duplicate_skips (
+	user_id BIGINT NOT NULL,
+	feedback_id TEXT NOT NULL,
+	skipped_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, feedback_id)
+);
@@ -0,0 +1,5 @@
+-- Expiry of the WB token, read from its "exp" claim when it is saved; NULL
+-- when the token carries none. token_expiry_notice is the last reminder
+-- sent about it (TokenNotice* in Go) and is reset with a new token
+ALTER TABLE user_configs ADD COLUMN token_expires_at TIMESTAMP;
+ALTER TABLE user_configs ADD COLUMN token_expiry_notice INTEGER NOT NULL DEFAULT 0;
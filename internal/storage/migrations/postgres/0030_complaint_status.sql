@@ -0,0 +1,3 @@
+-- Filed complaints are polled for their moderation outcome, the longest
+-- unchecked first
+CREATE INDEX IF NOT EXISTS idx_complaints_user_status ON complaints(user_id, status, updated_at);
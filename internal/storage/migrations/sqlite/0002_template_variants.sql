@@ -0,0 +1,9 @@
+-- Extra reply variants rotated with the main templates
+CREATE TABLE IF NOT EXISTS templates (
+	user_id INTEGER NOT NULL,
+	category TEXT NOT NULL,
+	idx INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, category, idx)
+);
@@ -0,0 +1,6 @@
+-- Users blocked by the admin; the bot ignores their messages
+CREATE TABLE IF NOT EXISTS banned_users (
+	user_id INTEGER PRIMARY KEY,
+	reason TEXT NOT NULL DEFAULT '',
+	banned_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,85 @@
+-- Timestamps used to be written with Go's time.Time.String() layout, e.g.
+-- "2025-10-20 13:04:05.123456789 +0300 MSK m=+0.01", partly in server local
+-- time. Such text does not sort chronologically once offsets differ. Rewrite
+-- it as UTC in the driver's ISO-8601 layout, "2025-10-20 10:04:05.123456789+00:00".
+--
+-- In a legacy value the first 19 characters are the wall time, an
+-- optional fraction follows, then a space and the "+hhmm" offset. New-style
+-- values have no space after the wall time and are left alone.
+
+UPDATE processed SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE processed_archive SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE user_configs SET updated_at = datetime(substr(updated_at, 1, 19)
+		|| substr(updated_at, instr(substr(updated_at, 20), ' ') + 20, 3) || ':' || substr(updated_at, instr(substr(updated_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(updated_at, 20, 1) = '.' THEN substr(updated_at, 20, instr(substr(updated_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(updated_at, 20), ' ') > 0;
+
+UPDATE user_configs SET poll_asked_at = datetime(substr(poll_asked_at, 1, 19)
+		|| substr(poll_asked_at, instr(substr(poll_asked_at, 20), ' ') + 20, 3) || ':' || substr(poll_asked_at, instr(substr(poll_asked_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(poll_asked_at, 20, 1) = '.' THEN substr(poll_asked_at, 20, instr(substr(poll_asked_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(poll_asked_at, 20), ' ') > 0;
+
+UPDATE category_benchmarks SET computed_at = datetime(substr(computed_at, 1, 19)
+		|| substr(computed_at, instr(substr(computed_at, 20), ' ') + 20, 3) || ':' || substr(computed_at, instr(substr(computed_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(computed_at, 20, 1) = '.' THEN substr(computed_at, 20, instr(substr(computed_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(computed_at, 20), ' ') > 0;
+
+UPDATE exclusions SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE changelog SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE blackouts SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE satisfaction SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE templates SET created_at = datetime(substr(created_at, 1, 19)
+		|| substr(created_at, instr(substr(created_at, 20), ' ') + 20, 3) || ':' || substr(created_at, instr(substr(created_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(created_at, 20, 1) = '.' THEN substr(created_at, 20, instr(substr(created_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(created_at, 20), ' ') > 0;
+
+UPDATE banned_users SET banned_at = datetime(substr(banned_at, 1, 19)
+		|| substr(banned_at, instr(substr(banned_at, 20), ' ') + 20, 3) || ':' || substr(banned_at, instr(substr(banned_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(banned_at, 20, 1) = '.' THEN substr(banned_at, 20, instr(substr(banned_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(banned_at, 20), ' ') > 0;
+
+UPDATE schema_version SET applied_at = datetime(substr(applied_at, 1, 19)
+		|| substr(applied_at, instr(substr(applied_at, 20), ' ') + 20, 3) || ':' || substr(applied_at, instr(substr(applied_at, 20), ' ') + 23, 2))
+	|| CASE WHEN substr(applied_at, 20, 1) = '.' THEN substr(applied_at, 20, instr(substr(applied_at, 20), ' ') - 1) ELSE '' END
+	|| '+00:00'
+WHERE instr(substr(applied_at, 20), ' ') > 0;
+
+-- Range scans by time: archival, history, statistics windows
+CREATE INDEX IF NOT EXISTS idx_processed_created_at ON processed(created_at);
+CREATE INDEX IF NOT EXISTS idx_processed_user_created_at ON processed(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_processed_archive_created_at ON processed_archive(created_at);
@@ -0,0 +1,2 @@
+-- Language of bot messages chosen with /language; empty means Russian
+ALTER TABLE user_configs ADD COLUMN language TEXT NOT NULL DEFAULT '';
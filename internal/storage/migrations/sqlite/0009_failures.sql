@@ -0,0 +1,12 @@
+-- Failed cycle steps shown to the user on the "⚠️ Ошибки" screen
+CREATE TABLE IF NOT EXISTS failures (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	feedback_id TEXT NOT NULL DEFAULT '',
+	kind TEXT NOT NULL DEFAULT 'feedback',
+	stage TEXT NOT NULL,
+	cause TEXT NOT NULL,
+	message TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_failures_user_created_at ON failures(user_id, created_at);
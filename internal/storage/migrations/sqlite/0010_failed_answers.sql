@@ -0,0 +1,14 @@
+-- Reviews whose answer could not be posted, retried with exponential backoff
+-- and shown to the user on the "⚠️ Проблемные отзывы" screen
+CREATE TABLE IF NOT EXISTS failed_answers (
+	user_id INTEGER NOT NULL,
+	id TEXT NOT NULL,
+	kind TEXT NOT NULL DEFAULT 'feedback',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	cause TEXT NOT NULL DEFAULT '',
+	last_error TEXT NOT NULL DEFAULT '',
+	first_failed_at TIMESTAMP NOT NULL,
+	next_retry_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, id)
+);
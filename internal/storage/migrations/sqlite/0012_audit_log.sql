@@ -0,0 +1,10 @@
+-- Significant account events for support, shown by "/admin audit"
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	actor INTEGER NOT NULL DEFAULT 0,
+	action TEXT NOT NULL,
+	details TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_created_at ON audit_log(user_id, created_at);
@@ -0,0 +1,9 @@
+-- Users' places in multi-step bot dialogs, so they survive restarts and
+-- reach whichever replica handles the next message
+CREATE TABLE IF NOT EXISTS conversations (
+	user_id INTEGER PRIMARY KEY,
+	state INTEGER NOT NULL,
+	subject TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at);
@@ -0,0 +1,2 @@
+-- Chat (e.g. the seller's support group) that gets a copy of 1-2 star reviews
+ALTER TABLE user_configs ADD COLUMN escalation_chat_id INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Semi-automatic mode: negative reviews of opted-in users get an AI draft
+-- that is posted only once the user approves it
+ALTER TABLE user_configs ADD COLUMN ai_drafts INTEGER NOT NULL DEFAULT 0;
+
+-- Drafts with the review details needed to record the answer; later cycles
+-- leave drafted reviews alone whatever the status (Draft* in Go)
+CREATE TABLE IF NOT EXISTS reply_drafts (
+	user_id INTEGER NOT NULL,
+	feedback_id TEXT NOT NULL,
+	rating INTEGER NOT NULL DEFAULT 0,
+	subject_name TEXT NOT NULL DEFAULT '',
+	nm_id INTEGER NOT NULL DEFAULT 0,
+	product_name TEXT NOT NULL DEFAULT '',
+	supplier_article TEXT NOT NULL DEFAULT '',
+	reviewed_at TIMESTAMP,
+	text TEXT NOT NULL,
+	status TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, feedback_id)
+);
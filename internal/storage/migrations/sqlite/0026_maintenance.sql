@@ -0,0 +1,8 @@
+-- Global maintenance mode switched with "/admin maintenance": while the row
+-- exists, only admins can use the bot and no cycles or jobs run
+CREATE TABLE IF NOT EXISTS maintenance (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	reason TEXT NOT NULL DEFAULT '',
+	started_by INTEGER NOT NULL,
+	started_at TIMESTAMP NOT NULL
+);
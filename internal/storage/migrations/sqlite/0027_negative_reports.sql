@@ -0,0 +1,22 @@
+-- Opted-in users get a daily message with the negative reviews of the last
+-- 24 hours and the replies posted to them
+ALTER TABLE user_configs ADD COLUMN negative_report INTEGER NOT NULL DEFAULT 0;
+
+-- 1–3★ reviews as first fetched for the report; replies are taken from
+-- processed. Rows older than NegativeReviewRetention are dropped
+CREATE TABLE IF NOT EXISTS negative_reviews (
+	user_id INTEGER NOT NULL,
+	feedback_id TEXT NOT NULL,
+	rating INTEGER NOT NULL,
+	subject_name TEXT NOT NULL DEFAULT '',
+	nm_id INTEGER NOT NULL DEFAULT 0,
+	product_name TEXT NOT NULL DEFAULT '',
+	supplier_article TEXT NOT NULL DEFAULT '',
+	text TEXT NOT NULL DEFAULT '',
+	pros TEXT NOT NULL DEFAULT '',
+	cons TEXT NOT NULL DEFAULT '',
+	reviewed_at TIMESTAMP NULL,
+	seen_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, feedback_id)
+);
+CREATE INDEX IF NOT EXISTS idx_negative_reviews_seen_at ON negative_reviews(seen_at);
@@ -0,0 +1,3 @@
+-- HTTP or SOCKS5 proxy for the user's WB API requests; empty uses the
+-- bot-wide WB_PROXY or none
+ALTER TABLE user_configs ADD COLUMN wb_proxy TEXT NOT NULL DEFAULT '';
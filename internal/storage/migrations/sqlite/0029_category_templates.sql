@@ -0,0 +1,11 @@
+-- Replies for the reviews of one WB product category (subjectId) that
+-- replace the user's main templates; an empty text keeps the main one
+CREATE TABLE IF NOT EXISTS category_templates (
+	user_id INTEGER NOT NULL,
+	subject_id INTEGER NOT NULL,
+	subject_name TEXT NOT NULL DEFAULT '',
+	template_good TEXT NOT NULL DEFAULT '',
+	template_bad TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, subject_id)
+);
@@ -3,22 +3,26 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // postgresStore is a PostgreSQL implementation of Store and ConfigStore.
 // It supports multiple concurrent connections and is optimized for high load.
 type postgresStore struct {
-	db *sql.DB
+	db     *sql.DB
+	tokens *TokenCipher // nil stores WB tokens in plaintext
+	locks  *pgLocks
 }
 
 // NewPostgreSQL opens a PostgreSQL connection and ensures the schema exists.
 // dsn should be in format: "host=localhost port=5432 user=postgres password=postgres dbname=feedbacks sslmode=disable"
 // Returns both Store and ConfigStore interfaces.
-func NewPostgreSQL(dsn string) (Store, ConfigStore, error) {
+func NewPostgreSQL(dsn string, tokens *TokenCipher) (Store, ConfigStore, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to open postgres connection: %w", err)
@@ -35,16 +39,32 @@ func NewPostgreSQL(dsn string) (Store, ConfigStore, error) {
 		return nil, nil, fmt.Errorf("failed to ping postgres: %w", err)
 	}
 
-	if err := migratePostgres(db); err != nil {
+	if err := migrate(db, postgresDialect); err != nil {
 		_ = db.Close()
 		return nil, nil, fmt.Errorf("failed to migrate postgres schema: %w", err)
 	}
+	if err := encryptStoredTokens(context.Background(), db, tokens, `UPDATE user_configs SET wb_token = $1 WHERE user_id = $2`); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("WB token encryption: %w", err)
+	}
+	if err := backfillTokenExpiry(context.Background(), db, tokens, `UPDATE user_configs SET token_expires_at = $1 WHERE user_id = $2`); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("WB token expiry: %w", err)
+	}
 
-	store := &postgresStore{db: db}
+	locks, err := newPGLocks(dsn)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	store := &postgresStore{db: db, tokens: tokens, locks: locks}
 	return store, store, nil
 }
 
-func migratePostgres(db *sql.DB) error {
+// baselinePostgres creates the schema as of version 1, upgrading databases
+// created before migrations were versioned. It is frozen: new schema changes
+// go into migrations/postgres (see migrate.go).
+func baselinePostgres(db *sql.DB) error {
 	// Create processed table with user_id support
 	const processedTable = `
 	CREATE TABLE IF NOT EXISTS processed (
@@ -75,6 +95,127 @@ func migratePostgres(db *sql.DB) error {
 		return fmt.Errorf("failed to create user_configs table: %w", err)
 	}
 
+	// Columns added after the initial release
+	const configColumns = `
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS work_start TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS work_end TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS template_off_hours TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS benchmark_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS template_question TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS poll_asked_at TIMESTAMP;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS token_hash TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS wb_base_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS daily_limit INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS humanize BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS changelog_seen_id BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE user_configs ADD COLUMN IF NOT EXISTS sentiment_routing BOOLEAN NOT NULL DEFAULT FALSE;
+	CREATE INDEX IF NOT EXISTS idx_user_configs_token_hash ON user_configs(token_hash);
+	`
+	if _, err := db.Exec(configColumns); err != nil {
+		return fmt.Errorf("failed to add user_configs columns: %w", err)
+	}
+	if err := backfillTokenHashes(db, `UPDATE user_configs SET token_hash = $1 WHERE user_id = $2`); err != nil {
+		return fmt.Errorf("failed to backfill token hashes: %w", err)
+	}
+
+	// Answer metadata for analytics
+	const processedColumns = `
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS rating INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS subject_name TEXT NOT NULL DEFAULT '';
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS response_seconds BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'feedback';
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS template_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE processed ADD COLUMN IF NOT EXISTS reply_text TEXT NOT NULL DEFAULT '';
+	`
+	if _, err := db.Exec(processedColumns); err != nil {
+		return fmt.Errorf("failed to add processed columns: %w", err)
+	}
+
+	// Category benchmarks, recomputed nightly
+	const benchmarksTable = `
+	CREATE TABLE IF NOT EXISTS category_benchmarks (
+		subject_name TEXT PRIMARY KEY,
+		users BIGINT NOT NULL,
+		answers BIGINT NOT NULL,
+		avg_response_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+		avg_rating DOUBLE PRECISION NOT NULL DEFAULT 0,
+		prev_avg_rating DOUBLE PRECISION NOT NULL DEFAULT 0,
+		computed_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(benchmarksTable); err != nil {
+		return fmt.Errorf("failed to create category_benchmarks table: %w", err)
+	}
+
+	// Articles and reviews excluded from auto-answering
+	const exclusionsTable = `
+	CREATE TABLE IF NOT EXISTS exclusions (
+		user_id BIGINT NOT NULL,
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, kind, value)
+	);
+	`
+	if _, err := db.Exec(exclusionsTable); err != nil {
+		return fmt.Errorf("failed to create exclusions table: %w", err)
+	}
+
+	// Answers posted per user per day, for the daily answer limit
+	const countersTable = `
+	CREATE TABLE IF NOT EXISTS answer_counters (
+		user_id BIGINT NOT NULL,
+		day TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, day)
+	);
+	`
+	if _, err := db.Exec(countersTable); err != nil {
+		return fmt.Errorf("failed to create answer_counters table: %w", err)
+	}
+
+	// In-bot "what's new" announcements
+	const changelogTable = `
+	CREATE TABLE IF NOT EXISTS changelog (
+		id BIGSERIAL PRIMARY KEY,
+		version TEXT NOT NULL,
+		text TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(changelogTable); err != nil {
+		return fmt.Errorf("failed to create changelog table: %w", err)
+	}
+
+	// Global maintenance windows during which no cycles run
+	const blackoutsTable = `
+	CREATE TABLE IF NOT EXISTS blackouts (
+		id BIGSERIAL PRIMARY KEY,
+		spec TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(blackoutsTable); err != nil {
+		return fmt.Errorf("failed to create blackouts table: %w", err)
+	}
+
+	// Answers to the periodic satisfaction poll
+	const satisfactionTable = `
+	CREATE TABLE IF NOT EXISTS satisfaction (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL,
+		score INTEGER NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	`
+	if _, err := db.Exec(satisfactionTable); err != nil {
+		return fmt.Errorf("failed to create satisfaction table: %w", err)
+	}
+
 	return nil
 }
 
@@ -82,7 +223,9 @@ func migratePostgres(db *sql.DB) error {
 func (s *postgresStore) Exists(ctx context.Context, userID int64, id string) (bool, error) {
 	var exists int
 	err := s.db.QueryRowContext(ctx,
-		`SELECT 1 FROM processed WHERE user_id = $1 AND id = $2 LIMIT 1`,
+		`SELECT 1 FROM processed WHERE user_id = $1 AND id = $2
+		 UNION ALL SELECT 1 FROM processed_archive WHERE user_id = $1 AND id = $2
+		 LIMIT 1`,
 		userID, id).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
@@ -95,51 +238,333 @@ func (s *postgresStore) Save(ctx context.Context, userID int64, id string) error
 	_, err := s.db.ExecContext(ctx,
 		`INSERT INTO processed (user_id, id, created_at) VALUES ($1, $2, $3)
 		 ON CONFLICT (user_id, id) DO NOTHING`,
-		userID, id, time.Now())
+		userID, id, utcNow())
+	return err
+}
+
+// SaveAnswer inserts the ID with answer metadata; duplicates are ignored like in Save.
+func (s *postgresStore) SaveAnswer(ctx context.Context, userID int64, rec AnswerRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO processed (`+insertColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 ON CONFLICT (user_id, id) DO NOTHING`,
+		answerArgs(userID, rec, utcNow())...)
 	return err
 }
 
+// ExistsBatch returns which of ids are stored in processed or
+// processed_archive. PostgreSQL takes the whole list as one array parameter.
+func (s *postgresStore) ExistsBatch(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+	const query = `SELECT id FROM processed WHERE user_id = $1 AND id = ANY($2)
+		UNION SELECT id FROM processed_archive WHERE user_id = $1 AND id = ANY($2)`
+	if err := queryIDSet(ctx, s.db, found, query, userID, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// SaveBatch inserts answers with multi-row statements of up to batchRows
+// rows inside one transaction; duplicates are skipped via ON CONFLICT.
+func (s *postgresStore) SaveBatch(ctx context.Context, userID int64, recs []AnswerRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	for start := 0; start < len(recs); start += batchRows {
+		chunk := recs[start:min(start+batchRows, len(recs))]
+		var values strings.Builder
+		args := make([]any, 0, len(chunk)*answerArgCount)
+		for i, rec := range chunk {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			values.WriteByte('(')
+			for j := range answerArgCount {
+				if j > 0 {
+					values.WriteString(", ")
+				}
+				fmt.Fprintf(&values, "$%d", i*answerArgCount+j+1)
+			}
+			values.WriteByte(')')
+			args = append(args, answerArgs(userID, rec, now)...)
+		}
+		stmt := `INSERT INTO processed (` + insertColumns + `)
+			VALUES ` + values.String() + `
+			ON CONFLICT (user_id, id) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateAnswerText replaces the stored reply of an edited answer.
+func (s *postgresStore) UpdateAnswerText(ctx context.Context, userID int64, id, source, text string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, stmt := range []string{
+		`UPDATE processed SET reply_text = $1, source = $2, template_version = '' WHERE user_id = $3 AND id = $4`,
+		`UPDATE processed_archive SET reply_text = $1, source = $2, template_version = '' WHERE user_id = $3 AND id = $4`,
+	} {
+		res, err := tx.ExecContext(ctx, stmt, text, source, userID, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to update answer text: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		total += n
+	}
+	return total > 0, tx.Commit()
+}
+
+// RecentAnswers returns the user's latest answers, newest first.
+func (s *postgresStore) RecentAnswers(ctx context.Context, userID int64, limit int) ([]AnswerRecord, error) {
+	const query = `
+		SELECT ` + answerColumns + `
+		FROM processed WHERE user_id = $1
+		ORDER BY created_at DESC LIMIT $2
+	`
+	return queryAnswers(ctx, s.db, query, userID, limit)
+}
+
+// ListAnswers returns all of the user's answers, oldest first.
+func (s *postgresStore) ListAnswers(ctx context.Context, userID int64) ([]AnswerRecord, error) {
+	const query = `
+		SELECT ` + answerColumns + ` FROM processed WHERE user_id = $1
+		UNION ALL SELECT ` + answerColumns + ` FROM processed_archive WHERE user_id = $1
+		ORDER BY created_at
+	`
+	return queryAnswers(ctx, s.db, query, userID)
+}
+
+// ArchiveAnswers moves answers older than before into processed_archive.
+func (s *postgresStore) ArchiveAnswers(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const copyStmt = `INSERT INTO processed_archive (` + archiveColumns + `)
+		SELECT ` + archiveColumns + ` FROM processed WHERE created_at < $1
+		ON CONFLICT (user_id, id) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, copyStmt, dbTime(before)); err != nil {
+		return 0, fmt.Errorf("failed to copy answers to archive: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM processed WHERE created_at < $1`, dbTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived answers: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// DeleteProcessedOlderThan deletes the user's answers stored before the cutoff.
+func (s *postgresStore) DeleteProcessedOlderThan(ctx context.Context, userID int64, before time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, stmt := range []string{
+		`DELETE FROM processed WHERE user_id = $1 AND created_at < $2`,
+		`DELETE FROM processed_archive WHERE user_id = $1 AND created_at < $2`,
+	} {
+		res, err := tx.ExecContext(ctx, stmt, userID, dbTime(before))
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete old answers: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+// ProductBreakdown aggregates the user's review answers within window by product.
+func (s *postgresStore) ProductBreakdown(ctx context.Context, userID int64, window time.Duration, limit int) ([]ProductStats, error) {
+	const query = `
+		SELECT nm_id, MAX(product_name), MAX(supplier_article), COUNT(*),
+			COALESCE(AVG(CASE WHEN rating > 0 THEN rating END), 0)::DOUBLE PRECISION
+		FROM processed
+		WHERE user_id = $1 AND nm_id <> 0 AND created_at >= $2
+		GROUP BY nm_id
+		ORDER BY COUNT(*) DESC, nm_id
+		LIMIT $3
+	`
+	return queryProductStats(ctx, s.db, query, userID, utcNow().Add(-window), limit)
+}
+
+// PeriodSummary aggregates the user's answers posted in [from, to).
+func (s *postgresStore) PeriodSummary(ctx context.Context, userID int64, from, to time.Time) (PeriodSummary, error) {
+	const query = `
+		SELECT kind, rating, COUNT(*)
+		FROM processed
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY kind, rating
+	`
+	return queryPeriodSummary(ctx, s.db, query, userID, dbTime(from), dbTime(to))
+}
+
+// SourceBreakdown aggregates the user's answers within window by source and template version.
+func (s *postgresStore) SourceBreakdown(ctx context.Context, userID int64, window time.Duration) ([]SourceStats, error) {
+	const query = `
+		SELECT source, template_version, COUNT(*),
+			COALESCE(AVG(CASE WHEN rating > 0 THEN rating END), 0)::DOUBLE PRECISION
+		FROM processed
+		WHERE user_id = $1 AND source <> '' AND created_at >= $2
+		GROUP BY source, template_version
+		ORDER BY COUNT(*) DESC
+	`
+	return querySourceStats(ctx, s.db, query, userID, utcNow().Add(-window))
+}
+
+// Ping checks that a connection to the server can be made and used.
+func (s *postgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 // Close closes the underlying *sql.DB.
 func (s *postgresStore) Close() error {
+	s.locks.close()
 	return s.db.Close()
 }
 
+// TryLock implements Locker with a PostgreSQL advisory lock.
+func (s *postgresStore) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	return s.locks.TryLock(ctx, key)
+}
+
 // SaveUserConfig saves or updates user configuration.
 func (s *postgresStore) SaveUserConfig(ctx context.Context, chatID int64, wbToken, tplGood, tplBad string) error {
+	// New users start with all existing changelog entries marked as read
+	// and a new token with no expiry notices sent
 	const stmt = `
-		INSERT INTO user_configs (user_id, wb_token, template_good, template_bad, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO user_configs (user_id, wb_token, template_good, template_bad, updated_at, token_hash, changelog_seen_id, token_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT COALESCE(MAX(id), 0) FROM changelog), $7)
 		ON CONFLICT (user_id) DO UPDATE SET
 			wb_token = EXCLUDED.wb_token,
 			template_good = EXCLUDED.template_good,
 			template_bad = EXCLUDED.template_bad,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			token_expiry_notice = CASE WHEN user_configs.token_hash = EXCLUDED.token_hash THEN user_configs.token_expiry_notice ELSE 0 END,
+			token_hash = EXCLUDED.token_hash,
+			token_expires_at = EXCLUDED.token_expires_at
 	`
-	_, err := s.db.ExecContext(ctx, stmt, chatID, wbToken, tplGood, tplBad, time.Now())
+	stored, err := s.tokens.seal(wbToken)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, stmt, chatID, stored, tplGood, tplBad, utcNow(), TokenHash(wbToken), nullTime(TokenExpiresAt(wbToken)))
 	return err
 }
 
 // GetUserConfig retrieves user configuration by chat ID.
 func (s *postgresStore) GetUserConfig(ctx context.Context, chatID int64) (*UserConfig, error) {
-	const stmt = `
-		SELECT user_id, wb_token, template_good, template_bad, updated_at
+	stmt := `
+		SELECT ` + userConfigColumns + `
 		FROM user_configs WHERE user_id = $1 LIMIT 1
 	`
-	var cfg UserConfig
-	err := s.db.QueryRowContext(ctx, stmt, chatID).Scan(
-		&cfg.UserID,
-		&cfg.WBToken,
-		&cfg.TemplateGood,
-		&cfg.TemplateBad,
-		&cfg.UpdatedAt,
-	)
+	cfg, err := scanUserConfig(s.db.QueryRowContext(ctx, stmt, chatID), s.tokens)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	return cfg, nil
+}
+
+// ListUserIDs returns IDs of all users with a stored config.
+func (s *postgresStore) ListUserIDs(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM user_configs ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListUsersByTokenHash returns users registered with the same WB token.
+func (s *postgresStore) ListUsersByTokenHash(ctx context.Context, hash string) ([]int64, error) {
+	return queryUserIDs(ctx, s.db, `SELECT user_id FROM user_configs WHERE token_hash = $1 ORDER BY user_id`, hash)
+}
+
+// ListActiveConfigs returns configs that can run unattended, ordered by user ID.
+func (s *postgresStore) ListActiveConfigs(ctx context.Context) ([]*UserConfig, error) {
+	const stmt = `
+		SELECT ` + userConfigColumns + `
+		FROM user_configs
+		WHERE wb_token <> '' AND wb_token <> 'not_set'
+			AND template_good <> '' AND template_bad <> '' AND NOT paused
+		ORDER BY user_id
+	`
+	return queryUserConfigs(ctx, s.db, s.tokens, stmt)
+}
+
+// ListUserConfigs returns one page of all configs, ordered by user ID.
+func (s *postgresStore) ListUserConfigs(ctx context.Context, offset, limit int) ([]*UserConfig, error) {
+	const stmt = `
+		SELECT ` + userConfigColumns + `
+		FROM user_configs
+		ORDER BY user_id
+		LIMIT $1 OFFSET $2
+	`
+	return queryUserConfigs(ctx, s.db, s.tokens, stmt, limit, offset)
+}
+
+// UpdateBusinessHours sets timezone and working hours for the user.
+func (s *postgresStore) UpdateBusinessHours(ctx context.Context, chatID int64, timezone, workStart, workEnd string) error {
+	const stmt = `UPDATE user_configs SET timezone = $1, work_start = $2, work_end = $3, updated_at = $4 WHERE user_id = $5`
+	_, err := s.db.ExecContext(ctx, stmt, timezone, workStart, workEnd, utcNow(), chatID)
+	return err
+}
+
+// SetTimezone sets the user's timezone and leaves working hours untouched.
+func (s *postgresStore) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	const stmt = `UPDATE user_configs SET timezone = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, timezone, utcNow(), chatID)
+	return err
+}
+
+// UpdateOffHoursTemplate sets the reply used outside business hours.
+func (s *postgresStore) UpdateOffHoursTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_off_hours = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
 }
 
 // DeleteUserConfig removes user configuration from database.
@@ -156,6 +581,58 @@ func (s *postgresStore) DeleteUserConfig(ctx context.Context, chatID int64) erro
 		return fmt.Errorf("failed to delete processed feedbacks: %w", err)
 	}
 
+	if _, err := tx.ExecContext(ctx, `DELETE FROM processed_archive WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete archived answers: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM exclusions WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete exclusions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM answer_counters WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete answer counters: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete template variants: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM category_templates WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete category templates: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM failures WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete failures: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cycle_results WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete cycle results: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_snapshots WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete review snapshots: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM duplicate_skips WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete duplicate skips: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reply_drafts WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete reply drafts: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM negative_reviews WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete negative reviews: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM complaints WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete complaints: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM conversations WHERE user_id = $1`, chatID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
 	// Delete user config
 	if _, err := tx.ExecContext(ctx, `DELETE FROM user_configs WHERE user_id = $1`, chatID); err != nil {
 		return fmt.Errorf("failed to delete user config: %w", err)
@@ -168,15 +645,1065 @@ func (s *postgresStore) DeleteUserConfig(ctx context.Context, chatID int64) erro
 	return nil
 }
 
-// GetStats retrieves statistics about users.
+var postgresStatsQueries = statsQueries{
+	engine: "postgres",
+	counts: `SELECT
+		(SELECT COUNT(DISTINCT user_id) FROM user_configs),
+		(SELECT COUNT(*) FROM user_configs
+			WHERE wb_token <> '' AND wb_token <> 'not_set' AND template_good <> '' AND template_bad <> ''),
+		(SELECT COUNT(DISTINCT f.user_id) FROM failures f JOIN user_configs c ON c.user_id = f.user_id
+			WHERE f.cause IN ('unauthorized', 'forbidden') AND f.created_at > c.updated_at),
+		(SELECT COUNT(*) FROM processed WHERE kind = 'feedback') + (SELECT COUNT(*) FROM processed_archive WHERE kind = 'feedback'),
+		(SELECT COUNT(*) FROM processed WHERE kind = 'feedback' AND created_at >= $1)`,
+	version:    `SHOW server_version`,
+	size:       `SELECT pg_database_size(current_database())`,
+	complaints: `SELECT status, COUNT(*) FROM complaints GROUP BY status`,
+}
+
+// GetStats retrieves statistics about users, answers and the database.
 func (s *postgresStore) GetStats(ctx context.Context) (*Stats, error) {
-	var totalUsers int64
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(DISTINCT user_id) FROM user_configs`).Scan(&totalUsers)
+	return getStats(ctx, s.db, postgresStatsQueries)
+}
+
+// UpdateQuestionTemplate sets the reply for product questions.
+func (s *postgresStore) UpdateQuestionTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_question = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// UpdateTemplate replaces the good or bad reply template.
+func (s *postgresStore) UpdateTemplate(ctx context.Context, chatID int64, category, text string) error {
+	col, err := templateColumn(category)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	stmt := `UPDATE user_configs SET ` + col + ` = $1, updated_at = $2 WHERE user_id = $3`
+	_, err = s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// UpdateMediaTemplate sets the reply for positive reviews with photos or video.
+func (s *postgresStore) UpdateMediaTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_media = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// RecordFailure stores a failed cycle step and drops the user's entries
+// older than FailureRetention.
+func (s *postgresStore) RecordFailure(ctx context.Context, userID int64, f Failure) error {
+	kind := f.Kind
+	if kind == "" {
+		kind = KindFeedback
+	}
+	now := utcNow()
+	const stmt = `INSERT INTO failures (user_id, feedback_id, kind, stage, cause, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, f.FeedbackID, kind, f.Stage, f.Cause, truncateMessage(f.Message), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failures WHERE user_id = $1 AND created_at < $2`, userID, now.Add(-FailureRetention))
+	return err
+}
+
+// RecentFailures returns the user's latest failures, newest first.
+func (s *postgresStore) RecentFailures(ctx context.Context, userID int64, limit int) ([]Failure, error) {
+	const query = `SELECT feedback_id, kind, stage, cause, message, created_at
+		FROM failures WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2`
+	return queryFailures(ctx, s.db, query, userID, limit)
+}
+
+// SaveComplaint upserts a complaint about a review.
+func (s *postgresStore) SaveComplaint(ctx context.Context, userID int64, c Complaint) error {
+	now := utcNow()
+	const stmt = `INSERT INTO complaints (user_id, feedback_id, reason_id, reason, rating, nm_id, status, error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, feedback_id) DO UPDATE SET
+			reason_id = EXCLUDED.reason_id,
+			reason = EXCLUDED.reason,
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			updated_at = EXCLUDED.updated_at`
+	_, err := s.db.ExecContext(ctx, stmt, userID, c.FeedbackID, c.ReasonID, c.Reason, c.Rating, c.NmID, c.Status, truncateMessage(c.Error), now, now)
+	return err
+}
+
+// RecentComplaints returns the user's latest complaints, newest first.
+func (s *postgresStore) RecentComplaints(ctx context.Context, userID int64, limit int) ([]Complaint, error) {
+	const query = `SELECT ` + complaintColumns + `
+		FROM complaints WHERE user_id = $1
+		ORDER BY updated_at DESC, feedback_id LIMIT $2`
+	return queryComplaints(ctx, s.db, query, userID, limit)
+}
+
+// PendingComplaints returns filed complaints last checked before
+// checkedBefore, the longest unchecked first.
+func (s *postgresStore) PendingComplaints(ctx context.Context, userID int64, checkedBefore time.Time, limit int) ([]Complaint, error) {
+	const query = `SELECT ` + complaintColumns + `
+		FROM complaints WHERE user_id = $1 AND status = $2 AND updated_at < $3
+		ORDER BY updated_at, feedback_id LIMIT $4`
+	return queryComplaints(ctx, s.db, query, userID, ComplaintFiled, dbTime(checkedBefore), limit)
+}
+
+// SetComplaintStatus sets the status of a complaint and its check time.
+func (s *postgresStore) SetComplaintStatus(ctx context.Context, userID int64, feedbackID, status string) error {
+	const stmt = `UPDATE complaints SET status = $1, updated_at = $2 WHERE user_id = $3 AND feedback_id = $4`
+	_, err := s.db.ExecContext(ctx, stmt, status, utcNow(), userID, feedbackID)
+	return err
+}
+
+// ComplaintStats counts the user's complaints filed in [from, to) by status.
+func (s *postgresStore) ComplaintStats(ctx context.Context, userID int64, from, to time.Time) (ComplaintStats, error) {
+	const query = `SELECT status, COUNT(*) FROM complaints
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY status`
+	return countComplaints(ctx, s.db, query, userID, dbTime(from), dbTime(to))
+}
+
+// RecordAudit appends an audit event and drops the user's entries older
+// than AuditRetention.
+func (s *postgresStore) RecordAudit(ctx context.Context, ev AuditEvent) error {
+	now := utcNow()
+	const stmt = `INSERT INTO audit_log (user_id, actor, action, details, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+	if _, err := s.db.ExecContext(ctx, stmt, ev.UserID, ev.Actor, ev.Action, truncateMessage(ev.Details), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM audit_log WHERE user_id = $1 AND created_at < $2`, ev.UserID, now.Add(-AuditRetention))
+	return err
+}
+
+// RecentAudit returns the user's latest audit events, newest first.
+func (s *postgresStore) RecentAudit(ctx context.Context, userID int64, limit int) ([]AuditEvent, error) {
+	const query = `SELECT user_id, actor, action, details, created_at
+		FROM audit_log WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2`
+	return queryAuditEvents(ctx, s.db, query, userID, limit)
+}
+
+var postgresSubscriptionQueries = subscriptionQueries{
+	ensure: `INSERT INTO subscriptions (trial_started_at, updated_at, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`,
+	get:    `SELECT trial_started_at, trial_answers, paid_until FROM subscriptions WHERE user_id = $1`,
+	extend: `UPDATE subscriptions SET paid_until = $1, updated_at = $2 WHERE user_id = $3`,
+}
+
+// GetSubscription returns the user's billing state, starting the trial on first use.
+func (s *postgresStore) GetSubscription(ctx context.Context, userID int64) (Subscription, error) {
+	return getSubscription(ctx, s.db, postgresSubscriptionQueries, userID)
+}
+
+// AddTrialAnswers counts n more answers against the user's trial.
+func (s *postgresStore) AddTrialAnswers(ctx context.Context, userID int64, n int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE subscriptions SET trial_answers = trial_answers + $1, updated_at = $2 WHERE user_id = $3`,
+		n, utcNow(), userID)
+	return err
+}
+
+// ExtendSubscription adds d of paid access and returns the new end. The row
+// is locked so that concurrent extensions add up.
+func (s *postgresStore) ExtendSubscription(ctx context.Context, userID int64, d time.Duration) (time.Time, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	until, err := extendSubscription(ctx, tx, postgresLockedSubscriptionQueries, userID, d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until, tx.Commit()
+}
+
+// postgresLockedSubscriptionQueries locks the row read before an extension.
+var postgresLockedSubscriptionQueries = subscriptionQueries{
+	ensure: postgresSubscriptionQueries.ensure,
+	get:    postgresSubscriptionQueries.get + ` FOR UPDATE`,
+	extend: postgresSubscriptionQueries.extend,
+}
+
+// RecordPayment stores a payment and extends paid access, once per charge ID.
+func (s *postgresStore) RecordPayment(ctx context.Context, p Payment) (time.Time, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO payments (charge_id, user_id, provider_charge_id, amount, currency, days, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (charge_id) DO NOTHING`,
+		p.ChargeID, p.UserID, p.ProviderChargeID, p.Amount, p.Currency, p.Days, utcNow())
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		sub, err2 := getSubscription(ctx, tx, postgresSubscriptionQueries, p.UserID)
+		if err == nil {
+			err = err2
+		}
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return sub.PaidUntil, false, tx.Commit()
+	}
+	until, err := extendSubscription(ctx, tx, postgresLockedSubscriptionQueries, p.UserID, time.Duration(p.Days)*24*time.Hour)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return until, true, tx.Commit()
+}
+
+// SaveReferral records userID as invited by referrerID unless the user was
+// referred before or has already started the trial.
+func (s *postgresStore) SaveReferral(ctx context.Context, userID, referrerID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO referrals (user_id, referrer_id, created_at)
+		 SELECT $1::BIGINT, $2::BIGINT, $3 WHERE NOT EXISTS (SELECT 1 FROM subscriptions WHERE user_id = $1)
+		 ON CONFLICT (user_id) DO NOTHING`,
+		userID, referrerID, utcNow())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreditReferral credits the referrer of userID once.
+func (s *postgresStore) CreditReferral(ctx context.Context, userID int64, bonusDays int) (Referral, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Referral{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	r := Referral{UserID: userID, BonusDays: bonusDays, CreditedAt: now}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE referrals SET credited_at = $1, bonus_days = $2
+		 WHERE user_id = $3 AND credited_at IS NULL
+		 RETURNING referrer_id, created_at`,
+		now, bonusDays, userID).Scan(&r.ReferrerID, &r.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Referral{}, false, nil
+	}
+	if err != nil {
+		return Referral{}, false, err
+	}
+	r.CreatedAt = fromDB(r.CreatedAt)
+	if bonusDays > 0 {
+		if _, err := extendSubscription(ctx, tx, postgresLockedSubscriptionQueries, r.ReferrerID, time.Duration(bonusDays)*24*time.Hour); err != nil {
+			return Referral{}, false, err
+		}
+	}
+	return r, true, tx.Commit()
+}
+
+// ReferralStats summarises the referrals of referrerID.
+func (s *postgresStore) ReferralStats(ctx context.Context, referrerID int64) (ReferralStats, error) {
+	stats, err := queryReferralStats(ctx, s.db,
+		`SELECT $1::BIGINT, COUNT(*), COUNT(credited_at), COALESCE(SUM(bonus_days), 0)
+		 FROM referrals WHERE referrer_id = $1`,
+		referrerID)
+	if err != nil || len(stats) == 0 {
+		return ReferralStats{ReferrerID: referrerID}, err
 	}
-	return &Stats{
-		TotalUsers: totalUsers,
-	}, nil
+	return stats[0], nil
 }
 
+// TopReferrers returns the referrers with the most credited referrals.
+func (s *postgresStore) TopReferrers(ctx context.Context, limit int) ([]ReferralStats, error) {
+	return queryReferralStats(ctx, s.db,
+		`SELECT referrer_id, COUNT(*), COUNT(credited_at), COALESCE(SUM(bonus_days), 0)
+		 FROM referrals GROUP BY referrer_id
+		 ORDER BY COUNT(credited_at) DESC, COUNT(*) DESC, referrer_id LIMIT $1`,
+		limit)
+}
+
+// SaveFailedAnswer upserts the retry state of an answer that could not be posted.
+func (s *postgresStore) SaveFailedAnswer(ctx context.Context, userID int64, f FailedAnswer) error {
+	kind := f.Kind
+	if kind == "" {
+		kind = KindFeedback
+	}
+	now := utcNow()
+	const stmt = `INSERT INTO failed_answers (user_id, id, kind, attempts, cause, last_error, first_failed_at, next_retry_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, id) DO UPDATE SET
+			kind = EXCLUDED.kind,
+			attempts = EXCLUDED.attempts,
+			cause = EXCLUDED.cause,
+			last_error = EXCLUDED.last_error,
+			next_retry_at = EXCLUDED.next_retry_at,
+			updated_at = EXCLUDED.updated_at`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, f.FeedbackID, kind, f.Attempts, f.Cause, truncateMessage(f.LastError), now, dbTime(f.NextRetryAt), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failed_answers WHERE user_id = $1 AND updated_at < $2`, userID, now.Add(-FailureRetention))
+	return err
+}
+
+// ListFailedAnswers returns the user's failed answers, most attempts first.
+func (s *postgresStore) ListFailedAnswers(ctx context.Context, userID int64) ([]FailedAnswer, error) {
+	const query = `SELECT ` + failedAnswerColumns + `
+		FROM failed_answers WHERE user_id = $1
+		ORDER BY attempts DESC, updated_at DESC`
+	return queryFailedAnswers(ctx, s.db, query, userID)
+}
+
+// DeleteFailedAnswer forgets a failed answer once it was posted.
+func (s *postgresStore) DeleteFailedAnswer(ctx context.Context, userID int64, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failed_answers WHERE user_id = $1 AND id = $2`, userID, id)
+	return err
+}
+
+// RetryFailedAnswersNow makes all of the user's failed answers due now.
+func (s *postgresStore) RetryFailedAnswersNow(ctx context.Context, userID int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE failed_answers SET next_retry_at = $1 WHERE user_id = $2`, utcNow(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RecordCycleResult stores the outcome of a cycle and drops the user's
+// entries older than CycleResultRetention.
+func (s *postgresStore) RecordCycleResult(ctx context.Context, userID int64, r CycleResult) error {
+	now := utcNow()
+	const stmt = `INSERT INTO cycle_results (user_id, answered, failed, cause, message, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, r.Answered, r.Failed, r.Cause, truncateMessage(r.Message), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM cycle_results WHERE user_id = $1 AND finished_at < $2`, userID, now.Add(-CycleResultRetention))
+	return err
+}
+
+// CycleProblems returns the users whose latest cycle since the cutoff failed.
+func (s *postgresStore) CycleProblems(ctx context.Context, since time.Time, limit int) ([]CycleProblem, error) {
+	const query = `SELECT user_id, answered, failed, cause, message, finished_at
+		FROM cycle_results WHERE finished_at >= $1
+		ORDER BY user_id, finished_at DESC, id DESC`
+	return queryCycleProblems(ctx, s.db, query, limit, dbTime(since))
+}
+
+// RecentCycleResults returns the user's latest cycle outcomes, newest first.
+func (s *postgresStore) RecentCycleResults(ctx context.Context, userID int64, limit int) ([]CycleResult, error) {
+	const query = `SELECT answered, failed, cause, message, finished_at
+		FROM cycle_results WHERE user_id = $1
+		ORDER BY finished_at DESC, id DESC LIMIT $2`
+	return queryCycleResults(ctx, s.db, query, userID, limit)
+}
+
+// ReviewSnapshots returns the user's answered reviews as last seen on WB.
+func (s *postgresStore) ReviewSnapshots(ctx context.Context, userID int64) (map[string]ReviewSnapshot, error) {
+	const query = `SELECT feedback_id, rating, text_hash, checked_at FROM review_snapshots WHERE user_id = $1`
+	return queryReviewSnapshots(ctx, s.db, query, userID)
+}
+
+// SaveReviewSnapshots upserts snapshots in one transaction and drops the
+// user's snapshots older than ReviewSnapshotRetention.
+func (s *postgresStore) SaveReviewSnapshots(ctx context.Context, userID int64, snaps []ReviewSnapshot) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT INTO review_snapshots (user_id, feedback_id, rating, text_hash, checked_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, feedback_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			text_hash = EXCLUDED.text_hash,
+			checked_at = EXCLUDED.checked_at`
+	for _, snap := range snaps {
+		if _, err := tx.ExecContext(ctx, stmt, userID, snap.FeedbackID, snap.Rating, snap.TextHash, now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_snapshots WHERE user_id = $1 AND checked_at < $2`, userID, now.Add(-ReviewSnapshotRetention)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SkipDuplicates records reviews skipped as duplicates; recorded ones are ignored.
+func (s *postgresStore) SkipDuplicates(ctx context.Context, userID int64, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT INTO duplicate_skips (user_id, feedback_id, skipped_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, feedback_id) DO NOTHING`
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, stmt, userID, id, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SkippedDuplicates returns the subset of ids skipped as duplicates.
+func (s *postgresStore) SkippedDuplicates(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+	const query = `SELECT feedback_id FROM duplicate_skips WHERE user_id = $1 AND feedback_id = ANY($2)`
+	if err := queryIDSet(ctx, s.db, found, query, userID, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// CountSkippedDuplicates returns how many reviews the user skipped as duplicates.
+func (s *postgresStore) CountSkippedDuplicates(ctx context.Context, userID int64) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM duplicate_skips WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+// SaveNegativeReviews inserts new negative reviews in one transaction and
+// drops the user's reviews older than NegativeReviewRetention.
+func (s *postgresStore) SaveNegativeReviews(ctx context.Context, userID int64, reviews []NegativeReview) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT INTO negative_reviews (user_id, feedback_id, rating, subject_name, nm_id, product_name, supplier_article, text, pros, cons, reviewed_at, seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (user_id, feedback_id) DO NOTHING`
+	for _, r := range reviews {
+		if _, err := tx.ExecContext(ctx, stmt, userID, r.FeedbackID, r.Rating, r.SubjectName, r.NmID, r.ProductName, r.SupplierArticle,
+			r.Text, r.Pros, r.Cons, nullTime(r.ReviewedAt), now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM negative_reviews WHERE user_id = $1 AND seen_at < $2`, userID, now.Add(-NegativeReviewRetention)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// NegativeReviews returns negative reviews seen since the given time with
+// their replies.
+func (s *postgresStore) NegativeReviews(ctx context.Context, userID int64, since time.Time) ([]NegativeReview, error) {
+	const query = `SELECT ` + negativeReviewColumns + ` FROM negative_reviews n
+		LEFT JOIN processed p ON p.user_id = n.user_id AND p.id = n.feedback_id
+		WHERE n.user_id = $1 AND n.seen_at >= $2 ORDER BY n.seen_at, n.feedback_id`
+	return queryNegativeReviews(ctx, s.db, query, userID, dbTime(since))
+}
+
+// SaveDraft records a reply draft unless the review already has one.
+func (s *postgresStore) SaveDraft(ctx context.Context, userID int64, d Draft) (bool, error) {
+	now := utcNow()
+	const stmt = `INSERT INTO reply_drafts (user_id, feedback_id, rating, subject_name, nm_id, product_name, supplier_article, reviewed_at, text, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (user_id, feedback_id) DO NOTHING`
+	res, err := s.db.ExecContext(ctx, stmt, userID, d.FeedbackID, d.Rating, d.SubjectName, d.NmID, d.ProductName, d.SupplierArticle,
+		nullTime(d.ReviewedAt), d.Text, d.Status, now, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetDraft returns the draft for a review, or nil if there is none.
+func (s *postgresStore) GetDraft(ctx context.Context, userID int64, feedbackID string) (*Draft, error) {
+	const query = `SELECT ` + draftColumns + ` FROM reply_drafts WHERE user_id = $1 AND feedback_id = $2`
+	return scanDraft(s.db.QueryRowContext(ctx, query, userID, feedbackID))
+}
+
+// DraftedIDs returns the subset of ids that have a draft.
+func (s *postgresStore) DraftedIDs(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+	const query = `SELECT feedback_id FROM reply_drafts WHERE user_id = $1 AND feedback_id = ANY($2)`
+	if err := queryIDSet(ctx, s.db, found, query, userID, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// ResolveDraft moves a draft from one status to another.
+func (s *postgresStore) ResolveDraft(ctx context.Context, userID int64, feedbackID, from, to string) (bool, error) {
+	const stmt = `UPDATE reply_drafts SET status = $1, updated_at = $2 WHERE user_id = $3 AND feedback_id = $4 AND status = $5`
+	res, err := s.db.ExecContext(ctx, stmt, to, utcNow(), userID, feedbackID, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetLanguage stores the language of bot messages for the user.
+func (s *postgresStore) SetLanguage(ctx context.Context, chatID int64, lang string) error {
+	const stmt = `UPDATE user_configs SET language = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, lang, utcNow(), chatID)
+	return err
+}
+
+// SetPaused stops or resumes automatic answering for the user.
+func (s *postgresStore) SetPaused(ctx context.Context, chatID int64, paused bool) error {
+	const stmt = `UPDATE user_configs SET paused = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, paused, utcNow(), chatID)
+	return err
+}
+
+// SetWBBaseURL overrides the WB API URL for the user.
+func (s *postgresStore) SetWBBaseURL(ctx context.Context, chatID int64, baseURL string) error {
+	const stmt = `UPDATE user_configs SET wb_base_url = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, baseURL, utcNow(), chatID)
+	return err
+}
+
+// SetDailyLimit sets the maximum number of answers per day.
+func (s *postgresStore) SetDailyLimit(ctx context.Context, chatID int64, limit int) error {
+	const stmt = `UPDATE user_configs SET daily_limit = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, limit, utcNow(), chatID)
+	return err
+}
+
+// SetHumanize toggles random pauses between answers.
+func (s *postgresStore) SetHumanize(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET humanize = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetAnswerHoursOnly toggles holding reviews until business hours.
+func (s *postgresStore) SetAnswerHoursOnly(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET answer_hours_only = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetAnswerDelay sets the minimum review age in minutes.
+func (s *postgresStore) SetAnswerDelay(ctx context.Context, chatID int64, minutes int) error {
+	const stmt = `UPDATE user_configs SET answer_delay_minutes = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, minutes, utcNow(), chatID)
+	return err
+}
+
+// SetSentimentRouting toggles text-based routing of complaints.
+func (s *postgresStore) SetSentimentRouting(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET sentiment_routing = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetTrackEdits toggles re-checking answered reviews for buyer edits.
+func (s *postgresStore) SetTrackEdits(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET track_edits = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetDuplicateShare sets the percent of identical reviews that is answered.
+func (s *postgresStore) SetDuplicateShare(ctx context.Context, chatID int64, percent int) error {
+	const stmt = `UPDATE user_configs SET duplicate_share = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, percent, utcNow(), chatID)
+	return err
+}
+
+// SetAIDrafts toggles AI reply drafts for negative reviews.
+func (s *postgresStore) SetAIDrafts(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET ai_drafts = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetNegativeReport toggles the daily report of negative reviews.
+func (s *postgresStore) SetNegativeReport(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET negative_report = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetWBProxy sets the proxy for the user's WB requests.
+func (s *postgresStore) SetWBProxy(ctx context.Context, chatID int64, proxy string) error {
+	const stmt = `UPDATE user_configs SET wb_proxy = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, proxy, utcNow(), chatID)
+	return err
+}
+
+// ListTokenExpiries returns users whose WB token expires before the given
+// time and who have not been told it expired.
+func (s *postgresStore) ListTokenExpiries(ctx context.Context, before time.Time) ([]TokenExpiry, error) {
+	const query = `SELECT user_id, token_expires_at, token_expiry_notice FROM user_configs
+		WHERE token_expires_at IS NOT NULL AND token_expires_at < $1 AND token_expiry_notice < $2
+			AND wb_token <> '' AND wb_token <> 'not_set'
+		ORDER BY token_expires_at, user_id`
+	return queryTokenExpiries(ctx, s.db, query, dbTime(before), TokenNoticeExpired)
+}
+
+// SetTokenExpiryNotice records the last token expiry notice sent to the user.
+func (s *postgresStore) SetTokenExpiryNotice(ctx context.Context, chatID int64, notice int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET token_expiry_notice = $1 WHERE user_id = $2`, notice, chatID)
+	return err
+}
+
+// SetEscalationChat sets the chat that gets a copy of 1–2★ reviews.
+func (s *postgresStore) SetEscalationChat(ctx context.Context, chatID, target int64) error {
+	const stmt = `UPDATE user_configs SET escalation_chat_id = $1, updated_at = $2 WHERE user_id = $3`
+	_, err := s.db.ExecContext(ctx, stmt, target, utcNow(), chatID)
+	return err
+}
+
+// DailyCount returns the number of answers posted by the user on day.
+func (s *postgresStore) DailyCount(ctx context.Context, userID int64, day string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT count FROM answer_counters WHERE user_id = $1 AND day = $2`, userID, day).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// IncrementDailyCount bumps the user's counter for day and returns it.
+func (s *postgresStore) IncrementDailyCount(ctx context.Context, userID int64, day string) (int, error) {
+	const stmt = `
+		INSERT INTO answer_counters (user_id, day, count) VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, day) DO UPDATE SET count = answer_counters.count + 1
+		RETURNING count
+	`
+	var n int
+	if err := s.db.QueryRowContext(ctx, stmt, userID, day).Scan(&n); err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		// First answer of the day: previous days are no longer needed
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM answer_counters WHERE user_id = $1 AND day <> $2`, userID, day); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// SetBenchmarkOptIn toggles participation in category benchmarks.
+func (s *postgresStore) SetBenchmarkOptIn(ctx context.Context, chatID int64, optIn bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET benchmark_opt_in = $1 WHERE user_id = $2`, optIn, chatID)
+	return err
+}
+
+const postgresBenchmarkQuery = `
+	SELECT p.subject_name, COUNT(DISTINCT p.user_id), COUNT(*),
+		AVG(CASE WHEN p.response_seconds > 0 THEN p.response_seconds END)::DOUBLE PRECISION,
+		AVG(p.rating)::DOUBLE PRECISION
+	FROM processed p JOIN user_configs u ON u.user_id = p.user_id
+	WHERE u.benchmark_opt_in AND p.subject_name <> '' AND p.rating > 0
+		AND p.created_at >= $1 AND p.created_at < $2
+	GROUP BY p.subject_name
+`
+
+// RefreshCategoryBenchmarks recomputes and replaces the category_benchmarks table.
+func (s *postgresStore) RefreshCategoryBenchmarks(ctx context.Context, window time.Duration, minUsers int) error {
+	stats, err := windowedCategoryStats(ctx, s.db, postgresBenchmarkQuery, window)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate benchmarks: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM category_benchmarks`); err != nil {
+		return fmt.Errorf("failed to clear benchmarks: %w", err)
+	}
+	const insertStmt = `
+		INSERT INTO category_benchmarks
+			(subject_name, users, answers, avg_response_seconds, avg_rating, prev_avg_rating, computed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for _, st := range stats {
+		if st.Users < int64(minUsers) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, insertStmt, st.SubjectName, st.Users, st.Answers,
+			st.AvgResponse.Seconds(), st.AvgRating, st.PrevAvgRating, st.ComputedAt); err != nil {
+			return fmt.Errorf("failed to insert benchmark: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCategoryBenchmarks returns the last computed category averages.
+func (s *postgresStore) GetCategoryBenchmarks(ctx context.Context) ([]CategoryStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT subject_name, users, answers, avg_response_seconds, avg_rating, prev_avg_rating, computed_at
+		FROM category_benchmarks ORDER BY answers DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CategoryStats
+	for rows.Next() {
+		var st CategoryStats
+		var avgResponse float64
+		if err := rows.Scan(&st.SubjectName, &st.Users, &st.Answers, &avgResponse,
+			&st.AvgRating, &st.PrevAvgRating, &st.ComputedAt); err != nil {
+			return nil, err
+		}
+		st.AvgResponse = time.Duration(avgResponse * float64(time.Second))
+		st.ComputedAt = fromDB(st.ComputedAt)
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// GetUserCategoryStats returns the user's own per-category averages within window.
+func (s *postgresStore) GetUserCategoryStats(ctx context.Context, userID int64, window time.Duration) ([]CategoryStats, error) {
+	const query = `
+		SELECT subject_name, COUNT(DISTINCT user_id), COUNT(*),
+			AVG(CASE WHEN response_seconds > 0 THEN response_seconds END)::DOUBLE PRECISION,
+			AVG(rating)::DOUBLE PRECISION
+		FROM processed
+		WHERE user_id = $1 AND subject_name <> '' AND rating > 0
+			AND created_at >= $2 AND created_at < $3
+		GROUP BY subject_name
+	`
+	return windowedCategoryStats(ctx, s.db, query, window, userID)
+}
+
+// AddExclusion excludes an article or a review from auto-answering.
+func (s *postgresStore) AddExclusion(ctx context.Context, chatID int64, ex Exclusion) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO exclusions (user_id, kind, value, created_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id, kind, value) DO NOTHING`,
+		chatID, ex.Kind, ex.Value, utcNow())
+	return err
+}
+
+// RemoveExclusion deletes an exclusion.
+func (s *postgresStore) RemoveExclusion(ctx context.Context, chatID int64, ex Exclusion) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM exclusions WHERE user_id = $1 AND kind = $2 AND value = $3`,
+		chatID, ex.Kind, ex.Value)
+	return err
+}
+
+// ListExclusions returns the user's exclusions.
+func (s *postgresStore) ListExclusions(ctx context.Context, chatID int64) ([]Exclusion, error) {
+	return queryExclusions(ctx, s.db, `SELECT kind, value FROM exclusions WHERE user_id = $1 ORDER BY kind, value`, chatID)
+}
+
+// ListPollDue returns active users due for the satisfaction poll.
+func (s *postgresStore) ListPollDue(ctx context.Context, askedBefore time.Time) ([]int64, error) {
+	const query = `
+		SELECT u.user_id FROM user_configs u
+		WHERE u.wb_token <> '' AND u.wb_token <> 'not_set' AND NOT u.paused
+			AND (u.poll_asked_at IS NULL OR u.poll_asked_at < $1)
+			AND EXISTS (SELECT 1 FROM processed p WHERE p.user_id = u.user_id)
+		ORDER BY u.user_id
+	`
+	return queryUserIDs(ctx, s.db, query, dbTime(askedBefore))
+}
+
+// MarkPollAsked records that the satisfaction poll was sent to the user.
+func (s *postgresStore) MarkPollAsked(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET poll_asked_at = $1 WHERE user_id = $2`, utcNow(), chatID)
+	return err
+}
+
+// SaveSatisfaction stores a poll answer.
+func (s *postgresStore) SaveSatisfaction(ctx context.Context, chatID int64, score int) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO satisfaction (user_id, score, created_at) VALUES ($1, $2, $3)`,
+		chatID, score, utcNow())
+	return err
+}
+
+// SetSatisfactionComment attaches a suggestion to the user's latest poll answer.
+func (s *postgresStore) SetSatisfactionComment(ctx context.Context, chatID int64, comment string) error {
+	const stmt = `
+		UPDATE satisfaction SET comment = $1
+		WHERE id = (SELECT MAX(id) FROM satisfaction WHERE user_id = $2)
+	`
+	_, err := s.db.ExecContext(ctx, stmt, comment, chatID)
+	return err
+}
+
+// ListSatisfaction returns the latest poll answers, newest first.
+func (s *postgresStore) ListSatisfaction(ctx context.Context, limit int) ([]SatisfactionResponse, error) {
+	const query = `SELECT user_id, score, comment, created_at FROM satisfaction ORDER BY id DESC LIMIT $1`
+	return querySatisfaction(ctx, s.db, query, limit)
+}
+
+// AddChangelogEntry publishes a new "what's new" entry.
+func (s *postgresStore) AddChangelogEntry(ctx context.Context, version, text string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO changelog (version, text, created_at) VALUES ($1, $2, $3)`,
+		version, text, utcNow())
+	return err
+}
+
+// ListChangelog returns entries newer than afterID, newest first.
+func (s *postgresStore) ListChangelog(ctx context.Context, afterID int64, limit int) ([]ChangelogEntry, error) {
+	const query = `SELECT id, version, text, created_at FROM changelog WHERE id > $1 ORDER BY id DESC LIMIT $2`
+	return queryChangelog(ctx, s.db, query, afterID, limit)
+}
+
+// CountChangelog returns the number of entries newer than afterID.
+func (s *postgresStore) CountChangelog(ctx context.Context, afterID int64) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM changelog WHERE id > $1`, afterID).Scan(&n)
+	return n, err
+}
+
+// MarkChangelogSeen records that the user has read entries up to id.
+func (s *postgresStore) MarkChangelogSeen(ctx context.Context, chatID int64, id int64) error {
+	const stmt = `UPDATE user_configs SET changelog_seen_id = $1 WHERE user_id = $2 AND changelog_seen_id < $1`
+	_, err := s.db.ExecContext(ctx, stmt, id, chatID)
+	return err
+}
+
+// AddBlackout stores a global maintenance window.
+func (s *postgresStore) AddBlackout(ctx context.Context, spec string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO blackouts (spec, created_at) VALUES ($1, $2)`, spec, utcNow())
+	return err
+}
+
+// RemoveBlackout deletes a maintenance window by ID.
+func (s *postgresStore) RemoveBlackout(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM blackouts WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListBlackouts returns all maintenance windows, oldest first.
+func (s *postgresStore) ListBlackouts(ctx context.Context) ([]Blackout, error) {
+	return queryBlackouts(ctx, s.db, `SELECT id, spec, created_at FROM blackouts ORDER BY id`)
+}
+
+// BanUser blocks the user, replacing the reason of an existing ban.
+func (s *postgresStore) BanUser(ctx context.Context, chatID int64, reason string) error {
+	const stmt = `INSERT INTO banned_users (user_id, reason, banned_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET reason = EXCLUDED.reason`
+	_, err := s.db.ExecContext(ctx, stmt, chatID, reason, utcNow())
+	return err
+}
+
+// UnbanUser lifts a ban.
+func (s *postgresStore) UnbanUser(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM banned_users WHERE user_id = $1`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListBannedUsers returns all banned users ordered by user ID.
+func (s *postgresStore) ListBannedUsers(ctx context.Context) ([]BannedUser, error) {
+	return queryBannedUsers(ctx, s.db, `SELECT user_id, reason, banned_at FROM banned_users ORDER BY user_id`)
+}
+
+// SaveConversation stores the user's dialog state, replacing the previous one.
+func (s *postgresStore) SaveConversation(ctx context.Context, c Conversation) error {
+	const stmt = `INSERT INTO conversations (user_id, state, subject, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT(user_id) DO UPDATE SET
+			state = EXCLUDED.state,
+			subject = EXCLUDED.subject,
+			updated_at = EXCLUDED.updated_at`
+	_, err := s.db.ExecContext(ctx, stmt, c.UserID, c.State, c.Subject, utcNow())
+	return err
+}
+
+// GetConversation returns the user's dialog state, or nil if there is none
+// or it has expired.
+func (s *postgresStore) GetConversation(ctx context.Context, chatID int64) (*Conversation, error) {
+	const query = `SELECT user_id, state, subject, updated_at FROM conversations
+		WHERE user_id = $1 AND updated_at >= $2`
+	return queryConversation(ctx, s.db, query, chatID, dbTime(time.Now().Add(-ConversationTTL)))
+}
+
+// DeleteConversation ends the user's dialog.
+func (s *postgresStore) DeleteConversation(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE user_id = $1`, chatID)
+	return err
+}
+
+// PruneConversations deletes expired dialogs.
+func (s *postgresStore) PruneConversations(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE updated_at < $1`, dbTime(time.Now().Add(-ConversationTTL)))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// AddAdmin grants admin rights; an existing admin keeps the original record.
+func (s *postgresStore) AddAdmin(ctx context.Context, chatID, addedBy int64) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO admins (user_id, added_by, added_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`, chatID, addedBy, utcNow())
+	return err
+}
+
+// RemoveAdmin revokes admin rights granted at runtime.
+func (s *postgresStore) RemoveAdmin(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM admins WHERE user_id = $1`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListAdmins returns admins added at runtime ordered by user ID.
+func (s *postgresStore) ListAdmins(ctx context.Context) ([]Admin, error) {
+	return queryAdmins(ctx, s.db, `SELECT user_id, added_by, added_at FROM admins ORDER BY user_id`)
+}
+
+// AddExemption lets the user through the channel subscription check; an
+// existing exemption keeps the original record.
+func (s *postgresStore) AddExemption(ctx context.Context, chatID, addedBy int64) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO subscription_exemptions (user_id, added_by, added_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`, chatID, addedBy, utcNow())
+	return err
+}
+
+// RemoveExemption removes an exemption added with AddExemption.
+func (s *postgresStore) RemoveExemption(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM subscription_exemptions WHERE user_id = $1`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListExemptions returns exemptions added at runtime ordered by user ID.
+func (s *postgresStore) ListExemptions(ctx context.Context) ([]Exemption, error) {
+	return queryExemptions(ctx, s.db, `SELECT user_id, added_by, added_at FROM subscription_exemptions ORDER BY user_id`)
+}
+
+// StartMaintenance turns maintenance mode on or replaces its reason.
+func (s *postgresStore) StartMaintenance(ctx context.Context, m Maintenance) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO maintenance (id, reason, started_by, started_at) VALUES (1, $1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET reason = EXCLUDED.reason`, m.Reason, m.StartedBy, dbTime(m.StartedAt))
+	return err
+}
+
+// StopMaintenance turns maintenance mode off.
+func (s *postgresStore) StopMaintenance(ctx context.Context) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM maintenance`)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetMaintenance returns the maintenance mode, or nil if it is off.
+func (s *postgresStore) GetMaintenance(ctx context.Context) (*Maintenance, error) {
+	return queryMaintenance(ctx, s.db, `SELECT reason, started_by, started_at FROM maintenance WHERE id = 1`)
+}
+
+// AddTemplateVariant appends a reply variant after the user's last one in category.
+func (s *postgresStore) AddTemplateVariant(ctx context.Context, chatID int64, category, text string) (int, error) {
+	const stmt = `INSERT INTO templates (user_id, category, idx, text, created_at)
+		SELECT $1, $2, COALESCE(MAX(idx), 0) + 1, $3, $4 FROM templates WHERE user_id = $1 AND category = $2
+		RETURNING idx`
+	var idx int
+	err := s.db.QueryRowContext(ctx, stmt, chatID, category, text, utcNow()).Scan(&idx)
+	return idx, err
+}
+
+// RemoveTemplateVariant deletes a reply variant.
+func (s *postgresStore) RemoveTemplateVariant(ctx context.Context, chatID int64, category string, idx int) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM templates WHERE user_id = $1 AND category = $2 AND idx = $3`, chatID, category, idx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReplaceTemplates overwrites the user's reply texts and variants in one transaction.
+func (s *postgresStore) ReplaceTemplates(ctx context.Context, chatID int64, set TemplateSet) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	res, err := tx.ExecContext(ctx, `UPDATE user_configs SET template_good = $1, template_bad = $2, template_question = $3,
+		template_media = $4, template_off_hours = $5, updated_at = $6 WHERE user_id = $7`,
+		set.Good, set.Bad, set.Question, set.Media, set.OffHours, now, chatID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("user %d has no config: %w", chatID, sql.ErrNoRows)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE user_id = $1`, chatID); err != nil {
+		return err
+	}
+	for category, texts := range map[string][]string{VariantGood: set.GoodVariants, VariantBad: set.BadVariants, VariantSignature: set.Signatures} {
+		for i, text := range texts {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO templates (user_id, category, idx, text, created_at) VALUES ($1, $2, $3, $4, $5)`,
+				chatID, category, i+1, text, now); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// SetCategoryTemplate stores one reply of a product category.
+func (s *postgresStore) SetCategoryTemplate(ctx context.Context, chatID int64, subjectID int64, subjectName, category, text string) error {
+	col, err := templateColumn(category)
+	if err != nil {
+		return err
+	}
+	stmt := `INSERT INTO category_templates (user_id, subject_id, subject_name, ` + col + `, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, subject_id) DO UPDATE SET
+			subject_name = EXCLUDED.subject_name, ` + col + ` = EXCLUDED.` + col + `, updated_at = EXCLUDED.updated_at`
+	_, err = s.db.ExecContext(ctx, stmt, chatID, subjectID, subjectName, text, utcNow())
+	return err
+}
+
+// RemoveCategoryTemplate deletes the replies of a product category.
+func (s *postgresStore) RemoveCategoryTemplate(ctx context.Context, chatID int64, subjectID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM category_templates WHERE user_id = $1 AND subject_id = $2`, chatID, subjectID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListCategoryTemplates returns the user's product category replies.
+func (s *postgresStore) ListCategoryTemplates(ctx context.Context, chatID int64) ([]CategoryTemplate, error) {
+	return queryCategoryTemplates(ctx, s.db, `SELECT subject_id, subject_name, template_good, template_bad
+		FROM category_templates WHERE user_id = $1 ORDER BY subject_name, subject_id`, chatID)
+}
+
+// ListTemplateVariants returns the user's reply variants.
+func (s *postgresStore) ListTemplateVariants(ctx context.Context, chatID int64) ([]TemplateVariant, error) {
+	return queryTemplateVariants(ctx, s.db, `SELECT category, idx, text FROM templates WHERE user_id = $1 ORDER BY category, idx`, chatID)
+}
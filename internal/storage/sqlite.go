@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -18,14 +19,15 @@ import (
 // Uses modernc.org/sqlite driver — pure Go, so no CGO headaches in CI/CD.
 // Tested with Go 1.22.
 type sqliteStore struct {
-	db *sql.DB
+	db     *sql.DB
+	tokens *TokenCipher // nil stores WB tokens in plaintext
 }
 
 // NewSQLite opens (or creates) the database at the given path and ensures the
 // schema exists. Caller is responsible for calling Close() when done.
 // Returns both Store and ConfigStore interfaces.
-func NewSQLite(path string) (Store, ConfigStore, error) {
-	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_busy_timeout=5000", path)
+func NewSQLite(path string, tokens *TokenCipher) (Store, ConfigStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_busy_timeout=5000&_time_format=sqlite", path)
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, nil, err
@@ -35,15 +37,26 @@ func NewSQLite(path string) (Store, ConfigStore, error) {
 	db.SetMaxIdleConns(1)
 	db.SetConnMaxLifetime(0)
 
-	if err := migrate(db); err != nil {
+	if err := migrate(db, sqliteDialect); err != nil {
 		_ = db.Close()
 		return nil, nil, err
 	}
-	store := &sqliteStore{db: db}
+	if err := encryptStoredTokens(context.Background(), db, tokens, `UPDATE user_configs SET wb_token = ? WHERE user_id = ?;`); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("WB token encryption: %w", err)
+	}
+	if err := backfillTokenExpiry(context.Background(), db, tokens, `UPDATE user_configs SET token_expires_at = ? WHERE user_id = ?;`); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("WB token expiry: %w", err)
+	}
+	store := &sqliteStore{db: db, tokens: tokens}
 	return store, store, nil
 }
 
-func migrate(db *sql.DB) error {
+// baselineSQLite creates the schema as of version 1, upgrading databases
+// created before migrations were versioned. It is frozen: new schema changes
+// go into migrations/sqlite (see migrate.go).
+func baselineSQLite(db *sql.DB) error {
 	// Check if old table exists (without user_id)
 	var oldTableCount int
 	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='processed'`).Scan(&oldTableCount)
@@ -125,14 +138,161 @@ func migrate(db *sql.DB) error {
 	if _, err := db.Exec(configStmt); err != nil {
 		return err
 	}
+
+	// Columns added after the initial release
+	for _, col := range []struct{ name, ddl string }{
+		{"timezone", "TEXT NOT NULL DEFAULT ''"},
+		{"work_start", "TEXT NOT NULL DEFAULT ''"},
+		{"work_end", "TEXT NOT NULL DEFAULT ''"},
+		{"template_off_hours", "TEXT NOT NULL DEFAULT ''"},
+		{"benchmark_opt_in", "INTEGER NOT NULL DEFAULT 0"},
+		{"template_question", "TEXT NOT NULL DEFAULT ''"},
+		{"paused", "INTEGER NOT NULL DEFAULT 0"},
+		{"poll_asked_at", "TIMESTAMP"},
+		{"token_hash", "TEXT NOT NULL DEFAULT ''"},
+		{"wb_base_url", "TEXT NOT NULL DEFAULT ''"},
+		{"daily_limit", "INTEGER NOT NULL DEFAULT 0"},
+		{"humanize", "INTEGER NOT NULL DEFAULT 0"},
+		{"changelog_seen_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"sentiment_routing", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := ensureColumn(db, "user_configs", col.name, col.ddl); err != nil {
+			return err
+		}
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_user_configs_token_hash ON user_configs(token_hash);`); err != nil {
+		return err
+	}
+	if err := backfillTokenHashes(db, `UPDATE user_configs SET token_hash = ? WHERE user_id = ?;`); err != nil {
+		return fmt.Errorf("failed to backfill token hashes: %w", err)
+	}
+
+	// Answer metadata for analytics
+	for _, col := range []struct{ name, ddl string }{
+		{"rating", "INTEGER NOT NULL DEFAULT 0"},
+		{"subject_name", "TEXT NOT NULL DEFAULT ''"},
+		{"response_seconds", "INTEGER NOT NULL DEFAULT 0"},
+		{"kind", "TEXT NOT NULL DEFAULT 'feedback'"},
+		{"source", "TEXT NOT NULL DEFAULT ''"},
+		{"template_version", "TEXT NOT NULL DEFAULT ''"},
+		{"reply_text", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if err := ensureColumn(db, "processed", col.name, col.ddl); err != nil {
+			return err
+		}
+	}
+
+	// Category benchmarks, recomputed nightly
+	const benchmarksStmt = `CREATE TABLE IF NOT EXISTS category_benchmarks (
+		subject_name TEXT PRIMARY KEY,
+		users INTEGER NOT NULL,
+		answers INTEGER NOT NULL,
+		avg_response_seconds REAL NOT NULL DEFAULT 0,
+		avg_rating REAL NOT NULL DEFAULT 0,
+		prev_avg_rating REAL NOT NULL DEFAULT 0,
+		computed_at TIMESTAMP NOT NULL
+	);`
+	if _, err := db.Exec(benchmarksStmt); err != nil {
+		return err
+	}
+
+	// Articles and reviews excluded from auto-answering
+	const exclusionsStmt = `CREATE TABLE IF NOT EXISTS exclusions (
+		user_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, kind, value)
+	);`
+	if _, err := db.Exec(exclusionsStmt); err != nil {
+		return err
+	}
+
+	// Answers posted per user per day, for the daily answer limit
+	const countersStmt = `CREATE TABLE IF NOT EXISTS answer_counters (
+		user_id INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, day)
+	);`
+	if _, err := db.Exec(countersStmt); err != nil {
+		return err
+	}
+
+	// In-bot "what's new" announcements
+	const changelogStmt = `CREATE TABLE IF NOT EXISTS changelog (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version TEXT NOT NULL,
+		text TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+	if _, err := db.Exec(changelogStmt); err != nil {
+		return err
+	}
+
+	// Global maintenance windows during which no cycles run
+	const blackoutsStmt = `CREATE TABLE IF NOT EXISTS blackouts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		spec TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);`
+	if _, err := db.Exec(blackoutsStmt); err != nil {
+		return err
+	}
+
+	// Answers to the periodic satisfaction poll
+	const satisfactionStmt = `CREATE TABLE IF NOT EXISTS satisfaction (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		score INTEGER NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);`
+	if _, err := db.Exec(satisfactionStmt); err != nil {
+		return err
+	}
 	
 	return nil
 }
 
+// ensureColumn adds the column to the table unless it already exists.
+// SQLite has no ADD COLUMN IF NOT EXISTS, so we inspect table_info first.
+func ensureColumn(db *sql.DB, table, column, ddl string) error {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid int
+		var name, dataType string
+		var notnull, pk int
+		var dfltValue interface{}
+		if err := rows.Scan(&cid, &name, &dataType, &notnull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, table, column, ddl)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
 // Exists checks whether the given ID is already stored for the user.
 func (s *sqliteStore) Exists(ctx context.Context, userID int64, id string) (bool, error) {
 	var exists int
-	err := s.db.QueryRowContext(ctx, `SELECT 1 FROM processed WHERE user_id = ? AND id = ? LIMIT 1;`, userID, id).Scan(&exists)
+	const query = `SELECT 1 FROM processed WHERE user_id = ? AND id = ?
+		UNION ALL SELECT 1 FROM processed_archive WHERE user_id = ? AND id = ?
+		LIMIT 1;`
+	err := s.db.QueryRowContext(ctx, query, userID, id, userID, id).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -141,10 +301,200 @@ func (s *sqliteStore) Exists(ctx context.Context, userID int64, id string) (bool
 
 // Save inserts the ID for the user; duplicate IDs are ignored via INSERT OR IGNORE to keep idempotency.
 func (s *sqliteStore) Save(ctx context.Context, userID int64, id string) error {
-	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO processed(user_id, id, created_at) VALUES(?, ?, ?);`, userID, id, time.Now())
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO processed(user_id, id, created_at) VALUES(?, ?, ?);`, userID, id, utcNow())
+	return err
+}
+
+// SaveAnswer inserts the ID with answer metadata; duplicates are ignored like in Save.
+func (s *sqliteStore) SaveAnswer(ctx context.Context, userID int64, rec AnswerRecord) error {
+	stmt := `INSERT OR IGNORE INTO processed(` + insertColumns + `)
+		VALUES(` + strings.TrimSuffix(strings.Repeat("?, ", answerArgCount), ", ") + `);`
+	_, err := s.db.ExecContext(ctx, stmt, answerArgs(userID, rec, utcNow())...)
 	return err
 }
 
+// ExistsBatch returns which of ids are stored in processed or
+// processed_archive, querying up to batchRows IDs at a time.
+func (s *sqliteStore) ExistsBatch(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for start := 0; start < len(ids); start += batchRows {
+		chunk := ids[start:min(start+batchRows, len(ids))]
+		in := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		query := `SELECT id FROM processed WHERE user_id = ? AND id IN (` + in + `)
+			UNION SELECT id FROM processed_archive WHERE user_id = ? AND id IN (` + in + `);`
+		args := make([]any, 0, 2*len(chunk)+2)
+		for range 2 {
+			args = append(args, userID)
+			for _, id := range chunk {
+				args = append(args, id)
+			}
+		}
+		if err := queryIDSet(ctx, s.db, found, query, args...); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// SaveBatch inserts answers with multi-row INSERT OR IGNORE statements of up
+// to batchRows rows inside one transaction.
+func (s *sqliteStore) SaveBatch(ctx context.Context, userID int64, recs []AnswerRecord) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", answerArgCount), ", ") + ")"
+	for start := 0; start < len(recs); start += batchRows {
+		chunk := recs[start:min(start+batchRows, len(recs))]
+		stmt := `INSERT OR IGNORE INTO processed(` + insertColumns + `)
+			VALUES ` + strings.TrimSuffix(strings.Repeat(row+", ", len(chunk)), ", ") + `;`
+		args := make([]any, 0, len(chunk)*answerArgCount)
+		for _, rec := range chunk {
+			args = append(args, answerArgs(userID, rec, now)...)
+		}
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateAnswerText replaces the stored reply of an edited answer.
+func (s *sqliteStore) UpdateAnswerText(ctx context.Context, userID int64, id, source, text string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, stmt := range []string{
+		`UPDATE processed SET reply_text = ?, source = ?, template_version = '' WHERE user_id = ? AND id = ?;`,
+		`UPDATE processed_archive SET reply_text = ?, source = ?, template_version = '' WHERE user_id = ? AND id = ?;`,
+	} {
+		res, err := tx.ExecContext(ctx, stmt, text, source, userID, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to update answer text: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		total += n
+	}
+	return total > 0, tx.Commit()
+}
+
+// RecentAnswers returns the user's latest answers, newest first.
+func (s *sqliteStore) RecentAnswers(ctx context.Context, userID int64, limit int) ([]AnswerRecord, error) {
+	const query = `SELECT ` + answerColumns + `
+		FROM processed WHERE user_id = ?
+		ORDER BY created_at DESC LIMIT ?;`
+	return queryAnswers(ctx, s.db, query, userID, limit)
+}
+
+// ListAnswers returns all of the user's answers, oldest first.
+func (s *sqliteStore) ListAnswers(ctx context.Context, userID int64) ([]AnswerRecord, error) {
+	const query = `SELECT ` + answerColumns + ` FROM processed WHERE user_id = ?
+		UNION ALL SELECT ` + answerColumns + ` FROM processed_archive WHERE user_id = ?
+		ORDER BY created_at;`
+	return queryAnswers(ctx, s.db, query, userID, userID)
+}
+
+// ArchiveAnswers moves answers older than before into processed_archive.
+func (s *sqliteStore) ArchiveAnswers(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const copyStmt = `INSERT OR IGNORE INTO processed_archive (` + archiveColumns + `)
+		SELECT ` + archiveColumns + ` FROM processed WHERE created_at < ?;`
+	if _, err := tx.ExecContext(ctx, copyStmt, dbTime(before)); err != nil {
+		return 0, fmt.Errorf("failed to copy answers to archive: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM processed WHERE created_at < ?;`, dbTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived answers: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// DeleteProcessedOlderThan deletes the user's answers stored before the cutoff.
+func (s *sqliteStore) DeleteProcessedOlderThan(ctx context.Context, userID int64, before time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int64
+	for _, stmt := range []string{
+		`DELETE FROM processed WHERE user_id = ? AND created_at < ?;`,
+		`DELETE FROM processed_archive WHERE user_id = ? AND created_at < ?;`,
+	} {
+		res, err := tx.ExecContext(ctx, stmt, userID, dbTime(before))
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete old answers: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+// SourceBreakdown aggregates the user's answers within window by source and template version.
+func (s *sqliteStore) SourceBreakdown(ctx context.Context, userID int64, window time.Duration) ([]SourceStats, error) {
+	const query = `SELECT source, template_version, COUNT(*), COALESCE(AVG(CASE WHEN rating > 0 THEN rating END), 0)
+		FROM processed
+		WHERE user_id = ? AND source <> '' AND created_at >= ?
+		GROUP BY source, template_version
+		ORDER BY COUNT(*) DESC;`
+	return querySourceStats(ctx, s.db, query, userID, utcNow().Add(-window))
+}
+
+// ProductBreakdown aggregates the user's review answers within window by product.
+func (s *sqliteStore) ProductBreakdown(ctx context.Context, userID int64, window time.Duration, limit int) ([]ProductStats, error) {
+	const query = `SELECT nm_id, MAX(product_name), MAX(supplier_article), COUNT(*),
+			COALESCE(AVG(CASE WHEN rating > 0 THEN rating END), 0)
+		FROM processed
+		WHERE user_id = ? AND nm_id <> 0 AND created_at >= ?
+		GROUP BY nm_id
+		ORDER BY COUNT(*) DESC, nm_id
+		LIMIT ?;`
+	return queryProductStats(ctx, s.db, query, userID, utcNow().Add(-window), limit)
+}
+
+// PeriodSummary aggregates the user's answers posted in [from, to).
+func (s *sqliteStore) PeriodSummary(ctx context.Context, userID int64, from, to time.Time) (PeriodSummary, error) {
+	const query = `SELECT kind, rating, COUNT(*)
+		FROM processed
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY kind, rating;`
+	return queryPeriodSummary(ctx, s.db, query, userID, dbTime(from), dbTime(to))
+}
+
+// Ping runs a query reading the schema: opening the file can succeed for a
+// database that was deleted or damaged underneath the bot.
+func (s *sqliteStore) Ping(ctx context.Context) error {
+	var n int
+	return s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master;`).Scan(&n)
+}
+
 // Close closes the underlying *sql.DB.
 func (s *sqliteStore) Close() error {
 	return s.db.Close()
@@ -152,36 +502,102 @@ func (s *sqliteStore) Close() error {
 
 // SaveUserConfig saves or updates user configuration.
 func (s *sqliteStore) SaveUserConfig(ctx context.Context, chatID int64, wbToken, tplGood, tplBad string) error {
-	const stmt = `INSERT INTO user_configs (user_id, wb_token, template_good, template_bad, updated_at)
-        VALUES (?, ?, ?, ?, ?)
+	// New users start with all existing changelog entries marked as read
+	// and a new token with no expiry notices sent
+	const stmt = `INSERT INTO user_configs (user_id, wb_token, template_good, template_bad, updated_at, token_hash, changelog_seen_id, token_expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(id), 0) FROM changelog), ?)
         ON CONFLICT(user_id) DO UPDATE SET
             wb_token = excluded.wb_token,
             template_good = excluded.template_good,
             template_bad = excluded.template_bad,
-            updated_at = excluded.updated_at;`
-	_, err := s.db.ExecContext(ctx, stmt, chatID, wbToken, tplGood, tplBad, time.Now())
+            updated_at = excluded.updated_at,
+            token_expiry_notice = CASE WHEN user_configs.token_hash = excluded.token_hash THEN user_configs.token_expiry_notice ELSE 0 END,
+            token_hash = excluded.token_hash,
+            token_expires_at = excluded.token_expires_at;`
+	stored, err := s.tokens.seal(wbToken)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, stmt, chatID, stored, tplGood, tplBad, utcNow(), TokenHash(wbToken), nullTime(TokenExpiresAt(wbToken)))
 	return err
 }
 
 // GetUserConfig retrieves user configuration by chat ID.
 func (s *sqliteStore) GetUserConfig(ctx context.Context, chatID int64) (*UserConfig, error) {
-	const stmt = `SELECT user_id, wb_token, template_good, template_bad, updated_at
+	stmt := `SELECT ` + userConfigColumns + `
         FROM user_configs WHERE user_id = ? LIMIT 1;`
-	var cfg UserConfig
-	err := s.db.QueryRowContext(ctx, stmt, chatID).Scan(
-		&cfg.UserID,
-		&cfg.WBToken,
-		&cfg.TemplateGood,
-		&cfg.TemplateBad,
-		&cfg.UpdatedAt,
-	)
+	cfg, err := scanUserConfig(s.db.QueryRowContext(ctx, stmt, chatID), s.tokens)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	return cfg, nil
+}
+
+// ListUserIDs returns IDs of all users with a stored config.
+func (s *sqliteStore) ListUserIDs(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT user_id FROM user_configs ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListUsersByTokenHash returns users registered with the same WB token.
+func (s *sqliteStore) ListUsersByTokenHash(ctx context.Context, hash string) ([]int64, error) {
+	return queryUserIDs(ctx, s.db, `SELECT user_id FROM user_configs WHERE token_hash = ? ORDER BY user_id;`, hash)
+}
+
+// ListActiveConfigs returns configs that can run unattended, ordered by user ID.
+func (s *sqliteStore) ListActiveConfigs(ctx context.Context) ([]*UserConfig, error) {
+	const stmt = `SELECT ` + userConfigColumns + `
+		FROM user_configs
+		WHERE wb_token <> '' AND wb_token <> 'not_set'
+			AND template_good <> '' AND template_bad <> '' AND paused = 0
+		ORDER BY user_id;`
+	return queryUserConfigs(ctx, s.db, s.tokens, stmt)
+}
+
+// ListUserConfigs returns one page of all configs, ordered by user ID.
+func (s *sqliteStore) ListUserConfigs(ctx context.Context, offset, limit int) ([]*UserConfig, error) {
+	const stmt = `SELECT ` + userConfigColumns + `
+		FROM user_configs
+		ORDER BY user_id
+		LIMIT ? OFFSET ?;`
+	return queryUserConfigs(ctx, s.db, s.tokens, stmt, limit, offset)
+}
+
+// UpdateBusinessHours sets timezone and working hours for the user.
+func (s *sqliteStore) UpdateBusinessHours(ctx context.Context, chatID int64, timezone, workStart, workEnd string) error {
+	const stmt = `UPDATE user_configs SET timezone = ?, work_start = ?, work_end = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, timezone, workStart, workEnd, utcNow(), chatID)
+	return err
+}
+
+// SetTimezone sets the user's timezone and leaves working hours untouched.
+func (s *sqliteStore) SetTimezone(ctx context.Context, chatID int64, timezone string) error {
+	const stmt = `UPDATE user_configs SET timezone = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, timezone, utcNow(), chatID)
+	return err
+}
+
+// UpdateOffHoursTemplate sets the reply used outside business hours.
+func (s *sqliteStore) UpdateOffHoursTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_off_hours = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
 }
 
 // DeleteUserConfig removes user configuration from database.
@@ -192,6 +608,58 @@ func (s *sqliteStore) DeleteUserConfig(ctx context.Context, chatID int64) error
 	if _, err := s.db.ExecContext(ctx, deleteProcessedStmt, chatID); err != nil {
 		return fmt.Errorf("failed to delete processed feedbacks: %w", err)
 	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM processed_archive WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete archived answers: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM exclusions WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete exclusions: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM answer_counters WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete answer counters: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM templates WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete template variants: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM category_templates WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete category templates: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM failures WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete failures: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM cycle_results WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete cycle results: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM review_snapshots WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete review snapshots: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM duplicate_skips WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete duplicate skips: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM reply_drafts WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete reply drafts: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM negative_reviews WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete negative reviews: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM complaints WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete complaints: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE user_id = ?;`, chatID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
 	
 	// Delete user config
 	const deleteConfigStmt = `DELETE FROM user_configs WHERE user_id = ?;`
@@ -199,14 +667,1041 @@ func (s *sqliteStore) DeleteUserConfig(ctx context.Context, chatID int64) error
 	return err
 }
 
-// GetStats retrieves statistics about users.
+var sqliteStatsQueries = statsQueries{
+	engine: "sqlite",
+	counts: `SELECT
+		(SELECT COUNT(DISTINCT user_id) FROM user_configs),
+		(SELECT COUNT(*) FROM user_configs
+			WHERE wb_token <> '' AND wb_token <> 'not_set' AND template_good <> '' AND template_bad <> ''),
+		(SELECT COUNT(DISTINCT f.user_id) FROM failures f JOIN user_configs c ON c.user_id = f.user_id
+			WHERE f.cause IN ('unauthorized', 'forbidden') AND f.created_at > c.updated_at),
+		(SELECT COUNT(*) FROM processed WHERE kind = 'feedback') + (SELECT COUNT(*) FROM processed_archive WHERE kind = 'feedback'),
+		(SELECT COUNT(*) FROM processed WHERE kind = 'feedback' AND created_at >= ?);`,
+	version:    `SELECT sqlite_version();`,
+	size:       `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size();`,
+	complaints: `SELECT status, COUNT(*) FROM complaints GROUP BY status;`,
+}
+
+// GetStats retrieves statistics about users, answers and the database.
 func (s *sqliteStore) GetStats(ctx context.Context) (*Stats, error) {
-	var totalUsers int64
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(DISTINCT user_id) FROM user_configs`).Scan(&totalUsers)
+	return getStats(ctx, s.db, sqliteStatsQueries)
+}
+
+// UpdateQuestionTemplate sets the reply for product questions.
+func (s *sqliteStore) UpdateQuestionTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_question = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// UpdateTemplate replaces the good or bad reply template.
+func (s *sqliteStore) UpdateTemplate(ctx context.Context, chatID int64, category, text string) error {
+	col, err := templateColumn(category)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	stmt := `UPDATE user_configs SET ` + col + ` = ?, updated_at = ? WHERE user_id = ?;`
+	_, err = s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// UpdateMediaTemplate sets the reply for positive reviews with photos or video.
+func (s *sqliteStore) UpdateMediaTemplate(ctx context.Context, chatID int64, text string) error {
+	const stmt = `UPDATE user_configs SET template_media = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, text, utcNow(), chatID)
+	return err
+}
+
+// RecordFailure stores a failed cycle step and drops the user's entries
+// older than FailureRetention.
+func (s *sqliteStore) RecordFailure(ctx context.Context, userID int64, f Failure) error {
+	kind := f.Kind
+	if kind == "" {
+		kind = KindFeedback
+	}
+	now := utcNow()
+	const stmt = `INSERT INTO failures (user_id, feedback_id, kind, stage, cause, message, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, f.FeedbackID, kind, f.Stage, f.Cause, truncateMessage(f.Message), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failures WHERE user_id = ? AND created_at < ?;`, userID, now.Add(-FailureRetention))
+	return err
+}
+
+// RecentFailures returns the user's latest failures, newest first.
+func (s *sqliteStore) RecentFailures(ctx context.Context, userID int64, limit int) ([]Failure, error) {
+	const query = `SELECT feedback_id, kind, stage, cause, message, created_at
+		FROM failures WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT ?;`
+	return queryFailures(ctx, s.db, query, userID, limit)
+}
+
+// SaveComplaint upserts a complaint about a review.
+func (s *sqliteStore) SaveComplaint(ctx context.Context, userID int64, c Complaint) error {
+	now := utcNow()
+	const stmt = `INSERT INTO complaints (user_id, feedback_id, reason_id, reason, rating, nm_id, status, error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, feedback_id) DO UPDATE SET
+			reason_id = excluded.reason_id,
+			reason = excluded.reason,
+			status = excluded.status,
+			error = excluded.error,
+			updated_at = excluded.updated_at;`
+	_, err := s.db.ExecContext(ctx, stmt, userID, c.FeedbackID, c.ReasonID, c.Reason, c.Rating, c.NmID, c.Status, truncateMessage(c.Error), now, now)
+	return err
+}
+
+// RecentComplaints returns the user's latest complaints, newest first.
+func (s *sqliteStore) RecentComplaints(ctx context.Context, userID int64, limit int) ([]Complaint, error) {
+	const query = `SELECT ` + complaintColumns + `
+		FROM complaints WHERE user_id = ?
+		ORDER BY updated_at DESC, feedback_id LIMIT ?;`
+	return queryComplaints(ctx, s.db, query, userID, limit)
+}
+
+// PendingComplaints returns filed complaints last checked before
+// checkedBefore, the longest unchecked first.
+func (s *sqliteStore) PendingComplaints(ctx context.Context, userID int64, checkedBefore time.Time, limit int) ([]Complaint, error) {
+	const query = `SELECT ` + complaintColumns + `
+		FROM complaints WHERE user_id = ? AND status = ? AND updated_at < ?
+		ORDER BY updated_at, feedback_id LIMIT ?;`
+	return queryComplaints(ctx, s.db, query, userID, ComplaintFiled, dbTime(checkedBefore), limit)
+}
+
+// SetComplaintStatus sets the status of a complaint and its check time.
+func (s *sqliteStore) SetComplaintStatus(ctx context.Context, userID int64, feedbackID, status string) error {
+	const stmt = `UPDATE complaints SET status = ?, updated_at = ? WHERE user_id = ? AND feedback_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, status, utcNow(), userID, feedbackID)
+	return err
+}
+
+// ComplaintStats counts the user's complaints filed in [from, to) by status.
+func (s *sqliteStore) ComplaintStats(ctx context.Context, userID int64, from, to time.Time) (ComplaintStats, error) {
+	const query = `SELECT status, COUNT(*) FROM complaints
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY status;`
+	return countComplaints(ctx, s.db, query, userID, dbTime(from), dbTime(to))
+}
+
+// RecordAudit appends an audit event and drops the user's entries older
+// than AuditRetention.
+func (s *sqliteStore) RecordAudit(ctx context.Context, ev AuditEvent) error {
+	now := utcNow()
+	const stmt = `INSERT INTO audit_log (user_id, actor, action, details, created_at)
+		VALUES (?, ?, ?, ?, ?);`
+	if _, err := s.db.ExecContext(ctx, stmt, ev.UserID, ev.Actor, ev.Action, truncateMessage(ev.Details), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM audit_log WHERE user_id = ? AND created_at < ?;`, ev.UserID, now.Add(-AuditRetention))
+	return err
+}
+
+// RecentAudit returns the user's latest audit events, newest first.
+func (s *sqliteStore) RecentAudit(ctx context.Context, userID int64, limit int) ([]AuditEvent, error) {
+	const query = `SELECT user_id, actor, action, details, created_at
+		FROM audit_log WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT ?;`
+	return queryAuditEvents(ctx, s.db, query, userID, limit)
+}
+
+var sqliteSubscriptionQueries = subscriptionQueries{
+	ensure: `INSERT OR IGNORE INTO subscriptions (trial_started_at, updated_at, user_id) VALUES (?, ?, ?);`,
+	get:    `SELECT trial_started_at, trial_answers, paid_until FROM subscriptions WHERE user_id = ?;`,
+	extend: `UPDATE subscriptions SET paid_until = ?, updated_at = ? WHERE user_id = ?;`,
+}
+
+// GetSubscription returns the user's billing state, starting the trial on first use.
+func (s *sqliteStore) GetSubscription(ctx context.Context, userID int64) (Subscription, error) {
+	return getSubscription(ctx, s.db, sqliteSubscriptionQueries, userID)
+}
+
+// AddTrialAnswers counts n more answers against the user's trial.
+func (s *sqliteStore) AddTrialAnswers(ctx context.Context, userID int64, n int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE subscriptions SET trial_answers = trial_answers + ?, updated_at = ? WHERE user_id = ?;`,
+		n, utcNow(), userID)
+	return err
+}
+
+// ExtendSubscription adds d of paid access and returns the new end.
+func (s *sqliteStore) ExtendSubscription(ctx context.Context, userID int64, d time.Duration) (time.Time, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	until, err := extendSubscription(ctx, tx, sqliteSubscriptionQueries, userID, d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until, tx.Commit()
+}
+
+// RecordPayment stores a payment and extends paid access, once per charge ID.
+func (s *sqliteStore) RecordPayment(ctx context.Context, p Payment) (time.Time, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const stmt = `INSERT OR IGNORE INTO payments (charge_id, user_id, provider_charge_id, amount, currency, days, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);`
+	res, err := tx.ExecContext(ctx, stmt, p.ChargeID, p.UserID, p.ProviderChargeID, p.Amount, p.Currency, p.Days, utcNow())
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		sub, err2 := getSubscription(ctx, tx, sqliteSubscriptionQueries, p.UserID)
+		if err == nil {
+			err = err2
+		}
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return sub.PaidUntil, false, tx.Commit()
+	}
+	until, err := extendSubscription(ctx, tx, sqliteSubscriptionQueries, p.UserID, time.Duration(p.Days)*24*time.Hour)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return until, true, tx.Commit()
+}
+
+// SaveReferral records userID as invited by referrerID unless the user was
+// referred before or has already started the trial.
+func (s *sqliteStore) SaveReferral(ctx context.Context, userID, referrerID int64) (bool, error) {
+	const stmt = `INSERT OR IGNORE INTO referrals (user_id, referrer_id, created_at)
+		SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM subscriptions WHERE user_id = ?);`
+	res, err := s.db.ExecContext(ctx, stmt, userID, referrerID, utcNow(), userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreditReferral credits the referrer of userID once.
+func (s *sqliteStore) CreditReferral(ctx context.Context, userID int64, bonusDays int) (Referral, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Referral{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	res, err := tx.ExecContext(ctx, `UPDATE referrals SET credited_at = ?, bonus_days = ? WHERE user_id = ? AND credited_at IS NULL;`,
+		now, bonusDays, userID)
+	if err != nil {
+		return Referral{}, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return Referral{}, false, err
+	}
+	r := Referral{UserID: userID, BonusDays: bonusDays, CreditedAt: now}
+	if err := tx.QueryRowContext(ctx, `SELECT referrer_id, created_at FROM referrals WHERE user_id = ?;`, userID).
+		Scan(&r.ReferrerID, &r.CreatedAt); err != nil {
+		return Referral{}, false, err
 	}
-	return &Stats{
-		TotalUsers: totalUsers,
-	}, nil
+	r.CreatedAt = fromDB(r.CreatedAt)
+	if bonusDays > 0 {
+		if _, err := extendSubscription(ctx, tx, sqliteSubscriptionQueries, r.ReferrerID, time.Duration(bonusDays)*24*time.Hour); err != nil {
+			return Referral{}, false, err
+		}
+	}
+	return r, true, tx.Commit()
+}
+
+// ReferralStats summarises the referrals of referrerID.
+func (s *sqliteStore) ReferralStats(ctx context.Context, referrerID int64) (ReferralStats, error) {
+	const query = `SELECT ?, COUNT(*), COUNT(credited_at), COALESCE(SUM(bonus_days), 0)
+		FROM referrals WHERE referrer_id = ?;`
+	stats, err := queryReferralStats(ctx, s.db, query, referrerID, referrerID)
+	if err != nil || len(stats) == 0 {
+		return ReferralStats{ReferrerID: referrerID}, err
+	}
+	return stats[0], nil
+}
+
+// TopReferrers returns the referrers with the most credited referrals.
+func (s *sqliteStore) TopReferrers(ctx context.Context, limit int) ([]ReferralStats, error) {
+	const query = `SELECT referrer_id, COUNT(*), COUNT(credited_at), COALESCE(SUM(bonus_days), 0)
+		FROM referrals GROUP BY referrer_id
+		ORDER BY COUNT(credited_at) DESC, COUNT(*) DESC, referrer_id LIMIT ?;`
+	return queryReferralStats(ctx, s.db, query, limit)
+}
+
+// SaveFailedAnswer upserts the retry state of an answer that could not be posted.
+func (s *sqliteStore) SaveFailedAnswer(ctx context.Context, userID int64, f FailedAnswer) error {
+	kind := f.Kind
+	if kind == "" {
+		kind = KindFeedback
+	}
+	now := utcNow()
+	const stmt = `INSERT INTO failed_answers (user_id, id, kind, attempts, cause, last_error, first_failed_at, next_retry_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, id) DO UPDATE SET
+			kind = excluded.kind,
+			attempts = excluded.attempts,
+			cause = excluded.cause,
+			last_error = excluded.last_error,
+			next_retry_at = excluded.next_retry_at,
+			updated_at = excluded.updated_at;`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, f.FeedbackID, kind, f.Attempts, f.Cause, truncateMessage(f.LastError), now, dbTime(f.NextRetryAt), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failed_answers WHERE user_id = ? AND updated_at < ?;`, userID, now.Add(-FailureRetention))
+	return err
+}
+
+// ListFailedAnswers returns the user's failed answers, most attempts first.
+func (s *sqliteStore) ListFailedAnswers(ctx context.Context, userID int64) ([]FailedAnswer, error) {
+	const query = `SELECT ` + failedAnswerColumns + `
+		FROM failed_answers WHERE user_id = ?
+		ORDER BY attempts DESC, updated_at DESC;`
+	return queryFailedAnswers(ctx, s.db, query, userID)
+}
+
+// DeleteFailedAnswer forgets a failed answer once it was posted.
+func (s *sqliteStore) DeleteFailedAnswer(ctx context.Context, userID int64, id string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM failed_answers WHERE user_id = ? AND id = ?;`, userID, id)
+	return err
+}
+
+// RetryFailedAnswersNow makes all of the user's failed answers due now.
+func (s *sqliteStore) RetryFailedAnswersNow(ctx context.Context, userID int64) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `UPDATE failed_answers SET next_retry_at = ? WHERE user_id = ?;`, utcNow(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RecordCycleResult stores the outcome of a cycle and drops the user's
+// entries older than CycleResultRetention.
+func (s *sqliteStore) RecordCycleResult(ctx context.Context, userID int64, r CycleResult) error {
+	now := utcNow()
+	const stmt = `INSERT INTO cycle_results (user_id, answered, failed, cause, message, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?);`
+	if _, err := s.db.ExecContext(ctx, stmt, userID, r.Answered, r.Failed, r.Cause, truncateMessage(r.Message), now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM cycle_results WHERE user_id = ? AND finished_at < ?;`, userID, now.Add(-CycleResultRetention))
+	return err
+}
+
+// CycleProblems returns the users whose latest cycle since the cutoff failed.
+func (s *sqliteStore) CycleProblems(ctx context.Context, since time.Time, limit int) ([]CycleProblem, error) {
+	const query = `SELECT user_id, answered, failed, cause, message, finished_at
+		FROM cycle_results WHERE finished_at >= ?
+		ORDER BY user_id, finished_at DESC, id DESC;`
+	return queryCycleProblems(ctx, s.db, query, limit, dbTime(since))
+}
+
+// RecentCycleResults returns the user's latest cycle outcomes, newest first.
+func (s *sqliteStore) RecentCycleResults(ctx context.Context, userID int64, limit int) ([]CycleResult, error) {
+	const query = `SELECT answered, failed, cause, message, finished_at
+		FROM cycle_results WHERE user_id = ?
+		ORDER BY finished_at DESC, id DESC LIMIT ?;`
+	return queryCycleResults(ctx, s.db, query, userID, limit)
+}
+
+// ReviewSnapshots returns the user's answered reviews as last seen on WB.
+func (s *sqliteStore) ReviewSnapshots(ctx context.Context, userID int64) (map[string]ReviewSnapshot, error) {
+	const query = `SELECT feedback_id, rating, text_hash, checked_at FROM review_snapshots WHERE user_id = ?;`
+	return queryReviewSnapshots(ctx, s.db, query, userID)
+}
+
+// SaveReviewSnapshots upserts snapshots in one transaction and drops the
+// user's snapshots older than ReviewSnapshotRetention.
+func (s *sqliteStore) SaveReviewSnapshots(ctx context.Context, userID int64, snaps []ReviewSnapshot) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT INTO review_snapshots (user_id, feedback_id, rating, text_hash, checked_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, feedback_id) DO UPDATE SET
+			rating = excluded.rating,
+			text_hash = excluded.text_hash,
+			checked_at = excluded.checked_at;`
+	for _, snap := range snaps {
+		if _, err := tx.ExecContext(ctx, stmt, userID, snap.FeedbackID, snap.Rating, snap.TextHash, now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_snapshots WHERE user_id = ? AND checked_at < ?;`, userID, now.Add(-ReviewSnapshotRetention)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveNegativeReviews inserts new negative reviews in one transaction and
+// drops the user's reviews older than NegativeReviewRetention.
+func (s *sqliteStore) SaveNegativeReviews(ctx context.Context, userID int64, reviews []NegativeReview) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT OR IGNORE INTO negative_reviews (user_id, feedback_id, rating, subject_name, nm_id, product_name, supplier_article, text, pros, cons, reviewed_at, seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	for _, r := range reviews {
+		if _, err := tx.ExecContext(ctx, stmt, userID, r.FeedbackID, r.Rating, r.SubjectName, r.NmID, r.ProductName, r.SupplierArticle,
+			r.Text, r.Pros, r.Cons, nullTime(r.ReviewedAt), now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM negative_reviews WHERE user_id = ? AND seen_at < ?;`, userID, now.Add(-NegativeReviewRetention)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// NegativeReviews returns negative reviews seen since the given time with
+// their replies.
+func (s *sqliteStore) NegativeReviews(ctx context.Context, userID int64, since time.Time) ([]NegativeReview, error) {
+	const query = `SELECT ` + negativeReviewColumns + ` FROM negative_reviews n
+		LEFT JOIN processed p ON p.user_id = n.user_id AND p.id = n.feedback_id
+		WHERE n.user_id = ? AND n.seen_at >= ? ORDER BY n.seen_at, n.feedback_id;`
+	return queryNegativeReviews(ctx, s.db, query, userID, dbTime(since))
+}
+
+// SkipDuplicates records reviews skipped as duplicates; recorded ones are ignored.
+func (s *sqliteStore) SkipDuplicates(ctx context.Context, userID int64, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	const stmt = `INSERT OR IGNORE INTO duplicate_skips (user_id, feedback_id, skipped_at) VALUES (?, ?, ?);`
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, stmt, userID, id, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SkippedDuplicates returns the subset of ids skipped as duplicates.
+func (s *sqliteStore) SkippedDuplicates(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for start := 0; start < len(ids); start += batchRows {
+		chunk := ids[start:min(start+batchRows, len(ids))]
+		query := `SELECT feedback_id FROM duplicate_skips WHERE user_id = ? AND feedback_id IN (` +
+			strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ") + `);`
+		args := make([]any, 0, len(chunk)+1)
+		args = append(args, userID)
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		if err := queryIDSet(ctx, s.db, found, query, args...); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// CountSkippedDuplicates returns how many reviews the user skipped as duplicates.
+func (s *sqliteStore) CountSkippedDuplicates(ctx context.Context, userID int64) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM duplicate_skips WHERE user_id = ?;`, userID).Scan(&n)
+	return n, err
+}
+
+// SaveDraft records a reply draft unless the review already has one.
+func (s *sqliteStore) SaveDraft(ctx context.Context, userID int64, d Draft) (bool, error) {
+	now := utcNow()
+	const stmt = `INSERT OR IGNORE INTO reply_drafts (user_id, feedback_id, rating, subject_name, nm_id, product_name, supplier_article, reviewed_at, text, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	res, err := s.db.ExecContext(ctx, stmt, userID, d.FeedbackID, d.Rating, d.SubjectName, d.NmID, d.ProductName, d.SupplierArticle,
+		nullTime(d.ReviewedAt), d.Text, d.Status, now, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetDraft returns the draft for a review, or nil if there is none.
+func (s *sqliteStore) GetDraft(ctx context.Context, userID int64, feedbackID string) (*Draft, error) {
+	const query = `SELECT ` + draftColumns + ` FROM reply_drafts WHERE user_id = ? AND feedback_id = ?;`
+	return scanDraft(s.db.QueryRowContext(ctx, query, userID, feedbackID))
+}
+
+// DraftedIDs returns the subset of ids that have a draft.
+func (s *sqliteStore) DraftedIDs(ctx context.Context, userID int64, ids []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	for start := 0; start < len(ids); start += batchRows {
+		chunk := ids[start:min(start+batchRows, len(ids))]
+		query := `SELECT feedback_id FROM reply_drafts WHERE user_id = ? AND feedback_id IN (` +
+			strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ") + `);`
+		args := make([]any, 0, len(chunk)+1)
+		args = append(args, userID)
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		if err := queryIDSet(ctx, s.db, found, query, args...); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// ResolveDraft moves a draft from one status to another.
+func (s *sqliteStore) ResolveDraft(ctx context.Context, userID int64, feedbackID, from, to string) (bool, error) {
+	const stmt = `UPDATE reply_drafts SET status = ?, updated_at = ? WHERE user_id = ? AND feedback_id = ? AND status = ?;`
+	res, err := s.db.ExecContext(ctx, stmt, to, utcNow(), userID, feedbackID, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetLanguage stores the language of bot messages for the user.
+func (s *sqliteStore) SetLanguage(ctx context.Context, chatID int64, lang string) error {
+	const stmt = `UPDATE user_configs SET language = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, lang, utcNow(), chatID)
+	return err
+}
+
+// SetPaused stops or resumes automatic answering for the user.
+func (s *sqliteStore) SetPaused(ctx context.Context, chatID int64, paused bool) error {
+	const stmt = `UPDATE user_configs SET paused = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, paused, utcNow(), chatID)
+	return err
+}
+
+// SetWBBaseURL overrides the WB API URL for the user.
+func (s *sqliteStore) SetWBBaseURL(ctx context.Context, chatID int64, baseURL string) error {
+	const stmt = `UPDATE user_configs SET wb_base_url = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, baseURL, utcNow(), chatID)
+	return err
+}
+
+// SetDailyLimit sets the maximum number of answers per day.
+func (s *sqliteStore) SetDailyLimit(ctx context.Context, chatID int64, limit int) error {
+	const stmt = `UPDATE user_configs SET daily_limit = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, limit, utcNow(), chatID)
+	return err
+}
+
+// SetHumanize toggles random pauses between answers.
+func (s *sqliteStore) SetHumanize(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET humanize = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetAnswerHoursOnly toggles holding reviews until business hours.
+func (s *sqliteStore) SetAnswerHoursOnly(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET answer_hours_only = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetAnswerDelay sets the minimum review age in minutes.
+func (s *sqliteStore) SetAnswerDelay(ctx context.Context, chatID int64, minutes int) error {
+	const stmt = `UPDATE user_configs SET answer_delay_minutes = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, minutes, utcNow(), chatID)
+	return err
+}
+
+// SetSentimentRouting toggles text-based routing of complaints.
+func (s *sqliteStore) SetSentimentRouting(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET sentiment_routing = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetTrackEdits toggles re-checking answered reviews for buyer edits.
+func (s *sqliteStore) SetTrackEdits(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET track_edits = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetDuplicateShare sets the percent of identical reviews that is answered.
+func (s *sqliteStore) SetDuplicateShare(ctx context.Context, chatID int64, percent int) error {
+	const stmt = `UPDATE user_configs SET duplicate_share = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, percent, utcNow(), chatID)
+	return err
+}
+
+// SetAIDrafts toggles AI reply drafts for negative reviews.
+func (s *sqliteStore) SetAIDrafts(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET ai_drafts = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetNegativeReport toggles the daily report of negative reviews.
+func (s *sqliteStore) SetNegativeReport(ctx context.Context, chatID int64, on bool) error {
+	const stmt = `UPDATE user_configs SET negative_report = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, on, utcNow(), chatID)
+	return err
+}
+
+// SetWBProxy sets the proxy for the user's WB requests.
+func (s *sqliteStore) SetWBProxy(ctx context.Context, chatID int64, proxy string) error {
+	const stmt = `UPDATE user_configs SET wb_proxy = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, proxy, utcNow(), chatID)
+	return err
+}
+
+// ListTokenExpiries returns users whose WB token expires before the given
+// time and who have not been told it expired.
+func (s *sqliteStore) ListTokenExpiries(ctx context.Context, before time.Time) ([]TokenExpiry, error) {
+	const query = `SELECT user_id, token_expires_at, token_expiry_notice FROM user_configs
+		WHERE token_expires_at IS NOT NULL AND token_expires_at < ? AND token_expiry_notice < ?
+			AND wb_token <> '' AND wb_token <> 'not_set'
+		ORDER BY token_expires_at, user_id;`
+	return queryTokenExpiries(ctx, s.db, query, dbTime(before), TokenNoticeExpired)
+}
+
+// SetTokenExpiryNotice records the last token expiry notice sent to the user.
+func (s *sqliteStore) SetTokenExpiryNotice(ctx context.Context, chatID int64, notice int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET token_expiry_notice = ? WHERE user_id = ?;`, notice, chatID)
+	return err
+}
+
+// SetEscalationChat sets the chat that gets a copy of 1–2★ reviews.
+func (s *sqliteStore) SetEscalationChat(ctx context.Context, chatID, target int64) error {
+	const stmt = `UPDATE user_configs SET escalation_chat_id = ?, updated_at = ? WHERE user_id = ?;`
+	_, err := s.db.ExecContext(ctx, stmt, target, utcNow(), chatID)
+	return err
+}
+
+// DailyCount returns the number of answers posted by the user on day.
+func (s *sqliteStore) DailyCount(ctx context.Context, userID int64, day string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT count FROM answer_counters WHERE user_id = ? AND day = ?;`, userID, day).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// IncrementDailyCount bumps the user's counter for day and returns it.
+func (s *sqliteStore) IncrementDailyCount(ctx context.Context, userID int64, day string) (int, error) {
+	const stmt = `INSERT INTO answer_counters (user_id, day, count) VALUES (?, ?, 1)
+        ON CONFLICT(user_id, day) DO UPDATE SET count = answer_counters.count + 1
+        RETURNING count;`
+	var n int
+	if err := s.db.QueryRowContext(ctx, stmt, userID, day).Scan(&n); err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		// First answer of the day: previous days are no longer needed
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM answer_counters WHERE user_id = ? AND day <> ?;`, userID, day); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// SetBenchmarkOptIn toggles participation in category benchmarks.
+func (s *sqliteStore) SetBenchmarkOptIn(ctx context.Context, chatID int64, optIn bool) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET benchmark_opt_in = ? WHERE user_id = ?;`, optIn, chatID)
+	return err
+}
+
+const sqliteBenchmarkQuery = `SELECT p.subject_name, COUNT(DISTINCT p.user_id), COUNT(*),
+		AVG(CASE WHEN p.response_seconds > 0 THEN p.response_seconds END), AVG(p.rating)
+	FROM processed p JOIN user_configs u ON u.user_id = p.user_id
+	WHERE u.benchmark_opt_in = 1 AND p.subject_name <> '' AND p.rating > 0
+		AND p.created_at >= ? AND p.created_at < ?
+	GROUP BY p.subject_name;`
+
+// RefreshCategoryBenchmarks recomputes and replaces the category_benchmarks table.
+func (s *sqliteStore) RefreshCategoryBenchmarks(ctx context.Context, window time.Duration, minUsers int) error {
+	stats, err := windowedCategoryStats(ctx, s.db, sqliteBenchmarkQuery, window)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate benchmarks: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM category_benchmarks;`); err != nil {
+		return fmt.Errorf("failed to clear benchmarks: %w", err)
+	}
+	const insertStmt = `INSERT INTO category_benchmarks
+		(subject_name, users, answers, avg_response_seconds, avg_rating, prev_avg_rating, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);`
+	for _, st := range stats {
+		if st.Users < int64(minUsers) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, insertStmt, st.SubjectName, st.Users, st.Answers,
+			st.AvgResponse.Seconds(), st.AvgRating, st.PrevAvgRating, st.ComputedAt); err != nil {
+			return fmt.Errorf("failed to insert benchmark: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCategoryBenchmarks returns the last computed category averages.
+func (s *sqliteStore) GetCategoryBenchmarks(ctx context.Context) ([]CategoryStats, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT subject_name, users, answers, avg_response_seconds,
+		avg_rating, prev_avg_rating, computed_at FROM category_benchmarks ORDER BY answers DESC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []CategoryStats
+	for rows.Next() {
+		var st CategoryStats
+		var avgResponse float64
+		if err := rows.Scan(&st.SubjectName, &st.Users, &st.Answers, &avgResponse,
+			&st.AvgRating, &st.PrevAvgRating, &st.ComputedAt); err != nil {
+			return nil, err
+		}
+		st.AvgResponse = time.Duration(avgResponse * float64(time.Second))
+		st.ComputedAt = fromDB(st.ComputedAt)
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// GetUserCategoryStats returns the user's own per-category averages within window.
+func (s *sqliteStore) GetUserCategoryStats(ctx context.Context, userID int64, window time.Duration) ([]CategoryStats, error) {
+	const query = `SELECT subject_name, COUNT(DISTINCT user_id), COUNT(*),
+			AVG(CASE WHEN response_seconds > 0 THEN response_seconds END), AVG(rating)
+		FROM processed
+		WHERE user_id = ? AND subject_name <> '' AND rating > 0
+			AND created_at >= ? AND created_at < ?
+		GROUP BY subject_name;`
+	return windowedCategoryStats(ctx, s.db, query, window, userID)
+}
+
+// AddExclusion excludes an article or a review from auto-answering.
+func (s *sqliteStore) AddExclusion(ctx context.Context, chatID int64, ex Exclusion) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO exclusions (user_id, kind, value, created_at) VALUES (?, ?, ?, ?);`,
+		chatID, ex.Kind, ex.Value, utcNow())
+	return err
+}
+
+// RemoveExclusion deletes an exclusion.
+func (s *sqliteStore) RemoveExclusion(ctx context.Context, chatID int64, ex Exclusion) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM exclusions WHERE user_id = ? AND kind = ? AND value = ?;`,
+		chatID, ex.Kind, ex.Value)
+	return err
+}
+
+// ListExclusions returns the user's exclusions.
+func (s *sqliteStore) ListExclusions(ctx context.Context, chatID int64) ([]Exclusion, error) {
+	return queryExclusions(ctx, s.db, `SELECT kind, value FROM exclusions WHERE user_id = ? ORDER BY kind, value;`, chatID)
+}
+
+// ListPollDue returns active users due for the satisfaction poll.
+func (s *sqliteStore) ListPollDue(ctx context.Context, askedBefore time.Time) ([]int64, error) {
+	const query = `SELECT u.user_id FROM user_configs u
+		WHERE u.wb_token <> '' AND u.wb_token <> 'not_set' AND u.paused = 0
+			AND (u.poll_asked_at IS NULL OR u.poll_asked_at < ?)
+			AND EXISTS (SELECT 1 FROM processed p WHERE p.user_id = u.user_id)
+		ORDER BY u.user_id;`
+	return queryUserIDs(ctx, s.db, query, dbTime(askedBefore))
+}
+
+// MarkPollAsked records that the satisfaction poll was sent to the user.
+func (s *sqliteStore) MarkPollAsked(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE user_configs SET poll_asked_at = ? WHERE user_id = ?;`, utcNow(), chatID)
+	return err
+}
+
+// SaveSatisfaction stores a poll answer.
+func (s *sqliteStore) SaveSatisfaction(ctx context.Context, chatID int64, score int) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO satisfaction (user_id, score, created_at) VALUES (?, ?, ?);`,
+		chatID, score, utcNow())
+	return err
+}
+
+// SetSatisfactionComment attaches a suggestion to the user's latest poll answer.
+func (s *sqliteStore) SetSatisfactionComment(ctx context.Context, chatID int64, comment string) error {
+	const stmt = `UPDATE satisfaction SET comment = ?
+		WHERE id = (SELECT MAX(id) FROM satisfaction WHERE user_id = ?);`
+	_, err := s.db.ExecContext(ctx, stmt, comment, chatID)
+	return err
+}
+
+// ListSatisfaction returns the latest poll answers, newest first.
+func (s *sqliteStore) ListSatisfaction(ctx context.Context, limit int) ([]SatisfactionResponse, error) {
+	const query = `SELECT user_id, score, comment, created_at FROM satisfaction ORDER BY id DESC LIMIT ?;`
+	return querySatisfaction(ctx, s.db, query, limit)
+}
+
+// AddChangelogEntry publishes a new "what's new" entry.
+func (s *sqliteStore) AddChangelogEntry(ctx context.Context, version, text string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO changelog (version, text, created_at) VALUES (?, ?, ?);`,
+		version, text, utcNow())
+	return err
+}
+
+// ListChangelog returns entries newer than afterID, newest first.
+func (s *sqliteStore) ListChangelog(ctx context.Context, afterID int64, limit int) ([]ChangelogEntry, error) {
+	const query = `SELECT id, version, text, created_at FROM changelog WHERE id > ? ORDER BY id DESC LIMIT ?;`
+	return queryChangelog(ctx, s.db, query, afterID, limit)
+}
+
+// CountChangelog returns the number of entries newer than afterID.
+func (s *sqliteStore) CountChangelog(ctx context.Context, afterID int64) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM changelog WHERE id > ?;`, afterID).Scan(&n)
+	return n, err
+}
+
+// MarkChangelogSeen records that the user has read entries up to id.
+func (s *sqliteStore) MarkChangelogSeen(ctx context.Context, chatID int64, id int64) error {
+	const stmt = `UPDATE user_configs SET changelog_seen_id = ? WHERE user_id = ? AND changelog_seen_id < ?;`
+	_, err := s.db.ExecContext(ctx, stmt, id, chatID, id)
+	return err
+}
+
+// AddBlackout stores a global maintenance window.
+func (s *sqliteStore) AddBlackout(ctx context.Context, spec string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO blackouts (spec, created_at) VALUES (?, ?);`, spec, utcNow())
+	return err
+}
+
+// RemoveBlackout deletes a maintenance window by ID.
+func (s *sqliteStore) RemoveBlackout(ctx context.Context, id int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM blackouts WHERE id = ?;`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListBlackouts returns all maintenance windows, oldest first.
+func (s *sqliteStore) ListBlackouts(ctx context.Context) ([]Blackout, error) {
+	return queryBlackouts(ctx, s.db, `SELECT id, spec, created_at FROM blackouts ORDER BY id;`)
+}
+
+// BanUser blocks the user, replacing the reason of an existing ban.
+func (s *sqliteStore) BanUser(ctx context.Context, chatID int64, reason string) error {
+	const stmt = `INSERT INTO banned_users (user_id, reason, banned_at) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET reason = excluded.reason;`
+	_, err := s.db.ExecContext(ctx, stmt, chatID, reason, utcNow())
+	return err
+}
+
+// UnbanUser lifts a ban.
+func (s *sqliteStore) UnbanUser(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM banned_users WHERE user_id = ?;`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListBannedUsers returns all banned users ordered by user ID.
+func (s *sqliteStore) ListBannedUsers(ctx context.Context) ([]BannedUser, error) {
+	return queryBannedUsers(ctx, s.db, `SELECT user_id, reason, banned_at FROM banned_users ORDER BY user_id;`)
+}
+
+// SaveConversation stores the user's dialog state, replacing the previous one.
+func (s *sqliteStore) SaveConversation(ctx context.Context, c Conversation) error {
+	const stmt = `INSERT INTO conversations (user_id, state, subject, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			state = excluded.state,
+			subject = excluded.subject,
+			updated_at = excluded.updated_at;`
+	_, err := s.db.ExecContext(ctx, stmt, c.UserID, c.State, c.Subject, utcNow())
+	return err
+}
+
+// GetConversation returns the user's dialog state, or nil if there is none
+// or it has expired.
+func (s *sqliteStore) GetConversation(ctx context.Context, chatID int64) (*Conversation, error) {
+	const query = `SELECT user_id, state, subject, updated_at FROM conversations
+		WHERE user_id = ? AND updated_at >= ?;`
+	return queryConversation(ctx, s.db, query, chatID, dbTime(time.Now().Add(-ConversationTTL)))
+}
+
+// DeleteConversation ends the user's dialog.
+func (s *sqliteStore) DeleteConversation(ctx context.Context, chatID int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE user_id = ?;`, chatID)
+	return err
+}
+
+// PruneConversations deletes expired dialogs.
+func (s *sqliteStore) PruneConversations(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM conversations WHERE updated_at < ?;`, dbTime(time.Now().Add(-ConversationTTL)))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// AddAdmin grants admin rights; an existing admin keeps the original record.
+func (s *sqliteStore) AddAdmin(ctx context.Context, chatID, addedBy int64) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO admins (user_id, added_by, added_at) VALUES (?, ?, ?);`,
+		chatID, addedBy, utcNow())
+	return err
+}
+
+// RemoveAdmin revokes admin rights granted at runtime.
+func (s *sqliteStore) RemoveAdmin(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM admins WHERE user_id = ?;`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListAdmins returns admins added at runtime ordered by user ID.
+func (s *sqliteStore) ListAdmins(ctx context.Context) ([]Admin, error) {
+	return queryAdmins(ctx, s.db, `SELECT user_id, added_by, added_at FROM admins ORDER BY user_id;`)
+}
+
+// AddExemption lets the user through the channel subscription check; an
+// existing exemption keeps the original record.
+func (s *sqliteStore) AddExemption(ctx context.Context, chatID, addedBy int64) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO subscription_exemptions (user_id, added_by, added_at) VALUES (?, ?, ?);`, chatID, addedBy, utcNow())
+	return err
+}
+
+// RemoveExemption removes an exemption added with AddExemption.
+func (s *sqliteStore) RemoveExemption(ctx context.Context, chatID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM subscription_exemptions WHERE user_id = ?;`, chatID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListExemptions returns exemptions added at runtime ordered by user ID.
+func (s *sqliteStore) ListExemptions(ctx context.Context) ([]Exemption, error) {
+	return queryExemptions(ctx, s.db, `SELECT user_id, added_by, added_at FROM subscription_exemptions ORDER BY user_id;`)
+}
+
+// StartMaintenance turns maintenance mode on or replaces its reason.
+func (s *sqliteStore) StartMaintenance(ctx context.Context, m Maintenance) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO maintenance (id, reason, started_by, started_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET reason = excluded.reason;`, m.Reason, m.StartedBy, dbTime(m.StartedAt))
+	return err
+}
+
+// StopMaintenance turns maintenance mode off.
+func (s *sqliteStore) StopMaintenance(ctx context.Context) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM maintenance;`)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetMaintenance returns the maintenance mode, or nil if it is off.
+func (s *sqliteStore) GetMaintenance(ctx context.Context) (*Maintenance, error) {
+	return queryMaintenance(ctx, s.db, `SELECT reason, started_by, started_at FROM maintenance WHERE id = 1;`)
+}
+
+// AddTemplateVariant appends a reply variant after the user's last one in category.
+func (s *sqliteStore) AddTemplateVariant(ctx context.Context, chatID int64, category, text string) (int, error) {
+	const stmt = `INSERT INTO templates (user_id, category, idx, text, created_at)
+		SELECT ?, ?, COALESCE(MAX(idx), 0) + 1, ?, ? FROM templates WHERE user_id = ? AND category = ?
+		RETURNING idx;`
+	var idx int
+	err := s.db.QueryRowContext(ctx, stmt, chatID, category, text, utcNow(), chatID, category).Scan(&idx)
+	return idx, err
+}
+
+// RemoveTemplateVariant deletes a reply variant.
+func (s *sqliteStore) RemoveTemplateVariant(ctx context.Context, chatID int64, category string, idx int) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM templates WHERE user_id = ? AND category = ? AND idx = ?;`, chatID, category, idx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReplaceTemplates overwrites the user's reply texts and variants in one transaction.
+func (s *sqliteStore) ReplaceTemplates(ctx context.Context, chatID int64, set TemplateSet) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := utcNow()
+	res, err := tx.ExecContext(ctx, `UPDATE user_configs SET template_good = ?, template_bad = ?, template_question = ?,
+		template_media = ?, template_off_hours = ?, updated_at = ? WHERE user_id = ?;`,
+		set.Good, set.Bad, set.Question, set.Media, set.OffHours, now, chatID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("user %d has no config: %w", chatID, sql.ErrNoRows)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM templates WHERE user_id = ?;`, chatID); err != nil {
+		return err
+	}
+	for category, texts := range map[string][]string{VariantGood: set.GoodVariants, VariantBad: set.BadVariants, VariantSignature: set.Signatures} {
+		for i, text := range texts {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO templates (user_id, category, idx, text, created_at) VALUES (?, ?, ?, ?, ?);`,
+				chatID, category, i+1, text, now); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// SetCategoryTemplate stores one reply of a product category.
+func (s *sqliteStore) SetCategoryTemplate(ctx context.Context, chatID int64, subjectID int64, subjectName, category, text string) error {
+	col, err := templateColumn(category)
+	if err != nil {
+		return err
+	}
+	stmt := `INSERT INTO category_templates (user_id, subject_id, subject_name, ` + col + `, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, subject_id) DO UPDATE SET
+			subject_name = excluded.subject_name, ` + col + ` = excluded.` + col + `, updated_at = excluded.updated_at;`
+	_, err = s.db.ExecContext(ctx, stmt, chatID, subjectID, subjectName, text, utcNow())
+	return err
+}
+
+// RemoveCategoryTemplate deletes the replies of a product category.
+func (s *sqliteStore) RemoveCategoryTemplate(ctx context.Context, chatID int64, subjectID int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM category_templates WHERE user_id = ? AND subject_id = ?;`, chatID, subjectID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListCategoryTemplates returns the user's product category replies.
+func (s *sqliteStore) ListCategoryTemplates(ctx context.Context, chatID int64) ([]CategoryTemplate, error) {
+	return queryCategoryTemplates(ctx, s.db, `SELECT subject_id, subject_name, template_good, template_bad
+		FROM category_templates WHERE user_id = ? ORDER BY subject_name, subject_id;`, chatID)
+}
+
+// ListTemplateVariants returns the user's reply variants.
+func (s *sqliteStore) ListTemplateVariants(ctx context.Context, chatID int64) ([]TemplateVariant, error) {
+	return queryTemplateVariants(ctx, s.db, `SELECT category, idx, text FROM templates WHERE user_id = ? ORDER BY category, idx;`, chatID)
 }
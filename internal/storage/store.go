@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -14,9 +16,419 @@ import (
 type Store interface {
 	Exists(ctx context.Context, userID int64, id string) (bool, error)
 	Save(ctx context.Context, userID int64, id string) error
+	// SaveAnswer is like Save but also persists answer metadata used by analytics.
+	SaveAnswer(ctx context.Context, userID int64, rec AnswerRecord) error
+	// ExistsBatch is Exists for many IDs at once: it returns the subset of
+	// ids already stored for the user, archived answers included.
+	ExistsBatch(ctx context.Context, userID int64, ids []string) (map[string]bool, error)
+	// SaveBatch stores several answers in one transaction with multi-row
+	// inserts; duplicates are ignored like in SaveAnswer.
+	SaveBatch(ctx context.Context, userID int64, recs []AnswerRecord) error
+	// UpdateAnswerText replaces the stored reply of an answered item after it
+	// was edited on WB, records source as its origin and clears the template
+	// version. It reports whether the item was found, archive included.
+	UpdateAnswerText(ctx context.Context, userID int64, id, source, text string) (bool, error)
+	// RecentAnswers returns the user's latest answers, newest first.
+	RecentAnswers(ctx context.Context, userID int64, limit int) ([]AnswerRecord, error)
+	// ListAnswers returns all of the user's answers, oldest first, for export.
+	// Archived answers are included.
+	ListAnswers(ctx context.Context, userID int64) ([]AnswerRecord, error)
+	// ArchiveAnswers moves answers stored before the cutoff from processed to
+	// processed_archive and returns how many were moved. Exists and
+	// ListAnswers still see archived rows.
+	ArchiveAnswers(ctx context.Context, before time.Time) (int64, error)
+	// DeleteProcessedOlderThan permanently removes the user's answers stored
+	// before the cutoff, from processed and processed_archive, and returns how
+	// many rows were deleted.
+	DeleteProcessedOlderThan(ctx context.Context, userID int64, before time.Time) (int64, error)
+	// SourceBreakdown aggregates the user's answers within window by decision
+	// source and template version, for comparing template variants.
+	SourceBreakdown(ctx context.Context, userID int64, window time.Duration) ([]SourceStats, error)
+	// ProductBreakdown aggregates the user's review answers within window by
+	// product, most answered first, at most limit products.
+	ProductBreakdown(ctx context.Context, userID int64, window time.Duration, limit int) ([]ProductStats, error)
+	// PeriodSummary aggregates the user's answers posted in [from, to).
+	PeriodSummary(ctx context.Context, userID int64, from, to time.Time) (PeriodSummary, error)
+	// DailyCount returns how many answers the user posted on day ("2006-01-02").
+	DailyCount(ctx context.Context, userID int64, day string) (int, error)
+	// IncrementDailyCount adds one answer to the user's counter for day and
+	// returns the new value. Counters of earlier days are dropped when a new
+	// day starts, so each day begins from zero.
+	IncrementDailyCount(ctx context.Context, userID int64, day string) (int, error)
+	// RecordFailure stores a failed cycle step for the user's error screen.
+	// Entries older than FailureRetention are dropped.
+	RecordFailure(ctx context.Context, userID int64, f Failure) error
+	// RecentFailures returns the user's latest failures, newest first.
+	RecentFailures(ctx context.Context, userID int64, limit int) ([]Failure, error)
+	// SaveFailedAnswer creates or replaces the retry state of an answer that
+	// could not be posted; FirstFailedAt is kept from the first failure.
+	// Entries not updated within FailureRetention are dropped.
+	SaveFailedAnswer(ctx context.Context, userID int64, f FailedAnswer) error
+	// ListFailedAnswers returns the user's failed answers, most attempts first.
+	ListFailedAnswers(ctx context.Context, userID int64) ([]FailedAnswer, error)
+	// DeleteFailedAnswer forgets a failed answer once it was posted.
+	DeleteFailedAnswer(ctx context.Context, userID int64, id string) error
+	// RetryFailedAnswersNow makes all of the user's failed answers due for
+	// the next cycle and returns how many there are.
+	RetryFailedAnswersNow(ctx context.Context, userID int64) (int64, error)
+	// RecordCycleResult stores the outcome of one of the user's cycles.
+	// Entries older than CycleResultRetention are dropped.
+	RecordCycleResult(ctx context.Context, userID int64, r CycleResult) error
+	// CycleProblems returns the users whose latest cycle since the cutoff
+	// failed, longest failure streak first, at most limit users.
+	CycleProblems(ctx context.Context, since time.Time, limit int) ([]CycleProblem, error)
+	// RecentCycleResults returns the user's latest cycle outcomes, newest
+	// first.
+	RecentCycleResults(ctx context.Context, userID int64, limit int) ([]CycleResult, error)
+	// ReviewSnapshots returns the user's answered reviews as last seen on
+	// WB, by feedback ID.
+	ReviewSnapshots(ctx context.Context, userID int64) (map[string]ReviewSnapshot, error)
+	// SaveReviewSnapshots creates or replaces snapshots of the user's answered
+	// reviews in one transaction. Snapshots not saved again within
+	// ReviewSnapshotRetention are dropped.
+	SaveReviewSnapshots(ctx context.Context, userID int64, snaps []ReviewSnapshot) error
+	// SkipDuplicates records reviews left unanswered as part of a burst of
+	// identical reviews; they are kept until the user's data is deleted.
+	SkipDuplicates(ctx context.Context, userID int64, ids []string) error
+	// SkippedDuplicates returns the subset of ids recorded with SkipDuplicates.
+	SkippedDuplicates(ctx context.Context, userID int64, ids []string) (map[string]bool, error)
+	// CountSkippedDuplicates returns how many reviews the user has skipped as duplicates.
+	CountSkippedDuplicates(ctx context.Context, userID int64) (int64, error)
+	// SaveNegativeReviews records newly fetched 1–3★ reviews in one
+	// transaction; recorded ones keep their SeenAt. Reviews seen before
+	// NegativeReviewRetention are dropped.
+	SaveNegativeReviews(ctx context.Context, userID int64, reviews []NegativeReview) error
+	// NegativeReviews returns the user's reviews recorded with
+	// SaveNegativeReviews since the given time, oldest first, with the
+	// replies posted to them.
+	NegativeReviews(ctx context.Context, userID int64, since time.Time) ([]NegativeReview, error)
+	// SaveDraft records a reply draft awaiting the user's approval and
+	// reports whether it was stored; a review that already has a draft keeps
+	// it. Drafts are kept until the user's data is deleted.
+	SaveDraft(ctx context.Context, userID int64, d Draft) (bool, error)
+	// GetDraft returns the draft for a review, or nil if there is none.
+	GetDraft(ctx context.Context, userID int64, feedbackID string) (*Draft, error)
+	// DraftedIDs returns the subset of ids that have a draft in any status.
+	DraftedIDs(ctx context.Context, userID int64, ids []string) (map[string]bool, error)
+	// ResolveDraft moves a draft from status from to status to and reports
+	// whether it was in status from, so that one draft is sent only once.
+	ResolveDraft(ctx context.Context, userID int64, feedbackID, from, to string) (bool, error)
+	// SaveComplaint records a complaint about a review, replacing an earlier
+	// one about the same review; CreatedAt is kept from the first attempt.
+	SaveComplaint(ctx context.Context, userID int64, c Complaint) error
+	// RecentComplaints returns the user's latest complaints, newest first.
+	RecentComplaints(ctx context.Context, userID int64, limit int) ([]Complaint, error)
+	// PendingComplaints returns the user's complaints still under
+	// moderation that were last checked before checkedBefore, the longest
+	// unchecked first.
+	PendingComplaints(ctx context.Context, userID int64, checkedBefore time.Time, limit int) ([]Complaint, error)
+	// SetComplaintStatus sets the status of a complaint and marks it checked
+	// now; setting ComplaintFiled again only records the check.
+	SetComplaintStatus(ctx context.Context, userID int64, feedbackID, status string) error
+	// ComplaintStats counts the user's complaints filed in [from, to) by
+	// status.
+	ComplaintStats(ctx context.Context, userID int64, from, to time.Time) (ComplaintStats, error)
+	// RecordAudit appends an event to the user's audit trail. Entries older
+	// than AuditRetention are dropped.
+	RecordAudit(ctx context.Context, ev AuditEvent) error
+	// RecentAudit returns the user's latest audit events, newest first.
+	RecentAudit(ctx context.Context, userID int64, limit int) ([]AuditEvent, error)
+	// GetSubscription returns the user's billing state. The first call for a
+	// user starts the trial.
+	GetSubscription(ctx context.Context, userID int64) (Subscription, error)
+	// AddTrialAnswers counts n more answers against the user's trial.
+	AddTrialAnswers(ctx context.Context, userID int64, n int) error
+	// ExtendSubscription adds d of paid access, counted from the current
+	// end of the paid period or from now if it is over, and returns the new end.
+	ExtendSubscription(ctx context.Context, userID int64, d time.Duration) (time.Time, error)
+	// RecordPayment stores a successful payment and extends the user's paid
+	// access by p.Days. A payment already recorded under the same ChargeID
+	// changes nothing and is reported with recorded = false.
+	RecordPayment(ctx context.Context, p Payment) (paidUntil time.Time, recorded bool, err error)
+	// SaveReferral records that userID came through referrerID's invite link.
+	// A user is referred once and only before their trial has started;
+	// saved is false otherwise.
+	SaveReferral(ctx context.Context, userID, referrerID int64) (saved bool, err error)
+	// CreditReferral marks userID's referral as credited and extends the
+	// referrer's paid access by bonusDays. credited is false if userID was not
+	// referred or the referral has already been credited.
+	CreditReferral(ctx context.Context, userID int64, bonusDays int) (r Referral, credited bool, err error)
+	// ReferralStats summarises the referrals of one referrer.
+	ReferralStats(ctx context.Context, referrerID int64) (ReferralStats, error)
+	// TopReferrers returns the referrers with the most credited referrals.
+	TopReferrers(ctx context.Context, limit int) ([]ReferralStats, error)
+	// Ping checks that the database is reachable and answers queries.
+	Ping(ctx context.Context) error
 	Close() error
 }
 
+// Kinds of answered items stored in processed.kind.
+const (
+	KindFeedback = "feedback"
+	KindQuestion = "question"
+)
+
+// AnswerRecord describes a posted answer; it is stored in processed alongside the ID.
+type AnswerRecord struct {
+	FeedbackID   string
+	Kind         string        // KindFeedback (default) or KindQuestion
+	Rating       int           // 1–5 stars
+	SubjectName  string        // WB product category, e.g. "Футболки"
+	ResponseTime time.Duration // from review creation to answer; 0 if unknown
+
+	// Reviewed product; zero for questions and rows saved before it was recorded.
+	NmID            int64 // WB article
+	ProductName     string
+	SupplierArticle string // seller's own article
+
+	// Decision audit: which template (and which revision of it) produced the answer.
+	Source          string // e.g. "good", "bad", "off_hours", "question"; empty for legacy rows
+	TemplateVersion string
+	ReplyText       string // text posted to WB; empty for legacy rows
+
+	AnsweredAt time.Time // set by storage on read
+}
+
+// FailureRetention is how long failed cycle steps are kept.
+const FailureRetention = 30 * 24 * time.Hour
+
+// Failure is a cycle step that did not complete: fetching reviews, posting
+// an answer or recording it.
+type Failure struct {
+	FeedbackID string // empty when the whole cycle failed (e.g. fetch)
+	Kind       string // KindFeedback or KindQuestion
+	Stage      string // what failed, e.g. "fetch", "answer", "save"
+	Cause      string // classification used to explain the failure, e.g. "unauthorized"
+	Message    string // raw error text
+	CreatedAt  time.Time
+}
+
+// FailedAnswer is an answer that could not be posted. Instead of retrying it
+// on every cycle the service waits until NextRetryAt, doubling the delay
+// after each attempt.
+type FailedAnswer struct {
+	FeedbackID    string
+	Kind          string // KindFeedback or KindQuestion
+	Attempts      int
+	Cause         string // classification of the last error, as in Failure.Cause
+	LastError     string
+	FirstFailedAt time.Time // set by storage
+	NextRetryAt   time.Time
+	UpdatedAt     time.Time // set by storage
+}
+
+// CycleResultRetention is how long cycle outcomes are kept.
+const CycleResultRetention = 7 * 24 * time.Hour
+
+// CycleResult is the outcome of a polling cycle that reached WB.
+type CycleResult struct {
+	Answered   int
+	Failed     int       // answers WB did not accept
+	Cause      string    // classification of the last error, as in Failure.Cause
+	Message    string    // raw text of the last error
+	FinishedAt time.Time // set by storage
+}
+
+// Failing reports whether the cycle stopped on an error or lost answers.
+func (r CycleResult) Failing() bool {
+	return r.Cause != "" || r.Failed > 0
+}
+
+// CycleProblem describes a user whose latest cycles failed.
+type CycleProblem struct {
+	UserID int64
+	Last   CycleResult // the latest cycle
+	Streak int         // failing cycles in a row, up to the latest
+	Since  time.Time   // when the first cycle of the streak finished
+}
+
+// ReviewSnapshotRetention is how long an answered review is remembered after
+// it was last seen on WB.
+const ReviewSnapshotRetention = 30 * 24 * time.Hour
+
+// ReviewSnapshot is an answered review as last seen on WB; later fetches are
+// compared with it to notice that the buyer edited the review.
+type ReviewSnapshot struct {
+	FeedbackID string
+	Rating     int       // 1–5 stars
+	TextHash   string    // fingerprint of the text, pros and cons
+	CheckedAt  time.Time // set by storage
+}
+
+// NegativeReviewRetention is how long negative reviews are kept for the
+// daily report.
+const NegativeReviewRetention = 7 * 24 * time.Hour
+
+// NegativeReview is a 1–3★ review recorded for the daily report.
+type NegativeReview struct {
+	FeedbackID      string
+	Rating          int    // 1–3 stars
+	SubjectName     string // WB category of the product
+	NmID            int64  // WB article of the reviewed product
+	ProductName     string
+	SupplierArticle string
+	Text            string
+	Pros            string
+	Cons            string
+	ReviewedAt      time.Time // when the review was written; zero if unknown
+	SeenAt          time.Time // set by storage: when the review was first fetched
+	Reply           string    // set by NegativeReviews: the answer posted; empty if none
+}
+
+// Complaint statuses.
+const (
+	ComplaintFiled    = "filed"    // WB accepted the complaint for moderation
+	ComplaintFailed   = "failed"   // WB refused the request, see Complaint.Error
+	ComplaintAccepted = "accepted" // moderation removed the review
+	ComplaintRejected = "rejected" // the review stayed published after moderation
+)
+
+// Complaint is a seller's complaint about an unfair review, filed through
+// WB's supplierFeedbackValuation. WB does not report the moderation outcome
+// through the API; the service infers it by polling the review, which WB
+// removes when it accepts the complaint.
+type Complaint struct {
+	FeedbackID string
+	ReasonID   int       // WB complaint reason
+	Reason     string    // text of the reason as WB named it
+	Rating     int       // 1–5 stars of the review
+	NmID       int64     // WB article of the reviewed product
+	Status     string    // Complaint* status
+	Error      string    // why filing failed
+	CreatedAt  time.Time // set by storage
+	UpdatedAt  time.Time // set by storage; the last status check of a filed complaint
+}
+
+// ComplaintStats counts complaints by status.
+type ComplaintStats struct {
+	Pending  int64 // filed and still under moderation
+	Accepted int64
+	Rejected int64
+	Failed   int64 // WB refused to file them
+}
+
+// Filed returns the number of complaints WB took for moderation.
+func (s ComplaintStats) Filed() int64 {
+	return s.Pending + s.Accepted + s.Rejected
+}
+
+// Draft statuses.
+const (
+	DraftPending = "pending" // waiting for the user
+	DraftSent    = "sent"    // posted to WB on the user's approval
+	DraftSkipped = "skipped" // the user chose to leave the review unanswered
+)
+
+// Draft is a reply to a negative review prepared for the user's approval,
+// with the review details needed to record the answer once it is posted.
+type Draft struct {
+	FeedbackID      string
+	Rating          int    // 1–5 stars of the review
+	SubjectName     string // WB category of the product
+	NmID            int64  // WB article of the reviewed product
+	ProductName     string
+	SupplierArticle string
+	ReviewedAt      time.Time // when the review was written; zero if unknown
+	Text            string
+	Status          string    // DraftPending, DraftSent or DraftSkipped
+	CreatedAt       time.Time // set by storage
+}
+
+// AuditRetention is how long audit events are kept. They are not removed
+// with the user's data, so that support can see what preceded a deletion.
+const AuditRetention = 180 * 24 * time.Hour
+
+// Actions stored in audit_log.action.
+const (
+	AuditTokenSaved      = "token_saved"
+	AuditTemplateChanged = "template_changed"
+	AuditCycleRun        = "cycle_run"
+	AuditAnswerPosted    = "answer_posted"
+	AuditAnswerEdited    = "answer_edited"
+	AuditConfigDeleted   = "config_deleted"
+	AuditPayment         = "payment"
+	AuditAccessGranted   = "access_granted"
+	AuditReferralBonus   = "referral_bonus"
+	AuditComplaintFiled  = "complaint_filed"
+)
+
+// AuditEvent is an entry in the audit trail of a user's account.
+type AuditEvent struct {
+	UserID    int64  // account the event concerns
+	Actor     int64  // who acted: the user, an admin, or 0 for the bot itself
+	Action    string // one of the Audit* constants
+	Details   string // e.g. template category or cycle totals
+	CreatedAt time.Time
+}
+
+// Subscription is a user's billing state: a free trial followed by paid
+// periods.
+type Subscription struct {
+	UserID         int64
+	TrialStartedAt time.Time
+	TrialAnswers   int       // answers posted during the trial
+	PaidUntil      time.Time // zero if the user never paid
+}
+
+// Payment is a successful payment for paid access.
+type Payment struct {
+	ChargeID         string // Telegram payment charge ID
+	ProviderChargeID string // ID assigned by the payment provider, e.g. YooKassa
+	UserID           int64
+	Amount           int64 // in the smallest currency units, e.g. kopecks
+	Currency         string
+	Days             int       // paid access bought
+	CreatedAt        time.Time // set by storage
+}
+
+// Referral is a user who came through another user's invite link.
+type Referral struct {
+	UserID     int64
+	ReferrerID int64
+	BonusDays  int // paid access credited to the referrer
+	CreatedAt  time.Time
+	CreditedAt time.Time // zero until the invited user saves a WB token
+}
+
+// ReferralStats aggregates the referrals of one referrer.
+type ReferralStats struct {
+	ReferrerID int64
+	Invited    int // users who opened the invite link
+	Credited   int // of them, users who saved a WB token
+	BonusDays  int
+}
+
+// SourceStats aggregates answers produced by one template revision.
+type SourceStats struct {
+	Source          string
+	TemplateVersion string
+	Answers         int64
+	AvgRating       float64 // 0 for questions
+}
+
+// ProductStats aggregates answers to reviews of one product.
+type ProductStats struct {
+	NmID            int64
+	ProductName     string
+	SupplierArticle string
+	Answers         int64
+	AvgRating       float64
+}
+
+// PeriodSummary aggregates a user's answers over a period.
+type PeriodSummary struct {
+	Reviews   int64    // reviews answered
+	Questions int64    // questions answered
+	ByRating  [5]int64 // reviews answered by rating: ByRating[0] is 1★, ByRating[4] is 5★
+	AvgRating float64  // 0 without rated reviews
+}
+
+// Negative returns the number of 1–3★ reviews, the ones answered with the
+// bad template.
+func (p PeriodSummary) Negative() int64 {
+	return p.ByRating[0] + p.ByRating[1] + p.ByRating[2]
+}
+
 // UserConfig represents user configuration stored in database.
 type UserConfig struct {
 	UserID       int64
@@ -24,11 +436,79 @@ type UserConfig struct {
 	TemplateGood string
 	TemplateBad  string
 	UpdatedAt    time.Time
+
+	// Business hours: answers generated outside [WorkStart, WorkEnd) in
+	// Timezone use TemplateOffHours instead of the rating template.
+	// Empty values mean the feature is not configured.
+	Timezone         string // IANA name, e.g. "Europe/Moscow"
+	WorkStart        string // "HH:MM"
+	WorkEnd          string // "HH:MM"
+	TemplateOffHours string
+	AnswerHoursOnly  bool // outside working hours reviews wait instead of being answered
+	AnswerDelay      int  // minutes a review must age before it is answered; 0 answers at once
+
+	TemplateQuestion string // reply for product questions; empty disables question answering
+
+	BenchmarkOptIn bool // user shares anonymized stats and sees category averages
+
+	Paused bool // user stopped automatic answering; config is kept
+
+	WBBaseURL string // overrides the bot-wide WB API URL (sandbox, regional endpoint); empty = default
+
+	DailyLimit int // maximum answers per day; 0 means the service default
+
+	Humanize bool // random pause between answers so they do not appear at once
+
+	ChangelogSeenID int64 // newest changelog entry the user has read
+
+	SentimentRouting bool // positive reviews with complaint text get the bad template
+
+	TemplateMedia string // reply for 4–5★ reviews with photos or video; empty uses TemplateGood
+
+	Language string // language of bot messages (i18n code); empty means Russian
+
+	TrackEdits bool // answered reviews are re-checked and rating flips reported
+
+	EscalationChatID int64 // Telegram chat that gets a copy of 1–2★ reviews; 0 disables it
+
+	DuplicateShare int // percent of each burst of identical reviews answered; 0 answers all
+
+	TokenExpiresAt time.Time // from the WB token's "exp" claim; zero if it has none
+
+	AIDrafts bool // negative reviews get an AI draft posted only on the user's approval
+
+	NegativeReport bool // a daily message lists the negative reviews of the last 24 hours
+
+	WBProxy string // HTTP or SOCKS5 proxy for the user's WB requests; empty uses the bot-wide one
+}
+
+// Reminders about an expiring WB token, stored in token_expiry_notice. A new
+// token starts again from TokenNoticeNone.
+const (
+	TokenNoticeNone    = 0
+	TokenNoticeWarned  = 1 // told that the token expires soon
+	TokenNoticeExpired = 2 // told that it expired and answering stopped
+)
+
+// TokenExpiry is a user whose WB token expires, with the last notice sent.
+type TokenExpiry struct {
+	UserID    int64
+	ExpiresAt time.Time
+	Notice    int // TokenNotice*
 }
 
 // Stats represents statistics about users and system.
 type Stats struct {
-	TotalUsers int64 // Total number of users in the system
+	TotalUsers      int64          // Total number of users in the system
+	ConfiguredUsers int64          // users with a WB token and both templates, paused ones included
+	StaleTokens     int64          // users whose WB token was rejected after it was last saved
+	TotalAnswered   int64          // feedbacks answered by the bot, archive included
+	Answered24h     int64          // feedbacks answered in the last 24 hours
+	Complaints      ComplaintStats // complaints of all users by status
+
+	Engine        string // "sqlite" or "postgres"
+	EngineVersion string // e.g. "3.46.0" or "16.2"
+	DBSize        int64  // size of the database in bytes
 }
 
 // ConfigStore abstracts persistence of user configurations.
@@ -36,5 +516,360 @@ type ConfigStore interface {
 	SaveUserConfig(ctx context.Context, chatID int64, wbToken, tplGood, tplBad string) error
 	GetUserConfig(ctx context.Context, chatID int64) (*UserConfig, error)
 	DeleteUserConfig(ctx context.Context, chatID int64) error
-	GetStats(ctx context.Context) (*Stats, error) // Get statistics about users
-}
\ No newline at end of file
+	// Ping checks that the database is reachable and answers queries.
+	Ping(ctx context.Context) error
+	GetStats(ctx context.Context) (*Stats, error)     // Get statistics about users
+	ListUserIDs(ctx context.Context) ([]int64, error) // All users with a stored config, ascending
+	// ListUsersByTokenHash returns users whose WB token has the given TokenHash, ascending.
+	ListUsersByTokenHash(ctx context.Context, hash string) ([]int64, error)
+	// ListActiveConfigs returns configs that can run unattended: a WB token is
+	// set, both templates are non-empty and the user has not paused answering.
+	ListActiveConfigs(ctx context.Context) ([]*UserConfig, error)
+	// ListUserConfigs returns one page of all stored configs ordered by user ID.
+	ListUserConfigs(ctx context.Context, offset, limit int) ([]*UserConfig, error)
+
+	// UpdateBusinessHours sets timezone and working hours; empty start/end disables the feature.
+	UpdateBusinessHours(ctx context.Context, chatID int64, timezone, workStart, workEnd string) error
+	// SetTimezone sets the IANA timezone used for the user's working hours,
+	// digests and dates; empty restores the default.
+	SetTimezone(ctx context.Context, chatID int64, timezone string) error
+	// UpdateOffHoursTemplate sets the reply used outside business hours.
+	UpdateOffHoursTemplate(ctx context.Context, chatID int64, text string) error
+	// SetAnswerHoursOnly toggles holding reviews until business hours instead of answering them.
+	SetAnswerHoursOnly(ctx context.Context, chatID int64, on bool) error
+	// SetAnswerDelay sets how many minutes a review must age before it is answered; 0 disables the delay.
+	SetAnswerDelay(ctx context.Context, chatID int64, minutes int) error
+
+	// UpdateTemplate replaces the main reply for category (VariantGood or
+	// VariantBad) and leaves the token and the other template untouched.
+	UpdateTemplate(ctx context.Context, chatID int64, category, text string) error
+	// UpdateQuestionTemplate sets the reply for product questions; empty disables them.
+	UpdateQuestionTemplate(ctx context.Context, chatID int64, text string) error
+	// UpdateMediaTemplate sets the thank-you reply for positive reviews with photos or video; empty disables it.
+	UpdateMediaTemplate(ctx context.Context, chatID int64, text string) error
+	// SetLanguage stores the language of bot messages for the user.
+	SetLanguage(ctx context.Context, chatID int64, lang string) error
+
+	// SetBenchmarkOptIn toggles participation in category benchmarks.
+	SetBenchmarkOptIn(ctx context.Context, chatID int64, optIn bool) error
+	// SetPaused stops or resumes automatic answering without touching other settings.
+	SetPaused(ctx context.Context, chatID int64, paused bool) error
+	// SetWBBaseURL overrides the WB API URL for the user; empty restores the default.
+	SetWBBaseURL(ctx context.Context, chatID int64, baseURL string) error
+	// SetDailyLimit sets the maximum number of answers per day; 0 restores the default.
+	SetDailyLimit(ctx context.Context, chatID int64, limit int) error
+	// SetHumanize toggles random pauses between answers.
+	SetHumanize(ctx context.Context, chatID int64, on bool) error
+	// SetSentimentRouting toggles text-based routing of complaints to the bad template.
+	SetSentimentRouting(ctx context.Context, chatID int64, on bool) error
+	// SetTrackEdits toggles re-checking answered reviews for buyer edits.
+	SetTrackEdits(ctx context.Context, chatID int64, on bool) error
+	// SetEscalationChat sets the chat that gets a copy of 1–2★ reviews; 0 disables it.
+	SetEscalationChat(ctx context.Context, chatID, target int64) error
+	// SetDuplicateShare sets the percent of each burst of identical reviews that is answered; 0 answers all.
+	SetDuplicateShare(ctx context.Context, chatID int64, percent int) error
+	// SetAIDrafts toggles AI reply drafts for negative reviews.
+	SetAIDrafts(ctx context.Context, chatID int64, on bool) error
+	// SetNegativeReport toggles the daily report of negative reviews.
+	SetNegativeReport(ctx context.Context, chatID int64, on bool) error
+	// SetWBProxy sets the proxy for the user's WB requests; empty restores the bot-wide one.
+	SetWBProxy(ctx context.Context, chatID int64, proxy string) error
+	// ListTokenExpiries returns users whose WB token expires before the given
+	// time and who have not yet been told it expired, soonest first.
+	ListTokenExpiries(ctx context.Context, before time.Time) ([]TokenExpiry, error)
+	// SetTokenExpiryNotice records the last token expiry notice (TokenNotice*) sent to the user.
+	SetTokenExpiryNotice(ctx context.Context, chatID int64, notice int) error
+	// RefreshCategoryBenchmarks recomputes category averages from answers of
+	// opted-in users posted within window. Categories with fewer than
+	// minUsers distinct users are omitted to keep the data anonymous.
+	RefreshCategoryBenchmarks(ctx context.Context, window time.Duration, minUsers int) error
+	// GetCategoryBenchmarks returns the last computed category averages.
+	GetCategoryBenchmarks(ctx context.Context) ([]CategoryStats, error)
+	// GetUserCategoryStats returns the user's own per-category averages within window.
+	GetUserCategoryStats(ctx context.Context, userID int64, window time.Duration) ([]CategoryStats, error)
+
+	// AddExclusion excludes an article or a review from auto-answering; duplicates are ignored.
+	AddExclusion(ctx context.Context, chatID int64, ex Exclusion) error
+	// RemoveExclusion deletes an exclusion; removing a missing one is not an error.
+	RemoveExclusion(ctx context.Context, chatID int64, ex Exclusion) error
+	// ListExclusions returns the user's exclusions ordered by kind and value.
+	ListExclusions(ctx context.Context, chatID int64) ([]Exclusion, error)
+
+	// ListPollDue returns active users who have answered at least one review and
+	// were last asked the satisfaction poll before askedBefore (or never).
+	ListPollDue(ctx context.Context, askedBefore time.Time) ([]int64, error)
+	// MarkPollAsked records that the satisfaction poll was sent to the user now.
+	MarkPollAsked(ctx context.Context, chatID int64) error
+	// SaveSatisfaction stores a poll answer.
+	SaveSatisfaction(ctx context.Context, chatID int64, score int) error
+	// SetSatisfactionComment attaches a free-text suggestion to the user's latest poll answer.
+	SetSatisfactionComment(ctx context.Context, chatID int64, comment string) error
+	// ListSatisfaction returns the latest poll answers of all users, newest first.
+	ListSatisfaction(ctx context.Context, limit int) ([]SatisfactionResponse, error)
+
+	// AddChangelogEntry publishes a new "what's new" entry.
+	AddChangelogEntry(ctx context.Context, version, text string) error
+	// ListChangelog returns entries newer than afterID, newest first.
+	ListChangelog(ctx context.Context, afterID int64, limit int) ([]ChangelogEntry, error)
+	// CountChangelog returns the number of entries newer than afterID.
+	CountChangelog(ctx context.Context, afterID int64) (int, error)
+	// MarkChangelogSeen records that the user has read entries up to id.
+	MarkChangelogSeen(ctx context.Context, chatID int64, id int64) error
+
+	// AddBlackout stores a global maintenance window as typed by the admin.
+	AddBlackout(ctx context.Context, spec string) error
+	// RemoveBlackout deletes a window; it reports whether one existed.
+	RemoveBlackout(ctx context.Context, id int64) (bool, error)
+	// ListBlackouts returns all stored windows, oldest first.
+	ListBlackouts(ctx context.Context) ([]Blackout, error)
+
+	// AddTemplateVariant stores an extra reply text for category and returns its index.
+	AddTemplateVariant(ctx context.Context, chatID int64, category, text string) (int, error)
+	// RemoveTemplateVariant deletes a variant; it reports whether one existed.
+	RemoveTemplateVariant(ctx context.Context, chatID int64, category string, idx int) (bool, error)
+	// ListTemplateVariants returns the user's variants ordered by category and index.
+	ListTemplateVariants(ctx context.Context, chatID int64) ([]TemplateVariant, error)
+	// ReplaceTemplates overwrites all reply texts and variants of an existing
+	// user at once; nothing changes if it fails.
+	ReplaceTemplates(ctx context.Context, chatID int64, set TemplateSet) error
+	// SetCategoryTemplate sets the reply for category (VariantGood or
+	// VariantBad) of the reviews of one WB product category, adding the
+	// category if needed; empty text returns it to the main template.
+	SetCategoryTemplate(ctx context.Context, chatID int64, subjectID int64, subjectName, category, text string) error
+	// RemoveCategoryTemplate deletes both replies of a category; it reports whether it had any.
+	RemoveCategoryTemplate(ctx context.Context, chatID int64, subjectID int64) (bool, error)
+	// ListCategoryTemplates returns the user's category replies ordered by category name.
+	ListCategoryTemplates(ctx context.Context, chatID int64) ([]CategoryTemplate, error)
+
+	// BanUser blocks the user; banning again replaces the reason.
+	BanUser(ctx context.Context, chatID int64, reason string) error
+	// UnbanUser lifts a ban; it reports whether one existed.
+	UnbanUser(ctx context.Context, chatID int64) (bool, error)
+	// ListBannedUsers returns all banned users ordered by user ID.
+	ListBannedUsers(ctx context.Context) ([]BannedUser, error)
+
+	// SaveConversation stores the user's dialog state, replacing the previous one.
+	SaveConversation(ctx context.Context, c Conversation) error
+	// GetConversation returns the user's dialog state, or nil if there is none
+	// or it is older than ConversationTTL.
+	GetConversation(ctx context.Context, chatID int64) (*Conversation, error)
+	// DeleteConversation ends the user's dialog; a missing one is not an error.
+	DeleteConversation(ctx context.Context, chatID int64) error
+	// PruneConversations deletes dialogs older than ConversationTTL and
+	// returns how many were removed.
+	PruneConversations(ctx context.Context) (int64, error)
+
+	// AddAdmin grants admin rights; adding an existing admin is not an error.
+	AddAdmin(ctx context.Context, chatID, addedBy int64) error
+	// RemoveAdmin revokes rights granted with AddAdmin; it reports whether they existed.
+	RemoveAdmin(ctx context.Context, chatID int64) (bool, error)
+	// ListAdmins returns admins added at runtime ordered by user ID.
+	ListAdmins(ctx context.Context) ([]Admin, error)
+
+	// AddExemption lets the user through the channel subscription check;
+	// adding an existing exemption is not an error.
+	AddExemption(ctx context.Context, chatID, addedBy int64) error
+	// RemoveExemption removes an exemption added with AddExemption; it reports whether it existed.
+	RemoveExemption(ctx context.Context, chatID int64) (bool, error)
+	// ListExemptions returns exemptions added at runtime ordered by user ID.
+	ListExemptions(ctx context.Context) ([]Exemption, error)
+
+	// StartMaintenance turns global maintenance mode on, replacing the
+	// reason if it is already on.
+	StartMaintenance(ctx context.Context, m Maintenance) error
+	// StopMaintenance turns maintenance mode off; it reports whether it was on.
+	StopMaintenance(ctx context.Context) (bool, error)
+	// GetMaintenance returns the maintenance mode, or nil if it is off.
+	GetMaintenance(ctx context.Context) (*Maintenance, error)
+}
+
+// Kinds of exclusions stored in exclusions.kind.
+const (
+	ExclusionArticle  = "nm_id"    // WB article (nmId) as decimal string
+	ExclusionFeedback = "feedback" // individual review ID
+)
+
+// Exclusion marks an article or a single review that must not be answered automatically.
+type Exclusion struct {
+	Kind  string // ExclusionArticle or ExclusionFeedback
+	Value string
+}
+
+// Satisfaction scores of the periodic poll.
+const (
+	SatisfactionBad   = 1
+	SatisfactionOK    = 2
+	SatisfactionGreat = 3
+)
+
+// SatisfactionResponse is a user's answer to the "are you happy with the bot" poll.
+type SatisfactionResponse struct {
+	UserID    int64
+	Score     int    // SatisfactionBad..SatisfactionGreat
+	Comment   string // optional suggestion
+	CreatedAt time.Time
+}
+
+// ChangelogEntry is an in-bot announcement of a new version or feature.
+type ChangelogEntry struct {
+	ID        int64
+	Version   string
+	Text      string
+	CreatedAt time.Time
+}
+
+// Template categories that can have extra variants.
+const (
+	VariantGood      = "good"      // rotated with UserConfig.TemplateGood
+	VariantBad       = "bad"       // rotated with UserConfig.TemplateBad
+	VariantSignature = "signature" // closing lines appended to every reply
+)
+
+// TemplateVariant is an additional reply text for a category. The bot picks
+// randomly among the main template and its variants.
+type TemplateVariant struct {
+	Category string // VariantGood, VariantBad or VariantSignature
+	Idx      int
+	Text     string
+}
+
+// CategoryTemplate replaces the main replies for the reviews of one WB
+// product category (Feedback.SubjectID). An empty text leaves the main
+// template for those ratings.
+type CategoryTemplate struct {
+	SubjectID   int64
+	SubjectName string // as WB names the category, e.g. "Кроссовки"
+	Good        string
+	Bad         string
+}
+
+// TemplateSet is every reply text of a user, as moved between accounts with
+// a template file.
+type TemplateSet struct {
+	Good         string
+	Bad          string
+	GoodVariants []string
+	BadVariants  []string
+	Question     string // empty disables question answering
+	Media        string // empty uses Good for reviews with photos
+	OffHours     string // empty disables the off-hours reply
+	Signatures   []string
+}
+
+// Blackout is a stored maintenance window. Spec is parsed by
+// service.ParseBlackout when the windows are loaded.
+type Blackout struct {
+	ID        int64
+	Spec      string
+	CreatedAt time.Time
+}
+
+// BannedUser is a user blocked by the admin.
+type BannedUser struct {
+	UserID   int64
+	Reason   string
+	BannedAt time.Time
+}
+
+// Conversation is a user's place in a multi-step bot dialog.
+type Conversation struct {
+	UserID    int64
+	State     int    // the bot's UserState
+	Subject   string // review the dialog is about, if any
+	UpdatedAt time.Time
+}
+
+// ConversationTTL is how long an untouched dialog is resumed; older ones are
+// treated as finished.
+const ConversationTTL = 24 * time.Hour
+
+// Admin is a user granted admin rights at runtime by another admin.
+type Admin struct {
+	UserID  int64
+	AddedBy int64
+	AddedAt time.Time
+}
+
+// Exemption is a user an admin let through the channel subscription check.
+type Exemption struct {
+	UserID  int64
+	AddedBy int64
+	AddedAt time.Time
+}
+
+// Maintenance is the global maintenance mode turned on by an admin.
+type Maintenance struct {
+	Reason    string // shown to users; may be empty
+	StartedBy int64
+	StartedAt time.Time
+}
+
+// CategoryStats holds answer aggregates for one WB product category.
+// For benchmarks Users counts distinct sellers; for a single user it is 1.
+type CategoryStats struct {
+	SubjectName   string
+	Users         int64
+	Answers       int64
+	AvgResponse   time.Duration // average time from review to answer
+	AvgRating     float64       // average rating in the current window
+	PrevAvgRating float64       // average rating in the preceding window, 0 if no data
+	ComputedAt    time.Time
+}
+
+// userConfigColumns lists user_configs columns in the order expected by scanUserConfig.
+// Both backends select with it so that new columns are added in one place.
+const userConfigColumns = `user_id, wb_token, template_good, template_bad, updated_at,
+	timezone, work_start, work_end, template_off_hours, benchmark_opt_in, template_question, paused, wb_base_url, daily_limit, humanize, changelog_seen_id, sentiment_routing, template_media, language, answer_hours_only, answer_delay_minutes, track_edits, escalation_chat_id, duplicate_share, token_expires_at, ai_drafts, negative_report, wb_proxy`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUserConfig reads a single user_configs row selected with userConfigColumns
+// and decrypts the WB token.
+func scanUserConfig(row rowScanner, tokens *TokenCipher) (*UserConfig, error) {
+	var cfg UserConfig
+	var tokenExpires sql.NullTime
+	err := row.Scan(
+		&cfg.UserID,
+		&cfg.WBToken,
+		&cfg.TemplateGood,
+		&cfg.TemplateBad,
+		&cfg.UpdatedAt,
+		&cfg.Timezone,
+		&cfg.WorkStart,
+		&cfg.WorkEnd,
+		&cfg.TemplateOffHours,
+		&cfg.BenchmarkOptIn,
+		&cfg.TemplateQuestion,
+		&cfg.Paused,
+		&cfg.WBBaseURL,
+		&cfg.DailyLimit,
+		&cfg.Humanize,
+		&cfg.ChangelogSeenID,
+		&cfg.SentimentRouting,
+		&cfg.TemplateMedia,
+		&cfg.Language,
+		&cfg.AnswerHoursOnly,
+		&cfg.AnswerDelay,
+		&cfg.TrackEdits,
+		&cfg.EscalationChatID,
+		&cfg.DuplicateShare,
+		&tokenExpires,
+		&cfg.AIDrafts,
+		&cfg.NegativeReport,
+		&cfg.WBProxy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	cfg.UpdatedAt = fromDB(cfg.UpdatedAt)
+	if tokenExpires.Valid {
+		cfg.TokenExpiresAt = fromDB(tokenExpires.Time)
+	}
+	if cfg.WBToken, err = tokens.open(cfg.WBToken); err != nil {
+		return nil, fmt.Errorf("user %d: %w", cfg.UserID, err)
+	}
+	return &cfg, nil
+}
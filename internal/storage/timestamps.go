@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Timestamps are stored in UTC by both backends so that range queries
+// (archival, statistics windows, poll scheduling) compare like with like:
+//
+//   - SQLite keeps them as ISO-8601 text with an explicit offset,
+//     "2006-01-02 15:04:05.999999999+00:00" (the driver's _time_format=sqlite).
+//     With a single offset the text sorts chronologically, so created_at
+//     comparisons and indexes work on the raw column.
+//   - PostgreSQL keeps them in TIMESTAMP columns holding the UTC wall time.
+//     Every write passes an explicit value, so the session TimeZone does not
+//     matter.
+//
+// Rows written before this convention are normalized by migration 0005.
+// Go code must not pass time.Now() or caller-supplied times to queries
+// directly: use utcNow for new values and dbTime for bounds.
+
+// utcNow is the timestamp written for new and updated rows.
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// dbTime converts a query argument to the stored form: UTC, without the
+// monotonic clock reading that would otherwise end up in SQLite text.
+func dbTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// nullTime is dbTime for nullable columns: the zero time is stored as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: dbTime(t), Valid: !t.IsZero()}
+}
+
+// fromDB normalizes a scanned timestamp. Drivers return stored UTC values in
+// Local or in a fixed zone depending on the backend; callers convert to the
+// user's timezone for display.
+func fromDB(t time.Time) time.Time {
+	return t.UTC()
+}
@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// adminErrorsWindow is how far back "/admin errors" looks for cycles.
+	adminErrorsWindow = 24 * time.Hour
+	// adminErrorsLimit is how many users "/admin errors" lists.
+	adminErrorsLimit = 30
+	// adminErrorsMessageLen is how much of the raw error text is shown.
+	adminErrorsMessageLen = 200
+)
+
+// handleAdminErrorsCommand handles "/admin errors": the users whose latest
+// cycle failed (rejected token, rate limit, answers WB did not accept),
+// longest failure streak first, so that the operator can contact them.
+func (b *Bot) handleAdminErrorsCommand(chatID int64) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	problems, err := b.userStore.CycleProblems(dbCtx, time.Now().Add(-adminErrorsWindow), adminErrorsLimit)
+	if err != nil {
+		b.log.Errorw("failed to get cycle problems", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_cycle_problems")
+		b.SendMessage(chatID, "❌ *Ошибка при получении списка*\n\nПопробуйте позже.")
+		return
+	}
+	b.SendMessage(chatID, formatCycleProblems(b.chatFormatter(chatID), problems))
+}
+
+// formatCycleProblems renders the users with failing cycles.
+func formatCycleProblems(f locale.Formatter, problems []storage.CycleProblem) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ *Пользователи с ошибками* за %s\n", f.Duration(adminErrorsWindow)))
+	if len(problems) == 0 {
+		sb.WriteString("\nВ последних циклах всех пользователей ошибок нет.")
+		return sb.String()
+	}
+	for _, p := range problems {
+		sb.WriteString(fmt.Sprintf("\n`%d` · циклов с ошибкой подряд: *%s*, с %s", p.UserID, f.Count(int64(p.Streak)), f.ShortDateTime(p.Since)))
+		last := p.Last
+		if last.Cause != "" {
+			cause, _ := failureHelp(f.Lang, last.Cause)
+			sb.WriteString("\n   Причина: " + escapeMarkdownV1(cause))
+		}
+		sb.WriteString(fmt.Sprintf("\n   Последний цикл: %s, ответов %s, не отправлено %s",
+			f.ShortDateTime(last.FinishedAt), f.Count(int64(last.Answered)), f.Count(int64(last.Failed))))
+		if msg := last.Message; msg != "" {
+			if r := []rune(msg); len(r) > adminErrorsMessageLen {
+				msg = string(r[:adminErrorsMessageLen]) + "…"
+			}
+			sb.WriteString("\n   ↳ " + escapeMarkdownV1(msg))
+		}
+	}
+	sb.WriteString("\n\nПодробности по пользователю: /admin\\_user ID и /admin audit ID.")
+	return sb.String()
+}
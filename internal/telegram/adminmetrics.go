@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"fmt"
+	"time"
+)
+
+// handleAdminMetricsCommand handles "/admin metrics": a short overview of
+// the Prometheus metrics for the last hour plus the current service state.
+func (b *Bot) handleAdminMetricsCommand(chatID int64) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+
+	recent, covered := b.metricsHistory.Recent()
+
+	var running, busy int
+	var backlog, inCooldown, withErrors int64
+	b.svcMu.RLock()
+	running = len(b.services)
+	for _, sched := range b.schedulers {
+		if sched.Busy() {
+			busy++
+		}
+	}
+	for _, svc := range b.services {
+		backlog += svc.Backlog()
+		if svc.CooldownLeft() > 0 {
+			inCooldown++
+		}
+		if svc.LastError() != nil {
+			withErrors++
+		}
+	}
+	b.svcMu.RUnlock()
+
+	f := b.chatFormatter(chatID)
+	period := "последний час"
+	if covered < 55*time.Minute {
+		period = "последние " + f.Duration(max(covered.Round(time.Minute), time.Minute))
+	}
+
+	msg := fmt.Sprintf(`📈 *Метрики*
+
+👥 Активных сервисов: *%s*
+⚙️ Циклов выполняется сейчас: %s (ждут в очереди: %s)
+📥 Неотвеченных отзывов после последних циклов: %s
+🧊 На паузе из-за лимита WB: %s
+⚠️ С ошибкой в последнем цикле: %s
+
+*За %s:*
+💬 Ответов на отзывы: %s (ошибок %s, %s)
+❓ Ответов на вопросы: %s (ошибок %s)
+🌐 Ошибок WB API: %s
+✈️ Ошибок Telegram API: %s
+🗄 Ошибок БД: %s
+🚦 Превышений лимита запросов: %s
+
+⏱ Аптайм: %s`,
+		f.Count(int64(running)), f.Count(int64(busy)), f.Count(int64(b.cycles.QueueDepth())), f.Count(backlog), f.Count(inCooldown), f.Count(withErrors),
+		period,
+		f.Count(recent.FeedbacksAnswered), f.Count(recent.FeedbacksFailed), errorRate(recent.FeedbacksFailed, recent.FeedbacksAnswered),
+		f.Count(recent.QuestionsAnswered), f.Count(recent.QuestionsFailed),
+		f.Count(recent.WBErrors), f.Count(recent.TelegramErrors), f.Count(recent.DatabaseErrors), f.Count(recent.RateLimitHits),
+		f.Duration(time.Since(b.startedAt).Round(time.Minute)))
+
+	b.SendMessage(chatID, msg)
+}
+
+// errorRate renders failed/(failed+ok) as a percentage.
+func errorRate(failed, ok int64) string {
+	if failed+ok == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(failed)*100/float64(failed+ok))
+}
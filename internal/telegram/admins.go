@@ -0,0 +1,179 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// loadAdmins reads admins added at runtime from storage into b.runtimeAdmins.
+func (b *Bot) loadAdmins() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	admins, err := b.configStore.ListAdmins(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to load admins", "err", err)
+		metrics.IncrementDatabaseError("list_admins")
+		return
+	}
+
+	set := make(map[int64]struct{}, len(admins))
+	for _, a := range admins {
+		set[a.UserID] = struct{}{}
+	}
+	b.adminsMu.Lock()
+	b.runtimeAdmins = set
+	b.adminsMu.Unlock()
+	b.log.Infow("admins loaded", "configured", len(b.configAdmins), "runtime", len(set))
+}
+
+// adminIDs returns all admins in ascending order.
+func (b *Bot) adminIDs() []int64 {
+	ids := make([]int64, 0, len(b.configAdmins))
+	for id := range b.configAdmins {
+		ids = append(ids, id)
+	}
+	b.adminsMu.RLock()
+	for id := range b.runtimeAdmins {
+		if _, ok := b.configAdmins[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	b.adminsMu.RUnlock()
+	slices.Sort(ids)
+	return ids
+}
+
+// notifyAdmins sends a Markdown message to every admin.
+func (b *Bot) notifyAdmins(text string) {
+	for _, id := range b.adminIDs() {
+		if err := b.SendMessage(id, text); err != nil {
+			b.log.Warnw("failed to notify admin", "admin_id", id, "err", err)
+		}
+	}
+}
+
+// handleAdminsCommand handles the admin commands
+//
+//	/admin admins        list admins
+//	/admin add <id>      grant admin rights
+//	/admin del <id>      revoke rights granted with /admin add
+func (b *Bot) handleAdminsCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if sub == "admins" {
+		b.sendAdminList(chatID)
+		return
+	}
+
+	userID, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+	if err != nil || userID <= 0 {
+		b.SendMessage(chatID, "Использование: `/admin add <user_id>` или `/admin del <user_id>`")
+		return
+	}
+
+	switch sub {
+	case "add":
+		if b.isAdmin(userID) {
+			b.SendMessage(chatID, fmt.Sprintf("Пользователь `%d` уже администратор.", userID))
+			return
+		}
+		b.confirmAdminAction(chatID,
+			fmt.Sprintf("🔑 Выдать права администратора пользователю `%d`?\n\nОн получит доступ ко всем командам администратора, включая удаление данных и рассылку.", userID),
+			fmt.Sprintf("новый администратор %d", userID),
+			func() { b.addAdmin(chatID, userID) })
+	case "del":
+		if _, ok := b.configAdmins[userID]; ok {
+			b.SendMessage(chatID, fmt.Sprintf("⚠️ Администратор `%d` задан в `ADMIN_USER_IDS`. Уберите его из переменной окружения и перезапустите бота.", userID))
+			return
+		}
+		if !b.isAdmin(userID) {
+			b.SendMessage(chatID, fmt.Sprintf("Пользователь `%d` не администратор.", userID))
+			return
+		}
+		b.confirmAdminAction(chatID,
+			fmt.Sprintf("🔒 Отозвать права администратора у пользователя `%d`?", userID),
+			fmt.Sprintf("отзыв прав администратора у %d", userID),
+			func() { b.removeAdmin(chatID, userID) })
+	default:
+		b.SendMessage(chatID, "Использование: `/admin add <user_id>` или `/admin del <user_id>`")
+	}
+}
+
+// sendAdminList shows configured and runtime admins.
+func (b *Bot) sendAdminList(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runtime, err := b.configStore.ListAdmins(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to list admins", "err", err)
+		metrics.IncrementDatabaseError("list_admins")
+		b.SendMessage(chatID, "❌ Не удалось получить список администраторов. Попробуйте позже.")
+		return
+	}
+
+	f := b.chatFormatter(chatID)
+	var sb strings.Builder
+	sb.WriteString("🔑 *Администраторы*\n\n")
+	for _, id := range b.adminIDs() {
+		if _, ok := b.configAdmins[id]; ok {
+			fmt.Fprintf(&sb, "• `%d` — из настроек\n", id)
+		}
+	}
+	for _, a := range runtime {
+		if _, ok := b.configAdmins[a.UserID]; ok {
+			continue
+		}
+		fmt.Fprintf(&sb, "• `%d` — добавил `%d` %s\n", a.UserID, a.AddedBy, f.DateTime(a.AddedAt))
+	}
+	sb.WriteString("\nДобавить: `/admin add <user_id>`\nУбрать: `/admin del <user_id>`")
+	b.SendMessage(chatID, sb.String())
+}
+
+func (b *Bot) addAdmin(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.AddAdmin(dbCtx, userID, adminID); err != nil {
+		b.log.Errorw("failed to add admin", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("add_admin")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось добавить администратора `%d`.", userID))
+		return
+	}
+	b.adminsMu.Lock()
+	b.runtimeAdmins[userID] = struct{}{}
+	b.adminsMu.Unlock()
+
+	b.log.Infow("admin added", "admin_id", adminID, "user_id", userID)
+	b.SendMessage(adminID, fmt.Sprintf("🔑 Пользователь `%d` теперь администратор.", userID))
+	b.SendMessage(userID, "🔑 Вам выданы права администратора бота. Панель: /admin")
+}
+
+func (b *Bot) removeAdmin(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	existed, err := b.configStore.RemoveAdmin(dbCtx, userID)
+	if err != nil {
+		b.log.Errorw("failed to remove admin", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("remove_admin")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось отозвать права у `%d`.", userID))
+		return
+	}
+	b.adminsMu.Lock()
+	delete(b.runtimeAdmins, userID)
+	b.adminsMu.Unlock()
+
+	if !existed {
+		b.SendMessage(adminID, fmt.Sprintf("Пользователь `%d` не был администратором.", userID))
+		return
+	}
+	b.log.Infow("admin removed", "admin_id", adminID, "user_id", userID)
+	b.SendMessage(adminID, fmt.Sprintf("🔒 Права администратора у `%d` отозваны.", userID))
+}
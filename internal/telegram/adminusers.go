@@ -0,0 +1,526 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// adminUsersPageSize is the number of users per page of the admin user list.
+	adminUsersPageSize = 10
+	// adminUserCycles and adminUserFailures are how many of the user's latest
+	// cycles and errors the user card shows.
+	adminUserCycles   = 3
+	adminUserFailures = 3
+)
+
+// loadBannedUsers reads banned users from storage into b.banned.
+func (b *Bot) loadBannedUsers() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	banned, err := b.configStore.ListBannedUsers(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to load banned users", "err", err)
+		metrics.IncrementDatabaseError("list_banned")
+		return
+	}
+
+	set := make(map[int64]struct{}, len(banned))
+	for _, u := range banned {
+		set[u.UserID] = struct{}{}
+	}
+	b.bannedMu.Lock()
+	b.banned = set
+	b.bannedMu.Unlock()
+	b.log.Infow("banned users loaded", "count", len(set))
+}
+
+// isBanned reports whether the admin has blocked the user.
+func (b *Bot) isBanned(chatID int64) bool {
+	b.bannedMu.RLock()
+	defer b.bannedMu.RUnlock()
+	_, ok := b.banned[chatID]
+	return ok
+}
+
+// handleAdminUserCallback dispatches the buttons of the admin user panel.
+// Every button carries a page number or a user ID in arg.
+func (b *Bot) handleAdminUserCallback(chatID int64, prefix, arg string, ctx context.Context) {
+	if !b.isAdmin(chatID) {
+		b.log.Warnw("admin panel callback from non-admin", "chat_id", chatID, "data", prefix+arg)
+		return
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+
+	switch prefix {
+	case CallbackAdminUsersPrefix:
+		b.sendAdminUserList(chatID, int(n), ctx)
+	case CallbackAdminUserPrefix:
+		b.sendAdminUserCard(chatID, n, ctx)
+	case CallbackAdminStopPrefix:
+		b.confirmAdminAction(chatID,
+			fmt.Sprintf("⏸ Остановить автоответы пользователя `%d`?\n\nСервис будет остановлен и поставлен на паузу. Пользователь сможет возобновить его сам.", n),
+			fmt.Sprintf("остановка сервиса пользователя %d", n),
+			func() { b.adminStopUser(chatID, n) })
+	case CallbackAdminDelPrefix:
+		b.confirmAdminAction(chatID,
+			fmt.Sprintf("🗑 Удалить все данные пользователя `%d`?\n\nТокен, шаблоны, настройки и история ответов будут удалены безвозвратно.", n),
+			fmt.Sprintf("удаление данных пользователя %d", n),
+			func() { b.adminDeleteUser(chatID, n) })
+	case CallbackAdminBanPrefix:
+		if b.isAdmin(n) {
+			b.SendMessage(chatID, "⚠️ Нельзя заблокировать администратора.")
+			return
+		}
+		b.confirmAdminAction(chatID,
+			fmt.Sprintf("🚫 Заблокировать пользователя `%d`?\n\nСервис будет остановлен, бот перестанет отвечать на его сообщения. Данные сохранятся.", n),
+			fmt.Sprintf("блокировка пользователя %d", n),
+			func() { b.adminBanUser(chatID, n) })
+	case CallbackAdminUnbanPrefix:
+		b.adminUnbanUser(chatID, n)
+	case CallbackAdminRunPrefix:
+		b.adminRunCycle(chatID, n)
+	default:
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+	}
+}
+
+// handleAdminUserCommand handles "/admin user <user_id>": the user's card,
+// the same as from the user list, for answering support requests.
+func (b *Bot) handleAdminUserCommand(chatID int64, args string, ctx context.Context) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil || userID <= 0 {
+		b.SendMessage(chatID, "Использование: `/admin user <user_id>`\n\nПоказывает настройки пользователя (токен скрыт), состояние его расписания, последние циклы и ошибки, подписку и кнопки для запуска цикла и остановки сервиса.")
+		return
+	}
+	b.sendAdminUserCard(chatID, userID, ctx)
+}
+
+// sendAdminUserList shows one page of users with a button per user.
+func (b *Bot) sendAdminUserList(chatID int64, page int, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	stats, err := b.configStore.GetStats(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to get stats", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_stats")
+		b.SendMessage(chatID, "❌ *Ошибка при получении списка пользователей*\n\nПопробуйте позже.")
+		return
+	}
+	pages := int((stats.TotalUsers + adminUsersPageSize - 1) / adminUsersPageSize)
+	if pages == 0 {
+		b.SendMessage(chatID, "👥 Пользователей пока нет.")
+		return
+	}
+	page = min(page, pages-1)
+
+	configs, err := b.configStore.ListUserConfigs(dbCtx, page*adminUsersPageSize, adminUsersPageSize)
+	if err != nil {
+		b.log.Errorw("failed to list user configs", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("list_configs")
+		b.SendMessage(chatID, "❌ *Ошибка при получении списка пользователей*\n\nПопробуйте позже.")
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, cfg := range configs {
+		label := fmt.Sprintf("%s %d", b.adminUserIcon(cfg), cfg.UserID)
+		if row := b.appendCallbackButton(nil, label, CallbackAdminUserPrefix, strconv.FormatInt(cfg.UserID, 10)); len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = b.appendCallbackButton(nav, "◀️ Назад", CallbackAdminUsersPrefix, strconv.Itoa(page-1))
+	}
+	if page < pages-1 {
+		nav = b.appendCallbackButton(nav, "Вперёд ▶️", CallbackAdminUsersPrefix, strconv.Itoa(page+1))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	f := b.chatFormatter(chatID)
+	msg := fmt.Sprintf(`👥 *Пользователи* — страница %d из %d
+
+Всего: %s
+✅ работает · ⏸ на паузе · ⚠️ не настроен · 🚫 заблокирован
+
+Нажмите на пользователя, чтобы открыть его настройки.`, page+1, pages, f.Count(stats.TotalUsers))
+	b.SendMessageWithKeyboard(chatID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// adminUserIcon is the status marker shown next to a user in the list.
+func (b *Bot) adminUserIcon(cfg *storage.UserConfig) string {
+	switch {
+	case b.isBanned(cfg.UserID):
+		return "🚫"
+	case b.getServiceForUser(cfg.UserID) != nil:
+		return "✅"
+	case cfg.Paused:
+		return "⏸"
+	default:
+		return "⚠️"
+	}
+}
+
+// sendAdminUserCard shows a user's config with the token masked and the
+// actions available to the admin.
+func (b *Bot) sendAdminUserCard(chatID, userID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cfg, err := b.configStore.GetUserConfig(dbCtx, userID)
+	if err != nil {
+		b.log.Errorw("failed to get user config for admin", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("get_config")
+		b.SendMessage(chatID, "❌ *Ошибка при получении настроек*\n\nПопробуйте позже.")
+		return
+	}
+	banned := b.isBanned(userID)
+	running := b.getServiceForUser(userID) != nil
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "👤 *Пользователь* `%d`\n\n", userID)
+	switch {
+	case banned:
+		sb.WriteString("Статус: 🚫 Заблокирован\n")
+	case running:
+		sb.WriteString("Статус: ✅ Сервис работает\n")
+	case cfg != nil && cfg.Paused:
+		sb.WriteString("Статус: ⏸ На паузе\n")
+	default:
+		sb.WriteString("Статус: ⚠️ Сервис не запущен\n")
+	}
+
+	if cfg == nil {
+		sb.WriteString("\nНастройки не сохранены.")
+	} else {
+		f := formatterFor(cfg)
+		fmt.Fprintf(&sb, "Токен WB: `%s`\n", maskToken(cfg.WBToken))
+		fmt.Fprintf(&sb, "Шаблон 4–5⭐: %s\n", escapeMarkdownV1(variantPreview(cfg.TemplateGood)))
+		fmt.Fprintf(&sb, "Шаблон 1–3⭐: %s\n", escapeMarkdownV1(variantPreview(cfg.TemplateBad)))
+		if cfg.TemplateQuestion != "" {
+			fmt.Fprintf(&sb, "Ответ на вопросы: %s\n", escapeMarkdownV1(variantPreview(cfg.TemplateQuestion)))
+		}
+		if cfg.WorkStart != "" {
+			fmt.Fprintf(&sb, "Рабочие часы: %s–%s (%s)\n", cfg.WorkStart, cfg.WorkEnd, escapeMarkdownV1(cfg.Timezone))
+		}
+		if cfg.DailyLimit > 0 {
+			fmt.Fprintf(&sb, "Дневной лимит: %d\n", cfg.DailyLimit)
+		}
+		if cfg.AnswerDelay > 0 {
+			fmt.Fprintf(&sb, "Задержка ответа: %s\n", f.Duration(time.Duration(cfg.AnswerDelay)*time.Minute))
+		}
+		if cfg.TrackEdits {
+			sb.WriteString("Изменения отзывов: отслеживаются\n")
+		}
+		if cfg.NegativeReport {
+			sb.WriteString("Отчёт о негативных отзывах: ежедневно\n")
+		}
+		if cfg.EscalationChatID != 0 {
+			fmt.Fprintf(&sb, "Негативные отзывы: пересылаются в чат %d\n", cfg.EscalationChatID)
+		}
+		if cfg.WBBaseURL != "" {
+			fmt.Fprintf(&sb, "WB API: %s\n", escapeMarkdownV1(cfg.WBBaseURL))
+		}
+		if cfg.WBProxy != "" {
+			fmt.Fprintf(&sb, "Прокси: %s\n", b.proxyStatus(cfg))
+		}
+		fmt.Fprintf(&sb, "Обновлено: %s\n", f.DateTime(cfg.UpdatedAt))
+		b.writeAdminUserSchedule(&sb, f, userID)
+		b.writeAdminUserAccess(dbCtx, &sb, f, userID)
+		b.writeAdminUserCycles(dbCtx, &sb, f, userID)
+	}
+
+	id := strconv.FormatInt(userID, 10)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	addRow := func(row []tgbotapi.InlineKeyboardButton) {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if running {
+		row := b.appendCallbackButton(nil, "🚀 Запустить цикл", CallbackAdminRunPrefix, id)
+		addRow(b.appendCallbackButton(row, "⏸ Остановить сервис", CallbackAdminStopPrefix, id))
+	}
+	if banned {
+		addRow(b.appendCallbackButton(nil, "✅ Разблокировать", CallbackAdminUnbanPrefix, id))
+	} else {
+		addRow(b.appendCallbackButton(nil, "🚫 Заблокировать", CallbackAdminBanPrefix, id))
+	}
+	if cfg != nil {
+		addRow(b.appendCallbackButton(nil, "🗑 Удалить данные", CallbackAdminDelPrefix, id))
+	}
+	row := b.appendCallbackButton(nil, "🔄 Обновить", CallbackAdminUserPrefix, id)
+	addRow(b.appendCallbackButton(row, "⬅️ К списку", CallbackAdminUsersPrefix, "0"))
+	b.SendMessageWithKeyboard(chatID, sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// writeAdminUserSchedule adds the state of the user's scheduled cycles to
+// the user card.
+func (b *Bot) writeAdminUserSchedule(sb *strings.Builder, f locale.Formatter, userID int64) {
+	job := b.userJob(userID)
+	if job == nil {
+		sb.WriteString("\n*Расписание:* не запущено\n")
+		return
+	}
+	fmt.Fprintf(sb, "\n*Расписание:* каждые %s\n", f.Duration(job.Interval()))
+	if last := job.LastRun(); last.IsZero() {
+		sb.WriteString("Последний запуск: ещё не запускался\n")
+	} else {
+		fmt.Fprintf(sb, "Последний запуск: %s\n", f.DateTime(last))
+	}
+	if job.Busy() {
+		sb.WriteString("Сейчас: цикл выполняется\n")
+	} else if next := job.NextRun(); !next.IsZero() {
+		fmt.Fprintf(sb, "Следующий запуск: %s\n", f.DateTime(next))
+	}
+}
+
+// writeAdminUserAccess adds the user's paid access and channel subscription
+// to the user card.
+func (b *Bot) writeAdminUserAccess(ctx context.Context, sb *strings.Builder, f locale.Formatter, userID int64) {
+	if b.plan != nil {
+		sub, err := b.userStore.GetSubscription(ctx, userID)
+		if err != nil {
+			b.log.Warnw("failed to read subscription for admin", "user_id", userID, "err", err)
+			metrics.IncrementDatabaseError("get_subscription")
+			sb.WriteString("\n*Доступ:* не удалось получить\n")
+		} else {
+			switch a := b.plan.Access(sub, time.Now()); {
+			case a.Paid:
+				fmt.Fprintf(sb, "\n*Доступ:* оплачен до %s\n", f.DateTime(a.Until))
+			case a.Active:
+				fmt.Fprintf(sb, "\n*Доступ:* пробный период до %s, осталось ответов %s\n", f.DateTime(a.Until), f.Count(int64(a.AnswersLeft)))
+			default:
+				sb.WriteString("\n*Доступ:* 🔒 закончился\n")
+			}
+		}
+	}
+	if len(b.channels()) == 0 {
+		return
+	}
+	if b.isExempt(userID) {
+		sb.WriteString("Подписка на каналы: не требуется\n")
+	} else if missing := b.missingChannels(userID); len(missing) == 0 {
+		sb.WriteString("Подписка на каналы: ✅ есть\n")
+	} else {
+		fmt.Fprintf(sb, "Подписка на каналы: ❌ нет на %s\n", escapeMarkdownV1(strings.Join(channelKeys(missing), ", ")))
+	}
+}
+
+// writeAdminUserCycles adds the user's latest cycles and errors to the user
+// card.
+func (b *Bot) writeAdminUserCycles(ctx context.Context, sb *strings.Builder, f locale.Formatter, userID int64) {
+	results, err := b.userStore.RecentCycleResults(ctx, userID, adminUserCycles)
+	if err != nil {
+		b.log.Warnw("failed to get cycle results for admin", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("get_cycle_results")
+		sb.WriteString("\n*Последние циклы:* не удалось получить\n")
+	} else if len(results) == 0 {
+		fmt.Fprintf(sb, "\n*Последние циклы:* нет за %s\n", f.Days(storage.CycleResultRetention))
+	} else {
+		sb.WriteString("\n*Последние циклы:*\n")
+		for _, r := range results {
+			fmt.Fprintf(sb, "%s · ответов %s", f.ShortDateTime(r.FinishedAt), f.Count(int64(r.Answered)))
+			if r.Failed > 0 {
+				fmt.Fprintf(sb, ", не отправлено %s", f.Count(int64(r.Failed)))
+			}
+			if r.Cause != "" {
+				cause, _ := failureHelp(f.Lang, r.Cause)
+				sb.WriteString(" · " + escapeMarkdownV1(cause))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	failures, err := b.userStore.RecentFailures(ctx, userID, adminUserFailures)
+	if err != nil {
+		b.log.Warnw("failed to get failures for admin", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("get_failures")
+		sb.WriteString("\n*Последние ошибки:* не удалось получить\n")
+		return
+	}
+	if len(failures) == 0 {
+		fmt.Fprintf(sb, "\n*Последние ошибки:* нет за %s\n", f.Days(storage.FailureRetention))
+		return
+	}
+	sb.WriteString("\n*Последние ошибки:*\n")
+	for _, fl := range failures {
+		fmt.Fprintf(sb, "%s · %s", f.ShortDateTime(fl.CreatedAt), failureStageLabel(f.Lang, fl))
+		if fl.FeedbackID != "" {
+			sb.WriteString(" `" + fl.FeedbackID + "`")
+		}
+		msg := fl.Message
+		if r := []rune(msg); len(r) > adminErrorsMessageLen {
+			msg = string(r[:adminErrorsMessageLen]) + "…"
+		}
+		if msg != "" {
+			sb.WriteString("\n   ↳ " + escapeMarkdownV1(msg))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// maskToken keeps only the ends of a WB token so that the admin can tell
+// tokens apart without seeing them.
+func maskToken(token string) string {
+	if token == "" || token == "not_set" {
+		return "не установлен"
+	}
+	if len(token) <= 12 {
+		return "***"
+	}
+	return token[:4] + "…" + token[len(token)-4:]
+}
+
+// adminStopUser pauses the user's answering and stops the scheduler.
+func (b *Bot) adminStopUser(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.SetPaused(dbCtx, userID, true); err != nil {
+		b.log.Errorw("admin stop: failed to pause user", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось остановить сервис пользователя `%d`.", userID))
+		return
+	}
+	b.shutdownUserService(userID)
+	b.log.Infow("admin stopped user service", "admin_id", adminID, "user_id", userID)
+	b.SendMessage(adminID, fmt.Sprintf("⏸ Сервис пользователя `%d` остановлен.", userID))
+}
+
+// adminRunCycle runs one cycle of the user's service now and reports the
+// outcome to the admin; the user is not notified.
+func (b *Bot) adminRunCycle(adminID, userID int64) {
+	svc := b.getServiceForUser(userID)
+	if svc == nil {
+		b.SendMessage(adminID, fmt.Sprintf("⚠️ Сервис пользователя `%d` не запущен.", userID))
+		return
+	}
+	b.svcMu.RLock()
+	draining := b.draining
+	if !draining {
+		b.manualCycles.Add(1)
+	}
+	b.svcMu.RUnlock()
+	if draining {
+		b.SendMessage(adminID, "⏳ Бот останавливается, цикл не запущен.")
+		return
+	}
+
+	b.log.Infow("admin started a cycle for user", "admin_id", adminID, "user_id", userID)
+	b.audit(userID, adminID, storage.AuditCycleRun, "admin run")
+	b.SendMessage(adminID, fmt.Sprintf("🚀 Цикл пользователя `%d` запущен.", userID))
+	go func() {
+		b.manualRunning.Add(1)
+		defer b.manualCycles.Done()
+		defer b.manualRunning.Add(-1)
+		defer func() {
+			if r := recover(); r != nil {
+				b.log.Errorw("panic recovered in admin cycle", "user_id", userID, "panic", r)
+			}
+		}()
+
+		if !b.withUserLock(b.cycleCtx, userID, b.batchGuard(userID, svc.HandleCycle)) {
+			b.SendMessage(adminID, fmt.Sprintf("⏳ Цикл пользователя `%d` уже выполняется.", userID))
+			return
+		}
+		if b.cycleCtx.Err() != nil {
+			return
+		}
+		msg := fmt.Sprintf("✅ Цикл пользователя `%d` завершён.", userID)
+		if reason := describeWBError(b.lang(adminID), svc.LastError()); reason != "" {
+			msg = fmt.Sprintf("⚠️ Цикл пользователя `%d` завершён с ошибкой\n\n%s", userID, reason)
+		}
+		b.SendMessage(adminID, msg)
+	}()
+}
+
+// adminDeleteUser removes everything stored for the user.
+func (b *Bot) adminDeleteUser(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := b.configStore.DeleteUserConfig(dbCtx, userID); err != nil {
+		b.log.Errorw("admin delete: failed to delete user", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("delete_config")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось удалить данные пользователя `%d`.", userID))
+		return
+	}
+	b.shutdownUserService(userID)
+	b.resetUserState(userID)
+	b.log.Infow("admin deleted user data", "admin_id", adminID, "user_id", userID)
+	b.audit(userID, adminID, storage.AuditConfigDeleted, "")
+	b.SendMessage(adminID, fmt.Sprintf("🗑 Данные пользователя `%d` удалены.", userID))
+}
+
+// adminBanUser blocks the user and stops their service; the config is kept.
+func (b *Bot) adminBanUser(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.BanUser(dbCtx, userID, fmt.Sprintf("admin %d", adminID)); err != nil {
+		b.log.Errorw("admin ban: failed to ban user", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("ban_user")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось заблокировать пользователя `%d`.", userID))
+		return
+	}
+	b.bannedMu.Lock()
+	b.banned[userID] = struct{}{}
+	b.bannedMu.Unlock()
+
+	b.shutdownUserService(userID)
+	b.resetUserState(userID)
+	b.log.Infow("admin banned user", "admin_id", adminID, "user_id", userID)
+	b.SendMessage(adminID, fmt.Sprintf("🚫 Пользователь `%d` заблокирован.", userID))
+}
+
+// adminUnbanUser lifts the ban and starts the service again if the user
+// is configured and not paused.
+func (b *Bot) adminUnbanUser(adminID, userID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	existed, err := b.configStore.UnbanUser(dbCtx, userID)
+	if err != nil {
+		b.log.Errorw("admin unban: failed to unban user", "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("ban_user")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Не удалось разблокировать пользователя `%d`.", userID))
+		return
+	}
+	b.bannedMu.Lock()
+	delete(b.banned, userID)
+	b.bannedMu.Unlock()
+	if !existed {
+		b.SendMessage(adminID, fmt.Sprintf("Пользователь `%d` не был заблокирован.", userID))
+		return
+	}
+
+	running, err := b.restartUserService(userID)
+	if err != nil {
+		b.log.Warnw("admin unban: failed to restart service", "user_id", userID, "err", err)
+	}
+	b.log.Infow("admin unbanned user", "admin_id", adminID, "user_id", userID, "running", running)
+	msg := fmt.Sprintf("✅ Пользователь `%d` разблокирован.", userID)
+	if running {
+		msg += " Сервис запущен."
+	}
+	b.SendMessage(adminID, msg)
+}
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// answerDelayOption holds reviews back until they are as old as the user asked.
+func answerDelayOption(cfg *storage.UserConfig) service.Option {
+	if cfg.AnswerDelay <= 0 {
+		return nil
+	}
+	return service.WithAnswerDelay(time.Duration(cfg.AnswerDelay) * time.Minute)
+}
+
+// answerDelayDisplay renders the user's answer delay for the info screen.
+func answerDelayDisplay(cfg *storage.UserConfig) string {
+	f := formatterFor(cfg)
+	if cfg.AnswerDelay <= 0 {
+		return i18n.T(f.Lang, i18n.MsgAnswerDelayNone)
+	}
+	return f.Duration(time.Duration(cfg.AnswerDelay) * time.Minute)
+}
+
+// parseAnswerDelay reads a delay like "2h", "90m", "1ч 30мин" or a bare
+// number of hours. "0" disables the delay.
+func parseAnswerDelay(text string) (time.Duration, error) {
+	s := strings.ToLower(strings.Join(strings.Fields(text), ""))
+	if hours, err := strconv.Atoi(s); err == nil {
+		s = strconv.Itoa(hours) + "h"
+	}
+	s = strings.NewReplacer("мин", "m", "м", "m", "ч", "h").Replace(s)
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 || d > service.MaxAnswerDelay {
+		return 0, errors.New("answer delay out of range")
+	}
+	return d.Round(time.Minute), nil
+}
+
+func (b *Bot) handleAnswerDelayButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingAnswerDelay)
+	msg := b.t(chatID, i18n.MsgAnswerDelayPrompt,
+		answerDelayDisplay(cfg), int(service.MaxAnswerDelay.Hours()))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleAnswerDelayInput(chatID int64, text string, ctx context.Context) {
+	d, err := parseAnswerDelay(text)
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgAnswerDelayInvalid, int(service.MaxAnswerDelay.Hours())), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if err := b.configStore.SetAnswerDelay(ctx, chatID, int(d.Minutes())); err != nil {
+		b.log.Errorw("failed to save answer delay", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgAnswerDelayOff)
+	if d > 0 {
+		msg = b.t(chatID, i18n.MsgAnswerDelaySet, b.chatFormatter(chatID).Duration(d))
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,21 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TelegramAPI is the part of the Bot API client the bot uses.
+// *tgbotapi.BotAPI implements it; telegramtest.API is an in-memory
+// implementation for driving the bot without Telegram.
+type TelegramAPI interface {
+	GetMe() (tgbotapi.User, error)
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetChat(config tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error)
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+	GetFileDirectURL(fileID string) (string, error)
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+}
+
+var _ TelegramAPI = (*tgbotapi.BotAPI)(nil)
@@ -0,0 +1,143 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+)
+
+const (
+	archiveCountTimeout   = 5 * time.Minute
+	archiveProgressPeriod = time.Minute // minimum pause between progress messages
+)
+
+// handleArchiveCommand handles "/archive": it counts archived reviews that
+// have no answer and asks for confirmation before answering them, since
+// there can be thousands. "/archive stop" stops a running pass.
+func (b *Bot) handleArchiveCommand(chatID int64, args string) {
+	if strings.TrimSpace(args) == "stop" {
+		b.archiveMu.Lock()
+		cancel, ok := b.archiveRuns[chatID]
+		b.archiveMu.Unlock()
+		if !ok {
+			b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveNotRunning))
+			return
+		}
+		cancel()
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveStopping))
+		return
+	}
+	if b.archiveRunning(chatID) {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveBusy))
+		return
+	}
+	svc := b.getServiceForUser(chatID)
+	if svc == nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgArchiveNoService), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveCounting))
+	ctx, cancel := context.WithTimeout(b.ctx, archiveCountTimeout)
+	defer cancel()
+	count, err := svc.CountArchived(ctx)
+	if err != nil {
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgArchiveFetchFailed)
+		}
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgArchiveUnavailable)+reason, b.CreateMainMenuForUser(chatID))
+		return
+	}
+	f := b.chatFormatter(chatID)
+	if count.Unanswered == 0 {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgArchiveNothing, f.Count(int64(count.Scanned))), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnArchiveAnswer), CallbackArchiveConfirm),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCancel), CallbackCancel),
+		),
+	)
+	unanswered := int64(count.Unanswered)
+	reviews := f.Count(unanswered) + " " + i18n.Plural(f.Lang, i18n.MsgReviewNoun, unanswered)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgArchivePrompt, reviews, f.Count(int64(count.Scanned))), keyboard)
+}
+
+// handleArchiveConfirm starts answering the archive in the background.
+func (b *Bot) handleArchiveConfirm(chatID int64) {
+	svc := b.getServiceForUser(chatID)
+	if svc == nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgArchiveNoServiceConfirm), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.archiveMu.Lock()
+	if _, ok := b.archiveRuns[chatID]; ok {
+		b.archiveMu.Unlock()
+		cancel()
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveBusy))
+		return
+	}
+	b.archiveRuns[chatID] = cancel
+	b.archiveMu.Unlock()
+
+	b.log.Infow("archive run started", "chat_id", chatID)
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveStarted))
+	go func() {
+		defer func() {
+			b.archiveMu.Lock()
+			delete(b.archiveRuns, chatID)
+			b.archiveMu.Unlock()
+			cancel()
+		}()
+
+		f := b.chatFormatter(chatID)
+		lastReport := time.Now()
+		res, err := svc.AnswerArchived(ctx, func(r service.ArchiveResult) {
+			if time.Since(lastReport) < archiveProgressPeriod {
+				return
+			}
+			lastReport = time.Now()
+			b.SendMessage(chatID, b.t(chatID, i18n.MsgArchiveProgress, f.Count(int64(r.Scanned)), f.Count(int64(r.Answered))))
+		})
+
+		var sb strings.Builder
+		switch {
+		case err == nil && res.LimitReached:
+			sb.WriteString(b.t(chatID, i18n.MsgArchiveLimitReached))
+		case err == nil:
+			sb.WriteString(b.t(chatID, i18n.MsgArchiveDone))
+		case ctx.Err() != nil && b.ctx.Err() == nil:
+			sb.WriteString(b.t(chatID, i18n.MsgArchiveStopped))
+		default:
+			reason := describeWBError(b.lang(chatID), err)
+			if reason == "" {
+				reason = b.t(chatID, i18n.MsgArchiveUnreachable)
+			}
+			sb.WriteString(b.t(chatID, i18n.MsgArchiveAborted, reason))
+		}
+		sb.WriteString(b.t(chatID, i18n.MsgArchiveTotals,
+			f.Count(int64(res.Scanned)), f.Count(int64(res.Unanswered)), f.Count(int64(res.Answered)),
+			f.Count(int64(res.Skipped)), f.Count(int64(res.Failed))))
+		if res.Failed > 0 {
+			sb.WriteString(b.t(chatID, i18n.MsgArchiveRetryLater))
+		}
+		b.SendMessageWithKeyboard(chatID, sb.String(), b.CreateMainMenuForUser(chatID))
+	}()
+}
+
+func (b *Bot) archiveRunning(chatID int64) bool {
+	b.archiveMu.Lock()
+	defer b.archiveMu.Unlock()
+	_, ok := b.archiveRuns[chatID]
+	return ok
+}
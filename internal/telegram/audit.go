@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// auditLimit is how many events "/admin audit" shows.
+const auditLimit = 30
+
+// audit records an event in userID's audit trail on behalf of actor.
+func (b *Bot) audit(userID, actor int64, action, details string) {
+	service.RecordAudit(context.Background(), b.userStore,
+		storage.AuditEvent{UserID: userID, Actor: actor, Action: action, Details: details}, b.log)
+}
+
+// handleAdminAuditCommand handles "/admin audit <user_id>": the user's
+// latest audit events, for support requests and disputes.
+func (b *Bot) handleAdminAuditCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		b.SendMessage(chatID, "Использование: `/admin audit <user_id>`\n\nПоказывает последние действия по аккаунту пользователя: сохранение токена, изменения шаблонов, циклы с ответами, ответы вручную и удаление данных.")
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := b.userStore.RecentAudit(dbCtx, userID, auditLimit)
+	if err != nil {
+		b.log.Errorw("failed to get audit log", "chat_id", chatID, "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("get_audit")
+		b.SendMessage(chatID, "❌ *Ошибка при получении журнала*\n\nПопробуйте позже.")
+		return
+	}
+	b.SendMessage(chatID, formatAudit(b.chatFormatter(chatID), userID, events))
+}
+
+// formatAudit renders audit events, newest first.
+func formatAudit(f locale.Formatter, userID int64, events []storage.AuditEvent) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧾 *Журнал действий* пользователя `%d`\n", userID))
+	if len(events) == 0 {
+		sb.WriteString("\nЗаписей нет.")
+		return sb.String()
+	}
+	for _, ev := range events {
+		sb.WriteString("\n" + f.ShortDateTime(ev.CreatedAt) + " · " + auditActionLabel(ev.Action))
+		switch ev.Actor {
+		case 0:
+			sb.WriteString(" · бот")
+		case ev.UserID:
+			sb.WriteString(" · пользователь")
+		default:
+			sb.WriteString(fmt.Sprintf(" · админ `%d`", ev.Actor))
+		}
+		if ev.Details != "" {
+			sb.WriteString("\n   ↳ " + escapeMarkdownV1(ev.Details))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n\nХранится %d дней, в том числе после удаления данных пользователя.", int(storage.AuditRetention/(24*time.Hour))))
+	return sb.String()
+}
+
+func auditActionLabel(action string) string {
+	switch action {
+	case storage.AuditTokenSaved:
+		return "🔑 токен сохранён"
+	case storage.AuditTemplateChanged:
+		return "📝 шаблон изменён"
+	case storage.AuditCycleRun:
+		return "🔄 обработка отзывов"
+	case storage.AuditAnswerPosted:
+		return "✅ ответ вручную"
+	case storage.AuditAnswerEdited:
+		return "✏️ ответ изменён"
+	case storage.AuditConfigDeleted:
+		return "🗑 данные удалены"
+	case storage.AuditPayment:
+		return "💳 оплата"
+	case storage.AuditAccessGranted:
+		return "🎁 доступ выдан"
+	case storage.AuditReferralBonus:
+		return "🤝 бонус за приглашение"
+	case storage.AuditComplaintFiled:
+		return "🚩 жалоба на отзыв"
+	default:
+		return escapeMarkdownV1(action)
+	}
+}
+
+// templateAuditDetails describes a template change for the audit log; an
+// empty text means the template was turned off.
+func templateAuditDetails(category, text string) string {
+	if text == "" {
+		return category + " disabled"
+	}
+	return category
+}
@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// wbSandboxURL is the WB sandbox for the feedbacks API. It accepts only
+// sandbox tokens issued in the seller's account and never touches real reviews.
+const wbSandboxURL = "https://feedbacks-api-sandbox.wildberries.ru"
+
+// baseURLFor returns the WB API URL for the user: their override if set,
+// otherwise the bot-wide default.
+func (b *Bot) baseURLFor(cfg *storage.UserConfig) string {
+	if cfg != nil && cfg.WBBaseURL != "" {
+		return cfg.WBBaseURL
+	}
+	return b.wbBaseURL
+}
+
+// baseURLDisplay returns the info-view line for a custom WB API URL, or ""
+// when the default is used.
+func baseURLDisplay(cfg *storage.UserConfig) string {
+	switch cfg.WBBaseURL {
+	case "":
+		return ""
+	case wbSandboxURL:
+		return "*Адрес API:* 🧪 песочница WB\n"
+	}
+	return fmt.Sprintf("*Адрес API:* `%s`\n", cfg.WBBaseURL)
+}
+
+// parseBaseURL validates a user-supplied WB API URL. "default" (or "-")
+// clears the override and yields "". Only https URLs without query or
+// fragment are accepted, since the user's token is sent there.
+func parseBaseURL(lang, s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "default", "-":
+		return "", nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return "", errors.New(i18n.T(lang, i18n.MsgBaseURLUnparsable))
+	}
+	if u.Scheme != "https" {
+		return "", errors.New(i18n.T(lang, i18n.MsgBaseURLNotHTTPS))
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", errors.New(i18n.T(lang, i18n.MsgBaseURLExtras))
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// handleBaseURLCommand handles "/base_url [url|default]" for advanced users
+// who work with the WB sandbox or a regional endpoint.
+func (b *Bot) handleBaseURLCommand(chatID int64, args string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	if strings.TrimSpace(args) == "" {
+		msg := b.t(chatID, i18n.MsgBaseURLPrompt, b.baseURLFor(cfg))
+		b.SendMessage(chatID, msg)
+		return
+	}
+
+	if err := b.setBaseURL(chatID, args); err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	cfg.WBBaseURL, _ = parseBaseURL(b.lang(chatID), args)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBaseURLSaved, b.baseURLFor(cfg)), b.CreateMainMenuForUser(chatID))
+}
+
+// handleAdvancedButton shows the advanced settings: which WB API the
+// user's client talks to and through which proxy.
+func (b *Bot) handleAdvancedButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	var current i18n.Key
+	switch cfg.WBBaseURL {
+	case "":
+		current = i18n.MsgBaseURLStandard
+	case wbSandboxURL:
+		current = i18n.MsgBaseURLSandbox
+	default:
+		current = i18n.MsgBaseURLCustom
+	}
+	msg := b.t(chatID, i18n.MsgAdvancedSettings, b.t(chatID, current), b.baseURLFor(cfg), b.proxyStatus(cfg))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if cfg.WBBaseURL != wbSandboxURL {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnSandboxOn), CallbackSandboxOn)))
+	}
+	if cfg.WBBaseURL != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnSandboxOff), CallbackSandboxOff)))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCustomBaseURL), CallbackCustomBaseURL)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCustomProxy), CallbackCustomProxy)),
+	)
+	if cfg.WBProxy != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnProxyOff), CallbackProxyOff)))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu)),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleSandboxToggle switches the user's client between the WB sandbox and
+// the standard API.
+func (b *Bot) handleSandboxToggle(chatID int64, on bool) {
+	raw, msg := "default", b.t(chatID, i18n.MsgSandboxOff)
+	if on {
+		raw, msg = wbSandboxURL, b.t(chatID, i18n.MsgSandboxOn)
+	}
+	if err := b.setBaseURL(chatID, raw); err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handleCustomBaseURLButton(chatID int64) {
+	b.setUserState(chatID, StateWaitingBaseURL)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBaseURLAsk), b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleBaseURLInput(chatID int64, text string) {
+	if err := b.setBaseURL(chatID, text); err != nil {
+		b.SendMessageWithKeyboard(chatID, "❌ "+err.Error(), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	b.resetUserState(chatID)
+	baseURL, _ := parseBaseURL(b.lang(chatID), text)
+	if baseURL == "" {
+		baseURL = b.wbBaseURL
+	}
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBaseURLSaved, baseURL), b.CreateMainMenuForUser(chatID))
+}
+
+// handleAdminBaseURLCommand handles "/set_base_url <user_id> <url|default>".
+func (b *Bot) handleAdminBaseURLCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		b.SendMessage(chatID, "Использование: `/set_base_url <user_id> <url|default>`")
+		return
+	}
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || userID <= 0 {
+		b.SendMessage(chatID, "❌ Некорректный ID пользователя.")
+		return
+	}
+	if err := b.setBaseURL(userID, fields[1]); err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	b.log.Infow("admin changed user base url", "admin_id", chatID, "user_id", userID)
+	b.SendMessage(chatID, fmt.Sprintf("✅ Адрес API пользователя `%d` обновлён.", userID))
+}
+
+// setBaseURL validates and stores the override, then restarts a running
+// service so the new client is used. Returned errors are user-facing.
+func (b *Bot) setBaseURL(chatID int64, raw string) error {
+	baseURL, err := parseBaseURL(b.lang(chatID), raw)
+	if err != nil {
+		return err
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.SetWBBaseURL(dbCtx, chatID, baseURL); err != nil {
+		b.log.Errorw("failed to save base url", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		return errors.New(b.t(chatID, i18n.MsgSaveFailedInline))
+	}
+	b.log.Infow("wb base url changed", "chat_id", chatID, "base_url", baseURL)
+	b.reloadUserService(chatID, b.ctx)
+	return nil
+}
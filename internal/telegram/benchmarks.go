@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// handleBenchmarks shows the user's per-category stats next to anonymized
+// averages of other sellers. Participation is opt-in.
+func (b *Bot) handleBenchmarks(chatID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil || cfg == nil {
+		b.SendMessageWithKeyboard(chatID, "❌ *Информация не найдена*\n\nБот еще не настроен.", b.CreateMainMenu(chatID))
+		return
+	}
+
+	if !cfg.BenchmarkOptIn {
+		msg := `📊 *Сравнение с рынком*
+
+Сравните скорость ответов и средний рейтинг своих товаров со средними значениями других продавцов в тех же категориях.
+
+Данные обезличены: категории показываются, только если в них участвует не менее 3 продавцов. Статистика пересчитывается каждую ночь.
+
+Чтобы видеть сравнение, нужно участвовать — ваши данные тоже попадут в общую статистику.`
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Участвовать", CallbackBenchmarkOptIn),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+			),
+		)
+		b.SendMessageWithKeyboard(chatID, msg, keyboard)
+		return
+	}
+
+	own, err := b.configStore.GetUserCategoryStats(dbCtx, chatID, service.BenchmarkWindow)
+	if err != nil {
+		b.log.Warnw("failed to get user category stats", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_category_stats")
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при получении статистики*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+	market, err := b.configStore.GetCategoryBenchmarks(dbCtx)
+	if err != nil {
+		b.log.Warnw("failed to get category benchmarks", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_benchmarks")
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при получении статистики*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚫 Не участвовать", CallbackBenchmarkOptOut),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, formatBenchmarks(formatterFor(cfg), own, market), keyboard)
+}
+
+func (b *Bot) handleBenchmarkOptIn(chatID int64, optIn bool, ctx context.Context) {
+	if err := b.configStore.SetBenchmarkOptIn(ctx, chatID, optIn); err != nil {
+		b.log.Errorw("failed to save benchmark opt-in", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	if !optIn {
+		b.SendMessageWithKeyboard(chatID, "✅ Вы больше не участвуете в сравнении. Ваши данные не попадут в следующий расчёт.", b.CreateMainMenuForUser(chatID))
+		return
+	}
+	b.handleBenchmarks(chatID, ctx)
+}
+
+// formatBenchmarks renders own vs market stats for categories the user sells in.
+func formatBenchmarks(f locale.Formatter, own, market []storage.CategoryStats) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 *Сравнение с рынком* (за %s)\n", f.Days(service.BenchmarkWindow)))
+
+	if len(own) == 0 {
+		sb.WriteString("\nПока нет ваших ответов с указанной категорией товара. Сравнение появится после первых обработанных отзывов.")
+		return sb.String()
+	}
+
+	byName := make(map[string]storage.CategoryStats, len(market))
+	var computedAt time.Time
+	for _, m := range market {
+		byName[m.SubjectName] = m
+		computedAt = m.ComputedAt
+	}
+
+	for _, o := range own {
+		sb.WriteString(fmt.Sprintf("\n*%s*\n", escapeMarkdownV1(o.SubjectName)))
+		m, ok := byName[o.SubjectName]
+		sb.WriteString(fmt.Sprintf("⏱ Время ответа: %s", f.Duration(o.AvgResponse)))
+		if ok {
+			sb.WriteString(fmt.Sprintf(" (рынок: %s)", f.Duration(m.AvgResponse)))
+		}
+		sb.WriteString(fmt.Sprintf("\n⭐ Рейтинг: %.2f %s", o.AvgRating, trendArrow(o.AvgRating, o.PrevAvgRating)))
+		if ok {
+			sb.WriteString(fmt.Sprintf(" (рынок: %.2f %s, продавцов: %d)", m.AvgRating, trendArrow(m.AvgRating, m.PrevAvgRating), m.Users))
+		} else {
+			sb.WriteString("\n_Недостаточно данных по категории_")
+		}
+		sb.WriteString("\n")
+	}
+	if !computedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("\n_Данные рынка на %s_", f.DateTime(computedAt)))
+	}
+	return sb.String()
+}
+
+// trendArrow compares the current average with the previous window.
+func trendArrow(cur, prev float64) string {
+	switch {
+	case prev == 0:
+		return ""
+	case cur-prev > 0.05:
+		return "📈"
+	case prev-cur > 0.05:
+		return "📉"
+	default:
+		return "➡️"
+	}
+}
+
+// escapeMarkdownV1 escapes characters that break legacy Markdown parse mode.
+func escapeMarkdownV1(s string) string {
+	return strings.NewReplacer("*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[").Replace(s)
+}
@@ -0,0 +1,264 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// invoicePrefix starts the payload of subscription invoices, followed by
+	// "<days>:<price in kopecks>" so that a payment is checked against the
+	// terms it was offered on.
+	invoicePrefix = "sub:"
+	// maxGrantDays bounds "/admin grant" against typos.
+	maxGrantDays = 3650
+)
+
+// EnableBilling makes answering require paid access once the user's trial
+// under plan is over. Without paymentToken invoices are not offered and
+// access can only be granted with "/admin grant". Call before Run.
+func (b *Bot) EnableBilling(plan service.Plan, paymentToken string) {
+	b.plan = &plan
+	b.paymentToken = paymentToken
+}
+
+// billingOption stops the user's cycles when access runs out and tells the
+// user how to extend it; nil when the bot is free.
+func (b *Bot) billingOption(chatID int64) service.Option {
+	if b.plan == nil {
+		return nil
+	}
+	return service.WithBilling(*b.plan, func(service.Access) {
+		msg := b.t(chatID, i18n.MsgAccessEnded) + b.priceText(chatID)
+		b.SendMessageWithKeyboard(chatID, msg, b.payKeyboard(chatID))
+	})
+}
+
+// requireAccess reports whether the user may run answering and otherwise
+// offers to pay. Access is not restricted if it cannot be checked.
+func (b *Bot) requireAccess(chatID int64) bool {
+	if b.plan == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := b.userStore.GetSubscription(ctx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to read subscription", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_subscription")
+		return true
+	}
+	if b.plan.Access(sub, time.Now()).Active {
+		return true
+	}
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTrialEnded)+b.priceText(chatID), b.payKeyboard(chatID))
+	return false
+}
+
+// handleSubscriptionButton shows the user's trial or paid period.
+func (b *Bot) handleSubscriptionButton(chatID int64) {
+	if b.plan == nil {
+		b.showMainMenu(chatID)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := b.userStore.GetSubscription(ctx, chatID)
+	if err != nil {
+		b.log.Errorw("failed to read subscription", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_subscription")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSubscriptionFailed), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	f := formatterFor(cfg)
+
+	a := b.plan.Access(sub, time.Now())
+	var status string
+	switch {
+	case a.Paid:
+		status = i18n.T(f.Lang, i18n.MsgSubscriptionPaid, f.DateTime(a.Until))
+	case a.Active:
+		status = i18n.T(f.Lang, i18n.MsgSubscriptionTrial, f.DateTime(a.Until), f.Count(int64(a.AnswersLeft)))
+	default:
+		status = i18n.T(f.Lang, i18n.MsgSubscriptionExpired)
+	}
+	b.SendMessageWithKeyboard(chatID, i18n.T(f.Lang, i18n.MsgSubscriptionTitle)+status+"\n\n"+b.priceText(chatID), b.payKeyboard(chatID))
+}
+
+// priceText describes the price of access and how to get it.
+func (b *Bot) priceText(chatID int64) string {
+	text := b.t(chatID, i18n.MsgPrice, b.plan.Price/100, b.plan.PeriodDays)
+	if b.paymentToken == "" {
+		text += b.t(chatID, i18n.MsgPriceAskAdmin)
+	}
+	return text
+}
+
+// payKeyboard offers to pay when payments are set up.
+func (b *Bot) payKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if b.paymentToken != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnPay, b.plan.Price/100), CallbackPay),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// sendInvoice sends the user an invoice for one period of access.
+func (b *Bot) sendInvoice(chatID int64) {
+	if b.plan == nil || b.paymentToken == "" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPaymentUnavailable), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	invoice := tgbotapi.NewInvoice(chatID,
+		b.t(chatID, i18n.MsgInvoiceTitle),
+		b.t(chatID, i18n.MsgInvoiceDescription, b.plan.PeriodDays),
+		fmt.Sprintf("%s%d:%d", invoicePrefix, b.plan.PeriodDays, b.plan.Price),
+		b.paymentToken, "", "RUB",
+		[]tgbotapi.LabeledPrice{{Label: b.t(chatID, i18n.MsgInvoiceLabel, b.plan.PeriodDays), Amount: int(b.plan.Price)}})
+	invoice.SuggestedTipAmounts = []int{} // a nil slice is sent as null and rejected
+	if _, err := b.api.Send(invoice); err != nil {
+		b.log.Errorw("failed to send invoice", "chat_id", chatID, "err", err)
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgInvoiceFailed))
+	}
+}
+
+// parseInvoicePayload returns the days and price an invoice was issued for.
+func parseInvoicePayload(payload string) (days int, price int64, ok bool) {
+	rest, found := strings.CutPrefix(payload, invoicePrefix)
+	if !found {
+		return 0, 0, false
+	}
+	d, p, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, 0, false
+	}
+	days, err := strconv.Atoi(d)
+	if err != nil || days <= 0 {
+		return 0, 0, false
+	}
+	price, err = strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return days, price, true
+}
+
+// handlePreCheckout confirms a payment only if the invoice matches the
+// current plan, so a price change does not honour old invoices.
+func (b *Bot) handlePreCheckout(q *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: q.ID, OK: true}
+	days, price, ok := parseInvoicePayload(q.InvoicePayload)
+	if b.plan == nil || !ok || days != b.plan.PeriodDays || price != b.plan.Price ||
+		int64(q.TotalAmount) != price || q.Currency != "RUB" {
+		b.log.Warnw("rejecting outdated invoice", "user_id", q.From.ID, "payload", q.InvoicePayload, "amount", q.TotalAmount)
+		answer = tgbotapi.PreCheckoutConfig{
+			PreCheckoutQueryID: q.ID,
+			ErrorMessage:       b.t(q.From.ID, i18n.MsgInvoiceOutdated),
+		}
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		b.log.Errorw("failed to answer pre-checkout query", "user_id", q.From.ID, "err", err)
+	}
+}
+
+// handleSuccessfulPayment extends the user's access by the paid period.
+func (b *Bot) handleSuccessfulPayment(ctx context.Context, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	p := msg.SuccessfulPayment
+	days, _, ok := parseInvoicePayload(p.InvoicePayload)
+	if !ok {
+		b.log.Errorw("payment with unknown payload", "chat_id", chatID, "payload", p.InvoicePayload, "charge_id", p.TelegramPaymentChargeID)
+		b.notifyAdmins(fmt.Sprintf("⚠️ Платёж пользователя `%d` с неизвестным назначением, проверьте вручную. Charge ID: `%s`", chatID, p.TelegramPaymentChargeID))
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	until, recorded, err := b.userStore.RecordPayment(dbCtx, storage.Payment{
+		ChargeID:         p.TelegramPaymentChargeID,
+		ProviderChargeID: p.ProviderPaymentChargeID,
+		UserID:           chatID,
+		Amount:           int64(p.TotalAmount),
+		Currency:         p.Currency,
+		Days:             days,
+	})
+	if err != nil {
+		b.log.Errorw("failed to record payment", "chat_id", chatID, "charge_id", p.TelegramPaymentChargeID, "err", err)
+		metrics.IncrementDatabaseError("record_payment")
+		b.notifyAdmins(fmt.Sprintf("⚠️ Не удалось записать платёж пользователя `%d` на %d дней. Выдайте доступ командой /admin grant. Charge ID: `%s`", chatID, days, p.TelegramPaymentChargeID))
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPaymentNotRecorded), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if !recorded {
+		b.log.Infow("duplicate payment notification ignored", "chat_id", chatID, "charge_id", p.TelegramPaymentChargeID)
+		return
+	}
+
+	b.log.Infow("payment received", "chat_id", chatID, "amount", p.TotalAmount, "currency", p.Currency, "days", days)
+	b.audit(chatID, chatID, storage.AuditPayment,
+		fmt.Sprintf("charge=%s amount=%d %s days=%d", p.TelegramPaymentChargeID, p.TotalAmount, p.Currency, days))
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPaymentReceived, formatterFor(cfg).DateTime(until)),
+		b.CreateMainMenuForUser(chatID))
+}
+
+// handleAdminGrantCommand handles "/admin grant <user_id> <days>": extends
+// the user's access without payment, e.g. after a failed payment or as a
+// bonus.
+func (b *Bot) handleAdminGrantCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	fields := strings.Fields(args)
+	var userID int64
+	var days int
+	var err error
+	if len(fields) == 2 {
+		userID, err = strconv.ParseInt(fields[0], 10, 64)
+		if err == nil {
+			days, err = strconv.Atoi(fields[1])
+		}
+	}
+	if len(fields) != 2 || err != nil || days <= 0 || days > maxGrantDays {
+		b.SendMessage(chatID, fmt.Sprintf("Использование: `/admin grant <user_id> <дней>`, от 1 до %d.\n\nПродлевает доступ пользователя от конца оплаченного срока или от сегодняшнего дня.", maxGrantDays))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	until, err := b.userStore.ExtendSubscription(ctx, userID, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		b.log.Errorw("failed to grant access", "admin_id", chatID, "user_id", userID, "err", err)
+		metrics.IncrementDatabaseError("extend_subscription")
+		b.SendMessage(chatID, "❌ Не удалось продлить доступ. Подробности в логе.")
+		return
+	}
+	b.audit(userID, chatID, storage.AuditAccessGranted, fmt.Sprintf("days=%d", days))
+	b.log.Infow("access granted", "admin_id", chatID, "user_id", userID, "days", days)
+
+	msg := fmt.Sprintf("✅ Доступ пользователя `%d` продлён на %d дней, до %s.", userID, days, b.chatFormatter(chatID).DateTime(until))
+	if b.plan == nil {
+		msg += "\n\nОплата сейчас отключена (`BILLING`), срок будет учтён, если её включить."
+	}
+	b.SendMessage(chatID, msg)
+	if b.plan != nil {
+		cfg, _ := b.configStore.GetUserConfig(ctx, userID)
+		b.SendMessage(userID, b.t(userID, i18n.MsgAccessGranted, formatterFor(cfg).DateTime(until)))
+	}
+}
@@ -0,0 +1,195 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// blackoutLocation is the timezone admin-entered windows are interpreted in;
+// WB announces maintenance in Moscow time.
+func blackoutLocation() *time.Location {
+	loc, err := time.LoadLocation(service.DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// loadBlackouts reads maintenance windows from storage into b.blackouts.
+// Windows that no longer parse or are already over are skipped.
+func (b *Bot) loadBlackouts() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stored, err := b.configStore.ListBlackouts(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to load maintenance windows", "err", err)
+		metrics.IncrementDatabaseError("list_blackouts")
+		return
+	}
+
+	now := time.Now()
+	var windows []*service.Blackout
+	for _, s := range stored {
+		w, err := service.ParseBlackout(s.Spec, blackoutLocation())
+		if err != nil {
+			b.log.Warnw("invalid maintenance window, ignoring", "id", s.ID, "spec", s.Spec, "err", err)
+			continue
+		}
+		if !w.Expired(now) {
+			windows = append(windows, w)
+		}
+	}
+	b.blackouts.Set(windows)
+	b.log.Infow("maintenance windows loaded", "count", len(windows))
+}
+
+// handleBlackoutCommand handles the admin command
+//
+//	/blackout                   list windows
+//	/blackout add <window>      add a window
+//	/blackout del <id>          remove a window
+func (b *Bot) handleBlackoutCommand(chatID int64, args string, ctx context.Context) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	action, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+
+	switch strings.ToLower(action) {
+	case "", "list":
+		b.sendBlackoutList(chatID, ctx)
+	case "add":
+		w, err := service.ParseBlackout(rest, blackoutLocation())
+		if err != nil {
+			b.SendMessage(chatID, "❌ Не удалось разобрать окно: "+escapeMarkdownV1(err.Error())+"\n\nПримеры:\n`/blackout add 02:00-04:00` — ежедневно\n`/blackout add 2025-10-20 01:00 2025-10-20 05:00` — один раз")
+			return
+		}
+		prompt := fmt.Sprintf("🛠 *Добавить техническое окно?*\n\n%s\n\nВ это время циклы всех пользователей будут пропускаться.", w)
+		b.confirmAdminAction(chatID, prompt, "техническое окно "+w.String(), func() {
+			b.addBlackout(chatID, rest)
+		})
+	case "del":
+		id, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			b.SendMessage(chatID, "Использование: `/blackout del <id>`")
+			return
+		}
+		found, err := b.configStore.RemoveBlackout(ctx, id)
+		if err != nil {
+			b.log.Errorw("failed to remove maintenance window", "id", id, "err", err)
+			metrics.IncrementDatabaseError("save_blackout")
+			b.SendMessage(chatID, "❌ Ошибка при удалении. Попробуйте позже.")
+			return
+		}
+		if !found {
+			b.SendMessage(chatID, fmt.Sprintf("⚠️ Окно #%d не найдено.", id))
+			return
+		}
+		b.log.Infow("maintenance window removed", "admin_id", chatID, "id", id)
+		b.loadBlackouts()
+		b.sendBlackoutList(chatID, ctx)
+	default:
+		b.SendMessage(chatID, "Использование: `/blackout`, `/blackout add <окно>`, `/blackout del <id>`")
+	}
+}
+
+func (b *Bot) addBlackout(chatID int64, spec string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.AddBlackout(dbCtx, spec); err != nil {
+		b.log.Errorw("failed to add maintenance window", "err", err)
+		metrics.IncrementDatabaseError("save_blackout")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+		return
+	}
+	b.log.Infow("maintenance window added", "admin_id", chatID, "spec", spec)
+	b.loadBlackouts()
+	b.sendBlackoutList(chatID, dbCtx)
+}
+
+func (b *Bot) sendBlackoutList(chatID int64, ctx context.Context) {
+	stored, err := b.configStore.ListBlackouts(ctx)
+	if err != nil {
+		b.log.Errorw("failed to list maintenance windows", "err", err)
+		metrics.IncrementDatabaseError("list_blackouts")
+		b.SendMessage(chatID, "❌ Не удалось получить список окон.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🛠 *Технические окна*\n\nВ эти периоды циклы всех пользователей пропускаются, а ручной запуск откладывается до конца окна.\n")
+	if len(stored) == 0 {
+		sb.WriteString("\nОкон нет.")
+	}
+	now := time.Now()
+	for _, s := range stored {
+		w, err := service.ParseBlackout(s.Spec, blackoutLocation())
+		switch {
+		case err != nil:
+			fmt.Fprintf(&sb, "\n#%d — некорректно: %s", s.ID, escapeMarkdownV1(s.Spec))
+		case w.Expired(now):
+			fmt.Fprintf(&sb, "\n#%d — %s (завершено)", s.ID, w)
+		default:
+			fmt.Fprintf(&sb, "\n#%d — %s", s.ID, w)
+		}
+	}
+	sb.WriteString("\n\nДобавить: `/blackout add 02:00-04:00`\nУдалить: `/blackout del <id>`")
+	b.SendMessage(chatID, sb.String())
+}
+
+// blackoutGuard wraps a user's cycle so that it is skipped during maintenance
+// windows and in maintenance mode.
+func (b *Bot) blackoutGuard(chatID int64, cycle func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if b.InMaintenance() {
+			b.log.Debugw("cycle skipped: maintenance mode", "chat_id", chatID)
+			return
+		}
+		if active, until := b.blackouts.Active(time.Now()); active {
+			b.log.Infow("cycle skipped: maintenance window", "chat_id", chatID, "until", until.Format(time.RFC3339))
+			return
+		}
+		cycle(ctx)
+	}
+}
+
+// deferManualCycle postpones a manual run requested during maintenance until
+// the window ends. The service is looked up again then, since it may have been
+// stopped or reloaded meanwhile.
+func (b *Bot) deferManualCycle(chatID int64, cfg *storage.UserConfig, until time.Time) {
+	f := formatterFor(cfg)
+	msg := i18n.T(f.Lang, i18n.MsgBlackoutDeferred, f.ShortDateTime(until))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+	b.log.Infow("manual cycle deferred: maintenance window", "chat_id", chatID, "until", until.Format(time.RFC3339))
+
+	go func() {
+		// Windows may be extended or chained, so wait until none is active.
+		for active := true; active; active, until = b.blackouts.Active(time.Now()) {
+			timer := time.NewTimer(time.Until(until))
+			select {
+			case <-b.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		if b.InMaintenance() && !b.isAdmin(chatID) {
+			b.log.Infow("deferred manual cycle dropped: maintenance mode", "chat_id", chatID)
+			return
+		}
+		svc := b.getServiceForUser(chatID)
+		if svc == nil {
+			b.log.Infow("deferred manual cycle dropped: service stopped", "chat_id", chatID)
+			return
+		}
+		b.runManualCycle(chatID, svc)
+	}()
+}
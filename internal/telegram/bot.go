@@ -2,9 +2,12 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -12,6 +15,8 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
 	"feedback_bot/internal/scheduler"
 	"feedback_bot/internal/service"
 	"feedback_bot/internal/storage"
@@ -19,7 +24,8 @@ import (
 	"feedback_bot/pkg/metrics"
 )
 
-// UserState represents the current state of user in configuration flow
+// UserState represents the current state of user in configuration flow.
+// States are stored by value in conversations, so new ones go at the end.
 type UserState int
 
 const (
@@ -28,6 +34,36 @@ const (
 	StateWaitingTemplateGood
 	StateWaitingTemplateBad
 	StateReady
+	StateWaitingBusinessHours
+	StateWaitingOffHoursTemplate
+	StateWaitingQuestionTemplate
+	StateWaitingBroadcast
+	StateWaitingSimulation
+	StateWaitingPollComment
+	StateWaitingExclusion
+	StateWaitingDailyLimit
+	StateWaitingVariantGood
+	StateWaitingVariantBad
+	StateWaitingMediaTemplate
+	StateWaitingEditGood
+	StateWaitingEditBad
+	StateWaitingEditAnswerID
+	StateWaitingEditAnswerText
+	StateWaitingCustomReply
+	StateWaitingAnswerDelay
+	StateWaitingTemplateFile
+	StateWaitingBaseURL
+	StateWaitingSignature
+	StateWaitingEscalationChat
+	StateWaitingTimezone
+	StateWaitingDuplicateShare
+	StateWaitingDraftText
+	StateWaitingProxy
+	StateWaitingCategoryGood
+	StateWaitingCategoryBad
+	StateWizardToken
+	StateWizardGood
+	StateWizardBad
 )
 
 // Callback button data prefixes
@@ -36,73 +72,309 @@ const (
 	CallbackAddToken          = "add_token"
 	CallbackAddTemplateGood   = "add_template_good"
 	CallbackAddTemplateBad    = "add_template_bad"
+	CallbackEditTemplateGood  = "edit_template_good"
+	CallbackEditTemplateBad   = "edit_template_bad"
 	CallbackViewInfo          = "view_info"
 	CallbackDeleteAll         = "delete_all"
 	CallbackCancel            = "cancel"
 	CallbackConfirmDelete     = "confirm_delete"
 	CallbackRunNow            = "run_now"
 	CallbackCheckSubscription = "check_subscription"
+	CallbackBusinessHours     = "business_hours"
+	CallbackOffHoursTemplate  = "off_hours_template"
+	CallbackHoursOnlyOn       = "hours_only_on"
+	CallbackHoursOnlyOff      = "hours_only_off"
+	CallbackBenchmarks        = "benchmarks"
+	CallbackBenchmarkOptIn    = "benchmark_opt_in"
+	CallbackBenchmarkOptOut   = "benchmark_opt_out"
+	CallbackQuestionTemplate  = "question_template"
+	CallbackMediaTemplate     = "media_template"
+	CallbackLanguage          = "language"
+	CallbackFailures          = "failures"
+	CallbackProblems          = "problems"
+	CallbackProblemRetry      = "problem_retry"
+	CallbackBrowse            = "browse"
+	CallbackBrowsePrefix      = "browse:"
+	CallbackBrowseAnsPrefix   = "browse_ans:"
+	CallbackBrowseOwnPrefix   = "browse_own:"
+	CallbackComplainPrefix    = "browse_cmp:"  // followed by the feedback ID
+	CallbackComplainRsnPrefix = "browse_cmpr:" // followed by "<reason>:<feedback ID>"
+	CallbackArchiveConfirm    = "archive_confirm"
+	CallbackLanguagePrefix    = "lang:" // followed by the language code
+	CallbackTimezone          = "timezone"
+	CallbackTimezonePrefix    = "tz:" // followed by the IANA zone name
+	CallbackSimulate          = "simulate"
+	CallbackPreview           = "preview"
+	CallbackHistory           = "history"
+	CallbackPause             = "pause"
+	CallbackResume            = "resume"
+	CallbackPollPrefix        = "poll:" // followed by the satisfaction score
+	CallbackPollSkip          = "poll_skip"
+	CallbackExclusions        = "exclusions"
+	CallbackRestart           = "restart"
+	CallbackDailyLimit        = "daily_limit"
+	CallbackHumanize          = "humanize"
+	CallbackWhatsNew          = "whats_new"
+	CallbackSentiment         = "sentiment"
+	CallbackSentimentOn       = "sentiment_on"
+	CallbackSentimentOff      = "sentiment_off"
+	CallbackTracking          = "tracking"
+	CallbackTrackingOn        = "tracking_on"
+	CallbackTrackingOff       = "tracking_off"
+	CallbackEscalation        = "escalation"
+	CallbackDuplicates        = "duplicates"
+	CallbackHumanizeOn        = "humanize_on"
+	CallbackHumanizeOff       = "humanize_off"
+	CallbackVariants          = "variants"
+	CallbackExportHistory     = "export_history"
+	CallbackEditAnswer        = "edit_answer"
+	CallbackEditAnswerPrefix  = "edit_ans:" // followed by the feedback ID
+	CallbackVariantAddGood    = "variant_add_good"
+	CallbackVariantAddBad     = "variant_add_bad"
+	CallbackVariantDelPrefix  = "variant_del:" // followed by "<category>:<idx>"
+	CallbackAdminYesPrefix    = "admin_ok:"    // followed by the confirmation token
+	CallbackAdminNoPrefix     = "admin_no:"    // followed by the confirmation token
+	CallbackAdminUsersPrefix  = "adm_users:"   // followed by the page number
+	CallbackAdminUserPrefix   = "adm_user:"    // followed by the user ID, as are the ones below
+	CallbackAdminStopPrefix   = "adm_stop:"
+	CallbackAdminDelPrefix    = "adm_del:"
+	CallbackAdminBanPrefix    = "adm_ban:"
+	CallbackAdminUnbanPrefix  = "adm_unban:"
+	CallbackAdminRunPrefix    = "adm_run:"
+	CallbackSubscription      = "subscription"
+	CallbackPay               = "pay"
+	CallbackInvite            = "invite"
+	CallbackReplaceToken      = "replace_token"
+	CallbackAnswerDelay       = "answer_delay"
+	CallbackTemplateFile      = "template_file"
+	CallbackExportTemplates   = "export_templates"
+	CallbackAdvanced          = "advanced"
+	CallbackSandboxOn         = "sandbox_on"
+	CallbackSandboxOff        = "sandbox_off"
+	CallbackCustomBaseURL     = "custom_base_url"
+	CallbackCustomProxy       = "custom_proxy"
+	CallbackProxyOff          = "proxy_off"
+	CallbackSignatures        = "signatures"
+	CallbackSignatureAdd      = "signature_add"
+	CallbackDrafts            = "drafts"
+	CallbackDraftsOn          = "drafts_on"
+	CallbackDraftsOff         = "drafts_off"
+	CallbackDraftSendPrefix   = "draft_send:" // followed by the feedback ID, as are the ones below
+	CallbackDraftEditPrefix   = "draft_edit:"
+	CallbackDraftSkipPrefix   = "draft_skip:"
+	CallbackNegativeReport    = "negative_report"
+	CallbackNegativeReportOn  = "negative_report_on"
+	CallbackNegativeReportOff = "negative_report_off"
+	CallbackCategories        = "categories"
+	CallbackCategoryPrefix    = "cat:"     // followed by the WB subject ID
+	CallbackCategorySetPrefix = "cat_set:" // followed by "<good|bad>:<subject ID>"
+	CallbackCategoryDelPrefix = "cat_del:" // followed by the WB subject ID
+	CallbackWizardPrefix      = "wizard:"  // followed by the setup step to show
 )
 
 // Constants for DoS protection
 const (
-	// MaxRequestsPerMinute limits requests per user per minute
-	MaxRequestsPerMinute = 30
-	// MaxBurstSize allows burst of requests
-	MaxBurstSize = 10
-	// MaxTemplateLength limits template size in characters
-	MaxTemplateLength = 10000
+	// DefaultRequestsPerMinute limits requests per user per minute
+	DefaultRequestsPerMinute = 30
+	// DefaultBurstSize allows burst of requests
+	DefaultBurstSize = 10
+	// DefaultMaxConcurrentUpdates caps updates handled in parallel
+	DefaultMaxConcurrentUpdates = 100
+	// DefaultWBRPS and DefaultWBBurst limit WB API calls per user's client
+	DefaultWBRPS   = 3
+	DefaultWBBurst = 6
+	// DefaultCycleInterval is the time between a user's cycles
+	DefaultCycleInterval = 10 * time.Minute
+	// MaxTemplateLength limits template size in characters so that a signed
+	// answer stays within content.MaxLength
+	MaxTemplateLength = content.MaxLength - maxSignatureLength - len("\n\n")
 	// MinTokenLength minimum token length
 	MinTokenLength = 20
 	// MaxTokenLength maximum token length (JWT tokens can be 500-1000 chars)
 	MaxTokenLength = 2000
 )
 
+// Limits tunes DoS protection and the WB request rate per deployment.
+// Zero fields take the Default* values above.
+type Limits struct {
+	RequestsPerMinute    int // Telegram updates per user per minute
+	Burst                int // updates a user may send at once above the rate
+	MaxConcurrentUpdates int // updates handled in parallel
+	WBRPS                int // WB API requests per second for each user's client
+	WBBurst              int
+	WBGlobalRPS          int // WB API requests per second of all users together; 0 means no limit
+	WBGlobalBurst        int
+	WBMaxConns           int           // connections to a WB host shared by all clients
+	MaxConcurrentCycles  int           // user cycles run at once by the worker pool
+	CycleInterval        time.Duration // time between a user's cycles
+}
+
+// withDefaults fills zero fields with the Default* values.
+func (l Limits) withDefaults() Limits {
+	if l.RequestsPerMinute <= 0 {
+		l.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if l.Burst <= 0 {
+		l.Burst = DefaultBurstSize
+	}
+	if l.MaxConcurrentUpdates <= 0 {
+		l.MaxConcurrentUpdates = DefaultMaxConcurrentUpdates
+	}
+	if l.WBRPS <= 0 {
+		l.WBRPS = DefaultWBRPS
+	}
+	if l.WBBurst <= 0 {
+		l.WBBurst = DefaultWBBurst
+	}
+	if l.WBGlobalRPS > 0 && l.WBGlobalBurst <= 0 {
+		l.WBGlobalBurst = l.WBGlobalRPS
+	}
+	if l.WBMaxConns <= 0 {
+		l.WBMaxConns = wbapi.DefaultMaxConnsPerHost
+	}
+	if l.MaxConcurrentCycles <= 0 {
+		l.MaxConcurrentCycles = scheduler.DefaultPoolWorkers
+	}
+	if l.CycleInterval <= 0 {
+		l.CycleInterval = DefaultCycleInterval
+	}
+	return l
+}
+
 // Bot handles Telegram commands and configuration flow.
 type Bot struct {
-	api         *tgbotapi.BotAPI
+	api         TelegramAPI
+	username    string // the bot's Telegram username, for links
 	log         *zap.SugaredLogger
 	ctx         context.Context
 	configStore storage.ConfigStore
 	userStore   storage.Store
 
 	// User states for configuration flow
-	userStates map[int64]UserState
-	userConfig map[int64]*storage.UserConfig // Temporary storage during setup
-	mu         sync.RWMutex
+	userStates     map[int64]UserState
+	userConfig     map[int64]*storage.UserConfig // Temporary storage during setup
+	editAnswerIDs  map[int64]string              // review whose answer is being edited
+	browse         map[int64]*browseList         // unanswered reviews shown by the review browser
+	customReplyIDs map[int64]string              // review a custom reply is being written for
+	draftIDs       map[int64]string              // review whose draft is being edited
+	categoryIDs    map[int64]string              // WB subject whose reply is being typed
+	subjectNames   map[int64]string              // WB product category names by subject ID, as seen in reviews
+	mu             sync.RWMutex
 
 	// Service creation dependencies
-	wbBaseURL    string
-	pollInterval string
+	wbBaseURL   string
+	wbHTTP      *http.Client    // shared by all WB clients to pool connections
+	wbBudget    *wbapi.Budget   // WB request rate shared by all users' clients
+	wbTransport *http.Transport // under wbHTTP; proxies clone it and fall back to it
+	wbProxy     *wbapi.Proxy    // bot-wide proxy; nil without one. See EnableWBProxy
+
+	// Users' own proxies by URL, shared by users with the same one
+	userProxies map[string]*wbapi.Proxy
+	userProxyMu sync.Mutex
 
-	// Per-user services and schedulers for multi-user support
+	// Per-user services and their jobs in the shared cycle pool
 	services   map[int64]*service.Service
-	schedulers map[int64]*scheduler.Scheduler
+	schedulers map[int64]*scheduler.Job
 	svcMu      sync.RWMutex // mutex for services and schedulers maps
-
-	// DoS protection: rate limiting per user
+	cycles     *scheduler.Pool
+
+	// Cycles run under cycleCtx rather than the signal context so that
+	// Shutdown can let them finish; cancelCycles cuts them off after the
+	// grace period. Manual runs are tracked in manualCycles.
+	cycleCtx      context.Context
+	cancelCycles  context.CancelFunc
+	manualCycles  sync.WaitGroup
+	manualRunning atomic.Int32
+	draining      bool // set by Shutdown under svcMu; no new cycles start
+
+	// DoS protection: rate limiting per user. limits and the required
+	// channel below are guarded by settingsMu; see Reload
+	limits           Limits
 	userRateLimiters map[int64]*rate.Limiter
 	rateLimitMu      sync.RWMutex
+	settingsMu       sync.RWMutex
 
 	// DoS protection: semaphore for concurrent goroutines
 	goroutineSemaphore chan struct{}
 
 	// Channel subscription check
-	requiredChannel   string // Telegram channel username (e.g., "@channel" or "novikovpromarket")
-	requiredChannelID int64  // Telegram channel ID (numeric). If set, used directly for GetChatMember
-	adminUserID       int64  // Admin user ID for /admin command access
-
-	// Subscription cache: map[userID] = {isSubscribed: bool, expiresAt: time.Time}
-	subscriptionCache map[int64]struct {
-		isSubscribed bool
-		expiresAt    time.Time
-	}
+	requiredChannels  []Channel          // all must be joined; none disables the check
+	configExempt      map[int64]struct{} // SUBSCRIPTION_EXEMPT_IDS; replaced by Reload
+	runtimeExempt     map[int64]struct{} // added with "/admin exempt add", loaded from storage
+	configAdmins      map[int64]struct{} // admins from ADMIN_USER_IDS; cannot be removed at runtime
+	startupStagger    time.Duration      // window over which restored services start their first cycle
+	blockSharedTokens bool               // reject WB tokens already registered by another user
+	startedAt         time.Time
+
+	// Archive passes started with "/archive", one per user
+	archiveRuns map[int64]context.CancelFunc
+	archiveMu   sync.Mutex
+
+	// Admin broadcast: only one may run at a time
+	broadcastRunning atomic.Bool
+
+	// Global maintenance windows during which no cycles run
+	blackouts service.BlackoutSet
+	// Maintenance mode switched with "/admin maintenance"; nil when off.
+	// Only admins are served and no cycles run while it is set.
+	maintenance atomic.Pointer[storage.Maintenance]
+	// Detects WB outages from all users' cycles and pauses them meanwhile
+	wbOutage *service.OutageTracker
+
+	// Paid access; nil when the bot is free. See EnableBilling.
+	plan         *service.Plan
+	paymentToken string // Telegram Payments provider token; empty disables invoices
+
+	// Drafts replies for users' approval; nil when AI is not configured. See EnableDrafts.
+	drafter service.Drafter
+
+	// Retries messages Telegram refused temporarily; see Bot.send
+	outbox *outbox
+
+	// Notifications of running cycles, per user; see batchGuard
+	batches map[int64]*notifyBatch
+	batchMu sync.Mutex
+
+	// Users whose WB requests are logged; see "/admin wbdebug"
+	wbDebugUsers map[int64]struct{}
+	wbDebugMu    sync.Mutex
+
+	// Locks users' cycles across replicas; nil on a single instance. See
+	// EnableCycleLocks.
+	locker storage.Locker
+
+	// Recent metric snapshots for "/admin metrics"
+	metricsHistory *metrics.History
+
+	// Admins added at runtime with "/admin add", loaded from storage
+	runtimeAdmins map[int64]struct{}
+	adminsMu      sync.RWMutex
+
+	// Language of bot messages per user, cached from user_configs
+	langs  map[int64]string
+	langMu sync.RWMutex
+
+	// Users blocked by the admin; their messages are ignored
+	banned   map[int64]struct{}
+	bannedMu sync.RWMutex
+
+	// Handlers of inline buttons by callback data; see callbackRoutes
+	callbacks *callbackRouter
+
+	// Destructive admin actions awaiting confirmation, by token
+	pendingActions map[string]pendingAdminAction
+	pendingMu      sync.Mutex
+
+	// Subscription cache: membership per user and channel, and channels
+	// resolved through GetChat by Channel.key
+	subscriptionCache   map[subscriptionKey]subscriptionEntry
+	channelChats        map[string]tgbotapi.Chat
 	subscriptionCacheMu sync.RWMutex
 }
 
 // New creates a new Telegram bot instance.
 // Telegram token is now required.
-func New(token string, configStore storage.ConfigStore, userStore storage.Store, logger *zap.SugaredLogger, ctx context.Context, requiredChannel string, requiredChannelID int64, adminUserID int64) (*Bot, error) {
+func New(token string, configStore storage.ConfigStore, userStore storage.Store, logger *zap.SugaredLogger, ctx context.Context, sub Subscription, adminUserIDs []int64, startupStagger time.Duration, blockSharedTokens bool, limits Limits) (*Bot, error) {
 	if token == "" {
 		return nil, fmt.Errorf("telegram token is required")
 	}
@@ -111,60 +383,106 @@ func New(token string, configStore storage.ConfigStore, userStore storage.Store,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create telegram bot: %w", err)
 	}
+	return NewWithAPI(api, configStore, userStore, logger, ctx, sub, adminUserIDs, startupStagger, blockSharedTokens, limits)
+}
+
+// NewWithAPI creates a bot that talks to Telegram through api, with the
+// remaining parameters as in New. Use it to run the bot against
+// telegramtest.API.
+func NewWithAPI(api TelegramAPI, configStore storage.ConfigStore, userStore storage.Store, logger *zap.SugaredLogger, ctx context.Context, sub Subscription, adminUserIDs []int64, startupStagger time.Duration, blockSharedTokens bool, limits Limits) (*Bot, error) {
+	me, err := api.GetMe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot info: %w", err)
+	}
 
 	if logger == nil {
 		logger = zap.NewNop().Sugar()
 	}
 
-	// Normalize channel username (remove @ if present, add @ if missing)
-	channel := strings.TrimSpace(requiredChannel)
-	if channel != "" && !strings.HasPrefix(channel, "@") {
-		channel = "@" + channel
-	}
+	channels := normalizeChannels(sub.Channels)
+	limits = limits.withDefaults()
+	cycleCtx, cancelCycles := context.WithCancel(context.WithoutCancel(ctx))
+	wbTransport := wbapi.NewTransport(limits.WBMaxConns)
 
 	bot := &Bot{
 		api:                api,
+		username:           me.UserName,
 		log:                logger,
 		ctx:                ctx,
+		cycleCtx:           cycleCtx,
+		cancelCycles:       cancelCycles,
 		configStore:        configStore,
 		userStore:          userStore,
 		userStates:         make(map[int64]UserState),
 		userConfig:         make(map[int64]*storage.UserConfig),
+		editAnswerIDs:      make(map[int64]string),
+		browse:             make(map[int64]*browseList),
+		customReplyIDs:     make(map[int64]string),
+		draftIDs:           make(map[int64]string),
+		categoryIDs:        make(map[int64]string),
+		subjectNames:       make(map[int64]string),
+		archiveRuns:        make(map[int64]context.CancelFunc),
+		wbDebugUsers:       make(map[int64]struct{}),
 		wbBaseURL:          "https://feedbacks-api.wildberries.ru",
-		pollInterval:       "10m",
+		wbHTTP:             wbapi.NewHTTPClient(wbTransport),
+		wbTransport:        wbTransport,
+		userProxies:        make(map[string]*wbapi.Proxy),
+		wbBudget:           wbapi.NewBudget(limits.WBGlobalRPS, limits.WBGlobalBurst),
 		services:           make(map[int64]*service.Service),
-		schedulers:         make(map[int64]*scheduler.Scheduler),
+		schedulers:         make(map[int64]*scheduler.Job),
+		cycles:             scheduler.NewPool(limits.MaxConcurrentCycles, logger),
+		limits:             limits,
 		userRateLimiters:   make(map[int64]*rate.Limiter),
-		goroutineSemaphore: make(chan struct{}, 100), // максимум 100 одновременных горутин
-		requiredChannel:    channel,
-		requiredChannelID:  requiredChannelID,
-		adminUserID:        adminUserID,
-		subscriptionCache: make(map[int64]struct {
-			isSubscribed bool
-			expiresAt    time.Time
-		}),
+		goroutineSemaphore: make(chan struct{}, limits.MaxConcurrentUpdates),
+		requiredChannels:   channels,
+		configExempt:       idSet(sub.Exempt),
+		runtimeExempt:      make(map[int64]struct{}),
+		configAdmins:       make(map[int64]struct{}, len(adminUserIDs)),
+		runtimeAdmins:      make(map[int64]struct{}),
+		banned:             make(map[int64]struct{}),
+		langs:              make(map[int64]string),
+		batches:            make(map[int64]*notifyBatch),
+		startupStagger:     startupStagger,
+		blockSharedTokens:  blockSharedTokens,
+		startedAt:          time.Now(),
+		pendingActions:     make(map[string]pendingAdminAction),
+		metricsHistory:     metrics.NewHistory(time.Minute, time.Hour),
+		subscriptionCache:  make(map[subscriptionKey]subscriptionEntry),
+		channelChats:       make(map[string]tgbotapi.Chat),
 	}
 
 	// Log subscription check configuration
-	if requiredChannelID != 0 || channel != "" {
-		if requiredChannelID != 0 {
-			logger.Infow("✅ SUBSCRIPTION CHECK ENABLED",
-				"channel_id", requiredChannelID,
-				"channel_username", channel,
-				"important", "Bot must be administrator in the channel to check subscriptions")
-		} else {
-			logger.Infow("✅ SUBSCRIPTION CHECK ENABLED",
-				"channel_username", channel,
-				"tip", "Consider using REQUIRED_CHANNEL_ID for better performance",
-				"important", "Bot must be administrator in the channel to check subscriptions")
-		}
+	if len(channels) > 0 {
+		logger.Infow("✅ SUBSCRIPTION CHECK ENABLED",
+			"channels", channelKeys(channels),
+			"tip", "Channel IDs are checked faster than usernames",
+			"important", "Bot must be administrator in every channel to check subscriptions")
 	} else {
 		logger.Warnw("⚠️ SUBSCRIPTION CHECK DISABLED - no channel configured",
-			"tip", "Set REQUIRED_CHANNEL_ID or REQUIRED_CHANNEL to enable subscription check",
+			"tip", "Set REQUIRED_CHANNELS, REQUIRED_CHANNEL_ID or REQUIRED_CHANNEL to enable subscription check",
 			"warning", "All users will have access without subscription check")
 	}
 
-	bot.log.Infow("telegram bot authorized", "username", api.Self.UserName)
+	bot.wbOutage = service.NewOutageTracker(0, 0, bot.wbOutageChanged)
+	bot.callbacks = bot.callbackRoutes()
+	bot.loadBlackouts()
+	bot.loadMaintenance()
+	bot.loadBannedUsers()
+	bot.loadExemptions()
+	for _, id := range adminUserIDs {
+		bot.configAdmins[id] = struct{}{}
+	}
+	bot.loadAdmins()
+	go bot.metricsHistory.Run(ctx)
+	go bot.cycles.Run(cycleCtx)
+	bot.outbox = newOutbox(func(c tgbotapi.Chattable) error {
+		_, err := api.Send(c)
+		return err
+	}, logger)
+	// Not stopped with ctx so that messages sent during shutdown still go out
+	go bot.outbox.run(context.WithoutCancel(ctx))
+
+	bot.log.Infow("telegram bot authorized", "username", me.UserName)
 	return bot, nil
 }
 
@@ -175,8 +493,9 @@ func (b *Bot) getUserRateLimiter(userID int64) *rate.Limiter {
 
 	limiter, exists := b.userRateLimiters[userID]
 	if !exists {
-		// Allow MaxRequestsPerMinute requests per minute with burst of MaxBurstSize
-		limiter = rate.NewLimiter(rate.Limit(MaxRequestsPerMinute)/60, MaxBurstSize)
+		// Allow RequestsPerMinute requests per minute with a burst of Burst
+		limits := b.currentLimits()
+		limiter = rate.NewLimiter(rate.Limit(limits.RequestsPerMinute)/60, limits.Burst)
 		b.userRateLimiters[userID] = limiter
 	}
 	return limiter
@@ -207,6 +526,31 @@ func isValidTokenFormat(token string) bool {
 	return true
 }
 
+// validateWBToken checks the token against WB API with a single-item fetch.
+// Returns an empty string if the token works, otherwise a user-facing
+// explanation of what is wrong and how to fix it.
+func (b *Bot) validateWBToken(chatID int64, token, baseURL string, proxy *wbapi.Proxy) string {
+	ctx, cancel := context.WithTimeout(context.Background(), wbapi.DefaultHTTPTimeout)
+	defer cancel()
+
+	client := wbapi.New(token, wbapi.WithBaseURL(baseURL), wbapi.WithHTTPClient(b.wbHTTP), wbapi.WithProxy(proxy), wbapi.WithBudget(b.wbBudget, chatID), wbapi.WithLogger(b.log))
+	err := client.ValidateToken(ctx)
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case errors.Is(err, wbapi.ErrUnauthorized):
+		return "❌ *Токен не принят Wildberries*\n\nТокен недействителен или просрочен. Создайте новый токен в личном кабинете продавца (Настройки → Доступ к API) и отправьте его сюда."
+	case errors.Is(err, wbapi.ErrForbidden):
+		return "❌ *У токена нет доступа к отзывам*\n\nПри создании токена отметьте категорию «Отзывы и вопросы» и отправьте новый токен."
+	case errors.Is(err, wbapi.ErrRateLimited):
+		return "⚠️ *Wildberries ограничил частоту запросов*\n\nНе удалось проверить токен. Подождите минуту и отправьте токен ещё раз."
+	}
+	b.log.Warnw("token validation failed", "err", err)
+	return "⚠️ *Не удалось проверить токен*\n\nСервис Wildberries сейчас недоступен. Попробуйте отправить токен позже."
+}
+
 // Run starts the bot's update loop. It blocks until context is cancelled.
 func (b *Bot) Run(ctx context.Context) {
 	u := tgbotapi.NewUpdate(0)
@@ -229,7 +573,21 @@ func (b *Bot) Run(ctx context.Context) {
 			select {
 			case b.goroutineSemaphore <- struct{}{}:
 				// Got slot, process update
-				if update.CallbackQuery != nil {
+				if update.PreCheckoutQuery != nil {
+					go func() {
+						defer func() {
+							<-b.goroutineSemaphore
+							// Panic recovery
+							if r := recover(); r != nil {
+								b.log.Errorw("panic recovered in handlePreCheckout",
+									"query_id", update.PreCheckoutQuery.ID,
+									"panic", r,
+									"update_id", update.UpdateID)
+							}
+						}()
+						b.handlePreCheckout(update.PreCheckoutQuery)
+					}()
+				} else if update.CallbackQuery != nil {
 					go func() {
 						defer func() {
 							<-b.goroutineSemaphore
@@ -257,6 +615,9 @@ func (b *Bot) Run(ctx context.Context) {
 						}()
 						b.handleMessage(ctx, update.Message)
 					}()
+				} else {
+					// Nothing to handle, give the slot back
+					<-b.goroutineSemaphore
 				}
 			case <-ctx.Done():
 				return
@@ -279,7 +640,7 @@ func (b *Bot) SendMessage(chatID int64, text string) error {
 
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = tgbotapi.ModeMarkdown
-	_, err := b.api.Send(msg)
+	err := b.send(chatID, msg)
 	if err != nil {
 		b.log.Warnw("failed to send telegram message", "chat_id", chatID, "err", err)
 		metrics.IncrementAPIError("telegram", "send_message")
@@ -300,7 +661,7 @@ func (b *Bot) SendMessageWithKeyboard(chatID int64, text string, keyboard tgbota
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = tgbotapi.ModeMarkdown
 	msg.ReplyMarkup = keyboard
-	_, err := b.api.Send(msg)
+	err := b.send(chatID, msg)
 	if err != nil {
 		b.log.Warnw("failed to send telegram message with keyboard", "chat_id", chatID, "err", err)
 		return err
@@ -309,24 +670,25 @@ func (b *Bot) SendMessageWithKeyboard(chatID int64, text string, keyboard tgbota
 }
 
 // CreateMainMenu creates the main menu keyboard
-func (b *Bot) CreateMainMenu() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) CreateMainMenu(chatID int64) tgbotapi.InlineKeyboardMarkup {
 	// Simple menu without user-specific info
+	lang := b.lang(chatID)
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 Информация", CallbackViewInfo),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnInfo), CallbackViewInfo),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔑 Добавить токен WB", CallbackAddToken),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddToken), CallbackAddToken),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Добавить ответ (позитив)", CallbackAddTemplateGood),
-			tgbotapi.NewInlineKeyboardButtonData("❌ Добавить ответ (негатив)", CallbackAddTemplateBad),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddGood), CallbackAddTemplateGood),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddBad), CallbackAddTemplateBad),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить программу", CallbackRunNow),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnRun), CallbackRunNow),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🗑 СТЕРЕТЬ ВСЮ ИНФОРМАЦИЮ", CallbackDeleteAll),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDeleteAll), CallbackDeleteAll),
 		),
 	)
 }
@@ -338,25 +700,39 @@ func (b *Bot) CreateMainMenuForUser(chatID int64) tgbotapi.InlineKeyboardMarkup
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	lang := b.lang(chatID)
 
 	var keyboard [][]tgbotapi.InlineKeyboardButton
 
-	// Always show information button
-	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("📋 Информация", CallbackViewInfo),
-	})
+	// Always show information and language buttons, and the timezone once
+	// there is a token
+	row := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnInfo), CallbackViewInfo),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnLanguage), CallbackLanguage),
+	}
+	if cfg != nil && cfg.WBToken != "" && cfg.WBToken != "not_set" {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnTimezone), CallbackTimezone))
+	}
+	keyboard = append(keyboard, row)
+
+	// Announcements badge while there are unread changelog entries
+	if unread := b.unreadChangelog(ctx, cfg); unread > 0 {
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnWhatsNew, unread), CallbackWhatsNew),
+		})
+	}
 
 	// Token button
 	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔑 Добавить токен WB", CallbackAddToken),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddToken), CallbackAddToken),
 	})
 
 	// Template buttons (only if token is set)
 	hasToken := cfg != nil && cfg.WBToken != "" && cfg.WBToken != "not_set"
 	if hasToken {
 		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("✅ Добавить ответ (позитив)", CallbackAddTemplateGood),
-			tgbotapi.NewInlineKeyboardButtonData("❌ Добавить ответ (негатив)", CallbackAddTemplateBad),
+			b.templateButton(chatID, storage.VariantGood, cfg.TemplateGood),
+			b.templateButton(chatID, storage.VariantBad, cfg.TemplateBad),
 		})
 
 		// Run button (only if everything is configured)
@@ -365,15 +741,90 @@ func (b *Bot) CreateMainMenuForUser(chatID int64) tgbotapi.InlineKeyboardMarkup
 
 		if hasTemplates {
 			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-				tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить программу", CallbackRunNow),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnBusinessHours), CallbackBusinessHours),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnOffHours), CallbackOffHoursTemplate),
+			})
+			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnQuestions), CallbackQuestionTemplate),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnBenchmarks), CallbackBenchmarks),
+			})
+			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnPreview), CallbackPreview),
+			})
+			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnSimulate), CallbackSimulate),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnHistory), CallbackHistory),
+			})
+			row := []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnUnread), CallbackBrowse),
+			}
+			if b.plan != nil {
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnSubscription), CallbackSubscription))
+			}
+			keyboard = append(keyboard, row, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnInvite), CallbackInvite),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDuplicates), CallbackDuplicates),
+			})
+			row = []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnExclusions), CallbackExclusions),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDailyLimit), CallbackDailyLimit),
+			}
+			keyboard = append(keyboard, row, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAnswerDelay), CallbackAnswerDelay),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAdvanced), CallbackAdvanced),
 			})
+			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnHumanize), CallbackHumanize),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnSentiment), CallbackSentiment),
+			})
+			row = []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnVariants), CallbackVariants),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnMedia), CallbackMediaTemplate),
+			}
+			keyboard = append(keyboard, row, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnTemplateFile), CallbackTemplateFile),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnTracking), CallbackTracking),
+			})
+			row = []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnEscalation), CallbackEscalation),
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnFailures), CallbackFailures),
+			}
+			if b.getServiceForUser(chatID) != nil {
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnRestart), CallbackRestart))
+			}
+			keyboard = append(keyboard, row)
+			row = []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDailyReport), CallbackNegativeReport),
+			}
+			if b.drafter != nil {
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDrafts), CallbackDrafts))
+			}
+			keyboard = append(keyboard, row)
+			if stuck := b.stuckAnswers(ctx, chatID); stuck > 0 {
+				keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+					tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnProblems, stuck), CallbackProblems),
+				})
+			}
+			if cfg.Paused {
+				keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+					tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnResume), CallbackResume),
+				})
+			} else {
+				row := []tgbotapi.InlineKeyboardButton{
+					tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnRun), CallbackRunNow),
+				}
+				if b.getServiceForUser(chatID) != nil {
+					row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnPause), CallbackPause))
+				}
+				keyboard = append(keyboard, row)
+			}
 		}
 	}
 
 	// Always show delete button (if config exists)
 	if cfg != nil {
 		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("🗑 СТЕРЕТЬ ВСЮ ИНФОРМАЦИЮ", CallbackDeleteAll),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnDeleteAll), CallbackDeleteAll),
 		})
 	}
 
@@ -381,20 +832,22 @@ func (b *Bot) CreateMainMenuForUser(chatID int64) tgbotapi.InlineKeyboardMarkup
 }
 
 // CreateCancelKeyboard creates a cancel button
-func (b *Bot) CreateCancelKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) CreateCancelKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	lang := b.lang(chatID)
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить", CallbackCancel),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnCancel), CallbackCancel),
 		),
 	)
 }
 
 // CreateConfirmDeleteKeyboard creates confirmation buttons for delete
-func (b *Bot) CreateConfirmDeleteKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) CreateConfirmDeleteKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	lang := b.lang(chatID)
 	return tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Да, удалить", CallbackConfirmDelete),
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить", CallbackCancel),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnConfirmDelete), CallbackConfirmDelete),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnCancel), CallbackCancel),
 		),
 	)
 }
@@ -410,80 +863,41 @@ func (b *Bot) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQ
 	if !b.checkRateLimit(chatID) {
 		b.log.Warnw("rate limit exceeded", "chat_id", chatID, "callback", data)
 		metrics.IncrementRateLimitHit(chatID)
-		b.SendMessage(chatID, "⚠️ *Превышен лимит запросов*\n\nПожалуйста, подождите немного перед следующим запросом.")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgRateLimited))
 		return
 	}
 
 	b.log.Debugw("received callback query", "chat_id", chatID, "data", data)
 
-	switch data {
-	case CallbackMainMenu:
-		// Check subscription before showing main menu
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.showMainMenu(chatID)
-	case CallbackViewInfo:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleViewInfo(chatID, ctx)
-	case CallbackAddToken:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleAddTokenButton(chatID)
-	case CallbackAddTemplateGood:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleAddTemplateGoodButton(chatID)
-	case CallbackAddTemplateBad:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleAddTemplateBadButton(chatID)
-	case CallbackDeleteAll:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleDeleteAllButton(chatID)
-	case CallbackConfirmDelete:
-		b.log.Infow("CallbackConfirmDelete received", "chat_id", chatID)
-		if !b.checkChannelSubscription(chatID) {
-			b.log.Warnw("subscription check failed for delete", "chat_id", chatID)
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.log.Infow("subscription check passed, calling handleConfirmDelete", "chat_id", chatID)
-		b.handleConfirmDelete(chatID, ctx)
-	case CallbackCancel:
-		// Check subscription before canceling
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleCancel(chatID)
-	case CallbackRunNow:
-		if !b.checkChannelSubscription(chatID) {
-			b.sendChannelSubscriptionMessage(chatID)
-			return
-		}
-		b.handleRunNowButton(chatID, ctx)
-	case CallbackCheckSubscription:
-		b.handleCheckSubscription(chatID)
-	default:
-		b.SendMessage(chatID, "❓ Неизвестная команда")
+	if b.isBanned(chatID) {
+		b.log.Debugw("ignoring callback from banned user", "chat_id", chatID)
+		return
+	}
+	if b.rejectInMaintenance(chatID) {
+		return
+	}
+
+	route, args, ok := b.callbacks.match(data)
+	if !ok {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	if route.gated && !b.checkChannelSubscription(chatID) {
+		b.sendChannelSubscriptionMessage(chatID)
+		return
 	}
+	route.handle(ctx, callbackQuery{ChatID: chatID, MessageID: query.Message.MessageID, Data: data, Args: args})
 }
 
 func (b *Bot) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
+	if msg != nil && msg.SuccessfulPayment != nil {
+		b.handleSuccessfulPayment(ctx, msg)
+		return
+	}
+	if msg != nil && msg.Document != nil {
+		b.handleDocument(ctx, msg)
+		return
+	}
 	if msg == nil || msg.Text == "" {
 		return
 	}
@@ -495,18 +909,37 @@ func (b *Bot) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 	if !b.checkRateLimit(chatID) {
 		b.log.Warnw("rate limit exceeded", "chat_id", chatID, "command", command)
 		metrics.IncrementRateLimitHit(chatID)
-		b.SendMessage(chatID, "⚠️ *Превышен лимит запросов*\n\nПожалуйста, подождите немного перед следующим запросом.")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgRateLimited))
 		return
 	}
 
 	b.log.Debugw("received telegram message", "chat_id", chatID, "command", command)
 
+	if b.isBanned(chatID) {
+		b.log.Debugw("ignoring message from banned user", "chat_id", chatID)
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgBanned))
+		return
+	}
+	if b.rejectInMaintenance(chatID) {
+		return
+	}
+
 	// Handle commands
 	if strings.HasPrefix(command, "/") {
 		switch {
-		case command == "/start" || command == "/help":
+		case command == "/start":
+			b.handleStartCommand(chatID, ctx)
+			return
+		case strings.HasPrefix(command, "/start "):
+			b.handleStartParam(chatID, strings.TrimSpace(strings.TrimPrefix(command, "/start")))
+			b.handleStartCommand(chatID, ctx)
+			return
+		case command == "/help":
 			b.showMainMenu(chatID)
 			return
+		case command == "/settings":
+			b.handleSettingsCommand(chatID, ctx)
+			return
 		case command == "/status":
 			// Check subscription before allowing access
 			if !b.checkChannelSubscription(chatID) {
@@ -523,10 +956,135 @@ func (b *Bot) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 			}
 			b.handleRunNow(chatID, ctx)
 			return
+		case command == "/admin admins" || strings.HasPrefix(command, "/admin add") || strings.HasPrefix(command, "/admin del"):
+			b.handleAdminsCommand(chatID, strings.TrimPrefix(command, "/admin"))
+			return
+		case command == "/admin metrics":
+			b.handleAdminMetricsCommand(chatID)
+			return
+		case command == "/admin user" || strings.HasPrefix(command, "/admin user ") ||
+			command == "/admin_user" || strings.HasPrefix(command, "/admin_user "):
+			b.handleAdminUserCommand(chatID, strings.TrimPrefix(strings.TrimPrefix(command, "/admin_user"), "/admin user"), ctx)
+			return
+		case command == "/admin errors" || command == "/admin_errors":
+			b.handleAdminErrorsCommand(chatID)
+			return
+		case command == "/admin cleanup" || strings.HasPrefix(command, "/admin cleanup "):
+			b.handleAdminCleanupCommand(chatID, strings.TrimPrefix(command, "/admin cleanup"))
+			return
+		case command == "/admin grant" || strings.HasPrefix(command, "/admin grant "):
+			b.handleAdminGrantCommand(chatID, strings.TrimPrefix(command, "/admin grant"))
+			return
+		case command == "/admin referrals":
+			b.handleAdminReferralsCommand(chatID)
+			return
+		case command == "/admin audit" || strings.HasPrefix(command, "/admin audit "):
+			b.handleAdminAuditCommand(chatID, strings.TrimPrefix(command, "/admin audit"))
+			return
+		case command == "/admin wbdebug" || strings.HasPrefix(command, "/admin wbdebug "):
+			b.handleAdminWBDebugCommand(chatID, strings.TrimPrefix(command, "/admin wbdebug"))
+			return
+		case command == "/admin maintenance" || strings.HasPrefix(command, "/admin maintenance "):
+			b.handleAdminMaintenanceCommand(chatID, strings.TrimSpace(msg.Text)[len("/admin maintenance"):])
+			return
+		case command == "/admin exempt" || strings.HasPrefix(command, "/admin exempt "):
+			b.handleAdminExemptCommand(chatID, strings.TrimPrefix(command, "/admin exempt"))
+			return
 		case command == "/admin":
 			// Admin command - check if user is admin
 			b.handleAdminCommand(chatID, ctx)
 			return
+		case command == "/preview":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handlePreviewButton(chatID, ctx)
+			return
+		case command == "/categories":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleCategoriesButton(chatID, ctx)
+			return
+		case command == "/simulate":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleSimulateButton(chatID)
+			return
+		case command == "/feedback":
+			b.handleSatisfactionCommand(chatID, ctx)
+			return
+		case command == "/errors":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleFailures(chatID)
+			return
+		case command == "/problems":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleProblemReviews(chatID)
+			return
+		case command == "/archive" || strings.HasPrefix(command, "/archive "):
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleArchiveCommand(chatID, strings.TrimPrefix(command, "/archive"))
+			return
+		case command == "/language":
+			b.handleLanguageCommand(chatID)
+			return
+		case command == "/timezone" || strings.HasPrefix(command, "/timezone "):
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleTimezoneCommand(chatID, strings.TrimSpace(msg.Text)[len("/timezone"):], ctx)
+			return
+		case command == "/base_url" || strings.HasPrefix(command, "/base_url "):
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleBaseURLCommand(chatID, strings.TrimSpace(msg.Text)[len("/base_url"):])
+			return
+		case command == "/proxy" || strings.HasPrefix(command, "/proxy "):
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleProxyCommand(chatID, strings.TrimSpace(msg.Text)[len("/proxy"):])
+			return
+		case strings.HasPrefix(command, "/set_base_url"):
+			b.handleAdminBaseURLCommand(chatID, strings.TrimSpace(msg.Text)[len("/set_base_url"):])
+			return
+		case command == "/whatsnew":
+			if !b.checkChannelSubscription(chatID) {
+				b.sendChannelSubscriptionMessage(chatID)
+				return
+			}
+			b.handleWhatsNew(chatID)
+			return
+		case strings.HasPrefix(command, "/announce"):
+			b.handleAnnounceCommand(chatID, strings.TrimSpace(msg.Text)[len("/announce"):], ctx)
+			return
+		case command == "/restart" || strings.HasPrefix(command, "/restart "):
+			b.handleRestartCommand(chatID, strings.TrimPrefix(command, "/restart"))
+			return
+		case command == "/blackout" || strings.HasPrefix(command, "/blackout "):
+			b.handleBlackoutCommand(chatID, strings.TrimSpace(msg.Text)[len("/blackout"):], ctx)
+			return
+		case command == "/broadcast" || strings.HasPrefix(command, "/broadcast "):
+			b.handleBroadcastCommand(chatID, strings.TrimSpace(strings.TrimSpace(msg.Text)[len("/broadcast"):]))
+			return
 		}
 	}
 
@@ -548,8 +1106,66 @@ func (b *Bot) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 		b.handleTemplateGoodInput(chatID, msg.Text, ctx)
 	case StateWaitingTemplateBad:
 		b.handleTemplateBadInput(chatID, msg.Text, ctx)
+	case StateWizardToken:
+		b.handleWizardTokenInput(chatID, msg.Text, ctx)
+	case StateWizardGood:
+		b.handleWizardTemplateInput(chatID, storage.VariantGood, msg.Text, ctx)
+	case StateWizardBad:
+		b.handleWizardTemplateInput(chatID, storage.VariantBad, msg.Text, ctx)
+	case StateWaitingEditGood:
+		b.handleEditTemplateInput(chatID, storage.VariantGood, msg.Text, ctx)
+	case StateWaitingEditBad:
+		b.handleEditTemplateInput(chatID, storage.VariantBad, msg.Text, ctx)
+	case StateWaitingEditAnswerID:
+		b.handleEditAnswerIDInput(chatID, msg.Text)
+	case StateWaitingEditAnswerText:
+		b.handleEditAnswerTextInput(chatID, msg.Text, ctx)
+	case StateWaitingCustomReply:
+		b.handleCustomReplyInput(chatID, msg.Text, ctx)
 	case StateReady:
 		b.showMainMenu(chatID)
+	case StateWaitingBusinessHours:
+		b.handleBusinessHoursInput(chatID, msg.Text, ctx)
+	case StateWaitingOffHoursTemplate:
+		b.handleOffHoursTemplateInput(chatID, msg.Text, ctx)
+	case StateWaitingQuestionTemplate:
+		b.handleQuestionTemplateInput(chatID, msg.Text, ctx)
+	case StateWaitingMediaTemplate:
+		b.handleMediaTemplateInput(chatID, msg.Text, ctx)
+	case StateWaitingBroadcast:
+		b.handleBroadcastInput(chatID, msg.Text)
+	case StateWaitingSimulation:
+		b.handleSimulateInput(chatID, msg.Text)
+	case StateWaitingExclusion:
+		b.handleExclusionInput(chatID, msg.Text, ctx)
+	case StateWaitingDailyLimit:
+		b.handleDailyLimitInput(chatID, msg.Text, ctx)
+	case StateWaitingAnswerDelay:
+		b.handleAnswerDelayInput(chatID, msg.Text, ctx)
+	case StateWaitingBaseURL:
+		b.handleBaseURLInput(chatID, msg.Text)
+	case StateWaitingProxy:
+		b.handleProxyInput(chatID, msg.Text)
+	case StateWaitingVariantGood:
+		b.handleVariantInput(chatID, storage.VariantGood, msg.Text, ctx)
+	case StateWaitingVariantBad:
+		b.handleVariantInput(chatID, storage.VariantBad, msg.Text, ctx)
+	case StateWaitingCategoryGood:
+		b.handleCategoryInput(chatID, storage.VariantGood, msg.Text, ctx)
+	case StateWaitingCategoryBad:
+		b.handleCategoryInput(chatID, storage.VariantBad, msg.Text, ctx)
+	case StateWaitingSignature:
+		b.handleSignatureInput(chatID, msg.Text, ctx)
+	case StateWaitingEscalationChat:
+		b.handleEscalationInput(chatID, msg.Text, ctx)
+	case StateWaitingTimezone:
+		b.saveTimezone(chatID, msg.Text, ctx)
+	case StateWaitingDuplicateShare:
+		b.handleDuplicatesInput(chatID, msg.Text, ctx)
+	case StateWaitingDraftText:
+		b.handleDraftTextInput(chatID, msg.Text, ctx)
+	case StateWaitingPollComment:
+		b.handlePollCommentInput(chatID, msg.Text, ctx)
 	}
 }
 
@@ -569,242 +1185,26 @@ func (b *Bot) showMainMenu(chatID int64) {
 
 	if cfg == nil {
 		// No config yet
-		msg = `🤖 *Добро пожаловать!
-		
-Это БЕСПЛАТНЫЙ Автоответчик на отзывы Wildberries.*
-
-Для начала работы тебе следует выполнить ряд действий:
-
-1) Добавить токен Wildberries.
-
-2) Добавить шаблоны ответов.
-
-3) 🚀 Запустите программу.
-
-Важно все делать по инструкции
-ИНАЧЕ БОТ НЕ БУДЕТ РАБОТАТЬ. 
-
-Если возникли проблемы / вопросы:
-Пиши =>  @RyslanNovikov`
-
+		msg = b.t(chatID, i18n.MsgWelcome)
 	} else {
 		// Check configuration status
 		hasToken := cfg.WBToken != "" && cfg.WBToken != "not_set"
 		hasTemplates := cfg.TemplateGood != "" && cfg.TemplateGood != "Спасибо за ваш отзыв!" &&
 			cfg.TemplateBad != "" && cfg.TemplateBad != "Спасибо за ваш отзыв!"
 
-		msg = `🤖 *Автоответчик на отзывы Wildberries*
-
-Текущий статус настройки:`
-
+		msg = b.t(chatID, i18n.MsgStatusHeader)
 		if !hasToken {
-			msg += "\n\n⚠️ *Шаг 1:* Добавьте токен WB ⏳"
-			msg += "\n⚠️ *Шаг 2:* Добавьте шаблоны ответов ⏳"
+			msg += b.t(chatID, i18n.MsgStepsNoToken)
 		} else if !hasTemplates {
-			msg += "\n\n✅ *Шаг 1:* Токен добавлен ✅"
-			msg += "\n⚠️ *Шаг 2:* Добавьте шаблоны ответов ⏳"
+			msg += b.t(chatID, i18n.MsgStepsNoTpl)
 		} else {
-			msg += "\n\n✅ *Шаг 1:* Токен добавлен ✅"
-			msg += "\n✅ *Шаг 2:* Шаблоны добавлены ✅"
-			msg += "\n\n🎉 *Бот готов к работе!*"
+			msg += b.t(chatID, i18n.MsgStepsDone)
 		}
 	}
 
 	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
 }
 
-// checkChannelSubscription checks if user is subscribed to the required channel
-// Uses channel ID directly if available (faster and more reliable), otherwise uses username
-// Results are cached for 5 minutes to reduce API calls and log noise
-func (b *Bot) checkChannelSubscription(chatID int64) bool {
-	// Check cache first
-	b.subscriptionCacheMu.RLock()
-	cached, exists := b.subscriptionCache[chatID]
-	if exists && time.Now().Before(cached.expiresAt) {
-		b.subscriptionCacheMu.RUnlock()
-		b.log.Debugw("subscription check from cache",
-			"chat_id", chatID,
-			"is_subscribed", cached.isSubscribed,
-			"cache_expires_at", cached.expiresAt)
-		return cached.isSubscribed
-	}
-	b.subscriptionCacheMu.RUnlock()
-
-	b.log.Infow("performing fresh subscription check",
-		"chat_id", chatID,
-		"channel_id", b.requiredChannelID,
-		"channel_username", b.requiredChannel)
-
-	// If no channel requirement set, allow access silently (for backwards compatibility)
-	// Don't log warning on every check - only log once at startup
-	if b.requiredChannelID == 0 && b.requiredChannel == "" {
-		b.log.Debugw("subscription check skipped - no channel configured",
-			"chat_id", chatID,
-			"tip", "Set REQUIRED_CHANNEL_ID or REQUIRED_CHANNEL to enable subscription check")
-		return true // Allow access if no channel requirement
-	}
-
-	var channelChatID int64
-	var channelIdentifier string
-
-	// Use channel ID directly if available (preferred method, like in Python code)
-	if b.requiredChannelID != 0 {
-		channelChatID = b.requiredChannelID
-		channelIdentifier = fmt.Sprintf("ID:%d", b.requiredChannelID)
-		b.log.Infow("checking subscription using channel ID",
-			"chat_id", chatID,
-			"channel_id", channelChatID,
-			"channel_username", b.requiredChannel)
-	} else {
-		// Fallback to username method
-		channelUsername := strings.TrimPrefix(b.requiredChannel, "@")
-		channelIdentifier = b.requiredChannel
-
-		b.log.Infow("getting channel ID from username",
-			"chat_id", chatID,
-			"channel", b.requiredChannel,
-			"username", channelUsername)
-
-		// Get chat info by username to obtain chat ID
-		chatConfig := tgbotapi.ChatInfoConfig{
-			ChatConfig: tgbotapi.ChatConfig{
-				SuperGroupUsername: channelUsername,
-			},
-		}
-
-		chat, err := b.api.GetChat(chatConfig)
-		if err != nil {
-			b.log.Errorw("FAILED: Cannot get channel info - bot may not have access",
-				"channel", b.requiredChannel,
-				"username", channelUsername,
-				"chat_id", chatID,
-				"error", err.Error(),
-				"tip", "Try using REQUIRED_CHANNEL_ID instead, or ensure bot is admin in the channel")
-			return false
-		}
-
-		channelChatID = chat.ID
-		b.log.Infow("channel ID retrieved from username",
-			"chat_id", chatID,
-			"channel_id", channelChatID,
-			"channel_title", chat.Title)
-	}
-
-	// Check if user is member of the channel (like in Python: bot.get_chat_member(chan_id, user_id))
-	memberConfig := tgbotapi.GetChatMemberConfig{
-		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
-			ChatID: channelChatID,
-			UserID: chatID,
-		},
-	}
-
-	member, err := b.api.GetChatMember(memberConfig)
-	if err != nil {
-		b.log.Errorw("FAILED: Cannot check subscription - bot must be administrator in the channel!",
-			"chat_id", chatID,
-			"channel", channelIdentifier,
-			"channel_id", channelChatID,
-			"error", err.Error(),
-			"solution", "Bot must be added as administrator to the channel with permission to view members")
-		return false
-	}
-
-	// Check if user is member, administrator, or creator (like in Python code)
-	status := member.Status
-	isSubscribed := status == "member" || status == "administrator" || status == "creator"
-
-	// Log at info level for better diagnostics
-	b.log.Infow("subscription check result",
-		"chat_id", chatID,
-		"channel", channelIdentifier,
-		"channel_id", channelChatID,
-		"user_status", status,
-		"is_subscribed", isSubscribed)
-
-	// Cache result for 5 minutes
-	b.subscriptionCacheMu.Lock()
-	b.subscriptionCache[chatID] = struct {
-		isSubscribed bool
-		expiresAt    time.Time
-	}{
-		isSubscribed: isSubscribed,
-		expiresAt:    time.Now().Add(5 * time.Minute),
-	}
-	b.subscriptionCacheMu.Unlock()
-
-	if !isSubscribed {
-		b.log.Warnw("user is NOT subscribed to the channel",
-			"chat_id", chatID,
-			"channel", channelIdentifier,
-			"channel_id", channelChatID,
-			"user_status", status,
-			"allowed_statuses", "member, administrator, creator")
-	}
-
-	return isSubscribed
-}
-
-// sendChannelSubscriptionMessage sends a message asking user to subscribe
-func (b *Bot) sendChannelSubscriptionMessage(chatID int64) {
-	b.log.Infow("sending channel subscription message", "chat_id", chatID)
-
-	// Use username for URL (even if we use ID for checking)
-	var channelUsername string
-	var channelDisplay string
-	if b.requiredChannel != "" {
-		channelUsername = strings.TrimPrefix(b.requiredChannel, "@")
-		channelDisplay = "@" + channelUsername
-	} else if b.requiredChannelID != 0 {
-		// If only ID is set, try to construct URL
-		channelUsername = "novikovpromarket" // fallback - should be set via REQUIRED_CHANNEL
-		channelDisplay = fmt.Sprintf("канал (ID: %d)", b.requiredChannelID)
-		b.log.Warnw("channel username not set, using fallback",
-			"channel_id", b.requiredChannelID,
-			"tip", "Set REQUIRED_CHANNEL environment variable for better user experience")
-	} else {
-		// This shouldn't happen, but handle it gracefully
-		channelUsername = "novikovpromarket"
-		channelDisplay = "канал"
-		b.log.Errorw("neither channel ID nor username is set",
-			"chat_id", chatID,
-			"warning", "Subscription check should not be called without channel configuration")
-	}
-	channelURL := "https://t.me/" + channelUsername
-
-	b.log.Infow("subscription message details",
-		"chat_id", chatID,
-		"channel_username", channelUsername,
-		"channel_id", b.requiredChannelID,
-		"channel_url", channelURL)
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonURL("📢 Подписаться на канал", channelURL),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("✅ Я подписался, проверить", "check_subscription"),
-		),
-	)
-
-	msg := fmt.Sprintf(`🔒 *Доступ ограничен*
-
-Для использования бота необходимо подписаться на наш канал:
-
-📢 *%s*
-
-После подписки нажмите кнопку "✅ Я подписался, проверить" для проверки.`,
-		channelDisplay)
-
-	message := tgbotapi.NewMessage(chatID, msg)
-	message.ParseMode = tgbotapi.ModeMarkdown
-	message.ReplyMarkup = keyboard
-	if _, err := b.api.Send(message); err != nil {
-		b.log.Errorw("failed to send subscription message",
-			"chat_id", chatID,
-			"error", err.Error())
-	}
-}
-
 func (b *Bot) handleViewInfo(chatID int64, ctx context.Context) {
 	// Use context with timeout for DB query to avoid hanging
 	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -817,7 +1217,7 @@ func (b *Bot) handleViewInfo(chatID int64, ctx context.Context) {
 		msg := `❌ *Ошибка при получении информации*
 
 Попробуйте позже или обратитесь к администратору.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
 		return
 	}
 
@@ -825,7 +1225,7 @@ func (b *Bot) handleViewInfo(chatID int64, ctx context.Context) {
 		msg := `❌ *Информация не найдена*
 
 Бот еще не настроен. Используйте меню для добавления информации.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
 		return
 	}
 
@@ -837,6 +1237,10 @@ func (b *Bot) handleViewInfo(chatID int64, ctx context.Context) {
 	status := "✅ Активен"
 	if !isConfigured {
 		status = "⚠️ Не полностью настроен"
+	} else if cfg.Paused {
+		status = "⏸ Приостановлен"
+	} else if tokenExpired(cfg, time.Now()) {
+		status = "🔑 Срок действия токена истёк"
 	} else {
 		b.svcMu.RLock()
 		svc := b.services[chatID]
@@ -916,47 +1320,90 @@ func (b *Bot) handleViewInfo(chatID int64, ctx context.Context) {
 		"*Маркетплейс:* Wildberries\n"+
 		"*Статус:* %s\n"+
 		"*База данных:* SQLite\n\n"+
-		"*Токен Wildberries:*\n`%s`\n\n"+
+		"*Токен Wildberries:*\n`%s`\n"+
+		"_Срок действия: %s_\n\n"+
 		"*Шаблон для положительных отзывов (4-5 ⭐):*\n"+
 		"_%d символов_\n"+
 		"`%s`\n\n"+
 		"*Шаблон для отрицательных отзывов (1-3 ⭐):*\n"+
 		"_%d символов_\n"+
 		"`%s`\n\n"+
+		"*Рабочие часы:* %s\n"+
+		"*Часовой пояс:* %s\n"+
+		"*Ответы на вопросы:* %s\n"+
+		"*Благодарность за фото:* %s\n"+
+		"*Дневной лимит:* %s\n"+
+		"*Задержка ответа:* %s\n"+
+		"*Одинаковые отзывы:* %s\n"+
+		"*Черновики ИИ:* %s\n"+
+		"*Отчёт о негативных отзывах:* %s\n"+
+		"*Приглашено продавцов:* %s\n"+
+		"%s%s\n"+
 		"*Обновлено:* %s",
 		status,
 		tokenDisplay,
+		tokenExpiryDisplay(cfg, time.Now()),
 		len(cfg.TemplateGood),
 		templateGoodDisplay,
 		len(cfg.TemplateBad),
 		templateBadDisplay,
-		cfg.UpdatedAt.Format("02.01.2006 15:04"))
+		escapeMarkdown(businessHoursDisplay(cfg)),
+		escapeMarkdown(timezoneDisplay(b.lang(chatID), cfg)),
+		questionTemplateDisplay(cfg),
+		mediaTemplateDisplay(cfg),
+		dailyLimitDisplay(cfg),
+		answerDelayDisplay(cfg),
+		b.duplicatesDisplay(dbCtx, cfg),
+		b.aiDraftsDisplay(cfg),
+		negativeReportDisplay(cfg),
+		b.referralsDisplay(dbCtx, chatID),
+		baseURLDisplay(cfg),
+		proxyDisplay(cfg),
+		formatterFor(cfg).DateTime(cfg.UpdatedAt))
+
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
+}
 
-	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+// isAdmin reports whether the chat belongs to a bot administrator, either
+// configured with ADMIN_USER_IDS or added at runtime.
+func (b *Bot) isAdmin(chatID int64) bool {
+	if _, ok := b.configAdmins[chatID]; ok {
+		return true
+	}
+	b.adminsMu.RLock()
+	defer b.adminsMu.RUnlock()
+	_, ok := b.runtimeAdmins[chatID]
+	return ok
 }
 
-// handleAdminCommand handles /admin command - shows statistics
-func (b *Bot) handleAdminCommand(chatID int64, ctx context.Context) {
+// requireAdmin checks admin rights for an admin command and explains the
+// refusal to the user. Returns true if the command may proceed.
+func (b *Bot) requireAdmin(chatID int64) bool {
 	// Check if user is admin
-	if b.adminUserID == 0 {
+	if len(b.adminIDs()) == 0 {
 		b.log.Warnw("admin command called but admin not configured",
 			"chat_id", chatID,
-			"admin_user_id", b.adminUserID,
-			"tip", "Set ADMIN_USER_ID environment variable and restart bot")
-		b.SendMessage(chatID, "❌ *Команда недоступна*\n\nАдминистративная панель не настроена.\n\nУстановите переменную окружения `ADMIN_USER_ID` для включения и перезапустите бота.")
-		return
+			"tip", "Set ADMIN_USER_IDS environment variable and restart bot")
+		b.SendMessage(chatID, "❌ *Команда недоступна*\n\nАдминистративная панель не настроена.\n\nУстановите переменную окружения `ADMIN_USER_IDS` для включения и перезапустите бота.")
+		return false
 	}
 
 	b.log.Infow("admin command called",
 		"chat_id", chatID,
-		"admin_user_id", b.adminUserID,
-		"is_authorized", chatID == b.adminUserID)
+		"is_authorized", b.isAdmin(chatID))
 
-	if chatID != b.adminUserID {
+	if !b.isAdmin(chatID) {
 		b.log.Warnw("unauthorized admin access attempt",
-			"chat_id", chatID,
-			"admin_id", b.adminUserID)
+			"chat_id", chatID)
 		b.SendMessage(chatID, "❌ *Доступ запрещен*\n\nУ вас нет прав администратора.")
+		return false
+	}
+	return true
+}
+
+// handleAdminCommand handles /admin command - shows statistics
+func (b *Bot) handleAdminCommand(chatID int64, ctx context.Context) {
+	if !b.requireAdmin(chatID) {
 		return
 	}
 
@@ -979,16 +1426,50 @@ func (b *Bot) handleAdminCommand(chatID int64, ctx context.Context) {
 	b.svcMu.RUnlock()
 
 	// Format statistics message
+	f := b.chatFormatter(chatID)
 	msg := fmt.Sprintf(`🔐 *Административная панель*
 
 📊 *Статистика:*
 
-👥 Всего пользователей в боте: *%d*
-🚀 Активных пользователей: *%d*
+👥 Всего пользователей в боте: *%s*
+⚙️ Настроили токен и шаблоны: *%s*
+🚀 Активных пользователей: *%s*
+🔑 Токен отклонён WB: *%s*
+
+✅ Ответов на отзывы всего: *%s*
+🕐 За последние 24 часа: *%s*
+
+🚩 Жалоб подано: *%s*, принято: *%s*, отклонено: *%s*, на модерации: *%s*
+
+🗄 База данных: %s %s, *%s*
+
+*Активный пользователь* — это пользователь с настроенным и запущенным сервисом обработки отзывов. *Токен отклонён* — WB ответил 401 или 403 после последнего сохранения настроек.
+
+📈 /admin metrics — сводка метрик за последний час
+⚠️ /admin\_errors — пользователи, у которых падают циклы
+👤 /admin\_user ID — карточка пользователя: настройки, расписание, циклы и ошибки
+🔑 /admin admins — администраторы, /admin add ID и /admin del ID
+🎫 /admin exempt — без проверки подписки, /admin exempt add ID и del ID
+🗑 /admin cleanup ДНЕЙ — удалить историю ответов старше срока
+🧾 /admin audit ID — журнал действий по аккаунту пользователя
+🔎 /admin wbdebug ID on|off — писать в лог запросы пользователя к API WB
+🎁 /admin grant ID ДНЕЙ — продлить пользователю доступ без оплаты
+🤝 /admin referrals — пользователи, пригласившие больше всего продавцов
+📣 /broadcast — рассылка сообщения всем пользователям
+🔄 /restart ID — перезапуск сервиса пользователя
+✨ /announce — опубликовать запись в «Что нового»
+🛠 /blackout — технические окна WB, когда циклы не запускаются
+🚧 /admin maintenance on|off — режим обслуживания: бот доступен только администраторам
+💬 /feedback — ответы пользователей на опрос о боте`,
+		f.Count(stats.TotalUsers), f.Count(stats.ConfiguredUsers), f.Count(int64(activeUsersCount)), f.Count(stats.StaleTokens),
+		f.Count(stats.TotalAnswered), f.Count(stats.Answered24h),
+		f.Count(stats.Complaints.Filed()), f.Count(stats.Complaints.Accepted), f.Count(stats.Complaints.Rejected), f.Count(stats.Complaints.Pending),
+		stats.Engine, escapeMarkdownV1(stats.EngineVersion), f.Bytes(stats.DBSize))
 
-*Активный пользователь* — это пользователь с настроенным и запущенным сервисом обработки отзывов.`, stats.TotalUsers, activeUsersCount)
-
-	b.SendMessage(chatID, msg)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		b.appendCallbackButton(nil, "👥 Пользователи", CallbackAdminUsersPrefix, "0"),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
 }
 
 func (b *Bot) handleAddTokenButton(chatID int64) {
@@ -1003,24 +1484,14 @@ func (b *Bot) handleAddTokenButton(chatID int64) {
 		if len(tokenDisplay) > 20 {
 			tokenDisplay = tokenDisplay[:20] + "..."
 		}
-		msg := fmt.Sprintf(`✅ *Токен Wildberries уже настроен*
-
-Токен: %s
-
-Если хотите изменить токен, сначала удалите все данные и начните заново.`, tokenDisplay)
+		msg := b.t(chatID, i18n.MsgTokenAlreadySet, tokenDisplay)
 		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
 		return
 	}
 
 	// Show form for WB token input
 	b.setUserState(chatID, StateWaitingToken)
-	msg := `🔑 *Добавление токена Wildberries*
-
-Отправьте токен доступа к API Wildberries.
-
-Токен должен иметь право «Отзывы и вопросы» (бит 7).
-Получить токен можно в личном кабинете продавца Wildberries.`
-	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard())
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenPrompt), b.CreateCancelKeyboard(chatID))
 }
 
 func (b *Bot) handleAddTemplateGoodButton(chatID int64) {
@@ -1030,10 +1501,7 @@ func (b *Bot) handleAddTemplateGoodButton(chatID int64) {
 	defer cancel()
 	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
 	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
-		msg := `⚠️ *Сначала добавьте токен*
-
-Для добавления шаблонов сначала необходимо добавить токен Wildberries.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
 		return
 	}
 
@@ -1047,14 +1515,7 @@ func (b *Bot) handleAddTemplateGoodButton(chatID int64) {
 	}
 	b.setUserConfig(chatID, cfg)
 
-	msg := `✅ *Добавление ответа для положительных отзывов*
-
-Отправьте текст ответа для *положительных* отзывов (4-5 звезд).
-
-*Пример:*
-"Спасибо за ваш отзыв и доверие к нашему магазину! Нам очень важно, что вы делитесь своим опытом это помогает нам становиться лучше."`
-
-	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard())
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgGoodPrompt), b.CreateCancelKeyboard(chatID))
 }
 
 func (b *Bot) handleAddTemplateBadButton(chatID int64) {
@@ -1064,10 +1525,7 @@ func (b *Bot) handleAddTemplateBadButton(chatID int64) {
 	defer cancel()
 	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
 	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
-		msg := `⚠️ *Сначала добавьте токен*
-
-Для добавления шаблонов сначала необходимо добавить токен Wildberries.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
 		return
 	}
 
@@ -1081,28 +1539,11 @@ func (b *Bot) handleAddTemplateBadButton(chatID int64) {
 	}
 	b.setUserConfig(chatID, cfg)
 
-	msg := `❌ *Добавление ответа для отрицательных отзывов*
-
-Отправьте текст ответа для *отрицательных* отзывов (1-3 звезды).
-
-*Пример:*
-"Здравствуйте! Сожалеем, что товар не оправдал ожиданий. У вас есть инструкция, как связаться с нами. Напишите, поможем решить вашу проблему!"`
-
-	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard())
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBadPrompt), b.CreateCancelKeyboard(chatID))
 }
 
 func (b *Bot) handleDeleteAllButton(chatID int64) {
-	msg := `⚠️ *ВНИМАНИЕ!*
-
-Вы уверены, что хотите удалить ВСЮ информацию?
-
-Это действие нельзя отменить!
-Будут удалены:
-• Токен Wildberries
-• Шаблон для положительных отзывов
-• Шаблон для отрицательных отзывов`
-
-	b.SendMessageWithKeyboard(chatID, msg, b.CreateConfirmDeleteKeyboard())
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDeleteConfirm), b.CreateConfirmDeleteKeyboard(chatID))
 }
 
 func (b *Bot) handleConfirmDelete(chatID int64, ctx context.Context) {
@@ -1112,12 +1553,13 @@ func (b *Bot) handleConfirmDelete(chatID int64, ctx context.Context) {
 	if err != nil {
 		b.log.Errorw("failed to delete user config from DB", "chat_id", chatID, "err", err)
 		// Try to send error message
-		errMsg := tgbotapi.NewMessage(chatID, "Ошибка при удалении информации. Попробуйте позже.")
+		errMsg := tgbotapi.NewMessage(chatID, b.t(chatID, i18n.MsgDeleteFailed))
 		b.api.Send(errMsg)
 		return
 	}
 
 	b.log.Infow("config deleted from DB", "chat_id", chatID)
+	b.audit(chatID, chatID, storage.AuditConfigDeleted, "")
 
 	// Shutdown user's service and scheduler
 	b.log.Infow("calling shutdownUserService", "chat_id", chatID)
@@ -1129,11 +1571,15 @@ func (b *Bot) handleConfirmDelete(chatID int64, ctx context.Context) {
 
 	b.log.Infow("starting to send confirmation message", "chat_id", chatID)
 
+	// The stored language went with the config; reply in it, then forget it
+	msg := b.t(chatID, i18n.MsgDeleted)
+	minimal := b.t(chatID, i18n.MsgDeletedShort)
+	b.forgetLanguage(chatID)
+
 	// Try multiple times to send the message
-	msg := "Вся информация удалена. Все данные успешно удалены из базы данных. Сервис остановлен. Используйте меню для добавления новой информации."
 
 	// First try: with keyboard
-	if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu()); err != nil {
+	if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID)); err != nil {
 		b.log.Errorw("failed to send delete confirmation with keyboard", "chat_id", chatID, "err", err)
 
 		// Second try: simple message without keyboard
@@ -1142,7 +1588,7 @@ func (b *Bot) handleConfirmDelete(chatID int64, ctx context.Context) {
 			b.log.Errorw("failed to send simple delete confirmation", "chat_id", chatID, "err", err2)
 
 			// Third try: minimal message
-			minMsg := tgbotapi.NewMessage(chatID, "Информация удалена.")
+			minMsg := tgbotapi.NewMessage(chatID, minimal)
 			if _, err3 := b.api.Send(minMsg); err3 != nil {
 				b.log.Errorw("CRITICAL: failed to send any delete confirmation", "chat_id", chatID, "err", err3)
 			} else {
@@ -1158,33 +1604,96 @@ func (b *Bot) handleConfirmDelete(chatID int64, ctx context.Context) {
 
 func (b *Bot) handleCancel(chatID int64) {
 	b.resetUserState(chatID)
-	b.SendMessageWithKeyboard(chatID, "❌ Действие отменено.", b.CreateMainMenu())
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgCancelled), b.CreateMainMenu(chatID))
 }
 
 func (b *Bot) handleTokenInput(chatID int64, token string, ctx context.Context) {
 	token = strings.TrimSpace(token)
-	if token == "" {
-		b.SendMessageWithKeyboard(chatID, "❌ Токен не может быть пустым. Отправьте корректный токен.", b.CreateCancelKeyboard())
+	// State stays StateWaitingToken for retry
+	if problem := b.tokenProblem(chatID, token); problem != "" {
+		b.SendMessageWithKeyboard(chatID, problem, b.CreateCancelKeyboard(chatID))
 		return
 	}
 
+	cfg, err := b.storeToken(chatID, token, ctx)
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.setUserConfig(chatID, cfg)
+
+	// Initialize service if all fields are filled
+	allFieldsSet := cfg.WBToken != "" && cfg.WBToken != "not_set" &&
+		cfg.TemplateGood != "" && cfg.TemplateGood != "Спасибо за ваш отзыв!" &&
+		cfg.TemplateBad != "" && cfg.TemplateBad != "Спасибо за ваш отзыв!"
+
+	if allFieldsSet {
+		b.initializeServiceForUser(chatID, cfg, ctx)
+		msg := b.t(chatID, i18n.MsgTokenSavedReady)
+		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
+			b.log.Errorw("failed to send token saved message", "chat_id", chatID, "err", err)
+			simpleMsg := tgbotapi.NewMessage(chatID, msg)
+			b.api.Send(simpleMsg)
+		} else {
+			b.log.Infow("token saved", "chat_id", chatID)
+		}
+	} else {
+		msg := b.t(chatID, i18n.MsgTokenSavedNext)
+		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
+			b.log.Errorw("failed to send token saved message", "chat_id", chatID, "err", err)
+			simpleMsg := tgbotapi.NewMessage(chatID, msg)
+			b.api.Send(simpleMsg)
+		} else {
+			b.log.Infow("token saved", "chat_id", chatID)
+		}
+	}
+	b.resetUserState(chatID)
+}
+
+// tokenProblem checks a WB token sent by the user, against WB too, so that
+// problems surface now, not silently in the next cycle. It returns the
+// reason to show, or "" if the token can be saved.
+func (b *Bot) tokenProblem(chatID int64, token string) string {
+	if token == "" {
+		return b.t(chatID, i18n.MsgTokenEmpty)
+	}
+
 	// Validate token length
 	if len(token) < MinTokenLength {
-		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Токен слишком короткий. Минимальная длина: %d символов.", MinTokenLength), b.CreateCancelKeyboard())
-		return
+		return b.t(chatID, i18n.MsgTokenTooShort, MinTokenLength)
 	}
 
 	if len(token) > MaxTokenLength {
-		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Токен слишком длинный. Максимальная длина: %d символов.", MaxTokenLength), b.CreateCancelKeyboard())
-		return
+		return b.t(chatID, i18n.MsgTokenTooLong, MaxTokenLength)
 	}
 
 	// Validate token format (basic check - alphanumeric and some special chars)
 	if !isValidTokenFormat(token) {
-		b.SendMessageWithKeyboard(chatID, "⚠️ Токен содержит недопустимые символы. Проверьте правильность токена.", b.CreateCancelKeyboard())
-		return
+		return b.t(chatID, i18n.MsgTokenBadChars)
 	}
 
+	dbCtxURL, cancelURL := context.WithTimeout(context.Background(), 5*time.Second)
+	stored, _ := b.configStore.GetUserConfig(dbCtxURL, chatID)
+	cancelURL()
+	if exp := storage.TokenExpiresAt(token); !exp.IsZero() && !time.Now().Before(exp) {
+		return b.t(chatID, i18n.MsgTokenAlreadyExpired, formatterFor(stored).ShortDateTime(exp))
+	}
+	if problem := b.validateWBToken(chatID, token, b.baseURLFor(stored), b.proxyFor(stored)); problem != "" {
+		b.log.Infow("token rejected by validation", "chat_id", chatID)
+		return problem
+	}
+
+	if b.checkSharedToken(chatID, token) {
+		return b.t(chatID, i18n.MsgTokenShared)
+	}
+	return ""
+}
+
+// storeToken saves a token that passed tokenProblem, keeping the user's
+// templates or putting placeholders until they are added, and returns the
+// saved config.
+func (b *Bot) storeToken(chatID int64, token string, ctx context.Context) (*storage.UserConfig, error) {
 	cfg := b.getUserConfig(chatID)
 	if cfg == nil {
 		cfg = &storage.UserConfig{UserID: chatID}
@@ -1198,82 +1707,53 @@ func (b *Bot) handleTokenInput(chatID int64, token string, ctx context.Context)
 		cfg.TemplateGood = existing.TemplateGood
 		cfg.TemplateBad = existing.TemplateBad
 	}
-
 	cfg.WBToken = token
-	b.setUserConfig(chatID, cfg)
 
 	// Save to database immediately (with default templates if not set)
-	templateGood := cfg.TemplateGood
-	templateBad := cfg.TemplateBad
-	if templateGood == "" {
-		templateGood = "Спасибо за ваш отзыв!"
+	if cfg.TemplateGood == "" {
+		cfg.TemplateGood = "Спасибо за ваш отзыв!"
 	}
-	if templateBad == "" {
-		templateBad = "Спасибо за ваш отзыв!"
+	if cfg.TemplateBad == "" {
+		cfg.TemplateBad = "Спасибо за ваш отзыв!"
 	}
 
-	if err := b.configStore.SaveUserConfig(ctx, chatID, token, templateGood, templateBad); err != nil {
+	if err := b.configStore.SaveUserConfig(ctx, chatID, token, cfg.TemplateGood, cfg.TemplateBad); err != nil {
 		b.log.Errorw("failed to save user config", "chat_id", chatID, "err", err)
 		metrics.IncrementDatabaseError("save_config")
-		b.SendMessageWithKeyboard(chatID, "❌ Ошибка при сохранении. Попробуйте позже.", b.CreateMainMenu())
-		b.resetUserState(chatID)
-		return
+		return nil, err
 	}
+	b.audit(chatID, chatID, storage.AuditTokenSaved, "")
+	b.creditReferral(ctx, chatID)
 
-	// Update in-memory config
-	cfg.TemplateGood = templateGood
-	cfg.TemplateBad = templateBad
-	b.setUserConfig(chatID, cfg)
-
-	// Initialize service if all fields are filled
-	allFieldsSet := cfg.WBToken != "" && cfg.WBToken != "not_set" &&
-		cfg.TemplateGood != "" && cfg.TemplateGood != "Спасибо за ваш отзыв!" &&
-		cfg.TemplateBad != "" && cfg.TemplateBad != "Спасибо за ваш отзыв!"
-
-	if allFieldsSet {
-		b.initializeServiceForUser(chatID, cfg, ctx)
-		msg := "✅ Токен сохранен!\n\nБот готов к работе. Все необходимые данные настроены."
-		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
-			b.log.Errorw("failed to send token saved message", "chat_id", chatID, "err", err)
-			simpleMsg := tgbotapi.NewMessage(chatID, msg)
-			b.api.Send(simpleMsg)
-		} else {
-			b.log.Infow("token saved", "chat_id", chatID)
-		}
-	} else {
-		msg := "✅ Токен сохранен!\n\nТеперь добавьте шаблоны ответов через меню:\n• ✅ Добавить ответ (позитив)\n• ❌ Добавить ответ (негатив)"
-		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
-			b.log.Errorw("failed to send token saved message", "chat_id", chatID, "err", err)
-			simpleMsg := tgbotapi.NewMessage(chatID, msg)
-			b.api.Send(simpleMsg)
-		} else {
-			b.log.Infow("token saved", "chat_id", chatID)
-		}
-	}
-	b.resetUserState(chatID)
+	b.persistLanguage(ctx, chatID)
+	return cfg, nil
 }
 
 func (b *Bot) handleTemplateGoodInput(chatID int64, text string, ctx context.Context) {
 	text = strings.TrimSpace(text)
 	if text == "" {
-		b.SendMessageWithKeyboard(chatID, "❌ Текст ответа не может быть пустым.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateEmpty), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	// Validate template length
 	if len([]rune(text)) < 10 {
-		b.SendMessageWithKeyboard(chatID, "⚠️ Текст слишком короткий. Рекомендуется минимум 20-30 символов.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	if len([]rune(text)) > MaxTemplateLength {
-		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Текст слишком длинный. Максимальная длина: %d символов.", MaxTemplateLength), b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	// Validate UTF-8 encoding
 	if !utf8.ValidString(text) {
-		b.SendMessageWithKeyboard(chatID, "❌ Текст содержит некорректные символы. Используйте только допустимые символы.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if b.rejectContent(chatID, text) {
 		return
 	}
 
@@ -1307,10 +1787,11 @@ func (b *Bot) handleTemplateGoodInput(chatID int64, text string, ctx context.Con
 
 	if err := b.configStore.SaveUserConfig(ctx, chatID, wbToken, cfg.TemplateGood, templateBad); err != nil {
 		b.log.Errorw("failed to save user config", "chat_id", chatID, "err", err)
-		b.SendMessageWithKeyboard(chatID, "❌ Ошибка при сохранении. Попробуйте позже.", b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
 		b.resetUserState(chatID)
 		return
 	}
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, storage.VariantGood)
 
 	// Update in-memory config
 	cfg.WBToken = wbToken
@@ -1324,12 +1805,12 @@ func (b *Bot) handleTemplateGoodInput(chatID int64, text string, ctx context.Con
 
 	if allFieldsSet {
 		b.initializeServiceForUser(chatID, cfg, ctx)
-		msg := "✅ Шаблон для положительных отзывов сохранен!\n\nБот готов к работе. Все необходимые данные настроены."
+		msg := b.t(chatID, i18n.MsgGoodSavedReady)
 
 		// Create inline keyboard with "Run Now" button
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить программу", CallbackRunNow),
+				tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnRun), CallbackRunNow),
 			),
 		)
 
@@ -1345,7 +1826,7 @@ func (b *Bot) handleTemplateGoodInput(chatID int64, text string, ctx context.Con
 			b.log.Infow("template good saved with run button", "chat_id", chatID)
 		}
 	} else {
-		msg := "✅ Шаблон для положительных отзывов сохранен!\n\nТеперь добавьте шаблон для отрицательных отзывов через меню."
+		msg := b.t(chatID, i18n.MsgGoodSavedNext)
 		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
 			b.log.Errorw("failed to send template saved message", "chat_id", chatID, "err", err)
 			simpleMsg := tgbotapi.NewMessage(chatID, msg)
@@ -1363,27 +1844,31 @@ func (b *Bot) handleTemplateBadInput(chatID int64, text string, ctx context.Cont
 	text = strings.TrimSpace(text)
 	if text == "" {
 		b.log.Warnw("empty template text", "chat_id", chatID)
-		b.SendMessageWithKeyboard(chatID, "❌ Текст ответа не может быть пустым.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateEmpty), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	// Validate template length
 	if len([]rune(text)) < 10 {
 		b.log.Warnw("template too short", "chat_id", chatID, "length", len([]rune(text)))
-		b.SendMessageWithKeyboard(chatID, "⚠️ Текст слишком короткий. Рекомендуется минимум 20-30 символов.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	if len([]rune(text)) > MaxTemplateLength {
 		b.log.Warnw("template too long", "chat_id", chatID, "length", len([]rune(text)))
-		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Текст слишком длинный. Максимальная длина: %d символов.", MaxTemplateLength), b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength), b.CreateCancelKeyboard(chatID))
 		return
 	}
 
 	// Validate UTF-8 encoding
 	if !utf8.ValidString(text) {
 		b.log.Warnw("invalid UTF-8 in template", "chat_id", chatID)
-		b.SendMessageWithKeyboard(chatID, "❌ Текст содержит некорректные символы. Используйте только допустимые символы.", b.CreateCancelKeyboard())
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if b.rejectContent(chatID, text) {
 		return
 	}
 
@@ -1421,11 +1906,12 @@ func (b *Bot) handleTemplateBadInput(chatID int64, text string, ctx context.Cont
 
 	if err := b.configStore.SaveUserConfig(ctx, chatID, wbToken, templateGood, cfg.TemplateBad); err != nil {
 		b.log.Errorw("failed to save user config to DB", "chat_id", chatID, "err", err)
-		errMsg := tgbotapi.NewMessage(chatID, "Ошибка при сохранении. Попробуйте позже.")
+		errMsg := tgbotapi.NewMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
 		b.api.Send(errMsg)
 		b.resetUserState(chatID)
 		return
 	}
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, storage.VariantBad)
 
 	b.log.Infow("template bad saved to DB successfully", "chat_id", chatID)
 
@@ -1446,14 +1932,14 @@ func (b *Bot) handleTemplateBadInput(chatID int64, text string, ctx context.Cont
 		b.initializeServiceForUser(chatID, cfg, ctx)
 		b.log.Infow("service initialization completed, preparing message", "chat_id", chatID)
 
-		msg := `✅ Шаблон для отрицательных отзывов сохранен!`
+		msg := b.t(chatID, i18n.MsgBadSavedReady)
 
 		b.log.Infow("sending completion message", "chat_id", chatID)
 
 		// Create inline keyboard with "Run Now" button
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить программу", CallbackRunNow),
+				tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnRun), CallbackRunNow),
 			),
 		)
 
@@ -1475,7 +1961,7 @@ func (b *Bot) handleTemplateBadInput(chatID int64, text string, ctx context.Cont
 		}
 	} else {
 		b.log.Infow("not all fields set yet", "chat_id", chatID)
-		msg := "Шаблон для отрицательных отзывов сохранен! Продолжите настройку через меню."
+		msg := b.t(chatID, i18n.MsgBadSavedNext)
 
 		simpleMsg := tgbotapi.NewMessage(chatID, msg)
 		if _, err := b.api.Send(simpleMsg); err != nil {
@@ -1490,6 +1976,65 @@ func (b *Bot) handleTemplateBadInput(chatID int64, text string, ctx context.Cont
 }
 
 func (b *Bot) initializeServiceForUser(chatID int64, cfg *storage.UserConfig, ctx context.Context) {
+	b.startUserService(chatID, cfg, 0)
+}
+
+// RestoreServices starts services for all fully configured, non-paused users
+// so that answering continues after a restart without user interaction.
+// First cycles are spread over the startup stagger window.
+func (b *Bot) RestoreServices(ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	active, err := b.restorableConfigs(dbCtx)
+	if err != nil {
+		b.log.Errorw("restore services: failed to list configs", "err", err)
+		metrics.IncrementDatabaseError("list_configs")
+		return
+	}
+
+	for i, cfg := range active {
+		if ctx.Err() != nil {
+			return
+		}
+		b.startUserService(cfg.UserID, cfg, b.staggerDelay(i, len(active)))
+	}
+	b.log.Infow("services restored", "count", len(active), "stagger", b.startupStagger)
+}
+
+// restorableConfigs returns the configs RestoreServices starts services for.
+func (b *Bot) restorableConfigs(ctx context.Context) ([]*storage.UserConfig, error) {
+	configs, err := b.configStore.ListActiveConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates still holding the placeholder text are not configured yet
+	var active []*storage.UserConfig
+	for _, cfg := range configs {
+		if cfg.TemplateGood == "Спасибо за ваш отзыв!" || cfg.TemplateBad == "Спасибо за ваш отзыв!" {
+			continue
+		}
+		if b.isBanned(cfg.UserID) {
+			continue
+		}
+		active = append(active, cfg)
+	}
+	return active, nil
+}
+
+// staggerDelay spreads n services evenly over the startup stagger window and
+// returns the first-cycle delay for the i-th one (0 ≤ i < n).
+func (b *Bot) staggerDelay(i, n int) time.Duration {
+	if n <= 1 || b.startupStagger <= 0 {
+		return 0
+	}
+	return b.startupStagger * time.Duration(i) / time.Duration(n)
+}
+
+// startUserService creates the user's service and scheduler. The first cycle
+// runs after firstRunDelay; 0 means immediately.
+func (b *Bot) startUserService(chatID int64, cfg *storage.UserConfig, firstRunDelay time.Duration) {
 	b.log.Infow("initializeServiceForUser: starting", "chat_id", chatID)
 
 	b.log.Infow("initializeServiceForUser: acquiring lock", "chat_id", chatID)
@@ -1500,6 +2045,21 @@ func (b *Bot) initializeServiceForUser(chatID int64, cfg *storage.UserConfig, ct
 	}()
 	b.log.Infow("initializeServiceForUser: lock acquired", "chat_id", chatID)
 
+	// Paused users keep their config but must not be answered automatically
+	if cfg.Paused {
+		b.log.Infow("user is paused, not starting service", "chat_id", chatID)
+		return
+	}
+	// WB rejects an expired token; CheckTokenExpiry asks the user for a new one
+	if tokenExpired(cfg, time.Now()) {
+		b.log.Infow("WB token expired, not starting service", "chat_id", chatID, "expired_at", cfg.TokenExpiresAt)
+		return
+	}
+	if b.draining {
+		b.log.Infow("shutting down, not starting service", "chat_id", chatID)
+		return
+	}
+
 	// Check if service already exists for this user
 	if _, exists := b.services[chatID]; exists {
 		b.log.Infow("service already exists for user", "chat_id", chatID)
@@ -1507,16 +2067,17 @@ func (b *Bot) initializeServiceForUser(chatID int64, cfg *storage.UserConfig, ct
 	}
 
 	// Create Wildberries API client for this user
-	wbClient := wbapi.New(
-		cfg.WBToken,
-		wbapi.WithBaseURL(b.wbBaseURL),
-		wbapi.WithRateLimit(3, 6),
-		wbapi.WithLogger(b.log),
-	)
+	wbClient := b.wbClientFor(cfg)
 	b.log.Infow("wb client initialized for user", "chat_id", chatID)
 
-	// Create service with user's templates and userID
+	// Create service with user's templates and userID.
+	// After a WB 429 the service asks its pool job (created below) to retry
+	// once the cooldown is over instead of waiting for the next tick.
 	const maxTake = 5000
+	var poller *scheduler.Job
+	opts := append(b.serviceOptions(chatID, cfg), service.WithReschedule(func(after time.Duration) {
+		poller.RunSoon(after + time.Second)
+	}))
 	svc := service.New(
 		chatID,
 		wbClient,
@@ -1525,20 +2086,19 @@ func (b *Bot) initializeServiceForUser(chatID int64, cfg *storage.UserConfig, ct
 		cfg.TemplateGood,
 		b.log,
 		maxTake,
+		opts...,
 	)
 
 	b.services[chatID] = svc
 	b.log.Infow("service initialized for user", "chat_id", chatID)
 
-	// Start scheduler for this user
-	// Use b.ctx (bot's main context) instead of request ctx to keep scheduler running
-	b.log.Infow("creating scheduler", "chat_id", chatID)
-	poller := scheduler.New(10*time.Minute, svc.HandleCycle, b.log)
+	// Register the user's cycle in the shared pool. Its runs get b.cycleCtx
+	// rather than the request ctx; it outlives the signal context so
+	// Shutdown can drain a running cycle
+	interval := b.currentLimits().CycleInterval
+	poller = b.cycles.Add(chatID, interval, firstRunDelay, b.blackoutGuard(chatID, b.lockGuard(chatID, b.batchGuard(chatID, svc.HandleCycle))))
 	b.schedulers[chatID] = poller
-
-	b.log.Infow("starting scheduler goroutine", "chat_id", chatID)
-	go poller.Run(b.ctx)
-	b.log.Infow("scheduler started for user", "chat_id", chatID, "interval", "10m")
+	b.log.Infow("cycle scheduled for user", "chat_id", chatID, "interval", interval, "first_run_delay", firstRunDelay)
 
 	// Update metrics
 	b.log.Infow("updating metrics", "chat_id", chatID)
@@ -1552,6 +2112,24 @@ func (b *Bot) getServiceForUser(chatID int64) *service.Service {
 	return b.services[chatID]
 }
 
+// userJob returns the user's scheduled cycle; nil if the service is not
+// running.
+func (b *Bot) userJob(chatID int64) *scheduler.Job {
+	b.svcMu.RLock()
+	defer b.svcMu.RUnlock()
+	return b.schedulers[chatID]
+}
+
+// lastCycleRun returns when the user's scheduled cycle last started; zero if
+// the service is not running or has not run yet.
+func (b *Bot) lastCycleRun(chatID int64) time.Time {
+	job := b.userJob(chatID)
+	if job == nil {
+		return time.Time{}
+	}
+	return job.LastRun()
+}
+
 func (b *Bot) shutdownUserService(chatID int64) {
 	b.svcMu.Lock()
 	defer b.svcMu.Unlock()
@@ -1561,6 +2139,7 @@ func (b *Bot) shutdownUserService(chatID int64) {
 		delete(b.schedulers, chatID)
 	}
 	delete(b.services, chatID)
+	metrics.ForgetUser(chatID)
 	b.log.Infow("service and scheduler stopped for user", "chat_id", chatID)
 
 	// Update metrics (call without holding lock to avoid deadlock)
@@ -1580,6 +2159,7 @@ func (b *Bot) cleanupInactiveUsers(ctx context.Context) {
 			return
 		case <-ticker.C:
 			b.performCleanup()
+			b.pruneConversations()
 		}
 	}
 }
@@ -1610,6 +2190,11 @@ func (b *Bot) performCleanup() {
 		}
 	}
 
+	// Review browser snapshots are reloaded on demand
+	for chatID := range b.browse {
+		delete(b.browse, chatID)
+	}
+
 	// Clean up rate limiters for users without active services
 	b.rateLimitMu.Lock()
 	for chatID := range b.userRateLimiters {
@@ -1621,13 +2206,22 @@ func (b *Bot) performCleanup() {
 
 	// Clean up subscription cache for users without active services
 	b.subscriptionCacheMu.Lock()
-	for chatID := range b.subscriptionCache {
-		if !activeUserIDs[chatID] {
-			delete(b.subscriptionCache, chatID)
+	for key := range b.subscriptionCache {
+		if !activeUserIDs[key.userID] {
+			delete(b.subscriptionCache, key)
 		}
 	}
 	b.subscriptionCacheMu.Unlock()
 
+	// Clean up cached languages; they are reloaded on the next message
+	b.langMu.Lock()
+	for chatID := range b.langs {
+		if !activeUserIDs[chatID] {
+			delete(b.langs, chatID)
+		}
+	}
+	b.langMu.Unlock()
+
 	b.log.Debugw("cleanup completed",
 		"user_states", len(b.userStates),
 		"user_configs", len(b.userConfig),
@@ -1643,27 +2237,100 @@ func (b *Bot) updateActiveUsersMetric() {
 	metrics.UpdateActiveUsers(count)
 }
 
-// Shutdown gracefully stops all schedulers and cleans up resources
-func (b *Bot) Shutdown() {
+// Shutdown gracefully stops all schedulers and cleans up resources. Cycles
+// already running, scheduled or manual, get up to grace to finish; the rest
+// are cancelled, which records the answers they have posted, and waited for
+// until ctx is done.
+// The returned report is logged here; SendShutdownReport forwards it to the admin.
+func (b *Bot) Shutdown(ctx context.Context, grace time.Duration) ShutdownReport {
 	b.log.Info("shutting down bot, stopping all schedulers...")
 
-	b.svcMu.Lock()
-	defer b.svcMu.Unlock()
+	report := ShutdownReport{
+		Uptime:               time.Since(b.startedAt),
+		BroadcastInterrupted: b.broadcastRunning.Load(),
+		RestoreOnStart:       -1,
+	}
 
-	// Stop all schedulers
+	b.svcMu.Lock()
+	b.draining = true
+	// Stop all schedulers; running cycles go on until drained
+	scheds := make([]*scheduler.Job, 0, len(b.schedulers))
+	running := int(b.manualRunning.Load())
 	for chatID, sched := range b.schedulers {
-		sched.Shutdown()
+		if sched.Busy() {
+			running++
+		}
+		sched.Stop()
+		scheds = append(scheds, sched)
 		b.log.Debugw("scheduler stopped", "chat_id", chatID)
 	}
+	report.StoppedServices = len(b.schedulers)
 
 	// Clear maps
-	b.schedulers = make(map[int64]*scheduler.Scheduler)
+	b.schedulers = make(map[int64]*scheduler.Job)
 	b.services = make(map[int64]*service.Service)
+	b.svcMu.Unlock()
+
+	if running > 0 {
+		b.log.Infow("waiting for running cycles", "count", running, "grace", grace.String())
+	}
+	drained := make(chan struct{})
+	go func() {
+		for _, sched := range scheds {
+			sched.Wait(context.Background())
+		}
+		b.manualCycles.Wait()
+		close(drained)
+	}()
+	graceTimer := time.NewTimer(grace)
+	select {
+	case <-drained:
+	case <-graceTimer.C:
+		interrupted := int(b.manualRunning.Load())
+		for _, sched := range scheds {
+			if sched.Busy() {
+				interrupted++
+			}
+		}
+		report.InterruptedCycles = interrupted
+		b.log.Warnw("grace period over, cancelling running cycles", "count", interrupted)
+		b.cancelCycles()
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			b.log.Warnw("shutdown: cancelled cycles did not stop in time", "err", ctx.Err())
+		}
+	}
+	graceTimer.Stop()
+	report.DrainedCycles = max(running-report.InterruptedCycles, 0)
+	b.cancelCycles()
 
 	b.log.Info("all schedulers stopped")
 
+	// Give messages refused by Telegram a last chance, e.g. the
+	// notifications of the cycles drained above
+	if n := b.outbox.drain(ctx, outboxDrainTimeout); n > 0 {
+		report.UndeliveredMessages = n
+		b.log.Warnw("shutdown: telegram messages left undelivered", "count", n)
+	}
+
 	// Update metrics
 	metrics.UpdateActiveUsers(0)
+
+	if restorable, err := b.restorableConfigs(ctx); err != nil {
+		b.log.Warnw("shutdown: failed to count restorable users", "err", err)
+	} else {
+		report.RestoreOnStart = len(restorable)
+	}
+
+	b.log.Infow("shutdown report",
+		"uptime", report.Uptime.Round(time.Second).String(),
+		"stopped_services", report.StoppedServices,
+		"drained_cycles", report.DrainedCycles,
+		"interrupted_cycles", report.InterruptedCycles,
+		"broadcast_interrupted", report.BroadcastInterrupted,
+		"restore_on_start", report.RestoreOnStart)
+	return report
 }
 
 func (b *Bot) handleRunNowButton(chatID int64, ctx context.Context) {
@@ -1681,7 +2348,7 @@ func (b *Bot) handleRunNowButton(chatID int64, ctx context.Context) {
 • Добавить шаблон для отрицательных отзывов
 
 Используйте меню для добавления информации.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
 		return
 	}
 
@@ -1714,7 +2381,22 @@ func (b *Bot) handleRunNowButton(chatID int64, ctx context.Context) {
 
 Используйте кнопку "📋 Информация" для проверки текущих настроек.`,
 			strings.Join(missingFields, ", "))
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
+		return
+	}
+
+	if cfg.Paused {
+		msg := `⏸ *Автоответы остановлены*
+
+Чтобы запустить программу, сначала нажмите "▶️ Возобновить".`
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if tokenExpired(cfg, time.Now()) {
+		b.SendMessageWithKeyboard(chatID, tokenExpiredMessage(formatterFor(cfg), cfg.TokenExpiresAt), b.replaceTokenKeyboard(chatID))
+		return
+	}
+	if !b.requireAccess(chatID) {
 		return
 	}
 
@@ -1729,14 +2411,28 @@ func (b *Bot) handleRunNowButton(chatID int64, ctx context.Context) {
 		msg := `❌ *Сервис не инициализирован*
 
 Проверьте правильность введенных данных и попробуйте снова.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
+		return
+	}
+
+	if active, until := b.blackouts.Active(time.Now()); active {
+		b.deferManualCycle(chatID, cfg, until)
+		return
+	}
+	if down, since := b.wbOutage.Degraded(); down {
+		b.sendWBDown(chatID, cfg, since)
+		return
+	}
+	if opens, closed := svc.WindowClosed(time.Now()); closed {
+		msg := b.t(chatID, i18n.MsgOutsideHours, formatterFor(cfg).ShortDateTime(opens))
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
 		return
 	}
 
 	// Send immediate feedback
 	msg := "🚀 Запуск обработки отзывов\n\nБот начал обрабатывать отзывы на Wildberries.\nЭто может занять некоторое время..."
 
-	if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu()); err != nil {
+	if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID)); err != nil {
 		b.log.Errorw("failed to send run confirmation", "chat_id", chatID, "err", err)
 		// Fallback
 		simpleMsg := tgbotapi.NewMessage(chatID, msg)
@@ -1746,28 +2442,54 @@ func (b *Bot) handleRunNowButton(chatID int64, ctx context.Context) {
 	}
 
 	// Run in background
-	go func() {
-		// Panic recovery
-		defer func() {
-			if r := recover(); r != nil {
-				b.log.Errorw("panic recovered in handleRunNowButton cycle",
-					"chat_id", chatID,
-					"panic", r)
-			}
-		}()
-
-		// Use background context for cycle execution
-		cycleCtx := context.Background()
-		b.log.Infow("manual cycle triggered via telegram button", "chat_id", chatID)
-		svc.HandleCycle(cycleCtx)
-
-		// Send completion message
-		completionMsg := "✅ Обработка завершена\n\nБот завершил обработку отзывов.\nПроверьте результаты в личном кабинете Wildberries.\n\nДля повторного запуска используйте кнопку \"🚀 Запустить программу\""
+	b.svcMu.RLock()
+	draining := b.draining
+	if !draining {
+		b.manualCycles.Add(1)
+	}
+	b.svcMu.RUnlock()
+	if draining {
+		return
+	}
+	b.audit(chatID, chatID, storage.AuditCycleRun, "manual run")
+	go b.runManualCycle(chatID, svc)
+}
 
-		if err := b.SendMessage(chatID, completionMsg); err != nil {
-			b.log.Errorw("failed to send completion message", "chat_id", chatID, "err", err)
+// runManualCycle runs one cycle for a "🚀 Запустить программу" request and
+// reports the outcome to the user. The caller must have added it to
+// b.manualCycles.
+func (b *Bot) runManualCycle(chatID int64, svc *service.Service) {
+	b.manualRunning.Add(1)
+	defer b.manualCycles.Done()
+	defer b.manualRunning.Add(-1)
+	// Panic recovery
+	defer func() {
+		if r := recover(); r != nil {
+			b.log.Errorw("panic recovered in handleRunNowButton cycle",
+				"chat_id", chatID,
+				"panic", r)
 		}
 	}()
+
+	b.log.Infow("manual cycle triggered via telegram button", "chat_id", chatID)
+	if !b.withUserLock(b.cycleCtx, chatID, b.batchGuard(chatID, svc.HandleCycle)) {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgCycleBusy))
+		return
+	}
+	if b.cycleCtx.Err() != nil {
+		// Cut off by shutdown; the user is not told the run completed
+		return
+	}
+
+	// Send completion message
+	completionMsg := "✅ Обработка завершена\n\nБот завершил обработку отзывов.\nПроверьте результаты в личном кабинете Wildberries.\n\nДля повторного запуска используйте кнопку \"🚀 Запустить программу\""
+	if reason := describeWBError(b.lang(chatID), svc.LastError()); reason != "" {
+		completionMsg = "⚠️ Обработка завершена с ошибкой\n\n" + reason
+	}
+
+	if err := b.SendMessage(chatID, completionMsg); err != nil {
+		b.log.Errorw("failed to send completion message", "chat_id", chatID, "err", err)
+	}
 }
 
 func (b *Bot) handleRunNow(chatID int64, ctx context.Context) {
@@ -1776,39 +2498,39 @@ func (b *Bot) handleRunNow(chatID int64, ctx context.Context) {
 
 func (b *Bot) handleCheckSubscription(chatID int64) {
 	// Invalidate cache for this user to force fresh check
-	b.subscriptionCacheMu.Lock()
-	delete(b.subscriptionCache, chatID)
-	b.subscriptionCacheMu.Unlock()
+	b.forgetSubscription(chatID)
 
 	// Now check subscription (will make API call)
 	if b.checkChannelSubscription(chatID) {
 		msg := `✅ *Подписка подтверждена!*
 
 Добро пожаловать! Теперь вы можете использовать все функции бота.`
-		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu())
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenu(chatID))
 	} else {
 		b.sendChannelSubscriptionMessage(chatID)
 	}
 }
 
-// State management helpers
+// State management helpers. The state is kept in storage (see
+// conversation.go); the maps hold a copy used when storage fails.
 func (b *Bot) getUserState(chatID int64) UserState {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	return b.userStates[chatID]
+	return b.loadConversation(chatID)
 }
 
 func (b *Bot) setUserState(chatID int64, state UserState) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	b.userStates[chatID] = state
+	b.setConversation(chatID, state, "")
 }
 
 func (b *Bot) resetUserState(chatID int64) {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	delete(b.userStates, chatID)
 	delete(b.userConfig, chatID)
+	delete(b.editAnswerIDs, chatID)
+	delete(b.customReplyIDs, chatID)
+	delete(b.draftIDs, chatID)
+	delete(b.categoryIDs, chatID)
+	b.mu.Unlock()
+	b.deleteConversation(chatID)
 }
 
 func (b *Bot) getUserConfig(chatID int64) *storage.UserConfig {
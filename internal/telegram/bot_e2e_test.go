@@ -0,0 +1,1524 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"feedback_bot/internal/ai"
+	"feedback_bot/internal/ai/aitest"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/telegram"
+	"feedback_bot/internal/telegram/telegramtest"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/internal/wbapi/wbapitest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestBotE2E drives telegram.Bot with a service behind it.
+func TestBotE2E(t *testing.T) {
+	runScenarios(t, []scenario{
+		{"bot handles commands and buttons", botHandlesUpdates},
+		{"bot resumes a dialog after restart", botResumesDialog},
+		{"bot requires every channel", botRequiresChannels},
+		{"bot lets exempt users through", botExemptsUsers},
+		{"bot keeps the user's timezone", botKeepsTimezone},
+		{"bot shows a user to the admin", botShowsUserToAdmin},
+		{"bot previews a reply", botPreviewsReply},
+		{"bot routes buttons", botRoutesButtons},
+		{"bot tracks token expiry", botTracksTokenExpiry},
+		{"bot drafts replies for approval", botDraftsReplies},
+		{"bot keeps maintenance mode", botKeepsMaintenance},
+		{"bot reports negative reviews daily", botReportsNegativeReviews},
+		{"bot batches cycle notifications", botBatchesNotifications},
+		{"bot checks a proxy before saving it", botChecksProxy},
+		{"bot sets a reply for a product category", botSetsCategoryReply},
+		{"bot guides a new user through setup", botRunsSetupWizard},
+		{"bot lists users with failing cycles", botListsFailingUsers},
+	})
+}
+
+// botHandlesUpdates drives telegram.Bot through telegramtest.API: /start
+// greets a new user, the "add token" button asks for the token and a
+// malformed token is rejected without being saved.
+func botHandlesUpdates(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	const chatID int64 = 42
+	// reply waits for the next message to chatID after the n already sent.
+	reply := func(n int, what string) (telegramtest.Sent, error) {
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return telegramtest.Sent{}, fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n], nil
+	}
+
+	api.SendText(chatID, "/start")
+	start, err := reply(0, "/start")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(start.Text, "Добро пожаловать") {
+		return fmt.Errorf("/start reply = %q, want the welcome", start.Text)
+	}
+
+	api.Press(chatID, start.MessageID, telegram.CallbackAddToken)
+	prompt, err := reply(1, "the add token button")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(prompt.Text, "Добавление токена") {
+		return fmt.Errorf("add token reply = %q, want the token prompt", prompt.Text)
+	}
+	answered := false
+	for _, r := range api.Requests() {
+		_, ok := r.(tgbotapi.CallbackConfig)
+		answered = answered || ok
+	}
+	if !answered {
+		return fmt.Errorf("button press was not answered")
+	}
+
+	api.SendText(chatID, "short")
+	rejected, err := reply(2, "a short token")
+	if err != nil {
+		return err
+	}
+	if want := i18n.T(locale.LangRU, i18n.MsgTokenTooShort, telegram.MinTokenLength); rejected.Text != want {
+		return fmt.Errorf("short token reply = %q, want %q", rejected.Text, want)
+	}
+	if cfg, _ := env.Config.GetUserConfig(ctx, chatID); cfg != nil && cfg.WBToken == "short" {
+		return fmt.Errorf("malformed token was saved")
+	}
+	return nil
+}
+
+// botRequiresChannels checks the subscription gate with three channels: one
+// message lists only the missing ones with a join button each, answers are
+// cached per channel, and the check button asks Telegram again.
+func botRequiresChannels(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	api := telegramtest.New()
+	api.AddChannel(-1001, "shop_news", "Новости магазина")
+	api.AddChannel(-1003, "sale_chat", "Скидки")
+	channels := []telegram.Channel{
+		{Username: "@shop_news"},
+		{ID: -1002, Link: "https://t.me/+private"},
+		{ID: -1003},
+	}
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{Channels: channels}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	const chatID int64 = 42
+	reply := func(n int, what string) (telegramtest.Sent, error) {
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return telegramtest.Sent{}, fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n], nil
+	}
+	links := func(s telegramtest.Sent) []string {
+		var urls []string
+		if m, ok := s.Config.(tgbotapi.MessageConfig); ok {
+			kb, _ := m.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+			for _, row := range kb.InlineKeyboard {
+				for _, btn := range row {
+					if btn.URL != nil {
+						urls = append(urls, *btn.URL)
+					}
+				}
+			}
+		}
+		return urls
+	}
+
+	api.SetChannelMember(-1001, chatID, "member")
+	api.SendText(chatID, "/start")
+	gate, err := reply(0, "/start")
+	if err != nil {
+		return err
+	}
+	want := []string{"https://t.me/+private", "https://t.me/sale_chat"}
+	if !strings.Contains(gate.Text, "Скидки") || strings.Contains(gate.Text, "shop") || !slices.Equal(links(gate), want) {
+		return fmt.Errorf("gate = %q with links %v, want the two missing channels with %v", gate.Text, links(gate), want)
+	}
+
+	api.SetChannelMember(-1002, chatID, "member")
+	api.SetChannelMember(-1003, chatID, "administrator")
+	api.Press(chatID, gate.MessageID, telegram.CallbackCheckSubscription)
+	ok, err := reply(1, "the check button")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(ok.Text, "Подписка подтверждена") {
+		return fmt.Errorf("check reply = %q, want the confirmation", ok.Text)
+	}
+
+	// Membership is cached: leaving a channel shows up on the next check
+	api.SetChannelMember(-1001, chatID, "left")
+	api.SendText(chatID, "/start")
+	menu, err := reply(2, "/start")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(menu.Text, "Доступ ограничен") {
+		return fmt.Errorf("/start within the cache time = %q, want the menu", menu.Text)
+	}
+	api.Press(chatID, menu.MessageID, telegram.CallbackCheckSubscription)
+	gate, err = reply(3, "the check button")
+	if err != nil {
+		return err
+	}
+	if want := []string{"https://t.me/shop_news"}; !strings.Contains(gate.Text, "@shop\\_news") || !slices.Equal(links(gate), want) {
+		return fmt.Errorf("gate = %q with links %v, want only @shop_news with %v", gate.Text, links(gate), want)
+	}
+	return nil
+}
+
+// botExemptsUsers checks who skips the subscription check: admins, users
+// from the configuration and users added with "/admin exempt add", who stay
+// exempt after a restart until "/admin exempt del".
+func botExemptsUsers(ctx context.Context, env *Env) error {
+	const adminID, configured, user int64 = 99, 7, 42
+	sub := telegram.Subscription{Channels: []telegram.Channel{{ID: -1001}}, Exempt: []int64{configured}}
+	start := func(ctx context.Context) (*telegramtest.API, error) {
+		api := telegramtest.New()
+		bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, sub, []int64{adminID}, 0, false, telegram.Limits{})
+		if err != nil {
+			return nil, fmt.Errorf("NewWithAPI: %w", err)
+		}
+		go bot.Run(ctx)
+		return api, nil
+	}
+	// send sends text from chatID and returns the reply
+	send := func(api *telegramtest.API, chatID int64, text string) (string, error) {
+		n := len(api.Messages(chatID))
+		api.SendText(chatID, text)
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", fmt.Errorf("no reply to %q from %d", text, chatID)
+		}
+		return msgs[n].Text, nil
+	}
+	gated := func(api *telegramtest.API, chatID int64, want bool) error {
+		reply, err := send(api, chatID, "/start")
+		if err != nil {
+			return err
+		}
+		if got := strings.Contains(reply, "Доступ ограничен"); got != want {
+			return fmt.Errorf("/start from %d = %q, want gated %v", chatID, reply, want)
+		}
+		return nil
+	}
+
+	first, cancel := context.WithCancel(ctx)
+	api, err := start(first)
+	if err != nil {
+		cancel()
+		return err
+	}
+	for _, c := range []struct {
+		chatID int64
+		gated  bool
+	}{{adminID, false}, {configured, false}, {user, true}} {
+		if err := gated(api, c.chatID, c.gated); err != nil {
+			cancel()
+			return err
+		}
+	}
+	if _, err := send(api, adminID, fmt.Sprintf("/admin exempt add %d", user)); err != nil {
+		cancel()
+		return err
+	}
+	err = gated(api, user, false)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	// Exemptions added in the bot are stored
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	if api, err = start(ctx); err != nil {
+		return err
+	}
+	if err := gated(api, user, false); err != nil {
+		return fmt.Errorf("after restart: %w", err)
+	}
+	list, err := send(api, adminID, "/admin exempt")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(list, fmt.Sprintf("`%d` — из настроек", configured)) || !strings.Contains(list, fmt.Sprintf("`%d` — добавил `%d`", user, adminID)) {
+		return fmt.Errorf("/admin exempt = %q, want %d from the settings and %d added by %d", list, configured, user, adminID)
+	}
+	if _, err := send(api, adminID, fmt.Sprintf("/admin exempt del %d", user)); err != nil {
+		return err
+	}
+	return gated(api, user, true)
+}
+
+// botKeepsTimezone sets the user's timezone with /timezone and checks that
+// working hours entered without a zone and the info screen follow it.
+func botKeepsTimezone(ctx context.Context, env *Env) error {
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	// reply waits for the next message to the user after n sent so far
+	reply := func(n int) (string, error) {
+		msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", errors.New("no reply")
+		}
+		return msgs[n].Text, nil
+	}
+	send := func(text string) (string, error) {
+		n := len(api.Messages(userID))
+		api.SendText(userID, text)
+		got, err := reply(n)
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", text, err)
+		}
+		return got, nil
+	}
+	timezone := func() string {
+		cfg, _ := env.Config.GetUserConfig(ctx, userID)
+		if cfg == nil {
+			return ""
+		}
+		return cfg.Timezone
+	}
+
+	if got, err := send("/timezone Mars/Olympus"); err != nil {
+		return err
+	} else if !strings.Contains(got, "Неизвестный часовой пояс") || timezone() != "" {
+		return fmt.Errorf("/timezone Mars/Olympus = %q, timezone %q; want it rejected", got, timezone())
+	}
+	if got, err := send("/timezone Asia/Vladivostok"); err != nil {
+		return err
+	} else if !strings.Contains(got, "`Asia/Vladivostok`") || timezone() != "Asia/Vladivostok" {
+		return fmt.Errorf("/timezone Asia/Vladivostok = %q, timezone %q", got, timezone())
+	}
+
+	// The picker takes a typed zone as well
+	if got, err := send("/timezone"); err != nil {
+		return err
+	} else if !strings.Contains(got, "Сейчас: `Asia/Vladivostok`") {
+		return fmt.Errorf("/timezone = %q, want the current zone", got)
+	}
+	if _, err := send("Europe/Samara"); err != nil {
+		return err
+	}
+	if tz := timezone(); tz != "Europe/Samara" {
+		return fmt.Errorf("timezone after typing Europe/Samara = %q", tz)
+	}
+
+	n := len(api.Messages(userID))
+	api.Press(userID, 1, telegram.CallbackBusinessHours)
+	if _, err := reply(n); err != nil {
+		return fmt.Errorf("business hours button: %w", err)
+	}
+	if got, err := send("09:00-18:00"); err != nil {
+		return err
+	} else if !strings.Contains(got, "(Europe/Samara)") {
+		return fmt.Errorf("hours without a zone = %q, want them in Europe/Samara", got)
+	}
+	if tz := timezone(); tz != "Europe/Samara" {
+		return fmt.Errorf("timezone after setting hours = %q", tz)
+	}
+
+	cfg, err := env.Config.GetUserConfig(ctx, userID)
+	if err != nil || cfg == nil {
+		return fmt.Errorf("GetUserConfig: %v", err)
+	}
+	samara, err := time.LoadLocation("Europe/Samara")
+	if err != nil {
+		return err
+	}
+	info, err := send("/status")
+	if err != nil {
+		return err
+	}
+	updated := "*Обновлено:* " + locale.New(locale.LangRU, samara).DateTime(cfg.UpdatedAt)
+	if !strings.Contains(info, "*Часовой пояс:* Europe/Samara") || !strings.Contains(info, updated) {
+		return fmt.Errorf("/status = %q, want the zone and %q", info, updated)
+	}
+	return nil
+}
+
+// botShowsUserToAdmin opens a running user's card with /admin_user: only for
+// admins, with the token masked, the schedule, the latest cycle and error;
+// its button runs a cycle and reports to the admin, not the user.
+func botShowsUserToAdmin(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	const adminID int64 = 99
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	if err := env.Store.RecordFailure(ctx, userID, storage.Failure{Stage: service.StageFetch, Cause: service.CauseServer, Message: "upstream timeout"}); err != nil {
+		return fmt.Errorf("RecordFailure: %w", err)
+	}
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 5})
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, []int64{adminID}, 0, false,
+		telegram.Limits{CycleInterval: time.Hour})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+	bot.RestoreServices(ctx)
+
+	// The restored service runs its first cycle right away
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if results, _ := env.Store.RecentCycleResults(ctx, userID, 1); len(results) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	send := func(chatID int64, text string) (telegramtest.Sent, error) {
+		n := len(api.Messages(chatID))
+		api.SendText(chatID, text)
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return telegramtest.Sent{}, fmt.Errorf("no reply to %q from %d", text, chatID)
+		}
+		return msgs[n], nil
+	}
+	command := fmt.Sprintf("/admin_user %d", userID)
+	if got, err := send(userID, command); err != nil {
+		return err
+	} else if strings.Contains(got.Text, "*Пользователь*") {
+		return fmt.Errorf("%s from the user = %q, want it refused", command, got.Text)
+	}
+	card, err := send(adminID, command)
+	if err != nil {
+		return err
+	}
+	for _, want := range []string{
+		fmt.Sprintf("*Пользователь* `%d`", userID), "Статус: ✅ Сервис работает", "Токен WB: `test…cdef`",
+		"*Расписание:* каждые", "Следующий запуск:", "*Последние циклы:*", "ответов 1", "upstream timeout",
+	} {
+		if !strings.Contains(card.Text, want) {
+			return fmt.Errorf("user card = %q, want %q", card.Text, want)
+		}
+	}
+	if strings.Contains(card.Text, testToken) {
+		return fmt.Errorf("user card shows the token: %q", card.Text)
+	}
+
+	// The run button answers the new review and tells only the admin
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-2", ProductValuation: 5})
+	toUser := len(api.Messages(userID))
+	n := len(api.Messages(adminID))
+	api.Press(adminID, card.MessageID, fmt.Sprintf("%s%d", telegram.CallbackAdminRunPrefix, userID))
+	msgs := api.WaitMessages(adminID, n+2, 5*time.Second)
+	if len(msgs) < n+2 || !strings.Contains(msgs[n+1].Text, "завершён") {
+		return fmt.Errorf("admin messages after the run button = %v, want the run reported", msgs[n:])
+	}
+	if err := expectAnswers(env.Server, map[string]string{"fb-1": goodText, "fb-2": goodText}); err != nil {
+		return err
+	}
+	if got := api.Messages(userID); len(got) != toUser {
+		return fmt.Errorf("user got %v after the admin run, want nothing", got[toUser:])
+	}
+	return nil
+}
+
+// botPreviewsReply checks /preview before answering is started: it shows
+// the newest unanswered review with the reply the templates give it, falls
+// back to the newest answered one, and never posts anything.
+func botPreviewsReply(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	preview := func() (string, error) {
+		n := len(api.Messages(userID))
+		api.SendText(userID, "/preview")
+		msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", errors.New("no reply to /preview")
+		}
+		return msgs[n].Text, nil
+	}
+
+	if got, err := preview(); err != nil {
+		return err
+	} else if !strings.Contains(got, "Отзывов пока нет") {
+		return fmt.Errorf("/preview without reviews = %q", got)
+	}
+
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 2, Text: "Пришла мятая", SubjectName: "Футболки"})
+	got, err := preview()
+	if err != nil {
+		return err
+	}
+	for _, want := range []string{"Последний неотвеченный отзыв", "Пришла мятая", "Футболки", badText, "шаблон для отрицательных отзывов"} {
+		if !strings.Contains(got, want) {
+			return fmt.Errorf("/preview = %q, want %q", got, want)
+		}
+	}
+	if n := len(env.Server.Answers()); n != 0 {
+		return fmt.Errorf("answers after /preview = %d, want 0", n)
+	}
+
+	// Answered by hand on WB: the answered review and its reply are shown
+	if err := env.Client(testToken).AnswerFeedback(ctx, "fb-1", "Заменим, напишите в чат"); err != nil {
+		return fmt.Errorf("AnswerFeedback: %w", err)
+	}
+	if got, err = preview(); err != nil {
+		return err
+	}
+	for _, want := range []string{"показан последний отзыв с ответом", badText, "Заменим, напишите в чат"} {
+		if !strings.Contains(got, want) {
+			return fmt.Errorf("/preview of an answered review = %q, want %q", got, want)
+		}
+	}
+	if n := len(env.Server.Answers()); n != 1 {
+		return fmt.Errorf("answers after /preview = %d, want only the one by hand", n)
+	}
+	return nil
+}
+
+// botRoutesButtons checks how inline buttons reach their handlers: unknown
+// data and prefixes with missing or extra arguments are rejected, arguments
+// reach the handler, and only gated buttons ask for the channel
+// subscription.
+func botRoutesButtons(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	const member, stranger int64 = userID, 43
+	for _, id := range []int64{member, stranger} {
+		if err := env.Config.SaveUserConfig(ctx, id, fmt.Sprintf("%s-%d", testToken, id), goodText, badText); err != nil {
+			return fmt.Errorf("SaveUserConfig: %w", err)
+		}
+	}
+	api := telegramtest.New()
+	api.SetChannelMember(-1001, member, "member")
+	sub := telegram.Subscription{Channels: []telegram.Channel{{ID: -1001}}}
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, sub, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	press := func(chatID int64, data string) (string, error) {
+		n := len(api.Messages(chatID))
+		api.Press(chatID, 1, data)
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", fmt.Errorf("no reply to %q", data)
+		}
+		return msgs[n].Text, nil
+	}
+	timezone := func(chatID int64) (string, error) {
+		cfg, err := env.Config.GetUserConfig(ctx, chatID)
+		if err != nil {
+			return "", fmt.Errorf("GetUserConfig: %w", err)
+		}
+		return cfg.Timezone, nil
+	}
+
+	for _, data := range []string{"no_such_button", telegram.CallbackTimezonePrefix, "browse_cmpr:1", "browse_cmpr:1:", "adm_user"} {
+		got, err := press(member, data)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(got, "Неизвестная команда") {
+			return fmt.Errorf("reply to %q = %q, want the unknown command message", data, got)
+		}
+	}
+
+	got, err := press(member, telegram.CallbackTimezonePrefix+"Asia/Omsk")
+	if err != nil {
+		return err
+	}
+	if tz, err := timezone(member); err != nil {
+		return err
+	} else if tz != "Asia/Omsk" || !strings.Contains(got, "Asia/Omsk") {
+		return fmt.Errorf("after the tz button timezone = %q, reply %q; want Asia/Omsk", tz, got)
+	}
+
+	// Not subscribed: gated buttons show the gate, the language still works
+	if got, err = press(stranger, telegram.CallbackTimezonePrefix+"Asia/Omsk"); err != nil {
+		return err
+	}
+	if !strings.Contains(got, "Доступ ограничен") {
+		return fmt.Errorf("gated button without the subscription = %q, want the gate", got)
+	}
+	if tz, err := timezone(stranger); err != nil {
+		return err
+	} else if tz == "Asia/Omsk" {
+		return errors.New("gated button changed the timezone without the subscription")
+	}
+	if got, err = press(stranger, telegram.CallbackLanguagePrefix+"en"); err != nil {
+		return err
+	}
+	if !strings.Contains(got, "Bot language: English") {
+		return fmt.Errorf("language button without the subscription = %q, want it switched", got)
+	}
+	return nil
+}
+
+// jwtToken builds an unsigned WB-like token expiring at exp.
+func jwtToken(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"ES256","typ":"JWT"}`))
+	payload := enc.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d,"sid":"e2e"}`, exp.Unix())))
+	return header + "." + payload + ".c2lnbmF0dXJl"
+}
+
+// botTracksTokenExpiry checks the expiry read from a JWT token on save: one
+// reminder before it, one notice when it passes that stops answering, no
+// reminders again for the same token, and a token that has already expired
+// is refused in the dialog while a fresh one is accepted.
+func botTracksTokenExpiry(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	now := time.Now()
+	soon := now.Add(48 * time.Hour)
+	if err := env.Config.SaveUserConfig(ctx, userID, jwtToken(soon), goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	cfg, err := env.Config.GetUserConfig(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("GetUserConfig: %w", err)
+	}
+	if cfg.TokenExpiresAt.Unix() != soon.Unix() {
+		return fmt.Errorf("TokenExpiresAt = %v, want %v", cfg.TokenExpiresAt, soon)
+	}
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	// check runs the hourly job and returns what it sent
+	check := func() []string {
+		n := len(api.Messages(userID))
+		bot.CheckTokenExpiry(ctx)
+		return sentTexts(api.Messages(userID)[n:])
+	}
+	if got := check(); len(got) != 1 || !strings.Contains(got[0], "скоро истечёт") {
+		return fmt.Errorf("reminder = %q, want one about the token expiring soon", got)
+	}
+	if got := check(); len(got) != 0 {
+		return fmt.Errorf("second check sent %q, want nothing", got)
+	}
+	// Templates saved with the same token keep the reminder sent
+	if err := env.Config.SaveUserConfig(ctx, userID, jwtToken(soon), goodText+" 2", badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if got := check(); len(got) != 0 {
+		return fmt.Errorf("check after saving templates sent %q, want nothing", got)
+	}
+
+	expired := jwtToken(now.Add(-time.Hour))
+	if err := env.Config.SaveUserConfig(ctx, userID, expired, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if got := check(); len(got) != 1 || !strings.Contains(got[0], "Срок действия токена истёк") {
+		return fmt.Errorf("expiry notice = %q, want one saying the token expired", got)
+	}
+	if got := check(); len(got) != 0 {
+		return fmt.Errorf("second check after expiry sent %q, want nothing", got)
+	}
+
+	reply := func(what string, send func()) (string, error) {
+		n := len(api.Messages(userID))
+		send()
+		msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n].Text, nil
+	}
+	got, err := reply("/run", func() { api.SendText(userID, "/run") })
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(got, "Срок действия токена истёк") {
+		return fmt.Errorf("/run with an expired token = %q, want the expiry notice", got)
+	}
+
+	if _, err := reply("the replace token button", func() { api.Press(userID, 1, telegram.CallbackReplaceToken) }); err != nil {
+		return err
+	}
+	if got, err = reply("an expired token", func() { api.SendText(userID, jwtToken(now.Add(-time.Minute))) }); err != nil {
+		return err
+	}
+	if !strings.Contains(got, "Срок действия токена истёк") {
+		return fmt.Errorf("expired token in the dialog = %q, want it refused", got)
+	}
+
+	fresh := jwtToken(now.Add(30 * 24 * time.Hour))
+	srv := wbapitest.NewServer(fresh)
+	defer srv.Close()
+	if err := env.Config.SetWBBaseURL(ctx, userID, srv.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	if _, err := reply("a fresh token", func() { api.SendText(userID, fresh) }); err != nil {
+		return err
+	}
+	due, err := env.Config.ListTokenExpiries(ctx, now.Add(60*24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("ListTokenExpiries: %w", err)
+	}
+	if len(due) != 1 || due[0].Notice != storage.TokenNoticeNone || due[0].ExpiresAt.Unix() != now.Add(30*24*time.Hour).Unix() {
+		return fmt.Errorf("expiries after a new token = %+v, want it with no notice sent", due)
+	}
+	if got, err = reply("the info button", func() { api.Press(userID, 1, telegram.CallbackViewInfo) }); err != nil {
+		return err
+	}
+	if !strings.Contains(got, "Срок действия: до ") || strings.Contains(got, "истёк") {
+		return fmt.Errorf("info = %q, want the new token's expiry", got)
+	}
+	return nil
+}
+
+// botDraftsReplies checks the semi-automatic mode: negative reviews are not
+// answered by the cycle but get an AI draft sent with buttons, and only the
+// approved or edited drafts are posted, once. Later cycles leave drafted
+// reviews alone, and a failing AI leaves the template reply as the draft.
+func botDraftsReplies(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	const (
+		aiKey   = "ai-key"
+		drafted = "Здравствуйте! Очень жаль, что футболка пришла с дыркой. Мы проверим партию."
+		edited  = "Здравствуйте! Заменим футболку, оформите возврат в личном кабинете."
+	)
+	aiSrv := aitest.NewServer(aiKey)
+	defer aiSrv.Close()
+	aiSrv.SetReply(drafted)
+
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	if err := env.Config.SetAIDrafts(ctx, userID, true); err != nil {
+		return fmt.Errorf("SetAIDrafts: %w", err)
+	}
+	env.Server.AddFeedbacks(
+		wbapi.Feedback{ID: "fb-good", ProductValuation: 5, Text: "Отличная футболка"},
+		wbapi.Feedback{ID: "fb-send", ProductValuation: 1, Text: "Пришла с дыркой", SubjectName: "Футболки"},
+		wbapi.Feedback{ID: "fb-skip", ProductValuation: 2, Text: "Маломерит"},
+		wbapi.Feedback{ID: "fb-edit", ProductValuation: 3, Text: "Полиняла после стирки"},
+	)
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	bot.EnableDrafts(ai.New(aiKey, ai.WithBaseURL(aiSrv.URL)))
+	go bot.Run(ctx)
+
+	// Drafts are sent in the middle of the cycle, so wait for /run to finish
+	api.SendText(userID, "/run")
+	var drafts []telegramtest.Sent
+	for deadline, done := time.Now().Add(10*time.Second), false; !done && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		drafts = drafts[:0]
+		for _, m := range api.Messages(userID) {
+			switch {
+			case strings.Contains(m.Text, "Черновик ответа"):
+				drafts = append(drafts, m)
+			case strings.Contains(m.Text, "Обработка завершена"):
+				done = true
+			}
+		}
+	}
+	if len(drafts) != 3 {
+		return fmt.Errorf("drafts sent = %d, want one for each 1–3★ review", len(drafts))
+	}
+	if err := expectAnswers(env.Server, map[string]string{"fb-good": goodText}); err != nil {
+		return fmt.Errorf("with drafts on: %w", err)
+	}
+	// The cycle started with the service and the one of /run may both draft
+	reqs := aiSrv.Requests()
+	if len(reqs) < 3 {
+		return fmt.Errorf("AI requests = %d, want at least 3", len(reqs))
+	}
+	if prompt := reqs[0].Messages[len(reqs[0].Messages)-1].Content; !strings.Contains(prompt, badText) {
+		return fmt.Errorf("AI prompt = %q, want the bad template as the example", prompt)
+	}
+	var send telegramtest.Sent
+	for _, m := range drafts {
+		if strings.Contains(m.Text, "Пришла с дыркой") {
+			send = m
+		}
+	}
+	if !strings.Contains(send.Text, drafted) {
+		return fmt.Errorf("draft message = %q, want the review and the AI reply", send.Text)
+	}
+	markup, _ := send.Config.(tgbotapi.MessageConfig).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	var buttons []string
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			buttons = append(buttons, btn.Text)
+		}
+	}
+	if want := []string{"✅ Отправить", "✏️ Редактировать", "⏭ Пропустить"}; !slices.Equal(buttons, want) {
+		return fmt.Errorf("draft buttons = %q, want %q", buttons, want)
+	}
+
+	reply := func(what string, send func()) (string, error) {
+		n := len(api.Messages(userID))
+		send()
+		msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+		if len(msgs) <= n || msgs[n].Edit {
+			return "", fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n].Text, nil
+	}
+	press := func(data string) (string, error) {
+		return reply(data, func() { api.Press(userID, send.MessageID, data) })
+	}
+
+	if got, err := press(telegram.CallbackDraftSendPrefix + "fb-send"); err != nil {
+		return err
+	} else if !strings.Contains(got, "опубликован") {
+		return fmt.Errorf("send = %q, want the answer posted", got)
+	}
+	if got, err := press(telegram.CallbackDraftSendPrefix + "fb-send"); err != nil {
+		return err
+	} else if !strings.Contains(got, "уже принято решение") {
+		return fmt.Errorf("second send = %q, want it refused", got)
+	}
+	if got, err := press(telegram.CallbackDraftSkipPrefix + "fb-skip"); err != nil {
+		return err
+	} else if !strings.Contains(got, "пропущен") {
+		return fmt.Errorf("skip = %q", got)
+	}
+	if _, err := press(telegram.CallbackDraftEditPrefix + "fb-edit"); err != nil {
+		return err
+	}
+	if got, err := reply("the edited text", func() { api.SendText(userID, edited) }); err != nil {
+		return err
+	} else if !strings.Contains(got, "опубликован") {
+		return fmt.Errorf("edited text = %q, want the answer posted", got)
+	}
+	if err := expectAnswers(env.Server, map[string]string{"fb-good": goodText, "fb-send": drafted, "fb-edit": edited}); err != nil {
+		return fmt.Errorf("after the buttons: %w", err)
+	}
+	recs, err := env.Store.RecentAnswers(ctx, userID, 10)
+	if err != nil {
+		return fmt.Errorf("RecentAnswers: %w", err)
+	}
+	sources := make(map[string]string)
+	for _, r := range recs {
+		sources[r.FeedbackID] = r.Source
+	}
+	if sources["fb-send"] != service.SourceDraft || sources["fb-edit"] != service.SourceManual {
+		return fmt.Errorf("stored sources = %v, want fb-send %s and fb-edit %s", sources, service.SourceDraft, service.SourceManual)
+	}
+
+	// A later cycle neither answers nor redrafts the skipped review; a new one
+	// gets the template reply as its draft while the AI is down
+	aiSrv.SetFailing(true)
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-new", ProductValuation: 1, Text: "Не тот размер"})
+	var delivered []storage.Draft
+	svc := env.Service(service.WithDrafts(ai.New(aiKey, ai.WithBaseURL(aiSrv.URL)), func(_ wbapi.Feedback, d storage.Draft) {
+		delivered = append(delivered, d)
+	}))
+	svc.HandleCycle(ctx)
+	if len(delivered) != 1 || delivered[0].FeedbackID != "fb-new" || delivered[0].Text != badText {
+		return fmt.Errorf("drafts of the next cycle = %+v, want fb-new with the bad template", delivered)
+	}
+	if n := len(env.Server.Answers()); n != 3 {
+		return fmt.Errorf("answers after the next cycle = %d, want still 3", n)
+	}
+	return nil
+}
+
+// botResumesDialog starts the token dialog on one bot and answers it on a
+// second one sharing the database, as after a restart: the second bot must
+// treat the message as the token.
+// botKeepsMaintenance turns maintenance mode on with the admin command and
+// checks that it survives a restart: users get the maintenance message for
+// commands and buttons, restored cycles do not answer, and the admin keeps
+// using the bot. Turning it off lets the user run a cycle again.
+func botKeepsMaintenance(ctx context.Context, env *Env) error {
+	const adminID int64 = 99
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 5})
+
+	start := func(ctx context.Context) (*telegram.Bot, *telegramtest.API, error) {
+		api := telegramtest.New()
+		bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, []int64{adminID}, 0, false, telegram.Limits{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("NewWithAPI: %w", err)
+		}
+		go bot.Run(ctx)
+		return bot, api, nil
+	}
+	// reply sends an update from chatID and returns the bot's reply
+	reply := func(api *telegramtest.API, chatID int64, send func()) (string, error) {
+		n := len(api.Messages(chatID))
+		send()
+		msgs := api.WaitMessages(chatID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return "", fmt.Errorf("no reply to %d", chatID)
+		}
+		return msgs[n].Text, nil
+	}
+	const blocked = "Бот на обслуживании"
+
+	first, cancel := context.WithCancel(ctx)
+	_, api, err := start(first)
+	if err != nil {
+		cancel()
+		return err
+	}
+	text, err := reply(api, adminID, func() { api.SendText(adminID, "/admin maintenance on Переезд на новый сервер") })
+	cancel()
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(text, "Режим обслуживания включён") || !strings.Contains(text, "Переезд на новый сервер") {
+		return fmt.Errorf("/admin maintenance on = %q, want the mode on with the reason", text)
+	}
+
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	bot, api, err := start(ctx)
+	if err != nil {
+		return err
+	}
+	if !bot.InMaintenance() {
+		return errors.New("maintenance mode lost on restart")
+	}
+	bot.RestoreServices(ctx)
+	for _, c := range []struct {
+		name string
+		send func()
+	}{
+		{"/start", func() { api.SendText(userID, "/start") }},
+		{"/run", func() { api.SendText(userID, "/run") }},
+		{"a button", func() { api.Press(userID, 1, telegram.CallbackViewInfo) }},
+	} {
+		text, err := reply(api, userID, c.send)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(text, blocked) || !strings.Contains(text, "Переезд на новый сервер") {
+			return fmt.Errorf("%s in maintenance = %q, want the maintenance message with the reason", c.name, text)
+		}
+	}
+	if text, err = reply(api, adminID, func() { api.SendText(adminID, "/start") }); err != nil {
+		return err
+	}
+	if strings.Contains(text, blocked) {
+		return fmt.Errorf("admin /start in maintenance = %q, want it served", text)
+	}
+	// The restored service's first cycle is due at once
+	time.Sleep(300 * time.Millisecond)
+	if err := expectAnswers(env.Server, nil); err != nil {
+		return fmt.Errorf("in maintenance: %w", err)
+	}
+
+	if text, err = reply(api, adminID, func() { api.SendText(adminID, "/admin maintenance off") }); err != nil {
+		return err
+	}
+	if !strings.Contains(text, "Режим обслуживания выключен") {
+		return fmt.Errorf("/admin maintenance off = %q, want the mode off", text)
+	}
+	if m, err := env.Config.GetMaintenance(ctx); err != nil || m != nil {
+		return fmt.Errorf("GetMaintenance after off = %+v, %v; want nil", m, err)
+	}
+	api.SendText(userID, "/run")
+	for deadline := time.Now().Add(5 * time.Second); len(env.Server.Answers()) == 0 && time.Now().Before(deadline); {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return expectAnswers(env.Server, map[string]string{"fb-1": goodText})
+}
+
+// botReportsNegativeReviews checks the daily report: the cycle records the
+// 1–3★ reviews it fetches, answered or not, and the report lists them with
+// the replies posted. The user's timezone is chosen so that the report is due.
+func botReportsNegativeReviews(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	// Etc/GMT-N is N hours east of UTC
+	offset := (9 - time.Now().UTC().Hour() + 24) % 24
+	if offset > 12 {
+		offset -= 24
+	}
+	if err := env.Config.SetTimezone(ctx, userID, fmt.Sprintf("Etc/GMT%+d", -offset)); err != nil {
+		return fmt.Errorf("SetTimezone: %w", err)
+	}
+	if err := env.Config.AddExclusion(ctx, userID, storage.Exclusion{Kind: storage.ExclusionFeedback, Value: "fb-held"}); err != nil {
+		return fmt.Errorf("AddExclusion: %w", err)
+	}
+	shirt := wbapi.ProductDetails{NmID: 123456, ProductName: "Футболка хлопковая"}
+	env.Server.AddFeedbacks(
+		wbapi.Feedback{ID: "fb-good", ProductValuation: 5, Text: "Отличная футболка", ProductDetails: shirt},
+		wbapi.Feedback{ID: "fb-bad", ProductValuation: 2, Text: "Швы расходятся", Cons: "Тонкая ткань", ProductDetails: shirt},
+		wbapi.Feedback{ID: "fb-held", ProductValuation: 1, Text: "Пришла не того цвета", ProductDetails: shirt},
+	)
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	// The report is turned on with its button; the cycle records reviews
+	// only once it is on
+	api.Press(userID, 1, telegram.CallbackNegativeReportOn)
+	if msgs := api.WaitMessages(userID, 1, 5*time.Second); len(msgs) == 0 || !strings.Contains(msgs[0].Text, "Отчёт включён") {
+		return fmt.Errorf("report on replies = %q, want it on", sentTexts(msgs))
+	}
+	api.SendText(userID, "/run")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if msgs := api.Messages(userID); strings.Contains(msgs[len(msgs)-1].Text, "Обработка завершена") {
+			break
+		}
+	}
+	if err := expectAnswers(env.Server, map[string]string{"fb-good": goodText, "fb-bad": badText}); err != nil {
+		return err
+	}
+
+	n := len(api.Messages(userID))
+	bot.SendNegativeReports(ctx)
+	msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+	if len(msgs) <= n {
+		return errors.New("no negative report sent")
+	}
+	report := msgs[n].Text
+	for _, want := range []string{"Негативные отзывы за сутки", "Отзывов на 1–3⭐: *2*, без ответа: *1*",
+		"Швы расходятся", "➖ Тонкая ткань", "💬 " + badText, "Пришла не того цвета", "Ответ не отправлен", "Футболка хлопковая"} {
+		if !strings.Contains(report, want) {
+			return fmt.Errorf("report = %q, want %q in it", report, want)
+		}
+	}
+	if strings.Contains(report, "Отличная футболка") {
+		return fmt.Errorf("report = %q, want no positive reviews", report)
+	}
+
+	api.Press(userID, 1, telegram.CallbackNegativeReportOff)
+	api.WaitMessages(userID, n+2, 5*time.Second)
+	bot.SendNegativeReports(ctx)
+	time.Sleep(100 * time.Millisecond)
+	if msgs := api.Messages(userID); len(msgs) != n+2 {
+		return fmt.Errorf("messages after the report was turned off = %q, want no report", sentTexts(msgs[n+2:]))
+	}
+	return nil
+}
+
+func botBatchesNotifications(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	const group = int64(-1001234567890)
+	if err := env.Config.SetEscalationChat(ctx, userID, group); err != nil {
+		return fmt.Errorf("SetEscalationChat: %w", err)
+	}
+	texts := []string{"Пришла рваная", "Маломерит", "Не тот цвет", "Запах химии", "Сломалась молния"}
+	for i, text := range texts {
+		env.Server.AddFeedbacks(wbapi.Feedback{ID: fmt.Sprintf("fb-%d", i), ProductValuation: 1 + i%2, Text: text})
+	}
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-good", ProductValuation: 5})
+
+	// The cycle run when the service is restored escalates five reviews:
+	// one summary instead of five messages
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+	bot.RestoreServices(ctx)
+
+	msgs := api.WaitMessages(group, 1, 5*time.Second)
+	time.Sleep(200 * time.Millisecond)
+	if msgs = api.Messages(group); len(msgs) != 1 {
+		return fmt.Errorf("escalation chat got %q, want one summary", sentTexts(msgs))
+	}
+	if !strings.Contains(msgs[0].Text, "Негативных отзывов: 5") {
+		return fmt.Errorf("summary = %q, want the number of reviews", msgs[0].Text)
+	}
+	for _, text := range texts {
+		if !strings.Contains(msgs[0].Text, text) {
+			return fmt.Errorf("summary = %q, want %q in it", msgs[0].Text, text)
+		}
+	}
+
+	// A few reviews are escalated one by one, but the group takes no more
+	// than its limit at once: the last one is held back for a while
+	env.Server.AddFeedbacks(
+		wbapi.Feedback{ID: "fb-6", ProductValuation: 1, Text: "Не подошёл размер"},
+		wbapi.Feedback{ID: "fb-7", ProductValuation: 2, Text: "Долгая доставка"},
+		wbapi.Feedback{ID: "fb-8", ProductValuation: 1, Text: "Брак"},
+	)
+	api.SendText(userID, "/run")
+	msgs = api.WaitMessages(group, 3, 5*time.Second)
+	time.Sleep(time.Second)
+	if msgs = api.Messages(group); len(msgs) != 3 {
+		return fmt.Errorf("escalation chat got %d messages right after the cycle, want 3 with the last review held back", len(msgs))
+	}
+	msgs = api.WaitMessages(group, 4, 5*time.Second)
+	if len(msgs) != 4 {
+		return fmt.Errorf("escalation chat got %d messages, want the held back review delivered", len(msgs))
+	}
+	for i, want := range []string{"Не подошёл размер", "Долгая доставка", "Брак"} {
+		if m := msgs[i+1].Text; !strings.Contains(m, "Негативный отзыв") || !strings.Contains(m, want) {
+			return fmt.Errorf("escalation %d = %q, want the review %q", i+1, m, want)
+		}
+	}
+	if err := expectAnswers(env.Server, map[string]string{
+		"fb-0": badText, "fb-1": badText, "fb-2": badText, "fb-3": badText, "fb-4": badText,
+		"fb-6": badText, "fb-7": badText, "fb-8": badText, "fb-good": goodText,
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func botChecksProxy(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	fp, err := wbapitest.StartProxy("127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("start proxy: %w", err)
+	}
+	defer fp.Close()
+	// A port nobody listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	dead := ln.Addr().String()
+	ln.Close()
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	command := func(text string) (string, error) {
+		n := len(api.Messages(userID))
+		api.SendText(userID, text)
+		msgs := api.WaitMessages(userID, n+1, 15*time.Second)
+		if len(msgs) <= n {
+			return "", fmt.Errorf("no reply to %s", text)
+		}
+		return msgs[n].Text, nil
+	}
+	proxy := func() string {
+		cfg, _ := env.Config.GetUserConfig(ctx, userID)
+		if cfg == nil {
+			return "<no config>"
+		}
+		return cfg.WBProxy
+	}
+
+	if got, err := command("/proxy socks4://" + fp.Addr); err != nil {
+		return err
+	} else if !strings.Contains(got, "socks5://") || proxy() != "" {
+		return fmt.Errorf("unsupported scheme: reply %q, stored %q; want it rejected", got, proxy())
+	}
+	if got, err := command("/proxy http://" + dead); err != nil {
+		return err
+	} else if !strings.Contains(got, "не отвечает") || proxy() != "" {
+		return fmt.Errorf("dead proxy: reply %q, stored %q; want it rejected", got, proxy())
+	}
+	if got, err := command("/proxy http://" + fp.Addr); err != nil {
+		return err
+	} else if !strings.Contains(got, "через прокси") || proxy() != "http://"+fp.Addr {
+		return fmt.Errorf("live proxy: reply %q, stored %q; want it saved", got, proxy())
+	}
+
+	// The user's cycle goes through it
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 5})
+	before := fp.Carried()
+	api.SendText(userID, "/run")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if msgs := api.Messages(userID); strings.Contains(msgs[len(msgs)-1].Text, "Обработка завершена") {
+			break
+		}
+	}
+	if err := expectAnswers(env.Server, map[string]string{"fb-1": goodText}); err != nil {
+		return err
+	}
+	if fp.Carried() == before {
+		return errors.New("the cycle did not go through the user's proxy")
+	}
+
+	if got, err := command("/proxy off"); err != nil {
+		return err
+	} else if !strings.Contains(got, "отключён") || proxy() != "" {
+		return fmt.Errorf("proxy off: reply %q, stored %q; want it cleared", got, proxy())
+	}
+	return nil
+}
+
+// Product categories of botSetsCategoryReply.
+const (
+	shoesID   = 105
+	shoesText = "Спасибо за отзыв! Если размер не подошёл, загляните в таблицу размеров в карточке."
+	shirtsID  = 7
+)
+
+// botSetsCategoryReply goes through /categories: the categories of the
+// user's reviews are offered, a reply typed for one is stored and the next
+// cycle uses it.
+func botSetsCategoryReply(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := env.Config.SaveUserConfig(ctx, userID, testToken, goodText, badText); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, env.Server.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	env.Server.AddFeedbacks(
+		wbapi.Feedback{ID: "fb-shirt", ProductValuation: 5, SubjectID: shirtsID, SubjectName: "Футболки"},
+		wbapi.Feedback{ID: "fb-shoes-1", ProductValuation: 5, SubjectID: shoesID, SubjectName: "Кроссовки"},
+		wbapi.Feedback{ID: "fb-shoes-2", ProductValuation: 4, SubjectID: shoesID, SubjectName: "Кроссовки"},
+	)
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	// reply returns the last of the count messages sent in reply
+	reply := func(what string, count int, send func()) (telegramtest.Sent, error) {
+		n := len(api.Messages(userID))
+		send()
+		msgs := api.WaitMessages(userID, n+count, 5*time.Second)
+		if len(msgs) < n+count {
+			return telegramtest.Sent{}, fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n+count-1], nil
+	}
+	buttons := func(m telegramtest.Sent) map[string]string {
+		markup, _ := m.Config.(tgbotapi.MessageConfig).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+		out := make(map[string]string)
+		for _, row := range markup.InlineKeyboard {
+			for _, btn := range row {
+				out[btn.Text] = *btn.CallbackData
+			}
+		}
+		return out
+	}
+
+	list, err := reply("/categories", 1, func() { api.SendText(userID, "/categories") })
+	if err != nil {
+		return err
+	}
+	open, ok := buttons(list)["📂 Кроссовки (2)"]
+	if _, shirts := buttons(list)["📂 Футболки (1)"]; !ok || !shirts {
+		return fmt.Errorf("/categories buttons = %v, want both categories with their review counts", buttons(list))
+	}
+	screen, err := reply(open, 1, func() { api.Press(userID, 1, open) })
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(screen.Text, "Кроссовки") || !strings.Contains(screen.Text, "основной шаблон") {
+		return fmt.Errorf("category screen = %q, want the category on the main templates", screen.Text)
+	}
+	set := buttons(screen)["👍 Ответ на 4–5★"]
+	if _, err := reply(set, 1, func() { api.Press(userID, 1, set) }); err != nil {
+		return err
+	}
+	saved, err := reply("the category reply", 2, func() { api.SendText(userID, shoesText) })
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(saved.Text, "📂 *Кроссовки*") {
+		return fmt.Errorf("after saving = %q, want the category screen", saved.Text)
+	}
+	stored, err := env.Config.ListCategoryTemplates(ctx, userID)
+	if err != nil || len(stored) != 1 || stored[0].SubjectID != shoesID || stored[0].SubjectName != "Кроссовки" || stored[0].Good != shoesText || stored[0].Bad != "" {
+		return fmt.Errorf("stored category replies = %+v, %v; want the good reply of Кроссовки", stored, err)
+	}
+
+	bot.RestoreServices(ctx)
+	want := map[string]string{"fb-shirt": goodText, "fb-shoes-1": shoesText, "fb-shoes-2": shoesText}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline) && len(env.Server.Answers()) < len(want); time.Sleep(20 * time.Millisecond) {
+	}
+	return expectAnswers(env.Server, want)
+}
+
+func botResumesDialog(ctx context.Context, env *Env) error {
+	const chatID int64 = 42
+	start := func(ctx context.Context) (*telegramtest.API, error) {
+		api := telegramtest.New()
+		bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+		if err != nil {
+			return nil, fmt.Errorf("NewWithAPI: %w", err)
+		}
+		go bot.Run(ctx)
+		return api, nil
+	}
+
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	first, err := start(firstCtx)
+	if err != nil {
+		stopFirst()
+		return err
+	}
+	first.Press(chatID, 1, telegram.CallbackAddToken)
+	msgs := first.WaitMessages(chatID, 1, 5*time.Second)
+	stopFirst()
+	if len(msgs) == 0 || !strings.Contains(msgs[0].Text, "Добавление токена") {
+		return fmt.Errorf("add token replies = %q, want the token prompt", sentTexts(msgs))
+	}
+
+	secondCtx, stopSecond := context.WithCancel(ctx)
+	defer stopSecond()
+	second, err := start(secondCtx)
+	if err != nil {
+		return err
+	}
+	second.SendText(chatID, "short")
+	msgs = second.WaitMessages(chatID, 1, 5*time.Second)
+	want := i18n.T(locale.LangRU, i18n.MsgTokenTooShort, telegram.MinTokenLength)
+	if len(msgs) == 0 || msgs[0].Text != want {
+		return fmt.Errorf("replies after restart = %q, want %q", sentTexts(msgs), want)
+	}
+
+	// Cancel ends the dialog for every bot.
+	second.Press(chatID, 1, telegram.CallbackCancel)
+	second.WaitMessages(chatID, 2, 5*time.Second)
+	if conv, err := env.Config.GetConversation(ctx, chatID); err != nil || conv != nil {
+		return fmt.Errorf("conversation after cancel = %+v, %v; want none", conv, err)
+	}
+	return nil
+}
+
+// sentTexts returns the texts of the bot's messages for error reports.
+func sentTexts(msgs []telegramtest.Sent) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.Text
+	}
+	return out
+}
+
+// botRunsSetupWizard goes through the setup wizard /start opens for a user
+// without a token: a bad token is asked again, back returns to a step with
+// the option to keep its value, the test step previews the reply to a real
+// review without posting it and the run button starts answering.
+func botRunsSetupWizard(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	token := jwtToken(time.Now().Add(30 * 24 * time.Hour))
+	srv := wbapitest.NewServer(token)
+	defer srv.Close()
+	// The user has started before, so the bot knows where to check the token
+	if err := env.Config.SaveUserConfig(ctx, userID, "not_set", "Спасибо за ваш отзыв!", "Спасибо за ваш отзыв!"); err != nil {
+		return fmt.Errorf("SaveUserConfig: %w", err)
+	}
+	if err := env.Config.SetWBBaseURL(ctx, userID, srv.URL); err != nil {
+		return fmt.Errorf("SetWBBaseURL: %w", err)
+	}
+	srv.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 5})
+
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+
+	reply := func(what string, send func()) (telegramtest.Sent, error) {
+		n := len(api.Messages(userID))
+		send()
+		msgs := api.WaitMessages(userID, n+1, 5*time.Second)
+		if len(msgs) <= n {
+			return telegramtest.Sent{}, fmt.Errorf("no reply to %s", what)
+		}
+		return msgs[n], nil
+	}
+	buttons := func(m telegramtest.Sent) map[string]string {
+		markup, _ := m.Config.(tgbotapi.MessageConfig).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+		out := make(map[string]string)
+		for _, row := range markup.InlineKeyboard {
+			for _, btn := range row {
+				out[btn.Text] = *btn.CallbackData
+			}
+		}
+		return out
+	}
+	const back, keep, run = "⬅️ Назад", "➡️ Оставить как есть", "🚀 Запустить программу"
+
+	step, err := reply("/start", func() { api.SendText(userID, "/start") })
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 1 из 4") || !strings.Contains(step.Text, "Добавление токена") {
+		return fmt.Errorf("/start = %q, want the token step", step.Text)
+	}
+	if _, ok := buttons(step)[keep]; ok {
+		return fmt.Errorf("token step buttons = %v, want no keep without a token", buttons(step))
+	}
+	rejected, err := reply("a short token", func() { api.SendText(userID, "short") })
+	if err != nil {
+		return err
+	}
+	if want := i18n.T(locale.LangRU, i18n.MsgTokenTooShort, telegram.MinTokenLength); rejected.Text != want {
+		return fmt.Errorf("short token = %q, want %q", rejected.Text, want)
+	}
+	if step, err = reply("the token", func() { api.SendText(userID, token) }); err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 2 из 4") || !strings.Contains(step.Text, "✅ Токен") {
+		return fmt.Errorf("after the token = %q, want step 2 with the token done", step.Text)
+	}
+	if step, err = reply("the good template", func() { api.SendText(userID, goodText) }); err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 3 из 4") {
+		return fmt.Errorf("after the good template = %q, want step 3", step.Text)
+	}
+	if step, err = reply(back, func() { api.Press(userID, 1, buttons(step)[back]) }); err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 2 из 4") || buttons(step)[keep] == "" {
+		return fmt.Errorf("back = %q with buttons %v, want step 2 with keep", step.Text, buttons(step))
+	}
+	if step, err = reply(keep, func() { api.Press(userID, 1, buttons(step)[keep]) }); err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 3 из 4") {
+		return fmt.Errorf("keep = %q, want step 3", step.Text)
+	}
+	if step, err = reply("the bad template", func() { api.SendText(userID, badText) }); err != nil {
+		return err
+	}
+	if !strings.Contains(step.Text, "Шаг 4 из 4") || !strings.Contains(step.Text, goodText) || buttons(step)[run] != telegram.CallbackRunNow {
+		return fmt.Errorf("test step = %q with buttons %v, want the preview of the good reply and the run button", step.Text, buttons(step))
+	}
+	cfg, err := env.Config.GetUserConfig(ctx, userID)
+	if err != nil || cfg == nil || cfg.WBToken != token || cfg.TemplateGood != goodText || cfg.TemplateBad != badText {
+		return fmt.Errorf("config after the wizard = %+v, %v; want the token and both templates", cfg, err)
+	}
+	if err := expectAnswers(srv, nil); err != nil {
+		return fmt.Errorf("test step: %w", err)
+	}
+
+	api.Press(userID, 1, telegram.CallbackRunNow)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline) && len(srv.Answers()) == 0; time.Sleep(20 * time.Millisecond) {
+	}
+	if err := expectAnswers(srv, map[string]string{"fb-1": goodText}); err != nil {
+		return err
+	}
+	menu, err := reply("/start after setup", func() { api.SendText(userID, "/start") })
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(menu.Text, "Бот готов к работе") {
+		return fmt.Errorf("/start after setup = %q, want the main menu", menu.Text)
+	}
+	return nil
+}
+
+// botListsFailingUsers checks that "/admin_errors" lists a user whose token
+// WB rejects, and not one whose cycles succeed.
+func botListsFailingUsers(ctx context.Context, env *Env) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	env.Server.AddFeedbacks(wbapi.Feedback{ID: "fb-1", ProductValuation: 5})
+	revoked := service.New(userID, env.Client("revoked-token"), env.Store, badText, goodText, env.Log, 100)
+	healthy := service.New(userID+1, env.Client(testToken), env.Store, badText, goodText, env.Log, 100)
+	revoked.HandleCycle(ctx)
+	healthy.HandleCycle(ctx)
+
+	const adminID int64 = 7
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, env.Config, env.Store, env.Log, ctx, telegram.Subscription{}, []int64{adminID}, 0, false, telegram.Limits{})
+	if err != nil {
+		return fmt.Errorf("NewWithAPI: %w", err)
+	}
+	go bot.Run(ctx)
+	api.SendText(adminID, "/admin_errors")
+	msgs := api.WaitMessages(adminID, 1, 5*time.Second)
+	if len(msgs) == 0 {
+		return fmt.Errorf("no reply to /admin_errors")
+	}
+	if text := msgs[0].Text; !strings.Contains(text, fmt.Sprintf("`%d`", userID)) || strings.Contains(text, fmt.Sprintf("`%d`", userID+1)) {
+		return fmt.Errorf("/admin_errors reply = %q, want only user %d", text, userID)
+	}
+	return nil
+}
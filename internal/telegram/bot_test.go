@@ -0,0 +1,221 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/telegram"
+	"feedback_bot/internal/telegram/telegramtest"
+)
+
+const (
+	chatID    int64 = 42
+	testToken       = "test-token-0123456789-abcdef"
+	goodText        = "Спасибо за отзыв!"
+	badText         = "Нам жаль, что товар не понравился."
+)
+
+// testBot is a bot running against telegramtest.API and a temporary SQLite
+// store. admins are configured as with ADMIN_USER_IDS.
+type testBot struct {
+	*telegram.Bot
+	api    *telegramtest.API
+	config storage.ConfigStore
+}
+
+func newTestBot(t *testing.T, sub telegram.Subscription, admins ...int64) *testBot {
+	t.Helper()
+	st, cs, err := storage.NewSQLite(filepath.Join(t.TempDir(), "bot.db"), nil)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	api := telegramtest.New()
+	bot, err := telegram.NewWithAPI(api, cs, st, nil, ctx, sub, admins, 0, false, telegram.Limits{})
+	if err != nil {
+		t.Fatalf("NewWithAPI: %v", err)
+	}
+	go bot.Run(ctx)
+	return &testBot{Bot: bot, api: api, config: cs}
+}
+
+// reply sends an update with send and returns the next message the bot
+// sends to chatID.
+func (tb *testBot) reply(t *testing.T, send func()) telegramtest.Sent {
+	t.Helper()
+	n := len(tb.api.Messages(chatID))
+	send()
+	msgs := tb.api.WaitMessages(chatID, n+1, 5*time.Second)
+	if len(msgs) <= n {
+		t.Fatal("no reply")
+	}
+	return msgs[n]
+}
+
+func buttons(t *testing.T, s telegramtest.Sent) []string {
+	t.Helper()
+	msg, ok := s.Config.(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatalf("sent %T, want a message", s.Config)
+	}
+	kb, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok {
+		return nil
+	}
+	var data []string
+	for _, row := range kb.InlineKeyboard {
+		for _, b := range row {
+			if b.CallbackData != nil {
+				data = append(data, *b.CallbackData)
+			}
+		}
+	}
+	return data
+}
+
+// failingMe is an API whose GetMe fails, as when the token is revoked.
+type failingMe struct{ *telegramtest.API }
+
+func (failingMe) GetMe() (tgbotapi.User, error) {
+	return tgbotapi.User{}, errors.New("unauthorized")
+}
+
+func TestNewWithAPIFailsWithoutBotInfo(t *testing.T) {
+	_, err := telegram.NewWithAPI(failingMe{telegramtest.New()}, nil, nil, nil, context.Background(), telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("NewWithAPI = %v, want the GetMe error", err)
+	}
+}
+
+func TestSendMessageWithKeyboard(t *testing.T) {
+	tb := newTestBot(t, telegram.Subscription{})
+	if err := tb.SendMessageWithKeyboard(chatID, "текст\xff", tb.CreateMainMenu(chatID)); err != nil {
+		t.Fatalf("SendMessageWithKeyboard: %v", err)
+	}
+	msgs := tb.api.Messages(chatID)
+	if len(msgs) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(msgs))
+	}
+	if msgs[0].Text != "текст" {
+		t.Errorf("text = %q, want the invalid UTF-8 dropped", msgs[0].Text)
+	}
+	if msg := msgs[0].Config.(tgbotapi.MessageConfig); msg.ParseMode != tgbotapi.ModeMarkdown {
+		t.Errorf("parse mode = %q, want Markdown", msg.ParseMode)
+	}
+	if got := buttons(t, msgs[0]); len(got) == 0 || got[0] != telegram.CallbackViewInfo {
+		t.Errorf("buttons = %v, want the main menu", got)
+	}
+}
+
+func TestStartOpensWizardForNewUser(t *testing.T) {
+	tb := newTestBot(t, telegram.Subscription{})
+	start := tb.reply(t, func() { tb.api.SendText(chatID, "/start") })
+	if !strings.Contains(start.Text, "Добро пожаловать") {
+		t.Errorf("/start = %q, want the welcome", start.Text)
+	}
+	if !strings.Contains(start.Text, i18n.T(locale.LangRU, i18n.MsgWizardHeader, 1, 4)) {
+		t.Errorf("/start = %q, want the first wizard step", start.Text)
+	}
+}
+
+func TestStartShowsMenuAfterSetup(t *testing.T) {
+	tb := newTestBot(t, telegram.Subscription{})
+	if err := tb.config.SaveUserConfig(context.Background(), chatID, testToken, goodText, badText); err != nil {
+		t.Fatalf("SaveUserConfig: %v", err)
+	}
+	start := tb.reply(t, func() { tb.api.SendText(chatID, "/start") })
+	if strings.Contains(start.Text, i18n.T(locale.LangRU, i18n.MsgWizardHeader, 1, 4)) {
+		t.Errorf("/start = %q, want the menu instead of the wizard", start.Text)
+	}
+	if got := buttons(t, start); !slices.Contains(got, telegram.CallbackRunNow) {
+		t.Errorf("/start buttons = %v, want the run button", got)
+	}
+}
+
+func TestTokenPromptRejectsShortToken(t *testing.T) {
+	tb := newTestBot(t, telegram.Subscription{})
+	prompt := tb.reply(t, func() { tb.api.Press(chatID, 1, telegram.CallbackAddToken) })
+	if !strings.Contains(prompt.Text, "Добавление токена") {
+		t.Fatalf("add token = %q, want the token prompt", prompt.Text)
+	}
+	answered := false
+	for _, r := range tb.api.Requests() {
+		if cb, ok := r.(tgbotapi.CallbackConfig); ok && cb.CallbackQueryID != "" {
+			answered = true
+		}
+	}
+	if !answered {
+		t.Error("button press was not answered")
+	}
+
+	rejected := tb.reply(t, func() { tb.api.SendText(chatID, "short") })
+	if want := i18n.T(locale.LangRU, i18n.MsgTokenTooShort, telegram.MinTokenLength); rejected.Text != want {
+		t.Errorf("short token = %q, want %q", rejected.Text, want)
+	}
+	if cfg, _ := tb.config.GetUserConfig(context.Background(), chatID); cfg != nil && cfg.WBToken == "short" {
+		t.Error("malformed token was saved")
+	}
+}
+
+func TestUnknownCallbacksAreRejected(t *testing.T) {
+	tb := newTestBot(t, telegram.Subscription{})
+	for _, data := range []string{"", "no_such_button", telegram.CallbackTimezonePrefix, "browse_cmpr:1:"} {
+		got := tb.reply(t, func() { tb.api.Press(chatID, 1, data) })
+		if !strings.Contains(got.Text, "Неизвестная команда") {
+			t.Errorf("press %q = %q, want the unknown command message", data, got.Text)
+		}
+	}
+}
+
+func TestAdminConfirmationBelongsToItsAdmin(t *testing.T) {
+	const other int64 = 43
+	tb := newTestBot(t, telegram.Subscription{}, chatID, other)
+	prompt := tb.reply(t, func() { tb.api.SendText(chatID, "/admin add 100") })
+	var yes string
+	for _, data := range buttons(t, prompt) {
+		if strings.HasPrefix(data, telegram.CallbackAdminYesPrefix) {
+			yes = data
+		}
+	}
+	if yes == "" {
+		t.Fatalf("prompt buttons = %v, want a confirmation", buttons(t, prompt))
+	}
+
+	// Another admin cannot use the button, nor use it up
+	tb.api.Press(other, prompt.MessageID, yes)
+	if msgs := tb.api.WaitMessages(other, 1, 5*time.Second); len(msgs) == 0 || !strings.Contains(msgs[0].Text, "недействительно") {
+		t.Fatalf("press by another admin = %v, want the invalid confirmation", msgs)
+	}
+	done := tb.reply(t, func() { tb.api.Press(chatID, prompt.MessageID, yes) })
+	if !done.Edit || !strings.Contains(done.Text, "Подтверждено") {
+		t.Errorf("press by the admin = %q, want the action confirmed", done.Text)
+	}
+}
+
+func TestSubscriptionGate(t *testing.T) {
+	const channel int64 = -1001
+	tb := newTestBot(t, telegram.Subscription{Channels: []telegram.Channel{{ID: channel}}})
+	gate := tb.reply(t, func() { tb.api.SendText(chatID, "/start") })
+	if !strings.Contains(gate.Text, "Доступ ограничен") {
+		t.Fatalf("/start without the subscription = %q, want the gate", gate.Text)
+	}
+
+	tb.api.SetChannelMember(channel, chatID, "member")
+	checked := tb.reply(t, func() { tb.api.Press(chatID, gate.MessageID, telegram.CallbackCheckSubscription) })
+	if strings.Contains(checked.Text, "Доступ ограничен") {
+		t.Errorf("check after joining = %q, want access", checked.Text)
+	}
+}
@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/time/rate"
+
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// broadcastRate stays below Telegram's ~30 msg/s global limit.
+	broadcastRate = 20
+	// broadcastProgressEvery controls how often the admin's progress message is edited.
+	broadcastProgressEvery = 25
+)
+
+// handleBroadcastCommand handles "/broadcast [text]". Without text the bot
+// asks for it; with text the broadcast starts immediately.
+func (b *Bot) handleBroadcastCommand(chatID int64, text string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	if text == "" {
+		b.setUserState(chatID, StateWaitingBroadcast)
+		b.SendMessageWithKeyboard(chatID, "📣 *Рассылка*\n\nОтправьте текст сообщения, которое получат все пользователи бота.\n\nТекст будет отправлен как есть, без форматирования.", b.CreateCancelKeyboard(chatID))
+		return
+	}
+	b.confirmBroadcast(chatID, text)
+}
+
+func (b *Bot) handleBroadcastInput(chatID int64, text string) {
+	b.resetUserState(chatID)
+	if !b.isAdmin(chatID) {
+		return
+	}
+	b.confirmBroadcast(chatID, text)
+}
+
+// confirmBroadcast shows the admin a preview and the number of recipients and
+// starts the broadcast only after confirmation.
+func (b *Bot) confirmBroadcast(adminChatID int64, text string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	userIDs, err := b.configStore.ListUserIDs(dbCtx)
+	cancel()
+	if err != nil {
+		b.log.Errorw("broadcast: failed to list users", "err", err)
+		metrics.IncrementDatabaseError("list_users")
+		b.SendMessage(adminChatID, "❌ Не удалось получить список пользователей. Рассылка отменена.")
+		return
+	}
+
+	prompt := fmt.Sprintf("📣 *Подтвердите рассылку*\n\nПолучателей: %d\n\nТекст:\n%s", len(userIDs), escapeMarkdownV1(text))
+	b.confirmAdminAction(adminChatID, prompt, fmt.Sprintf("рассылка на %d получателей", len(userIDs)), func() {
+		b.startBroadcast(adminChatID, text)
+	})
+}
+
+// startBroadcast launches delivery in the background; only one broadcast may run at a time.
+func (b *Bot) startBroadcast(adminChatID int64, text string) {
+	if !b.broadcastRunning.CompareAndSwap(false, true) {
+		b.SendMessage(adminChatID, "⚠️ Рассылка уже выполняется. Дождитесь её завершения.")
+		return
+	}
+
+	go func() {
+		defer b.broadcastRunning.Store(false)
+		defer func() {
+			if r := recover(); r != nil {
+				b.log.Errorw("panic recovered in broadcast", "panic", r)
+			}
+		}()
+		b.runBroadcast(b.ctx, adminChatID, text)
+	}()
+}
+
+// runBroadcast sends text to every user in user_configs with rate limiting,
+// periodically editing a progress message for the admin. Users who blocked
+// the bot are skipped and counted separately.
+func (b *Bot) runBroadcast(ctx context.Context, adminChatID int64, text string) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	userIDs, err := b.configStore.ListUserIDs(dbCtx)
+	cancel()
+	if err != nil {
+		b.log.Errorw("broadcast: failed to list users", "err", err)
+		metrics.IncrementDatabaseError("list_users")
+		b.SendMessage(adminChatID, "❌ Не удалось получить список пользователей. Рассылка отменена.")
+		return
+	}
+
+	total := len(userIDs)
+	progress, err := b.api.Send(tgbotapi.NewMessage(adminChatID, fmt.Sprintf("📣 Рассылка начата: 0/%d", total)))
+	if err != nil {
+		b.log.Warnw("broadcast: failed to send progress message", "err", err)
+	}
+	updateProgress := func(line string) {
+		if progress.MessageID == 0 {
+			return
+		}
+		edit := tgbotapi.NewEditMessageText(adminChatID, progress.MessageID, line)
+		if _, err := b.api.Send(edit); err != nil {
+			b.log.Debugw("broadcast: failed to edit progress", "err", err)
+		}
+	}
+
+	limiter := rate.NewLimiter(broadcastRate, 1)
+	var sent, blocked, queued, failed int
+	for i, userID := range userIDs {
+		if err := limiter.Wait(ctx); err != nil {
+			break // bot is shutting down
+		}
+
+		msg := tgbotapi.NewMessage(userID, text)
+		if _, err := b.api.Send(msg); err != nil {
+			if isBlockedByUser(err) {
+				blocked++
+			} else if wait, retry := retryDelay(err); retry {
+				queued++
+				b.outbox.add(userID, msg, 1, wait)
+			} else {
+				failed++
+				b.log.Warnw("broadcast: send failed", "chat_id", userID, "err", err)
+				metrics.IncrementAPIError("telegram", "broadcast")
+			}
+		} else {
+			sent++
+		}
+
+		if (i+1)%broadcastProgressEvery == 0 {
+			updateProgress(fmt.Sprintf("📣 Рассылка: %d/%d (доставлено %d, заблокировали %d, в очереди %d, ошибок %d)", i+1, total, sent, blocked, queued, failed))
+		}
+	}
+
+	summary := fmt.Sprintf("✅ Рассылка завершена\n\nВсего пользователей: %d\nДоставлено: %d\nЗаблокировали бота: %d\nОтложено из-за лимитов Telegram (будут доставлены позже): %d\nОшибок: %d", total, sent, blocked, queued, failed)
+	if ctx.Err() != nil {
+		summary = fmt.Sprintf("⚠️ Рассылка прервана остановкой бота\n\nДоставлено: %d из %d", sent, total)
+	}
+	updateProgress(summary)
+	b.log.Infow("broadcast finished", "total", total, "sent", sent, "blocked", blocked, "queued", queued, "failed", failed)
+}
+
+// isBlockedByUser reports whether Telegram refused delivery because the
+// user blocked the bot or deleted the account (HTTP 403).
+func isBlockedByUser(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusForbidden
+}
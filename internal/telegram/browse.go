@@ -0,0 +1,306 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+)
+
+const (
+	browseTake    = 50   // newest unanswered reviews loaded by the browser
+	browseTextLen = 1000 // longer texts, pros and cons are cut so the page fits a message
+)
+
+// browseList is the snapshot of unanswered reviews a user is paging through.
+// Pages are rendered from it, so navigation does not call WB.
+type browseList struct {
+	feedbacks []wbapi.Feedback
+	total     int // countUnanswered reported by WB, may exceed len(feedbacks)
+	f         locale.Formatter
+}
+
+// userService returns the user's running service or, if answering is paused
+// or not started, a temporary one built from stored settings.
+func (b *Bot) userService(chatID int64, cfg *storage.UserConfig) *service.Service {
+	if svc := b.getServiceForUser(chatID); svc != nil {
+		return svc
+	}
+	client := b.wbClientFor(cfg)
+	return service.New(chatID, client, b.userStore, cfg.TemplateBad, cfg.TemplateGood, b.log, browseTake, b.serviceOptions(chatID, cfg)...)
+}
+
+// handleBrowseButton loads the newest unanswered reviews from WB and shows
+// the first of them.
+func (b *Bot) handleBrowseButton(chatID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" || !templateSet(cfg.TemplateGood) || !templateSet(cfg.TemplateBad) {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBrowseSetupFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	page, err := b.userService(chatID, cfg).Unanswered(wbCtx, browseTake, 0)
+	if err != nil {
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBrowseLoadFailed)+reason, b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	list := &browseList{feedbacks: page.Feedbacks, total: page.CountUnanswered, f: formatterFor(cfg)}
+	b.mu.Lock()
+	b.browse[chatID] = list
+	b.mu.Unlock()
+
+	if len(list.feedbacks) == 0 {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBrowseEmpty), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	text, keyboard := b.browsePage(chatID, list, 0)
+	b.SendMessageWithKeyboard(chatID, text, keyboard)
+}
+
+// handleBrowseNav shows the review at index ("browse:<i>") in place of the
+// current page.
+func (b *Bot) handleBrowseNav(chatID int64, messageID int, index string) {
+	i, err := strconv.Atoi(index)
+	list := b.browseList(chatID)
+	if err != nil || list == nil || len(list.feedbacks) == 0 {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+	i = max(0, min(i, len(list.feedbacks)-1))
+	text, keyboard := b.browsePage(chatID, list, i)
+	b.editBrowseMessage(chatID, messageID, text, keyboard)
+}
+
+// handleBrowseAnswer answers the review with the given ID
+// ("browse_ans:<id>") right away and replaces the page with the result.
+func (b *Bot) handleBrowseAnswer(chatID int64, messageID int, id string, ctx context.Context) {
+	fb, i, ok := b.browsedFeedback(chatID, id)
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if !ok || cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	decision, err := b.userService(chatID, cfg).AnswerNow(wbCtx, fb)
+	switch {
+	case errors.Is(err, service.ErrNoAccess):
+		b.requireAccess(chatID)
+		return
+	case errors.Is(err, service.ErrDailyLimit):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDailyLimitReached))
+		return
+	case errors.Is(err, service.ErrAlreadyAnswered):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgBrowseAlreadyAnswered))
+	case err != nil:
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgAnswerNotSent)+reason)
+		return
+	}
+
+	next := b.dropBrowsed(chatID, id)
+	if err != nil {
+		if next == nil || len(next.feedbacks) == 0 {
+			b.editBrowseMessage(chatID, messageID, b.t(chatID, i18n.MsgBrowseNoMore), b.browseMenuKeyboard(chatID))
+			return
+		}
+		text, keyboard := b.browsePage(chatID, next, min(i, len(next.feedbacks)-1))
+		b.editBrowseMessage(chatID, messageID, text, keyboard)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.t(chatID, i18n.MsgAnswerSent))
+	sb.WriteString(browseReview(formatterFor(cfg), fb))
+	sb.WriteString(b.t(chatID, i18n.MsgBrowseAnswer))
+	sb.WriteString(escapeMarkdownV1(decision.Text))
+	b.editBrowseMessage(chatID, messageID, sb.String(), b.browseNextKeyboard(chatID, next, i))
+}
+
+// browseNextKeyboard is the menu shown after the review at index i of the
+// list was answered, with a button to the review now at i when the updated
+// list next still has reviews.
+func (b *Bot) browseNextKeyboard(chatID int64, next *browseList, i int) tgbotapi.InlineKeyboardMarkup {
+	keyboard := b.browseMenuKeyboard(chatID)
+	if next == nil || len(next.feedbacks) == 0 {
+		return keyboard
+	}
+	if row := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnNextReview), CallbackBrowsePrefix, strconv.Itoa(min(i, len(next.feedbacks)-1))); len(row) > 0 {
+		keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{row}, keyboard.InlineKeyboard...)
+	}
+	return keyboard
+}
+
+func (b *Bot) browseList(chatID int64) *browseList {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.browse[chatID]
+}
+
+// browsedFeedback returns the review with id from the user's browser
+// snapshot, its index and whether it was found.
+func (b *Bot) browsedFeedback(chatID int64, id string) (wbapi.Feedback, int, bool) {
+	list := b.browseList(chatID)
+	if list == nil {
+		return wbapi.Feedback{}, -1, false
+	}
+	for i, fb := range list.feedbacks {
+		if fb.ID == id {
+			return fb, i, true
+		}
+	}
+	return wbapi.Feedback{}, -1, false
+}
+
+// dropBrowsed removes an answered review from the user's list and returns
+// the updated list, nil if there is none.
+func (b *Bot) dropBrowsed(chatID int64, id string) *browseList {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	list := b.browse[chatID]
+	if list == nil {
+		return nil
+	}
+	kept := make([]wbapi.Feedback, 0, len(list.feedbacks))
+	for _, fb := range list.feedbacks {
+		if fb.ID != id {
+			kept = append(kept, fb)
+		}
+	}
+	list = &browseList{feedbacks: kept, total: max(0, list.total-(len(list.feedbacks)-len(kept))), f: list.f}
+	b.browse[chatID] = list
+	return list
+}
+
+func (b *Bot) sendBrowseExpired(chatID int64) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnReload), CallbackBrowse),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgBrowseExpired), keyboard)
+}
+
+// editBrowseMessage replaces the browser page; if the message can no longer
+// be edited, the page is sent as a new message.
+func (b *Bot) editBrowseMessage(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard)
+	edit.ParseMode = tgbotapi.ModeMarkdown
+	if _, err := b.api.Send(edit); err != nil {
+		b.log.Debugw("failed to edit review browser", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, text, keyboard)
+	}
+}
+
+// browsePage renders review i of list with navigation and the answer button.
+func (b *Bot) browsePage(chatID int64, list *browseList, i int) (string, tgbotapi.InlineKeyboardMarkup) {
+	fb := list.feedbacks[i]
+	var sb strings.Builder
+	sb.WriteString(b.t(chatID, i18n.MsgBrowsePage, i+1, len(list.feedbacks)))
+	if list.total > len(list.feedbacks) {
+		sb.WriteString(b.t(chatID, i18n.MsgBrowseTotal, list.f.Count(int64(list.total))))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(browseReview(list.f, fb))
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if i > 0 {
+		nav = b.appendCallbackButton(nav, b.t(chatID, i18n.BtnPrev), CallbackBrowsePrefix, strconv.Itoa(i-1))
+	}
+	if i < len(list.feedbacks)-1 {
+		nav = b.appendCallbackButton(nav, b.t(chatID, i18n.BtnNext), CallbackBrowsePrefix, strconv.Itoa(i+1))
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	answer := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnAnswerNow), CallbackBrowseAnsPrefix, fb.ID)
+	if answer = b.appendCallbackButton(answer, b.t(chatID, i18n.BtnOwnAnswer), CallbackBrowseOwnPrefix, fb.ID); len(answer) > 0 {
+		rows = append(rows, answer)
+	}
+	if fb.SupplierFeedbackValuation == 0 {
+		if row := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnComplain), CallbackComplainPrefix, fb.ID); len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, b.browseMenuKeyboard(chatID).InlineKeyboard...)
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// browseReview renders the rating, product, date and text of a review.
+func browseReview(f locale.Formatter, fb wbapi.Feedback) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("⭐", max(0, min(fb.ProductValuation, 5))))
+	if fb.SubjectName != "" {
+		sb.WriteString(" · " + escapeMarkdownV1(fb.SubjectName))
+	}
+	if fb.ProductDetails.NmID != 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewArticle, fb.ProductDetails.NmID))
+	}
+	if !fb.CreatedDate.IsZero() {
+		sb.WriteString("\n🕒 " + f.ShortDateTime(fb.CreatedDate))
+	}
+	if n := len(fb.PhotoLinks); n > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewPhotos, n))
+	}
+	if fb.Video != nil && fb.Video.Link != "" {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewVideo))
+	}
+	if fb.SupplierFeedbackValuation != 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewComplained))
+	}
+	sb.WriteString("\n")
+	if fb.Text == "" && fb.Pros == "" && fb.Cons == "" {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewNoText))
+	}
+	if fb.Text != "" {
+		sb.WriteString("\n" + escapeMarkdownV1(clipReview(fb.Text)))
+	}
+	if fb.Pros != "" {
+		sb.WriteString("\n➕ " + escapeMarkdownV1(clipReview(fb.Pros)))
+	}
+	if fb.Cons != "" {
+		sb.WriteString("\n➖ " + escapeMarkdownV1(clipReview(fb.Cons)))
+	}
+	return sb.String()
+}
+
+func clipReview(text string) string {
+	if utf8.RuneCountInString(text) > browseTextLen {
+		return string([]rune(text)[:browseTextLen]) + "…"
+	}
+	return text
+}
+
+func (b *Bot) browseMenuKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+}
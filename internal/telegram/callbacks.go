@@ -0,0 +1,176 @@
+package telegram
+
+import (
+	"context"
+
+	"feedback_bot/internal/storage"
+)
+
+// callbackRoutes registers the handlers of all inline buttons. Buttons are
+// gated by the channel subscription, except the ones needed to pass it
+// (check, language) and the ones answering the bot's own prompts.
+func (b *Bot) callbackRoutes() *callbackRouter {
+	r := newCallbackRouter()
+	// button registers a gated button whose handler needs only the chat.
+	button := func(data string, fn func(chatID int64)) {
+		r.handle(data, true, func(_ context.Context, q callbackQuery) { fn(q.ChatID) })
+	}
+	// buttonCtx is button for handlers that also take the update context.
+	buttonCtx := func(data string, fn func(chatID int64, ctx context.Context)) {
+		r.handle(data, true, func(ctx context.Context, q callbackQuery) { fn(q.ChatID, ctx) })
+	}
+	// toggle registers a gated on/off pair.
+	toggle := func(on, off string, fn func(chatID int64, enable bool, ctx context.Context)) {
+		buttonCtx(on, func(chatID int64, ctx context.Context) { fn(chatID, true, ctx) })
+		buttonCtx(off, func(chatID int64, ctx context.Context) { fn(chatID, false, ctx) })
+	}
+
+	// Main menu and setup
+	button(CallbackMainMenu, b.showMainMenu)
+	buttonCtx(CallbackViewInfo, b.handleViewInfo)
+	button(CallbackAddToken, b.handleAddTokenButton)
+	button(CallbackAddTemplateGood, b.handleAddTemplateGoodButton)
+	button(CallbackAddTemplateBad, b.handleAddTemplateBadButton)
+	button(CallbackEditTemplateGood, func(chatID int64) { b.handleEditTemplateButton(chatID, storage.VariantGood) })
+	button(CallbackEditTemplateBad, func(chatID int64) { b.handleEditTemplateButton(chatID, storage.VariantBad) })
+	button(CallbackDeleteAll, b.handleDeleteAllButton)
+	r.handle(CallbackConfirmDelete, true, func(ctx context.Context, q callbackQuery) {
+		b.log.Infow("CallbackConfirmDelete received", "chat_id", q.ChatID)
+		b.handleConfirmDelete(q.ChatID, ctx)
+	})
+	button(CallbackCancel, b.handleCancel)
+	r.handlePrefix(CallbackWizardPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleWizardCallback(q.ChatID, q.Arg(0), ctx)
+	})
+	buttonCtx(CallbackRunNow, b.handleRunNowButton)
+	r.handle(CallbackCheckSubscription, false, func(_ context.Context, q callbackQuery) { b.handleCheckSubscription(q.ChatID) })
+	r.handle(CallbackReplaceToken, false, func(_ context.Context, q callbackQuery) { b.handleReplaceTokenButton(q.ChatID) })
+	r.handle(CallbackLanguage, false, func(_ context.Context, q callbackQuery) { b.handleLanguageCommand(q.ChatID) })
+	r.handlePrefix(CallbackLanguagePrefix, 1, false, func(ctx context.Context, q callbackQuery) {
+		b.handleLanguageCallback(q.ChatID, q.Arg(0), ctx)
+	})
+	buttonCtx(CallbackTimezone, func(chatID int64, ctx context.Context) { b.handleTimezoneCommand(chatID, "", ctx) })
+	r.handlePrefix(CallbackTimezonePrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.saveTimezone(q.ChatID, q.Arg(0), ctx)
+	})
+
+	// Answering settings
+	button(CallbackBusinessHours, b.handleBusinessHoursButton)
+	button(CallbackOffHoursTemplate, b.handleOffHoursTemplateButton)
+	toggle(CallbackHoursOnlyOn, CallbackHoursOnlyOff, b.handleAnswerHoursOnlyToggle)
+	button(CallbackQuestionTemplate, b.handleQuestionTemplateButton)
+	button(CallbackMediaTemplate, b.handleMediaTemplateButton)
+	button(CallbackExclusions, b.handleExclusionsButton)
+	button(CallbackDailyLimit, b.handleDailyLimitButton)
+	button(CallbackAnswerDelay, b.handleAnswerDelayButton)
+	button(CallbackAdvanced, b.handleAdvancedButton)
+	button(CallbackSandboxOn, func(chatID int64) { b.handleSandboxToggle(chatID, true) })
+	button(CallbackSandboxOff, func(chatID int64) { b.handleSandboxToggle(chatID, false) })
+	button(CallbackCustomBaseURL, b.handleCustomBaseURLButton)
+	button(CallbackCustomProxy, b.handleCustomProxyButton)
+	button(CallbackProxyOff, b.handleProxyOff)
+	button(CallbackSentiment, b.handleSentimentButton)
+	toggle(CallbackSentimentOn, CallbackSentimentOff, b.handleSentimentToggle)
+	button(CallbackTracking, b.handleTrackingButton)
+	toggle(CallbackTrackingOn, CallbackTrackingOff, b.handleTrackingToggle)
+	button(CallbackEscalation, b.handleEscalationButton)
+	button(CallbackDuplicates, b.handleDuplicatesButton)
+	button(CallbackDrafts, b.handleDraftsButton)
+	toggle(CallbackDraftsOn, CallbackDraftsOff, b.handleDraftsToggle)
+	button(CallbackNegativeReport, b.handleNegativeReportButton)
+	toggle(CallbackNegativeReportOn, CallbackNegativeReportOff, b.handleNegativeReportToggle)
+	button(CallbackHumanize, b.handleHumanizeButton)
+	toggle(CallbackHumanizeOn, CallbackHumanizeOff, b.handleHumanizeToggle)
+	button(CallbackVariants, b.handleVariantsButton)
+	button(CallbackVariantAddGood, func(chatID int64) { b.handleVariantAddButton(chatID, storage.VariantGood) })
+	button(CallbackVariantAddBad, func(chatID int64) { b.handleVariantAddButton(chatID, storage.VariantBad) })
+	r.handlePrefix(CallbackVariantDelPrefix, 2, true, func(ctx context.Context, q callbackQuery) {
+		b.handleVariantDelete(q.ChatID, q.Arg(0), q.Arg(1), ctx)
+	})
+	button(CallbackSignatures, b.handleSignaturesButton)
+	button(CallbackSignatureAdd, b.handleSignatureAddButton)
+	buttonCtx(CallbackCategories, b.handleCategoriesButton)
+	r.handlePrefix(CallbackCategoryPrefix, 1, true, func(_ context.Context, q callbackQuery) {
+		b.handleCategory(q.ChatID, q.Arg(0))
+	})
+	r.handlePrefix(CallbackCategorySetPrefix, 2, true, func(_ context.Context, q callbackQuery) {
+		b.handleCategorySetButton(q.ChatID, q.Arg(0), q.Arg(1))
+	})
+	r.handlePrefix(CallbackCategoryDelPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleCategoryDelete(q.ChatID, q.Arg(0), ctx)
+	})
+	button(CallbackTemplateFile, b.handleTemplateFileButton)
+	button(CallbackExportTemplates, b.handleExportTemplates)
+	buttonCtx(CallbackBenchmarks, b.handleBenchmarks)
+	toggle(CallbackBenchmarkOptIn, CallbackBenchmarkOptOut, b.handleBenchmarkOptIn)
+
+	// Running the service
+	button(CallbackSimulate, b.handleSimulateButton)
+	buttonCtx(CallbackPreview, b.handlePreviewButton)
+	buttonCtx(CallbackPause, b.handlePauseButton)
+	buttonCtx(CallbackResume, b.handleResumeButton)
+	button(CallbackRestart, b.handleRestartButton)
+	button(CallbackWhatsNew, b.handleWhatsNew)
+	button(CallbackFailures, b.handleFailures)
+	button(CallbackProblems, b.handleProblemReviews)
+	buttonCtx(CallbackProblemRetry, b.handleProblemRetry)
+	button(CallbackArchiveConfirm, b.handleArchiveConfirm)
+	button(CallbackSubscription, b.handleSubscriptionButton)
+	button(CallbackPay, b.sendInvoice)
+	button(CallbackInvite, b.handleInviteButton)
+	r.handle(CallbackPollSkip, false, func(_ context.Context, q callbackQuery) { b.handlePollSkip(q.ChatID) })
+	r.handlePrefix(CallbackPollPrefix, 1, false, func(ctx context.Context, q callbackQuery) {
+		b.handlePollAnswer(q.ChatID, q.Arg(0), ctx)
+	})
+
+	// History and single reviews
+	buttonCtx(CallbackHistory, b.handleHistory)
+	button(CallbackExportHistory, b.handleExportHistory)
+	button(CallbackEditAnswer, b.handleEditAnswerButton)
+	r.handlePrefix(CallbackEditAnswerPrefix, 1, true, func(_ context.Context, q callbackQuery) {
+		b.handleEditAnswerIDInput(q.ChatID, q.Arg(0))
+	})
+	buttonCtx(CallbackBrowse, b.handleBrowseButton)
+	r.handlePrefix(CallbackBrowsePrefix, 1, true, func(_ context.Context, q callbackQuery) {
+		b.handleBrowseNav(q.ChatID, q.MessageID, q.Arg(0))
+	})
+	r.handlePrefix(CallbackBrowseAnsPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleBrowseAnswer(q.ChatID, q.MessageID, q.Arg(0), ctx)
+	})
+	r.handlePrefix(CallbackBrowseOwnPrefix, 1, true, func(_ context.Context, q callbackQuery) {
+		b.handleCustomReplyPick(q.ChatID, q.Arg(0))
+	})
+	r.handlePrefix(CallbackComplainPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleComplainPick(q.ChatID, q.MessageID, q.Arg(0), ctx)
+	})
+	r.handlePrefix(CallbackComplainRsnPrefix, 2, true, func(ctx context.Context, q callbackQuery) {
+		b.handleComplain(q.ChatID, q.MessageID, q.Arg(0), q.Arg(1), ctx)
+	})
+	r.handlePrefix(CallbackDraftSendPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleDraftSend(q.ChatID, q.MessageID, q.Arg(0), ctx)
+	})
+	r.handlePrefix(CallbackDraftEditPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleDraftEdit(q.ChatID, q.Arg(0), ctx)
+	})
+	r.handlePrefix(CallbackDraftSkipPrefix, 1, true, func(ctx context.Context, q callbackQuery) {
+		b.handleDraftSkip(q.ChatID, q.MessageID, q.Arg(0), ctx)
+	})
+
+	// Admin buttons check the admin rights themselves
+	r.handlePrefix(CallbackAdminYesPrefix, 1, false, func(_ context.Context, q callbackQuery) {
+		b.handleAdminConfirmCallback(q.ChatID, q.MessageID, true, q.Arg(0))
+	})
+	r.handlePrefix(CallbackAdminNoPrefix, 1, false, func(_ context.Context, q callbackQuery) {
+		b.handleAdminConfirmCallback(q.ChatID, q.MessageID, false, q.Arg(0))
+	})
+	for _, prefix := range []string{
+		CallbackAdminUsersPrefix, CallbackAdminUserPrefix, CallbackAdminStopPrefix,
+		CallbackAdminDelPrefix, CallbackAdminBanPrefix, CallbackAdminUnbanPrefix,
+		CallbackAdminRunPrefix,
+	} {
+		r.handlePrefix(prefix, 1, false, func(ctx context.Context, q callbackQuery) {
+			b.handleAdminUserCallback(q.ChatID, prefix, q.Arg(0), ctx)
+		})
+	}
+	return r
+}
@@ -0,0 +1,298 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// categoryButtons limits the categories offered on the category screen.
+const categoryButtons = 12
+
+// categoryOption loads the user's product category replies into a service
+// option. Returns nil if there are none or they cannot be loaded.
+func (b *Bot) categoryOption(chatID int64) service.Option {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, err := b.configStore.ListCategoryTemplates(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load category templates, ignoring", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return service.WithCategoryTemplates(list)
+}
+
+// subjectName returns the name of a WB product category as last seen in the
+// user's reviews or replies, or a placeholder with its ID.
+func (b *Bot) subjectName(chatID, subjectID int64) string {
+	b.mu.RLock()
+	name := b.subjectNames[subjectID]
+	b.mu.RUnlock()
+	if name != "" {
+		return name
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, _ := b.configStore.ListCategoryTemplates(dbCtx, chatID)
+	for _, t := range list {
+		if t.SubjectID == subjectID && t.SubjectName != "" {
+			return t.SubjectName
+		}
+	}
+	return b.t(chatID, i18n.MsgCategoryFallback, subjectID)
+}
+
+// handleCategoriesButton finds the product categories of the user's reviews
+// on WB and lists them with the ones that already have their own replies.
+func (b *Bot) handleCategoriesButton(chatID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	own, err := b.configStore.ListCategoryTemplates(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list category templates", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgCategoriesLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	found, err := b.userService(chatID, cfg).Categories(wbCtx)
+	if err != nil {
+		// The categories that have replies can still be edited
+		b.log.Infow("category discovery failed", "chat_id", chatID, "err", err)
+	}
+	b.mu.Lock()
+	for _, c := range found {
+		if c.Name != "" {
+			b.subjectNames[c.SubjectID] = c.Name
+		}
+	}
+	b.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString(b.t(chatID, i18n.MsgCategoriesIntro))
+	owned := make(map[int64]bool, len(own))
+	if len(own) > 0 {
+		sb.WriteString(b.t(chatID, i18n.MsgCategoriesOwn))
+		for _, t := range own {
+			owned[t.SubjectID] = true
+			fmt.Fprintf(&sb, "• %s: %s\n", escapeMarkdownV1(t.SubjectName), categoryRepliesSummary(b.lang(chatID), t))
+		}
+	}
+	switch {
+	case err != nil:
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		sb.WriteString(b.t(chatID, i18n.MsgCategoriesDiscoveryFailed, reason))
+	case len(found) == 0:
+		sb.WriteString(b.t(chatID, i18n.MsgCategoriesNone))
+	default:
+		sb.WriteString(b.t(chatID, i18n.MsgCategoriesChoose))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, t := range own {
+		if row := b.appendCallbackButton(nil, "✏️ "+t.SubjectName, CallbackCategoryPrefix, strconv.FormatInt(t.SubjectID, 10)); len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	shown := 0
+	for _, c := range found {
+		if owned[c.SubjectID] || shown == categoryButtons {
+			continue
+		}
+		shown++
+		name := c.Name
+		if name == "" {
+			name = b.t(chatID, i18n.MsgCategoryFallback, c.SubjectID)
+		}
+		if row := b.appendCallbackButton(nil, fmt.Sprintf("📂 %s (%d)", name, c.Reviews), CallbackCategoryPrefix, strconv.FormatInt(c.SubjectID, 10)); len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnBackToVariants), CallbackVariants),
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	b.SendMessageWithKeyboard(chatID, sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// categoryRepliesSummary says which of the category's replies are its own.
+func categoryRepliesSummary(lang string, t storage.CategoryTemplate) string {
+	switch {
+	case t.Good != "" && t.Bad != "":
+		return i18n.T(lang, i18n.MsgCategoryBoth)
+	case t.Good != "":
+		return "4–5★"
+	case t.Bad != "":
+		return "1–3★"
+	}
+	return i18n.T(lang, i18n.MsgNo)
+}
+
+// handleCategory shows the replies of one product category
+// (CallbackCategoryPrefix + "<subjectID>").
+func (b *Bot) handleCategory(chatID int64, idStr string) {
+	subjectID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || subjectID <= 0 {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil {
+		b.showMainMenu(chatID)
+		return
+	}
+	list, err := b.configStore.ListCategoryTemplates(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list category templates", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgCategoriesLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	var own storage.CategoryTemplate
+	for _, t := range list {
+		if t.SubjectID == subjectID {
+			own = t
+		}
+	}
+
+	show := func(text, main string) string {
+		if text == "" {
+			return b.t(chatID, i18n.MsgCategoryMainTemplate, variantPreview(main))
+		}
+		return variantPreview(text)
+	}
+	msg := b.t(chatID, i18n.MsgCategoryScreen,
+		escapeMarkdownV1(b.subjectName(chatID, subjectID)), show(own.Good, cfg.TemplateGood), show(own.Bad, cfg.TemplateBad))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	set := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnCategoryGood), CallbackCategorySetPrefix, storage.VariantGood, idStr)
+	set = b.appendCallbackButton(set, b.t(chatID, i18n.BtnCategoryBad), CallbackCategorySetPrefix, storage.VariantBad, idStr)
+	if len(set) > 0 {
+		rows = append(rows, set)
+	}
+	if own.SubjectID != 0 {
+		if del := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnCategoryReset), CallbackCategoryDelPrefix, idStr); len(del) > 0 {
+			rows = append(rows, del)
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCategories), CallbackCategories),
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	b.SendMessageWithKeyboard(chatID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleCategorySetButton asks for a category's reply
+// (CallbackCategorySetPrefix + "<good|bad>:<subjectID>").
+func (b *Bot) handleCategorySetButton(chatID int64, category, idStr string) {
+	subjectID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || subjectID <= 0 || (category != storage.VariantGood && category != storage.VariantBad) {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	state, title := StateWaitingCategoryGood, b.t(chatID, i18n.MsgCategoryGoodTitle)
+	if category == storage.VariantBad {
+		state, title = StateWaitingCategoryBad, b.t(chatID, i18n.MsgCategoryBadTitle)
+	}
+	b.setConversation(chatID, state, idStr)
+	msg := b.t(chatID, i18n.MsgCategoryPrompt, escapeMarkdownV1(b.subjectName(chatID, subjectID)), title)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleCategoryInput(chatID int64, category, text string, ctx context.Context) {
+	b.mu.RLock()
+	idStr := b.categoryIDs[chatID]
+	b.mu.RUnlock()
+	subjectID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		b.resetUserState(chatID)
+		b.showMainMenu(chatID)
+		return
+	}
+
+	text = strings.TrimSpace(text)
+	if lower := strings.ToLower(text); lower == "выкл" || lower == "off" {
+		text = ""
+	} else {
+		if len([]rune(text)) < 10 {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if len([]rune(text)) > MaxTemplateLength {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if !utf8.ValidString(text) {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if b.rejectContent(chatID, text) {
+			return
+		}
+	}
+
+	if err := b.configStore.SetCategoryTemplate(ctx, chatID, subjectID, b.subjectName(chatID, subjectID), category, text); err != nil {
+		b.log.Errorw("failed to save category template", "chat_id", chatID, "subject_id", subjectID, "err", err)
+		metrics.IncrementDatabaseError("save_template")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, templateAuditDetails(fmt.Sprintf("category %d %s", subjectID, category), text))
+	b.reloadUserService(chatID, ctx)
+
+	if text == "" {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgCategoryCleared))
+	} else {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgCategorySaved))
+	}
+	b.handleCategory(chatID, idStr)
+}
+
+// handleCategoryDelete returns a category to the main templates
+// (CallbackCategoryDelPrefix + "<subjectID>").
+func (b *Bot) handleCategoryDelete(chatID int64, idStr string, ctx context.Context) {
+	subjectID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || subjectID <= 0 {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	if _, err := b.configStore.RemoveCategoryTemplate(ctx, chatID, subjectID); err != nil {
+		b.log.Errorw("failed to delete category template", "chat_id", chatID, "subject_id", subjectID, "err", err)
+		metrics.IncrementDatabaseError("save_template")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgCategoryDeleteFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, fmt.Sprintf("category %d templates removed", subjectID))
+	b.reloadUserService(chatID, ctx)
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgCategoryRemoved))
+	b.handleCategoriesButton(chatID, ctx)
+}
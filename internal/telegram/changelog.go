@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// changelogLimit is how many entries "✨ Что нового" shows at once.
+	changelogLimit = 5
+	// Limits for admin-published entries; the text must fit a Telegram message.
+	maxChangelogVersion = 32
+	maxChangelogText    = 3000
+)
+
+// unreadChangelog returns the number of entries the user has not seen yet.
+// Errors are logged and treated as nothing new.
+func (b *Bot) unreadChangelog(ctx context.Context, cfg *storage.UserConfig) int {
+	if cfg == nil {
+		return 0
+	}
+	n, err := b.configStore.CountChangelog(ctx, cfg.ChangelogSeenID)
+	if err != nil {
+		b.log.Warnw("failed to count changelog entries", "chat_id", cfg.UserID, "err", err)
+		return 0
+	}
+	return n
+}
+
+// handleWhatsNew shows the latest changelog entries and marks them as read.
+func (b *Bot) handleWhatsNew(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	entries, err := b.configStore.ListChangelog(dbCtx, 0, changelogLimit)
+	if err != nil {
+		b.log.Warnw("failed to list changelog", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_changelog")
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при получении новостей*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+
+	var seen int64
+	if cfg != nil {
+		seen = cfg.ChangelogSeenID
+	}
+	if len(entries) > 0 && entries[0].ID > seen {
+		if err := b.configStore.MarkChangelogSeen(dbCtx, chatID, entries[0].ID); err != nil {
+			b.log.Warnw("failed to mark changelog seen", "chat_id", chatID, "err", err)
+			metrics.IncrementDatabaseError("save_config")
+		}
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, formatChangelog(formatterFor(cfg), entries, seen), keyboard)
+}
+
+// formatChangelog renders entries newest first, marking those newer than seen.
+func formatChangelog(f locale.Formatter, entries []storage.ChangelogEntry, seen int64) string {
+	if len(entries) == 0 {
+		return "✨ *Что нового*\n\nПока здесь пусто. Мы расскажем о новых возможностях бота, как только они появятся."
+	}
+	var sb strings.Builder
+	sb.WriteString("✨ *Что нового*\n")
+	for _, e := range entries {
+		mark := ""
+		if e.ID > seen {
+			mark = " 🆕"
+		}
+		fmt.Fprintf(&sb, "\n*%s*%s — %s\n%s\n", escapeMarkdownV1(e.Version), mark, f.Date(e.CreatedAt), escapeMarkdownV1(e.Text))
+	}
+	return sb.String()
+}
+
+// handleAnnounceCommand handles the admin command
+//
+//	/announce <версия>
+//	<текст>
+//
+// which publishes a changelog entry shown to users under "✨ Что нового".
+func (b *Bot) handleAnnounceCommand(chatID int64, args string, ctx context.Context) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	version, text, _ := strings.Cut(strings.TrimSpace(args), "\n")
+	version = strings.TrimSpace(version)
+	text = strings.TrimSpace(text)
+	if version == "" || text == "" {
+		b.SendMessage(chatID, "Использование:\n`/announce <версия>`\n`<текст на следующих строках>`")
+		return
+	}
+	if utf8.RuneCountInString(version) > maxChangelogVersion || utf8.RuneCountInString(text) > maxChangelogText {
+		b.SendMessage(chatID, fmt.Sprintf("⚠️ Слишком длинно: версия до %d символов, текст до %d.", maxChangelogVersion, maxChangelogText))
+		return
+	}
+
+	if err := b.configStore.AddChangelogEntry(ctx, version, text); err != nil {
+		b.log.Errorw("failed to add changelog entry", "err", err)
+		metrics.IncrementDatabaseError("save_changelog")
+		b.SendMessage(chatID, "❌ Не удалось сохранить запись. Попробуйте позже.")
+		return
+	}
+	b.log.Infow("changelog entry published", "admin_id", chatID, "version", version)
+	b.SendMessage(chatID, fmt.Sprintf("✅ Запись *%s* опубликована. Пользователи увидят её в «✨ Что нового».", escapeMarkdownV1(version)))
+}
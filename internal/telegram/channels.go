@@ -0,0 +1,264 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+)
+
+// subscriptionTTL is how long a membership answer is reused for a user and
+// channel before Telegram is asked again.
+const subscriptionTTL = 5 * time.Minute
+
+// Channel is a Telegram channel or group users must join to use the bot.
+type Channel struct {
+	ID       int64  // checked directly when set (faster and more reliable)
+	Username string // without "@"; checked when there is no ID, and gives the join link
+	Link     string // join link, e.g. the invite link of a private channel
+}
+
+// Subscription configures the channel subscription check.
+type Subscription struct {
+	Channels []Channel // all must be joined; none disables the check
+	Exempt   []int64   // users let through without joining; admins always are
+}
+
+// key identifies the channel in caches and logs.
+func (c Channel) key() string {
+	if c.ID != 0 {
+		return strconv.FormatInt(c.ID, 10)
+	}
+	return "@" + c.Username
+}
+
+// subscriptionKey is a user's membership in one required channel.
+type subscriptionKey struct {
+	userID  int64
+	channel string // Channel.key
+}
+
+type subscriptionEntry struct {
+	isSubscribed bool
+	expiresAt    time.Time
+}
+
+// normalizeChannels drops "@" from usernames and empty entries.
+func normalizeChannels(channels []Channel) []Channel {
+	out := make([]Channel, 0, len(channels))
+	for _, c := range channels {
+		c.Username = strings.TrimPrefix(strings.TrimSpace(c.Username), "@")
+		if c.ID != 0 || c.Username != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// channelKeys lists the channels for logs.
+func channelKeys(channels []Channel) []string {
+	keys := make([]string, len(channels))
+	for i, c := range channels {
+		keys[i] = c.key()
+	}
+	return keys
+}
+
+// checkChannelSubscription reports whether the user joined every required
+// channel. Without required channels everyone has access, and so do admins
+// and exempt users.
+func (b *Bot) checkChannelSubscription(chatID int64) bool {
+	if b.isExempt(chatID) {
+		return true
+	}
+	return len(b.missingChannels(chatID)) == 0
+}
+
+// missingChannels returns the required channels the user has not joined. A
+// channel that cannot be checked counts as not joined.
+func (b *Bot) missingChannels(chatID int64) []Channel {
+	var missing []Channel
+	for _, ch := range b.channels() {
+		if !b.isMember(chatID, ch) {
+			missing = append(missing, ch)
+		}
+	}
+	return missing
+}
+
+// isMember checks the user's membership in ch. Answers are cached for
+// subscriptionTTL per user and channel; failed checks are not cached.
+func (b *Bot) isMember(chatID int64, ch Channel) bool {
+	key := subscriptionKey{userID: chatID, channel: ch.key()}
+	b.subscriptionCacheMu.RLock()
+	cached, exists := b.subscriptionCache[key]
+	b.subscriptionCacheMu.RUnlock()
+	if exists && time.Now().Before(cached.expiresAt) {
+		b.log.Debugw("subscription check from cache",
+			"chat_id", chatID,
+			"channel", key.channel,
+			"is_subscribed", cached.isSubscribed,
+			"cache_expires_at", cached.expiresAt)
+		return cached.isSubscribed
+	}
+
+	channelChatID := ch.ID
+	if channelChatID == 0 {
+		chat, err := b.resolveChannel(ch)
+		if err != nil {
+			b.log.Errorw("FAILED: Cannot get channel info - bot may not have access",
+				"channel", key.channel,
+				"chat_id", chatID,
+				"error", err.Error(),
+				"tip", "Set the channel ID instead, or ensure bot is admin in the channel")
+			return false
+		}
+		channelChatID = chat.ID
+	}
+
+	member, err := b.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{
+			ChatID: channelChatID,
+			UserID: chatID,
+		},
+	})
+	if err != nil {
+		b.log.Errorw("FAILED: Cannot check subscription - bot must be administrator in the channel!",
+			"chat_id", chatID,
+			"channel", key.channel,
+			"channel_id", channelChatID,
+			"error", err.Error(),
+			"solution", "Bot must be added as administrator to the channel with permission to view members")
+		return false
+	}
+
+	status := member.Status
+	isSubscribed := status == "member" || status == "administrator" || status == "creator"
+	b.log.Infow("subscription check result",
+		"chat_id", chatID,
+		"channel", key.channel,
+		"channel_id", channelChatID,
+		"user_status", status,
+		"is_subscribed", isSubscribed)
+
+	b.subscriptionCacheMu.Lock()
+	b.subscriptionCache[key] = subscriptionEntry{isSubscribed: isSubscribed, expiresAt: time.Now().Add(subscriptionTTL)}
+	b.subscriptionCacheMu.Unlock()
+	return isSubscribed
+}
+
+// resolveChannel returns Telegram's info about ch, asked once per channel:
+// its ID for a username, and its title and links for the join button.
+func (b *Bot) resolveChannel(ch Channel) (tgbotapi.Chat, error) {
+	b.subscriptionCacheMu.RLock()
+	chat, ok := b.channelChats[ch.key()]
+	b.subscriptionCacheMu.RUnlock()
+	if ok {
+		return chat, nil
+	}
+
+	cfg := tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: ch.ID}}
+	if ch.ID == 0 {
+		cfg.SuperGroupUsername = "@" + ch.Username
+	}
+	chat, err := b.api.GetChat(cfg)
+	if err != nil {
+		return tgbotapi.Chat{}, err
+	}
+	b.subscriptionCacheMu.Lock()
+	b.channelChats[ch.key()] = chat
+	b.subscriptionCacheMu.Unlock()
+	return chat, nil
+}
+
+// channelButton returns the name shown for ch and the link to join it, which
+// is empty if neither the configuration nor Telegram gives one.
+func (b *Bot) channelButton(chatID int64, ch Channel) (name, link string) {
+	link = ch.Link
+	if ch.Username != "" {
+		if link == "" {
+			link = "https://t.me/" + ch.Username
+		}
+		return "@" + ch.Username, link
+	}
+
+	// Only the ID is known: ask Telegram for the title and a public link
+	name = b.t(chatID, i18n.MsgChannelFallbackName, ch.ID)
+	chat, err := b.resolveChannel(ch)
+	if err != nil {
+		b.log.Warnw("cannot get channel info for the join button", "channel", ch.key(), "err", err)
+		return name, link
+	}
+	if chat.Title != "" {
+		name = chat.Title
+	}
+	if link == "" && chat.UserName != "" {
+		link = "https://t.me/" + chat.UserName
+	}
+	if link == "" {
+		link = chat.InviteLink
+	}
+	if link == "" {
+		b.log.Warnw("channel has no public link, set a join link for it", "channel", ch.key())
+	}
+	return name, link
+}
+
+// sendChannelSubscriptionMessage asks the user to join the channels they are
+// missing, with a join button for each.
+func (b *Bot) sendChannelSubscriptionMessage(chatID int64) {
+	missing := b.missingChannels(chatID)
+	if len(missing) == 0 {
+		missing = b.channels()
+	}
+	b.log.Infow("sending channel subscription message", "chat_id", chatID, "channels", channelKeys(missing))
+
+	var list strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, ch := range missing {
+		name, link := b.channelButton(chatID, ch)
+		fmt.Fprintf(&list, "📢 *%s*\n", escapeMarkdownV1(name))
+		if link == "" {
+			continue
+		}
+		label := b.t(chatID, i18n.BtnJoinChannel)
+		if len(missing) > 1 {
+			label = "📢 " + name
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(label, link)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCheckSubscription), CallbackCheckSubscription),
+	))
+
+	gate := i18n.MsgChannelGateOne
+	if len(missing) > 1 {
+		gate = i18n.MsgChannelGateMany
+	}
+	msg := b.t(chatID, gate, list.String())
+
+	message := tgbotapi.NewMessage(chatID, msg)
+	message.ParseMode = tgbotapi.ModeMarkdown
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.api.Send(message); err != nil {
+		b.log.Errorw("failed to send subscription message",
+			"chat_id", chatID,
+			"error", err.Error())
+	}
+}
+
+// forgetSubscription drops the user's cached memberships, so that the next
+// check asks Telegram.
+func (b *Bot) forgetSubscription(chatID int64) {
+	b.subscriptionCacheMu.Lock()
+	for key := range b.subscriptionCache {
+		if key.userID == chatID {
+			delete(b.subscriptionCache, key)
+		}
+	}
+	b.subscriptionCacheMu.Unlock()
+}
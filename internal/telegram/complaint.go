@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+)
+
+// complaintStatusLabel names a complaint status for the history.
+func complaintStatusLabel(lang, status string) string {
+	switch status {
+	case storage.ComplaintFiled:
+		return i18n.T(lang, i18n.MsgComplaintFiled)
+	case storage.ComplaintAccepted:
+		return i18n.T(lang, i18n.MsgComplaintAccepted)
+	case storage.ComplaintRejected:
+		return i18n.T(lang, i18n.MsgComplaintRejected)
+	case storage.ComplaintFailed:
+		return i18n.T(lang, i18n.MsgComplaintFailed)
+	default:
+		return escapeMarkdownV1(status)
+	}
+}
+
+// handleComplainPick shows the complaint reasons WB accepts for the review
+// with the given ID ("browse_cmp:<id>") in place of the browser page.
+func (b *Bot) handleComplainPick(chatID int64, messageID int, id string, ctx context.Context) {
+	fb, i, ok := b.browsedFeedback(chatID, id)
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if !ok || cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	reasons, err := b.wbClientFor(cfg).ComplaintReasons(wbCtx)
+	if err != nil || len(reasons) == 0 {
+		b.log.Warnw("failed to load complaint reasons", "chat_id", chatID, "err", err)
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgComplaintReasonsFailed)+reason)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, r := range reasons {
+		if row := b.appendCallbackButton(nil, r.Text, CallbackComplainRsnPrefix, strconv.Itoa(r.ID), fb.ID); len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	if row := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnBackToReview), CallbackBrowsePrefix, strconv.Itoa(i)); len(row) > 0 {
+		rows = append(rows, row)
+	}
+	text := b.t(chatID, i18n.MsgComplaintPrompt, browseReview(formatterFor(cfg), fb))
+	b.editBrowseMessage(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleComplain files a complaint with the reason ID on the review with the
+// given ID ("browse_cmpr:<reason>:<id>") and shows the review again with the
+// result.
+func (b *Bot) handleComplain(chatID int64, messageID int, reasonStr, id string, ctx context.Context) {
+	reasonID, err := strconv.Atoi(reasonStr)
+	fb, i, ok := b.browsedFeedback(chatID, id)
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil || !ok || cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, 2*wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	client := b.wbClientFor(cfg)
+	// The reason text is stored for the history; the button only carries its ID.
+	reason := wbapi.ComplaintReason{ID: reasonID}
+	if reasons, err := client.ComplaintReasons(wbCtx); err == nil {
+		for _, r := range reasons {
+			if r.ID == reasonID {
+				reason = r
+			}
+		}
+	}
+	if err := service.Complain(wbCtx, client, b.userStore, chatID, fb, reason, b.log); err != nil {
+		msg := describeWBError(b.lang(chatID), err)
+		if msg == "" {
+			msg = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgComplaintNotFiled)+msg)
+		return
+	}
+
+	list := b.markComplained(chatID, id, reasonID)
+	if list == nil {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+	text, keyboard := b.browsePage(chatID, list, i)
+	b.editBrowseMessage(chatID, messageID, b.t(chatID, i18n.MsgComplaintSent)+text, keyboard)
+}
+
+// markComplained records the complaint in the user's browser snapshot so the
+// page shows it without reloading from WB.
+func (b *Bot) markComplained(chatID int64, id string, reasonID int) *browseList {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	list := b.browse[chatID]
+	if list == nil {
+		return nil
+	}
+	feedbacks := make([]wbapi.Feedback, len(list.feedbacks))
+	copy(feedbacks, list.feedbacks)
+	for i := range feedbacks {
+		if feedbacks[i].ID == id {
+			feedbacks[i].SupplierFeedbackValuation = reasonID
+		}
+	}
+	list = &browseList{feedbacks: feedbacks, total: list.total, f: list.f}
+	b.browse[chatID] = list
+	return list
+}
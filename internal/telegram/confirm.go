@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// adminConfirmTTL is how long a confirmation button for a destructive admin
+// action stays valid.
+const adminConfirmTTL = 2 * time.Minute
+
+// pendingAdminAction is a destructive admin action waiting for the second
+// step. It is keyed by a random single-use token carried in the buttons.
+type pendingAdminAction struct {
+	adminID int64
+	summary string // plain text, shown once the action is confirmed or cancelled
+	expires time.Time
+	run     func()
+}
+
+// confirmAdminAction asks the admin to confirm run with inline buttons instead
+// of running it right away. prompt is Markdown and should say what exactly
+// will happen; summary is a short plain-text label for the action.
+func (b *Bot) confirmAdminAction(chatID int64, prompt, summary string, run func()) {
+	token, err := newConfirmToken()
+	if err != nil {
+		b.log.Errorw("failed to generate confirmation token", "err", err)
+		b.SendMessage(chatID, "❌ Не удалось подготовить подтверждение. Попробуйте позже.")
+		return
+	}
+
+	now := time.Now()
+	b.pendingMu.Lock()
+	for t, p := range b.pendingActions {
+		if now.After(p.expires) {
+			delete(b.pendingActions, t)
+		}
+	}
+	b.pendingActions[token] = pendingAdminAction{
+		adminID: chatID,
+		summary: summary,
+		expires: now.Add(adminConfirmTTL),
+		run:     run,
+	}
+	b.pendingMu.Unlock()
+
+	row := b.appendCallbackButton(nil, "✅ Подтвердить", CallbackAdminYesPrefix, token)
+	row = b.appendCallbackButton(row, "❌ Отмена", CallbackAdminNoPrefix, token)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	b.SendMessageWithKeyboard(chatID, prompt+"\n\n⌛ Кнопка подтверждения действует 2 минуты.", keyboard)
+	b.log.Infow("admin action awaiting confirmation", "admin_id", chatID, "action", summary)
+}
+
+// handleAdminConfirmCallback runs or drops a pending action. The prompt
+// message is edited so its buttons cannot be pressed again. Only the admin
+// who started the action uses up its token; a press by anyone else leaves it
+// pending unless it has expired.
+func (b *Bot) handleAdminConfirmCallback(chatID int64, messageID int, confirmed bool, token string) {
+	admin := b.isAdmin(chatID)
+	b.pendingMu.Lock()
+	p, ok := b.pendingActions[token]
+	owner := ok && admin && p.adminID == chatID
+	if owner || (ok && time.Now().After(p.expires)) {
+		delete(b.pendingActions, token)
+	}
+	b.pendingMu.Unlock()
+
+	var result string
+	execute := false
+	switch {
+	case !owner:
+		result = "⌛ Подтверждение недействительно. Повторите команду."
+	case time.Now().After(p.expires):
+		result = "⌛ Время подтверждения истекло: " + p.summary + ". Повторите команду."
+	case !confirmed:
+		result = "❌ Отменено: " + p.summary
+	default:
+		result = "✅ Подтверждено: " + p.summary
+		execute = true
+	}
+
+	if _, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, messageID, result)); err != nil {
+		b.log.Debugw("failed to edit confirmation message", "chat_id", chatID, "err", err)
+		b.SendMessage(chatID, escapeMarkdownV1(result))
+	}
+	if execute {
+		b.log.Infow("admin action confirmed", "admin_id", chatID, "action", p.summary)
+		p.run()
+	}
+}
+
+func newConfirmToken() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"strings"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+)
+
+// rejectContent tells the user which fragments of text WB would not accept
+// in an answer and reports whether there were any; the dialog stays open for
+// a corrected text.
+func (b *Bot) rejectContent(chatID int64, text string) bool {
+	violations := content.Check(text)
+	if len(violations) == 0 {
+		return false
+	}
+	b.log.Infow("text refused by the content check", "chat_id", chatID, "violations", len(violations))
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgContentRejected, formatViolations(b.lang(chatID), violations)),
+		b.CreateCancelKeyboard(chatID))
+	return true
+}
+
+// formatViolations lists the offending fragments, one per line.
+func formatViolations(lang string, violations []content.Violation) string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "• " + violationLabel(lang, v.Rule) + ": " + escapeMarkdownV1(v.Fragment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// violationFragments is formatViolations on one line, for error texts.
+func violationFragments(lang string, violations []content.Violation) string {
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		parts[i] = violationLabel(lang, v.Rule) + " «" + v.Fragment + "»"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func violationLabel(lang, rule string) string {
+	switch rule {
+	case content.RuleLink:
+		return i18n.T(lang, i18n.MsgRuleLink)
+	case content.RuleEmail:
+		return i18n.T(lang, i18n.MsgRuleEmail)
+	case content.RulePhone:
+		return i18n.T(lang, i18n.MsgRulePhone)
+	case content.RuleHandle:
+		return i18n.T(lang, i18n.MsgRuleHandle)
+	case content.RuleMention:
+		return i18n.T(lang, i18n.MsgRuleMention)
+	}
+	return rule
+}
@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// A user's place in a multi-step dialog (waiting for a token, a template,
+// the text of a custom reply...) is stored as a conversation, so that the
+// next message is understood after a restart or on another replica.
+// Conversations untouched for storage.ConversationTTL are treated as
+// finished. The config draft kept during the setup is not stored: each input
+// handler rebuilds it from user_configs, and storing it would put the WB
+// token outside the encrypted column.
+
+// loadConversation returns the user's dialog state from storage and copies
+// it into the in-memory maps. If storage fails, the in-memory state is used.
+func (b *Bot) loadConversation(chatID int64) UserState {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conv, err := b.configStore.GetConversation(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load conversation, using the in-memory state", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_conversation")
+		b.mu.RLock()
+		defer b.mu.RUnlock()
+		return b.userStates[chatID]
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if conv == nil {
+		delete(b.userStates, chatID)
+		delete(b.editAnswerIDs, chatID)
+		delete(b.customReplyIDs, chatID)
+		delete(b.draftIDs, chatID)
+		delete(b.categoryIDs, chatID)
+		return StateIdle
+	}
+	state := UserState(conv.State)
+	b.userStates[chatID] = state
+	switch state {
+	case StateWaitingEditAnswerText:
+		b.editAnswerIDs[chatID] = conv.Subject
+	case StateWaitingCustomReply:
+		b.customReplyIDs[chatID] = conv.Subject
+	case StateWaitingDraftText:
+		b.draftIDs[chatID] = conv.Subject
+	case StateWaitingCategoryGood, StateWaitingCategoryBad:
+		b.categoryIDs[chatID] = conv.Subject
+	}
+	return state
+}
+
+// setConversation moves the user to state; subject is the review (or, for
+// category replies, the WB subject) the dialog is about, for states that
+// need one. StateIdle ends the dialog.
+func (b *Bot) setConversation(chatID int64, state UserState, subject string) {
+	if state == StateIdle {
+		b.resetUserState(chatID)
+		return
+	}
+	b.mu.Lock()
+	b.userStates[chatID] = state
+	switch state {
+	case StateWaitingEditAnswerText:
+		b.editAnswerIDs[chatID] = subject
+	case StateWaitingCustomReply:
+		b.customReplyIDs[chatID] = subject
+	case StateWaitingDraftText:
+		b.draftIDs[chatID] = subject
+	case StateWaitingCategoryGood, StateWaitingCategoryBad:
+		b.categoryIDs[chatID] = subject
+	}
+	b.mu.Unlock()
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conv := storage.Conversation{UserID: chatID, State: int(state), Subject: subject}
+	if err := b.configStore.SaveConversation(dbCtx, conv); err != nil {
+		b.log.Warnw("failed to save conversation", "chat_id", chatID, "state", state, "err", err)
+		metrics.IncrementDatabaseError("save_conversation")
+	}
+}
+
+// deleteConversation removes the user's stored dialog state.
+func (b *Bot) deleteConversation(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.DeleteConversation(dbCtx, chatID); err != nil {
+		b.log.Warnw("failed to delete conversation", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("delete_conversation")
+	}
+}
+
+// pruneConversations deletes expired dialogs; run with the hourly cleanup.
+func (b *Bot) pruneConversations() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	n, err := b.configStore.PruneConversations(dbCtx)
+	if err != nil {
+		b.log.Warnw("failed to prune conversations", "err", err)
+		metrics.IncrementDatabaseError("prune_conversations")
+		return
+	}
+	if n > 0 {
+		b.log.Debugw("expired conversations pruned", "count", n)
+	}
+}
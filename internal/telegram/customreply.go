@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/wbapi"
+)
+
+// handleCustomReplyPick starts writing a one-off reply to the review with
+// the given ID ("browse_own:<id>").
+func (b *Bot) handleCustomReplyPick(chatID int64, id string) {
+	fb, _, ok := b.browsedFeedback(chatID, id)
+	if !ok {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+
+	b.setConversation(chatID, StateWaitingCustomReply, id)
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgOwnAnswerPrompt, browseReview(formatterFor(cfg), fb)),
+		b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleCustomReplyInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateEmpty), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) < 10:
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) > content.MaxLength:
+		// Posted as is, without a signature
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, content.MaxLength), b.CreateCancelKeyboard(chatID))
+		return
+	case !utf8.ValidString(text):
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	case b.rejectContent(chatID, text):
+		return
+	}
+
+	b.mu.RLock()
+	id := b.customReplyIDs[chatID]
+	b.mu.RUnlock()
+	b.resetUserState(chatID)
+	fb, i, ok := b.browsedFeedback(chatID, id)
+	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	if !ok || cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.sendBrowseExpired(chatID)
+		return
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	err := b.userService(chatID, cfg).AnswerCustom(wbCtx, fb, text)
+	switch {
+	case errors.Is(err, service.ErrNoAccess):
+		b.requireAccess(chatID)
+		return
+	case errors.Is(err, service.ErrDailyLimit):
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDailyLimitReached), b.CreateMainMenuForUser(chatID))
+		return
+	case errors.Is(err, service.ErrAlreadyAnswered):
+		b.dropBrowsed(chatID, id)
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgOwnAnswerAlreadyAnswered), b.CreateMainMenuForUser(chatID))
+		return
+	case err != nil:
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgAnswerNotSent)+reason, b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	keyboard := b.browseNextKeyboard(chatID, b.dropBrowsed(chatID, id), i)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgOwnAnswerPosted), keyboard)
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// digestWeekday and digestHour are when, in the user's timezone,
+// SendWeeklyDigests sends the summary of the preceding week.
+const (
+	digestWeekday = time.Monday
+	digestHour    = 10
+)
+
+// SendWeeklyDigests sends every user with a running service a summary of the
+// past Monday–Sunday in the user's timezone: answers, ratings, negative
+// reviews and answers that still could not be posted. Users without any of
+// these get nothing. Intended to be run by an hourly scheduler; each user is
+// sent the digest on digestWeekday during digestHour of their local time.
+func (b *Bot) SendWeeklyDigests(ctx context.Context) {
+	b.svcMu.RLock()
+	chatIDs := make([]int64, 0, len(b.services))
+	for chatID := range b.services {
+		chatIDs = append(chatIDs, chatID)
+	}
+	b.svcMu.RUnlock()
+
+	now := time.Now()
+	limiter := rate.NewLimiter(rate.Limit(broadcastRate), 1)
+	sent := 0
+	for _, chatID := range chatIDs {
+		msg, ok := b.weeklyDigest(ctx, chatID, now)
+		if !ok {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
+			b.log.Debugw("weekly digest: delivery failed", "chat_id", chatID, "err", err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		b.log.Infow("weekly digest sent", "users", len(chatIDs), "sent", sent)
+	}
+}
+
+// weeklyDigest renders the user's digest; ok is false when it is not yet the
+// digest hour in the user's timezone, there is nothing to report or the data
+// cannot be loaded.
+func (b *Bot) weeklyDigest(ctx context.Context, chatID int64, now time.Time) (msg string, ok bool) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	f := formatterFor(cfg)
+	loc := f.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	if local.Weekday() != digestWeekday || local.Hour() != digestHour {
+		return "", false
+	}
+	y, m, d := local.Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	from := to.AddDate(0, 0, -7)
+
+	sum, err := b.userStore.PeriodSummary(dbCtx, chatID, from, to)
+	if err != nil {
+		b.log.Warnw("weekly digest: failed to load summary", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("period_summary")
+		return "", false
+	}
+	stuck := b.stuckAnswers(dbCtx, chatID)
+	if sum.Reviews == 0 && sum.Questions == 0 && stuck == 0 {
+		return "", false
+	}
+	return formatDigest(f, from, to.AddDate(0, 0, -1), sum, stuck), true
+}
+
+// formatDigest renders a weekly digest for the days first to last inclusive.
+func formatDigest(f locale.Formatter, first, last time.Time, sum storage.PeriodSummary, stuck int) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgDigestTitle, f.Date(first), f.Date(last)))
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryReviews, f.Count(sum.Reviews)))
+	if sum.AvgRating > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryAvgRating, sum.AvgRating))
+		for stars := 5; stars >= 1; stars-- {
+			if n := sum.ByRating[stars-1]; n > 0 {
+				fmt.Fprintf(&sb, "%s %s\n", strings.Repeat("⭐", stars), f.Count(n))
+			}
+		}
+	}
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryNegative, f.Count(sum.Negative())))
+	if sum.Questions > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryQuestions, f.Count(sum.Questions)))
+	}
+	if stuck > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgDigestStuck, stuck))
+	}
+	return sb.String()
+}
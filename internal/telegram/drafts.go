@@ -0,0 +1,238 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// EnableDrafts lets users have replies to negative reviews drafted by d and
+// posted only on their approval. Without it the setting is not offered.
+func (b *Bot) EnableDrafts(d service.Drafter) {
+	b.drafter = d
+}
+
+// draftsOption holds the user's negative reviews for approval; nil if the
+// user has not turned drafts on or AI is not configured.
+func (b *Bot) draftsOption(chatID int64, cfg *storage.UserConfig) service.Option {
+	if b.drafter == nil || !cfg.AIDrafts {
+		return nil
+	}
+	return service.WithDrafts(b.drafter, func(fb wbapi.Feedback, d storage.Draft) {
+		b.sendDraft(chatID, fb, d)
+	})
+}
+
+// sendDraft shows the user a draft with the buttons deciding its fate.
+func (b *Bot) sendDraft(chatID int64, fb wbapi.Feedback, d storage.Draft) {
+	msg := b.t(chatID, i18n.MsgDraftTitle) + browseReview(b.chatFormatter(chatID), fb) +
+		b.t(chatID, i18n.MsgBrowseAnswer) + escapeMarkdownV1(d.Text)
+	decide := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnDraftSend), CallbackDraftSendPrefix, d.FeedbackID)
+	decide = b.appendCallbackButton(decide, b.t(chatID, i18n.BtnDraftEdit), CallbackDraftEditPrefix, d.FeedbackID)
+	skip := b.appendCallbackButton(nil, b.t(chatID, i18n.BtnDraftSkip), CallbackDraftSkipPrefix, d.FeedbackID)
+	if len(decide) == 0 {
+		// The buttons share the feedback ID: without them the draft cannot
+		// be acted on, so it is only shown.
+		msg += b.t(chatID, i18n.MsgDraftNoButtons)
+		if err := b.SendMessage(chatID, msg); err != nil {
+			b.log.Warnw("drafts: delivery failed", "chat_id", chatID, "id", d.FeedbackID, "err", err)
+		}
+		return
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(decide, skip)
+	if err := b.SendMessageWithKeyboard(chatID, msg, keyboard); err != nil {
+		b.log.Warnw("drafts: delivery failed", "chat_id", chatID, "id", d.FeedbackID, "err", err)
+	}
+}
+
+func (b *Bot) handleDraftsButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if b.drafter == nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDraftsUnavailable), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := b.t(chatID, i18n.MsgDraftsOff)
+	button := tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOn), CallbackDraftsOn)
+	if cfg.AIDrafts {
+		status = b.t(chatID, i18n.MsgDraftsOn)
+		button = tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOff), CallbackDraftsOff)
+	}
+	msg := b.t(chatID, i18n.MsgDraftsPrompt, status)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handleDraftsToggle(chatID int64, on bool, ctx context.Context) {
+	if on && b.drafter == nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDraftsUnavailable), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if err := b.configStore.SetAIDrafts(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save ai drafts", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgDraftsDisabled)
+	if on {
+		msg = b.t(chatID, i18n.MsgDraftsEnabled)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+// handleDraftSend posts the draft as is ("draft_send:<id>").
+func (b *Bot) handleDraftSend(chatID int64, messageID int, id string, ctx context.Context) {
+	if b.postDraft(chatID, id, "", ctx) {
+		b.clearDraftButtons(chatID, messageID)
+	}
+}
+
+// handleDraftSkip leaves the review unanswered ("draft_skip:<id>").
+func (b *Bot) handleDraftSkip(chatID int64, messageID int, id string, ctx context.Context) {
+	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	if cfg == nil {
+		b.showMainMenu(chatID)
+		return
+	}
+	err := b.userService(chatID, cfg).SkipDraft(ctx, id)
+	switch {
+	case errors.Is(err, service.ErrDraftResolved):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftResolved))
+	case err != nil:
+		b.log.Errorw("failed to skip draft", "chat_id", chatID, "id", id, "err", err)
+		metrics.IncrementDatabaseError("resolve_draft")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+		return
+	default:
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftSkipped))
+	}
+	b.clearDraftButtons(chatID, messageID)
+}
+
+// handleDraftEdit asks for the text to post instead of the draft
+// ("draft_edit:<id>").
+func (b *Bot) handleDraftEdit(chatID int64, id string, ctx context.Context) {
+	d, err := b.userStore.GetDraft(ctx, chatID, id)
+	if err != nil {
+		b.log.Errorw("failed to load draft", "chat_id", chatID, "id", id, "err", err)
+		metrics.IncrementDatabaseError("get_draft")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+		return
+	}
+	if d == nil || d.Status != storage.DraftPending {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftResolved))
+		return
+	}
+
+	b.setConversation(chatID, StateWaitingDraftText, id)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDraftEditPrompt, strings.ReplaceAll(d.Text, "`", "'")),
+		b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleDraftTextInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateEmpty), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) < 10:
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) > content.MaxLength:
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, content.MaxLength), b.CreateCancelKeyboard(chatID))
+		return
+	case !utf8.ValidString(text):
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	case b.rejectContent(chatID, text):
+		return
+	}
+
+	b.mu.RLock()
+	id := b.draftIDs[chatID]
+	b.mu.RUnlock()
+	b.resetUserState(chatID)
+	b.postDraft(chatID, id, text, ctx)
+}
+
+// postDraft posts the draft, or text in its place, and tells the user the
+// outcome. It reports whether the draft is settled, so that its buttons
+// can be removed.
+func (b *Bot) postDraft(chatID int64, id, text string, ctx context.Context) bool {
+	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.showMainMenu(chatID)
+		return false
+	}
+
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	err := b.userService(chatID, cfg).SendDraft(wbCtx, id, text)
+	switch {
+	case errors.Is(err, service.ErrDraftNotFound), errors.Is(err, service.ErrDraftResolved):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftResolved))
+		return true
+	case errors.Is(err, service.ErrAlreadyAnswered):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftAlreadyAnswered))
+		return true
+	case errors.Is(err, service.ErrNoAccess):
+		b.requireAccess(chatID)
+		return false
+	case errors.Is(err, service.ErrDailyLimit):
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftDailyLimit))
+		return false
+	case err != nil:
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftNotSent, reason))
+		return false
+	}
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgDraftPosted))
+	return true
+}
+
+// clearDraftButtons removes the buttons of a settled draft.
+func (b *Bot) clearDraftButtons(chatID int64, messageID int) {
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	if _, err := b.api.Send(edit); err != nil {
+		b.log.Debugw("failed to clear draft buttons", "chat_id", chatID, "err", err)
+	}
+}
+
+// aiDraftsDisplay renders the drafts setting for the info screen.
+func (b *Bot) aiDraftsDisplay(cfg *storage.UserConfig) string {
+	switch {
+	case b.drafter == nil:
+		return "недоступны"
+	case cfg.AIDrafts:
+		return "на одобрении ответы на 1–3★"
+	}
+	return "выключены"
+}
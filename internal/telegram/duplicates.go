@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// duplicateShareOption answers only a share of identical reviews when the
+// user turned it on.
+func duplicateShareOption(cfg *storage.UserConfig) service.Option {
+	if cfg.DuplicateShare <= 0 {
+		return nil
+	}
+	return service.WithDuplicateShare(cfg.DuplicateShare)
+}
+
+// duplicatesDisplay renders the user's duplicate setting and how many
+// reviews it has left unanswered, for the info screen.
+func (b *Bot) duplicatesDisplay(ctx context.Context, cfg *storage.UserConfig) string {
+	f := formatterFor(cfg)
+	if cfg.DuplicateShare <= 0 {
+		return i18n.T(f.Lang, i18n.MsgDuplicatesAll)
+	}
+	msg := i18n.T(f.Lang, i18n.MsgDuplicatesShare, cfg.DuplicateShare)
+	n, err := b.userStore.CountSkippedDuplicates(ctx, cfg.UserID)
+	if err != nil {
+		b.log.Warnw("failed to count skipped duplicates", "chat_id", cfg.UserID, "err", err)
+		metrics.IncrementDatabaseError("count_duplicates")
+		return msg
+	}
+	return msg + i18n.T(f.Lang, i18n.MsgDuplicatesSkipped, f.Count(n))
+}
+
+// parseDuplicateShare reads a percent like "30" or "30%"; "выкл", "off" and
+// 0 turn the mode off.
+func parseDuplicateShare(text string) (int, bool) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if s == "выкл" || s == "off" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(s, "%")))
+	if err != nil || n < 0 || n > 99 {
+		return 0, false
+	}
+	return n, true
+}
+
+func (b *Bot) handleDuplicatesButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingDuplicateShare)
+	msg := b.t(chatID, i18n.MsgDuplicatesPrompt,
+		b.duplicatesDisplay(dbCtx, cfg), service.DuplicateMinBurst)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleDuplicatesInput(chatID int64, text string, ctx context.Context) {
+	percent, ok := parseDuplicateShare(text)
+	if !ok {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgDuplicatesInvalid), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if err := b.configStore.SetDuplicateShare(ctx, chatID, percent); err != nil {
+		b.log.Errorw("failed to save duplicate share", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgDuplicatesOff)
+	if percent > 0 {
+		msg = b.t(chatID, i18n.MsgDuplicatesOn, percent)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,97 @@
+// End-to-end checks of the bot: telegram.Bot is driven through
+// telegramtest.API with services behind it talking to the fake WB API from
+// wbapitest and a real SQLite store.
+package telegram_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/internal/wbapi/wbapitest"
+)
+
+// userID is the seller most scenarios act as.
+const userID int64 = 1
+
+// Env is the world a scenario runs in: a fresh fake WB server and an empty
+// SQLite store.
+type Env struct {
+	Server *wbapitest.Server
+	Store  storage.Store
+	Config storage.ConfigStore // same database as Store
+	Log    *zap.SugaredLogger
+}
+
+// Client returns a WB client pointed at the fake server.
+func (e *Env) Client(token string) *wbapi.Client {
+	return wbapi.New(token, wbapi.WithBaseURL(e.Server.URL), wbapi.WithLogger(e.Log))
+}
+
+// Service returns a service for userID with the fixture templates.
+func (e *Env) Service(opts ...service.Option) *service.Service {
+	return service.New(userID, e.Client(testToken), e.Store, badText, goodText, e.Log, 100, opts...)
+}
+
+// e2eTimeout bounds a single scenario.
+const e2eTimeout = time.Minute
+
+// scenario is one end-to-end check. run returns an error describing the
+// first expectation that did not hold.
+type scenario struct {
+	name string
+	run  func(ctx context.Context, env *Env) error
+}
+
+// runScenarios runs each scenario as a subtest with its own fake WB server
+// and SQLite database. Logs are shown with go test -v.
+func runScenarios(t *testing.T, scenarios []scenario) {
+	for _, sc := range scenarios {
+		t.Run(sc.name, func(t *testing.T) {
+			st, cfg, err := storage.NewSQLite(filepath.Join(t.TempDir(), "e2e.db"), nil)
+			if err != nil {
+				t.Fatalf("open store: %v", err)
+			}
+			defer st.Close()
+			srv := wbapitest.NewServer(testToken)
+			defer srv.Close()
+
+			log := zap.NewNop().Sugar()
+			if testing.Verbose() {
+				log = zaptest.NewLogger(t).Sugar()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), e2eTimeout)
+			defer cancel()
+			if err := sc.run(ctx, &Env{Server: srv, Store: st, Config: cfg, Log: log}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// expectAnswers checks that the server accepted exactly the given replies,
+// keyed by feedback ID.
+func expectAnswers(srv *wbapitest.Server, want map[string]string) error {
+	got := srv.Answers()
+	if len(got) != len(want) {
+		return fmt.Errorf("answers = %v, want %d", got, len(want))
+	}
+	for _, a := range got {
+		text, ok := want[a.ID]
+		if !ok {
+			return fmt.Errorf("unexpected answer to %s", a.ID)
+		}
+		if a.Text != text {
+			return fmt.Errorf("answer to %s = %q, want %q", a.ID, a.Text, text)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// editAnswerChoices is how many recent answers are offered as buttons.
+const editAnswerChoices = 5
+
+// handleEditAnswerButton starts editing a posted answer: the user picks one
+// of the latest answers or pastes a review ID from the WB seller account.
+func (b *Bot) handleEditAnswerButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	answers, err := b.userStore.RecentAnswers(dbCtx, chatID, historyLimit)
+	if err != nil {
+		// The ID can still be pasted by hand.
+		b.log.Warnw("failed to get recent answers", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+	}
+
+	f := formatterFor(cfg)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, a := range answers {
+		if len(rows) == editAnswerChoices {
+			break
+		}
+		data := CallbackEditAnswerPrefix + a.FeedbackID
+		if a.Kind == storage.KindQuestion || len(data) > 64 {
+			continue
+		}
+		label := f.ShortDateTime(a.AnsweredAt)
+		if a.Rating > 0 {
+			label += fmt.Sprintf(" · %d★", a.Rating)
+		}
+		if a.SubjectName != "" {
+			label += " · " + a.SubjectName
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(b.lang(chatID), i18n.BtnCancel), CallbackCancel),
+	))
+
+	b.setUserState(chatID, StateWaitingEditAnswerID)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerPrompt), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleEditAnswerIDInput remembers the review to edit and asks for the new
+// text, showing the current one when it is stored locally. Recent answers
+// chosen with a button ("edit_ans:<id>") come here too.
+func (b *Bot) handleEditAnswerIDInput(chatID int64, text string) {
+	id := strings.TrimSpace(text)
+	if id == "" || strings.ContainsAny(id, " \t\n") || len(id) > 64 {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerBadID), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	b.setConversation(chatID, StateWaitingEditAnswerText, id)
+
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgEditAnswerTextPrompt, id))
+	if current := b.storedReply(chatID, id); current != "" {
+		// Plain text: answers may contain Markdown characters.
+		msg := tgbotapi.NewMessage(chatID, current)
+		msg.ReplyMarkup = b.CreateCancelKeyboard(chatID)
+		if _, err := b.api.Send(msg); err != nil {
+			b.log.Warnw("failed to send current answer", "chat_id", chatID, "err", err)
+			metrics.IncrementAPIError("telegram", "send_message")
+		}
+		return
+	}
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerNoCurrent), b.CreateCancelKeyboard(chatID))
+}
+
+// storedReply returns the locally stored text of the answer to id among the
+// latest answers, or "".
+func (b *Bot) storedReply(chatID int64, id string) string {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	answers, err := b.userStore.RecentAnswers(dbCtx, chatID, historyLimit)
+	if err != nil {
+		return ""
+	}
+	for _, a := range answers {
+		if a.FeedbackID == id {
+			return a.ReplyText
+		}
+	}
+	return ""
+}
+
+func (b *Bot) handleEditAnswerTextInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateEmpty), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) < 10:
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+		return
+	case len([]rune(text)) > content.MaxLength:
+		// Posted as is, without a signature
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, content.MaxLength), b.CreateCancelKeyboard(chatID))
+		return
+	case !utf8.ValidString(text):
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	case b.rejectContent(chatID, text):
+		return
+	}
+
+	b.mu.RLock()
+	id := b.editAnswerIDs[chatID]
+	b.mu.RUnlock()
+	cfg, _ := b.configStore.GetUserConfig(ctx, chatID)
+	if id == "" || cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.resetUserState(chatID)
+		b.showMainMenu(chatID)
+		return
+	}
+
+	client := b.wbClientFor(cfg)
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	err := service.EditAnswer(wbCtx, client, b.userStore, chatID, id, text, b.log)
+	if errors.Is(err, wbapi.ErrBadRequest) {
+		// Keep the state so that another ID can be tried without starting over.
+		b.setUserState(chatID, StateWaitingEditAnswerID)
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerRefused), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	b.resetUserState(chatID)
+	if err != nil {
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerFailed)+reason, b.CreateMainMenuForUser(chatID))
+		return
+	}
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEditAnswerDone, id), b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// templateSet reports whether text is a template the user entered rather
+// than the placeholder stored before setup is finished.
+func templateSet(text string) bool {
+	return text != "" && text != "Спасибо за ваш отзыв!"
+}
+
+// templateButton returns the add button for a missing template and the edit
+// button once it is set.
+func (b *Bot) templateButton(chatID int64, category, current string) tgbotapi.InlineKeyboardButton {
+	lang := b.lang(chatID)
+	switch {
+	case category == storage.VariantGood && templateSet(current):
+		return tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnEditGood), CallbackEditTemplateGood)
+	case category == storage.VariantGood:
+		return tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddGood), CallbackAddTemplateGood)
+	case templateSet(current):
+		return tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnEditBad), CallbackEditTemplateBad)
+	default:
+		return tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, i18n.BtnAddBad), CallbackAddTemplateBad)
+	}
+}
+
+// handleEditTemplateButton starts editing the good or bad template. The
+// current text is sent as a separate plain message so it can be copied,
+// changed and sent back.
+func (b *Bot) handleEditTemplateButton(chatID int64, category string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	current, title, state := cfg.TemplateGood, b.t(chatID, i18n.MsgEditTemplateGoodTitle), StateWaitingEditGood
+	if category == storage.VariantBad {
+		current, title, state = cfg.TemplateBad, b.t(chatID, i18n.MsgEditTemplateBadTitle), StateWaitingEditBad
+	}
+	if !templateSet(current) {
+		// Nothing to edit yet: fall back to the regular setup step.
+		if category == storage.VariantBad {
+			b.handleAddTemplateBadButton(chatID)
+		} else {
+			b.handleAddTemplateGoodButton(chatID)
+		}
+		return
+	}
+
+	b.setUserState(chatID, state)
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgEditTemplatePrompt, title, utf8.RuneCountInString(current)))
+
+	// Plain text: templates may contain Markdown characters.
+	msg := tgbotapi.NewMessage(chatID, current)
+	msg.ReplyMarkup = b.CreateCancelKeyboard(chatID)
+	if _, err := b.api.Send(msg); err != nil {
+		b.log.Warnw("failed to send current template", "chat_id", chatID, "err", err)
+		metrics.IncrementAPIError("telegram", "send_message")
+	}
+}
+
+func (b *Bot) handleEditTemplateInput(chatID int64, category, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if problem := b.templateProblem(chatID, text); problem != "" {
+		b.SendMessageWithKeyboard(chatID, problem, b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if b.rejectContent(chatID, text) {
+		return
+	}
+
+	if err := b.configStore.UpdateTemplate(ctx, chatID, category, text); err != nil {
+		b.log.Errorw("failed to update template", "chat_id", chatID, "category", category, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, category)
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgEditTemplateGoodSaved)
+	if category == storage.VariantBad {
+		msg = b.t(chatID, i18n.MsgEditTemplateBadSaved)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
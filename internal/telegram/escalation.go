@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// wbProductURL is the buyer-facing product page on Wildberries.
+const wbProductURL = "https://www.wildberries.ru/catalog/%d/detail.aspx"
+
+// escalationOption forwards answered 1–2★ reviews to the chat the user chose,
+// e.g. their support team's group.
+func (b *Bot) escalationOption(chatID int64, cfg *storage.UserConfig) service.Option {
+	if cfg.EscalationChatID == 0 {
+		return nil
+	}
+	target, f := cfg.EscalationChatID, formatterFor(cfg)
+	return service.WithEscalation(func(fb wbapi.Feedback, reply string) {
+		msg, keyboard := formatEscalation(f, fb, reply)
+		b.notify(chatID, notification{
+			target:   target,
+			kind:     "escalation",
+			title:    i18n.T(f.Lang, i18n.MsgEscalationTitle),
+			text:     msg,
+			keyboard: keyboard,
+			line:     reviewLine(f, fb),
+		})
+	})
+}
+
+// formatEscalation renders a negative review for the escalation chat: the
+// whole review, the product and the bot's answer, with a link to the product.
+func formatEscalation(f locale.Formatter, fb wbapi.Feedback, reply string) (string, tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgEscalationReview))
+	sb.WriteString(browseReview(f, fb))
+	sb.WriteString("\n")
+	if p := fb.ProductDetails; p.ProductName != "" || p.SupplierArticle != "" {
+		sb.WriteString("\n📦 " + escapeMarkdownV1(p.ProductName))
+		if p.SupplierArticle != "" {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgEscalationSellerArticle, escapeMarkdownV1(p.SupplierArticle)))
+		}
+	}
+	fmt.Fprintf(&sb, "\n🆔 `%s`", fb.ID)
+	if reply != "" {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgEscalationReply) + escapeMarkdownV1(clipReview(reply)))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if fb.ProductDetails.NmID != 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(i18n.T(f.Lang, i18n.BtnOpenProduct), fmt.Sprintf(wbProductURL, fb.ProductDetails.NmID)),
+		))
+	}
+	return sb.String(), tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func (b *Bot) handleEscalationButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := b.t(chatID, i18n.MsgEscalationOff)
+	if cfg.EscalationChatID != 0 {
+		status = b.t(chatID, i18n.MsgEscalationChat, cfg.EscalationChatID)
+	}
+	b.setUserState(chatID, StateWaitingEscalationChat)
+	msg := b.t(chatID, i18n.MsgEscalationPrompt, status, service.EscalationMaxRating)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleEscalationInput(chatID int64, text string, ctx context.Context) {
+	target, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEscalationBadID), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if target != 0 {
+		// A test message both checks the ID and shows the team what to expect
+		if err := b.SendMessage(target, b.t(chatID, i18n.MsgEscalationHello)); err != nil {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgEscalationUnreachable), b.CreateCancelKeyboard(chatID))
+			return
+		}
+	}
+
+	if err := b.configStore.SetEscalationChat(ctx, chatID, target); err != nil {
+		b.log.Errorw("failed to save escalation chat", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgEscalationDisabled)
+	if target != 0 {
+		msg = b.t(chatID, i18n.MsgEscalationEnabled, service.EscalationMaxRating, target)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,170 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// maxExclusionLines limits how many entries can be changed in one message.
+const maxExclusionLines = 50
+
+// exclusionOption loads the user's exclusions into a service option.
+// Returns nil if there are none or they cannot be loaded.
+func (b *Bot) exclusionOption(chatID int64) service.Option {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, err := b.configStore.ListExclusions(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load exclusions, ignoring", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_exclusions")
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	var nmIDs []int64
+	var feedbackIDs []string
+	for _, ex := range list {
+		switch ex.Kind {
+		case storage.ExclusionArticle:
+			if id, err := strconv.ParseInt(ex.Value, 10, 64); err == nil {
+				nmIDs = append(nmIDs, id)
+			}
+		case storage.ExclusionFeedback:
+			feedbackIDs = append(feedbackIDs, ex.Value)
+		}
+	}
+	return service.WithExclusions(nmIDs, feedbackIDs)
+}
+
+// parseExclusion turns user input into an exclusion: digits are an article
+// (nmId), anything else is a review ID.
+func parseExclusion(s string) (storage.Exclusion, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t") || len(s) > 64 {
+		return storage.Exclusion{}, false
+	}
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if id <= 0 {
+			return storage.Exclusion{}, false
+		}
+		return storage.Exclusion{Kind: storage.ExclusionArticle, Value: s}, true
+	}
+	return storage.Exclusion{Kind: storage.ExclusionFeedback, Value: s}, true
+}
+
+func formatExclusions(list []storage.Exclusion) string {
+	if len(list) == 0 {
+		return "список пуст"
+	}
+	var articles, reviews []string
+	for _, ex := range list {
+		if ex.Kind == storage.ExclusionArticle {
+			articles = append(articles, "`"+ex.Value+"`")
+		} else {
+			reviews = append(reviews, "`"+ex.Value+"`")
+		}
+	}
+	var sb strings.Builder
+	if len(articles) > 0 {
+		sb.WriteString("\n*Артикулы:* " + strings.Join(articles, ", "))
+	}
+	if len(reviews) > 0 {
+		sb.WriteString("\n*Отзывы:* " + strings.Join(reviews, ", "))
+	}
+	return sb.String()
+}
+
+func (b *Bot) handleExclusionsButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	list, err := b.configStore.ListExclusions(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list exclusions", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_exclusions")
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при получении исключений*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingExclusion)
+	msg := fmt.Sprintf(`🚫 *Исключения*
+
+Бот не будет отвечать на отзывы по этим артикулам и на отдельные отзывы.
+
+Сейчас: %s
+
+Отправьте артикул WB (nmId) или ID отзыва — по одному на строку.
+Чтобы убрать из исключений, поставьте минус перед значением.
+
+*Пример:*
+"12345678
+-87654321"`, formatExclusions(list))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleExclusionInput(chatID int64, text string, ctx context.Context) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > maxExclusionLines {
+		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Слишком много строк. За один раз можно изменить не более %d значений.", maxExclusionLines), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	var added, removed int
+	var invalid []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		remove := strings.HasPrefix(line, "-")
+		ex, ok := parseExclusion(strings.TrimPrefix(line, "-"))
+		if !ok {
+			invalid = append(invalid, line)
+			continue
+		}
+		var err error
+		if remove {
+			err = b.configStore.RemoveExclusion(ctx, chatID, ex)
+		} else {
+			err = b.configStore.AddExclusion(ctx, chatID, ex)
+		}
+		if err != nil {
+			b.log.Errorw("failed to save exclusion", "chat_id", chatID, "err", err)
+			metrics.IncrementDatabaseError("save_exclusion")
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+			b.resetUserState(chatID)
+			return
+		}
+		if remove {
+			removed++
+		} else {
+			added++
+		}
+	}
+	if added == 0 && removed == 0 {
+		b.SendMessageWithKeyboard(chatID, "⚠️ Не найдено ни одного артикула или ID отзыва. Пример: `12345678`", b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	msg := fmt.Sprintf("✅ Исключения обновлены: добавлено %d, удалено %d.", added, removed)
+	if len(invalid) > 0 {
+		msg += "\n\nНе распознано: " + escapeMarkdownV1(strings.Join(invalid, ", "))
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
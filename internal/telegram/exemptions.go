@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// idSet turns a list of user IDs into a set.
+func idSet(ids []int64) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// loadExemptions reads exemptions added at runtime from storage into
+// b.runtimeExempt.
+func (b *Bot) loadExemptions() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exemptions, err := b.configStore.ListExemptions(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to load subscription exemptions", "err", err)
+		metrics.IncrementDatabaseError("list_exemptions")
+		return
+	}
+
+	set := make(map[int64]struct{}, len(exemptions))
+	for _, e := range exemptions {
+		set[e.UserID] = struct{}{}
+	}
+	b.settingsMu.Lock()
+	b.runtimeExempt = set
+	b.settingsMu.Unlock()
+	b.log.Infow("subscription exemptions loaded", "runtime", len(set))
+}
+
+// isExempt reports whether the user skips the channel subscription check:
+// admins, users from SUBSCRIPTION_EXEMPT_IDS and those added with
+// "/admin exempt add".
+func (b *Bot) isExempt(chatID int64) bool {
+	if b.isAdmin(chatID) {
+		return true
+	}
+	b.settingsMu.RLock()
+	defer b.settingsMu.RUnlock()
+	if _, ok := b.configExempt[chatID]; ok {
+		return true
+	}
+	_, ok := b.runtimeExempt[chatID]
+	return ok
+}
+
+// handleAdminExemptCommand handles the exemption commands
+//
+//	/admin exempt            list exempt users
+//	/admin exempt add <id>   let the user through the subscription check
+//	/admin exempt del <id>   remove an exemption added with /admin exempt add
+func (b *Bot) handleAdminExemptCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	const usage = "Использование: `/admin exempt add <user_id>` или `/admin exempt del <user_id>`"
+
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if sub == "" {
+		b.sendExemptionList(chatID)
+		return
+	}
+	userID, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+	if err != nil || userID <= 0 {
+		b.SendMessage(chatID, usage)
+		return
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	switch sub {
+	case "add":
+		if err := b.configStore.AddExemption(dbCtx, userID, chatID); err != nil {
+			b.log.Errorw("failed to add subscription exemption", "user_id", userID, "err", err)
+			metrics.IncrementDatabaseError("add_exemption")
+			b.SendMessage(chatID, fmt.Sprintf("❌ Не удалось освободить `%d` от проверки подписки.", userID))
+			return
+		}
+		b.settingsMu.Lock()
+		b.runtimeExempt[userID] = struct{}{}
+		b.settingsMu.Unlock()
+		b.log.Infow("subscription exemption added", "admin_id", chatID, "user_id", userID)
+		b.SendMessage(chatID, fmt.Sprintf("✅ Пользователь `%d` может пользоваться ботом без подписки на каналы.", userID))
+	case "del":
+		existed, err := b.configStore.RemoveExemption(dbCtx, userID)
+		if err != nil {
+			b.log.Errorw("failed to remove subscription exemption", "user_id", userID, "err", err)
+			metrics.IncrementDatabaseError("remove_exemption")
+			b.SendMessage(chatID, fmt.Sprintf("❌ Не удалось убрать исключение для `%d`.", userID))
+			return
+		}
+		b.settingsMu.Lock()
+		delete(b.runtimeExempt, userID)
+		_, configured := b.configExempt[userID]
+		b.settingsMu.Unlock()
+		// Answers cached before the exemption may be stale
+		b.forgetSubscription(userID)
+
+		switch {
+		case configured:
+			b.SendMessage(chatID, fmt.Sprintf("⚠️ Пользователь `%d` задан в `SUBSCRIPTION_EXEMPT_IDS`. Уберите его из настроек и перечитайте их (SIGHUP).", userID))
+		case !existed:
+			b.SendMessage(chatID, fmt.Sprintf("Пользователь `%d` не был в исключениях.", userID))
+		default:
+			b.log.Infow("subscription exemption removed", "admin_id", chatID, "user_id", userID)
+			b.SendMessage(chatID, fmt.Sprintf("🔒 Пользователь `%d` снова должен быть подписан на каналы.", userID))
+		}
+	default:
+		b.SendMessage(chatID, usage)
+	}
+}
+
+// sendExemptionList shows users exempt from the subscription check.
+func (b *Bot) sendExemptionList(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runtime, err := b.configStore.ListExemptions(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to list subscription exemptions", "err", err)
+		metrics.IncrementDatabaseError("list_exemptions")
+		b.SendMessage(chatID, "❌ Не удалось получить список исключений. Попробуйте позже.")
+		return
+	}
+
+	b.settingsMu.RLock()
+	configured := make([]int64, 0, len(b.configExempt))
+	for id := range b.configExempt {
+		configured = append(configured, id)
+	}
+	b.settingsMu.RUnlock()
+	slices.Sort(configured)
+
+	f := b.chatFormatter(chatID)
+	var sb strings.Builder
+	sb.WriteString("🎫 *Без проверки подписки*\n\nАдминистраторы — всегда.\n")
+	for _, id := range configured {
+		fmt.Fprintf(&sb, "• `%d` — из настроек\n", id)
+	}
+	for _, e := range runtime {
+		if slices.Contains(configured, e.UserID) {
+			continue
+		}
+		fmt.Fprintf(&sb, "• `%d` — добавил `%d` %s\n", e.UserID, e.AddedBy, f.DateTime(e.AddedAt))
+	}
+	sb.WriteString("\nДобавить: `/admin exempt add <user_id>`\nУбрать: `/admin exempt del <user_id>`")
+	b.SendMessage(chatID, sb.String())
+}
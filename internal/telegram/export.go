@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/export"
+	"feedback_bot/pkg/metrics"
+)
+
+// handleExportHistory sends all of the user's answers as a CSV document.
+func (b *Bot) handleExportHistory(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	answers, err := b.userStore.ListAnswers(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list answers for export", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при выгрузке истории*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+	if len(answers) == 0 {
+		b.SendMessageWithKeyboard(chatID, "📥 Выгружать пока нечего: бот ещё не ответил ни на один отзыв.", b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	f := formatterFor(cfg)
+	loc := f.Loc
+	if loc == nil {
+		loc = time.Local
+	}
+	var buf bytes.Buffer
+	if err := export.AnswersCSV(&buf, answers, loc); err != nil {
+		b.log.Errorw("failed to render history CSV", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, "❌ *Ошибка при выгрузке истории*\n\nПопробуйте позже.", b.CreateMainMenu(chatID))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("history_%s.csv", time.Now().In(loc).Format("2006-01-02")),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = fmt.Sprintf("📥 История ответов: %s записей.\nФайл открывается в Excel и Google Таблицах.", f.Count(int64(len(answers))))
+	if _, err := b.api.Send(doc); err != nil {
+		b.log.Errorw("failed to send history export", "chat_id", chatID, "err", err)
+		metrics.IncrementAPIError("telegram", "send_document")
+		b.SendMessage(chatID, "❌ Не удалось отправить файл. Попробуйте позже.")
+		return
+	}
+	b.log.Infow("history exported", "chat_id", chatID, "rows", len(answers))
+}
@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const failuresLimit = 20
+
+// handleFailures shows the user's recent failed answers and cycle errors
+// with an explanation and what to do about each.
+func (b *Bot) handleFailures(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	failures, err := b.userStore.RecentFailures(dbCtx, chatID, failuresLimit)
+	if err != nil {
+		b.log.Warnw("failed to get failures", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_failures")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgFailuresLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatFailures(formatterFor(cfg), failures))
+	if cfg != nil && cfg.Paused {
+		sb.WriteString(b.t(chatID, i18n.MsgFailuresPaused))
+	} else if cfg != nil && b.getServiceForUser(chatID) == nil {
+		sb.WriteString(b.t(chatID, i18n.MsgFailuresNotRunning))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, sb.String(), keyboard)
+}
+
+// formatFailures renders failures newest first. Consecutive repeats of the
+// same cycle-level error (e.g. an expired token failing every cycle) are
+// shown once with a counter.
+func formatFailures(f locale.Formatter, failures []storage.Failure) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgFailuresTitle))
+
+	if len(failures) == 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgFailuresNone, f.Days(storage.FailureRetention)))
+		return sb.String()
+	}
+
+	for i := 0; i < len(failures); {
+		fl := failures[i]
+		n := 1
+		for i+n < len(failures) && sameCycleFailure(fl, failures[i+n]) {
+			n++
+		}
+		i += n
+
+		cause, fix := failureHelp(f.Lang, fl.Cause)
+		sb.WriteString("\n")
+		sb.WriteString(f.ShortDateTime(fl.CreatedAt))
+		sb.WriteString(" · " + failureStageLabel(f.Lang, fl))
+		if fl.FeedbackID != "" {
+			sb.WriteString(" `" + fl.FeedbackID + "`") // WB IDs are alphanumeric
+		}
+		if n > 1 {
+			sb.WriteString(fmt.Sprintf(" (×%d)", n))
+		}
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgFailureCause) + cause)
+		if fix != "" {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgFailureFix) + fix)
+		}
+		sb.WriteString("\n")
+	}
+	n := int64(len(failures))
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgFailuresShown, n, i18n.Plural(f.Lang, i18n.MsgFailureEntries, n), f.Days(storage.FailureRetention)))
+	return sb.String()
+}
+
+// sameCycleFailure reports whether b repeats the cycle-level failure a.
+func sameCycleFailure(a, b storage.Failure) bool {
+	return a.FeedbackID == "" && b.FeedbackID == "" &&
+		a.Kind == b.Kind && a.Stage == b.Stage && a.Cause == b.Cause
+}
+
+func failureStageLabel(lang string, fl storage.Failure) string {
+	question := fl.Kind == storage.KindQuestion
+	switch fl.Stage {
+	case service.StageFetch:
+		if question {
+			return i18n.T(lang, i18n.MsgStageFetchQuestions)
+		}
+		return i18n.T(lang, i18n.MsgStageFetchReviews)
+	case service.StageCheck:
+		return i18n.T(lang, i18n.MsgStageCheck)
+	case service.StageAnswer:
+		if question {
+			return i18n.T(lang, i18n.MsgStageAnswerQuestion)
+		}
+		return i18n.T(lang, i18n.MsgStageAnswerReview)
+	case service.StageSave:
+		return i18n.T(lang, i18n.MsgStageSave)
+	}
+	return fl.Stage
+}
+
+// failureHelp returns a human-readable cause and a suggested fix in lang.
+func failureHelp(lang, cause string) (string, string) {
+	switch cause {
+	case service.CauseUnauthorized:
+		return i18n.T(lang, i18n.MsgCauseUnauthorized),
+			i18n.T(lang, i18n.MsgFixUnauthorized)
+	case service.CauseForbidden:
+		return i18n.T(lang, i18n.MsgCauseForbidden),
+			i18n.T(lang, i18n.MsgFixForbidden)
+	case service.CauseRateLimited:
+		return i18n.T(lang, i18n.MsgCauseRateLimited),
+			i18n.T(lang, i18n.MsgFixRateLimited)
+	case service.CauseServer:
+		return i18n.T(lang, i18n.MsgCauseServer),
+			i18n.T(lang, i18n.MsgFixNextCycle)
+	case service.CauseBadRequest:
+		return i18n.T(lang, i18n.MsgCauseBadRequest),
+			i18n.T(lang, i18n.MsgFixBadRequest)
+	case service.CauseNotFound:
+		return i18n.T(lang, i18n.MsgCauseNotFound),
+			i18n.T(lang, i18n.MsgFixNothing)
+	case service.CauseStorage:
+		return i18n.T(lang, i18n.MsgCauseStorage),
+			i18n.T(lang, i18n.MsgFixStorage)
+	case service.CauseContent:
+		return i18n.T(lang, i18n.MsgCauseContent),
+			i18n.T(lang, i18n.MsgFixContent)
+	case service.CauseLength:
+		return i18n.T(lang, i18n.MsgCauseLength, content.MaxLength),
+			i18n.T(lang, i18n.MsgFixLength)
+	case service.CauseNetwork:
+		return i18n.T(lang, i18n.MsgCauseNetwork),
+			i18n.T(lang, i18n.MsgFixNextCycle)
+	case service.CauseUnknown:
+		return i18n.T(lang, i18n.MsgCauseUnknown),
+			i18n.T(lang, i18n.MsgFixUnknown)
+	}
+	return cause, ""
+}
@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	historyLimit    = 10
+	historyProducts = 5 // products in the per-product breakdown
+	historyWindow   = 30 * 24 * time.Hour
+
+	historyComplaints = 5 // latest complaints shown under the answers
+)
+
+// handleHistory shows the latest answers with the reply posted to WB and the
+// template that produced it, and a per-template breakdown for comparing variants.
+func (b *Bot) handleHistory(chatID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	answers, err := b.userStore.RecentAnswers(dbCtx, chatID, historyLimit)
+	if err != nil {
+		b.log.Warnw("failed to get recent answers", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgHistoryLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	breakdown, err := b.userStore.SourceBreakdown(dbCtx, chatID, historyWindow)
+	if err != nil {
+		b.log.Warnw("failed to get source breakdown", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgHistoryLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	products, err := b.userStore.ProductBreakdown(dbCtx, chatID, historyWindow, historyProducts)
+	if err != nil {
+		// The product list is optional; show the rest of the history
+		b.log.Warnw("failed to get product breakdown", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+	}
+	complaints, err := b.userStore.RecentComplaints(dbCtx, chatID, historyComplaints)
+	if err != nil {
+		// Complaints are optional as well
+		b.log.Warnw("failed to get complaints", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+	}
+	now := time.Now()
+	complaintStats, err := b.userStore.ComplaintStats(dbCtx, chatID, now.Add(-historyWindow), now)
+	if err != nil {
+		b.log.Warnw("failed to count complaints", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_history")
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnExportHistory), CallbackExportHistory),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnEditAnswer), CallbackEditAnswer),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, formatHistory(formatterFor(cfg), answers, breakdown, products, complaints, complaintStats), keyboard)
+}
+
+// formatHistory renders recent answers, the per-template and per-product
+// breakdowns and recent complaints with their outcomes over historyWindow.
+func formatHistory(f locale.Formatter, answers []storage.AnswerRecord, breakdown []storage.SourceStats, products []storage.ProductStats, complaints []storage.Complaint, counts storage.ComplaintStats) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryTitle))
+
+	if len(answers) == 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryEmpty))
+	}
+
+	for _, a := range answers {
+		sb.WriteString("\n")
+		sb.WriteString(f.ShortDateTime(a.AnsweredAt))
+		if a.Kind == storage.KindQuestion {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryQuestion))
+		} else if a.Rating > 0 {
+			sb.WriteString(fmt.Sprintf(" · %d★", a.Rating))
+		}
+		switch {
+		case a.ProductName != "":
+			sb.WriteString(" · " + escapeMarkdownV1(a.ProductName))
+		case a.SubjectName != "":
+			sb.WriteString(" · " + escapeMarkdownV1(a.SubjectName))
+		}
+		sb.WriteString("\n   ↳ " + sourceLabel(f.Lang, a.Source, a.TemplateVersion))
+		if a.ReplyText != "" {
+			// Answers recorded before reply texts were stored have none.
+			sb.WriteString("\n   💬 " + variantPreview(a.ReplyText))
+		}
+	}
+
+	if len(breakdown) > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryBySource, f.Days(historyWindow)))
+		for _, st := range breakdown {
+			sb.WriteString(fmt.Sprintf("%s: %s", sourceLabel(f.Lang, st.Source, st.TemplateVersion), f.Count(st.Answers)))
+			if st.AvgRating > 0 {
+				sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryAvgRating, st.AvgRating))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryVersionNote))
+	}
+
+	if len(products) > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryByProduct, f.Days(historyWindow)))
+		for _, p := range products {
+			sb.WriteString(productLabel(f.Lang, p) + ": " + f.Count(p.Answers))
+			if p.AvgRating > 0 {
+				sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryAvgRating, p.AvgRating))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(complaints) > 0 || counts.Filed() > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryComplaints))
+		if counts.Filed() > 0 {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryComplaintsPeriod, f.Days(historyWindow), formatComplaintStats(f, counts)))
+		}
+		for _, c := range complaints {
+			sb.WriteString(f.ShortDateTime(c.UpdatedAt))
+			if c.Rating > 0 {
+				sb.WriteString(fmt.Sprintf(" · %d★", c.Rating))
+			}
+			if c.NmID != 0 {
+				sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewArticle, c.NmID))
+			}
+			sb.WriteString(" · " + complaintStatusLabel(f.Lang, c.Status))
+			sb.WriteString("\n   ↳ " + escapeMarkdownV1(c.Reason))
+			if c.Status == storage.ComplaintFailed && c.Error != "" {
+				sb.WriteString("\n   ⚠️ " + variantPreview(c.Error))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgHistoryComplaintsNote))
+	}
+	return sb.String()
+}
+
+// formatComplaintStats renders complaint counts, e.g. "подано 5, принято 2,
+// отклонено 1, на модерации 2".
+func formatComplaintStats(f locale.Formatter, c storage.ComplaintStats) string {
+	return i18n.T(f.Lang, i18n.MsgComplaintStats,
+		f.Count(c.Filed()), f.Count(c.Accepted), f.Count(c.Rejected), f.Count(c.Pending))
+}
+
+// productLabel names a product by its name and articles, e.g.
+// "Футболка (арт. 123456, FT-01)".
+func productLabel(lang string, p storage.ProductStats) string {
+	article := i18n.T(lang, i18n.MsgProductArticle, p.NmID)
+	if p.SupplierArticle != "" {
+		article += ", " + escapeMarkdownV1(p.SupplierArticle)
+	}
+	if p.ProductName == "" {
+		return article
+	}
+	return escapeMarkdownV1(p.ProductName) + " (" + article + ")"
+}
+
+// sourceLabel names the template that produced an answer, e.g. "👍 положительный v1a2b3c4d".
+func sourceLabel(lang, source, version string) string {
+	var key i18n.Key
+	switch source {
+	case service.SourceGood:
+		key = i18n.MsgSourceGood
+	case service.SourceBad:
+		key = i18n.MsgSourceBad
+	case service.SourceOffHours:
+		key = i18n.MsgSourceOffHours
+	case service.SourceSentiment:
+		key = i18n.MsgSourceSentiment
+	case service.SourceMedia:
+		key = i18n.MsgSourceMedia
+	case service.SourceCategory:
+		key = i18n.MsgSourceCategory
+	case service.SourceQuestion:
+		key = i18n.MsgSourceQuestion
+	case service.SourceEdited:
+		key = i18n.MsgSourceEdited
+	case service.SourceManual:
+		key = i18n.MsgSourceManual
+	case service.SourceDraft:
+		key = i18n.MsgSourceDraft
+	default:
+		return i18n.T(lang, i18n.MsgSourceUnknown)
+	}
+	label := i18n.T(lang, key)
+	if version != "" {
+		label += " `v" + version + "`"
+	}
+	return label
+}
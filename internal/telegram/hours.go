@@ -0,0 +1,314 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// businessHoursDisplay renders the user's working hours for the info screen.
+func businessHoursDisplay(cfg *storage.UserConfig) string {
+	lang := formatterFor(cfg).Lang
+	if cfg.WorkStart == "" || cfg.WorkEnd == "" {
+		return i18n.T(lang, i18n.MsgHoursUnset)
+	}
+	hours, err := service.ParseBusinessHours(cfg.Timezone, cfg.WorkStart, cfg.WorkEnd)
+	if err != nil {
+		return i18n.T(lang, i18n.MsgHoursInvalid)
+	}
+	if cfg.AnswerHoursOnly {
+		return i18n.T(lang, i18n.MsgHoursHold, hours.String())
+	}
+	if cfg.TemplateOffHours == "" {
+		return i18n.T(lang, i18n.MsgHoursNoOffTemplate, hours.String())
+	}
+	return hours.String()
+}
+
+// formatterFor returns a formatter in the user's language and timezone,
+// falling back to Russian and service.DefaultTimezone, never to the server's
+// zone. cfg may be nil.
+func formatterFor(cfg *storage.UserConfig) locale.Formatter {
+	tz, lang := service.DefaultTimezone, locale.LangRU
+	if cfg != nil {
+		if cfg.Timezone != "" {
+			tz = cfg.Timezone
+		}
+		lang = i18n.Normalize(cfg.Language)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc, err = time.LoadLocation(service.DefaultTimezone)
+	}
+	if err != nil {
+		loc = time.UTC
+	}
+	return locale.New(lang, loc)
+}
+
+// chatFormatter is formatterFor the stored settings of chatID, for messages
+// built without the user's config at hand, such as admin screens.
+func (b *Bot) chatFormatter(chatID int64) locale.Formatter {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	return formatterFor(cfg)
+}
+
+// outageOption shares the WB outage tracker with users on the bot-wide WB
+// endpoint. Errors behind a user's own API URL (the sandbox included) or
+// proxy say nothing about WB and must not pause the other users.
+func (b *Bot) outageOption(cfg *storage.UserConfig) service.Option {
+	if cfg.WBBaseURL != "" || cfg.WBProxy != "" {
+		return nil
+	}
+	return service.WithOutageTracker(b.wbOutage)
+}
+
+// serviceOptions converts persisted user settings into service options.
+// Invalid settings are logged and ignored so the service still starts.
+func (b *Bot) serviceOptions(chatID int64, cfg *storage.UserConfig) []service.Option {
+	var opts []service.Option
+	if cfg.WorkStart != "" && cfg.WorkEnd != "" && (cfg.TemplateOffHours != "" || cfg.AnswerHoursOnly) {
+		hours, err := service.ParseBusinessHours(cfg.Timezone, cfg.WorkStart, cfg.WorkEnd)
+		switch {
+		case err != nil:
+			b.log.Warnw("invalid business hours, ignoring", "chat_id", chatID, "err", err)
+		case cfg.AnswerHoursOnly:
+			opts = append(opts, service.WithAnswerWindow(hours))
+		default:
+			opts = append(opts, service.WithBusinessHours(hours, cfg.TemplateOffHours))
+		}
+	}
+	if cfg.TemplateQuestion != "" {
+		opts = append(opts, service.WithQuestionTemplate(cfg.TemplateQuestion))
+	}
+	if cfg.TemplateMedia != "" {
+		opts = append(opts, service.WithMediaTemplate(cfg.TemplateMedia))
+	}
+	if opt := b.exclusionOption(chatID); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.variantOption(chatID); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.signatureOption(chatID); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.categoryOption(chatID); opt != nil {
+		opts = append(opts, opt)
+	}
+	opts = append(opts, b.dailyLimitOption(chatID, cfg), b.tokenBreakerOption(chatID, cfg))
+	if opt := b.outageOption(cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.billingOption(chatID); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := answerDelayOption(cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if cfg.Humanize {
+		opts = append(opts, service.WithHumanize())
+	}
+	if cfg.SentimentRouting {
+		opts = append(opts, service.WithSentiment(nil))
+	}
+	if opt := b.trackingOption(chatID, cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.escalationOption(chatID, cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := duplicateShareOption(cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := b.draftsOption(chatID, cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	if opt := negativeReportOption(cfg); opt != nil {
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+// reloadUserService recreates a running service so that it picks up changed
+// settings. Users without a running service are left untouched.
+func (b *Bot) reloadUserService(chatID int64, ctx context.Context) {
+	if b.getServiceForUser(chatID) == nil {
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil || cfg == nil {
+		b.log.Warnw("reload service: failed to load config", "chat_id", chatID, "err", err)
+		return
+	}
+	b.shutdownUserService(chatID)
+	b.initializeServiceForUser(chatID, cfg, ctx)
+}
+
+func (b *Bot) handleBusinessHoursButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingBusinessHours)
+	msg := b.t(chatID, i18n.MsgHoursPrompt, businessHoursDisplay(cfg), formatterFor(cfg).Loc)
+	b.SendMessageWithKeyboard(chatID, msg, b.businessHoursKeyboard(chatID, cfg))
+}
+
+// businessHoursKeyboard adds the off-hours mode toggle to the cancel button
+// once working hours are set.
+func (b *Bot) businessHoursKeyboard(chatID int64, cfg *storage.UserConfig) tgbotapi.InlineKeyboardMarkup {
+	keyboard := b.CreateCancelKeyboard(chatID)
+	if cfg.WorkStart == "" || cfg.WorkEnd == "" {
+		return keyboard
+	}
+	toggle := tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnHoursOnlyOn), CallbackHoursOnlyOn)
+	if cfg.AnswerHoursOnly {
+		toggle = tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnHoursOnlyOff), CallbackHoursOnlyOff)
+	}
+	keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(toggle)}, keyboard.InlineKeyboard...)
+	return keyboard
+}
+
+// handleAnswerHoursOnlyToggle switches between holding reviews until working
+// hours and answering them with the off-hours template.
+func (b *Bot) handleAnswerHoursOnlyToggle(chatID int64, on bool, ctx context.Context) {
+	b.resetUserState(chatID)
+	if err := b.configStore.SetAnswerHoursOnly(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save answer hours only", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgHoursOnlyOff)
+	if on {
+		msg = b.t(chatID, i18n.MsgHoursOnlyOn)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handleBusinessHoursInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+
+	// Without a zone in the input the user's timezone applies, and turning
+	// the hours off keeps it
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	var tz, start, end string
+	if cfg != nil {
+		tz = cfg.Timezone
+	}
+	if lower := strings.ToLower(text); lower != "выкл" && lower != "off" {
+		fields := strings.Fields(text)
+		if len(fields) == 0 || len(fields) > 2 {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgHoursBadFormat), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		bounds := strings.SplitN(fields[0], "-", 2)
+		if len(bounds) != 2 {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgHoursBadFormat), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		start, end = bounds[0], bounds[1]
+		if len(fields) == 2 {
+			tz = fields[1]
+		}
+		hours, err := service.ParseBusinessHours(tz, start, end)
+		if err != nil {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgHoursParseFailed), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		tz = hours.Loc.String()
+		start, end = hours.Bounds()
+	}
+
+	if err := b.configStore.UpdateBusinessHours(ctx, chatID, tz, start, end); err != nil {
+		b.log.Errorw("failed to save business hours", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgHoursDisabled)
+	if start != "" {
+		msg = b.t(chatID, i18n.MsgHoursSaved, start, end, tz)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handleOffHoursTemplateButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingOffHoursTemplate)
+	msg := b.t(chatID, i18n.MsgOffHoursPrompt)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleOffHoursTemplateInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if lower := strings.ToLower(text); lower == "выкл" || lower == "off" {
+		text = ""
+	} else {
+		if len([]rune(text)) < 10 {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if len([]rune(text)) > MaxTemplateLength {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if !utf8.ValidString(text) {
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if b.rejectContent(chatID, text) {
+			return
+		}
+	}
+
+	if err := b.configStore.UpdateOffHoursTemplate(ctx, chatID, text); err != nil {
+		b.log.Errorw("failed to save off-hours template", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, templateAuditDetails("off_hours", text))
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgOffHoursSaved)
+	if text == "" {
+		msg = b.t(chatID, i18n.MsgOffHoursDisabled)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
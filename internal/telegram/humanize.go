@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/pkg/metrics"
+)
+
+func (b *Bot) handleHumanizeButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := "выключены"
+	button := tgbotapi.NewInlineKeyboardButtonData("✅ Включить", CallbackHumanizeOn)
+	if cfg.Humanize {
+		status = "включены"
+		button = tgbotapi.NewInlineKeyboardButtonData("🚫 Выключить", CallbackHumanizeOff)
+	}
+	msg := `🐢 *Паузы между ответами*
+
+Сейчас: ` + status + `
+
+Бот делает случайную паузу от 30 секунд до 5 минут перед каждым следующим ответом, чтобы ответы не появлялись на Wildberries все в одну секунду.
+
+Обработка большого числа отзывов при этом займёт заметно больше времени.`
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handleHumanizeToggle(chatID int64, on bool, ctx context.Context) {
+	if err := b.configStore.SetHumanize(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save humanize", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := "✅ Паузы между ответами выключены. Бот отвечает без задержек."
+	if on {
+		msg = "✅ Паузы между ответами включены."
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/pkg/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// lang returns the user's language for bot messages. It is read from the
+// stored config once and cached; users without a config get Russian.
+func (b *Bot) lang(chatID int64) string {
+	b.langMu.RLock()
+	lang, ok := b.langs[chatID]
+	b.langMu.RUnlock()
+	if ok {
+		return lang
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lang = locale.LangRU
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil {
+		// Don't cache: the next message retries the lookup
+		b.log.Warnw("failed to load language", "chat_id", chatID, "err", err)
+		return lang
+	}
+	if cfg != nil {
+		lang = i18n.Normalize(cfg.Language)
+	}
+	b.langMu.Lock()
+	b.langs[chatID] = lang
+	b.langMu.Unlock()
+	return lang
+}
+
+// t returns the message for key in the user's language.
+func (b *Bot) t(chatID int64, key i18n.Key, args ...any) string {
+	return i18n.T(b.lang(chatID), key, args...)
+}
+
+// forgetLanguage drops the cached language, e.g. after the config is deleted.
+func (b *Bot) forgetLanguage(chatID int64) {
+	b.langMu.Lock()
+	delete(b.langs, chatID)
+	b.langMu.Unlock()
+}
+
+// persistLanguage stores a language chosen before the user had a config.
+// Called right after the config row is created.
+func (b *Bot) persistLanguage(ctx context.Context, chatID int64) {
+	b.langMu.RLock()
+	lang, ok := b.langs[chatID]
+	b.langMu.RUnlock()
+	if !ok || lang == locale.LangRU {
+		return
+	}
+	if err := b.configStore.SetLanguage(ctx, chatID, lang); err != nil {
+		b.log.Warnw("failed to save language", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+	}
+}
+
+// handleLanguageCommand shows the language picker (/language).
+func (b *Bot) handleLanguageCommand(chatID int64) {
+	current := b.lang(chatID)
+	var row []tgbotapi.InlineKeyboardButton
+	for _, l := range i18n.Languages {
+		label := l.Name
+		if l.Code == current {
+			label = "✓ " + label
+		}
+		row = b.appendCallbackButton(row, label, CallbackLanguagePrefix, l.Code)
+	}
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgLanguagePrompt), tgbotapi.NewInlineKeyboardMarkup(row))
+}
+
+// handleLanguageCallback switches the user's language and shows the main
+// menu in it. Users without a config keep the choice in memory until their
+// token is saved.
+func (b *Bot) handleLanguageCallback(chatID int64, lang string, ctx context.Context) {
+	if !i18n.Supported(lang) {
+		return
+	}
+
+	if err := b.configStore.SetLanguage(ctx, chatID, lang); err != nil {
+		b.log.Errorw("failed to save language", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+		return
+	}
+	b.langMu.Lock()
+	b.langs[chatID] = lang
+	b.langMu.Unlock()
+
+	b.log.Infow("language changed", "chat_id", chatID, "lang", lang)
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgLanguageSet))
+	b.showMainMenu(chatID)
+}
@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// dailyLimitOption caps the user's answers per day, counted in the user's
+// timezone, and tells the user when the cap stops answering.
+func (b *Bot) dailyLimitOption(chatID int64, cfg *storage.UserConfig) service.Option {
+	return service.WithDailyLimit(cfg.DailyLimit, formatterFor(cfg).Loc, func(limit int) {
+		msg := fmt.Sprintf(`⏳ *Дневной лимит ответов исчерпан*
+
+Сегодня бот уже отправил %d ответов. Остальные отзывы получат ответ завтра.
+
+Изменить лимит можно кнопкой "📈 Дневной лимит".`, limit)
+		b.SendMessage(chatID, msg)
+	})
+}
+
+// dailyLimitDisplay renders the user's daily limit for the info screen.
+func dailyLimitDisplay(cfg *storage.UserConfig) string {
+	if cfg.DailyLimit <= 0 {
+		return fmt.Sprintf("%d (по умолчанию)", service.DefaultDailyLimit)
+	}
+	return strconv.Itoa(cfg.DailyLimit)
+}
+
+func (b *Bot) handleDailyLimitButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingDailyLimit)
+	msg := fmt.Sprintf(`📈 *Дневной лимит ответов*
+
+Сейчас: %s
+
+Бот перестаёт отвечать, когда за сутки отправлено столько ответов (отзывы и вопросы вместе), и продолжает на следующий день.
+
+Отправьте число от 1 до %d или 0, чтобы вернуть значение по умолчанию.`, dailyLimitDisplay(cfg), service.MaxDailyLimit)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleDailyLimitInput(chatID int64, text string, ctx context.Context) {
+	limit, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || limit < 0 || limit > service.MaxDailyLimit {
+		b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Отправьте число от 1 до %d или 0 для значения по умолчанию.", service.MaxDailyLimit), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if err := b.configStore.SetDailyLimit(ctx, chatID, limit); err != nil {
+		b.log.Errorw("failed to save daily limit", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+
+	if limit == 0 {
+		limit = service.DefaultDailyLimit
+	}
+	b.SendMessageWithKeyboard(chatID, fmt.Sprintf("✅ Дневной лимит: %d ответов.", limit), b.CreateMainMenuForUser(chatID))
+}
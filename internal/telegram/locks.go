@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"context"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// EnableCycleLocks makes every cycle take the user's lock in l first, so that
+// replicas sharing the database never process the same user at once. Call
+// before Run.
+func (b *Bot) EnableCycleLocks(l storage.Locker) {
+	b.locker = l
+}
+
+// withUserLock runs cycle under the user's lock and reports whether it ran.
+// It does not when another instance is processing the user, or when the lock
+// cannot be checked: skipping a cycle is safer than answering twice.
+func (b *Bot) withUserLock(ctx context.Context, chatID int64, cycle func(ctx context.Context)) bool {
+	if b.locker == nil {
+		cycle(ctx)
+		return true
+	}
+	release, ok, err := b.locker.TryLock(ctx, chatID)
+	if err != nil {
+		b.log.Warnw("cycle skipped: failed to take user lock", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("cycle_lock")
+		return false
+	}
+	if !ok {
+		b.log.Infow("cycle skipped: user is processed by another instance", "chat_id", chatID)
+		metrics.IncrementCycleLockSkip()
+		return false
+	}
+	defer release()
+	cycle(ctx)
+	return true
+}
+
+// lockGuard wraps a scheduled cycle with withUserLock.
+func (b *Bot) lockGuard(chatID int64, cycle func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		b.withUserLock(ctx, chatID, cycle)
+	}
+}
@@ -0,0 +1,127 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// loadMaintenance reads the maintenance mode from storage into
+// b.maintenance. On error the current mode is kept.
+func (b *Bot) loadMaintenance() {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := b.configStore.GetMaintenance(dbCtx)
+	if err != nil {
+		b.log.Errorw("failed to load maintenance mode", "err", err)
+		metrics.IncrementDatabaseError("get_maintenance")
+		return
+	}
+	b.maintenance.Store(m)
+	if m != nil {
+		b.log.Warnw("maintenance mode is on: only admins can use the bot, cycles and jobs are paused",
+			"since", m.StartedAt.Format(time.RFC3339), "by", m.StartedBy)
+	}
+}
+
+// InMaintenance reports whether the bot is in maintenance mode.
+func (b *Bot) InMaintenance() bool {
+	return b.maintenance.Load() != nil
+}
+
+// MaintenanceGuard wraps a periodic job so that it is skipped in
+// maintenance mode.
+func (b *Bot) MaintenanceGuard(name string, job func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if b.InMaintenance() {
+			b.log.Infow("job skipped: maintenance mode", "job", name)
+			return
+		}
+		job(ctx)
+	}
+}
+
+// rejectInMaintenance tells a user who is not an admin that the bot is
+// under maintenance. It returns true if the update must not be handled.
+func (b *Bot) rejectInMaintenance(chatID int64) bool {
+	m := b.maintenance.Load()
+	if m == nil || b.isAdmin(chatID) {
+		return false
+	}
+	msg := b.t(chatID, i18n.MsgMaintenance)
+	if m.Reason != "" {
+		msg += "\n\n" + escapeMarkdownV1(m.Reason)
+	}
+	b.SendMessage(chatID, msg)
+	return true
+}
+
+// handleAdminMaintenanceCommand handles the admin command
+//
+//	/admin maintenance                  show the mode
+//	/admin maintenance on [reason]      turn it on
+//	/admin maintenance off              turn it off
+func (b *Bot) handleAdminMaintenanceCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	sub, reason, _ := strings.Cut(strings.TrimSpace(args), " ")
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	switch strings.ToLower(sub) {
+	case "":
+		b.sendMaintenanceStatus(chatID)
+	case "on":
+		m := &storage.Maintenance{Reason: strings.TrimSpace(reason), StartedBy: chatID, StartedAt: time.Now()}
+		if prev := b.maintenance.Load(); prev != nil {
+			m.StartedBy, m.StartedAt = prev.StartedBy, prev.StartedAt
+		}
+		if err := b.configStore.StartMaintenance(dbCtx, *m); err != nil {
+			b.log.Errorw("failed to start maintenance mode", "err", err)
+			metrics.IncrementDatabaseError("save_maintenance")
+			b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+			return
+		}
+		b.maintenance.Store(m)
+		b.log.Warnw("maintenance mode on", "admin_id", chatID, "reason", m.Reason)
+		b.sendMaintenanceStatus(chatID)
+	case "off":
+		existed, err := b.configStore.StopMaintenance(dbCtx)
+		if err != nil {
+			b.log.Errorw("failed to stop maintenance mode", "err", err)
+			metrics.IncrementDatabaseError("save_maintenance")
+			b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+			return
+		}
+		b.maintenance.Store(nil)
+		if !existed {
+			b.SendMessage(chatID, "ℹ️ Режим обслуживания не был включён.")
+			return
+		}
+		b.log.Warnw("maintenance mode off", "admin_id", chatID)
+		b.SendMessage(chatID, "✅ *Режим обслуживания выключен*\n\nПользователи снова могут пользоваться ботом, циклы и задачи по расписанию возобновятся в своё время.")
+	default:
+		b.SendMessage(chatID, "Использование: `/admin maintenance`, `/admin maintenance on [причина]`, `/admin maintenance off`")
+	}
+}
+
+func (b *Bot) sendMaintenanceStatus(chatID int64) {
+	m := b.maintenance.Load()
+	if m == nil {
+		b.SendMessage(chatID, "🛠 *Режим обслуживания выключен*\n\nВключить: `/admin maintenance on [причина]` — бот перестанет отвечать на отзывы и выполнять задачи по расписанию, а пользователи вместо ответа получат сообщение «Бот на обслуживании». Администраторы продолжат пользоваться ботом.")
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🛠 *Режим обслуживания включён*\n\nС %s (администратор `%d`).", b.chatFormatter(chatID).DateTime(m.StartedAt), m.StartedBy)
+	if m.Reason != "" {
+		sb.WriteString("\nПричина: " + escapeMarkdownV1(m.Reason))
+	}
+	sb.WriteString("\n\nЦиклы и задачи по расписанию приостановлены, пользователи получают сообщение «Бот на обслуживании». Режим сохраняется при перезапуске.\n\nВыключить: `/admin maintenance off`")
+	b.SendMessage(chatID, sb.String())
+}
@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// mediaTemplateDisplay renders the photo thank-you status for the info screen.
+func mediaTemplateDisplay(cfg *storage.UserConfig) string {
+	if cfg.TemplateMedia == "" {
+		return "выключена"
+	}
+	return fmt.Sprintf("включена (%d символов)", len([]rune(cfg.TemplateMedia)))
+}
+
+func (b *Bot) handleMediaTemplateButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingMediaTemplate)
+	msg := fmt.Sprintf(`📸 *Благодарность за фото и видео*
+
+Сейчас: %s
+
+Отправьте текст, которым бот будет отвечать на положительные отзывы (4-5 ⭐) с фотографиями или видео. Остальные положительные отзывы получат обычный шаблон.
+
+*Пример:*
+"Спасибо за отзыв и фотографии! Живые снимки очень помогают другим покупателям сделать выбор."
+
+Чтобы отключить отдельный ответ, отправьте "выкл".`, mediaTemplateDisplay(cfg))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleMediaTemplateInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if lower := strings.ToLower(text); lower == "выкл" || lower == "off" {
+		text = ""
+	} else {
+		if len([]rune(text)) < 10 {
+			b.SendMessageWithKeyboard(chatID, "⚠️ Текст слишком короткий. Рекомендуется минимум 20-30 символов.", b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if len([]rune(text)) > MaxTemplateLength {
+			b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Текст слишком длинный. Максимальная длина: %d символов.", MaxTemplateLength), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if !utf8.ValidString(text) {
+			b.SendMessageWithKeyboard(chatID, "❌ Текст содержит некорректные символы. Используйте только допустимые символы.", b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if b.rejectContent(chatID, text) {
+			return
+		}
+	}
+
+	if err := b.configStore.UpdateMediaTemplate(ctx, chatID, text); err != nil {
+		b.log.Errorw("failed to save media template", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, templateAuditDetails("media", text))
+	b.reloadUserService(chatID, ctx)
+
+	msg := "✅ Шаблон благодарности за фото сохранен! Он будет использоваться для положительных отзывов с фото или видео."
+	if text == "" {
+		msg = "✅ Отдельный ответ на отзывы с фото отключен. Они получат обычный шаблон для положительных отзывов."
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// SendMonthlyReports sends every user with a running service a report on
+// the past calendar month in the user's timezone: answers, ratings and the
+// complaints filed with their outcomes. Users without answers or complaints
+// get nothing. Intended to be run by an hourly scheduler; each user is sent
+// the report on the 1st during digestHour of their local time.
+func (b *Bot) SendMonthlyReports(ctx context.Context) {
+	b.svcMu.RLock()
+	chatIDs := make([]int64, 0, len(b.services))
+	for chatID := range b.services {
+		chatIDs = append(chatIDs, chatID)
+	}
+	b.svcMu.RUnlock()
+
+	now := time.Now()
+	limiter := rate.NewLimiter(rate.Limit(broadcastRate), 1)
+	sent := 0
+	for _, chatID := range chatIDs {
+		msg, ok := b.monthlyReport(ctx, chatID, now)
+		if !ok {
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+		if err := b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID)); err != nil {
+			b.log.Debugw("monthly report: delivery failed", "chat_id", chatID, "err", err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		b.log.Infow("monthly report sent", "users", len(chatIDs), "sent", sent)
+	}
+}
+
+// monthlyReport renders the user's report; ok is false when it is not yet
+// the report hour in the user's timezone, there is nothing to report or the
+// data cannot be loaded.
+func (b *Bot) monthlyReport(ctx context.Context, chatID int64, now time.Time) (msg string, ok bool) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	f := formatterFor(cfg)
+	loc := f.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	if local.Day() != 1 || local.Hour() != digestHour {
+		return "", false
+	}
+	y, m, _ := local.Date()
+	to := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	from := to.AddDate(0, -1, 0)
+
+	sum, err := b.userStore.PeriodSummary(dbCtx, chatID, from, to)
+	if err != nil {
+		b.log.Warnw("monthly report: failed to load summary", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("period_summary")
+		return "", false
+	}
+	complaints, err := b.userStore.ComplaintStats(dbCtx, chatID, from, to)
+	if err != nil {
+		b.log.Warnw("monthly report: failed to count complaints", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("complaint_stats")
+		return "", false
+	}
+	if sum.Reviews == 0 && sum.Questions == 0 && complaints.Filed() == 0 {
+		return "", false
+	}
+	return formatMonthlyReport(f, from, sum, complaints), true
+}
+
+// formatMonthlyReport renders the report for the month starting at first.
+// Complaints are counted by the month they were filed in, so those filed at
+// its end may still be under moderation.
+func formatMonthlyReport(f locale.Formatter, first time.Time, sum storage.PeriodSummary, complaints storage.ComplaintStats) string {
+	var sb strings.Builder
+	last := first.AddDate(0, 1, -1)
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgMonthlyTitle, f.Date(first), f.Date(last)))
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryReviews, f.Count(sum.Reviews)))
+	if sum.AvgRating > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryAvgRating, sum.AvgRating))
+	}
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryNegative, f.Count(sum.Negative())))
+	if sum.Questions > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgSummaryQuestions, f.Count(sum.Questions)))
+	}
+	if complaints.Filed() > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgMonthlyComplaints, f.Count(complaints.Filed())))
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgMonthlyAccepted, f.Count(complaints.Accepted)))
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgMonthlyRejected, f.Count(complaints.Rejected)))
+		if complaints.Pending > 0 {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgMonthlyPending, f.Count(complaints.Pending)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"feedback_bot/internal/storage"
+)
+
+func TestFormatMonthlyReport(t *testing.T) {
+	f := formatterFor(nil)
+	first := time.Date(2026, time.February, 1, 0, 0, 0, 0, f.Loc)
+	sum := storage.PeriodSummary{Reviews: 40, AvgRating: 4.5}
+	complaints := storage.ComplaintStats{Pending: 1, Accepted: 3, Rejected: 2, Failed: 4}
+
+	got := formatMonthlyReport(f, first, sum, complaints)
+	for _, want := range []string{
+		"(01.02.2026 – 28.02.2026)",
+		"Ответов на отзывы: *40*",
+		"Жалоб на отзывы подано: *6*",
+		"Приняты, отзыв удалён: *3*",
+		"Отклонены: *2*",
+		"Ещё на модерации: *1*",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report does not contain %q:\n%s", want, got)
+		}
+	}
+
+	// Without complaints the section is left out
+	if got := formatMonthlyReport(f, first, sum, storage.ComplaintStats{Failed: 1}); strings.Contains(got, "Жалоб") {
+		t.Errorf("report without filed complaints mentions them:\n%s", got)
+	}
+}
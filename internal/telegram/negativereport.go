@@ -0,0 +1,233 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/time/rate"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// negativeReportHour is when, in the user's timezone, SendNegativeReports
+// sends the report of the preceding 24 hours.
+const negativeReportHour = 9
+
+const (
+	reportTextLen    = 300  // longer texts, pros, cons and replies are cut
+	reportMessageLen = 3500 // a longer report is split into several messages
+)
+
+// negativeReportOption records negative reviews for the daily report, if the
+// user turned it on.
+func negativeReportOption(cfg *storage.UserConfig) service.Option {
+	if !cfg.NegativeReport {
+		return nil
+	}
+	return service.WithNegativeLog()
+}
+
+// SendNegativeReports sends every user with a running service who turned the
+// report on the 1–3★ reviews the bot fetched in the last 24 hours, with the
+// replies posted to them. Intended to be run by an hourly scheduler; each
+// user gets the report during negativeReportHour of their local time.
+func (b *Bot) SendNegativeReports(ctx context.Context) {
+	b.svcMu.RLock()
+	chatIDs := make([]int64, 0, len(b.services))
+	for chatID := range b.services {
+		chatIDs = append(chatIDs, chatID)
+	}
+	b.svcMu.RUnlock()
+
+	now := time.Now()
+	limiter := rate.NewLimiter(rate.Limit(broadcastRate), 1)
+	sent := 0
+	for _, chatID := range chatIDs {
+		msgs, ok := b.negativeReport(ctx, chatID, now)
+		if !ok {
+			continue
+		}
+		delivered := true
+		for i, msg := range msgs {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+			var err error
+			if i == len(msgs)-1 {
+				err = b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+			} else {
+				err = b.SendMessage(chatID, msg)
+			}
+			if err != nil {
+				b.log.Debugw("negative report: delivery failed", "chat_id", chatID, "err", err)
+				delivered = false
+				break
+			}
+		}
+		if delivered {
+			sent++
+		}
+	}
+	if sent > 0 {
+		b.log.Infow("negative reports sent", "users", len(chatIDs), "sent", sent)
+	}
+}
+
+// negativeReport renders the user's report as one or more messages; ok is
+// false when the user has not turned it on, it is not yet the report hour in
+// the user's timezone or the reviews cannot be loaded.
+func (b *Bot) negativeReport(ctx context.Context, chatID int64, now time.Time) (msgs []string, ok bool) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || !cfg.NegativeReport {
+		return nil, false
+	}
+	f := formatterFor(cfg)
+	loc := f.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	if now.In(loc).Hour() != negativeReportHour {
+		return nil, false
+	}
+	reviews, err := b.userStore.NegativeReviews(dbCtx, chatID, now.Add(-24*time.Hour))
+	if err != nil {
+		b.log.Warnw("negative report: failed to load reviews", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("negative_reviews")
+		return nil, false
+	}
+	return formatNegativeReport(f, reviews), true
+}
+
+// formatNegativeReport renders the report, splitting it between reviews
+// into messages of at most reportMessageLen characters.
+func formatNegativeReport(f locale.Formatter, reviews []storage.NegativeReview) []string {
+	if len(reviews) == 0 {
+		return []string{i18n.T(f.Lang, i18n.MsgNegReportEmpty)}
+	}
+	unanswered := 0
+	for _, r := range reviews {
+		if r.Reply == "" {
+			unanswered++
+		}
+	}
+	header := i18n.T(f.Lang, i18n.MsgNegReportHeader, f.Count(int64(len(reviews))))
+	if unanswered > 0 {
+		header += i18n.T(f.Lang, i18n.MsgNegReportUnanswered, f.Count(int64(unanswered)))
+	}
+
+	var msgs []string
+	var sb strings.Builder
+	sb.WriteString(header)
+	for _, r := range reviews {
+		block := "\n\n" + formatNegativeReview(f, r)
+		if utf8.RuneCountInString(sb.String())+utf8.RuneCountInString(block) > reportMessageLen {
+			msgs = append(msgs, sb.String())
+			sb.Reset()
+			block = strings.TrimPrefix(block, "\n\n")
+		}
+		sb.WriteString(block)
+	}
+	return append(msgs, sb.String())
+}
+
+func formatNegativeReview(f locale.Formatter, r storage.NegativeReview) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("⭐", max(0, min(r.Rating, 5))))
+	switch {
+	case r.ProductName != "":
+		sb.WriteString(" · " + escapeMarkdownV1(r.ProductName))
+	case r.SubjectName != "":
+		sb.WriteString(" · " + escapeMarkdownV1(r.SubjectName))
+	}
+	if r.NmID != 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewArticle, r.NmID))
+	}
+	if !r.ReviewedAt.IsZero() {
+		sb.WriteString("\n🕒 " + f.ShortDateTime(r.ReviewedAt))
+	}
+	if r.Text == "" && r.Pros == "" && r.Cons == "" {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewNoText))
+	}
+	if r.Text != "" {
+		sb.WriteString("\n" + escapeMarkdownV1(clipReport(r.Text)))
+	}
+	if r.Pros != "" {
+		sb.WriteString("\n➕ " + escapeMarkdownV1(clipReport(r.Pros)))
+	}
+	if r.Cons != "" {
+		sb.WriteString("\n➖ " + escapeMarkdownV1(clipReport(r.Cons)))
+	}
+	if r.Reply != "" {
+		sb.WriteString("\n💬 " + escapeMarkdownV1(clipReport(r.Reply)))
+	} else {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgNegReportNoReply))
+	}
+	return sb.String()
+}
+
+func clipReport(text string) string {
+	if utf8.RuneCountInString(text) > reportTextLen {
+		return string([]rune(text)[:reportTextLen]) + "…"
+	}
+	return text
+}
+
+// negativeReportDisplay renders the report setting for the info screen.
+func negativeReportDisplay(cfg *storage.UserConfig) string {
+	if cfg.NegativeReport {
+		return fmt.Sprintf("ежедневно в %d:00", negativeReportHour)
+	}
+	return "выключен"
+}
+
+func (b *Bot) handleNegativeReportButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := b.t(chatID, i18n.MsgNegReportOff)
+	button := tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOn), CallbackNegativeReportOn)
+	if cfg.NegativeReport {
+		status = b.t(chatID, i18n.MsgNegReportOn)
+		button = tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOff), CallbackNegativeReportOff)
+	}
+	msg := b.t(chatID, i18n.MsgNegReportPrompt, status, negativeReportHour, escapeMarkdownV1(timezoneDisplay(b.lang(chatID), cfg)))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handleNegativeReportToggle(chatID int64, on bool, ctx context.Context) {
+	if err := b.configStore.SetNegativeReport(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save negative report", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgNegReportDisabled)
+	if on {
+		msg = b.t(chatID, i18n.MsgNegReportEnabled, negativeReportHour)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,173 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// Notifications about reviews are collected while the user's cycle runs and
+// delivered when it ends, so that a cycle answering hundreds of reviews does
+// not send hundreds of messages.
+const (
+	// notifyBatchMax is how many notifications of one kind a cycle delivers
+	// one by one; more are replaced by a single summary.
+	notifyBatchMax = 3
+	// notifySummaryLines is how many notifications a summary lists; the rest
+	// are only counted.
+	notifySummaryLines = 20
+	notifyLineTextLen  = 80
+)
+
+// notification is a message about one review, sent by a cycle.
+type notification struct {
+	target   int64  // chat to notify: the user's own or their escalation chat
+	kind     string // for logs and metrics; notifications are summarised per kind
+	title    string // summary header, with %d for the number of notifications
+	text     string
+	keyboard tgbotapi.InlineKeyboardMarkup
+	line     string // the notification as one line of a summary
+}
+
+// notifyBatch holds a user's notifications until their cycles end.
+type notifyBatch struct {
+	cycles int // cycles of the user running at once
+	items  []notification
+}
+
+// batchGuard wraps a user's cycle so that the notifications it sends are
+// delivered together when it ends.
+func (b *Bot) batchGuard(chatID int64, cycle func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		b.batchMu.Lock()
+		batch := b.batches[chatID]
+		if batch == nil {
+			batch = &notifyBatch{}
+			b.batches[chatID] = batch
+		}
+		batch.cycles++
+		b.batchMu.Unlock()
+
+		defer b.flushNotifications(chatID)
+		cycle(ctx)
+	}
+}
+
+// notify delivers n now or, while a cycle of the user runs, when it ends.
+func (b *Bot) notify(chatID int64, n notification) {
+	b.batchMu.Lock()
+	if batch := b.batches[chatID]; batch != nil {
+		batch.items = append(batch.items, n)
+		b.batchMu.Unlock()
+		return
+	}
+	b.batchMu.Unlock()
+	b.deliverNotification(chatID, n)
+}
+
+// flushNotifications ends a cycle of the user and, if it was the last one
+// running, delivers the notifications collected: up to notifyBatchMax of a
+// kind to a chat one by one, more as one summary.
+func (b *Bot) flushNotifications(chatID int64) {
+	b.batchMu.Lock()
+	batch := b.batches[chatID]
+	batch.cycles--
+	if batch.cycles > 0 {
+		b.batchMu.Unlock()
+		return
+	}
+	delete(b.batches, chatID)
+	b.batchMu.Unlock()
+
+	type group struct {
+		target int64
+		kind   string
+	}
+	var order []group
+	groups := make(map[group][]notification)
+	for _, n := range batch.items {
+		g := group{n.target, n.kind}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], n)
+	}
+	for _, g := range order {
+		items := groups[g]
+		if len(items) <= notifyBatchMax {
+			for _, n := range items {
+				b.deliverNotification(chatID, n)
+			}
+			continue
+		}
+		b.deliverNotification(chatID, b.summarizeNotifications(chatID, items))
+		b.log.Infow("notifications summarised", "chat_id", chatID, "target", g.target, "kind", g.kind, "count", len(items))
+	}
+}
+
+func (b *Bot) deliverNotification(chatID int64, n notification) {
+	var err error
+	if len(n.keyboard.InlineKeyboard) == 0 {
+		err = b.SendMessage(n.target, n.text)
+	} else {
+		err = b.SendMessageWithKeyboard(n.target, n.text, n.keyboard)
+	}
+	if err != nil {
+		b.log.Warnw("notification: delivery failed", "chat_id", chatID, "target", n.target, "kind", n.kind, "err", err)
+		metrics.IncrementAPIError("telegram", n.kind)
+	}
+}
+
+// summarizeNotifications replaces items, all of one kind and chat, with a
+// message listing them.
+func (b *Bot) summarizeNotifications(chatID int64, items []notification) notification {
+	first := items[0]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, first.title, len(items))
+	sb.WriteString("\n")
+	for i, n := range items {
+		if i == notifySummaryLines {
+			sb.WriteString(b.t(chatID, i18n.MsgMoreItems, len(items)-i))
+			break
+		}
+		sb.WriteString("\n" + n.line)
+	}
+	summary := notification{target: first.target, kind: first.kind, text: sb.String()}
+	if first.target == chatID {
+		summary.keyboard = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		))
+	}
+	return summary
+}
+
+// reviewLine renders a review as one line of a summary.
+func reviewLine(f locale.Formatter, fb wbapi.Feedback) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d⭐", fb.ProductValuation)
+	if fb.SubjectName != "" {
+		sb.WriteString(" · " + escapeMarkdownV1(fb.SubjectName))
+	}
+	if fb.ProductDetails.NmID != 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewArticle, fb.ProductDetails.NmID))
+	}
+	text := fb.Text
+	if text == "" {
+		text = fb.Cons
+	}
+	if text != "" {
+		if utf8.RuneCountInString(text) > notifyLineTextLen {
+			text = string([]rune(text)[:notifyLineTextLen]) + "…"
+		}
+		sb.WriteString(" — " + escapeMarkdownV1(strings.Join(strings.Fields(text), " ")))
+	}
+	return sb.String()
+}
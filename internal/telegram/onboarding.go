@@ -0,0 +1,276 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// Steps of the setup wizard, in the order they are shown.
+const (
+	wizardToken = iota + 1
+	wizardGood
+	wizardBad
+	wizardTest
+	wizardSteps = wizardTest
+)
+
+// setupComplete reports whether cfg has everything answering needs: a token
+// and both templates.
+func setupComplete(cfg *storage.UserConfig) bool {
+	return firstWizardStep(cfg) == wizardTest
+}
+
+// firstWizardStep returns the first step whose value cfg is missing, or the
+// test run when nothing is.
+func firstWizardStep(cfg *storage.UserConfig) int {
+	switch {
+	case cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set":
+		return wizardToken
+	case !templateSet(cfg.TemplateGood):
+		return wizardGood
+	case !templateSet(cfg.TemplateBad):
+		return wizardBad
+	}
+	return wizardTest
+}
+
+// handleStartCommand answers /start: users who have not finished the setup
+// are taken to the wizard at their first missing step, the others get the
+// main menu.
+func (b *Bot) handleStartCommand(chatID int64, ctx context.Context) {
+	if !b.checkChannelSubscription(chatID) {
+		b.sendChannelSubscriptionMessage(chatID)
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	cancel()
+	if err != nil || setupComplete(cfg) {
+		b.showMainMenu(chatID)
+		return
+	}
+	b.showWizardStep(chatID, firstWizardStep(cfg), cfg == nil, ctx)
+}
+
+// handleSettingsCommand answers /settings by walking through all the steps
+// from the token, with the option to keep each value.
+func (b *Bot) handleSettingsCommand(chatID int64, ctx context.Context) {
+	if !b.checkChannelSubscription(chatID) {
+		b.sendChannelSubscriptionMessage(chatID)
+		return
+	}
+	b.showWizardStep(chatID, wizardToken, false, ctx)
+}
+
+// handleWizardCallback handles the back and keep buttons, which carry the
+// step to show.
+func (b *Bot) handleWizardCallback(chatID int64, arg string, ctx context.Context) {
+	step, err := strconv.Atoi(arg)
+	if err != nil || step < wizardToken || step > wizardSteps {
+		return
+	}
+	b.showWizardStep(chatID, step, false, ctx)
+}
+
+// showWizardStep asks for the value of step, or runs the test at the last
+// one. Buttons of old messages cannot skip a missing value: the wizard stops
+// at the first step that still needs one.
+func (b *Bot) showWizardStep(chatID int64, step int, intro bool, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	cancel()
+	step = min(step, firstWizardStep(cfg))
+	if step == wizardTest {
+		b.resetUserState(chatID)
+		b.runWizardTest(chatID, cfg, ctx)
+		return
+	}
+
+	var sb strings.Builder
+	if intro {
+		sb.WriteString(b.t(chatID, i18n.MsgWizardIntro) + "\n\n")
+	}
+	sb.WriteString(b.wizardHeader(chatID, step))
+	state, prompt, current := StateWizardToken, i18n.MsgTokenPrompt, ""
+	switch step {
+	case wizardToken:
+		if cfg != nil && cfg.WBToken != "" && cfg.WBToken != "not_set" {
+			current = b.t(chatID, i18n.MsgWizardTokenSet, maskToken(cfg.WBToken))
+		}
+	case wizardGood:
+		state, prompt = StateWizardGood, i18n.MsgGoodPrompt
+		if templateSet(cfg.TemplateGood) {
+			current = b.t(chatID, i18n.MsgWizardTemplateSet, utf8.RuneCountInString(cfg.TemplateGood))
+		}
+	case wizardBad:
+		state, prompt = StateWizardBad, i18n.MsgBadPrompt
+		if templateSet(cfg.TemplateBad) {
+			current = b.t(chatID, i18n.MsgWizardTemplateSet, utf8.RuneCountInString(cfg.TemplateBad))
+		}
+	}
+	sb.WriteString(b.t(chatID, prompt))
+	if current != "" {
+		sb.WriteString("\n\n" + current)
+	}
+
+	b.setUserState(chatID, state)
+	b.SendMessageWithKeyboard(chatID, sb.String(), b.wizardKeyboard(chatID, step, current != ""))
+}
+
+// wizardHeader renders the step number and a line with the progress over
+// all the steps.
+func (b *Bot) wizardHeader(chatID int64, step int) string {
+	names := []i18n.Key{i18n.MsgWizardToken, i18n.MsgWizardGood, i18n.MsgWizardBad, i18n.MsgWizardTest}
+	marks := make([]string, len(names))
+	for i, name := range names {
+		mark := "▫️"
+		switch {
+		case i+1 < step:
+			mark = "✅"
+		case i+1 == step:
+			mark = "▶️"
+		}
+		marks[i] = mark + " " + b.t(chatID, name)
+	}
+	return b.t(chatID, i18n.MsgWizardHeader, step, wizardSteps) + "\n" + strings.Join(marks, " · ") + "\n\n"
+}
+
+// wizardKeyboard returns the buttons of an input step: back to the previous
+// step, keep the current value when there is one, and cancel.
+func (b *Bot) wizardKeyboard(chatID int64, step int, keep bool) tgbotapi.InlineKeyboardMarkup {
+	var nav []tgbotapi.InlineKeyboardButton
+	if step > wizardToken {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnWizardBack), wizardCallback(step-1)))
+	}
+	if keep {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnWizardKeep), wizardCallback(step+1)))
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnCancel), CallbackCancel),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func wizardCallback(step int) string {
+	return CallbackWizardPrefix + strconv.Itoa(step)
+}
+
+// wizardRetry repeats an input step after a rejected value, keeping its
+// buttons.
+func (b *Bot) wizardRetry(chatID int64, step int, problem string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	cancel()
+	keep := firstWizardStep(cfg) > step
+	b.SendMessageWithKeyboard(chatID, problem, b.wizardKeyboard(chatID, step, keep))
+}
+
+func (b *Bot) handleWizardTokenInput(chatID int64, text string, ctx context.Context) {
+	token := strings.TrimSpace(text)
+	if problem := b.tokenProblem(chatID, token); problem != "" {
+		b.wizardRetry(chatID, wizardToken, problem)
+		return
+	}
+	if _, err := b.storeToken(chatID, token, ctx); err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.log.Infow("token saved", "chat_id", chatID)
+	b.reloadUserService(chatID, ctx)
+	b.showWizardStep(chatID, wizardGood, false, ctx)
+}
+
+func (b *Bot) handleWizardTemplateInput(chatID int64, category, text string, ctx context.Context) {
+	step := wizardGood
+	if category == storage.VariantBad {
+		step = wizardBad
+	}
+	text = strings.TrimSpace(text)
+	if problem := b.templateProblem(chatID, text); problem != "" {
+		b.wizardRetry(chatID, step, problem)
+		return
+	}
+	if b.rejectContent(chatID, text) {
+		return
+	}
+
+	if err := b.configStore.UpdateTemplate(ctx, chatID, category, text); err != nil {
+		b.log.Errorw("failed to update template", "chat_id", chatID, "category", category, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, category)
+	b.reloadUserService(chatID, ctx)
+	b.showWizardStep(chatID, step+1, false, ctx)
+}
+
+// runWizardTest is the last step: it shows the reply the saved settings
+// would give a real review, without posting it, and offers to start
+// answering.
+func (b *Bot) runWizardTest(chatID int64, cfg *storage.UserConfig, ctx context.Context) {
+	header := b.wizardHeader(chatID, wizardTest)
+	retest := tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnWizardRetest), wizardCallback(wizardTest))
+	run := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnRun), CallbackRunNow))
+	back := func(step int) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnWizardBack), wizardCallback(step))
+	}
+
+	svc := b.userService(chatID, cfg)
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	fb, answered, err := svc.Sample(wbCtx)
+	switch {
+	case errors.Is(err, service.ErrNoReviews):
+		b.SendMessageWithKeyboard(chatID, header+b.t(chatID, i18n.MsgWizardNoReviews),
+			tgbotapi.NewInlineKeyboardMarkup(run, tgbotapi.NewInlineKeyboardRow(back(wizardBad))))
+	case err != nil:
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.log.Infow("wizard test run failed", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, header+b.t(chatID, i18n.MsgWizardTestFailed, reason),
+			tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(back(wizardToken), retest)))
+	default:
+		text := fmt.Sprintf("%s%s\n\n%s", header, formatPreview(formatterFor(cfg), fb, answered, svc.Explain(fb)), b.t(chatID, i18n.MsgWizardTestDone))
+		b.SendMessageWithKeyboard(chatID, text,
+			tgbotapi.NewInlineKeyboardMarkup(run, tgbotapi.NewInlineKeyboardRow(back(wizardBad), retest)))
+	}
+}
+
+// templateProblem checks a template sent by the user and returns the reason
+// to show, or "" if it can be saved. The content check is separate, see
+// rejectContent.
+func (b *Bot) templateProblem(chatID int64, text string) string {
+	switch {
+	case text == "":
+		return b.t(chatID, i18n.MsgTemplateEmpty)
+	case len([]rune(text)) < 10:
+		return b.t(chatID, i18n.MsgTemplateTooShort)
+	case len([]rune(text)) > MaxTemplateLength:
+		return b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength)
+	case !utf8.ValidString(text):
+		return b.t(chatID, i18n.MsgTemplateBadChars)
+	}
+	return ""
+}
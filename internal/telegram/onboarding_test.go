@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"testing"
+
+	"feedback_bot/internal/storage"
+)
+
+func TestFirstWizardStep(t *testing.T) {
+	const token, good, bad = "token", "Спасибо за отзыв!", "Нам жаль, что так вышло."
+	tests := []struct {
+		name string
+		cfg  *storage.UserConfig
+		want int
+	}{
+		{"no config", nil, wizardToken},
+		{"placeholder token", &storage.UserConfig{WBToken: "not_set", TemplateGood: good, TemplateBad: bad}, wizardToken},
+		{"no good template", &storage.UserConfig{WBToken: token, TemplateBad: bad}, wizardGood},
+		{"no bad template", &storage.UserConfig{WBToken: token, TemplateGood: good}, wizardBad},
+		{"complete", &storage.UserConfig{WBToken: token, TemplateGood: good, TemplateBad: bad}, wizardTest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := firstWizardStep(tt.cfg); got != tt.want {
+				t.Errorf("firstWizardStep = %d, want %d", got, tt.want)
+			}
+			if got := setupComplete(tt.cfg); got != (tt.want == wizardTest) {
+				t.Errorf("setupComplete = %v", got)
+			}
+		})
+	}
+}
+
+func TestMaskToken(t *testing.T) {
+	tests := []struct{ token, want string }{
+		{"", "не установлен"},
+		{"not_set", "не установлен"},
+		{"short-token", "***"},
+		{"eyJhbGciOiJFUzI1NiJ9.payload.signature", "eyJh…ture"},
+	}
+	for _, tt := range tests {
+		if got := maskToken(tt.token); got != tt.want {
+			t.Errorf("maskToken(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"fmt"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+)
+
+// wbOutageChanged tells the admins when WB goes down and when it is back.
+// Users are not notified: their cycles resume on their own.
+func (b *Bot) wbOutageChanged(degraded bool, since time.Time) {
+	f := formatterFor(nil)
+	if degraded {
+		b.log.Warnw("WB API is down, pausing cycles", "since", since.Format(time.RFC3339))
+		b.notifyAdmins(fmt.Sprintf("⚠️ *Wildberries недоступен*\n\nС %s запросы к API WB подряд завершаются ошибками сервера или сети. Циклы всех пользователей приостановлены; бот проверяет WB с растущим интервалом (до 10 минут) и продолжит работу сам.",
+			f.ShortDateTime(since)))
+		return
+	}
+	downtime := time.Since(since)
+	b.log.Infow("WB API is back, resuming cycles", "downtime", downtime.Round(time.Second).String())
+	b.notifyAdmins(fmt.Sprintf("✅ *Wildberries снова отвечает*\n\nЦиклы пользователей возобновлены. Простой: %s.", f.Duration(downtime)))
+}
+
+// DatabaseChanged tells the admins when the database stops answering the
+// watchdog's pings and when it is back; see service.DBWatchdog. Admin IDs
+// are kept in memory, so the alert does not need the database.
+func (b *Bot) DatabaseChanged(down bool, since time.Time, err error) {
+	f := formatterFor(nil)
+	if down {
+		b.notifyAdmins(fmt.Sprintf("🛑 *База данных недоступна*\n\nС %s бот не может выполнить запрос к базе: %s\n\nПока база не восстановится, циклы не отвечают на отзывы (бот не может проверить, на какие уже ответил), а настройки пользователей не сохраняются.",
+			f.ShortDateTime(since), escapeMarkdownV1(err.Error())))
+		return
+	}
+	b.notifyAdmins(fmt.Sprintf("✅ *База данных снова доступна*\n\nПростой: %s.", f.Duration(time.Since(since))))
+}
+
+// sendWBDown explains a manual run refused while WB is down.
+func (b *Bot) sendWBDown(chatID int64, cfg *storage.UserConfig, since time.Time) {
+	f := formatterFor(cfg)
+	msg := i18n.T(f.Lang, i18n.MsgWBDown, f.ShortDateTime(since))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,349 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// Outbound queue limits.
+const (
+	// outboxSize bounds queued messages; beyond it the oldest are dropped.
+	outboxSize = 10000
+	// outboxMaxAttempts is how many times a message is tried before it is
+	// dropped, the first direct send included.
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+	// outboxRate keeps retries under Telegram's limit of about 30 messages
+	// per second across chats.
+	outboxRate = 20
+	// outboxDrainTimeout is how long Shutdown waits for queued messages.
+	outboxDrainTimeout = 5 * time.Second
+)
+
+// Per-chat limits: Telegram allows about one message a second to a chat,
+// with short bursts, and 20 a minute to a group. Messages over them are
+// queued rather than sent into a 429.
+const (
+	chatRate   = 1
+	chatBurst  = 10
+	groupRate  = rate.Limit(20.0 / 60)
+	groupBurst = 3
+)
+
+type outboxItem struct {
+	chatID   int64
+	msg      tgbotapi.Chattable
+	attempts int
+}
+
+// outbox delivers messages that Telegram refused temporarily: flood limits
+// (429, retried after the retry_after it names), server errors and network
+// failures, retried with a doubling backoff. Messages to one chat keep their
+// order; while a chat has queued messages, new ones queue behind them.
+// Other refusals, such as a user who blocked the bot or malformed Markdown,
+// are final and reported to the caller of Bot.send instead. Messages over a
+// chat's limits wait in the queue too.
+//
+// The queue lives in memory: messages still queued at exit are lost.
+type outbox struct {
+	send    func(tgbotapi.Chattable) error
+	log     *zap.SugaredLogger
+	limiter *rate.Limiter
+	wake    chan struct{}
+
+	mu        sync.Mutex
+	items     []*outboxItem       // oldest first
+	notBefore map[int64]time.Time // per chat, after a refusal
+	chats     map[int64]*rate.Limiter
+	pruned    time.Time // when idle chat limiters were last dropped
+}
+
+func newOutbox(send func(tgbotapi.Chattable) error, log *zap.SugaredLogger) *outbox {
+	return &outbox{
+		send:      send,
+		log:       log,
+		limiter:   rate.NewLimiter(outboxRate, 1),
+		wake:      make(chan struct{}, 1),
+		notBefore: make(map[int64]time.Time),
+		chats:     make(map[int64]*rate.Limiter),
+	}
+}
+
+// allow takes a slot to send to chatID at now, or returns how long until
+// one frees up.
+func (o *outbox) allow(chatID int64, now time.Time) (time.Duration, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if now.Sub(o.pruned) >= time.Minute {
+		// A full limiter is the same as a new one
+		for id, l := range o.chats {
+			if l.TokensAt(now) >= float64(l.Burst()) {
+				delete(o.chats, id)
+			}
+		}
+		o.pruned = now
+	}
+	l := o.chats[chatID]
+	if l == nil {
+		l = rate.NewLimiter(chatRate, chatBurst)
+		if chatID < 0 {
+			l = rate.NewLimiter(groupRate, groupBurst)
+		}
+		o.chats[chatID] = l
+	}
+	if l.AllowN(now, 1) {
+		return 0, true
+	}
+	missing := 1 - l.TokensAt(now)
+	return time.Duration(missing / float64(l.Limit()) * float64(time.Second)), false
+}
+
+// retryDelay reports whether err is a temporary refusal worth retrying and
+// how long Telegram asked to wait; zero means no explicit wait.
+func retryDelay(err error) (time.Duration, bool) {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return 0, true // network error or timeout
+	}
+	switch {
+	case tgErr.Code == http.StatusTooManyRequests:
+		return max(time.Duration(tgErr.RetryAfter)*time.Second, time.Second), true
+	case tgErr.Code >= http.StatusInternalServerError:
+		return 0, true
+	}
+	return 0, false
+}
+
+// pending reports whether chatID has queued messages.
+func (o *outbox) pending(chatID int64) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, ok := o.notBefore[chatID]
+	return ok
+}
+
+// add queues msg after attempts failed tries; wait is the retry_after
+// Telegram asked for, if any, or for a message not tried yet (attempts == 0)
+// the time until its chat's limits let it go. A message queued behind others
+// of its chat with no wait does not delay them.
+func (o *outbox) add(chatID int64, msg tgbotapi.Chattable, attempts int, wait time.Duration) {
+	o.mu.Lock()
+	if len(o.items) >= outboxSize {
+		dropped := o.items[0]
+		o.items = o.items[1:]
+		o.forgetLocked(dropped.chatID)
+		o.log.Warnw("telegram outbox full, dropping the oldest message", "chat_id", dropped.chatID)
+		metrics.IncrementAPIError("telegram", "outbox_dropped")
+	}
+	o.items = append(o.items, &outboxItem{chatID: chatID, msg: msg, attempts: attempts})
+	at := time.Now()
+	if attempts > 0 {
+		at = at.Add(retryWait(wait, attempts))
+	} else {
+		at = at.Add(wait)
+	}
+	if cur, ok := o.notBefore[chatID]; !ok || at.After(cur) {
+		o.notBefore[chatID] = at
+	}
+	metrics.SetTelegramQueueDepth(len(o.items))
+	o.mu.Unlock()
+	o.poke()
+}
+
+// retryWait is the delay before the next try: the retry_after Telegram
+// named, or a backoff growing with the failed attempts.
+func retryWait(retryAfter time.Duration, attempts int) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	return backoff(attempts)
+}
+
+// backoff is the delay before the next try after attempts failed ones.
+func backoff(attempts int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, outboxMaxBackoff)
+}
+
+// forgetLocked drops the chat's retry time once it has nothing queued.
+func (o *outbox) forgetLocked(chatID int64) {
+	for _, it := range o.items {
+		if it.chatID == chatID {
+			return
+		}
+	}
+	delete(o.notBefore, chatID)
+}
+
+func (o *outbox) poke() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the oldest message whose chat may be retried now, or how
+// long to wait for one.
+func (o *outbox) next(now time.Time) (*outboxItem, time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	wait := time.Hour
+	seen := make(map[int64]bool)
+	for _, it := range o.items {
+		if seen[it.chatID] {
+			continue // only the first message of a chat may go
+		}
+		seen[it.chatID] = true
+		at := o.notBefore[it.chatID]
+		if !at.After(now) {
+			return it, 0
+		}
+		wait = min(wait, at.Sub(now))
+	}
+	return nil, wait
+}
+
+// run delivers queued messages until ctx is done.
+func (o *outbox) run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		it, wait := o.next(time.Now())
+		if it == nil {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return
+			case <-o.wake:
+			case <-timer.C:
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			continue
+		}
+		if err := o.limiter.Wait(ctx); err != nil {
+			return
+		}
+		if wait, ok := o.allow(it.chatID, time.Now()); !ok {
+			o.delay(it.chatID, wait)
+			continue
+		}
+		o.deliver(it)
+	}
+}
+
+// delay holds back the messages of chatID for wait.
+func (o *outbox) delay(chatID int64, wait time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.notBefore[chatID] = time.Now().Add(wait)
+}
+
+// deliver tries it once and removes it unless it is to be retried.
+func (o *outbox) deliver(it *outboxItem) {
+	err := o.send(it.msg)
+	it.attempts++
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	wait, retry := retryDelay(err)
+	if err != nil && retry && it.attempts < outboxMaxAttempts {
+		o.notBefore[it.chatID] = time.Now().Add(retryWait(wait, it.attempts))
+		o.log.Debugw("telegram message retry scheduled", "chat_id", it.chatID, "attempts", it.attempts, "err", err)
+		return
+	}
+	for i, q := range o.items {
+		if q == it {
+			o.items = append(o.items[:i], o.items[i+1:]...)
+			break
+		}
+	}
+	o.forgetLocked(it.chatID)
+	if _, ok := o.notBefore[it.chatID]; ok {
+		// The chat's next message may go right away.
+		o.notBefore[it.chatID] = time.Now()
+	}
+	metrics.SetTelegramQueueDepth(len(o.items))
+	switch {
+	case err == nil:
+		o.log.Infow("queued telegram message delivered", "chat_id", it.chatID, "attempts", it.attempts)
+	default:
+		o.log.Warnw("queued telegram message dropped", "chat_id", it.chatID, "attempts", it.attempts, "err", err)
+		metrics.IncrementAPIError("telegram", "outbox_dropped")
+	}
+}
+
+// len returns the number of queued messages.
+func (o *outbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.items)
+}
+
+// drain waits up to timeout for queued messages that are due within it to
+// go out and returns how many are left.
+func (o *outbox) drain(ctx context.Context, timeout time.Duration) int {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		n := o.len()
+		if n == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return n
+		case <-deadline.C:
+			return n
+		case <-tick.C:
+		}
+	}
+}
+
+// send delivers msg to chatID now or, if Telegram refuses it temporarily,
+// earlier messages to the chat are still queued or the chat is over its
+// limits, queues it and returns nil. Other errors are returned for the
+// caller to handle.
+func (b *Bot) send(chatID int64, msg tgbotapi.Chattable) error {
+	if b.outbox.pending(chatID) {
+		b.outbox.add(chatID, msg, 0, 0)
+		return nil
+	}
+	if wait, ok := b.outbox.allow(chatID, time.Now()); !ok {
+		b.log.Debugw("telegram message queued: chat over its limit", "chat_id", chatID, "wait", wait.String())
+		metrics.IncrementAPIError("telegram", "send_throttled")
+		b.outbox.add(chatID, msg, 0, wait)
+		return nil
+	}
+	_, err := b.api.Send(msg)
+	if err == nil {
+		return nil
+	}
+	wait, retry := retryDelay(err)
+	if !retry {
+		return err
+	}
+	b.log.Infow("telegram message queued for retry", "chat_id", chatID, "retry_after", wait.String(), "err", err)
+	metrics.IncrementAPIError("telegram", "send_queued")
+	b.outbox.add(chatID, msg, 1, wait)
+	return nil
+}
@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/pkg/metrics"
+)
+
+// handlePauseButton stops the user's scheduler and remembers the choice so
+// that the service is not started again until the user resumes it.
+func (b *Bot) handlePauseButton(chatID int64, ctx context.Context) {
+	if err := b.configStore.SetPaused(ctx, chatID, true); err != nil {
+		b.log.Errorw("failed to pause user", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.shutdownUserService(chatID)
+	b.log.Infow("user paused answering", "chat_id", chatID)
+
+	msg := b.t(chatID, i18n.MsgPaused)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+// handleResumeButton clears the paused flag and restarts the scheduler.
+func (b *Bot) handleResumeButton(chatID int64, ctx context.Context) {
+	if err := b.configStore.SetPaused(ctx, chatID, false); err != nil {
+		b.log.Errorw("failed to resume user", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.log.Infow("user resumed answering", "chat_id", chatID)
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	isConfigured := cfg != nil && cfg.WBToken != "" && cfg.WBToken != "not_set" &&
+		cfg.TemplateGood != "" && cfg.TemplateGood != "Спасибо за ваш отзыв!" &&
+		cfg.TemplateBad != "" && cfg.TemplateBad != "Спасибо за ваш отзыв!"
+	if err != nil || !isConfigured {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgResumedPending), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	b.initializeServiceForUser(chatID, cfg, ctx)
+
+	msg := b.t(chatID, i18n.MsgResumed, formatterFor(cfg).Duration(b.currentLimits().CycleInterval))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
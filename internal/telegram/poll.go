@@ -0,0 +1,174 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/time/rate"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// pollInterval is how often a user is asked whether they are happy with the bot.
+	pollInterval = 30 * 24 * time.Hour
+	// maxPollComment limits the length of a free-text suggestion.
+	maxPollComment = 2000
+	// satisfactionListLimit is the number of answers shown to the admin.
+	satisfactionListLimit = 20
+)
+
+// SendSatisfactionPolls asks every due user whether they are happy with the
+// bot. Users are marked as asked even if delivery fails, so that a blocked
+// bot is not retried every day. Intended to be run by a daily scheduler.
+func (b *Bot) SendSatisfactionPolls(ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	userIDs, err := b.configStore.ListPollDue(dbCtx, time.Now().Add(-pollInterval))
+	cancel()
+	if err != nil {
+		b.log.Errorw("satisfaction poll: failed to list users", "err", err)
+		metrics.IncrementDatabaseError("list_users")
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("😍 Отлично", pollCallback(storage.SatisfactionGreat)),
+			tgbotapi.NewInlineKeyboardButtonData("🙂 Нормально", pollCallback(storage.SatisfactionOK)),
+			tgbotapi.NewInlineKeyboardButtonData("😕 Плохо", pollCallback(storage.SatisfactionBad)),
+		),
+	)
+	const msg = `💬 *Как вам работа бота?*
+
+Оцените, пожалуйста, автоответчик — это займёт пару секунд и поможет нам сделать его лучше.`
+
+	limiter := rate.NewLimiter(rate.Limit(broadcastRate), 1)
+	sent := 0
+	for _, chatID := range userIDs {
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+		if err := b.SendMessageWithKeyboard(chatID, msg, keyboard); err != nil {
+			b.log.Debugw("satisfaction poll: delivery failed", "chat_id", chatID, "err", err)
+		} else {
+			sent++
+		}
+		dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := b.configStore.MarkPollAsked(dbCtx, chatID); err != nil {
+			b.log.Warnw("satisfaction poll: failed to mark user", "chat_id", chatID, "err", err)
+		}
+		cancel()
+	}
+	b.log.Infow("satisfaction poll sent", "due", len(userIDs), "sent", sent)
+}
+
+func pollCallback(score int) string {
+	return CallbackPollPrefix + strconv.Itoa(score)
+}
+
+// handlePollAnswer stores the score and offers to leave a suggestion.
+func (b *Bot) handlePollAnswer(chatID int64, arg string, ctx context.Context) {
+	score, err := strconv.Atoi(arg)
+	if err != nil || score < storage.SatisfactionBad || score > storage.SatisfactionGreat {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	if err := b.configStore.SaveSatisfaction(ctx, chatID, score); err != nil {
+		b.log.Errorw("failed to save satisfaction", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_satisfaction")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgSaveFailed))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingPollComment)
+	msg := "🙏 Спасибо за оценку!\n\nЕсли есть идеи, что улучшить в боте, напишите их одним сообщением."
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Пропустить", CallbackPollSkip),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handlePollCommentInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) > maxPollComment {
+		text = string([]rune(text)[:maxPollComment])
+	}
+	b.resetUserState(chatID)
+	if text != "" {
+		if err := b.configStore.SetSatisfactionComment(ctx, chatID, text); err != nil {
+			b.log.Errorw("failed to save satisfaction comment", "chat_id", chatID, "err", err)
+			metrics.IncrementDatabaseError("save_satisfaction")
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenuForUser(chatID))
+			return
+		}
+	}
+	b.SendMessageWithKeyboard(chatID, "✅ Спасибо! Мы обязательно прочитаем ваше сообщение.", b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handlePollSkip(chatID int64) {
+	b.resetUserState(chatID)
+	b.showMainMenu(chatID)
+}
+
+// handleSatisfactionCommand shows the admin the latest poll answers.
+func (b *Bot) handleSatisfactionCommand(chatID int64, ctx context.Context) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	answers, err := b.configStore.ListSatisfaction(dbCtx, satisfactionListLimit)
+	if err != nil {
+		b.log.Errorw("failed to list satisfaction", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("list_satisfaction")
+		b.SendMessage(chatID, "❌ *Ошибка при получении отзывов*\n\nПопробуйте позже.")
+		return
+	}
+	b.SendMessage(chatID, formatSatisfaction(b.chatFormatter(chatID), answers))
+}
+
+// formatSatisfaction renders poll answers with a score summary.
+func formatSatisfaction(f locale.Formatter, answers []storage.SatisfactionResponse) string {
+	if len(answers) == 0 {
+		return "💬 *Отзывы о боте*\n\nПока никто не ответил на опрос."
+	}
+
+	counts := make(map[int]int, 3)
+	for _, a := range answers {
+		counts[a.Score]++
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("💬 *Отзывы о боте* (последние %d)\n\n", len(answers)))
+	sb.WriteString(fmt.Sprintf("😍 %d · 🙂 %d · 😕 %d\n",
+		counts[storage.SatisfactionGreat], counts[storage.SatisfactionOK], counts[storage.SatisfactionBad]))
+	for _, a := range answers {
+		sb.WriteString(fmt.Sprintf("\n%s `%d` %s", satisfactionEmoji(a.Score), a.UserID, f.DateTime(a.CreatedAt)))
+		if a.Comment != "" {
+			sb.WriteString("\n" + escapeMarkdownV1(a.Comment))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func satisfactionEmoji(score int) string {
+	switch score {
+	case storage.SatisfactionGreat:
+		return "😍"
+	case storage.SatisfactionOK:
+		return "🙂"
+	default:
+		return "😕"
+	}
+}
@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/wbapi"
+)
+
+// handlePreviewButton loads one real review from WB and shows the reply the
+// current settings would give it. Nothing is posted, so it works before
+// answering is started.
+func (b *Bot) handlePreviewButton(chatID int64, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" || !templateSet(cfg.TemplateGood) || !templateSet(cfg.TemplateBad) {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPreviewSetupFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	svc := b.userService(chatID, cfg)
+	wbCtx, cancel := context.WithTimeout(ctx, wbapi.DefaultHTTPTimeout)
+	defer cancel()
+	fb, answered, err := svc.Sample(wbCtx)
+	if errors.Is(err, service.ErrNoReviews) {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPreviewNoReviews), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if err != nil {
+		reason := describeWBError(b.lang(chatID), err)
+		if reason == "" {
+			reason = b.t(chatID, i18n.MsgWBUnreachable)
+		}
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgPreviewLoadFailed)+reason, b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.SendMessageWithKeyboard(chatID, formatPreview(formatterFor(cfg), fb, answered, svc.Explain(fb)), b.previewKeyboard(chatID))
+}
+
+// formatPreview renders the review, the reply the bot would post and why.
+func formatPreview(f locale.Formatter, fb wbapi.Feedback, answered bool, d service.Decision) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewTitle))
+	if answered {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewAnsweredOnly))
+	} else {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewLatest))
+	}
+	sb.WriteString(browseReview(f, fb))
+
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewReply))
+	sb.WriteString(escapeMarkdownV1(d.Text))
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewWhy))
+	for i, step := range d.Trace {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, escapeMarkdownV1(step)))
+	}
+
+	if violations := content.Check(d.Text); len(violations) > 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewRejected, formatViolations(f.Lang, violations)))
+	} else if n := utf8.RuneCountInString(strings.TrimSpace(d.Text)); n > content.MaxLength {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewTooLong, n, content.MaxLength))
+	}
+	if answered && fb.Answer != nil && fb.Answer.Text != "" {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewOnWB) + escapeMarkdownV1(clipReview(fb.Answer.Text)) + "\n")
+	}
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgPreviewNothingSent))
+	return sb.String()
+}
+
+func (b *Bot) previewKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnAgain), CallbackPreview),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+}
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const problemsLimit = 20
+
+// stuckAnswers returns how many of the user's answers failed at least
+// service.StuckAttempts times, for the main menu badge. Errors count as 0.
+func (b *Bot) stuckAnswers(ctx context.Context, chatID int64) int {
+	list, err := b.userStore.ListFailedAnswers(ctx, chatID)
+	if err != nil {
+		return 0
+	}
+	var n int
+	for _, f := range list {
+		if f.Attempts >= service.StuckAttempts {
+			n++
+		}
+	}
+	return n
+}
+
+// handleProblemReviews lists reviews and questions whose answer could not be
+// posted, with the cause and the time of the next automatic attempt.
+func (b *Bot) handleProblemReviews(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	list, err := b.userStore.ListFailedAnswers(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list failed answers", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("list_failed_answers")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgProblemsLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(list) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnRetryNow), CallbackProblemRetry),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	b.SendMessageWithKeyboard(chatID, formatProblemReviews(formatterFor(cfg), list), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// formatProblemReviews renders failed answers, most attempts first.
+func formatProblemReviews(f locale.Formatter, list []storage.FailedAnswer) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemsTitle))
+
+	if len(list) == 0 {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemsNone))
+		return sb.String()
+	}
+
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemsIntro, f.Duration(service.RetryDelay(1))))
+	for i, fa := range list {
+		if i == problemsLimit {
+			n := int64(len(list) - problemsLimit)
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemsMore, n, i18n.Plural(f.Lang, i18n.MsgReviewNoun, n)))
+			break
+		}
+		cause, fix := failureHelp(f.Lang, fa.Cause)
+		sb.WriteString("\n")
+		if fa.Attempts >= service.StuckAttempts {
+			sb.WriteString("🔴 ")
+		}
+		if fa.Kind == storage.KindQuestion {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemQuestion))
+		} else {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemReview))
+		}
+		sb.WriteString(" `" + fa.FeedbackID + "`") // WB IDs are alphanumeric
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemAttempts, fa.Attempts))
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgFailureCause) + cause)
+		if fix != "" {
+			sb.WriteString(i18n.T(f.Lang, i18n.MsgFailureFix) + fix)
+		}
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemNextRetry, f.ShortDateTime(fa.NextRetryAt)))
+	}
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgProblemsStuck, service.StuckAttempts))
+	return sb.String()
+}
+
+// handleProblemRetry makes all failed answers due and asks the user's
+// scheduler for an early cycle.
+func (b *Bot) handleProblemRetry(chatID int64, ctx context.Context) {
+	n, err := b.userStore.RetryFailedAnswersNow(ctx, chatID)
+	if err != nil {
+		b.log.Errorw("failed to reset failed answers", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("retry_failed_answers")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	b.svcMu.RLock()
+	poller := b.schedulers[chatID]
+	b.svcMu.RUnlock()
+	if poller == nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgProblemsRetryLater, n), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	poller.RunSoon(0)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgProblemsRetrySoon, n), b.CreateMainMenuForUser(chatID))
+}
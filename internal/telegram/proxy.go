@@ -0,0 +1,183 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+	"feedback_bot/pkg/metrics"
+)
+
+// EnableWBProxy sends the WB requests of users without their own proxy
+// through proxyURL, directly while it is down. Call before RestoreServices.
+func (b *Bot) EnableWBProxy(proxyURL *url.URL) {
+	b.wbProxy = wbapi.NewProxy(proxyURL, b.wbTransport, b.log, b.wbProxyChanged)
+}
+
+// wbProxyChanged tells the admins when the bot-wide proxy goes down and
+// when it is back.
+func (b *Bot) wbProxyChanged(up bool) {
+	if up {
+		b.notifyAdmins("✅ *Прокси для WB снова работает*\n\nЗапросы к API WB опять идут через него.")
+		return
+	}
+	b.notifyAdmins(fmt.Sprintf("⚠️ *Прокси для WB недоступен*\n\nЗапросы к API WB идут напрямую. Бот проверяет прокси раз в %s и вернётся к нему сам.",
+		formatterFor(nil).Duration(wbapi.ProxyRecheckInterval)))
+}
+
+// proxyFor returns the proxy for the user's WB requests: their own, the
+// bot-wide one or nil. Users with the same proxy share it.
+func (b *Bot) proxyFor(cfg *storage.UserConfig) *wbapi.Proxy {
+	if cfg == nil || cfg.WBProxy == "" {
+		return b.wbProxy
+	}
+	b.userProxyMu.Lock()
+	defer b.userProxyMu.Unlock()
+	if p, ok := b.userProxies[cfg.WBProxy]; ok {
+		return p
+	}
+	u, err := wbapi.ParseProxy(cfg.WBProxy)
+	if err != nil {
+		b.log.Warnw("invalid user proxy, using the default", "chat_id", cfg.UserID, "err", err)
+		return b.wbProxy
+	}
+	p := wbapi.NewProxy(u, b.wbTransport, b.log, nil)
+	b.userProxies[cfg.WBProxy] = p
+	return p
+}
+
+// proxyStatus describes the proxy of the user's WB requests and whether it
+// works.
+func (b *Bot) proxyStatus(cfg *storage.UserConfig) string {
+	f := formatterFor(cfg)
+	p := b.proxyFor(cfg)
+	if p == nil {
+		return i18n.T(f.Lang, i18n.MsgProxyNone)
+	}
+	name := i18n.T(f.Lang, i18n.MsgProxyShared)
+	if cfg.WBProxy != "" {
+		name = "`" + p.String() + "`"
+	}
+	if up, since := p.Up(); !up {
+		return i18n.T(f.Lang, i18n.MsgProxyDown, name, f.ShortDateTime(since))
+	}
+	return name
+}
+
+// proxyDisplay returns the info-view line for the user's own proxy, or ""
+// without one.
+func proxyDisplay(cfg *storage.UserConfig) string {
+	if cfg.WBProxy == "" {
+		return ""
+	}
+	u, err := url.Parse(cfg.WBProxy)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("*Прокси:* `%s`\n", u.Redacted())
+}
+
+// handleProxyCommand handles "/proxy [url|off]" for users whose hosting
+// cannot reach WB directly.
+func (b *Bot) handleProxyCommand(chatID int64, args string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	if strings.TrimSpace(args) == "" {
+		msg := b.t(chatID, i18n.MsgProxyPrompt, b.proxyStatus(cfg))
+		b.SendMessage(chatID, msg)
+		return
+	}
+
+	msg, err := b.setProxy(chatID, cfg, args)
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handleCustomProxyButton(chatID int64) {
+	b.setUserState(chatID, StateWaitingProxy)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgProxyAsk), b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleProxyInput(chatID int64, text string) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil {
+		b.resetUserState(chatID)
+		b.showMainMenu(chatID)
+		return
+	}
+	msg, err := b.setProxy(chatID, cfg, text)
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, "❌ "+err.Error(), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	b.resetUserState(chatID)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+func (b *Bot) handleProxyOff(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil {
+		b.showMainMenu(chatID)
+		return
+	}
+	msg, err := b.setProxy(chatID, cfg, "off")
+	if err != nil {
+		b.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+// setProxy checks and stores the user's proxy, "off" (or "-") clearing it,
+// then restarts a running service so the new client is used. A proxy that
+// cannot reach the user's WB API address is not stored. It returns the
+// confirmation; errors are user-facing.
+func (b *Bot) setProxy(chatID int64, cfg *storage.UserConfig, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	var proxy string
+	msg := b.t(chatID, i18n.MsgProxyOff)
+	if !strings.EqualFold(raw, "off") && raw != "-" {
+		u, err := wbapi.ParseProxy(raw)
+		if err != nil {
+			return "", errors.New(b.t(chatID, i18n.MsgProxyBadScheme))
+		}
+		checkCtx, cancel := context.WithTimeout(context.Background(), wbapi.DefaultHTTPTimeout)
+		defer cancel()
+		if err := wbapi.NewProxy(u, b.wbTransport, b.log, nil).Check(checkCtx, b.baseURLFor(cfg)); err != nil {
+			b.log.Infow("user proxy check failed", "chat_id", chatID, "proxy", u.Redacted(), "err", err)
+			return "", errors.New(b.t(chatID, i18n.MsgProxyCheckFailed))
+		}
+		proxy = u.String()
+		msg = b.t(chatID, i18n.MsgProxySet, u.Redacted())
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.configStore.SetWBProxy(dbCtx, chatID, proxy); err != nil {
+		b.log.Errorw("failed to save proxy", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		return "", errors.New(b.t(chatID, i18n.MsgSaveFailedInline))
+	}
+	b.log.Infow("wb proxy changed", "chat_id", chatID, "proxy_set", proxy != "")
+	b.reloadUserService(chatID, b.ctx)
+	return msg, nil
+}
@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// questionTemplateDisplay renders question answering status for the info screen.
+func questionTemplateDisplay(cfg *storage.UserConfig) string {
+	if cfg.TemplateQuestion == "" {
+		return "выключены"
+	}
+	return fmt.Sprintf("включены (%d символов)", len([]rune(cfg.TemplateQuestion)))
+}
+
+func (b *Bot) handleQuestionTemplateButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingQuestionTemplate)
+	msg := fmt.Sprintf(`❓ *Ответ на вопросы покупателей*
+
+Сейчас: %s
+
+Отправьте текст, которым бот будет отвечать на новые вопросы о товарах.
+
+*Пример:*
+"Здравствуйте! Спасибо за вопрос. Подробные характеристики указаны в карточке товара, а если остались вопросы — напишите нам в чат."
+
+Чтобы отключить ответы на вопросы, отправьте "выкл".`, questionTemplateDisplay(cfg))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleQuestionTemplateInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if lower := strings.ToLower(text); lower == "выкл" || lower == "off" {
+		text = ""
+	} else {
+		if len([]rune(text)) < 10 {
+			b.SendMessageWithKeyboard(chatID, "⚠️ Текст слишком короткий. Рекомендуется минимум 20-30 символов.", b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if len([]rune(text)) > MaxTemplateLength {
+			b.SendMessageWithKeyboard(chatID, fmt.Sprintf("⚠️ Текст слишком длинный. Максимальная длина: %d символов.", MaxTemplateLength), b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if !utf8.ValidString(text) {
+			b.SendMessageWithKeyboard(chatID, "❌ Текст содержит некорректные символы. Используйте только допустимые символы.", b.CreateCancelKeyboard(chatID))
+			return
+		}
+		if b.rejectContent(chatID, text) {
+			return
+		}
+	}
+
+	if err := b.configStore.UpdateQuestionTemplate(ctx, chatID, text); err != nil {
+		b.log.Errorw("failed to save question template", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, templateAuditDetails("question", text))
+	b.reloadUserService(chatID, ctx)
+
+	msg := "✅ Шаблон ответа на вопросы сохранен! Бот будет отвечать на новые вопросы при каждом запуске."
+	if text == "" {
+		msg = "✅ Ответы на вопросы отключены."
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
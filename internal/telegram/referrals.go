@@ -0,0 +1,165 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// referralPrefix starts the /start parameter of invite links, followed
+	// by the referrer's user ID: t.me/<bot>?start=ref_12345.
+	referralPrefix = "ref_"
+	// topReferrersLimit is how many referrers "/admin referrals" lists.
+	topReferrersLimit = 20
+)
+
+// referralLink returns the user's invite link.
+func (b *Bot) referralLink(chatID int64) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%d", b.username, referralPrefix, chatID)
+}
+
+// referralBonusDays is the paid access credited for a referred seller; zero
+// when the bot is free.
+func (b *Bot) referralBonusDays() int {
+	if b.plan == nil {
+		return 0
+	}
+	return b.plan.ReferralDays
+}
+
+// handleStartParam handles the parameter of "/start <param>" deep links.
+// Only invite links are known; anything else opens the menu as usual.
+func (b *Bot) handleStartParam(chatID int64, param string) {
+	ref, ok := strings.CutPrefix(param, referralPrefix)
+	if !ok {
+		return
+	}
+	referrerID, err := strconv.ParseInt(ref, 10, 64)
+	if err != nil || referrerID == chatID {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Only new users can be referred, and only by users of the bot.
+	if cfg, err := b.configStore.GetUserConfig(ctx, chatID); err != nil || cfg != nil {
+		return
+	}
+	if cfg, err := b.configStore.GetUserConfig(ctx, referrerID); err != nil || cfg == nil {
+		return
+	}
+	saved, err := b.userStore.SaveReferral(ctx, chatID, referrerID)
+	if err != nil {
+		b.log.Errorw("failed to save referral", "chat_id", chatID, "referrer_id", referrerID, "err", err)
+		metrics.IncrementDatabaseError("save_referral")
+		return
+	}
+	if !saved {
+		return
+	}
+	b.log.Infow("referred user joined", "chat_id", chatID, "referrer_id", referrerID)
+	b.SendMessage(referrerID, b.t(referrerID, i18n.MsgReferralJoined)+b.referralBonusText(referrerID))
+}
+
+// creditReferral credits the user's referrer once the user has saved a WB
+// token, so that opening the link alone earns nothing.
+func (b *Bot) creditReferral(ctx context.Context, chatID int64) {
+	days := b.referralBonusDays()
+	r, credited, err := b.userStore.CreditReferral(ctx, chatID, days)
+	if err != nil {
+		b.log.Errorw("failed to credit referral", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("credit_referral")
+		return
+	}
+	if !credited {
+		return
+	}
+	b.log.Infow("referral credited", "chat_id", chatID, "referrer_id", r.ReferrerID, "bonus_days", days)
+	msg := b.t(r.ReferrerID, i18n.MsgReferralCredited)
+	if days > 0 {
+		b.audit(r.ReferrerID, chatID, storage.AuditReferralBonus, fmt.Sprintf("user=%d days=%d", chatID, days))
+		msg += b.t(r.ReferrerID, i18n.MsgReferralExtended, days)
+	}
+	b.SendMessage(r.ReferrerID, msg)
+}
+
+// referralBonusText describes what the referrer gets.
+func (b *Bot) referralBonusText(chatID int64) string {
+	if days := b.referralBonusDays(); days > 0 {
+		return b.t(chatID, i18n.MsgReferralBonusDays, days)
+	}
+	return b.t(chatID, i18n.MsgReferralBonusNone)
+}
+
+// referralsDisplay renders the user's referral counts for the info screen.
+func (b *Bot) referralsDisplay(ctx context.Context, chatID int64) string {
+	rs, err := b.userStore.ReferralStats(ctx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load referral stats", "chat_id", chatID, "err", err)
+		return "—"
+	}
+	return fmt.Sprintf("%d (подключили магазин: %d)", rs.Invited, rs.Credited)
+}
+
+func (b *Bot) handleInviteButton(chatID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rs, err := b.userStore.ReferralStats(ctx, chatID)
+	if err != nil {
+		b.log.Errorw("failed to load referral stats", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("referral_stats")
+	}
+
+	msg := b.t(chatID, i18n.MsgInviteTitle, b.referralLink(chatID))
+	if days := b.referralBonusDays(); days > 0 {
+		msg += b.t(chatID, i18n.MsgInviteBonus, days)
+	}
+	msg += b.t(chatID, i18n.MsgInviteStats, rs.Invited, rs.Credited)
+	if rs.BonusDays > 0 {
+		msg += b.t(chatID, i18n.MsgInviteBonusDays, rs.BonusDays)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	)))
+}
+
+// handleAdminReferralsCommand handles "/admin referrals": the users who
+// brought the most sellers.
+func (b *Bot) handleAdminReferralsCommand(chatID int64) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	top, err := b.userStore.TopReferrers(ctx, topReferrersLimit)
+	if err != nil {
+		b.log.Errorw("failed to load top referrers", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("top_referrers")
+		b.SendMessage(chatID, "❌ Не удалось получить статистику приглашений. Подробности в логе.")
+		return
+	}
+	if len(top) == 0 {
+		b.SendMessage(chatID, "🤝 По ссылкам-приглашениям пока никто не пришёл.")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🤝 *Лучшие пригласившие*\n\n")
+	for i, rs := range top {
+		fmt.Fprintf(&sb, "%d. `%d` — подключили магазин %d из %d", i+1, rs.ReferrerID, rs.Credited, rs.Invited)
+		if rs.BonusDays > 0 {
+			fmt.Fprintf(&sb, ", бонус %d дн.", rs.BonusDays)
+		}
+		sb.WriteString("\n")
+	}
+	b.SendMessage(chatID, sb.String())
+}
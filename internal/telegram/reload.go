@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"slices"
+
+	"golang.org/x/time/rate"
+)
+
+// currentLimits returns the limits in effect; Reload may change them.
+func (b *Bot) currentLimits() Limits {
+	b.settingsMu.RLock()
+	defer b.settingsMu.RUnlock()
+	return b.limits
+}
+
+// channels returns the required channels in effect.
+func (b *Bot) channels() []Channel {
+	b.settingsMu.RLock()
+	defer b.settingsMu.RUnlock()
+	return b.requiredChannels
+}
+
+// Reload applies settings re-read from the configuration to the running bot:
+// the cycle interval, the per-user Telegram and WB rate limits, the WB budget
+// shared by all users, the required channels and the users exempt from them. Users' services and their
+// jobs in the cycle pool are updated in place, so no cycle is restarted or
+// skipped.
+//
+// The maintenance mode is read from storage again, so that replicas pick
+// up one switched on another instance.
+//
+// WBMaxConns, MaxConcurrentUpdates and MaxConcurrentCycles size pools built
+// by New; changes to them are logged and take effect after a restart.
+func (b *Bot) Reload(limits Limits, sub Subscription) {
+	limits = limits.withDefaults()
+	channels := normalizeChannels(sub.Channels)
+
+	b.settingsMu.Lock()
+	old := b.limits
+	if limits.WBMaxConns != old.WBMaxConns || limits.MaxConcurrentUpdates != old.MaxConcurrentUpdates || limits.MaxConcurrentCycles != old.MaxConcurrentCycles {
+		b.log.Warnw("pool sizes changed, restart to apply them",
+			"wb_max_conns", limits.WBMaxConns,
+			"max_concurrent_updates", limits.MaxConcurrentUpdates,
+			"max_concurrent_cycles", limits.MaxConcurrentCycles)
+	}
+	limits.WBMaxConns = old.WBMaxConns
+	limits.MaxConcurrentUpdates = old.MaxConcurrentUpdates
+	limits.MaxConcurrentCycles = old.MaxConcurrentCycles
+	channelChanged := !slices.Equal(channels, b.requiredChannels)
+	b.limits = limits
+	b.requiredChannels = channels
+	b.configExempt = idSet(sub.Exempt)
+	b.settingsMu.Unlock()
+
+	if limits.RequestsPerMinute != old.RequestsPerMinute || limits.Burst != old.Burst {
+		b.rateLimitMu.Lock()
+		for _, l := range b.userRateLimiters {
+			l.SetBurst(limits.Burst)
+			l.SetLimit(rate.Limit(limits.RequestsPerMinute) / 60)
+		}
+		b.rateLimitMu.Unlock()
+	}
+
+	if limits.WBGlobalRPS != old.WBGlobalRPS || limits.WBGlobalBurst != old.WBGlobalBurst {
+		b.wbBudget.SetLimit(limits.WBGlobalRPS, limits.WBGlobalBurst)
+	}
+
+	wbChanged := limits.WBRPS != old.WBRPS || limits.WBBurst != old.WBBurst
+	intervalChanged := limits.CycleInterval != old.CycleInterval
+	if wbChanged || intervalChanged {
+		b.svcMu.RLock()
+		if wbChanged {
+			for _, svc := range b.services {
+				svc.SetRateLimit(limits.WBRPS, limits.WBBurst)
+			}
+		}
+		if intervalChanged {
+			for _, job := range b.schedulers {
+				job.SetInterval(limits.CycleInterval)
+			}
+		}
+		b.svcMu.RUnlock()
+	}
+
+	if channelChanged {
+		// A user may now miss a new channel; a channel's ID may have changed
+		b.subscriptionCacheMu.Lock()
+		clear(b.subscriptionCache)
+		clear(b.channelChats)
+		b.subscriptionCacheMu.Unlock()
+	}
+
+	b.loadMaintenance()
+
+	b.log.Infow("configuration reloaded",
+		"cycle_interval", limits.CycleInterval,
+		"tg_rate_limit", limits.RequestsPerMinute,
+		"tg_rate_burst", limits.Burst,
+		"wb_rps", limits.WBRPS,
+		"wb_burst", limits.WBBurst,
+		"wb_global_rps", limits.WBGlobalRPS,
+		"wb_global_burst", limits.WBGlobalBurst,
+		"channels", channelKeys(channels),
+		"subscription_exempt", len(sub.Exempt))
+}
@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// restartUserService tears down the user's client, service and scheduler and
+// builds them again from the stored config. Other users are not affected.
+// It reports whether a service is running afterwards; a user who is not
+// fully configured or is paused ends up without one.
+func (b *Bot) restartUserService(chatID int64) (bool, error) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil {
+		return false, err
+	}
+
+	b.shutdownUserService(chatID)
+	if cfg == nil || cfg.Paused || b.isBanned(chatID) ||
+		cfg.WBToken == "" || cfg.WBToken == "not_set" ||
+		cfg.TemplateGood == "" || cfg.TemplateGood == "Спасибо за ваш отзыв!" ||
+		cfg.TemplateBad == "" || cfg.TemplateBad == "Спасибо за ваш отзыв!" {
+		return false, nil
+	}
+	b.startUserService(chatID, cfg, 0)
+	b.log.Infow("service restarted", "chat_id", chatID)
+	return b.getServiceForUser(chatID) != nil, nil
+}
+
+func (b *Bot) handleRestartButton(chatID int64) {
+	running, err := b.restartUserService(chatID)
+	if err != nil {
+		b.log.Errorw("failed to restart service", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, "❌ Не удалось перезапустить сервис. Попробуйте позже.", b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if !running {
+		msg := `⚠️ *Сервис не запущен*
+
+Бот не полностью настроен или автоответы остановлены. Проверьте настройки через "📋 Информация".`
+		b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+		return
+	}
+	msg := `🔄 *Сервис перезапущен*
+
+Настройки загружены заново, обработка отзывов начнётся в ближайшие секунды.`
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
+
+// handleRestartCommand handles the admin command "/restart <user_id>".
+func (b *Bot) handleRestartCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil || userID <= 0 {
+		b.SendMessage(chatID, "Использование: `/restart <user_id>`")
+		return
+	}
+
+	running, err := b.restartUserService(userID)
+	switch {
+	case err != nil:
+		b.log.Errorw("admin restart failed", "user_id", userID, "err", err)
+		b.SendMessage(chatID, fmt.Sprintf("❌ Не удалось перезапустить сервис пользователя `%d`.", userID))
+	case !running:
+		b.SendMessage(chatID, fmt.Sprintf("⚠️ Сервис пользователя `%d` остановлен: бот не настроен или автоответы приостановлены.", userID))
+	default:
+		b.log.Infow("admin restarted user service", "admin_id", chatID, "user_id", userID)
+		b.SendMessage(chatID, fmt.Sprintf("🔄 Сервис пользователя `%d` перезапущен.", userID))
+	}
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/service"
+	"feedback_bot/pkg/metrics"
+)
+
+// handleAdminCleanupCommand handles "/admin cleanup <days>": a one-off run of
+// the retention job that deletes answer history older than days for every
+// user, after confirmation.
+func (b *Bot) handleAdminCleanupCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+
+	minDays := int(service.MinProcessedRetention / (24 * time.Hour))
+	days, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil || days < minDays {
+		b.SendMessage(chatID, fmt.Sprintf("Использование: `/admin cleanup <дней>`, не меньше %d.\n\nУдаляет историю ответов старше указанного срока у всех пользователей, включая архив. Ночная очистка настраивается переменной `PROCESSED_RETENTION_DAYS`.", minDays))
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days)
+	b.confirmAdminAction(chatID,
+		fmt.Sprintf("🗑 Удалить историю ответов старше %d дней (до %s) у всех пользователей?\n\nЗаписи удаляются безвозвратно, включая архив. Статистика и выгрузка истории за этот период станут недоступны.",
+			days, b.chatFormatter(chatID).Date(cutoff)),
+		fmt.Sprintf("очистка истории старше %d дней", days),
+		func() { b.runCleanup(chatID, days) })
+}
+
+func (b *Bot) runCleanup(adminID int64, days int) {
+	b.SendMessage(adminID, "⏳ Очистка запущена…")
+	ctx, cancel := context.WithTimeout(b.ctx, 10*time.Minute)
+	defer cancel()
+	deleted, err := service.PruneProcessed(ctx, b.userStore, b.configStore, time.Duration(days)*24*time.Hour, b.log)
+	if err != nil {
+		metrics.IncrementDatabaseError("prune_processed")
+		b.SendMessage(adminID, fmt.Sprintf("❌ Очистка прервана: удалено записей — %s. Подробности в логе.", formatterFor(nil).Count(deleted)))
+		return
+	}
+	b.log.Infow("admin cleanup done", "admin_id", adminID, "days", days, "deleted", deleted)
+	b.SendMessage(adminID, fmt.Sprintf("✅ Очистка завершена. Удалено записей: %s.", formatterFor(nil).Count(deleted)))
+}
@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxCallbackData is the longest callback data Telegram accepts, in bytes.
+const maxCallbackData = 64
+
+// callbackQuery is a pressed inline button: who pressed it, on which message
+// and the arguments encoded after the route's prefix.
+type callbackQuery struct {
+	ChatID    int64
+	MessageID int
+	Data      string
+	Args      []string // one per argument the route declares
+}
+
+// Arg returns the i-th argument, "" if there is none.
+func (q callbackQuery) Arg(i int) string {
+	if i < 0 || i >= len(q.Args) {
+		return ""
+	}
+	return q.Args[i]
+}
+
+// callbackRoute is the handler for one button or family of buttons.
+type callbackRoute struct {
+	prefix string // "" for exact routes
+	args   int    // arguments after the prefix, separated by ':'
+	gated  bool   // needs the channel subscription
+	handle func(ctx context.Context, q callbackQuery)
+}
+
+// callbackRouter dispatches callback data to handlers. Exact routes match
+// the whole data ("main_menu"); prefix routes match data starting with
+// their prefix ("browse_ans:<id>", "browse_cmpr:<reason>:<id>") and get the
+// rest split into a fixed number of non-empty arguments, the last of which
+// may contain ':'. The longest matching prefix wins.
+type callbackRouter struct {
+	exact    map[string]callbackRoute
+	prefixes []callbackRoute // longest prefix first
+}
+
+func newCallbackRouter() *callbackRouter {
+	return &callbackRouter{exact: make(map[string]callbackRoute)}
+}
+
+// handle registers fn for callback data equal to data. Registering the
+// same data twice panics.
+func (r *callbackRouter) handle(data string, gated bool, fn func(ctx context.Context, q callbackQuery)) {
+	if _, dup := r.exact[data]; dup {
+		panic(fmt.Sprintf("telegram: callback %q registered twice", data))
+	}
+	r.exact[data] = callbackRoute{gated: gated, handle: fn}
+}
+
+// handlePrefix registers fn for callback data made of prefix and args
+// arguments (at least one), as built by callbackData. Registering the same
+// prefix twice panics.
+func (r *callbackRouter) handlePrefix(prefix string, args int, gated bool, fn func(ctx context.Context, q callbackQuery)) {
+	if prefix == "" || args < 1 {
+		panic(fmt.Sprintf("telegram: callback prefix %q needs a name and arguments", prefix))
+	}
+	for _, rt := range r.prefixes {
+		if rt.prefix == prefix {
+			panic(fmt.Sprintf("telegram: callback prefix %q registered twice", prefix))
+		}
+	}
+	r.prefixes = append(r.prefixes, callbackRoute{prefix: prefix, args: args, gated: gated, handle: fn})
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+}
+
+// match returns the route for data with its decoded arguments. Data with a
+// known prefix but the wrong number of arguments does not match.
+func (r *callbackRouter) match(data string) (callbackRoute, []string, bool) {
+	if rt, ok := r.exact[data]; ok {
+		return rt, nil, true
+	}
+	for _, rt := range r.prefixes {
+		rest, ok := strings.CutPrefix(data, rt.prefix)
+		if !ok {
+			continue
+		}
+		args := strings.SplitN(rest, ":", rt.args)
+		if len(args) != rt.args {
+			return callbackRoute{}, nil, false
+		}
+		for _, a := range args {
+			if a == "" {
+				return callbackRoute{}, nil, false
+			}
+		}
+		return rt, args, true
+	}
+	return callbackRoute{}, nil, false
+}
+
+// callbackData encodes a button for a prefix route. Arguments must not be
+// empty and, except for the last one, must not contain ':'. Data over
+// Telegram's 64-byte limit would make the whole message fail. Arguments
+// come from WB and the database, so such data is an error rather than a
+// panic; feedback IDs and Telegram user IDs fit with room to spare.
+func callbackData(prefix string, args ...string) (string, error) {
+	for i, a := range args {
+		if a == "" || (i < len(args)-1 && strings.Contains(a, ":")) {
+			return "", fmt.Errorf("telegram: bad callback argument %q for %q", a, prefix)
+		}
+	}
+	data := prefix + strings.Join(args, ":")
+	if len(data) > maxCallbackData {
+		return "", fmt.Errorf("telegram: callback data %q is longer than %d bytes", data, maxCallbackData)
+	}
+	return data, nil
+}
+
+// appendCallbackButton appends a button for a prefix route to row. A button
+// whose data callbackData rejects is left out and logged, so the rest of the
+// message is still sent.
+func (b *Bot) appendCallbackButton(row []tgbotapi.InlineKeyboardButton, text, prefix string, args ...string) []tgbotapi.InlineKeyboardButton {
+	data, err := callbackData(prefix, args...)
+	if err != nil {
+		b.log.Warnw("button left out", "text", text, "err", err)
+		return row
+	}
+	return append(row, tgbotapi.NewInlineKeyboardButtonData(text, data))
+}
@@ -0,0 +1,113 @@
+package telegram
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCallbackData(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		args   []string
+		want   string
+		ok     bool
+	}{
+		{"one argument", "browse_ans:", []string{"YX52RZEBhH9mrcYdEJuD"}, "browse_ans:YX52RZEBhH9mrcYdEJuD", true},
+		{"two arguments", "browse_cmpr:", []string{"3", "YX52RZEBhH9mrcYdEJuD"}, "browse_cmpr:3:YX52RZEBhH9mrcYdEJuD", true},
+		{"separator in the last argument", "tz:", []string{"UTC:+3"}, "tz:UTC:+3", true},
+		{"exactly 64 bytes", "d:", []string{strings.Repeat("x", maxCallbackData-2)}, "d:" + strings.Repeat("x", maxCallbackData-2), true},
+		{"empty argument", "browse_ans:", []string{""}, "", false},
+		{"empty first of two", "browse_cmpr:", []string{"", "id"}, "", false},
+		{"empty last of two", "browse_cmpr:", []string{"3", ""}, "", false},
+		{"separator in a leading argument", "browse_cmpr:", []string{"3:4", "id"}, "", false},
+		{"over 64 bytes", "d:", []string{strings.Repeat("x", maxCallbackData-1)}, "", false},
+		{"multibyte over 64 bytes", "d:", []string{strings.Repeat("ж", 32)}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callbackData(tt.prefix, tt.args...)
+			if (err == nil) != tt.ok {
+				t.Fatalf("callbackData = %q, %v; want ok %v", got, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("callbackData = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackRouterRoundTrip(t *testing.T) {
+	r := newCallbackRouter()
+	noop := func(context.Context, callbackQuery) {}
+	r.handle("browse", false, noop)
+	r.handlePrefix("browse_", 1, false, noop)
+	r.handlePrefix("browse_cmpr:", 2, false, noop)
+	r.handlePrefix("tz:", 1, false, noop)
+
+	tests := []struct {
+		prefix string
+		args   []string
+	}{
+		{"browse_", []string{"2"}},
+		{"browse_cmpr:", []string{"3", "YX52RZEBhH9mrcYdEJuD"}},
+		{"browse_cmpr:", []string{"3", "id:with:colons"}},
+		{"tz:", []string{"Europe/Moscow"}},
+	}
+	for _, tt := range tests {
+		data, err := callbackData(tt.prefix, tt.args...)
+		if err != nil {
+			t.Fatalf("callbackData(%q, %q): %v", tt.prefix, tt.args, err)
+		}
+		rt, args, ok := r.match(data)
+		if !ok || rt.prefix != tt.prefix || !slices.Equal(args, tt.args) {
+			t.Errorf("match(%q) = %q %q %v, want %q %q", data, rt.prefix, args, ok, tt.prefix, tt.args)
+		}
+	}
+
+	for _, data := range []string{"", "unknown", "tz:", "browse_cmpr:3", "browse_cmpr:3:", "browse_cmpr::id"} {
+		if rt, args, ok := r.match(data); ok {
+			t.Errorf("match(%q) = %q %q, want no match", data, rt.prefix, args)
+		}
+	}
+	if rt, args, ok := r.match("browse"); !ok || rt.prefix != "" || args != nil {
+		t.Errorf("match(%q) = %q %q %v, want the exact route", "browse", rt.prefix, args, ok)
+	}
+}
+
+func TestCallbackRouterRegistrationPanics(t *testing.T) {
+	noop := func(context.Context, callbackQuery) {}
+	tests := []struct {
+		name     string
+		register func(r *callbackRouter)
+	}{
+		{"duplicate data", func(r *callbackRouter) { r.handle("a", false, noop); r.handle("a", false, noop) }},
+		{"duplicate prefix", func(r *callbackRouter) { r.handlePrefix("a:", 1, false, noop); r.handlePrefix("a:", 2, false, noop) }},
+		{"empty prefix", func(r *callbackRouter) { r.handlePrefix("", 1, false, noop) }},
+		{"no arguments", func(r *callbackRouter) { r.handlePrefix("a:", 0, false, noop) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("registration did not panic")
+				}
+			}()
+			tt.register(newCallbackRouter())
+		})
+	}
+}
+
+func TestAppendCallbackButtonSkipsBadData(t *testing.T) {
+	b := &Bot{log: zap.NewNop().Sugar()}
+	row := b.appendCallbackButton(nil, "ok", CallbackDraftSendPrefix, "id")
+	row = b.appendCallbackButton(row, "too long", CallbackDraftSendPrefix, strings.Repeat("x", maxCallbackData))
+	row = b.appendCallbackButton(row, "empty", CallbackDraftSendPrefix, "")
+	if len(row) != 1 || row[0].Text != "ok" || *row[0].CallbackData != CallbackDraftSendPrefix+"id" {
+		t.Errorf("row = %+v, want only the valid button", row)
+	}
+}
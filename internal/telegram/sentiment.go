@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/pkg/metrics"
+)
+
+func (b *Bot) handleSentimentButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := "выключен"
+	button := tgbotapi.NewInlineKeyboardButtonData("✅ Включить", CallbackSentimentOn)
+	if cfg.SentimentRouting {
+		status = "включён"
+		button = tgbotapi.NewInlineKeyboardButtonData("🚫 Выключить", CallbackSentimentOff)
+	}
+	msg := `😟 *Анализ текста отзыва*
+
+Сейчас: ` + status + `
+
+Бот читает текст отзыва и, если при оценке 4–5★ покупатель жалуется («пришёл с дефектом», «не тот размер»), отвечает шаблоном для отрицательных отзывов.
+
+Проверить, как это работает, можно в «🧪 Что ответит бот?».`
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handleSentimentToggle(chatID int64, on bool, ctx context.Context) {
+	if err := b.configStore.SetSentimentRouting(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save sentiment routing", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := "✅ Анализ текста выключен. Шаблон выбирается только по оценке."
+	if on {
+		msg = "✅ Анализ текста включён. Жалобы в положительных отзывах получат шаблон для отрицательных."
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShutdownReport summarises what a shutdown interrupted so that deploys
+// can be audited.
+type ShutdownReport struct {
+	Uptime               time.Duration
+	StoppedServices      int  // user schedulers stopped
+	DrainedCycles        int  // cycles that were running when stopped and finished within the grace period
+	InterruptedCycles    int  // cycles cancelled when the grace period ran out
+	BroadcastInterrupted bool // an admin broadcast was still being delivered
+	RestoreOnStart       int  // users whose services start again on the next launch; -1 if unknown
+	UndeliveredMessages  int  // Telegram messages still waiting for a retry
+}
+
+// SendShutdownReport sends the report to the admins. No-op without admins.
+func (b *Bot) SendShutdownReport(r ShutdownReport) {
+	f := formatterFor(nil)
+
+	var sb strings.Builder
+	sb.WriteString("🛑 *Бот остановлен*\n\n")
+	fmt.Fprintf(&sb, "Время работы: %s\n", f.Duration(r.Uptime))
+	fmt.Fprintf(&sb, "Остановлено сервисов: %s\n", f.Count(int64(r.StoppedServices)))
+	fmt.Fprintf(&sb, "Завершено циклов при остановке: %s\n", f.Count(int64(r.DrainedCycles)))
+	fmt.Fprintf(&sb, "Прервано циклов: %s\n", f.Count(int64(r.InterruptedCycles)))
+	if r.BroadcastInterrupted {
+		sb.WriteString("⚠️ Рассылка прервана\n")
+	}
+	if r.UndeliveredMessages > 0 {
+		fmt.Fprintf(&sb, "⚠️ Не доставлено сообщений из очереди: %s\n", f.Count(int64(r.UndeliveredMessages)))
+	}
+	if r.RestoreOnStart >= 0 {
+		fmt.Fprintf(&sb, "Будет восстановлено при запуске: %s", f.Count(int64(r.RestoreOnStart)))
+	} else {
+		sb.WriteString("Будет восстановлено при запуске: неизвестно")
+	}
+
+	b.notifyAdmins(sb.String())
+}
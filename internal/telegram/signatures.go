@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// maxSignatures limits the closing lines per user.
+	maxSignatures = 10
+	// maxSignatureLength keeps a signed reply within what WB accepts when
+	// the template itself is close to MaxTemplateLength.
+	maxSignatureLength = 200
+)
+
+// signatureOption loads the user's signatures into a service option.
+// Returns nil if there are none or they cannot be loaded.
+func (b *Bot) signatureOption(chatID int64) service.Option {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, err := b.configStore.ListTemplateVariants(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load signatures, ignoring", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		return nil
+	}
+	var texts []string
+	for _, v := range list {
+		if v.Category == storage.VariantSignature {
+			texts = append(texts, v.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+	return service.WithSignatures(texts)
+}
+
+func (b *Bot) handleSignaturesButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, err := b.configStore.ListTemplateVariants(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list signatures", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSignaturesLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	var signatures []storage.TemplateVariant
+	for _, v := range list {
+		if v.Category == storage.VariantSignature {
+			signatures = append(signatures, v)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.t(chatID, i18n.MsgSignaturesTitle))
+	if len(signatures) == 0 {
+		sb.WriteString(b.t(chatID, i18n.MsgSignaturesEmpty))
+	}
+	for _, v := range signatures {
+		fmt.Fprintf(&sb, "#%d: %s\n", v.Idx, variantPreview(v.Text))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(signatures) < maxSignatures {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnSignatureAdd), CallbackSignatureAdd),
+		))
+	}
+	var delRow []tgbotapi.InlineKeyboardButton
+	for _, v := range signatures {
+		label := fmt.Sprintf("🗑 #%d", v.Idx)
+		delRow = b.appendCallbackButton(delRow, label, CallbackVariantDelPrefix, v.Category, strconv.Itoa(v.Idx))
+		if len(delRow) == 4 {
+			rows = append(rows, delRow)
+			delRow = nil
+		}
+	}
+	if len(delRow) > 0 {
+		rows = append(rows, delRow)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnBackToVariants), CallbackVariants),
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	b.SendMessageWithKeyboard(chatID, sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (b *Bot) handleSignatureAddButton(chatID int64) {
+	b.setUserState(chatID, StateWaitingSignature)
+	msg := b.t(chatID, i18n.MsgSignaturePrompt, maxSignatureLength)
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleSignatureInput(chatID int64, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSignatureEmpty), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if utf8.RuneCountInString(text) > maxSignatureLength {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSignatureTooLong, maxSignatureLength), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if !utf8.ValidString(text) {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if b.rejectContent(chatID, text) {
+		return
+	}
+
+	list, err := b.configStore.ListTemplateVariants(ctx, chatID)
+	if err == nil {
+		n := 0
+		for _, v := range list {
+			if v.Category == storage.VariantSignature {
+				n++
+			}
+		}
+		if n >= maxSignatures {
+			b.resetUserState(chatID)
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSignaturesTooMany, maxSignatures), b.CreateMainMenuForUser(chatID))
+			return
+		}
+	}
+	if err == nil {
+		_, err = b.configStore.AddTemplateVariant(ctx, chatID, storage.VariantSignature, text)
+	}
+	if err != nil {
+		b.log.Errorw("failed to save signature", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_template")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, "signature added")
+	b.reloadUserService(chatID, ctx)
+
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgSignatureAdded))
+	b.handleSignaturesButton(chatID)
+}
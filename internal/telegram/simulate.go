@@ -0,0 +1,89 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/wbapi"
+)
+
+func (b *Bot) handleSimulateButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.TemplateGood == "" || cfg.TemplateBad == "" {
+		b.SendMessageWithKeyboard(chatID, "⚠️ *Сначала добавьте шаблоны ответов*\n\nСимулятор показывает, какой ответ выберет бот по вашим настройкам.", b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingSimulation)
+	msg := `🧪 *Что ответит бот?*
+
+Отправьте оценку (1–5) и текст отзыва одним сообщением — бот покажет, какой ответ будет отправлен и почему. Ничего не публикуется на Wildberries.
+
+*Пример:*
+"2 Пришёл с дефектом, молния не застёгивается"`
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleSimulateInput(chatID int64, text string) {
+	text = strings.TrimSpace(text)
+	fields := strings.SplitN(text, " ", 2)
+	rating, err := strconv.Atoi(strings.TrimSuffix(fields[0], "★"))
+	if err != nil || rating < 1 || rating > 5 {
+		b.SendMessageWithKeyboard(chatID, "⚠️ Сообщение должно начинаться с оценки от 1 до 5. Пример: `2 Пришёл с дефектом`", b.CreateCancelKeyboard(chatID))
+		return
+	}
+	fb := wbapi.Feedback{ProductValuation: rating, CreatedDate: time.Now()}
+	if len(fields) == 2 {
+		fb.Text = strings.TrimSpace(fields[1])
+	}
+
+	svc := b.simulationService(chatID)
+	if svc == nil {
+		b.resetUserState(chatID)
+		b.SendMessageWithKeyboard(chatID, "❌ Не удалось загрузить настройки. Попробуйте позже.", b.CreateMainMenuForUser(chatID))
+		return
+	}
+	d := svc.Explain(fb)
+	b.resetUserState(chatID)
+
+	var sb strings.Builder
+	sb.WriteString("🧪 *Результат проверки*\n\n*Почему:*\n")
+	for i, step := range d.Trace {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, escapeMarkdownV1(step)))
+	}
+	sb.WriteString("\n*Ответ бота:*\n")
+	sb.WriteString(escapeMarkdownV1(d.Text))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧪 Проверить ещё", CallbackSimulate),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, sb.String(), keyboard)
+}
+
+// simulationService returns the user's running service or, if none is
+// running, a throwaway one built from stored settings. The throwaway service
+// has no WB client and is only used for Explain.
+func (b *Bot) simulationService(chatID int64) *service.Service {
+	if svc := b.getServiceForUser(chatID); svc != nil {
+		return svc
+	}
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil || cfg == nil || strings.TrimSpace(cfg.TemplateGood) == "" || strings.TrimSpace(cfg.TemplateBad) == "" {
+		return nil
+	}
+	return service.New(chatID, nil, nil, cfg.TemplateBad, cfg.TemplateGood, b.log, 1, b.serviceOptions(chatID, cfg)...)
+}
@@ -0,0 +1,266 @@
+// Package telegramtest provides an in-memory Telegram Bot API for driving
+// telegram.Bot without Telegram: updates pushed into it reach Bot.Run and
+// everything the bot sends is recorded.
+package telegramtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/telegram"
+)
+
+var _ telegram.TelegramAPI = (*API)(nil)
+
+// API implements telegram.TelegramAPI in memory.
+//
+//	api := telegramtest.New()
+//	bot, _ := telegram.NewWithAPI(api, configStore, store, log, ctx, telegram.Subscription{}, nil, 0, false, telegram.Limits{})
+//	go bot.Run(ctx)
+//	api.SendText(42, "/start")
+//	msgs := api.WaitMessages(42, 1, time.Second)
+type API struct {
+	Me tgbotapi.User // returned by GetMe
+
+	updates chan tgbotapi.Update
+
+	mu       sync.Mutex
+	sent     []Sent
+	requests []tgbotapi.Chattable
+	members  map[member]string       // status in a channel; channel 0 stands for every channel
+	channels map[int64]tgbotapi.Chat // added with AddChannel
+	files    map[string]string
+	nextID   int
+	lastUpd  int
+}
+
+// Sent is a message or edit the bot sent.
+type Sent struct {
+	ChatID    int64
+	MessageID int    // ID assigned to a new message, or the edited one
+	Text      string // message text, edit text or document caption
+	Edit      bool
+	Config    tgbotapi.Chattable
+}
+
+type member struct{ channelID, userID int64 }
+
+// New returns an API for a bot named "test_bot".
+func New() *API {
+	return &API{
+		Me:       tgbotapi.User{ID: 1, IsBot: true, UserName: "test_bot"},
+		updates:  make(chan tgbotapi.Update, 100),
+		members:  make(map[member]string),
+		channels: make(map[int64]tgbotapi.Chat),
+		files:    make(map[string]string),
+	}
+}
+
+// GetMe implements telegram.TelegramAPI.
+func (a *API) GetMe() (tgbotapi.User, error) {
+	return a.Me, nil
+}
+
+// Send records c and returns the resulting message.
+func (a *API) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s := Sent{Config: c}
+	switch m := c.(type) {
+	case tgbotapi.MessageConfig:
+		s.ChatID, s.Text = m.ChatID, m.Text
+	case tgbotapi.EditMessageTextConfig:
+		s.ChatID, s.MessageID, s.Text, s.Edit = m.ChatID, m.MessageID, m.Text, true
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		s.ChatID, s.MessageID, s.Edit = m.ChatID, m.MessageID, true
+	case tgbotapi.DocumentConfig:
+		s.ChatID, s.Text = m.ChatID, m.Caption
+	case tgbotapi.InvoiceConfig:
+		s.ChatID, s.Text = m.ChatID, m.Title
+	default:
+		return tgbotapi.Message{}, fmt.Errorf("telegramtest: unsupported %T", c)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !s.Edit {
+		a.nextID++
+		s.MessageID = a.nextID
+	}
+	a.sent = append(a.sent, s)
+	return tgbotapi.Message{
+		MessageID: s.MessageID,
+		Chat:      &tgbotapi.Chat{ID: s.ChatID},
+		Text:      s.Text,
+		Date:      int(time.Now().Unix()),
+	}, nil
+}
+
+// Request records c, such as a callback answer, and reports success.
+func (a *API) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// GetChat returns a channel added with AddChannel, found by username or ID.
+// Other IDs are returned as channels without a title or username.
+func (a *API) GetChat(config tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if config.SuperGroupUsername != "" {
+		for _, chat := range a.channels {
+			if chat.UserName != "" && chat.UserName == strings.TrimPrefix(config.SuperGroupUsername, "@") {
+				return chat, nil
+			}
+		}
+		return tgbotapi.Chat{}, fmt.Errorf("telegramtest: chat %s not found", config.SuperGroupUsername)
+	}
+	if chat, ok := a.channels[config.ChatID]; ok {
+		return chat, nil
+	}
+	return tgbotapi.Chat{ID: config.ChatID, Type: "channel"}, nil
+}
+
+// GetChatMember returns the status set with SetChannelMember or SetMember,
+// "left" by default.
+func (a *API) GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	status, ok := a.members[member{config.ChatID, config.UserID}]
+	if !ok {
+		status, ok = a.members[member{0, config.UserID}]
+	}
+	if !ok {
+		status = "left"
+	}
+	return tgbotapi.ChatMember{User: &tgbotapi.User{ID: config.UserID}, Status: status}, nil
+}
+
+// GetFileDirectURL returns the URL set with SetFile.
+func (a *API) GetFileDirectURL(fileID string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	url, ok := a.files[fileID]
+	if !ok {
+		return "", fmt.Errorf("telegramtest: unknown file %q", fileID)
+	}
+	return url, nil
+}
+
+// GetUpdatesChan returns the channel fed by Push.
+func (a *API) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return a.updates
+}
+
+// StopReceivingUpdates does nothing; the channel stays open so that Push
+// never panics.
+func (a *API) StopReceivingUpdates() {}
+
+// SetMember sets the user's status in every channel, e.g. "member".
+func (a *API) SetMember(userID int64, status string) {
+	a.SetChannelMember(0, userID, status)
+}
+
+// SetChannelMember sets the user's status in one channel, overriding SetMember.
+func (a *API) SetChannelMember(channelID, userID int64, status string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.members[member{channelID, userID}] = status
+}
+
+// AddChannel makes a channel known to GetChat; username is empty for a
+// private one.
+func (a *API) AddChannel(id int64, username, title string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.channels[id] = tgbotapi.Chat{ID: id, Type: "channel", UserName: username, Title: title}
+}
+
+// SetFile makes fileID downloadable from url.
+func (a *API) SetFile(fileID, url string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files[fileID] = url
+}
+
+// Push delivers an update to the bot, numbering it.
+func (a *API) Push(u tgbotapi.Update) {
+	a.mu.Lock()
+	a.lastUpd++
+	u.UpdateID = a.lastUpd
+	a.mu.Unlock()
+	a.updates <- u
+}
+
+// SendText delivers a text message from the user in their private chat.
+// Commands ("/start") are marked as such.
+func (a *API) SendText(userID int64, text string) {
+	msg := &tgbotapi.Message{
+		From: &tgbotapi.User{ID: userID},
+		Chat: &tgbotapi.Chat{ID: userID, Type: "private"},
+		Date: int(time.Now().Unix()),
+		Text: text,
+	}
+	if len(text) > 1 && text[0] == '/' {
+		end := len(text)
+		for i, r := range text {
+			if r == ' ' || r == '@' {
+				end = i
+				break
+			}
+		}
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: end}}
+	}
+	a.Push(tgbotapi.Update{Message: msg})
+}
+
+// Press delivers a press of an inline button with the callback data on the
+// message with the given ID.
+func (a *API) Press(userID int64, messageID int, data string) {
+	a.Push(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   fmt.Sprintf("cb-%d-%d", userID, messageID),
+		From: &tgbotapi.User{ID: userID},
+		Message: &tgbotapi.Message{
+			MessageID: messageID,
+			Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		},
+		Data: data,
+	}})
+}
+
+// Messages returns what the bot sent to chatID so far, oldest first.
+func (a *API) Messages(chatID int64) []Sent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []Sent
+	for _, s := range a.sent {
+		if s.ChatID == chatID {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// WaitMessages waits until the bot has sent at least n messages to chatID or
+// timeout passes, and returns them.
+func (a *API) WaitMessages(chatID int64, n int, timeout time.Duration) []Sent {
+	deadline := time.Now().Add(timeout)
+	for {
+		msgs := a.Messages(chatID)
+		if len(msgs) >= n || time.Now().After(deadline) {
+			return msgs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Requests returns the requests made with Request, such as callback answers.
+func (a *API) Requests() []tgbotapi.Chattable {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]tgbotapi.Chattable(nil), a.requests...)
+}
@@ -0,0 +1,233 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/content"
+	"feedback_bot/internal/export"
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// maxTemplateFileSize bounds uploaded template files: ten variants of each
+// kind at MaxTemplateLength still fit comfortably.
+const maxTemplateFileSize = 1 << 20
+
+// handleTemplateFileButton explains template files, offers the download and
+// waits for an upload.
+func (b *Bot) handleTemplateFileButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingTemplateFile)
+	msg := b.t(chatID, i18n.MsgTemplateFilePrompt)
+	keyboard := b.CreateCancelKeyboard(chatID)
+	keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnExportTemplates), CallbackExportTemplates),
+	)}, keyboard.InlineKeyboard...)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+// handleExportTemplates sends the user's templates as a JSON file.
+func (b *Bot) handleExportTemplates(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := b.configStore.GetUserConfig(dbCtx, chatID)
+	if err != nil || cfg == nil {
+		b.log.Warnw("failed to load config for template export", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateExportFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	variants, err := b.configStore.ListTemplateVariants(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load variants for template export", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateExportFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	set := storage.TemplateSet{
+		Good:     cfg.TemplateGood,
+		Bad:      cfg.TemplateBad,
+		Question: cfg.TemplateQuestion,
+		Media:    cfg.TemplateMedia,
+		OffHours: cfg.TemplateOffHours,
+	}
+	for _, v := range variants {
+		switch v.Category {
+		case storage.VariantGood:
+			set.GoodVariants = append(set.GoodVariants, v.Text)
+		case storage.VariantBad:
+			set.BadVariants = append(set.BadVariants, v.Text)
+		case storage.VariantSignature:
+			set.Signatures = append(set.Signatures, v.Text)
+		}
+	}
+	var buf bytes.Buffer
+	if err := export.TemplatesJSON(&buf, set); err != nil {
+		b.log.Errorw("failed to render template file", "chat_id", chatID, "err", err)
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateExportFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("templates_%s.json", time.Now().In(formatterFor(cfg).Loc).Format("2006-01-02")),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = b.t(chatID, i18n.MsgTemplateExportCaption)
+	if _, err := b.api.Send(doc); err != nil {
+		b.log.Errorw("failed to send template export", "chat_id", chatID, "err", err)
+		metrics.IncrementAPIError("telegram", "send_document")
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgTemplateSendFailed))
+		return
+	}
+	b.log.Infow("templates exported", "chat_id", chatID, "variants", len(variants))
+}
+
+// handleDocument handles a file sent to the bot. Only template files are
+// accepted, and only after "📦 Шаблоны файлом".
+func (b *Bot) handleDocument(ctx context.Context, msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	if !b.checkRateLimit(chatID) {
+		b.log.Warnw("rate limit exceeded", "chat_id", chatID, "command", "document")
+		metrics.IncrementRateLimitHit(chatID)
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgRateLimited))
+		return
+	}
+	if b.isBanned(chatID) {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgBanned))
+		return
+	}
+	if b.rejectInMaintenance(chatID) {
+		return
+	}
+	if b.getUserState(chatID) != StateWaitingTemplateFile {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateFileUnexpected), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if msg.Document.FileSize > maxTemplateFileSize {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateFileTooBig), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	data, err := b.downloadFile(ctx, msg.Document.FileID)
+	if err != nil {
+		b.log.Warnw("failed to download template file", "chat_id", chatID, "err", err)
+		metrics.IncrementAPIError("telegram", "get_file")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateFileDownloadFailed), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	set, err := export.ParseTemplatesJSON(bytes.NewReader(data))
+	if err == nil {
+		err = checkTemplateSet(b.lang(chatID), set)
+	}
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateFileInvalid, escapeMarkdownV1(err.Error())), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if err := b.configStore.ReplaceTemplates(ctx, chatID, set); err != nil {
+		b.log.Errorw("failed to import templates", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, fmt.Sprintf("import variants=%d", len(set.GoodVariants)+len(set.BadVariants)))
+	b.reloadUserService(chatID, ctx)
+	b.log.Infow("templates imported", "chat_id", chatID)
+
+	lang := b.lang(chatID)
+	reply := b.t(chatID, i18n.MsgTemplateFileLoaded, len(set.GoodVariants), len(set.BadVariants), len(set.Signatures),
+		onOff(lang, set.Question), onOff(lang, set.Media), onOff(lang, set.OffHours))
+	b.SendMessageWithKeyboard(chatID, reply, b.CreateMainMenuForUser(chatID))
+}
+
+// downloadFile fetches a file sent to the bot, up to maxTemplateFileSize.
+func (b *Bot) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	url, err := b.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: http %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTemplateFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxTemplateFileSize {
+		return nil, fmt.Errorf("download file: larger than %d bytes", maxTemplateFileSize)
+	}
+	return data, nil
+}
+
+// checkTemplateSet applies the limits of the template screens to an
+// imported set, explaining a violation in lang.
+func checkTemplateSet(lang string, set storage.TemplateSet) error {
+	if len(set.GoodVariants) > maxTemplateVariants || len(set.BadVariants) > maxTemplateVariants {
+		return errors.New(i18n.T(lang, i18n.MsgTemplateTooManyVariants, maxTemplateVariants))
+	}
+	if len(set.Signatures) > maxSignatures {
+		return errors.New(i18n.T(lang, i18n.MsgTemplateTooManySignatures, maxSignatures))
+	}
+	for _, text := range set.Signatures {
+		if !utf8.ValidString(text) {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateBadEncoding))
+		}
+		if utf8.RuneCountInString(text) > maxSignatureLength {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateSignatureTooLong, maxSignatureLength, string([]rune(text)[:variantPreviewLen])))
+		}
+		if v := content.Check(text); len(v) > 0 {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateSignatureRejected, violationFragments(lang, v)))
+		}
+	}
+	texts := append([]string{set.Good, set.Bad, set.Question, set.Media, set.OffHours}, set.GoodVariants...)
+	for _, text := range append(texts, set.BadVariants...) {
+		if !utf8.ValidString(text) {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateBadEncoding))
+		}
+		if utf8.RuneCountInString(text) > MaxTemplateLength {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateTextTooLong, MaxTemplateLength, string([]rune(text)[:variantPreviewLen])))
+		}
+		if v := content.Check(text); len(v) > 0 {
+			return errors.New(i18n.T(lang, i18n.MsgTemplateTextRejected, violationFragments(lang, v)))
+		}
+	}
+	return nil
+}
+
+// onOff renders whether an optional template is set.
+func onOff(lang, text string) string {
+	if text == "" {
+		return i18n.T(lang, i18n.MsgNo)
+	}
+	return i18n.T(lang, i18n.MsgYes)
+}
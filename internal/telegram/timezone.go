@@ -0,0 +1,137 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// timezoneChoices are the zones offered as buttons by /timezone; any other
+// IANA name can be typed.
+var timezoneChoices = []struct {
+	Name string
+	City i18n.Key
+}{
+	{"Europe/Kaliningrad", i18n.MsgCityKaliningrad},
+	{"Europe/Moscow", i18n.MsgCityMoscow},
+	{"Europe/Samara", i18n.MsgCitySamara},
+	{"Asia/Yekaterinburg", i18n.MsgCityYekaterinburg},
+	{"Asia/Omsk", i18n.MsgCityOmsk},
+	{"Asia/Novosibirsk", i18n.MsgCityNovosibirsk},
+	{"Asia/Irkutsk", i18n.MsgCityIrkutsk},
+	{"Asia/Yakutsk", i18n.MsgCityYakutsk},
+	{"Asia/Vladivostok", i18n.MsgCityVladivostok},
+	{"Asia/Magadan", i18n.MsgCityMagadan},
+	{"Asia/Kamchatka", i18n.MsgCityKamchatka},
+}
+
+// parseTimezone loads an IANA timezone such as "Europe/Moscow". The server's
+// own zone ("Local") and an empty name are rejected.
+func parseTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, errors.New("timezone name required")
+	}
+	return time.LoadLocation(name)
+}
+
+// timezoneDisplay renders the user's timezone for the info screen.
+func timezoneDisplay(lang string, cfg *storage.UserConfig) string {
+	if cfg.Timezone == "" {
+		return i18n.T(lang, i18n.MsgTimezoneDefault, service.DefaultTimezone)
+	}
+	return cfg.Timezone
+}
+
+// utcOffset renders the current offset of loc, e.g. "UTC+3".
+func utcOffset(loc *time.Location) string {
+	_, offset := time.Now().In(loc).Zone()
+	s := fmt.Sprintf("UTC%+d", offset/3600)
+	if m := offset % 3600 / 60; m != 0 {
+		s += fmt.Sprintf(":%02d", max(m, -m))
+	}
+	return s
+}
+
+// handleTimezoneCommand shows the timezone picker (/timezone) or, with an
+// argument ("/timezone Asia/Almaty"), sets the zone right away.
+func (b *Bot) handleTimezoneCommand(chatID int64, args string, ctx context.Context) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	if name := strings.TrimSpace(args); name != "" {
+		b.saveTimezone(chatID, name, ctx)
+		return
+	}
+
+	b.setUserState(chatID, StateWaitingTimezone)
+	current := cfg.Timezone
+	if current == "" {
+		current = service.DefaultTimezone
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, z := range timezoneChoices {
+		loc, err := time.LoadLocation(z.Name)
+		if err != nil {
+			continue
+		}
+		label := fmt.Sprintf("%s (%s)", b.t(chatID, z.City), utcOffset(loc))
+		if z.Name == current {
+			label = "✓ " + label
+		}
+		row = b.appendCallbackButton(row, label, CallbackTimezonePrefix, z.Name)
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	keyboard := b.CreateCancelKeyboard(chatID)
+	keyboard.InlineKeyboard = append(rows, keyboard.InlineKeyboard...)
+
+	msg := b.t(chatID, i18n.MsgTimezonePrompt, current, formatterFor(cfg).ShortDateTime(time.Now()))
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+// saveTimezone stores the user's timezone, typed or picked from the
+// /timezone buttons, and restarts their service so that working hours and
+// the daily limit follow it.
+func (b *Bot) saveTimezone(chatID int64, name string, ctx context.Context) {
+	loc, err := parseTimezone(name)
+	if err != nil {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTimezoneUnknown), b.CreateCancelKeyboard(chatID))
+		return
+	}
+
+	if err := b.configStore.SetTimezone(ctx, chatID, loc.String()); err != nil {
+		b.log.Errorw("failed to save timezone", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.reloadUserService(chatID, ctx)
+	b.log.Infow("timezone changed", "chat_id", chatID, "timezone", loc.String())
+
+	msg := b.t(chatID, i18n.MsgTimezoneSaved,
+		loc.String(), utcOffset(loc), locale.New(b.lang(chatID), loc).ShortDateTime(time.Now()))
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
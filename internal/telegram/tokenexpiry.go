@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// tokenExpiryWarning is how long before its token expires a user is asked
+// to replace it.
+const tokenExpiryWarning = 3 * 24 * time.Hour
+
+// tokenExpired reports whether the user's WB token has an expiry that has
+// passed. WB would reject such a token, so no service is started with it.
+func tokenExpired(cfg *storage.UserConfig, now time.Time) bool {
+	return !cfg.TokenExpiresAt.IsZero() && !now.Before(cfg.TokenExpiresAt)
+}
+
+// tokenExpiryDisplay renders the token's expiry for the info screen.
+func tokenExpiryDisplay(cfg *storage.UserConfig, now time.Time) string {
+	switch {
+	case cfg.TokenExpiresAt.IsZero():
+		return "не указан в токене"
+	case tokenExpired(cfg, now):
+		return "истёк " + formatterFor(cfg).Date(cfg.TokenExpiresAt)
+	}
+	return "до " + formatterFor(cfg).Date(cfg.TokenExpiresAt)
+}
+
+// CheckTokenExpiry reminds users whose WB token expires within
+// tokenExpiryWarning and, once it has expired, stops their service and asks
+// for a new one. Each notice is sent once per token. Intended to be run by
+// an hourly scheduler.
+func (b *Bot) CheckTokenExpiry(ctx context.Context) {
+	now := time.Now()
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	due, err := b.configStore.ListTokenExpiries(dbCtx, now.Add(tokenExpiryWarning))
+	cancel()
+	if err != nil {
+		b.log.Errorw("token expiry: failed to list users", "err", err)
+		metrics.IncrementDatabaseError("list_users")
+		return
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(broadcastRate), 1)
+	for _, t := range due {
+		notice := storage.TokenNoticeExpired
+		if now.Before(t.ExpiresAt) {
+			if t.Notice >= storage.TokenNoticeWarned {
+				continue
+			}
+			notice = storage.TokenNoticeWarned
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return
+		}
+
+		dbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		cfg, err := b.configStore.GetUserConfig(dbCtx, t.UserID)
+		cancel()
+		if err != nil || cfg == nil || !cfg.TokenExpiresAt.Equal(t.ExpiresAt) {
+			continue // replaced meanwhile; the new token is checked next time
+		}
+		var msg string
+		if notice == storage.TokenNoticeExpired {
+			b.shutdownUserService(t.UserID)
+			b.log.Warnw("stopped service: WB token expired", "chat_id", t.UserID, "expired_at", t.ExpiresAt)
+			msg = tokenExpiredMessage(formatterFor(cfg), t.ExpiresAt)
+		} else {
+			msg = tokenExpiringMessage(formatterFor(cfg), t.ExpiresAt, now)
+		}
+		if err := b.SendMessageWithKeyboard(t.UserID, msg, b.replaceTokenKeyboard(t.UserID)); err != nil {
+			b.log.Debugw("token expiry: delivery failed", "chat_id", t.UserID, "err", err)
+		}
+
+		// Marked even if delivery failed so that a blocked bot is not retried every hour
+		dbCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+		if err := b.configStore.SetTokenExpiryNotice(dbCtx, t.UserID, notice); err != nil {
+			b.log.Warnw("token expiry: failed to mark user", "chat_id", t.UserID, "err", err)
+			metrics.IncrementDatabaseError("save_config")
+		}
+		cancel()
+	}
+}
+
+func tokenExpiringMessage(f locale.Formatter, expiresAt, now time.Time) string {
+	return i18n.T(f.Lang, i18n.MsgTokenExpiring,
+		f.ShortDateTime(expiresAt), f.Duration(expiresAt.Sub(now)))
+}
+
+func tokenExpiredMessage(f locale.Formatter, expiresAt time.Time) string {
+	return i18n.T(f.Lang, i18n.MsgTokenExpired, f.ShortDateTime(expiresAt))
+}
@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// checkSharedToken looks for other users registered with the same WB token
+// (compared by hash) and alerts the admin when it finds any. It reports
+// whether the token must be rejected, which happens only when blocking of
+// shared tokens is enabled. Lookup errors never block the user.
+func (b *Bot) checkSharedToken(chatID int64, token string) bool {
+	hash := storage.TokenHash(token)
+	if hash == "" {
+		return false
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	users, err := b.configStore.ListUsersByTokenHash(dbCtx, hash)
+	if err != nil {
+		b.log.Errorw("failed to look up token owners", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("list_users")
+		return false
+	}
+
+	var others []string
+	for _, id := range users {
+		if id != chatID {
+			others = append(others, fmt.Sprintf("`%d`", id))
+		}
+	}
+	if len(others) == 0 {
+		return false
+	}
+
+	b.log.Warnw("shared WB token detected", "chat_id", chatID, "other_users", len(others), "blocked", b.blockSharedTokens)
+
+	action := "токен сохранён"
+	if b.blockSharedTokens {
+		action = "токен отклонён"
+	}
+	alert := fmt.Sprintf("⚠️ *Повторное использование токена WB*\n\nПользователь `%d` отправил токен, который уже подключён у: %s\n\nДействие: %s.",
+		chatID, strings.Join(others, ", "), action)
+	b.notifyAdmins(alert)
+
+	return b.blockSharedTokens
+}
+
+// tokenBreakerOption stops the user's service once WB keeps rejecting the
+// token, instead of retrying it every cycle, and asks the user for a new one.
+func (b *Bot) tokenBreakerOption(chatID int64, cfg *storage.UserConfig) service.Option {
+	token := cfg.WBToken
+	return service.WithAuthBreaker(service.DefaultAuthFailures, func(err error) {
+		dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if current, _ := b.configStore.GetUserConfig(dbCtx, chatID); current == nil || current.WBToken != token {
+			return // replaced meanwhile; the new token has its own service
+		}
+		b.shutdownUserService(chatID)
+		b.log.Warnw("stopped service: WB rejects the token", "chat_id", chatID, "err", err)
+
+		msg := b.t(chatID, i18n.MsgTokenRevoked, describeWBError(b.lang(chatID), err))
+		b.SendMessageWithKeyboard(chatID, msg, b.replaceTokenKeyboard(chatID))
+	})
+}
+
+// replaceTokenKeyboard is attached to messages asking for a new WB token.
+func (b *Bot) replaceTokenKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnReplaceToken), CallbackReplaceToken)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu)),
+	)
+}
+
+// handleReplaceTokenButton asks for a new WB token in place of the saved
+// one, keeping templates and settings.
+func (b *Bot) handleReplaceTokenButton(chatID int64) {
+	b.setUserState(chatID, StateWaitingToken)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenPrompt), b.CreateCancelKeyboard(chatID))
+}
@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/locale"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+// trackingOption reports answered reviews that the buyer moved between
+// negative and positive, if the user turned tracking on.
+func (b *Bot) trackingOption(chatID int64, cfg *storage.UserConfig) service.Option {
+	if !cfg.TrackEdits {
+		return nil
+	}
+	f := formatterFor(cfg)
+	return service.WithReviewTracking(0, func(c service.ReviewChange) {
+		msg, keyboard := b.formatReviewChange(f, c)
+		b.notify(chatID, notification{
+			target:   chatID,
+			kind:     "review_change",
+			title:    i18n.T(f.Lang, i18n.MsgReviewChangeTitle),
+			text:     msg,
+			keyboard: keyboard,
+			line:     fmt.Sprintf("%d⭐ → %s", c.OldRating, reviewLine(f, c.Feedback)),
+		})
+	})
+}
+
+// formatReviewChange renders the notification about a flipped review. A
+// review turned negative gets a button to change the answer while WB allows it.
+func (b *Bot) formatReviewChange(f locale.Formatter, c service.ReviewChange) (string, tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	worse := c.Feedback.ProductValuation < c.OldRating
+	if worse {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewWorse))
+	} else {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewBetter))
+	}
+	sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewRatingChange, c.OldRating, c.Feedback.ProductValuation))
+	if c.TextChanged {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewTextChanged))
+	}
+	sb.WriteString(".\n\n")
+	sb.WriteString(browseReview(f, c.Feedback))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if worse {
+		sb.WriteString(i18n.T(f.Lang, i18n.MsgReviewAnswerOutdated))
+		if c.Feedback.Answer != nil && c.Feedback.Answer.Editable {
+			if row := b.appendCallbackButton(nil, i18n.T(f.Lang, i18n.BtnEditAnswer), CallbackEditAnswerPrefix, c.Feedback.ID); len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(f.Lang, i18n.BtnMainMenu), CallbackMainMenu),
+	))
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) handleTrackingButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirstSetting), b.CreateMainMenuForUser(chatID))
+		return
+	}
+
+	status := b.t(chatID, i18n.MsgOff)
+	button := tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOn), CallbackTrackingOn)
+	if cfg.TrackEdits {
+		status = b.t(chatID, i18n.MsgOn)
+		button = tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnTurnOff), CallbackTrackingOff)
+	}
+	msg := b.t(chatID, i18n.MsgTrackingPrompt, status, formatterFor(cfg).Duration(service.DefaultTrackInterval))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu),
+		),
+	)
+	b.SendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (b *Bot) handleTrackingToggle(chatID int64, on bool, ctx context.Context) {
+	if err := b.configStore.SetTrackEdits(ctx, chatID, on); err != nil {
+		b.log.Errorw("failed to save review tracking", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_config")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.reloadUserService(chatID, ctx)
+
+	msg := b.t(chatID, i18n.MsgTrackingDisabled)
+	if on {
+		msg = b.t(chatID, i18n.MsgTrackingEnabled)
+	}
+	b.SendMessageWithKeyboard(chatID, msg, b.CreateMainMenuForUser(chatID))
+}
@@ -0,0 +1,215 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/service"
+	"feedback_bot/internal/storage"
+	"feedback_bot/pkg/metrics"
+)
+
+const (
+	// maxTemplateVariants limits extra variants per category.
+	maxTemplateVariants = 10
+	// variantPreviewLen is how many characters of a variant the list shows.
+	variantPreviewLen = 60
+)
+
+// variantOption loads the user's reply variants into a service option.
+// Returns nil if there are none or they cannot be loaded.
+func (b *Bot) variantOption(chatID int64) service.Option {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	list, err := b.configStore.ListTemplateVariants(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to load template variants, ignoring", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		return nil
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	var bad, good []string
+	for _, v := range list {
+		switch v.Category {
+		case storage.VariantGood:
+			good = append(good, v.Text)
+		case storage.VariantBad:
+			bad = append(bad, v.Text)
+		}
+	}
+	return service.WithTemplateVariants(bad, good)
+}
+
+// variantPreview shortens a template to one line for the list.
+func variantPreview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > variantPreviewLen {
+		text = string([]rune(text)[:variantPreviewLen]) + "…"
+	}
+	return escapeMarkdownV1(text)
+}
+
+func (b *Bot) handleVariantsButton(chatID int64) {
+	dbCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, _ := b.configStore.GetUserConfig(dbCtx, chatID)
+	if cfg == nil || cfg.WBToken == "" || cfg.WBToken == "not_set" {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTokenFirst), b.CreateMainMenuForUser(chatID))
+		return
+	}
+	list, err := b.configStore.ListTemplateVariants(dbCtx, chatID)
+	if err != nil {
+		b.log.Warnw("failed to list template variants", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("get_templates")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgVariantsLoadFailed), b.CreateMainMenu(chatID))
+		return
+	}
+
+	var good, bad []storage.TemplateVariant
+	for _, v := range list {
+		switch v.Category {
+		case storage.VariantGood:
+			good = append(good, v)
+		case storage.VariantBad:
+			bad = append(bad, v)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.t(chatID, i18n.MsgVariantsTitle))
+	writeSection := func(title i18n.Key, main string, variants []storage.TemplateVariant) {
+		sb.WriteString(b.t(chatID, i18n.MsgVariantsMain, b.t(chatID, title), variantPreview(main)))
+		for _, v := range variants {
+			fmt.Fprintf(&sb, "#%d: %s\n", v.Idx, variantPreview(v.Text))
+		}
+	}
+	writeSection(i18n.MsgVariantsGood, cfg.TemplateGood, good)
+	writeSection(i18n.MsgVariantsBad, cfg.TemplateBad, bad)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var addRow []tgbotapi.InlineKeyboardButton
+	if len(good) < maxTemplateVariants {
+		addRow = append(addRow, tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnVariantAddGood), CallbackVariantAddGood))
+	}
+	if len(bad) < maxTemplateVariants {
+		addRow = append(addRow, tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnVariantAddBad), CallbackVariantAddBad))
+	}
+	if len(addRow) > 0 {
+		rows = append(rows, addRow)
+	}
+	var delRow []tgbotapi.InlineKeyboardButton
+	for _, v := range append(good, bad...) {
+		icon := "👍"
+		if v.Category == storage.VariantBad {
+			icon = "👎"
+		}
+		label := fmt.Sprintf("🗑 %s #%d", icon, v.Idx)
+		delRow = b.appendCallbackButton(delRow, label, CallbackVariantDelPrefix, v.Category, strconv.Itoa(v.Idx))
+		if len(delRow) == 3 {
+			rows = append(rows, delRow)
+			delRow = nil
+		}
+	}
+	if len(delRow) > 0 {
+		rows = append(rows, delRow)
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnSignatures), CallbackSignatures),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnByCategory), CallbackCategories),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(chatID, i18n.BtnMainMenu), CallbackMainMenu)),
+	)
+	b.SendMessageWithKeyboard(chatID, sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (b *Bot) handleVariantAddButton(chatID int64, category string) {
+	state, prompt := StateWaitingVariantGood, i18n.MsgVariantPromptGood
+	if category == storage.VariantBad {
+		state, prompt = StateWaitingVariantBad, i18n.MsgVariantPromptBad
+	}
+	b.setUserState(chatID, state)
+	b.SendMessageWithKeyboard(chatID, b.t(chatID, prompt), b.CreateCancelKeyboard(chatID))
+}
+
+func (b *Bot) handleVariantInput(chatID int64, category, text string, ctx context.Context) {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) < 10 {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooShort), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if len([]rune(text)) > MaxTemplateLength {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateTooLong, MaxTemplateLength), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if !utf8.ValidString(text) {
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgTemplateBadChars), b.CreateCancelKeyboard(chatID))
+		return
+	}
+	if b.rejectContent(chatID, text) {
+		return
+	}
+
+	list, err := b.configStore.ListTemplateVariants(ctx, chatID)
+	if err == nil {
+		n := 0
+		for _, v := range list {
+			if v.Category == category {
+				n++
+			}
+		}
+		if n >= maxTemplateVariants {
+			b.resetUserState(chatID)
+			b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgVariantsTooMany, maxTemplateVariants), b.CreateMainMenuForUser(chatID))
+			return
+		}
+	}
+	if err == nil {
+		_, err = b.configStore.AddTemplateVariant(ctx, chatID, category, text)
+	}
+	if err != nil {
+		b.log.Errorw("failed to save template variant", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_template")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgSaveFailed), b.CreateMainMenu(chatID))
+		b.resetUserState(chatID)
+		return
+	}
+	b.resetUserState(chatID)
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, category+" variant added")
+	b.reloadUserService(chatID, ctx)
+
+	b.SendMessage(chatID, b.t(chatID, i18n.MsgVariantAdded))
+	b.handleVariantsButton(chatID)
+}
+
+// handleVariantDelete removes the variant at idxStr in category
+// (CallbackVariantDelPrefix + "<category>:<idx>").
+func (b *Bot) handleVariantDelete(chatID int64, category, idxStr string, ctx context.Context) {
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || (category != storage.VariantGood && category != storage.VariantBad && category != storage.VariantSignature) {
+		b.SendMessage(chatID, b.t(chatID, i18n.MsgUnknownCommand))
+		return
+	}
+	if _, err := b.configStore.RemoveTemplateVariant(ctx, chatID, category, idx); err != nil {
+		b.log.Errorw("failed to delete template variant", "chat_id", chatID, "err", err)
+		metrics.IncrementDatabaseError("save_template")
+		b.SendMessageWithKeyboard(chatID, b.t(chatID, i18n.MsgVariantDeleteFailed), b.CreateMainMenu(chatID))
+		return
+	}
+	b.audit(chatID, chatID, storage.AuditTemplateChanged, fmt.Sprintf("%s variant %d removed", category, idx+1))
+	b.reloadUserService(chatID, ctx)
+	if category == storage.VariantSignature {
+		b.handleSignaturesButton(chatID)
+		return
+	}
+	b.handleVariantsButton(chatID)
+}
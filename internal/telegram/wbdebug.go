@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"feedback_bot/internal/storage"
+	"feedback_bot/internal/wbapi"
+)
+
+// wbClientFor builds a WB client for the user's token, base URL and debug
+// logging setting, sharing the bot's connection pool.
+func (b *Bot) wbClientFor(cfg *storage.UserConfig) *wbapi.Client {
+	limits := b.currentLimits()
+	return wbapi.New(cfg.WBToken,
+		wbapi.WithBaseURL(b.baseURLFor(cfg)),
+		wbapi.WithRateLimit(limits.WBRPS, limits.WBBurst),
+		wbapi.WithHTTPClient(b.wbHTTP),
+		wbapi.WithProxy(b.proxyFor(cfg)),
+		wbapi.WithBudget(b.wbBudget, cfg.UserID),
+		wbapi.WithLogger(b.log),
+		wbapi.WithDebugLog(b.wbDebugLog(cfg.UserID)),
+	)
+}
+
+// wbDebugLog returns the logger for the user's WB requests and responses, or
+// nil when they are not logged. They are logged for every user with
+// LOG_LEVEL=debug and for users switched on with "/admin wbdebug".
+func (b *Bot) wbDebugLog(chatID int64) *zap.SugaredLogger {
+	b.wbDebugMu.Lock()
+	_, on := b.wbDebugUsers[chatID]
+	b.wbDebugMu.Unlock()
+	if !on && !b.log.Desugar().Core().Enabled(zap.DebugLevel) {
+		return nil
+	}
+	return b.log.With("chat_id", chatID)
+}
+
+// handleAdminWBDebugCommand handles "/admin wbdebug <user_id> on|off":
+// logging of the user's WB requests and responses for a support case. The
+// setting lasts until the bot restarts.
+func (b *Bot) handleAdminWBDebugCommand(chatID int64, args string) {
+	if !b.requireAdmin(chatID) {
+		return
+	}
+	fields := strings.Fields(args)
+	var userID int64
+	var err error
+	if len(fields) == 2 {
+		userID, err = strconv.ParseInt(fields[0], 10, 64)
+	}
+	if len(fields) != 2 || err != nil || (fields[1] != "on" && fields[1] != "off") {
+		b.wbDebugMu.Lock()
+		ids := make([]string, 0, len(b.wbDebugUsers))
+		for id := range b.wbDebugUsers {
+			ids = append(ids, fmt.Sprintf("`%d`", id))
+		}
+		b.wbDebugMu.Unlock()
+		msg := "Использование: `/admin wbdebug <user_id> on|off`\n\nПишет в лог запросы пользователя к API Wildberries и ответы на них: метод, путь, статус, время и начало тела, без токена. Включение действует до перезапуска бота."
+		if len(ids) > 0 {
+			msg += "\n\nСейчас включено для: " + strings.Join(ids, ", ")
+		}
+		b.SendMessage(chatID, msg)
+		return
+	}
+
+	on := fields[1] == "on"
+	b.wbDebugMu.Lock()
+	if on {
+		b.wbDebugUsers[userID] = struct{}{}
+	} else {
+		delete(b.wbDebugUsers, userID)
+	}
+	b.wbDebugMu.Unlock()
+	b.log.Infow("wb debug logging changed", "admin_id", chatID, "user_id", userID, "enabled", on)
+
+	// The client is built with the service, so a running one is rebuilt.
+	b.reloadUserService(userID, b.ctx)
+	if on {
+		b.SendMessage(chatID, fmt.Sprintf("🔎 Запросы пользователя `%d` к API WB пишутся в лог. Выключить: `/admin wbdebug %d off`", userID, userID))
+	} else {
+		b.SendMessage(chatID, fmt.Sprintf("🔎 Запись запросов пользователя `%d` к API WB выключена.", userID))
+	}
+}
@@ -0,0 +1,27 @@
+package telegram
+
+import (
+	"errors"
+
+	"feedback_bot/internal/i18n"
+	"feedback_bot/internal/wbapi"
+)
+
+// describeWBError turns a WB API error into a short explanation for the
+// user in lang. It returns "" for errors that have no user-facing meaning
+// (network failures, cancelled contexts), which callers report generically.
+func describeWBError(lang string, err error) string {
+	switch {
+	case errors.Is(err, wbapi.ErrUnauthorized):
+		return i18n.T(lang, i18n.MsgWBUnauthorized)
+	case errors.Is(err, wbapi.ErrForbidden):
+		return i18n.T(lang, i18n.MsgWBForbidden)
+	case errors.Is(err, wbapi.ErrRateLimited):
+		return i18n.T(lang, i18n.MsgWBRateLimited)
+	case errors.Is(err, wbapi.ErrServer):
+		return i18n.T(lang, i18n.MsgWBServer)
+	case errors.Is(err, wbapi.ErrBadRequest):
+		return i18n.T(lang, i18n.MsgWBBadRequest)
+	}
+	return ""
+}
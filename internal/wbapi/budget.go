@@ -0,0 +1,138 @@
+package wbapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"feedback_bot/pkg/metrics"
+)
+
+// Budget is a request rate shared by the clients of all users (see
+// WithBudget), for when WB throttles the bot's IP as a whole rather than each
+// token. Requests waiting for the budget are served in turn by user: a user
+// with a long backlog gets one request, then every other waiting user gets
+// one, so that a large shop does not hold up the small ones.
+//
+// A Budget with rps <= 0 lets every request through at once.
+type Budget struct {
+	limiter *rate.Limiter
+
+	mu      sync.Mutex
+	queues  map[int64][]*budgetTicket // waiting requests by user, oldest first
+	order   []int64                   // users with waiting requests, next first
+	running bool                      // the dispatch goroutine is running
+}
+
+type budgetTicket struct {
+	ready     chan struct{} // closed when the request may go
+	cancelled bool          // the caller gave up; guarded by Budget.mu
+}
+
+// NewBudget returns a budget of rps requests per second with the given burst.
+func NewBudget(rps, burst int) *Budget {
+	b := &Budget{
+		limiter: rate.NewLimiter(rate.Inf, 0),
+		queues:  make(map[int64][]*budgetTicket),
+	}
+	b.SetLimit(rps, burst)
+	return b
+}
+
+// SetLimit changes the budget in place; rps <= 0 removes the limit.
+// Waiting requests pick it up.
+func (b *Budget) SetLimit(rps, burst int) {
+	if rps <= 0 {
+		b.limiter.SetLimit(rate.Inf)
+		return
+	}
+	b.limiter.SetBurst(max(burst, 1))
+	b.limiter.SetLimit(rate.Limit(rps))
+}
+
+// Wait blocks until userID may send a request or ctx is done. The time spent
+// waiting is observed in metrics.WBBudgetWait.
+func (b *Budget) Wait(ctx context.Context, userID int64) error {
+	if b == nil || b.limiter.Limit() == rate.Inf {
+		return nil
+	}
+	start := time.Now()
+	t := &budgetTicket{ready: make(chan struct{})}
+
+	b.mu.Lock()
+	if len(b.queues[userID]) == 0 {
+		b.order = append(b.order, userID)
+	}
+	b.queues[userID] = append(b.queues[userID], t)
+	if !b.running {
+		b.running = true
+		go b.dispatch()
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-t.ready:
+		metrics.ObserveWBBudgetWait(time.Since(start))
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		t.cancelled = true
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// dispatch hands out tokens of the budget to waiting requests, one user
+// after another, and exits when nobody is waiting.
+func (b *Budget) dispatch() {
+	for {
+		b.mu.Lock()
+		if len(b.order) == 0 {
+			b.running = false
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		if err := b.limiter.Wait(context.Background()); err != nil {
+			// Only possible with a burst of 0, which SetLimit rules out.
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		b.mu.Lock()
+		// nil if every waiting request was cancelled meanwhile; the token is lost.
+		if t := b.next(); t != nil {
+			close(t.ready)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// next removes and returns the oldest live request of the user whose turn
+// it is and moves the user to the back of the line. Callers hold b.mu.
+func (b *Budget) next() *budgetTicket {
+	for len(b.order) > 0 {
+		userID := b.order[0]
+		b.order = b.order[1:]
+		queue := b.queues[userID]
+		var t *budgetTicket
+		for len(queue) > 0 && t == nil {
+			if !queue[0].cancelled {
+				t = queue[0]
+			}
+			queue = queue[1:]
+		}
+		if len(queue) == 0 {
+			delete(b.queues, userID)
+		} else {
+			b.queues[userID] = queue
+			b.order = append(b.order, userID)
+		}
+		if t != nil {
+			return t
+		}
+	}
+	return nil
+}
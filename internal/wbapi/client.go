@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -35,7 +40,17 @@ type Client struct {
 	baseURL    *url.URL
 	token      string
 	limiter    *rate.Limiter
+	budget     *Budget // shared by all users' clients, set by WithBudget
+	userID     int64   // whose turn the client takes in budget
 	log        *zap.SugaredLogger
+	debugLog   *zap.SugaredLogger // set by WithDebugLog
+
+	version     *APIVersion
+	capMu       sync.RWMutex
+	unavailable map[Endpoint]bool // endpoints found missing by DetectCapabilities
+	capDone     bool              // EnsureCapabilities succeeded; guarded by capMu
+	capRetryAt  time.Time         // no EnsureCapabilities attempt before then
+	capBackoff  time.Duration     // wait after the latest failed attempt
 }
 
 // Option mutates the client during construction.
@@ -65,6 +80,40 @@ func WithRateLimit(rps, burst int) Option {
 	}
 }
 
+// WithBudget makes the client's requests count against b, a budget shared
+// with the clients of other users, after the client's own rate limit.
+// userID identifies the client's user for the turns b gives out.
+func WithBudget(b *Budget, userID int64) Option {
+	return func(c *Client) {
+		c.budget = b
+		c.userID = userID
+	}
+}
+
+// WithTransport replaces the HTTP transport used by the client while keeping
+// its timeout. Useful to inject recording or fault-injecting transports.
+// An http.Client set by WithHTTPClient is copied, not modified.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		if rt != nil {
+			hc := *c.httpClient
+			hc.Transport = rt
+			c.httpClient = &hc
+		}
+	}
+}
+
+// WithHTTPClient makes the client send requests through hc, typically one
+// shared by all users' clients (see NewTransport and NewHTTPClient) so they
+// reuse pooled connections. hc is used as is and should have a timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
 // WithLogger allows injecting custom zap logger. If nil, a no‑op logger will be used.
 func WithLogger(l *zap.SugaredLogger) Option {
 	return func(c *Client) {
@@ -79,51 +128,228 @@ func New(token string, opts ...Option) *Client {
 	// sensible defaults
 	base, _ := url.Parse("https://feedbacks-api.wildberries.ru")
 	c := &Client{
-		httpClient: &http.Client{Timeout: DefaultHTTPTimeout},
-		baseURL:    base,
-		token:      token,
-		limiter:    rate.NewLimiter(rate.Inf, 0), // disabled limiter by default
-		log:        zap.NewNop().Sugar(),
+		httpClient:  &http.Client{Timeout: DefaultHTTPTimeout},
+		baseURL:     base,
+		token:       token,
+		limiter:     rate.NewLimiter(rate.Inf, 0), // disabled limiter by default
+		log:         zap.NewNop().Sugar(),
+		version:     DefaultAPIVersion,
+		unavailable: make(map[Endpoint]bool),
 	}
 	for _, o := range opts {
 		o(c)
 	}
+	if c.debugLog != nil {
+		hc := *c.httpClient
+		hc.Transport = NewDebugTransport(hc.Transport, c.debugLog, 0)
+		c.httpClient = &hc
+	}
 	return c
 }
 
 // FetchUnanswered retrieves a slice of unanswered feedbacks ordered by date desc.
 // "take" must be ≤5000 as per API, "skip" may be 0. For MVP we need at most 5000.
 func (c *Client) FetchUnanswered(ctx context.Context, take, skip int) ([]Feedback, error) {
+	page, err := c.FetchUnansweredPage(ctx, take, skip)
+	return page.Feedbacks, err
+}
+
+// FeedbackPage is a page of unanswered feedbacks with the total reported by WB.
+type FeedbackPage struct {
+	Feedbacks       []Feedback
+	CountUnanswered int // all unanswered feedbacks, not only this page
+}
+
+// FetchUnansweredPage is FetchUnanswered that also returns countUnanswered.
+func (c *Client) FetchUnansweredPage(ctx context.Context, take, skip int) (FeedbackPage, error) {
+	return c.fetchFeedbacks(ctx, false, take, skip)
+}
+
+// FetchAnswered retrieves a page of answered feedbacks ordered by date desc,
+// as buyers see them now: a buyer may change the rating or the text after
+// the answer. WB keeps answered feedbacks in this list until they are
+// archived; take ≤5000.
+func (c *Client) FetchAnswered(ctx context.Context, take, skip int) ([]Feedback, error) {
+	page, err := c.fetchFeedbacks(ctx, true, take, skip)
+	return page.Feedbacks, err
+}
+
+func (c *Client) fetchFeedbacks(ctx context.Context, answered bool, take, skip int) (FeedbackPage, error) {
 	values := url.Values{}
-	values.Set("isAnswered", "false")
+	values.Set("isAnswered", fmt.Sprint(answered))
 	values.Set("take", fmt.Sprint(take))
 	values.Set("skip", fmt.Sprint(skip))
 	values.Set("order", "dateDesc")
 
-	endpoint := c.resolve("/api/v1/feedbacks") + "?" + values.Encode()
+	endpoint, err := c.endpoint(EndpointFeedbacks)
+	if err != nil {
+		return FeedbackPage{}, err
+	}
 	var resp feedbacksListResp
-	if err := c.get(ctx, endpoint, &resp); err != nil {
+	if err := c.get(ctx, endpoint+"?"+values.Encode(), &resp); err != nil {
+		return FeedbackPage{}, err
+	}
+	if resp.Error {
+		return FeedbackPage{}, &ResponseError{Text: resp.ErrorText}
+	}
+	return FeedbackPage{Feedbacks: resp.Data.Feedbacks, CountUnanswered: resp.Data.CountUnanswered}, nil
+}
+
+// FetchArchived retrieves a page of archived feedbacks ordered by date desc.
+// WB moves feedbacks to the archive once they are answered or get old, so
+// unlike FetchUnanswered the result also contains answered ones (Answer is
+// set). Pagination is separate from the unanswered list: take ≤5000.
+func (c *Client) FetchArchived(ctx context.Context, take, skip int) ([]Feedback, error) {
+	values := url.Values{}
+	values.Set("take", fmt.Sprint(take))
+	values.Set("skip", fmt.Sprint(skip))
+	values.Set("order", "dateDesc")
+
+	endpoint, err := c.endpoint(EndpointArchive)
+	if err != nil {
+		return nil, err
+	}
+	var resp feedbacksListResp
+	if err := c.get(ctx, endpoint+"?"+values.Encode(), &resp); err != nil {
 		return nil, err
 	}
 	if resp.Error {
-		return nil, fmt.Errorf("wb api error: %s", resp.ErrorText)
+		return nil, &ResponseError{Text: resp.ErrorText}
 	}
 	return resp.Data.Feedbacks, nil
 }
 
+// FetchFeedback retrieves one feedback by ID, answered or not. It returns
+// nil without an error when WB no longer has the feedback, as after
+// moderation removes it on a complaint.
+func (c *Client) FetchFeedback(ctx context.Context, id string) (*Feedback, error) {
+	endpoint, err := c.endpoint(EndpointFeedback)
+	if err != nil {
+		return nil, err
+	}
+	var resp feedbackResp
+	err = c.get(ctx, endpoint+"?"+url.Values{"id": {id}}.Encode(), &resp)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error {
+		return nil, &ResponseError{Text: resp.ErrorText}
+	}
+	return resp.Data, nil
+}
+
 // AnswerFeedback posts a reply to a feedback ID.
 func (c *Client) AnswerFeedback(ctx context.Context, id, text string) error {
 	body := answerRequest{ID: id, Text: text}
 	var generic genericResponse
-	if err := c.post(ctx, "/api/v1/feedbacks/answer", body, &generic); err != nil {
+	if err := c.post(ctx, EndpointFeedbackAnswer, body, &generic); err != nil {
 		return err
 	}
 	if generic.Error {
-		return fmt.Errorf("wb api error: %s", generic.ErrorText)
+		return &ResponseError{Text: generic.ErrorText}
 	}
 	return nil
 }
 
+// EditAnswer replaces the text of an answer already posted to a feedback.
+// WB allows editing an answer within a limited time after it was posted
+// and rejects later edits with ErrBadRequest.
+func (c *Client) EditAnswer(ctx context.Context, id, text string) error {
+	body := answerRequest{ID: id, Text: text}
+	var generic genericResponse
+	if err := c.send(ctx, http.MethodPatch, EndpointFeedbackEdit, body, &generic); err != nil {
+		return err
+	}
+	if generic.Error {
+		return &ResponseError{Text: generic.ErrorText}
+	}
+	return nil
+}
+
+// ComplaintReasons returns the reasons WB accepts for complaints about
+// reviews, ordered by ID.
+func (c *Client) ComplaintReasons(ctx context.Context) ([]ComplaintReason, error) {
+	endpoint, err := c.endpoint(EndpointValuations)
+	if err != nil {
+		return nil, err
+	}
+	var resp valuationsResp
+	if err := c.get(ctx, endpoint, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error {
+		return nil, &ResponseError{Text: resp.ErrorText}
+	}
+	out := make([]ComplaintReason, 0, len(resp.Data.FeedbackValuations))
+	for id, text := range resp.Data.FeedbackValuations {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		out = append(out, ComplaintReason{ID: n, Text: text})
+	}
+	slices.SortFunc(out, func(a, b ComplaintReason) int { return a.ID - b.ID })
+	return out, nil
+}
+
+// ComplainFeedback files a complaint about a feedback with one of the
+// ComplaintReasons. WB moderates it later; the outcome is not reported
+// through the API, but an accepted complaint removes the feedback, which
+// FetchFeedback then no longer finds.
+func (c *Client) ComplainFeedback(ctx context.Context, id string, reasonID int) error {
+	body := complaintRequest{ID: id, SupplierFeedbackValuation: reasonID}
+	return c.post(ctx, EndpointFeedbackAction, body, nil)
+}
+
+// FetchUnansweredQuestions retrieves unanswered product questions ordered by date desc.
+// Limits are the same as for feedbacks: take ≤5000.
+func (c *Client) FetchUnansweredQuestions(ctx context.Context, take, skip int) ([]Question, error) {
+	values := url.Values{}
+	values.Set("isAnswered", "false")
+	values.Set("take", fmt.Sprint(take))
+	values.Set("skip", fmt.Sprint(skip))
+	values.Set("order", "dateDesc")
+
+	endpoint, err := c.endpoint(EndpointQuestions)
+	if err != nil {
+		return nil, err
+	}
+	var resp questionsListResp
+	if err := c.get(ctx, endpoint+"?"+values.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error {
+		return nil, &ResponseError{Text: resp.ErrorText}
+	}
+	return resp.Data.Questions, nil
+}
+
+// AnswerQuestion publishes a reply to a question ID.
+func (c *Client) AnswerQuestion(ctx context.Context, id, text string) error {
+	body := questionAnswerRequest{ID: id, State: "wbRu"}
+	body.Answer.Text = text
+	var generic genericResponse
+	if err := c.send(ctx, http.MethodPatch, EndpointQuestionAnswer, body, &generic); err != nil {
+		return err
+	}
+	if generic.Error {
+		return &ResponseError{Text: generic.ErrorText}
+	}
+	return nil
+}
+
+// ValidateToken performs the cheapest authorised call (a single-item fetch)
+// to check that the token is accepted and has the feedbacks scope.
+// A nil error means the token is usable; otherwise test it with errors.Is
+// against ErrUnauthorized, ErrForbidden, ErrRateLimited etc.
+func (c *Client) ValidateToken(ctx context.Context) error {
+	_, err := c.FetchUnanswered(ctx, 1, 0)
+	return err
+}
+
 // --- internal helpers ---
 
 func (c *Client) get(ctx context.Context, endpoint string, out interface{}) error {
@@ -135,13 +361,21 @@ func (c *Client) get(ctx context.Context, endpoint string, out interface{}) erro
 	return c.do(req, out)
 }
 
-func (c *Client) post(ctx context.Context, path string, payload any, out interface{}) error {
-	reqURL := c.resolve(path)
+func (c *Client) post(ctx context.Context, ep Endpoint, payload any, out interface{}) error {
+	return c.send(ctx, http.MethodPost, ep, payload, out)
+}
+
+// send encodes payload as JSON and performs a request with a body (POST, PATCH).
+func (c *Client) send(ctx context.Context, method string, ep Endpoint, payload any, out interface{}) error {
+	reqURL, err := c.endpoint(ep)
+	if err != nil {
+		return err
+	}
 	buf := &bytes.Buffer{}
 	if err := json.NewEncoder(buf).Encode(payload); err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, buf)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, buf)
 	if err != nil {
 		return err
 	}
@@ -162,13 +396,21 @@ func (c *Client) do(req *http.Request, out interface{}) error {
 
 	if resp.StatusCode >= 400 {
 		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("wb api http %d: %s", resp.StatusCode, string(b))
+		apiErr := &APIError{Status: resp.StatusCode, Body: string(b)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiErr.RetryAfter = parseRetryAfter(resp.Header)
+		}
+		return apiErr
 	}
 
 	if out == nil {
 		io.Copy(io.Discard, resp.Body)
 		return nil
 	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("%w (http %d)", ErrMaintenance, resp.StatusCode)
+	}
 	return json.NewDecoder(resp.Body).Decode(out)
 }
 
@@ -182,9 +424,22 @@ func (c *Client) resolve(p string) string {
 	return u.String()
 }
 
+// SetRateLimit changes the client's rate limit in place, with the meaning of
+// WithRateLimit. Requests waiting for the limiter pick it up.
+func (c *Client) SetRateLimit(rps, burst int) {
+	if rps <= 0 {
+		c.limiter.SetLimit(rate.Inf)
+		return
+	}
+	c.limiter.SetBurst(burst)
+	c.limiter.SetLimit(rate.Limit(rps))
+}
+
 func (c *Client) wait(ctx context.Context) error {
-	if c.limiter == nil || c.limiter.Limit() == rate.Inf {
-		return nil
+	if c.limiter != nil && c.limiter.Limit() != rate.Inf {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
 	}
-	return c.limiter.Wait(ctx)
+	return c.budget.Wait(ctx, c.userID)
 }
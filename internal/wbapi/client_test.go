@@ -0,0 +1,251 @@
+package wbapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fixtureServer answers every request with one file of testdata and keeps
+// the last request it got.
+type fixtureServer struct {
+	mu          sync.Mutex
+	fixture     string
+	status      int
+	contentType string
+	header      http.Header
+	request     string
+}
+
+func (s *fixtureServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.request = dumpRequest(r, body)
+	fixture, status, contentType := s.fixture, s.status, s.contentType
+	for k, v := range s.header {
+		w.Header()[k] = v
+	}
+	s.mu.Unlock()
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	if fixture != "" {
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			panic(err)
+		}
+		w.Write(data)
+	}
+}
+
+func (s *fixtureServer) lastRequest() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.request
+}
+
+// dumpRequest renders the parts of a request WB cares about in a stable
+// form: the method, the path with the sorted query, the headers the client
+// sets and the body.
+func dumpRequest(r *http.Request, body []byte) string {
+	var sb strings.Builder
+	target := r.URL.Path
+	if q := r.URL.Query(); len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	fmt.Fprintf(&sb, "%s %s\n", r.Method, target)
+	for _, h := range []string{"Authorization", "Content-Type"} {
+		if v := r.Header.Get(h); v != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", h, v)
+		}
+	}
+	if len(body) > 0 {
+		sb.WriteString("\n")
+		sb.Write(body)
+	}
+	return sb.String()
+}
+
+func newFixtureClient(t *testing.T, fixture string) (*Client, *fixtureServer) {
+	t.Helper()
+	s := &fixtureServer{fixture: fixture}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return New("test-token", WithBaseURL(srv.URL)), s
+}
+
+// checkGolden compares got with testdata/name, or rewrites the file with
+// -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file:\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+// TestClientGolden calls every method of the client against a server
+// answering with a recorded WB response, and compares the request it sent
+// and the decoded result with the golden files of testdata.
+func TestClientGolden(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string // served response
+		call    func(ctx context.Context, c *Client) (any, error)
+	}{
+		{"fetch_unanswered", "feedbacks.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchUnanswered(ctx, 100, 0)
+		}},
+		{"fetch_unanswered_page", "feedbacks.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchUnansweredPage(ctx, 50, 100)
+		}},
+		{"fetch_answered", "feedbacks_answered.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchAnswered(ctx, 5000, 0)
+		}},
+		{"fetch_archived", "feedbacks_archive.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchArchived(ctx, 200, 400)
+		}},
+		{"fetch_feedback", "feedback.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchFeedback(ctx, "YX52RZEBhH9mrcYdEJuD")
+		}},
+		{"fetch_feedback_removed", "feedback_removed.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchFeedback(ctx, "YX52RZEBhH9mrcYdEJuD")
+		}},
+		{"answer_feedback", "ok.json", func(ctx context.Context, c *Client) (any, error) {
+			return nil, c.AnswerFeedback(ctx, "YX52RZEBhH9mrcYdEJuD", "Спасибо за отзыв!")
+		}},
+		{"edit_answer", "ok.json", func(ctx context.Context, c *Client) (any, error) {
+			return nil, c.EditAnswer(ctx, "YX52RZEBhH9mrcYdEJuD", "Спасибо! Ждём вас снова")
+		}},
+		{"complaint_reasons", "valuations.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.ComplaintReasons(ctx)
+		}},
+		{"complain_feedback", "", func(ctx context.Context, c *Client) (any, error) {
+			return nil, c.ComplainFeedback(ctx, "YX52RZEBhH9mrcYdEJuD", 2)
+		}},
+		{"fetch_unanswered_questions", "questions.json", func(ctx context.Context, c *Client) (any, error) {
+			return c.FetchUnansweredQuestions(ctx, 100, 0)
+		}},
+		{"answer_question", "ok.json", func(ctx context.Context, c *Client) (any, error) {
+			return nil, c.AnswerQuestion(ctx, "n5um6IUBQOOSTxXoo0gV", "Да, подходит")
+		}},
+		{"validate_token", "feedbacks_empty.json", func(ctx context.Context, c *Client) (any, error) {
+			return nil, c.ValidateToken(ctx)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newFixtureClient(t, tt.fixture)
+			got, err := tt.call(context.Background(), c)
+			if err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			checkGolden(t, tt.name+".request", []byte(s.lastRequest()))
+			if got == nil {
+				return
+			}
+			out, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".result.json", append(out, '\n'))
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixture     string
+		status      int
+		contentType string
+		header      http.Header
+		want        error
+		retryAfter  time.Duration
+	}{
+		{name: "error flag", fixture: "error.json", want: ErrBadRequest},
+		{name: "bad request", fixture: "error.json", status: http.StatusBadRequest, want: ErrBadRequest},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: ErrUnauthorized},
+		{name: "forbidden", status: http.StatusForbidden, want: ErrForbidden},
+		{name: "not found", status: http.StatusNotFound, want: ErrNotFound},
+		{
+			name: "rate limited", status: http.StatusTooManyRequests,
+			header: http.Header{"X-Ratelimit-Retry": {"7"}}, want: ErrRateLimited, retryAfter: 7 * time.Second,
+		},
+		{name: "server error", status: http.StatusBadGateway, want: ErrServer},
+		{name: "maintenance page", fixture: "maintenance.html", contentType: "text/html; charset=utf-8", want: ErrMaintenance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := newFixtureClient(t, tt.fixture)
+			s.status, s.contentType, s.header = tt.status, tt.contentType, tt.header
+			_, err := c.FetchUnanswered(context.Background(), 1, 0)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("FetchUnanswered = %v, want %v", err, tt.want)
+			}
+			if got := RetryAfter(err); got != tt.retryAfter {
+				t.Errorf("RetryAfter = %v, want %v", got, tt.retryAfter)
+			}
+		})
+	}
+
+	// A response with the error flag carries its text
+	c, _ := newFixtureClient(t, "error.json")
+	var respErr *ResponseError
+	if err := c.AnswerFeedback(context.Background(), "id", "text"); !errors.As(err, &respErr) || respErr.Text != "Отзыв не найден" {
+		t.Errorf("AnswerFeedback = %v, want the error text of the response", err)
+	}
+}
+
+func TestFetchFeedbackNotFound(t *testing.T) {
+	c, s := newFixtureClient(t, "error.json")
+	s.status = http.StatusNotFound
+	fb, err := c.FetchFeedback(context.Background(), "gone")
+	if err != nil || fb != nil {
+		t.Fatalf("FetchFeedback = %v, %v; want nil without an error", fb, err)
+	}
+}
+
+func TestClientUnsupportedEndpoint(t *testing.T) {
+	c, s := newFixtureClient(t, "ok.json")
+	c.version = &APIVersion{Name: "partial", Paths: map[Endpoint]string{EndpointFeedbacks: V1.Paths[EndpointFeedbacks]}}
+	if _, err := c.FetchUnansweredQuestions(context.Background(), 1, 0); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("FetchUnansweredQuestions = %v, want ErrUnsupported", err)
+	}
+	if err := c.AnswerQuestion(context.Background(), "id", "text"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("AnswerQuestion = %v, want ErrUnsupported", err)
+	}
+	if r := s.lastRequest(); r != "" {
+		t.Errorf("request sent to an unsupported endpoint:\n%s", r)
+	}
+}
@@ -0,0 +1,122 @@
+package wbapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultDebugBodyLimit is how many bytes of each request and response body
+// the debug transport logs.
+const DefaultDebugBodyLimit = 2048
+
+// redactedHeaders are logged as "***" by the debug transport.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// debugTransport logs every request and its response. See NewDebugTransport.
+type debugTransport struct {
+	base      http.RoundTripper
+	log       *zap.SugaredLogger
+	bodyLimit int
+}
+
+// NewDebugTransport wraps base (nil means http.DefaultTransport) to log the
+// method, path, status, duration, headers and the first bodyLimit bytes of
+// both bodies of every request at info level, so support can see what WB was
+// asked and what it answered. The Authorization header is redacted; bodies
+// are logged as they are and contain review texts and buyer names.
+// bodyLimit <= 0 means DefaultDebugBodyLimit.
+func NewDebugTransport(base http.RoundTripper, log *zap.SugaredLogger, bodyLimit int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if bodyLimit <= 0 {
+		bodyLimit = DefaultDebugBodyLimit
+	}
+	return &debugTransport{base: base, log: log, bodyLimit: bodyLimit}
+}
+
+// WithDebugLog logs the client's requests and responses to l through
+// NewDebugTransport, wrapping whatever transport the other options chose.
+// nil leaves logging off.
+func WithDebugLog(l *zap.SugaredLogger) Option {
+	return func(c *Client) {
+		c.debugLog = l
+	}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fields := []any{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"query", req.URL.RawQuery,
+		"request_headers", redactHeaders(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			fields = append(fields, "request_body", t.readPrefix(body))
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fields = append(fields, "duration", time.Since(start).String())
+	if err != nil {
+		t.log.Infow("wb request failed", append(fields, "err", err)...)
+		return resp, err
+	}
+
+	// Log the start of the body and hand the caller all of it.
+	prefix := make([]byte, t.bodyLimit+1)
+	n, _ := io.ReadFull(resp.Body, prefix)
+	prefix = prefix[:n]
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+
+	fields = append(fields,
+		"status", resp.StatusCode,
+		"response_headers", redactHeaders(resp.Header),
+		"response_body", truncateBody(prefix, t.bodyLimit),
+	)
+	t.log.Infow("wb request", fields...)
+	return resp, nil
+}
+
+// readPrefix returns the start of r for the log.
+func (t *debugTransport) readPrefix(r io.Reader) string {
+	buf, _ := io.ReadAll(io.LimitReader(r, int64(t.bodyLimit)+1))
+	return truncateBody(buf, t.bodyLimit)
+}
+
+// truncateBody cuts b to limit bytes, marking the cut.
+func truncateBody(b []byte, limit int) string {
+	if len(b) <= limit {
+		return string(b)
+	}
+	return string(bytes.ToValidUTF8(b[:limit], nil)) + "…(truncated)"
+}
+
+// redactHeaders flattens h for the log with credentials replaced by "***".
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	for _, k := range redactedHeaders {
+		if _, ok := out[k]; ok {
+			out[k] = "***"
+		}
+	}
+	return out
+}
+
+// readCloser reads from one reader and closes another.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
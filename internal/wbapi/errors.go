@@ -0,0 +1,100 @@
+package wbapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors matched via errors.Is against *APIError by HTTP status,
+// so callers can react to the kind of failure without parsing messages.
+var (
+	ErrBadRequest   = errors.New("wb api: bad request")  // 400, 422 and error responses with status 200
+	ErrUnauthorized = errors.New("wb api: unauthorized") // 401: token invalid or expired
+	ErrForbidden    = errors.New("wb api: forbidden")    // 403: token lacks the required scope
+	ErrNotFound     = errors.New("wb api: not found")    // 404
+	ErrRateLimited  = errors.New("wb api: rate limited") // 429
+	ErrServer       = errors.New("wb api: server error") // 5xx
+)
+
+// ErrMaintenance is returned when WB answers with an HTML page instead of
+// JSON, as its gateway does during maintenance.
+var ErrMaintenance = errors.New("wb api: maintenance page")
+
+// APIError is returned for any HTTP response with status >= 400.
+// Body holds at most the first 1 KiB of the response for diagnostics.
+type APIError struct {
+	Status int
+	Body   string
+
+	// RetryAfter is the server-suggested wait for 429 responses; 0 if absent.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wb api http %d: %s", e.Status, e.Body)
+}
+
+// Is maps the HTTP status onto the sentinel errors above.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.Status == http.StatusBadRequest || e.Status == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.Status == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Status == http.StatusForbidden
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrRateLimited:
+		return e.Status == http.StatusTooManyRequests
+	case ErrServer:
+		return e.Status >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// ResponseError is returned when WB answers with a successful status but
+// sets the "error" flag in the body. It matches ErrBadRequest.
+type ResponseError struct {
+	Text string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("wb api error: %s", e.Text)
+}
+
+func (e *ResponseError) Is(target error) bool {
+	return target == ErrBadRequest
+}
+
+// RetryAfter returns the wait suggested by WB for a rate-limited error, or 0.
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter reads WB's X-Ratelimit-Retry (seconds) or the standard
+// Retry-After header (seconds or HTTP date).
+func parseRetryAfter(h http.Header) time.Duration {
+	for _, key := range []string{"X-Ratelimit-Retry", "Retry-After"} {
+		v := h.Get(key)
+		if v == "" {
+			continue
+		}
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+	return 0
+}
@@ -9,14 +9,51 @@ import "time"
 // keep ID as string.
 // Doc: https://dev.wildberries.ru/en/openapi/user-communication#/Feedbacks/get_feedbacks
 type Feedback struct {
-	ID               string    `json:"id"`
-	Text             string    `json:"text"`
-	Pros             string    `json:"pros"`
-	Cons             string    `json:"cons"`
-	ProductValuation int       `json:"productValuation"` // 1–5 stars
-	CreatedDate      time.Time `json:"createdDate"`
-	WasViewed        bool      `json:"wasViewed"`
-	IsWarned         bool      `json:"isWarned"`
+	ID               string          `json:"id"`
+	Text             string          `json:"text"`
+	Pros             string          `json:"pros"`
+	Cons             string          `json:"cons"`
+	ProductValuation int             `json:"productValuation"` // 1–5 stars
+	CreatedDate      time.Time       `json:"createdDate"`
+	WasViewed        bool            `json:"wasViewed"`
+	IsWarned         bool            `json:"isWarned"`
+	SubjectID        int64           `json:"subjectId"`   // WB product category ID
+	SubjectName      string          `json:"subjectName"` // WB product category name, e.g. "Футболки"
+	ProductDetails   ProductDetails  `json:"productDetails"`
+	PhotoLinks       []PhotoLink     `json:"photoLinks"` // photos attached by the buyer
+	Video            *FeedbackVideo  `json:"video"`      // nil without video
+	Answer           *FeedbackAnswer `json:"answer"`     // nil while unanswered; archived feedbacks may have none
+
+	// SupplierFeedbackValuation is the complaint reason the seller filed
+	// with ComplainFeedback; zero if none.
+	SupplierFeedbackValuation int `json:"supplierFeedbackValuation"`
+}
+
+// PhotoLink is a photo attached to a feedback.
+type PhotoLink struct {
+	FullSize string `json:"fullSize"`
+	MiniSize string `json:"miniSize"`
+}
+
+// FeedbackVideo is a video attached to a feedback.
+type FeedbackVideo struct {
+	PreviewImage string `json:"previewImage"`
+	Link         string `json:"link"`
+	DurationSec  int    `json:"durationSec"`
+}
+
+// FeedbackAnswer is the seller's reply attached to a feedback.
+type FeedbackAnswer struct {
+	Text     string `json:"text"`
+	State    string `json:"state"`
+	Editable bool   `json:"editable"`
+}
+
+// ProductDetails identifies the reviewed product.
+type ProductDetails struct {
+	NmID            int64  `json:"nmId"` // WB article
+	ProductName     string `json:"productName"`
+	SupplierArticle string `json:"supplierArticle"` // seller's own article
 }
 
 // feedbacksListData is the "data" envelope inside the list response.
@@ -43,6 +80,43 @@ type feedbacksListResp struct {
 	AdditionalErrors interface{}       `json:"additionalErrors"`
 }
 
+// feedbackResp is the response for GET /feedback?id=; data is null when WB
+// no longer has the feedback.
+type feedbackResp struct {
+	Data      *Feedback `json:"data"`
+	Error     bool      `json:"error"`
+	ErrorText string    `json:"errorText"`
+}
+
+// ComplaintReason is a reason WB accepts for a complaint about a review,
+// e.g. {1, "Отзыв оставили конкуренты"}.
+type ComplaintReason struct {
+	ID   int
+	Text string
+}
+
+// valuationsResp is the response for GET /supplier-valuations. Reasons are
+// keyed by their ID as a string:
+//
+//	{ "data": { "feedbackValuations": { "1": "Отзыв оставили конкуренты", ... },
+//	            "productValuations": { ... } } }
+type valuationsResp struct {
+	Data struct {
+		FeedbackValuations map[string]string `json:"feedbackValuations"`
+	} `json:"data"`
+	Error     bool   `json:"error"`
+	ErrorText string `json:"errorText"`
+}
+
+// complaintRequest is the body for POST /feedbacks/actions
+// Example:
+//
+//	{ "id": "YX52RZEBhH9mrcYdEJuD", "supplierFeedbackValuation": 1 }
+type complaintRequest struct {
+	ID                        string `json:"id"`
+	SupplierFeedbackValuation int    `json:"supplierFeedbackValuation"`
+}
+
 // answerRequest is the body for POST /feedbacks/answer
 // Example:
 //   { "id": "YX52RZEBhH9mrcYdEJuD", "text": "Thank you!" }
@@ -59,3 +133,37 @@ type genericResponse struct {
 	ErrorText        string      `json:"errorText"`
 	AdditionalErrors interface{} `json:"additionalErrors"`
 }
+
+// Question is a customer question about a product (section "Вопросы").
+// Doc: https://dev.wildberries.ru/en/openapi/user-communication#/Questions/get_questions
+type Question struct {
+	ID          string    `json:"id"`
+	Text        string    `json:"text"`
+	CreatedDate time.Time `json:"createdDate"`
+	State       string    `json:"state"`
+	WasViewed   bool      `json:"wasViewed"`
+	IsWarned    bool      `json:"isWarned"`
+}
+
+// questionsListResp is the top‑level response for GET /questions
+type questionsListResp struct {
+	Data struct {
+		CountUnanswered int        `json:"countUnanswered"`
+		Questions       []Question `json:"questions"`
+	} `json:"data"`
+	Error            bool        `json:"error"`
+	ErrorText        string      `json:"errorText"`
+	AdditionalErrors interface{} `json:"additionalErrors"`
+}
+
+// questionAnswerRequest is the body for PATCH /questions
+// Example:
+//
+//	{ "id": "n5um6IUBQOOSTxXoo0gV", "answer": { "text": "Да, подходит" }, "state": "wbRu" }
+type questionAnswerRequest struct {
+	ID     string `json:"id"`
+	Answer struct {
+		Text string `json:"text"`
+	} `json:"answer"`
+	State string `json:"state"`
+}
@@ -0,0 +1,216 @@
+package wbapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ProxyRecheckInterval is how often a proxy found down is checked again;
+	// meanwhile requests go directly.
+	ProxyRecheckInterval = time.Minute
+	proxyCheckTimeout    = 10 * time.Second
+)
+
+// ParseProxy validates a proxy URL: http://, https://, socks5:// or
+// socks5h:// with a host and, optionally, a login and password.
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return nil, errors.New("invalid proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q: want http, https, socks5 or socks5h", u.Scheme)
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return nil, errors.New("proxy URL must not have a path or query")
+	}
+	return u, nil
+}
+
+// WithProxy sends the client's requests through p. Apply it after
+// WithHTTPClient; nil leaves the transport as is.
+func WithProxy(p *Proxy) Option {
+	return func(c *Client) {
+		if p != nil {
+			WithTransport(p)(c)
+		}
+	}
+}
+
+// Proxy is an http.RoundTripper that sends requests through an HTTP or
+// SOCKS5 proxy while it works and directly while it does not, so that a dead
+// proxy does not stop the bot. A request the proxy fails to carry is retried
+// directly and the proxy is marked down; while down it is checked every
+// ProxyRecheckInterval against the host of the latest request and used
+// again once a check passes.
+type Proxy struct {
+	url      *url.URL
+	via      *http.Transport
+	direct   http.RoundTripper
+	log      *zap.SugaredLogger
+	onChange func(up bool) // optional
+
+	down     atomic.Bool
+	checking atomic.Bool
+
+	mu      sync.Mutex
+	target  string    // scheme and host of the latest request
+	checked time.Time // latest check while down
+	since   time.Time // down since
+}
+
+// NewProxy returns a Proxy through proxyURL (see ParseProxy). Direct requests
+// go through base, such as the transport from NewTransport shared by all
+// clients; proxied ones through a clone of it. onChange, if not nil, is
+// called when the proxy goes down or comes back.
+func NewProxy(proxyURL *url.URL, base *http.Transport, log *zap.SugaredLogger, onChange func(up bool)) *Proxy {
+	via := base.Clone()
+	via.Proxy = http.ProxyURL(proxyURL)
+	if log == nil {
+		log = zap.NewNop().Sugar()
+	}
+	return &Proxy{url: proxyURL, via: via, direct: base, log: log, onChange: onChange}
+}
+
+// String returns the proxy URL with the password masked.
+func (p *Proxy) String() string {
+	return p.url.Redacted()
+}
+
+// Up reports whether requests go through the proxy, and if not, since when
+// they go directly.
+func (p *Proxy) Up() (bool, time.Time) {
+	if !p.down.Load() {
+		return true, time.Time{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return false, p.since
+}
+
+// RoundTrip implements http.RoundTripper.
+func (p *Proxy) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.mu.Lock()
+	p.target = req.URL.Scheme + "://" + req.URL.Host
+	p.mu.Unlock()
+
+	if p.down.Load() {
+		p.recheck()
+		return p.direct.RoundTrip(req)
+	}
+	resp, err := p.via.RoundTrip(req)
+	if err == nil || req.Context().Err() != nil || !proxyFailed(err) {
+		return resp, err
+	}
+	p.setDown(err)
+	retry, rerr := rewind(req)
+	if rerr != nil {
+		return nil, err
+	}
+	return p.direct.RoundTrip(retry)
+}
+
+// Check requests target through the proxy and marks the proxy up or down by
+// the outcome. Any HTTP response, an error status included, passes: only
+// the proxy's ability to reach the host is checked.
+func (p *Proxy) Check(ctx context.Context, target string) error {
+	ctx, cancel := context.WithTimeout(ctx, proxyCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.via.RoundTrip(req)
+	if err != nil {
+		p.setDown(err)
+		return err
+	}
+	resp.Body.Close()
+	p.setUp()
+	return nil
+}
+
+// recheck starts a check of a proxy that is down, unless one is running or
+// the latest was less than ProxyRecheckInterval ago.
+func (p *Proxy) recheck() {
+	p.mu.Lock()
+	due := time.Since(p.checked) >= ProxyRecheckInterval
+	if due {
+		p.checked = time.Now()
+	}
+	target := p.target
+	p.mu.Unlock()
+	if !due || !p.checking.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.checking.Store(false)
+		if err := p.Check(context.Background(), target); err != nil {
+			p.log.Debugw("wb proxy is still down", "proxy", p.String(), "err", err)
+		}
+	}()
+}
+
+func (p *Proxy) setDown(err error) {
+	if !p.down.CompareAndSwap(false, true) {
+		return
+	}
+	p.mu.Lock()
+	p.since = time.Now()
+	p.checked = p.since
+	p.mu.Unlock()
+	p.log.Warnw("wb proxy is down, sending requests directly", "proxy", p.String(), "err", err)
+	if p.onChange != nil {
+		p.onChange(false)
+	}
+}
+
+func (p *Proxy) setUp() {
+	if !p.down.CompareAndSwap(true, false) {
+		return
+	}
+	p.mu.Lock()
+	downtime := time.Since(p.since)
+	p.mu.Unlock()
+	p.log.Infow("wb proxy is back, sending requests through it", "proxy", p.String(), "downtime", downtime.Round(time.Second).String())
+	if p.onChange != nil {
+		p.onChange(true)
+	}
+}
+
+// proxyFailed reports whether err means the proxy could not be reached or
+// refused to connect, as opposed to an error of the WB API itself.
+func proxyFailed(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || opErr.Op == "socks connect")
+}
+
+// rewind returns req with its body ready to be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be sent again")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
@@ -0,0 +1,42 @@
+package wbapi
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxConnsPerHost caps connections a shared transport opens to one
+// WB host, across all clients using it.
+const DefaultMaxConnsPerHost = 100
+
+// NewTransport returns an http.Transport meant to be shared by the clients
+// of all users (see WithHTTPClient): connections to the WB API are pooled
+// and kept alive instead of every client dialing its own. maxConnsPerHost
+// <= 0 means DefaultMaxConnsPerHost.
+func NewTransport(maxConnsPerHost int) *http.Transport {
+	if maxConnsPerHost <= 0 {
+		maxConnsPerHost = DefaultMaxConnsPerHost
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxConnsPerHost * 2,
+		MaxIdleConnsPerHost:   maxConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		ResponseHeaderTimeout: DefaultHTTPTimeout,
+	}
+}
+
+// NewHTTPClient returns an http.Client over rt with DefaultHTTPTimeout, for
+// sharing between clients via WithHTTPClient.
+func NewHTTPClient(rt http.RoundTripper) *http.Client {
+	return &http.Client{Transport: rt, Timeout: DefaultHTTPTimeout}
+}
@@ -0,0 +1,162 @@
+package wbapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Endpoint names a logical API operation independent of its URL, so that
+// callers are not affected when WB moves or renames paths in a new version.
+type Endpoint string
+
+const (
+	EndpointFeedbacks      Endpoint = "feedbacks"       // list feedbacks
+	EndpointFeedback       Endpoint = "feedback"        // get one feedback by ID
+	EndpointFeedbackAnswer Endpoint = "feedback_answer" // answer a feedback
+	EndpointFeedbackEdit   Endpoint = "feedback_edit"   // edit a posted feedback answer
+	EndpointArchive        Endpoint = "archive"         // list archived feedbacks
+	EndpointQuestions      Endpoint = "questions"       // list questions
+	EndpointQuestionAnswer Endpoint = "question_answer" // answer a question
+	EndpointValuations     Endpoint = "valuations"      // list complaint reasons
+	EndpointFeedbackAction Endpoint = "feedback_action" // file a complaint about a feedback
+)
+
+// ErrUnsupported is returned when the selected API version (or the detected
+// capabilities of the server) does not provide the requested endpoint.
+var ErrUnsupported = errors.New("wb api: endpoint not supported")
+
+// APIVersion maps logical endpoints to paths for one WB API revision.
+// Endpoints missing from Paths are treated as unsupported.
+type APIVersion struct {
+	Name  string
+	Paths map[Endpoint]string
+}
+
+// V1 is the current Feedbacks/Questions API.
+// Doc: https://dev.wildberries.ru/en/openapi/user-communication
+var V1 = &APIVersion{
+	Name: "v1",
+	Paths: map[Endpoint]string{
+		EndpointFeedbacks:      "/api/v1/feedbacks",
+		EndpointFeedback:       "/api/v1/feedback",
+		EndpointFeedbackAnswer: "/api/v1/feedbacks/answer",
+		EndpointFeedbackEdit:   "/api/v1/feedbacks/answer",
+		EndpointArchive:        "/api/v1/feedbacks/archive",
+		EndpointQuestions:      "/api/v1/questions",
+		EndpointQuestionAnswer: "/api/v1/questions",
+		EndpointValuations:     "/api/v1/supplier-valuations",
+		EndpointFeedbackAction: "/api/v1/feedbacks/actions",
+	},
+}
+
+// DefaultAPIVersion is used by New unless WithAPIVersion is given.
+var DefaultAPIVersion = V1
+
+// WithAPIVersion selects the API revision. Nil keeps DefaultAPIVersion.
+func WithAPIVersion(v *APIVersion) Option {
+	return func(c *Client) {
+		if v != nil {
+			c.version = v
+		}
+	}
+}
+
+// APIVersion returns the name of the API revision used by the client.
+func (c *Client) APIVersion() string {
+	return c.version.Name
+}
+
+// Supports reports whether the endpoint is available: it must be defined by
+// the API version and not marked unavailable by DetectCapabilities.
+func (c *Client) Supports(ep Endpoint) bool {
+	if _, ok := c.version.Paths[ep]; !ok {
+		return false
+	}
+	c.capMu.RLock()
+	defer c.capMu.RUnlock()
+	return !c.unavailable[ep]
+}
+
+// DetectCapabilities probes the list endpoints with a single-item request and
+// marks those answering 404 or 405 as unavailable, e.g. when a token or a
+// sandbox does not expose questions. Other errors are returned as is and do
+// not change capabilities.
+func (c *Client) DetectCapabilities(ctx context.Context) error {
+	probes := []struct {
+		ep    Endpoint
+		probe func(context.Context) error
+	}{
+		{EndpointFeedbacks, func(ctx context.Context) error {
+			_, err := c.FetchUnanswered(ctx, 1, 0)
+			return err
+		}},
+		{EndpointQuestions, func(ctx context.Context) error {
+			_, err := c.FetchUnansweredQuestions(ctx, 1, 0)
+			return err
+		}},
+	}
+	for _, p := range probes {
+		ep := p.ep
+		if _, ok := c.version.Paths[ep]; !ok {
+			continue
+		}
+		err := p.probe(ctx)
+		var apiErr *APIError
+		missing := errors.As(err, &apiErr) &&
+			(apiErr.Status == http.StatusNotFound || apiErr.Status == http.StatusMethodNotAllowed)
+		if err != nil && !missing {
+			return err
+		}
+		c.capMu.Lock()
+		c.unavailable[ep] = missing
+		c.capMu.Unlock()
+		if missing {
+			c.log.Infow("wb api endpoint unavailable", "endpoint", ep, "version", c.version.Name)
+		}
+	}
+	return nil
+}
+
+// Backoff between failed EnsureCapabilities attempts: it doubles from
+// capRetryMin up to capRetryMax.
+const (
+	capRetryMin = time.Minute
+	capRetryMax = 30 * time.Minute
+)
+
+// EnsureCapabilities runs DetectCapabilities once per client. A failed
+// detection is retried no sooner than after a backoff, so that callers
+// running it before every fetch do not spend a request of their rate limit
+// on each try while WB is failing. Calls in between return nil at once and
+// leave every endpoint of the version available.
+func (c *Client) EnsureCapabilities(ctx context.Context) error {
+	c.capMu.Lock()
+	if c.capDone || time.Now().Before(c.capRetryAt) {
+		c.capMu.Unlock()
+		return nil
+	}
+	// Claim the attempt, so that concurrent callers do not probe as well.
+	c.capBackoff = min(max(2*c.capBackoff, capRetryMin), capRetryMax)
+	c.capRetryAt = time.Now().Add(c.capBackoff)
+	c.capMu.Unlock()
+
+	err := c.DetectCapabilities(ctx)
+	if err == nil {
+		c.capMu.Lock()
+		c.capDone, c.capBackoff = true, 0
+		c.capMu.Unlock()
+	}
+	return err
+}
+
+// endpoint returns the absolute URL of ep for the selected version.
+func (c *Client) endpoint(ep Endpoint) (string, error) {
+	p, ok := c.version.Paths[ep]
+	if !ok {
+		return "", fmt.Errorf("%w: %s in %s", ErrUnsupported, ep, c.version.Name)
+	}
+	return c.resolve(p), nil
+}
@@ -0,0 +1,151 @@
+package wbapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// capServer answers the list endpoints of V1 with the given statuses and
+// counts the requests to them.
+type capServer struct {
+	mu       sync.Mutex
+	status   map[string]int // by path; 200 when missing
+	requests int
+}
+
+func (s *capServer) setStatus(path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[path] = status
+}
+
+func (s *capServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *capServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	status := s.status[r.URL.Path]
+	s.mu.Unlock()
+	if status != 0 && status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"data":{}}`))
+}
+
+func newCapClient(t *testing.T, statuses map[string]int) (*Client, *capServer) {
+	t.Helper()
+	s := &capServer{status: statuses}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return New("token", WithBaseURL(srv.URL)), s
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	feedbacks, questions := V1.Paths[EndpointFeedbacks], V1.Paths[EndpointQuestions]
+	tests := []struct {
+		name          string
+		statuses      map[string]int
+		wantErr       bool
+		wantFeedbacks bool
+		wantQuestions bool
+	}{
+		{"all available", map[string]int{}, false, true, true},
+		{"questions 404", map[string]int{questions: http.StatusNotFound}, false, true, false},
+		{"feedbacks 405", map[string]int{feedbacks: http.StatusMethodNotAllowed}, false, false, true},
+		{"questions 500", map[string]int{questions: http.StatusInternalServerError}, true, true, true},
+		{"feedbacks 503", map[string]int{feedbacks: http.StatusServiceUnavailable}, true, true, true},
+		{"questions 401", map[string]int{questions: http.StatusUnauthorized}, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newCapClient(t, tt.statuses)
+			err := c.DetectCapabilities(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectCapabilities = %v, want error %v", err, tt.wantErr)
+			}
+			if got := c.Supports(EndpointFeedbacks); got != tt.wantFeedbacks {
+				t.Errorf("Supports(feedbacks) = %v, want %v", got, tt.wantFeedbacks)
+			}
+			if got := c.Supports(EndpointQuestions); got != tt.wantQuestions {
+				t.Errorf("Supports(questions) = %v, want %v", got, tt.wantQuestions)
+			}
+		})
+	}
+}
+
+func TestDetectCapabilitiesServerErrorKeepsKnownGaps(t *testing.T) {
+	questions := V1.Paths[EndpointQuestions]
+	c, s := newCapClient(t, map[string]int{questions: http.StatusNotFound})
+	if err := c.DetectCapabilities(context.Background()); err != nil {
+		t.Fatalf("DetectCapabilities: %v", err)
+	}
+	s.setStatus(questions, http.StatusBadGateway)
+	var apiErr *APIError
+	if err := c.DetectCapabilities(context.Background()); !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadGateway {
+		t.Fatalf("DetectCapabilities = %v, want the 502", err)
+	}
+	if c.Supports(EndpointQuestions) {
+		t.Error("questions became available after a 5xx")
+	}
+}
+
+func TestEnsureCapabilitiesBacksOff(t *testing.T) {
+	feedbacks := V1.Paths[EndpointFeedbacks]
+	c, s := newCapClient(t, map[string]int{feedbacks: http.StatusInternalServerError})
+	ctx := context.Background()
+
+	if err := c.EnsureCapabilities(ctx); err == nil {
+		t.Fatal("EnsureCapabilities = nil, want the 500")
+	}
+	probes := s.count()
+	if err := c.EnsureCapabilities(ctx); err != nil {
+		t.Fatalf("EnsureCapabilities within the backoff = %v, want nil", err)
+	}
+	if n := s.count() - probes; n != 0 {
+		t.Fatalf("requests within the backoff = %d, want 0", n)
+	}
+	if c.capBackoff != capRetryMin {
+		t.Errorf("backoff = %v, want %v", c.capBackoff, capRetryMin)
+	}
+
+	// The next failure doubles the backoff
+	c.capRetryAt = time.Time{}
+	if err := c.EnsureCapabilities(ctx); err == nil {
+		t.Fatal("second EnsureCapabilities = nil, want the 500")
+	}
+	if c.capBackoff != 2*capRetryMin {
+		t.Errorf("backoff after two failures = %v, want %v", c.capBackoff, 2*capRetryMin)
+	}
+	c.capBackoff = capRetryMax
+	c.capRetryAt = time.Time{}
+	c.EnsureCapabilities(ctx)
+	if c.capBackoff != capRetryMax {
+		t.Errorf("backoff = %v, want it capped at %v", c.capBackoff, capRetryMax)
+	}
+
+	// Once detection succeeds it is not repeated
+	s.setStatus(feedbacks, http.StatusNotFound)
+	c.capRetryAt = time.Time{}
+	if err := c.EnsureCapabilities(ctx); err != nil {
+		t.Fatalf("EnsureCapabilities after recovery = %v", err)
+	}
+	if c.Supports(EndpointFeedbacks) {
+		t.Error("feedbacks supported after a 404")
+	}
+	probes = s.count()
+	c.capRetryAt = time.Time{}
+	if err := c.EnsureCapabilities(ctx); err != nil || s.count() != probes {
+		t.Errorf("EnsureCapabilities after success = %v with %d requests, want nil and none", err, s.count()-probes)
+	}
+}
@@ -0,0 +1,50 @@
+package wbapitest
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+)
+
+// Proxy is an HTTP forward proxy for plain http URLs, counting the requests
+// it carries. Point wbapi.NewProxy at its URL to route a client's requests
+// to Server through it.
+type Proxy struct {
+	// Addr is the host:port the proxy listens on.
+	Addr string
+
+	srv     *http.Server
+	carried atomic.Int64
+}
+
+// StartProxy starts a proxy on addr; "127.0.0.1:0" picks a free port. The
+// address of a closed proxy can be passed again to bring it back.
+func StartProxy(addr string) (*Proxy, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proxy{Addr: ln.Addr().String()}
+	p.srv = &http.Server{Handler: &httputil.ReverseProxy{Rewrite: func(*httputil.ProxyRequest) {
+		p.carried.Add(1)
+	}}}
+	go p.srv.Serve(ln)
+	return p, nil
+}
+
+// URL returns the proxy URL to configure clients with.
+func (p *Proxy) URL() *url.URL {
+	return &url.URL{Scheme: "http", Host: p.Addr}
+}
+
+// Carried returns how many requests went through the proxy.
+func (p *Proxy) Carried() int64 {
+	return p.carried.Load()
+}
+
+// Close stops the proxy; requests to its address then fail to connect.
+func (p *Proxy) Close() error {
+	return p.srv.Close()
+}
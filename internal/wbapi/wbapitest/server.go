@@ -0,0 +1,475 @@
+package wbapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"feedback_bot/internal/wbapi"
+
+	"golang.org/x/time/rate"
+)
+
+// Server is an in-memory fake of the WB Feedbacks/Questions API served over
+// httptest. It keeps unanswered feedbacks and questions, removes them from
+// the unanswered lists once answered (as WB does; answered feedbacks are
+// listed with isAnswered=true), records every answer and can inject failures
+// per endpoint or simulate WB rate limiting.
+//
+//	srv := wbapitest.NewServer("token")
+//	defer srv.Close()
+//	srv.AddFeedbacks(wbapi.Feedback{ID: "fb1", ProductValuation: 5})
+//	cli := wbapi.New("token", wbapi.WithBaseURL(srv.URL))
+type Server struct {
+	URL string
+
+	srv   *httptest.Server
+	token string
+
+	mu          sync.Mutex
+	feedbacks   []wbapi.Feedback
+	answered    []wbapi.Feedback // answered through this server, until archived
+	archived    []wbapi.Feedback
+	questions   []wbapi.Question
+	answers     []Answer
+	complaints  map[string]int // feedback ID → reason ID
+	faults      map[wbapi.Endpoint][]Fault
+	disabled    map[wbapi.Endpoint]bool
+	limiter     *rate.Limiter
+	retryAfter  time.Duration
+	requests    map[wbapi.Endpoint]int
+	rateLimited int
+}
+
+// Answer is a reply accepted by the Server.
+type Answer struct {
+	Endpoint wbapi.Endpoint // EndpointFeedbackAnswer, EndpointFeedbackEdit or EndpointQuestionAnswer
+	ID       string
+	Text     string
+}
+
+// NewServer starts a fake WB API that accepts only the given token.
+// The caller must Close it.
+func NewServer(token string) *Server {
+	s := &Server{
+		token:      token,
+		faults:     make(map[wbapi.Endpoint][]Fault),
+		disabled:   make(map[wbapi.Endpoint]bool),
+		requests:   make(map[wbapi.Endpoint]int),
+		complaints: make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/feedbacks", s.route(wbapi.EndpointFeedbacks, s.listFeedbacks))
+	mux.HandleFunc("GET /api/v1/feedback", s.route(wbapi.EndpointFeedback, s.getFeedback))
+	mux.HandleFunc("GET /api/v1/feedbacks/archive", s.route(wbapi.EndpointArchive, s.listArchived))
+	mux.HandleFunc("POST /api/v1/feedbacks/answer", s.route(wbapi.EndpointFeedbackAnswer, s.answerFeedback))
+	mux.HandleFunc("PATCH /api/v1/feedbacks/answer", s.route(wbapi.EndpointFeedbackEdit, s.editFeedbackAnswer))
+	mux.HandleFunc("GET /api/v1/questions", s.route(wbapi.EndpointQuestions, s.listQuestions))
+	mux.HandleFunc("PATCH /api/v1/questions", s.route(wbapi.EndpointQuestionAnswer, s.answerQuestion))
+	mux.HandleFunc("GET /api/v1/supplier-valuations", s.route(wbapi.EndpointValuations, s.listValuations))
+	mux.HandleFunc("POST /api/v1/feedbacks/actions", s.route(wbapi.EndpointFeedbackAction, s.complainFeedback))
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// AddFeedbacks adds unanswered feedbacks. List responses keep the order in
+// which they were added; add the newest first to mirror order=dateDesc.
+func (s *Server) AddFeedbacks(fbs ...wbapi.Feedback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedbacks = append(s.feedbacks, fbs...)
+}
+
+// AddArchived adds feedbacks to the archive; set Answer for answered ones.
+// Answering an archived feedback fills its Answer, it stays in the archive.
+func (s *Server) AddArchived(fbs ...wbapi.Feedback) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.archived = append(s.archived, fbs...)
+}
+
+// UpdateFeedback replaces the unanswered, answered or archived feedback with
+// the ID of fb, as when a buyer edits a review; the seller's answer is kept.
+// It reports whether the feedback was found.
+func (s *Server) UpdateFeedback(fb wbapi.Feedback) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, list := range [][]wbapi.Feedback{s.feedbacks, s.answered, s.archived} {
+		if i := indexOf(list, func(f wbapi.Feedback) bool { return f.ID == fb.ID }); i >= 0 {
+			fb.Answer = list[i].Answer
+			list[i] = fb
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFeedback deletes the feedback with the given ID from every list, as
+// WB moderation does when it accepts a complaint. It reports whether the
+// feedback was found.
+func (s *Server) RemoveFeedback(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	match := func(fb wbapi.Feedback) bool { return fb.ID == id }
+	for _, list := range []*[]wbapi.Feedback{&s.feedbacks, &s.answered, &s.archived} {
+		if i := indexOf(*list, match); i >= 0 {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			found = true
+		}
+	}
+	return found
+}
+
+// AddQuestions adds unanswered questions.
+func (s *Server) AddQuestions(qs ...wbapi.Question) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.questions = append(s.questions, qs...)
+}
+
+// Fail queues faults for the next requests to ep, in FIFO order. Err is
+// ignored: the server can only answer with a status, use FaultTransport for
+// transport errors.
+func (s *Server) Fail(ep wbapi.Endpoint, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[ep] = append(s.faults[ep], faults...)
+}
+
+// Disable makes ep answer 404, as WB does for sections a token or a sandbox
+// does not expose. DetectCapabilities then marks it unavailable.
+func (s *Server) Disable(ep wbapi.Endpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disabled[ep] = true
+}
+
+// SetRateLimit simulates WB rate limiting: requests beyond rps with the
+// given burst are answered 429 with Retry-After. rps <= 0 turns it off.
+func (s *Server) SetRateLimit(rps float64, burst int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rps <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	s.retryAfter = retryAfter
+}
+
+// Answers returns a copy of the answers accepted so far, oldest first.
+func (s *Server) Answers() []Answer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Answer, len(s.answers))
+	copy(out, s.answers)
+	return out
+}
+
+// Complaints returns the complaints filed so far as feedback ID → reason ID.
+func (s *Server) Complaints() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.complaints))
+	for id, reason := range s.complaints {
+		out[id] = reason
+	}
+	return out
+}
+
+// Unanswered returns how many feedbacks are still waiting for an answer.
+func (s *Server) Unanswered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.feedbacks)
+}
+
+// Requests returns how many requests reached ep, including failed ones.
+func (s *Server) Requests(ep wbapi.Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[ep]
+}
+
+// RateLimited returns how many requests were rejected by SetRateLimit.
+func (s *Server) RateLimited() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimited
+}
+
+// route wraps an endpoint handler with auth, disabled endpoints, rate
+// limiting and injected faults, in the order WB applies them.
+func (s *Server) route(ep wbapi.Endpoint, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[ep]++
+		if r.Header.Get("Authorization") != "Bearer "+s.token {
+			s.mu.Unlock()
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if s.disabled[ep] {
+			s.mu.Unlock()
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if s.limiter != nil && !s.limiter.Allow() {
+			s.rateLimited++
+			retryAfter := s.retryAfter
+			s.mu.Unlock()
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+			}
+			writeError(w, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		var fault *Fault
+		if q := s.faults[ep]; len(q) > 0 {
+			fault = &q[0]
+			s.faults[ep] = q[1:]
+		}
+		s.mu.Unlock()
+
+		if fault == nil {
+			h(w, r)
+			return
+		}
+		if fault.Delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(fault.Delay):
+			}
+		}
+		for k, v := range fault.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(fault.Status)
+		fmt.Fprint(w, fault.Body)
+	}
+}
+
+func (s *Server) listFeedbacks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := s.feedbacks
+	if r.URL.Query().Get("isAnswered") == "true" {
+		list = s.answered
+	}
+	page := pageOf(list, r)
+	data := map[string]any{"countUnanswered": len(s.feedbacks), "feedbacks": page}
+	s.mu.Unlock()
+	writeData(w, data)
+}
+
+// getFeedback returns one unanswered, answered or archived feedback, or 404
+// when there is none with the ID.
+func (s *Server) getFeedback(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	s.mu.Lock()
+	var fb *wbapi.Feedback
+	for _, list := range [][]wbapi.Feedback{s.feedbacks, s.answered, s.archived} {
+		if i := indexOf(list, func(f wbapi.Feedback) bool { return f.ID == id }); i >= 0 {
+			found := list[i]
+			fb = &found
+			break
+		}
+	}
+	s.mu.Unlock()
+	if fb == nil {
+		writeError(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	writeData(w, fb)
+}
+
+func (s *Server) listArchived(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	page := pageOf(s.archived, r)
+	data := map[string]any{"feedbacks": page}
+	s.mu.Unlock()
+	writeData(w, data)
+}
+
+func (s *Server) listQuestions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	page := pageOf(s.questions, r)
+	data := map[string]any{"countUnanswered": len(s.questions), "questions": page}
+	s.mu.Unlock()
+	writeData(w, data)
+}
+
+func (s *Server) answerFeedback(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" || req.Text == "" {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := indexOf(s.feedbacks, func(fb wbapi.Feedback) bool { return fb.ID == req.ID })
+	switch j := indexOf(s.archived, func(fb wbapi.Feedback) bool { return fb.ID == req.ID }); {
+	case i >= 0:
+		fb := s.feedbacks[i]
+		fb.Answer = &wbapi.FeedbackAnswer{Text: req.Text, State: "wbRu", Editable: true}
+		s.answered = append([]wbapi.Feedback{fb}, s.answered...)
+		s.feedbacks = append(s.feedbacks[:i], s.feedbacks[i+1:]...)
+	case j >= 0 && s.archived[j].Answer == nil:
+		s.archived[j].Answer = &wbapi.FeedbackAnswer{Text: req.Text, State: "wbRu"}
+	default:
+		writeError(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	s.answers = append(s.answers, Answer{Endpoint: wbapi.EndpointFeedbackAnswer, ID: req.ID, Text: req.Text})
+	writeData(w, nil)
+}
+
+// editFeedbackAnswer accepts edits only for feedbacks answered through this
+// server, like WB does for unknown or unanswered IDs.
+func (s *Server) editFeedbackAnswer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID   string `json:"id"`
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" || req.Text == "" {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := indexOf(s.answers, func(a Answer) bool { return a.Endpoint == wbapi.EndpointFeedbackAnswer && a.ID == req.ID })
+	if i < 0 {
+		writeError(w, http.StatusBadRequest, "feedback has no answer")
+		return
+	}
+	s.answers = append(s.answers, Answer{Endpoint: wbapi.EndpointFeedbackEdit, ID: req.ID, Text: req.Text})
+	writeData(w, nil)
+}
+
+func (s *Server) answerQuestion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     string `json:"id"`
+		Answer struct {
+			Text string `json:"text"`
+		} `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" || req.Answer.Text == "" {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := indexOf(s.questions, func(q wbapi.Question) bool { return q.ID == req.ID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "question not found")
+		return
+	}
+	s.questions = append(s.questions[:i], s.questions[i+1:]...)
+	s.answers = append(s.answers, Answer{Endpoint: wbapi.EndpointQuestionAnswer, ID: req.ID, Text: req.Answer.Text})
+	writeData(w, nil)
+}
+
+// ComplaintReasons are the complaint reasons the Server lists and accepts.
+var ComplaintReasons = map[string]string{
+	"1": "Отзыв оставили конкуренты",
+	"2": "Отзыв не относится к товару",
+	"3": "Спам-реклама в тексте",
+	"4": "Нецензурная лексика",
+	"5": "Фото не имеет отношения к товару",
+}
+
+func (s *Server) listValuations(w http.ResponseWriter, r *http.Request) {
+	writeData(w, map[string]any{"feedbackValuations": ComplaintReasons, "productValuations": map[string]string{}})
+}
+
+// complainFeedback accepts a complaint about a known feedback with a listed
+// reason and answers 204 like WB.
+func (s *Server) complainFeedback(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     string `json:"id"`
+		Reason int    `json:"supplierFeedbackValuation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeError(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	if _, ok := ComplaintReasons[strconv.Itoa(req.Reason)]; !ok {
+		writeError(w, http.StatusBadRequest, "unknown supplierFeedbackValuation")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	match := func(fb wbapi.Feedback) bool { return fb.ID == req.ID }
+	if i := indexOf(s.feedbacks, match); i >= 0 {
+		s.feedbacks[i].SupplierFeedbackValuation = req.Reason
+	} else if j := indexOf(s.archived, match); j >= 0 {
+		s.archived[j].SupplierFeedbackValuation = req.Reason
+	} else {
+		writeError(w, http.StatusNotFound, "feedback not found")
+		return
+	}
+	s.complaints[req.ID] = req.Reason
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pageOf applies the take and skip query parameters to items.
+func pageOf[T any](items []T, r *http.Request) []T {
+	take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+	skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+	if skip < 0 || skip > len(items) {
+		skip = len(items)
+	}
+	end := len(items)
+	if take > 0 && skip+take < end {
+		end = skip + take
+	}
+	out := make([]T, end-skip)
+	copy(out, items[skip:end])
+	return out
+}
+
+func indexOf[T any](items []T, match func(T) bool) int {
+	for i, it := range items {
+		if match(it) {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeData writes the WB success envelope around data.
+func writeData(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"data":             data,
+		"error":            false,
+		"errorText":        "",
+		"additionalErrors": nil,
+	})
+}
+
+// writeError writes the WB error envelope with the given status.
+func writeError(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data":             nil,
+		"error":            true,
+		"errorText":        text,
+		"additionalErrors": nil,
+	})
+}
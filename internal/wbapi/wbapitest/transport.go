@@ -0,0 +1,121 @@
+// Package wbapitest provides helpers for exercising wbapi.Client without
+// talking to the real Wildberries API.
+package wbapitest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault describes a single injected failure. Exactly one of Err or Status
+// should be set; Delay is applied before either.
+type Fault struct {
+	Err    error         // transport-level error (connection reset, timeout…)
+	Status int           // HTTP status to return instead of calling Base
+	Body   string        // response body for Status
+	Header http.Header   // optional response headers (e.g. Retry-After)
+	Delay  time.Duration // artificial latency; honours request context
+}
+
+// FaultTransport is an http.RoundTripper that records every request and
+// replays queued faults in FIFO order. When the queue is empty requests are
+// passed to Base (http.DefaultTransport if nil).
+//
+//	ft := &wbapitest.FaultTransport{}
+//	ft.Push(wbapitest.Fault{Status: http.StatusTooManyRequests})
+//	cli := wbapi.New(token, wbapi.WithTransport(ft))
+type FaultTransport struct {
+	Base http.RoundTripper
+
+	mu       sync.Mutex
+	faults   []Fault
+	requests []RecordedRequest
+}
+
+// RecordedRequest is a snapshot of an outgoing request.
+type RecordedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+}
+
+// Push queues faults to be returned by subsequent requests.
+func (t *FaultTransport) Push(faults ...Fault) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.faults = append(t.faults, faults...)
+}
+
+// Requests returns a copy of all requests seen so far.
+func (t *FaultTransport) Requests() []RecordedRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]RecordedRequest, len(t.requests))
+	copy(out, t.requests)
+	return out
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := RecordedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone()}
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rec.Body = string(b)
+		req.Body = io.NopCloser(strings.NewReader(rec.Body))
+	}
+
+	t.mu.Lock()
+	t.requests = append(t.requests, rec)
+	var fault *Fault
+	if len(t.faults) > 0 {
+		f := t.faults[0]
+		t.faults = t.faults[1:]
+		fault = &f
+	}
+	t.mu.Unlock()
+
+	if fault == nil {
+		base := t.Base
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		return base.RoundTrip(req)
+	}
+
+	if fault.Delay > 0 {
+		timer := time.NewTimer(fault.Delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if fault.Err != nil {
+		return nil, fault.Err
+	}
+
+	header := fault.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode:    fault.Status,
+		Status:        http.StatusText(fault.Status),
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fault.Body)),
+		ContentLength: int64(len(fault.Body)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}, nil
+}
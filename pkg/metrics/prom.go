@@ -1,8 +1,12 @@
 package metrics
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -24,6 +28,24 @@ var (
 			Name: "feedback_bot_processed_feedbacks_total",
 			Help: "Total number of processed feedbacks",
 		},
+		[]string{"user_id", "status"}, // status: answered, skipped, duplicate, failed
+	)
+
+	// DuplicateSkips tracks reviews left unanswered as part of a burst of identical reviews
+	DuplicateSkips = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feedback_bot_duplicate_skips_total",
+			Help: "Reviews left unanswered because many identical reviews arrived in one cycle",
+		},
+		[]string{"user_id"},
+	)
+
+	// ProcessedQuestions tracks the number of processed product questions
+	ProcessedQuestions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feedback_bot_processed_questions_total",
+			Help: "Total number of processed product questions",
+		},
 		[]string{"user_id", "status"}, // status: answered, skipped, failed
 	)
 
@@ -36,6 +58,109 @@ var (
 		[]string{"user_id"},
 	)
 
+	// StuckAnswers tracks answers that failed StuckAttempts times or more
+	// and are still being retried
+	StuckAnswers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_stuck_answers",
+			Help: "Number of reviews and questions whose answer keeps failing",
+		},
+		[]string{"user_id"},
+	)
+
+	// LastCycleTimestamp tracks when each user's latest cycle finished
+	LastCycleTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_last_cycle_timestamp_seconds",
+			Help: "Unix time when the user's latest polling cycle finished",
+		},
+		[]string{"user_id"},
+	)
+
+	// LastCycleAnswered tracks feedbacks answered by each user's latest cycle
+	LastCycleAnswered = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_last_cycle_answered",
+			Help: "Number of feedbacks answered by the user's latest polling cycle",
+		},
+		[]string{"user_id"},
+	)
+
+	// FeedbacksPending tracks unanswered feedbacks as reported by WB
+	// (countUnanswered), including those beyond the fetched page
+	FeedbacksPending = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_feedbacks_pending",
+			Help: "Unanswered feedbacks reported by WB at the user's latest cycle",
+		},
+		[]string{"user_id"},
+	)
+
+	// FeedbacksPendingTotal is FeedbacksPending summed over users
+	FeedbacksPendingTotal = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_feedbacks_pending_total",
+			Help: "Unanswered feedbacks reported by WB, summed over all users",
+		},
+	)
+
+	// WBDegraded is 1 while WB is considered down and cycles are paused
+	WBDegraded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_wb_degraded",
+			Help: "1 while the WB API is considered down and cycles of all users are paused",
+		},
+	)
+
+	// DatabaseUp is 1 while the database answers the watchdog's pings
+	DatabaseUp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_database_up",
+			Help: "1 while the database answers the periodic health check, 0 after consecutive failures",
+		},
+	)
+
+	// CycleQueueDepth is the number of due user cycles waiting for a worker
+	CycleQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_cycle_queue_depth",
+			Help: "User cycles that are due and waiting for a free worker of the cycle pool",
+		},
+	)
+
+	// CycleWorkersBusy is the number of cycle pool workers running a cycle
+	CycleWorkersBusy = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_cycle_workers_busy",
+			Help: "Workers of the cycle pool currently running a user cycle",
+		},
+	)
+
+	// CycleLockSkips counts cycles skipped because another instance held the user's lock
+	CycleLockSkips = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "feedback_bot_cycle_lock_skips_total",
+			Help: "Cycles skipped because another bot instance was processing the same user",
+		},
+	)
+
+	// TelegramQueueDepth is the number of messages waiting for a retry
+	TelegramQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "feedback_bot_telegram_queue_depth",
+			Help: "Telegram messages refused temporarily (flood limit, server or network error) and waiting for a retry",
+		},
+	)
+
+	// WBBudgetWait tracks how long WB requests waited for the global rate budget
+	WBBudgetWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "feedback_bot_wb_budget_wait_seconds",
+			Help:    "Time WB API requests waited for the rate budget shared by all users",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+	)
+
 	// DatabaseErrors tracks database errors
 	DatabaseErrors = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -59,23 +184,38 @@ func init() {
 	// Register all metrics
 	prometheus.MustRegister(ActiveUsers)
 	prometheus.MustRegister(ProcessedFeedbacks)
+	prometheus.MustRegister(DuplicateSkips)
+	prometheus.MustRegister(ProcessedQuestions)
 	prometheus.MustRegister(RateLimitHits)
+	prometheus.MustRegister(StuckAnswers)
+	prometheus.MustRegister(LastCycleTimestamp)
+	prometheus.MustRegister(LastCycleAnswered)
+	prometheus.MustRegister(FeedbacksPending)
+	prometheus.MustRegister(FeedbacksPendingTotal)
+	prometheus.MustRegister(WBDegraded)
+	prometheus.MustRegister(DatabaseUp)
+	prometheus.MustRegister(CycleQueueDepth)
+	prometheus.MustRegister(CycleWorkersBusy)
+	prometheus.MustRegister(CycleLockSkips)
+	prometheus.MustRegister(TelegramQueueDepth)
+	prometheus.MustRegister(WBBudgetWait)
 	prometheus.MustRegister(DatabaseErrors)
 	prometheus.MustRegister(APIErrors)
 }
 
 // MustServe exposes Prometheus metrics on the given address (e.g., ":8080").
-// It registers the default Prometheus handler and launches http.Server in a
-// separate goroutine. Fatal‑logs on startup failure. Returns the server so the
-// caller can gracefully shutdown.
+// It registers the default Prometheus handler and HealthHandler(health) at
+// /healthz and launches http.Server in a separate goroutine. Fatal‑logs on
+// startup failure. Returns the server so the caller can gracefully shutdown.
 //
 // Example usage:
 //
-//	srv := metrics.MustServe(":8080", log)
+//	srv := metrics.MustServe(":8080", log, store.Ping)
 //	// later: srv.Shutdown(ctx)
-func MustServe(addr string, log *zap.SugaredLogger) *http.Server {
+func MustServe(addr string, log *zap.SugaredLogger, health func(context.Context) error) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/healthz", HealthHandler(health))
 
 	srv := &http.Server{
 		Addr:    addr,
@@ -92,6 +232,28 @@ func MustServe(addr string, log *zap.SugaredLogger) *http.Server {
 	return srv
 }
 
+// healthTimeout bounds a health check so that a hung database fails the
+// probe instead of blocking it.
+const healthTimeout = 3 * time.Second
+
+// HealthHandler answers 200 "ok" if check succeeds within healthTimeout and
+// 503 with the error otherwise, for liveness probes. A nil check always
+// succeeds.
+func HealthHandler(check func(context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+			defer cancel()
+			if err := check(ctx); err != nil {
+				http.Error(w, "unhealthy: "+err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ok\n")
+	})
+}
+
 // Helper functions for updating metrics
 
 // UpdateActiveUsers updates the active users metric
@@ -104,11 +266,114 @@ func IncrementProcessedFeedback(userID int64, status string) {
 	ProcessedFeedbacks.WithLabelValues(strconv.FormatInt(userID, 10), status).Inc()
 }
 
+// IncrementDuplicateSkip counts a review skipped as a duplicate; it is also
+// counted as a processed feedback with status "duplicate"
+func IncrementDuplicateSkip(userID int64) {
+	label := strconv.FormatInt(userID, 10)
+	DuplicateSkips.WithLabelValues(label).Inc()
+	ProcessedFeedbacks.WithLabelValues(label, "duplicate").Inc()
+}
+
+// IncrementProcessedQuestion increments processed question counter
+func IncrementProcessedQuestion(userID int64, status string) {
+	ProcessedQuestions.WithLabelValues(strconv.FormatInt(userID, 10), status).Inc()
+}
+
 // IncrementRateLimitHit increments rate limit hit counter
 func IncrementRateLimitHit(userID int64) {
 	RateLimitHits.WithLabelValues(strconv.FormatInt(userID, 10)).Inc()
 }
 
+// SetStuckAnswers sets the number of stuck answers of the user
+func SetStuckAnswers(userID int64, n int) {
+	StuckAnswers.WithLabelValues(strconv.FormatInt(userID, 10)).Set(float64(n))
+}
+
+// pending keeps the latest FeedbacksPending value per user to maintain
+// FeedbacksPendingTotal
+var pending = struct {
+	sync.Mutex
+	byUser map[int64]int
+}{byUser: make(map[int64]int)}
+
+// RecordCycle marks the end of a user's polling cycle
+func RecordCycle(userID int64, answered int) {
+	label := strconv.FormatInt(userID, 10)
+	LastCycleTimestamp.WithLabelValues(label).Set(float64(time.Now().Unix()))
+	LastCycleAnswered.WithLabelValues(label).Set(float64(answered))
+}
+
+// SetFeedbacksPending sets the user's unanswered feedbacks and updates the total
+func SetFeedbacksPending(userID int64, n int) {
+	FeedbacksPending.WithLabelValues(strconv.FormatInt(userID, 10)).Set(float64(n))
+	pending.Lock()
+	defer pending.Unlock()
+	pending.byUser[userID] = n
+	updatePendingTotal()
+}
+
+// ForgetUser drops the per-user gauges of a user whose service was stopped,
+// so that stale values do not stay in dashboards and the total
+func ForgetUser(userID int64) {
+	label := strconv.FormatInt(userID, 10)
+	LastCycleTimestamp.DeleteLabelValues(label)
+	LastCycleAnswered.DeleteLabelValues(label)
+	FeedbacksPending.DeleteLabelValues(label)
+	StuckAnswers.DeleteLabelValues(label)
+	pending.Lock()
+	defer pending.Unlock()
+	delete(pending.byUser, userID)
+	updatePendingTotal()
+}
+
+// updatePendingTotal must be called with pending locked
+func updatePendingTotal() {
+	var total int
+	for _, n := range pending.byUser {
+		total += n
+	}
+	FeedbacksPendingTotal.Set(float64(total))
+}
+
+// SetWBDegraded sets the WB degraded gauge
+func SetWBDegraded(degraded bool) {
+	v := 0.0
+	if degraded {
+		v = 1
+	}
+	WBDegraded.Set(v)
+}
+
+// SetDatabaseUp sets the database health gauge
+func SetDatabaseUp(up bool) {
+	v := 0.0
+	if up {
+		v = 1
+	}
+	DatabaseUp.Set(v)
+}
+
+// SetCyclePool sets the cycle pool queue depth and busy worker gauges
+func SetCyclePool(queued, busy int) {
+	CycleQueueDepth.Set(float64(queued))
+	CycleWorkersBusy.Set(float64(busy))
+}
+
+// IncrementCycleLockSkip counts a cycle skipped for another instance's lock
+func IncrementCycleLockSkip() {
+	CycleLockSkips.Inc()
+}
+
+// SetTelegramQueueDepth sets the Telegram retry queue gauge
+func SetTelegramQueueDepth(n int) {
+	TelegramQueueDepth.Set(float64(n))
+}
+
+// ObserveWBBudgetWait records how long a WB request waited for the global budget
+func ObserveWBBudgetWait(d time.Duration) {
+	WBBudgetWait.Observe(d.Seconds())
+}
+
 // IncrementDatabaseError increments database error counter
 func IncrementDatabaseError(operation string) {
 	DatabaseErrors.WithLabelValues(operation).Inc()
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Snapshot is a point-in-time reading of the bot's own metrics, used to show
+// the operator an overview in Telegram without opening Grafana.
+type Snapshot struct {
+	At          time.Time
+	ActiveUsers int
+
+	FeedbacksAnswered int64
+	FeedbacksFailed   int64
+	QuestionsAnswered int64
+	QuestionsFailed   int64
+
+	WBErrors       int64
+	TelegramErrors int64
+	DatabaseErrors int64
+	RateLimitHits  int64
+}
+
+// TakeSnapshot reads the current metric values, summed over user labels.
+func TakeSnapshot() Snapshot {
+	s := Snapshot{At: time.Now()}
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return s
+	}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			v := int64(m.GetCounter().GetValue())
+
+			switch mf.GetName() {
+			case "feedback_bot_active_users_total":
+				s.ActiveUsers = int(m.GetGauge().GetValue())
+			case "feedback_bot_processed_feedbacks_total":
+				switch labels["status"] {
+				case "answered":
+					s.FeedbacksAnswered += v
+				case "failed":
+					s.FeedbacksFailed += v
+				}
+			case "feedback_bot_processed_questions_total":
+				switch labels["status"] {
+				case "answered":
+					s.QuestionsAnswered += v
+				case "failed":
+					s.QuestionsFailed += v
+				}
+			case "feedback_bot_api_errors_total":
+				switch labels["api"] {
+				case "wb":
+					s.WBErrors += v
+				case "telegram":
+					s.TelegramErrors += v
+				}
+			case "feedback_bot_database_errors_total":
+				s.DatabaseErrors += v
+			case "feedback_bot_rate_limit_hits_total":
+				s.RateLimitHits += v
+			}
+		}
+	}
+	return s
+}
+
+// Sub returns the counter increase from old to s. Gauges keep s's value.
+func (s Snapshot) Sub(old Snapshot) Snapshot {
+	return Snapshot{
+		At:                s.At,
+		ActiveUsers:       s.ActiveUsers,
+		FeedbacksAnswered: s.FeedbacksAnswered - old.FeedbacksAnswered,
+		FeedbacksFailed:   s.FeedbacksFailed - old.FeedbacksFailed,
+		QuestionsAnswered: s.QuestionsAnswered - old.QuestionsAnswered,
+		QuestionsFailed:   s.QuestionsFailed - old.QuestionsFailed,
+		WBErrors:          s.WBErrors - old.WBErrors,
+		TelegramErrors:    s.TelegramErrors - old.TelegramErrors,
+		DatabaseErrors:    s.DatabaseErrors - old.DatabaseErrors,
+		RateLimitHits:     s.RateLimitHits - old.RateLimitHits,
+	}
+}
+
+// History keeps periodic snapshots so that counters can be reported for a
+// recent window ("за последний час") rather than since process start.
+type History struct {
+	every  time.Duration
+	window time.Duration
+
+	mu    sync.Mutex
+	snaps []Snapshot // oldest first
+}
+
+// NewHistory records a snapshot every interval and keeps those within window.
+func NewHistory(every, window time.Duration) *History {
+	return &History{every: every, window: window, snaps: []Snapshot{TakeSnapshot()}}
+}
+
+// Run records snapshots until ctx is cancelled.
+func (h *History) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.record(TakeSnapshot())
+		}
+	}
+}
+
+func (h *History) record(s Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snaps = append(h.snaps, s)
+	cutoff := s.At.Add(-h.window)
+	i := 0
+	for i < len(h.snaps)-1 && h.snaps[i].At.Before(cutoff) {
+		i++
+	}
+	h.snaps = h.snaps[i:]
+}
+
+// Recent returns the counter increase over the retained window and the
+// period it actually covers, which is shorter right after start.
+func (h *History) Recent() (Snapshot, time.Duration) {
+	now := TakeSnapshot()
+	h.mu.Lock()
+	oldest := h.snaps[0]
+	h.mu.Unlock()
+	return now.Sub(oldest), now.At.Sub(oldest.At)
+}